@@ -0,0 +1,266 @@
+/*
+ * Package bitvector implements a bit vector supporting rank and select.
+ *
+ * Rank(i) counts the ones before position i, and Select(k) finds the
+ * position of the k-th one. They are inverses of each other, and between
+ * them they let a bit vector stand in for a sorted set of integers, or mark
+ * boundaries in a packed array, using little more than one bit per element.
+ * They are the building blocks of "succinct" data structures, such as
+ * wavelet trees and compressed tries, which store data in close to the
+ * information-theoretic minimum space while still answering queries quickly.
+ *
+ * Counting ones naively takes O(n). Instead, counts are precomputed at two
+ * granularities:
+ *
+ *    words:       |  64  |  64  |  64  | ... |  64  |  64  | ...
+ *    blocks:      0      b1     b2          b7     0      ...  (uint16, ones
+ *                                                               since the start
+ *                                                               of the superblock)
+ *    superblocks: s0                                s1          (uint64, ones
+ *                                                                before it)
+ *
+ * A superblock is 8 words (512 bits). Rank then adds the superblock count,
+ * the block count and the popcount of part of one word, which is O(1). The
+ * counts take 64 bits per 512 plus 16 bits per 64, an overhead of 37.5%.
+ *
+ * Select searches the superblock counts, then the block counts within one
+ * superblock, then the bits of one word. To keep the first search short, the
+ * superblock holding every 512th one (and zero) is sampled, and the search is
+ * confined to the superblocks between two samples. Following Clark, a range
+ * between samples is either dense or sparse:
+ *
+ *    dense    at most 256 superblocks, binary searched in at most 9 steps
+ *    sparse   more than 256 superblocks, whose 512 positions are stored
+ *
+ * A sparse range holds 512 bits of its kind among more than 131072, so
+ * storing their positions costs about 25% more at most, and both cases take a
+ * bounded number of steps: Select is O(1) in the worst case.
+ *
+ * The counts are rebuilt on the first query after the bits are changed, so
+ * the structure suits vectors that are built once and then queried.
+ */
+
+package bitvector
+
+import (
+	"math/bits"
+	"sort"
+)
+
+const (
+	wordsPerSuper = 8
+	bitsPerSuper  = 64 * wordsPerSuper
+	sampleRate    = 512
+	sparseSupers  = 256 // widest range between samples that is searched
+)
+
+// BitVector is a fixed-length sequence of bits
+type BitVector struct {
+	words []uint64
+	n     int
+
+	// Rank and select index, valid unless dirty
+	dirty   bool
+	ones    int
+	super   []uint64
+	blocks  []uint16
+	samples [2][]int   // superblock holding every sampleRate-th zero and one
+	sparse  [2][][]int // positions of the bits after each sparse sample
+}
+
+// New creates a BitVector of *n* zero bits
+func New(n int) *BitVector {
+	return &BitVector{words: make([]uint64, (n+63)/64), n: n, dirty: true}
+}
+
+// FromBools creates a BitVector whose bits are set where *bs* is true
+func FromBools(bs []bool) *BitVector {
+	v := New(len(bs))
+	for i, b := range bs {
+		if b {
+			v.Set(i)
+		}
+	}
+	return v
+}
+
+// Len returns the number of bits
+func (v *BitVector) Len() int {
+	return v.n
+}
+
+// Get returns true if bit *i* is set
+func (v *BitVector) Get(i int) bool {
+	return v.words[i/64]&(1<<(i%64)) != 0
+}
+
+// Set sets bit *i* to one
+func (v *BitVector) Set(i int) {
+	v.words[i/64] |= 1 << (i % 64)
+	v.dirty = true
+}
+
+// Unset sets bit *i* to zero
+func (v *BitVector) Unset(i int) {
+	v.words[i/64] &^= 1 << (i % 64)
+	v.dirty = true
+}
+
+// index rebuilds the rank and select counts if the bits have changed
+func (v *BitVector) index() {
+	if !v.dirty {
+		return
+	}
+	v.super = make([]uint64, (len(v.words)+wordsPerSuper-1)/wordsPerSuper)
+	v.blocks = make([]uint16, len(v.words))
+	v.samples = [2][]int{nil, nil}
+	total, seen := 0, [2]int{}
+	for w, word := range v.words {
+		if w%wordsPerSuper == 0 {
+			v.super[w/wordsPerSuper] = uint64(total)
+		}
+		v.blocks[w] = uint16(total - int(v.super[w/wordsPerSuper]))
+		ones := bits.OnesCount64(word)
+		zeros := 64 - ones
+		if w == len(v.words)-1 && v.n%64 != 0 {
+			// The unused high bits of the last word are not zeros
+			zeros -= 64 - v.n%64
+		}
+		for b, count := range [2]int{zeros, ones} {
+			// Record the superblock of every sampleRate-th bit of each kind
+			for next := len(v.samples[b]) * sampleRate; next < seen[b]+count; next += sampleRate {
+				v.samples[b] = append(v.samples[b], w/wordsPerSuper)
+			}
+			seen[b] += count
+		}
+		total += ones
+	}
+	v.ones = total
+
+	// Ranges between samples spanning many superblocks have few bits of their
+	// kind, so store the positions of those bits outright
+	v.sparse = [2][][]int{nil, nil}
+	for b := range v.samples {
+		v.sparse[b] = make([][]int, len(v.samples[b]))
+		for s, lo := range v.samples[b] {
+			hi := len(v.super)
+			if s+1 < len(v.samples[b]) {
+				hi = v.samples[b][s+1] + 1
+			}
+			if hi-lo > sparseSupers {
+				v.sparse[b][s] = v.positions(b, s*sampleRate, lo)
+			}
+		}
+	}
+	v.dirty = false
+}
+
+// positions returns the positions of up to sampleRate bits of kind *b* (0 or
+// 1), starting with the one with rank *first*, which is in superblock *sb*
+func (v *BitVector) positions(b, first, sb int) []int {
+	found := make([]int, 0, sampleRate)
+	rank := int(v.super[sb])
+	if b == 0 {
+		rank = sb*bitsPerSuper - rank
+	}
+	for i := sb * bitsPerSuper; i < v.n && len(found) != sampleRate; i++ {
+		if v.Get(i) == (b == 1) {
+			if rank >= first {
+				found = append(found, i)
+			}
+			rank++
+		}
+	}
+	return found
+}
+
+// Ones returns the number of bits set
+func (v *BitVector) Ones() int {
+	v.index()
+	return v.ones
+}
+
+// Rank returns the number of ones in positions [0, i)
+func (v *BitVector) Rank(i int) int {
+	v.index()
+	if i >= v.n {
+		return v.ones
+	}
+	w := i / 64
+	mask := uint64(1)<<(i%64) - 1
+	return int(v.super[w/wordsPerSuper]) + int(v.blocks[w]) + bits.OnesCount64(v.words[w]&mask)
+}
+
+// Rank0 returns the number of zeros in positions [0, i)
+func (v *BitVector) Rank0(i int) int {
+	if i > v.n {
+		i = v.n
+	}
+	return i - v.Rank(i)
+}
+
+// Select returns the position of the one with rank *k* (counting from zero),
+// or false if there are not that many ones
+func (v *BitVector) Select(k int) (int, bool) {
+	v.index()
+	if k < 0 || k >= v.ones {
+		return 0, false
+	}
+	return v.selectBit(k, 1), true
+}
+
+// Select0 returns the position of the zero with rank *k* (counting from
+// zero), or false if there are not that many zeros
+func (v *BitVector) Select0(k int) (int, bool) {
+	v.index()
+	if k < 0 || k >= v.n-v.ones {
+		return 0, false
+	}
+	return v.selectBit(k, 0), true
+}
+
+// selectBit finds the bit of kind *b* (0 or 1) with rank *k*, which must
+// exist
+func (v *BitVector) selectBit(k int, b int) int {
+	// count returns the number of bits of kind b before a superblock or word,
+	// given the number of ones before it and its position in bits
+	count := func(ones, pos int) int {
+		if b == 1 {
+			return ones
+		}
+		return pos - ones
+	}
+
+	if found := v.sparse[b][k/sampleRate]; found != nil {
+		return found[k%sampleRate]
+	}
+
+	// Find the last superblock starting with at most k bits of kind b,
+	// between the samples either side of k
+	lo := v.samples[b][k/sampleRate]
+	hi := len(v.super)
+	if s := k/sampleRate + 1; s < len(v.samples[b]) {
+		hi = v.samples[b][s] + 1
+	}
+	sb := lo + sort.Search(hi-lo, func(j int) bool {
+		return count(int(v.super[lo+j]), (lo+j)*bitsPerSuper) > k
+	}) - 1
+	k -= count(int(v.super[sb]), sb*bitsPerSuper)
+
+	// Then the last word in the superblock doing the same
+	w := sb * wordsPerSuper
+	for w+1 < len(v.words) && w+1 < (sb+1)*wordsPerSuper && count(int(v.blocks[w+1]), (w+1-sb*wordsPerSuper)*64) <= k {
+		w++
+	}
+	k -= count(int(v.blocks[w]), (w-sb*wordsPerSuper)*64)
+
+	// Then the bit within the word, by clearing the lowest k matching bits
+	word := v.words[w]
+	if b == 0 {
+		word = ^word
+	}
+	for ; k != 0; k-- {
+		word &= word - 1
+	}
+	return w*64 + bits.TrailingZeros64(word)
+}
@@ -0,0 +1,168 @@
+package bitvector
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestGetSet(t *testing.T) {
+	v := New(130)
+	v.Set(0)
+	v.Set(64)
+	v.Set(129)
+	v.Unset(64)
+	if !v.Get(0) || v.Get(64) || !v.Get(129) || v.Get(1) {
+		t.Fail()
+	}
+	if v.Len() != 130 || v.Ones() != 2 {
+		t.Error(v.Len(), v.Ones())
+	}
+}
+
+func TestRankSelect(t *testing.T) {
+	v := FromBools([]bool{true, false, true, true, false, false, true})
+	ranks := []int{0, 1, 1, 2, 3, 3, 3, 4}
+	for i, expected := range ranks {
+		if r := v.Rank(i); r != expected {
+			t.Errorf("Rank(%d) = %d, expected %d", i, r, expected)
+		}
+		if r := v.Rank0(i); r != i-expected {
+			t.Errorf("Rank0(%d) = %d, expected %d", i, r, i-expected)
+		}
+	}
+	for k, expected := range []int{0, 2, 3, 6} {
+		if pos, ok := v.Select(k); !ok || pos != expected {
+			t.Errorf("Select(%d) = %d, expected %d", k, pos, expected)
+		}
+	}
+	for k, expected := range []int{1, 4, 5} {
+		if pos, ok := v.Select0(k); !ok || pos != expected {
+			t.Errorf("Select0(%d) = %d, expected %d", k, pos, expected)
+		}
+	}
+	if _, ok := v.Select(4); ok {
+		t.Fail()
+	}
+	if _, ok := v.Select0(3); ok {
+		t.Fail()
+	}
+}
+
+// checkAgainstNaive compares rank and select with a linear scan
+func checkAgainstNaive(t *testing.T, v *BitVector) {
+	ones, zeros := 0, 0
+	for i := 0; i != v.Len(); i++ {
+		if v.Rank(i) != ones {
+			t.Fatalf("Rank(%d) = %d, expected %d", i, v.Rank(i), ones)
+		}
+		if v.Get(i) {
+			if pos, ok := v.Select(ones); !ok || pos != i {
+				t.Fatalf("Select(%d) = %d, expected %d", ones, pos, i)
+			}
+			ones++
+		} else {
+			if pos, ok := v.Select0(zeros); !ok || pos != i {
+				t.Fatalf("Select0(%d) = %d, expected %d", zeros, pos, i)
+			}
+			zeros++
+		}
+	}
+	if v.Rank(v.Len()) != ones || v.Ones() != ones {
+		t.Fatal("total count")
+	}
+}
+
+func TestRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, n := range []int{0, 1, 63, 64, 65, 511, 512, 513, 5000, 20000} {
+		for _, density := range []float64{0.01, 0.5, 0.99} {
+			v := New(n)
+			for i := 0; i != n; i++ {
+				if r.Float64() < density {
+					v.Set(i)
+				}
+			}
+			checkAgainstNaive(t, v)
+		}
+	}
+}
+
+func TestSparse(t *testing.T) {
+	// A dense start, then one set bit in every 1000, so that later ranges
+	// between samples of ones are sparse and ranges of zeros are dense
+	n := 1200000
+	v := New(n)
+	for i := 0; i != 3000; i++ {
+		v.Set(i)
+	}
+	for i := 3000; i < n; i += 1000 {
+		v.Set(i)
+	}
+	stored := func(b int) int {
+		count := 0
+		for _, found := range v.sparse[b] {
+			if found != nil {
+				count++
+			}
+		}
+		return count
+	}
+	checkAgainstNaive(t, v)
+	if v.sparse[1][0] != nil || stored(1) == 0 || stored(0) != 0 {
+		t.Error(stored(0), stored(1))
+	}
+
+	// The same flipped, so that ranges of zeros are sparse
+	for i := 0; i != n; i++ {
+		if v.Get(i) {
+			v.Unset(i)
+		} else {
+			v.Set(i)
+		}
+	}
+	checkAgainstNaive(t, v)
+	if v.sparse[0][0] != nil || stored(0) == 0 || stored(1) != 0 {
+		t.Error(stored(0), stored(1))
+	}
+}
+
+func TestChangeAfterQuery(t *testing.T) {
+	v := New(1000)
+	v.Set(10)
+	if v.Rank(500) != 1 {
+		t.Fail()
+	}
+	v.Set(20)
+	if v.Rank(500) != 2 {
+		t.Fail()
+	}
+	checkAgainstNaive(t, v)
+}
+
+func BenchmarkRank(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	n := 1 << 20
+	v := New(n)
+	for i := 0; i != n/2; i++ {
+		v.Set(r.Intn(n))
+	}
+	v.Ones()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		v.Rank((i * 7919) % n)
+	}
+}
+
+func BenchmarkSelect(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	n := 1 << 20
+	v := New(n)
+	for i := 0; i != n/2; i++ {
+		v.Set(r.Intn(n))
+	}
+	ones := v.Ones()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		v.Select((i * 7919) % ones)
+	}
+}
@@ -0,0 +1,176 @@
+/*
+ * Package bloom implements approximate set membership filters: a plain Bloom
+ * filter, and two that support deletion, a counting Bloom filter and a cuckoo
+ * filter.
+ *
+ * A Bloom filter answers "is x in the set?" with either "no" or "probably",
+ * using far less space than the set itself. It is an array of m bits and k
+ * hash functions. Adding x sets the k bits at positions h1(x) ... hk(x), and
+ * x is reported as present if all k bits are set. Other keys may have set
+ * those bits too, so a false positive is possible, but a false negative is
+ * not. With n keys, the chance of a false positive is about
+ *
+ *    (1 - e^(-kn/m))^k
+ *
+ * which is smallest when k = (m/n) ln 2. A plain Bloom filter (see
+ * filter.go) cannot remove keys, since clearing a bit might also remove other
+ * keys that share it.
+ *
+ * A counting Bloom filter (Fan et al., 2000) replaces each bit with a small
+ * counter, which adding increments and removing decrements. Here counters
+ * are bytes, which saturate at 255 rather than overflow; a saturated counter
+ * is never decremented again, since its true count is unknown. The price of
+ * deletion is eight times the space of a plain filter.
+ *
+ * A cuckoo filter (Fan et al., 2014) stores a short fingerprint of each key
+ * in a cuckoo hash table instead (see cuckoo.go). It supports deletion, and
+ * for false positive rates below about 3% it is smaller than even a plain
+ * Bloom filter.
+ *
+ * The k hash functions are derived from one 64-bit hash h by double
+ * hashing, as hi(x) = h1 + i*h2, where h1 and h2 are its two halves
+ * (Kirsch and Mitzenmacher, 2006). The hash is fixed (see package hashing),
+ * so a filter can be serialized and read back in another process.
+ */
+
+package bloom
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+
+	"github.com/njwilson23/datastructures/hashing"
+)
+
+var ErrCorrupt = errors.New("corrupt filter encoding")
+
+// Parameters returns the number of bits or counters *m* and hash functions *k*
+// that give a false positive rate of *rate* when holding *n* keys. It panics
+// unless 0 < *rate* < 1.
+func Parameters(n int, rate float64) (m, k int) {
+	if !(rate > 0 && rate < 1) {
+		panic("bloom: false positive rate must be between 0 and 1")
+	}
+	if n < 1 {
+		n = 1
+	}
+	m = int(math.Ceil(-float64(n) * math.Log(rate) / (math.Ln2 * math.Ln2)))
+	k = int(math.Round(float64(m) / float64(n) * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+	return m, k
+}
+
+// Counting is a counting Bloom filter
+type Counting struct {
+	counters []uint8
+	k        int
+	n        int
+}
+
+// NewCounting creates an empty counting Bloom filter sized to hold *n* keys
+// with a false positive rate of *rate*
+func NewCounting(n int, rate float64) *Counting {
+	m, k := Parameters(n, rate)
+	return &Counting{counters: make([]uint8, m), k: k}
+}
+
+// positions calls *f* with the position of each of the key's counters
+func (c *Counting) positions(key []byte, f func(i int)) {
+	h := hashing.Bytes(key)
+	h1, h2 := h&0xffffffff, h>>32
+	m := uint64(len(c.counters))
+	for i := uint64(0); i != uint64(c.k); i++ {
+		f(int((h1 + i*h2) % m))
+	}
+}
+
+// Len returns the number of keys added and not removed
+func (c *Counting) Len() int {
+	return c.n
+}
+
+// Add adds a key to the filter. A key may be added more than once, and must
+// then be removed as many times.
+func (c *Counting) Add(key []byte) {
+	c.positions(key, func(i int) {
+		if c.counters[i] != math.MaxUint8 {
+			c.counters[i]++
+		}
+	})
+	c.n++
+}
+
+// Contains returns false if *key* is definitely not in the filter, and true
+// if it probably is
+func (c *Counting) Contains(key []byte) bool {
+	found := true
+	c.positions(key, func(i int) {
+		found = found && c.counters[i] != 0
+	})
+	return found
+}
+
+// Remove removes a key from the filter, and returns false if it was
+// definitely not present. Removing a key that was never added, but appears
+// to be present, can remove other keys.
+func (c *Counting) Remove(key []byte) bool {
+	if !c.Contains(key) {
+		return false
+	}
+	c.positions(key, func(i int) {
+		if c.counters[i] != math.MaxUint8 {
+			c.counters[i]--
+		}
+	})
+	c.n--
+	return true
+}
+
+// ExpectedFalsePositiveRate returns the false positive rate predicted from
+// the size of the filter and the number of keys it holds
+func (c *Counting) ExpectedFalsePositiveRate() float64 {
+	return math.Pow(1-math.Exp(-float64(c.k*c.n)/float64(len(c.counters))), float64(c.k))
+}
+
+// FalsePositiveRate estimates the false positive rate from the fraction of
+// counters in use, which is the chance that one of them is non-zero
+func (c *Counting) FalsePositiveRate() float64 {
+	used := 0
+	for _, count := range c.counters {
+		if count != 0 {
+			used++
+		}
+	}
+	return math.Pow(float64(used)/float64(len(c.counters)), float64(c.k))
+}
+
+// MarshalBinary encodes the filter as varints for the number of counters, the
+// number of hash functions and the number of keys, followed by the counters
+func (c *Counting) MarshalBinary() ([]byte, error) {
+	data := binary.AppendUvarint(nil, uint64(len(c.counters)))
+	data = binary.AppendUvarint(data, uint64(c.k))
+	data = binary.AppendUvarint(data, uint64(c.n))
+	return append(data, c.counters...), nil
+}
+
+// UnmarshalBinary decodes a filter encoded by MarshalBinary
+func (c *Counting) UnmarshalBinary(data []byte) error {
+	var header [3]uint64
+	for i := range header {
+		v, n := binary.Uvarint(data)
+		if n <= 0 {
+			return ErrCorrupt
+		}
+		header[i], data = v, data[n:]
+	}
+	m, k, n := header[0], header[1], header[2]
+	if m == 0 || k == 0 || uint64(len(data)) != m {
+		return ErrCorrupt
+	}
+	c.counters = append([]uint8{}, data...)
+	c.k, c.n = int(k), int(n)
+	return nil
+}
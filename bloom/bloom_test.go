@@ -0,0 +1,118 @@
+package bloom
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func key(i int) []byte {
+	return []byte(fmt.Sprintf("key-%d", i))
+}
+
+func TestParameters(t *testing.T) {
+	m, k := Parameters(1000, 0.01)
+	if m != 9586 || k != 7 {
+		t.Error(m, k)
+	}
+	// Rates outside (0, 1) would give no bits, or infinitely many
+	for _, rate := range []float64{0, -0.1, 1, 1.5, math.NaN()} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Error("no panic for rate", rate)
+				}
+			}()
+			Parameters(1000, rate)
+		}()
+	}
+}
+
+func TestCounting(t *testing.T) {
+	c := NewCounting(1000, 0.01)
+	for i := 0; i != 1000; i++ {
+		c.Add(key(i))
+	}
+	for i := 0; i != 1000; i++ {
+		if !c.Contains(key(i)) {
+			t.Fatal("false negative", i)
+		}
+	}
+	falsePositives := 0
+	for i := 1000; i != 101000; i++ {
+		if c.Contains(key(i)) {
+			falsePositives++
+		}
+	}
+	rate := float64(falsePositives) / 100000
+	if rate > 0.015 {
+		t.Error("false positive rate", rate)
+	}
+	for _, estimate := range []float64{c.ExpectedFalsePositiveRate(), c.FalsePositiveRate()} {
+		if math.Abs(estimate-rate) > 0.005 {
+			t.Error("estimated", estimate, "measured", rate)
+		}
+	}
+
+	for i := 0; i != 500; i++ {
+		if !c.Remove(key(i)) {
+			t.Fatal("not removed", i)
+		}
+	}
+	if c.Len() != 500 {
+		t.Error(c.Len())
+	}
+	for i := 500; i != 1000; i++ {
+		if !c.Contains(key(i)) {
+			t.Fatal("false negative after removal", i)
+		}
+	}
+	present := 0
+	for i := 0; i != 500; i++ {
+		if c.Contains(key(i)) {
+			present++
+		}
+	}
+	if present > 10 {
+		t.Error(present, "removed keys still present")
+	}
+}
+
+func TestCountingSaturation(t *testing.T) {
+	c := NewCounting(10, 0.01)
+	for i := 0; i != 300; i++ {
+		c.Add(key(1))
+	}
+	for i := 0; i != 300; i++ {
+		c.Remove(key(1))
+	}
+	// The counters stuck at 255, so the key is never forgotten
+	if !c.Contains(key(1)) {
+		t.Error("saturated counter was decremented")
+	}
+}
+
+func TestCountingMarshal(t *testing.T) {
+	c := NewCounting(100, 0.01)
+	for i := 0; i != 100; i++ {
+		c.Add(key(i))
+	}
+	data, _ := c.MarshalBinary()
+	var d Counting
+	if err := d.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if d.Len() != 100 || d.k != c.k {
+		t.Error(d.Len(), d.k)
+	}
+	for i := 0; i != 100; i++ {
+		if !d.Contains(key(i)) {
+			t.Fatal("false negative", i)
+		}
+	}
+	for _, corrupt := range [][]byte{nil, data[:3], data[:len(data)-1], append(data, 0)} {
+		if err := d.UnmarshalBinary(corrupt); err != ErrCorrupt {
+			t.Error(len(corrupt), err)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package bloom
+
+// Cuckoo filters
+//
+// A cuckoo filter is a cuckoo hash table (see Pagh and Rodler, 2001) that
+// stores a 16-bit fingerprint of each key rather than the key itself. The
+// table has b buckets of four slots, and a key with hash h and fingerprint f
+// may be stored in either of two buckets:
+//
+//    i1 = h mod b
+//    i2 = i1 xor (hash(f) mod b)
+//
+// Since i1 = i2 xor (hash(f) mod b) as well, the other bucket of a stored
+// fingerprint can be found from the fingerprint and the bucket it is in,
+// without knowing the key. Inserting into two full buckets therefore evicts
+// a random fingerprint from one of them and moves it to its other bucket,
+// evicting another if that is full too, and so on. Lookups and deletions
+// check just the eight slots of two buckets.
+//
+// A false positive happens when another key with the same fingerprint is in
+// one of the two buckets. Each of the (at most) eight stored fingerprints
+// matches with probability 2^-16, so the false positive rate is at most
+// about 8 / 65536, or 0.012%, and lower when the table is not full. The table
+// can be filled to about 95% before insertions begin to fail.
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"math/bits"
+
+	"github.com/njwilson23/datastructures/hashing"
+)
+
+var ErrFull = errors.New("cuckoo filter is full")
+
+const (
+	bucketSize = 4
+	maxKicks   = 500
+)
+
+// Cuckoo is a cuckoo filter
+type Cuckoo struct {
+	buckets [][bucketSize]uint16 // 0 marks an empty slot
+	mask    uint64
+	n       int
+	rng     uint64 // state for choosing fingerprints to evict
+}
+
+// NewCuckoo creates an empty cuckoo filter with room for at least *n* keys.
+// The number of buckets is rounded up to a power of two.
+func NewCuckoo(n int) *Cuckoo {
+	b := (n + bucketSize - 1) / bucketSize
+	if b < 1 {
+		b = 1
+	}
+	b = 1 << bits.Len(uint(b-1))
+	return &Cuckoo{buckets: make([][bucketSize]uint16, b), mask: uint64(b - 1), rng: 1}
+}
+
+// locate returns the fingerprint and the two buckets of a key
+func (c *Cuckoo) locate(key []byte) (uint16, uint64, uint64) {
+	h := hashing.Bytes(key)
+	f := uint16(h >> 48)
+	if f == 0 {
+		f = 1
+	}
+	i1 := h & c.mask
+	return f, i1, c.alternate(i1, f)
+}
+
+// alternate returns the other bucket of a fingerprint stored in bucket *i*
+func (c *Cuckoo) alternate(i uint64, f uint16) uint64 {
+	return (i ^ hashing.Mix(uint64(f))) & c.mask
+}
+
+// place stores *f* in an empty slot of bucket *i*, and returns false if it is
+// full
+func (c *Cuckoo) place(i uint64, f uint16) bool {
+	for j, slot := range c.buckets[i] {
+		if slot == 0 {
+			c.buckets[i][j] = f
+			return true
+		}
+	}
+	return false
+}
+
+// random returns a pseudo-random number from an xorshift generator
+func (c *Cuckoo) random() uint64 {
+	c.rng ^= c.rng << 13
+	c.rng ^= c.rng >> 7
+	c.rng ^= c.rng << 17
+	return c.rng
+}
+
+// Len returns the number of keys in the filter
+func (c *Cuckoo) Len() int {
+	return c.n
+}
+
+// Capacity returns the number of slots in the filter
+func (c *Cuckoo) Capacity() int {
+	return len(c.buckets) * bucketSize
+}
+
+// Add adds a key to the filter, or returns ErrFull if no room could be made
+// for it. A key may be added more than once, up to eight times, and must
+// then be removed as many times.
+func (c *Cuckoo) Add(key []byte) error {
+	f, i1, i2 := c.locate(key)
+	if c.place(i1, f) || c.place(i2, f) {
+		c.n++
+		return nil
+	}
+	// Evict fingerprints along a random path. If the path runs too long, undo
+	// it, so that a failed Add leaves every key in the filter.
+	type kick struct {
+		bucket uint64
+		slot   int
+	}
+	var path []kick
+	i := i1
+	if c.random()&1 == 0 {
+		i = i2
+	}
+	for len(path) != maxKicks {
+		j := int(c.random() % bucketSize)
+		f, c.buckets[i][j] = c.buckets[i][j], f
+		path = append(path, kick{i, j})
+		i = c.alternate(i, f)
+		if c.place(i, f) {
+			c.n++
+			return nil
+		}
+	}
+	for k := len(path) - 1; k >= 0; k-- {
+		p := path[k]
+		f, c.buckets[p.bucket][p.slot] = c.buckets[p.bucket][p.slot], f
+	}
+	return ErrFull
+}
+
+// Contains returns false if *key* is definitely not in the filter, and true
+// if it probably is
+func (c *Cuckoo) Contains(key []byte) bool {
+	f, i1, i2 := c.locate(key)
+	for _, i := range [2]uint64{i1, i2} {
+		for _, slot := range c.buckets[i] {
+			if slot == f {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Remove removes a key from the filter, and returns false if it was
+// definitely not present. Removing a key that was never added, but appears
+// to be present, removes another key.
+func (c *Cuckoo) Remove(key []byte) bool {
+	f, i1, i2 := c.locate(key)
+	for _, i := range [2]uint64{i1, i2} {
+		for j, slot := range c.buckets[i] {
+			if slot == f {
+				c.buckets[i][j] = 0
+				c.n--
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// FalsePositiveRate estimates the false positive rate from the fraction of
+// slots in use: a lookup compares the fingerprint with the occupied slots of
+// two buckets, each of which matches with probability about 2^-16
+func (c *Cuckoo) FalsePositiveRate() float64 {
+	occupied := 2 * bucketSize * float64(c.n) / float64(c.Capacity())
+	return 1 - math.Pow(1-1.0/65535, occupied)
+}
+
+// MarshalBinary encodes the filter as varints for the number of buckets and
+// keys, followed by the fingerprints as little-endian uint16s
+func (c *Cuckoo) MarshalBinary() ([]byte, error) {
+	data := binary.AppendUvarint(nil, uint64(len(c.buckets)))
+	data = binary.AppendUvarint(data, uint64(c.n))
+	for _, b := range c.buckets {
+		for _, f := range b {
+			data = binary.LittleEndian.AppendUint16(data, f)
+		}
+	}
+	return data, nil
+}
+
+// UnmarshalBinary decodes a filter encoded by MarshalBinary
+func (c *Cuckoo) UnmarshalBinary(data []byte) error {
+	b, n := binary.Uvarint(data)
+	if n <= 0 || b == 0 || b&(b-1) != 0 {
+		return ErrCorrupt
+	}
+	data = data[n:]
+	count, n := binary.Uvarint(data)
+	if n <= 0 || count > b*bucketSize {
+		return ErrCorrupt
+	}
+	data = data[n:]
+	if uint64(len(data)) != 2*bucketSize*b {
+		return ErrCorrupt
+	}
+	c.buckets = make([][bucketSize]uint16, b)
+	for i := range c.buckets {
+		for j := range c.buckets[i] {
+			c.buckets[i][j] = binary.LittleEndian.Uint16(data)
+			data = data[2:]
+		}
+	}
+	c.mask, c.n, c.rng = b-1, int(count), 1
+	return nil
+}
@@ -0,0 +1,114 @@
+package bloom
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCuckoo(t *testing.T) {
+	c := NewCuckoo(10000)
+	if c.Capacity() != 16384 {
+		t.Error(c.Capacity())
+	}
+	n := c.Capacity() * 9 / 10
+	for i := 0; i != n; i++ {
+		if err := c.Add(key(i)); err != nil {
+			t.Fatal(i, err)
+		}
+	}
+	for i := 0; i != n; i++ {
+		if !c.Contains(key(i)) {
+			t.Fatal("false negative", i)
+		}
+	}
+	falsePositives := 0
+	for i := n; i != n+1000000; i++ {
+		if c.Contains(key(i)) {
+			falsePositives++
+		}
+	}
+	rate := float64(falsePositives) / 1000000
+	if estimate := c.FalsePositiveRate(); math.Abs(estimate-rate) > estimate/2 {
+		t.Error("estimated", estimate, "measured", rate)
+	}
+
+	for i := 0; i < n; i += 2 {
+		if !c.Remove(key(i)) {
+			t.Fatal("not removed", i)
+		}
+	}
+	if c.Len() != n/2 {
+		t.Error(c.Len())
+	}
+	for i := 1; i < n; i += 2 {
+		if !c.Contains(key(i)) {
+			t.Fatal("false negative after removal", i)
+		}
+	}
+}
+
+func TestCuckooFull(t *testing.T) {
+	c := NewCuckoo(64)
+	added := 0
+	for ; c.Add(key(added)) == nil; added++ {
+	}
+	if added < 56 || c.Len() != added {
+		t.Error(added, c.Len())
+	}
+	// A failed Add keeps every key that was already present
+	for i := 0; i != added; i++ {
+		if !c.Contains(key(i)) {
+			t.Fatal("false negative", i)
+		}
+	}
+}
+
+func TestCuckooMarshal(t *testing.T) {
+	c := NewCuckoo(100)
+	for i := 0; i != 100; i++ {
+		c.Add(key(i))
+	}
+	data, _ := c.MarshalBinary()
+	var d Cuckoo
+	if err := d.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if d.Len() != 100 || d.Capacity() != c.Capacity() {
+		t.Error(d.Len(), d.Capacity())
+	}
+	for i := 0; i != 100; i++ {
+		if !d.Contains(key(i)) {
+			t.Fatal("false negative", i)
+		}
+	}
+	if err := d.Add(key(100)); err != nil || !d.Contains(key(100)) {
+		t.Error(err)
+	}
+	for _, corrupt := range [][]byte{nil, {3, 0}, data[:len(data)-1]} {
+		if err := d.UnmarshalBinary(corrupt); err != ErrCorrupt {
+			t.Error(len(corrupt), err)
+		}
+	}
+}
+
+func BenchmarkContains(b *testing.B) {
+	counting, cuckoo := NewCounting(100000, 0.0001), NewCuckoo(100000)
+	for i := 0; i != 100000; i++ {
+		counting.Add(key(i))
+		cuckoo.Add(key(i))
+	}
+	keys := make([][]byte, 1024)
+	for i := range keys {
+		keys[i] = key(i * 200)
+	}
+	b.Run("Counting", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			counting.Contains(keys[i%len(keys)])
+		}
+	})
+	b.Run("Cuckoo", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			cuckoo.Contains(keys[i%len(keys)])
+		}
+	})
+}
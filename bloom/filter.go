@@ -0,0 +1,96 @@
+package bloom
+
+// Plain Bloom filters
+//
+// A Filter is the plain Bloom filter described above, with a bit where a
+// counting filter has a byte, for sets that only grow, such as the keys of a
+// file that is written once. The bits are packed into uint64 words.
+
+import (
+	"encoding/binary"
+
+	"github.com/njwilson23/datastructures/hashing"
+)
+
+// Filter is a plain Bloom filter, which cannot remove keys
+type Filter struct {
+	bits []uint64
+	m    int
+	k    int
+	n    int
+}
+
+// NewFilter creates an empty Bloom filter sized to hold *n* keys with a false
+// positive rate of *rate*
+func NewFilter(n int, rate float64) *Filter {
+	m, k := Parameters(n, rate)
+	return &Filter{bits: make([]uint64, (m+63)/64), m: m, k: k}
+}
+
+// positions calls *f* with the position of each of the key's bits, as for
+// Counting
+func (f *Filter) positions(key []byte, g func(i int)) {
+	h := hashing.Bytes(key)
+	h1, h2 := h&0xffffffff, h>>32
+	for i := uint64(0); i != uint64(f.k); i++ {
+		g(int((h1 + i*h2) % uint64(f.m)))
+	}
+}
+
+// Len returns the number of keys added
+func (f *Filter) Len() int {
+	return f.n
+}
+
+// Add adds a key to the filter
+func (f *Filter) Add(key []byte) {
+	f.positions(key, func(i int) {
+		f.bits[i/64] |= 1 << (i % 64)
+	})
+	f.n++
+}
+
+// Contains returns false if *key* is definitely not in the filter, and true
+// if it probably is
+func (f *Filter) Contains(key []byte) bool {
+	found := true
+	f.positions(key, func(i int) {
+		found = found && f.bits[i/64]&(1<<(i%64)) != 0
+	})
+	return found
+}
+
+// MarshalBinary encodes the filter as varints for the number of bits, the
+// number of hash functions and the number of keys, followed by the bits as
+// little-endian uint64s
+func (f *Filter) MarshalBinary() ([]byte, error) {
+	data := binary.AppendUvarint(nil, uint64(f.m))
+	data = binary.AppendUvarint(data, uint64(f.k))
+	data = binary.AppendUvarint(data, uint64(f.n))
+	for _, word := range f.bits {
+		data = binary.LittleEndian.AppendUint64(data, word)
+	}
+	return data, nil
+}
+
+// UnmarshalBinary decodes a filter encoded by MarshalBinary
+func (f *Filter) UnmarshalBinary(data []byte) error {
+	var header [3]uint64
+	for i := range header {
+		v, n := binary.Uvarint(data)
+		if n <= 0 {
+			return ErrCorrupt
+		}
+		header[i], data = v, data[n:]
+	}
+	m, k, n := header[0], header[1], header[2]
+	if m == 0 || k == 0 || uint64(len(data)) != (m+63)/64*8 {
+		return ErrCorrupt
+	}
+	f.bits = make([]uint64, len(data)/8)
+	for i := range f.bits {
+		f.bits[i] = binary.LittleEndian.Uint64(data[8*i:])
+	}
+	f.m, f.k, f.n = int(m), int(k), int(n)
+	return nil
+}
@@ -0,0 +1,53 @@
+package bloom
+
+import "testing"
+
+func TestFilter(t *testing.T) {
+	f := NewFilter(1000, 0.01)
+	for i := 0; i != 1000; i++ {
+		f.Add(key(i))
+	}
+	for i := 0; i != 1000; i++ {
+		if !f.Contains(key(i)) {
+			t.Fatal("false negative", i)
+		}
+	}
+	falsePositives := 0
+	for i := 1000; i != 101000; i++ {
+		if f.Contains(key(i)) {
+			falsePositives++
+		}
+	}
+	if rate := float64(falsePositives) / 100000; rate > 0.015 {
+		t.Error("false positive rate", rate)
+	}
+	// A bit per counter
+	if c := NewCounting(1000, 0.01); len(f.bits)*8 > len(c.counters)/8+8 {
+		t.Error(len(f.bits), "words for", len(c.counters), "counters")
+	}
+}
+
+func TestFilterMarshal(t *testing.T) {
+	f := NewFilter(100, 0.01)
+	for i := 0; i != 100; i++ {
+		f.Add(key(i))
+	}
+	data, _ := f.MarshalBinary()
+	var g Filter
+	if err := g.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if g.Len() != 100 || g.k != f.k || g.m != f.m {
+		t.Error(g.Len(), g.k, g.m)
+	}
+	for i := 0; i != 100; i++ {
+		if !g.Contains(key(i)) {
+			t.Fatal("false negative", i)
+		}
+	}
+	for _, corrupt := range [][]byte{nil, data[:3], data[:len(data)-1], append(data, 0)} {
+		if err := g.UnmarshalBinary(corrupt); err != ErrCorrupt {
+			t.Error(len(corrupt), err)
+		}
+	}
+}
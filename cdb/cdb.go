@@ -0,0 +1,211 @@
+/*
+ * Package cdb implements a constant database: a hash table of byte-string
+ * keys and values that is written once to a file, and then read in place.
+ *
+ * A table built in memory at startup costs time and memory in every process
+ * that uses it. A constant database is built once, shipped as a file, and
+ * mapped into memory (mmap) by its readers, so that opening it costs nothing,
+ * the pages actually used are loaded on demand and shared between processes,
+ * and lookups return slices of the mapping without copying. The idea, and
+ * the name, come from D. J. Bernstein's cdb, but the format is simpler:
+ *
+ *    records  [count]{key length uvarint, value length uvarint, key, value}
+ *    table    [slots]{hash uint64, offset+1 uint64}, little-endian
+ *    footer   table offset uint64, slots uint64, count uint64, magic "CDB1"
+ *
+ * The table is an open-addressing hash table (see package hashtable for the
+ * in-memory kind), with at least twice as many slots as records, so linear
+ * probing from slot hash % slots finds a key after about 1.5 probes, and an
+ * empty slot (offset 0) ends the search for a missing one. Each slot keeps the
+ * key's full 64-bit hash, so that a record is read only when the hashes
+ * match. The footer is written last, which lets Builder write to a stream
+ * without seeking back.
+ *
+ * Open maps a file where the platform supports it, and otherwise reads it
+ * into memory; Load reads a database that is already in memory.
+ */
+
+package cdb
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"github.com/njwilson23/datastructures/hashing"
+)
+
+var ErrCorrupt = errors.New("corrupt constant database")
+
+const (
+	magic      = "CDB1"
+	footerSize = 28
+	slotSize   = 16
+)
+
+// Builder writes a constant database
+type Builder struct {
+	w       *bufio.Writer
+	offset  uint64
+	hashes  []uint64
+	offsets []uint64
+	buf     []byte
+}
+
+// NewBuilder creates a Builder writing to *w*
+func NewBuilder(w io.Writer) *Builder {
+	return &Builder{w: bufio.NewWriter(w)}
+}
+
+// Add writes a record. If a key is added more than once, Get returns the
+// value added first.
+func (b *Builder) Add(key, value []byte) error {
+	b.hashes = append(b.hashes, hashing.Bytes(key))
+	b.offsets = append(b.offsets, b.offset)
+	b.buf = binary.AppendUvarint(b.buf[:0], uint64(len(key)))
+	b.buf = binary.AppendUvarint(b.buf, uint64(len(value)))
+	for _, p := range [][]byte{b.buf, key, value} {
+		if _, err := b.w.Write(p); err != nil {
+			return err
+		}
+		b.offset += uint64(len(p))
+	}
+	return nil
+}
+
+// Finish writes the hash table and footer, completing the database
+func (b *Builder) Finish() error {
+	slots := uint64(1)
+	for slots < 2*uint64(len(b.hashes)) {
+		slots *= 2
+	}
+	table := make([]byte, slots*slotSize)
+	for i, h := range b.hashes {
+		s := h % slots
+		for binary.LittleEndian.Uint64(table[s*slotSize+8:]) != 0 {
+			s = (s + 1) % slots
+		}
+		binary.LittleEndian.PutUint64(table[s*slotSize:], h)
+		binary.LittleEndian.PutUint64(table[s*slotSize+8:], b.offsets[i]+1)
+	}
+	footer := binary.LittleEndian.AppendUint64(nil, b.offset)
+	footer = binary.LittleEndian.AppendUint64(footer, slots)
+	footer = binary.LittleEndian.AppendUint64(footer, uint64(len(b.hashes)))
+	footer = append(footer, magic...)
+	for _, p := range [][]byte{table, footer} {
+		if _, err := b.w.Write(p); err != nil {
+			return err
+		}
+	}
+	return b.w.Flush()
+}
+
+// Reader looks up keys in a constant database
+type Reader struct {
+	records []byte
+	table   []byte
+	slots   uint64
+	count   int
+	close   func() error
+}
+
+// Load returns a Reader for the database in *data*. The Reader refers to
+// *data* rather than copying it, so *data* must not be modified while the
+// Reader is in use.
+func Load(data []byte) (*Reader, error) {
+	if len(data) < footerSize || string(data[len(data)-4:]) != magic {
+		return nil, ErrCorrupt
+	}
+	footer := data[len(data)-footerSize:]
+	tableOffset := binary.LittleEndian.Uint64(footer)
+	slots := binary.LittleEndian.Uint64(footer[8:])
+	count := binary.LittleEndian.Uint64(footer[16:])
+	end := uint64(len(data) - footerSize)
+	if tableOffset > end || slots == 0 || slots > (end-tableOffset)/slotSize ||
+		tableOffset+slots*slotSize != end || count >= slots {
+		return nil, ErrCorrupt
+	}
+	return &Reader{
+		records: data[:tableOffset],
+		table:   data[tableOffset:end],
+		slots:   slots,
+		count:   int(count),
+		close:   func() error { return nil },
+	}, nil
+}
+
+// Len returns the number of records
+func (r *Reader) Len() int {
+	return r.count
+}
+
+// record returns the key and value of the record at *offset*, or false if
+// the record does not fit in the database
+func (r *Reader) record(offset uint64) ([]byte, []byte, uint64, bool) {
+	if offset >= uint64(len(r.records)) {
+		return nil, nil, 0, false
+	}
+	data := r.records[offset:]
+	keyLen, n := binary.Uvarint(data)
+	if n <= 0 {
+		return nil, nil, 0, false
+	}
+	valueLen, m := binary.Uvarint(data[n:])
+	if m <= 0 {
+		return nil, nil, 0, false
+	}
+	start := uint64(n + m)
+	if keyLen > uint64(len(data))-start || valueLen > uint64(len(data))-start-keyLen {
+		return nil, nil, 0, false
+	}
+	key := data[start : start+keyLen]
+	value := data[start+keyLen : start+keyLen+valueLen : start+keyLen+valueLen]
+	return key, value, offset + start + keyLen + valueLen, true
+}
+
+// Get returns the value of *key*, or false if it is not in the database. The
+// value is a slice of the database itself, and must not be modified.
+func (r *Reader) Get(key []byte) ([]byte, bool) {
+	h := hashing.Bytes(key)
+	s := h % r.slots
+	for probes := uint64(0); probes != r.slots; probes++ {
+		slot := r.table[s*slotSize:]
+		offset := binary.LittleEndian.Uint64(slot[8:])
+		if offset == 0 {
+			return nil, false
+		}
+		if binary.LittleEndian.Uint64(slot) == h {
+			if k, value, _, ok := r.record(offset - 1); ok && string(k) == string(key) {
+				return value, true
+			}
+		}
+		s = (s + 1) % r.slots
+	}
+	return nil, false
+}
+
+// Visit calls *f* with the key and value of each record, in the order they
+// were added, until *f* returns false. It returns ErrCorrupt if a record
+// cannot be read.
+func (r *Reader) Visit(f func(key, value []byte) bool) error {
+	for offset := uint64(0); offset != uint64(len(r.records)); {
+		key, value, next, ok := r.record(offset)
+		if !ok {
+			return ErrCorrupt
+		}
+		if !f(key, value) {
+			return nil
+		}
+		offset = next
+	}
+	return nil
+}
+
+// Close releases the memory of a database opened with Open. The Reader, and
+// the slices it returned, must not be used afterwards.
+func (r *Reader) Close() error {
+	release := r.close
+	r.close = func() error { return nil }
+	return release()
+}
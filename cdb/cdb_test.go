@@ -0,0 +1,132 @@
+package cdb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func build(t testing.TB, n int) []byte {
+	var buf bytes.Buffer
+	b := NewBuilder(&buf)
+	for i := 0; i != n; i++ {
+		if err := b.Add([]byte(fmt.Sprint("key", i)), []byte(fmt.Sprint("value", i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := b.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestGet(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 1000} {
+		r, err := Load(build(t, n))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.Len() != n {
+			t.Error(r.Len())
+		}
+		for i := 0; i != n; i++ {
+			if value, ok := r.Get([]byte(fmt.Sprint("key", i))); !ok || string(value) != fmt.Sprint("value", i) {
+				t.Fatal(n, i, string(value), ok)
+			}
+		}
+		for _, key := range []string{"key", fmt.Sprint("key", n), ""} {
+			if _, ok := r.Get([]byte(key)); ok {
+				t.Error("found", key)
+			}
+		}
+	}
+}
+
+func TestDuplicatesAndVisit(t *testing.T) {
+	var buf bytes.Buffer
+	b := NewBuilder(&buf)
+	b.Add([]byte("a"), []byte("first"))
+	b.Add([]byte(""), []byte("empty key"))
+	b.Add([]byte("a"), []byte("second"))
+	b.Finish()
+	r, err := Load(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value, ok := r.Get([]byte("a")); !ok || string(value) != "first" {
+		t.Error(string(value))
+	}
+	if value, ok := r.Get(nil); !ok || string(value) != "empty key" {
+		t.Error(string(value))
+	}
+	var visited []string
+	if err := r.Visit(func(key, value []byte) bool {
+		visited = append(visited, string(key)+"="+string(value))
+		return true
+	}); err != nil || fmt.Sprint(visited) != "[a=first =empty key a=second]" {
+		t.Error(visited, err)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "db")
+	if err := os.WriteFile(path, build(t, 100), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value, ok := r.Get([]byte("key42")); !ok || string(value) != "value42" {
+		t.Error(string(value))
+	}
+	if err := r.Close(); err != nil {
+		t.Error(err)
+	}
+	if err := r.Close(); err != nil {
+		t.Error("second close", err)
+	}
+	os.WriteFile(path, []byte("short"), 0o644)
+	if _, err := Open(path); !errors.Is(err, ErrCorrupt) {
+		t.Error(err)
+	}
+}
+
+func TestCorrupt(t *testing.T) {
+	data := build(t, 50)
+	if _, err := Load(data[:len(data)-1]); err != ErrCorrupt {
+		t.Error(err)
+	}
+	// Damaged records and tables give wrong answers or errors, never panics
+	r := rand.New(rand.NewSource(1))
+	for trial := 0; trial != 1000; trial++ {
+		damaged := append([]byte(nil), data...)
+		for i := 0; i != 4; i++ {
+			damaged[r.Intn(len(damaged))] = byte(r.Intn(256))
+		}
+		db, err := Load(damaged)
+		if err != nil {
+			continue
+		}
+		for i := 0; i != 60; i++ {
+			db.Get([]byte(fmt.Sprint("key", i)))
+		}
+		db.Visit(func(key, value []byte) bool { return true })
+	}
+}
+
+func BenchmarkGet(b *testing.B) {
+	r, _ := Load(build(b, 100000))
+	keys := make([][]byte, 1024)
+	for i := range keys {
+		keys[i] = []byte(fmt.Sprint("key", i*97))
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r.Get(keys[i%len(keys)])
+	}
+}
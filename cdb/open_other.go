@@ -0,0 +1,15 @@
+//go:build !unix
+
+package cdb
+
+import "os"
+
+// Open reads the database in the file at *path* into memory, on platforms
+// where it is not mapped
+func Open(path string) (*Reader, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Load(data)
+}
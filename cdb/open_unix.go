@@ -0,0 +1,38 @@
+//go:build unix
+
+package cdb
+
+import (
+	"os"
+	"syscall"
+)
+
+// Open maps the database in the file at *path* into memory, read-only. The
+// Reader must be closed to unmap it, after which the slices it returned must
+// not be used.
+func Open(path string) (*Reader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	// The mapping stays valid after the file is closed
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() < footerSize {
+		return nil, ErrCorrupt
+	}
+	data, err := syscall.Mmap(int(f.Fd()), 0, int(info.Size()), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, err
+	}
+	r, err := Load(data)
+	if err != nil {
+		syscall.Munmap(data)
+		return nil, err
+	}
+	r.close = func() error { return syscall.Munmap(data) }
+	return r, nil
+}
@@ -0,0 +1,118 @@
+/*
+ * Package chunker splits a stream into variable-size chunks at boundaries
+ * chosen by its content, as used by deduplicating backup and sync tools.
+ *
+ * Splitting a stream into fixed-size blocks makes deduplication fragile:
+ * inserting one byte near the start shifts every later block boundary, so no
+ * later block matches its old copy. Content-defined chunking instead places
+ * a boundary wherever a hash of the last few bytes (the window) has some
+ * property, here that its low bits are all zero. A boundary then depends only
+ * on the bytes just before it, so an edit only changes the chunks around it,
+ * and the chunking resynchronizes immediately after:
+ *
+ *    before   |aaaa|bbbbbb|ccc|dddd|
+ *    after    |aaaa|bbXbbbb|ccc|dddd|   only the edited chunk differs
+ *
+ * The window is hashed with a rolling hash (see strsearch.Rolling), so moving
+ * it along one byte costs O(1). If the low k bits of the hash are zero with
+ * probability 2^-k, boundaries occur on average every 2^k bytes. To keep
+ * chunks within useful bounds, no boundary is placed less than min bytes
+ * after the previous one, and one is forced after max bytes. Since hashing
+ * only starts a window before min, chunks average about min + avg bytes.
+ */
+
+package chunker
+
+import (
+	"io"
+
+	"github.com/njwilson23/datastructures/strsearch"
+)
+
+// Window is the number of bytes hashed to choose boundaries
+const Window = 48
+
+// Chunker reads a stream and splits it into chunks
+type Chunker struct {
+	r          io.Reader
+	buf        []byte
+	start, end int // buffered data not yet returned
+	err        error
+	minSize    int
+	maxSize    int
+	mask       uint64
+	hash       *strsearch.Rolling
+}
+
+// New creates a Chunker reading from *r*, which returns chunks of at least
+// *minSize* and at most *maxSize* bytes (except for the last chunk, which may
+// be shorter), with boundaries on average every *avgSize* bytes after the
+// minimum. *avgSize* must be a power of two.
+func New(r io.Reader, minSize, avgSize, maxSize int) *Chunker {
+	if minSize < 1 || maxSize < minSize || avgSize < 1 || avgSize&(avgSize-1) != 0 {
+		panic("chunker: sizes must satisfy 0 < min <= max, and avg must be a power of two")
+	}
+	return &Chunker{
+		r:       r,
+		buf:     make([]byte, 2*maxSize),
+		minSize: minSize,
+		maxSize: maxSize,
+		mask:    uint64(avgSize - 1),
+		hash:    strsearch.NewRolling(Window),
+	}
+}
+
+// fill reads until at least max bytes are buffered, or the reader is
+// exhausted
+func (c *Chunker) fill() {
+	if c.end-c.start >= c.maxSize || c.err != nil {
+		return
+	}
+	c.end = copy(c.buf, c.buf[c.start:c.end])
+	c.start = 0
+	for c.end < c.maxSize && c.err == nil {
+		var n int
+		n, c.err = c.r.Read(c.buf[c.end:])
+		c.end += n
+	}
+}
+
+// Next returns the next chunk, or io.EOF after the last one. The chunk is
+// only valid until the next call to Next. Any error from the reader other
+// than io.EOF is returned once the data read before it has been chunked.
+func (c *Chunker) Next() ([]byte, error) {
+	c.fill()
+	data := c.buf[c.start:c.end]
+	if len(data) == 0 {
+		if c.err == nil {
+			c.err = io.EOF
+		}
+		return nil, c.err
+	}
+	n := c.boundary(data)
+	c.start += n
+	return data[:n:n], nil
+}
+
+// boundary returns the length of the chunk at the start of *data*
+func (c *Chunker) boundary(data []byte) int {
+	if len(data) <= c.minSize {
+		return len(data)
+	}
+	if len(data) > c.maxSize {
+		data = data[:c.maxSize]
+	}
+	// A boundary at or after min only depends on the window before it, so
+	// hashing starts one window before min
+	i := c.minSize - Window
+	if i < 0 {
+		i = 0
+	}
+	c.hash.Reset()
+	for ; i < len(data); i++ {
+		if c.hash.Roll(data[i])&c.mask == 0 && i+1 >= c.minSize {
+			return i + 1
+		}
+	}
+	return len(data)
+}
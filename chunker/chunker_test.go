@@ -0,0 +1,94 @@
+package chunker
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"testing"
+	"testing/iotest"
+)
+
+func chunks(t testing.TB, r io.Reader, minSize, avgSize, maxSize int) [][]byte {
+	c := New(r, minSize, avgSize, maxSize)
+	var result [][]byte
+	for {
+		chunk, err := c.Next()
+		if err == io.EOF {
+			return result
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		result = append(result, append([]byte(nil), chunk...))
+	}
+}
+
+func randomData(n int) []byte {
+	data := make([]byte, n)
+	rand.New(rand.NewSource(1)).Read(data)
+	return data
+}
+
+func TestSizes(t *testing.T) {
+	data := randomData(1 << 20)
+	// A reader returning one byte at a time exercises refilling the buffer
+	result := chunks(t, iotest.OneByteReader(bytes.NewReader(data)), 1024, 4096, 16384)
+	if joined := bytes.Join(result, nil); !bytes.Equal(joined, data) {
+		t.Fatal("chunks do not reassemble the input")
+	}
+	for i, chunk := range result {
+		if len(chunk) > 16384 || (len(chunk) < 1024 && i != len(result)-1) {
+			t.Error(i, len(chunk))
+		}
+	}
+	if mean := len(data) / len(result); mean < 3000 || mean > 7000 {
+		t.Error("mean chunk size", mean)
+	}
+}
+
+func TestResynchronize(t *testing.T) {
+	data := randomData(1 << 18)
+	edited := append(append(append([]byte(nil), data[:1000]...), "inserted"...), data[1000:]...)
+	before := map[string]bool{}
+	for _, chunk := range chunks(t, bytes.NewReader(data), 256, 1024, 8192) {
+		before[string(chunk)] = true
+	}
+	after := chunks(t, bytes.NewReader(edited), 256, 1024, 8192)
+	changed := 0
+	for _, chunk := range after {
+		if !before[string(chunk)] {
+			changed++
+		}
+	}
+	if changed > 2 {
+		t.Error(changed, "of", len(after), "chunks changed")
+	}
+}
+
+func TestError(t *testing.T) {
+	// The reader fails after returning all of its data
+	c := New(iotest.TimeoutReader(bytes.NewReader(randomData(100))), 10, 16, 200)
+	n := 0
+	chunk, err := c.Next()
+	for ; err == nil; chunk, err = c.Next() {
+		n += len(chunk)
+	}
+	if n != 100 || err != iotest.ErrTimeout {
+		t.Error(n, err)
+	}
+	if _, err := c.Next(); err != iotest.ErrTimeout {
+		t.Error("error not repeated", err)
+	}
+	c = New(bytes.NewReader(nil), 10, 16, 200)
+	if _, err := c.Next(); err != io.EOF {
+		t.Error(err)
+	}
+}
+
+func BenchmarkChunker(b *testing.B) {
+	data := randomData(1 << 22)
+	b.SetBytes(int64(len(data)))
+	for i := 0; i < b.N; i++ {
+		chunks(b, bytes.NewReader(data), 2048, 8192, 65536)
+	}
+}
@@ -0,0 +1,266 @@
+/*
+ * Command dscompare runs the same workload against two data structures, and
+ * reports how long each took and whether they gave the same answers.
+ *
+ * Both structures are loaded with the same dataset, then given the same trace
+ * of insertions, deletions and lookups. The result of every lookup and
+ * deletion is compared, as are the final contents, so a disagreement points
+ * to a bug in one of them. If a structure cannot delete, the trace has no
+ * deletions.
+ *
+ * Usage:
+ *
+ *    dscompare [flags] a b
+ *
+ * where a and b name structures (see -list). The dataset is read from -data,
+ * one integer per line, or else generated. The trace is read from -trace
+ * (see package dstest for the format), or else generated, and a generated
+ * trace can be saved with -save to be replayed later. For example, to compare
+ * the red-black tree with the skip-list on a skewed workload:
+ *
+ *    dscompare -dist zipf -ops 1000000 rbtree skiplist
+ */
+
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/njwilson23/datastructures/dstest"
+	"github.com/njwilson23/datastructures/gen"
+)
+
+// config describes a workload
+type config struct {
+	n, max, ops        int
+	dist               string
+	inserts, deletions float64
+	seed               int64
+}
+
+// keys generates *n* keys according to the configured distribution
+func (c config) keys(g *gen.Generator, n int) ([]int, error) {
+	switch c.dist {
+	case "uniform":
+		return g.Uniform(n, c.max), nil
+	case "zipf":
+		return g.Zipf(n, c.max, 1.1), nil
+	}
+	return nil, fmt.Errorf("unknown distribution %q", c.dist)
+}
+
+// trace generates the operations of a workload
+func (c config) trace(canDelete bool) ([]dstest.Op, error) {
+	g := gen.New(c.seed + 1)
+	keys, err := c.keys(g, c.ops)
+	if err != nil {
+		return nil, err
+	}
+	r := rand.New(rand.NewSource(c.seed))
+	trace := make([]dstest.Op, c.ops)
+	for i, key := range keys {
+		kind := dstest.Lookup
+		switch p := r.Float64(); {
+		case p < c.inserts:
+			kind = dstest.Insert
+		case p < c.inserts+c.deletions && canDelete:
+			kind = dstest.Delete
+		}
+		trace[i] = dstest.Op{Kind: kind, Key: key}
+	}
+	return trace, nil
+}
+
+// readData reads one integer per line
+func readData(r io.Reader) ([]int, error) {
+	var data []int
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		key, err := strconv.Atoi(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		data = append(data, key)
+	}
+	return data, scanner.Err()
+}
+
+func readTrace(path string, canDelete bool) ([]dstest.Op, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	trace, err := dstest.ReadTrace(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	for i, op := range trace {
+		switch op.Kind {
+		case dstest.Insert, dstest.Lookup:
+		case dstest.Delete:
+			if !canDelete {
+				return nil, fmt.Errorf("%s: operation %d deletes, but a structure cannot", path, i)
+			}
+		default:
+			return nil, fmt.Errorf("%s: operation %d: unknown operation %q", path, i, op.Kind)
+		}
+	}
+	return trace, nil
+}
+
+func saveTrace(path string, trace []dstest.Op) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	if err := dstest.WriteTrace(w, trace); err != nil {
+		f.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// result records what a structure did with a workload
+type result struct {
+	load, run time.Duration
+	answers   []bool // for each deletion and lookup, in order
+	keys      []int
+}
+
+func run(s set, data []int, trace []dstest.Op) result {
+	var r result
+	start := time.Now()
+	for _, key := range data {
+		s.Insert(key)
+	}
+	r.load = time.Since(start)
+
+	r.answers = make([]bool, 0, len(trace))
+	start = time.Now()
+	for _, op := range trace {
+		found, _ := dstest.Apply(s, op)
+		if op.Kind != dstest.Insert {
+			r.answers = append(r.answers, found)
+		}
+	}
+	r.run = time.Since(start)
+	r.keys = s.Keys()
+	return r
+}
+
+// compare returns a description of the first difference between two results,
+// or "" if they agree
+func compare(trace []dstest.Op, a, b result) string {
+	j := 0
+	for i, op := range trace {
+		if op.Kind == dstest.Insert {
+			continue
+		}
+		if a.answers[j] != b.answers[j] {
+			return fmt.Sprintf("operation %d (%s %d): %v != %v", i, op.Kind, op.Key, a.answers[j], b.answers[j])
+		}
+		j++
+	}
+	if len(a.keys) != len(b.keys) {
+		return fmt.Sprintf("final sizes: %d != %d", len(a.keys), len(b.keys))
+	}
+	for i := range a.keys {
+		if a.keys[i] != b.keys[i] {
+			return fmt.Sprintf("final key %d: %d != %d", i, a.keys[i], b.keys[i])
+		}
+	}
+	return ""
+}
+
+func main() {
+	var c config
+	flag.IntVar(&c.n, "n", 100000, "number of keys to generate for the dataset")
+	flag.IntVar(&c.max, "max", 1000000, "generated keys are in [0, max)")
+	flag.IntVar(&c.ops, "ops", 100000, "number of operations in the trace")
+	flag.StringVar(&c.dist, "dist", "uniform", "distribution of generated keys: uniform or zipf")
+	flag.Float64Var(&c.inserts, "inserts", 0.3, "fraction of operations that are insertions")
+	flag.Float64Var(&c.deletions, "deletes", 0.1, "fraction of operations that are deletions")
+	flag.Int64Var(&c.seed, "seed", 1, "random seed")
+	dataFile := flag.String("data", "", "file with one integer key per line to load instead of generated keys")
+	traceFile := flag.String("trace", "", "trace file to run instead of a generated trace")
+	saveFile := flag.String("save", "", "file to save the generated trace to")
+	list := flag.Bool("list", false, "list the structures that can be compared")
+	flag.Parse()
+
+	if *list {
+		for _, name := range names() {
+			fmt.Println(name)
+		}
+		return
+	}
+	if flag.NArg() != 2 {
+		fmt.Fprintln(os.Stderr, "usage: dscompare [flags] a b")
+		flag.PrintDefaults()
+		os.Exit(2)
+	}
+	a, okA := structures[flag.Arg(0)]
+	b, okB := structures[flag.Arg(1)]
+	if !okA || !okB {
+		fmt.Fprintf(os.Stderr, "unknown structure; choose from %s\n", strings.Join(names(), ", "))
+		os.Exit(2)
+	}
+
+	var data []int
+	var err error
+	if *dataFile != "" {
+		var f *os.File
+		if f, err = os.Open(*dataFile); err == nil {
+			data, err = readData(f)
+			f.Close()
+		}
+	} else {
+		data, err = c.keys(gen.New(c.seed), c.n)
+	}
+	var trace []dstest.Op
+	if err == nil && *traceFile != "" {
+		trace, err = readTrace(*traceFile, a.canDelete && b.canDelete)
+	} else if err == nil {
+		trace, err = c.trace(a.canDelete && b.canDelete)
+		if err == nil && *saveFile != "" {
+			err = saveTrace(*saveFile, trace)
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	ra := run(a.new(), data, trace)
+	rb := run(b.new(), data, trace)
+	fmt.Printf("%-15s %12s %12s %12s\n", "", "load", "trace", "per op")
+	for i, r := range []result{ra, rb} {
+		var perOp time.Duration
+		if len(trace) != 0 {
+			perOp = r.run / time.Duration(len(trace))
+		}
+		fmt.Printf("%-15s %12v %12v %12v\n", flag.Arg(i), r.load, r.run, perOp)
+	}
+	if diff := compare(trace, ra, rb); diff != "" {
+		fmt.Println("MISMATCH:", diff)
+		os.Exit(1)
+	}
+	fmt.Printf("answers agree (%d keys loaded, %d operations, %d keys at end)\n", len(data), len(trace), len(ra.keys))
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/njwilson23/datastructures/gen"
+)
+
+func TestStructuresAgree(t *testing.T) {
+	c := config{n: 2000, max: 5000, ops: 5000, dist: "uniform", inserts: 0.3, deletions: 0.2, seed: 1}
+	for _, dist := range []string{"uniform", "zipf"} {
+		c.dist = dist
+		for _, name := range names() {
+			s := structures[name]
+			data, _ := c.keys(gen.New(c.seed), c.n)
+			trace, err := c.trace(s.canDelete)
+			if err != nil {
+				t.Fatal(err)
+			}
+			reference := run(structures["sortedset"].new(), data, trace)
+			if diff := compare(trace, reference, run(s.new(), data, trace)); diff != "" {
+				t.Errorf("%s, %s: %s", name, dist, diff)
+			}
+		}
+	}
+}
+
+// brokenSet forgets every tenth insertion
+type brokenSet struct {
+	set
+	n int
+}
+
+func (s *brokenSet) Insert(key int) {
+	s.n++
+	if s.n%10 != 0 {
+		s.set.Insert(key)
+	}
+}
+
+func TestMismatch(t *testing.T) {
+	c := config{n: 100, max: 1000, ops: 1000, dist: "uniform", inserts: 0.5, seed: 1}
+	data, _ := c.keys(gen.New(c.seed), c.n)
+	trace, _ := c.trace(true)
+	a := run(structures["rbtree"].new(), data, trace)
+	b := run(&brokenSet{set: structures["rbtree"].new()}, data, trace)
+	if diff := compare(trace, a, b); !strings.HasPrefix(diff, "operation") {
+		t.Error(diff)
+	}
+}
+
+func TestReadData(t *testing.T) {
+	data, err := readData(strings.NewReader("3\n\n-1\n 7 \n"))
+	if err != nil || len(data) != 3 || data[1] != -1 || data[2] != 7 {
+		t.Error(data, err)
+	}
+	if _, err := readData(strings.NewReader("1\nx\n")); err == nil || !strings.HasPrefix(err.Error(), "line 2") {
+		t.Error(err)
+	}
+}
+
+func TestSaveReadTrace(t *testing.T) {
+	c := config{max: 100, ops: 50, dist: "uniform", inserts: 0.3, deletions: 0.3, seed: 1}
+	trace, _ := c.trace(true)
+	path := filepath.Join(t.TempDir(), "trace.jsonl")
+	if err := saveTrace(path, trace); err != nil {
+		t.Fatal(err)
+	}
+	read, err := readTrace(path, true)
+	if err != nil || len(read) != len(trace) || read[7] != trace[7] {
+		t.Fatal(read, err)
+	}
+	if _, err := readTrace(path, false); err == nil {
+		t.Error("deletions accepted for a structure that cannot delete")
+	}
+}
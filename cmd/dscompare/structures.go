@@ -0,0 +1,185 @@
+package main
+
+// Structures
+//
+// Every structure that can be compared is adapted to the set interface, a set
+// of int keys. Inserting a key that is already present leaves the set
+// unchanged, so structures that allow duplicates check first.
+
+import (
+	"sort"
+
+	"github.com/njwilson23/datastructures/dstest"
+	"github.com/njwilson23/datastructures/hamt"
+	"github.com/njwilson23/datastructures/hashtable"
+	"github.com/njwilson23/datastructures/rbtree"
+	"github.com/njwilson23/datastructures/skiplist"
+	"github.com/njwilson23/datastructures/sortedset"
+	"github.com/njwilson23/datastructures/veb"
+)
+
+type set interface {
+	// Delete is only called if the structure's canDelete is true
+	dstest.Set
+	// Keys returns the keys in ascending order
+	Keys() []int
+}
+
+// structure describes a structure that can be compared
+type structure struct {
+	new       func() set
+	canDelete bool
+}
+
+var structures = map[string]structure{
+	"rbtree":        {func() set { return rbtreeSet{rbtree.New()} }, true},
+	"sortedset":     {func() set { return sortedSet{sortedset.New()} }, true},
+	"skiplist":      {func() set { return skiplistSet{skiplist.NewConcurrentOrderedMap()} }, true},
+	"deterministic": {func() set { return deterministicSet{skiplist.NewDeterministic()} }, false},
+	"hashtable":     {func() set { return hashtableSet{hashtable.InitHashTable(1 << 16)} }, true},
+	"hamt":          {func() set { return &hamtSet{hamt.New()} }, true},
+	"veb":           {func() set { return vebSet{veb.New(64)} }, true},
+}
+
+// names returns the names of the structures, sorted
+func names() []string {
+	var names []string
+	for name := range structures {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+type rbtreeSet struct {
+	tree *rbtree.RedBlackTree
+}
+
+func (s rbtreeSet) Insert(key int) {
+	if !s.tree.Contains(key) {
+		s.tree.Insert(key)
+	}
+}
+func (s rbtreeSet) Contains(key int) bool { return s.tree.Contains(key) }
+func (s rbtreeSet) Delete(key int) bool   { return s.tree.Delete(key) }
+func (s rbtreeSet) Keys() []int           { return s.tree.Keys() }
+
+type sortedSet struct {
+	set *sortedset.SortedSet
+}
+
+func (s sortedSet) Insert(key int)        { s.set.Insert(key) }
+func (s sortedSet) Contains(key int) bool { return s.set.Contains(key) }
+func (s sortedSet) Delete(key int) bool   { return s.set.Delete(key) }
+func (s sortedSet) Keys() []int           { return s.set.Keys() }
+
+type skiplistSet struct {
+	m *skiplist.ConcurrentOrderedMap
+}
+
+func (s skiplistSet) Insert(key int) { s.m.Store(key, nil) }
+func (s skiplistSet) Contains(key int) bool {
+	_, ok := s.m.Load(key)
+	return ok
+}
+func (s skiplistSet) Delete(key int) bool {
+	_, ok := s.m.LoadAndDelete(key)
+	return ok
+}
+func (s skiplistSet) Keys() []int {
+	var keys []int
+	s.m.Range(func(key int, _ interface{}) bool {
+		keys = append(keys, key)
+		return true
+	})
+	return keys
+}
+
+type deterministicSet struct {
+	s *skiplist.Deterministic
+}
+
+func (s deterministicSet) Insert(key int) { s.s.Insert(key, nil) }
+func (s deterministicSet) Contains(key int) bool {
+	_, err := s.s.Get(key)
+	return err == nil
+}
+func (s deterministicSet) Delete(key int) bool { panic("deterministic skip-list has no Delete") }
+func (s deterministicSet) Keys() []int {
+	var keys []int
+	for _, item := range s.s.Items() {
+		keys = append(keys, item.Key)
+	}
+	return keys
+}
+
+// intKey adapts an int to hashtable.Hashable
+type intKey int
+
+func (k intKey) Hash() int { return int(k) }
+
+type hashtableSet struct {
+	ht *hashtable.HashTable
+}
+
+func (s hashtableSet) Insert(key int) {
+	if !s.Contains(key) {
+		s.ht.Insert(intKey(key), nil)
+	}
+}
+func (s hashtableSet) Contains(key int) bool {
+	_, err := s.ht.Get(intKey(key))
+	return err == nil
+}
+func (s hashtableSet) Delete(key int) bool { return s.ht.Delete(intKey(key)) == nil }
+func (s hashtableSet) Keys() []int {
+	var keys []int
+	for key := range s.ht.ToMap() {
+		keys = append(keys, int(key.(intKey)))
+	}
+	sort.Ints(keys)
+	return keys
+}
+
+// hamtSet holds the latest version of a persistent map
+type hamtSet struct {
+	m *hamt.Map
+}
+
+func (s *hamtSet) Insert(key int) { s.m = s.m.Put(intKey(key), nil) }
+func (s *hamtSet) Contains(key int) bool {
+	_, ok := s.m.Get(intKey(key))
+	return ok
+}
+func (s *hamtSet) Delete(key int) bool {
+	n := s.m.Len()
+	s.m = s.m.Delete(intKey(key))
+	return s.m.Len() != n
+}
+func (s *hamtSet) Keys() []int {
+	var keys []int
+	for _, kv := range s.m.Items() {
+		keys = append(keys, int(kv.Key.(intKey)))
+	}
+	sort.Ints(keys)
+	return keys
+}
+
+// vebSet flips the sign bit of keys, which maps the ints onto the uint64s in
+// the same order
+type vebSet struct {
+	t *veb.Tree
+}
+
+func toUint(key int) uint64 { return uint64(key) ^ 1<<63 }
+
+func (s vebSet) Insert(key int)        { s.t.Insert(toUint(key)) }
+func (s vebSet) Contains(key int) bool { return s.t.Contains(toUint(key)) }
+func (s vebSet) Delete(key int) bool   { return s.t.Delete(toUint(key)) }
+func (s vebSet) Keys() []int {
+	var keys []int
+	for x, ok := s.t.Min(); ok; x, ok = s.t.Successor(x) {
+		keys = append(keys, int(x^1<<63))
+	}
+	return keys
+}
@@ -0,0 +1,264 @@
+/*
+ * Package datrie implements a double-array trie, a compact and very fast
+ * static dictionary of strings.
+ *
+ * A trie stores a set of strings as a tree of characters, so that looking up
+ * a string follows one edge per character, however many strings there are.
+ * Stored naively, each node needs a table or list of its children, which
+ * wastes space or time. A double-array trie (Aoe, 1989) packs every node's
+ * transitions into two shared integer arrays, base and check. The child of
+ * node s on character c is node t = base[s] + c, and the transition exists
+ * only if check[t] = s:
+ *
+ *    s --c--> t    iff    t = base[s] + c  and  check[t] = s
+ *
+ * so a transition costs two array reads and no search. Building the arrays
+ * means choosing, for each node, a base at which the slots for all of its
+ * children are still free, so that the nodes of the whole trie interleave
+ * in the arrays with few gaps.
+ *
+ * Here characters are bytes, coded as byte+1, and code 0 is an end-of-word
+ * marker, so that a word that is a prefix of another can be stored. The node
+ * reached by code 0 is a leaf, whose base holds -(id+1), where id is the
+ * position of the word in the sorted list the trie was built from.
+ *
+ * The trie is static: it is built once from a sorted list of words.
+ * MarshalBinary writes the two arrays as little-endian int32s, and Load reads
+ * them back without copying or parsing, so a dictionary file can be memory
+ * mapped and used directly.
+ */
+
+package datrie
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+var (
+	ErrUnsorted = errors.New("words are not sorted and unique")
+	ErrCorrupt  = errors.New("corrupt double-array trie encoding")
+)
+
+const (
+	free     = -1 // check value of an unused slot
+	reserved = -2 // check value of the root, which is no node's child
+	magic    = "DAT1"
+	header   = 12 // magic, number of slots, number of words
+)
+
+// Trie is a double-array trie holding a static set of words
+type Trie struct {
+	base  []byte // int32 per slot, little-endian
+	check []byte
+	slots int
+	words int
+}
+
+// builder holds the arrays while they are being filled in
+type builder struct {
+	base, check []int32
+	hint        int // no free slots are below hint
+}
+
+// slot grows the arrays to include slot *i*
+func (b *builder) slot(i int) {
+	for len(b.check) <= i {
+		b.base = append(b.base, 0)
+		b.check = append(b.check, free)
+	}
+}
+
+// findBase returns a base at which the slots for every code in *codes*, which
+// are in increasing order, are free
+func (b *builder) findBase(codes []int) int {
+	for b.hint < len(b.check) && b.check[b.hint] != free {
+		b.hint++
+	}
+	for pos := b.hint; ; pos++ {
+		b.slot(pos)
+		if b.check[pos] != free || pos-codes[0] < 1 {
+			continue
+		}
+		base := pos - codes[0]
+		ok := true
+		for _, c := range codes[1:] {
+			b.slot(base + c)
+			if b.check[base+c] != free {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return base
+		}
+	}
+}
+
+// code returns the code of the character of *word* at *depth*, or 0 at the
+// end of the word
+func code(word string, depth int) int {
+	if depth == len(word) {
+		return 0
+	}
+	return int(word[depth]) + 1
+}
+
+// Build creates a Trie holding *words*, which must be sorted and unique. The
+// ID of each word is its position in the list.
+func Build(words []string) (*Trie, error) {
+	for i := 1; i < len(words); i++ {
+		if words[i-1] >= words[i] {
+			return nil, ErrUnsorted
+		}
+	}
+	b := &builder{base: []int32{0}, check: []int32{reserved}, hint: 1}
+
+	// Each task is a node and the range of words below it, which share their
+	// first *depth* bytes
+	type task struct {
+		node, lo, hi, depth int
+	}
+	var stack []task
+	if len(words) != 0 {
+		stack = append(stack, task{0, 0, len(words), 0})
+	}
+	var codes, starts []int
+	for len(stack) != 0 {
+		t := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		// The words are sorted, so those with the same next code are
+		// consecutive, and a word that ends here comes first
+		codes, starts = codes[:0], starts[:0]
+		for i := t.lo; i != t.hi; i++ {
+			if c := code(words[i], t.depth); len(codes) == 0 || c != codes[len(codes)-1] {
+				codes = append(codes, c)
+				starts = append(starts, i)
+			}
+		}
+		base := b.findBase(codes)
+		b.base[t.node] = int32(base)
+		for _, c := range codes {
+			b.check[base+c] = int32(t.node)
+		}
+		for i, c := range codes {
+			child := base + c
+			if c == 0 {
+				b.base[child] = int32(-starts[i] - 1)
+				continue
+			}
+			hi := t.hi
+			if i+1 < len(starts) {
+				hi = starts[i+1]
+			}
+			stack = append(stack, task{child, starts[i], hi, t.depth + 1})
+		}
+	}
+
+	trie := &Trie{slots: len(b.check), words: len(words)}
+	trie.base = make([]byte, 4*len(b.base))
+	trie.check = make([]byte, 4*len(b.check))
+	for i := range b.base {
+		binary.LittleEndian.PutUint32(trie.base[4*i:], uint32(b.base[i]))
+		binary.LittleEndian.PutUint32(trie.check[4*i:], uint32(b.check[i]))
+	}
+	return trie, nil
+}
+
+func (t *Trie) baseAt(i int) int {
+	return int(int32(binary.LittleEndian.Uint32(t.base[4*i:])))
+}
+
+func (t *Trie) checkAt(i int) int {
+	return int(int32(binary.LittleEndian.Uint32(t.check[4*i:])))
+}
+
+// next returns the child of node *s* on code *c*, or -1 if there is none
+func (t *Trie) next(s, c int) int {
+	child := t.baseAt(s) + c
+	if child < 0 || child >= t.slots || t.checkAt(child) != s {
+		return -1
+	}
+	return child
+}
+
+// Len returns the number of words in the trie
+func (t *Trie) Len() int {
+	return t.words
+}
+
+// Size returns the number of bytes used by the two arrays
+func (t *Trie) Size() int {
+	return len(t.base) + len(t.check)
+}
+
+// Lookup returns the ID of *word*, or false if it is not in the trie
+func (t *Trie) Lookup(word string) (int, bool) {
+	s := 0
+	for i := 0; i != len(word); i++ {
+		if s = t.next(s, int(word[i])+1); s < 0 {
+			return 0, false
+		}
+	}
+	if leaf := t.next(s, 0); leaf >= 0 {
+		return -t.baseAt(leaf) - 1, true
+	}
+	return 0, false
+}
+
+// Contains returns true if *word* is in the trie
+func (t *Trie) Contains(word string) bool {
+	_, ok := t.Lookup(word)
+	return ok
+}
+
+// CommonPrefixes returns the IDs of the words in the trie that are prefixes
+// of *s*, shortest first, as needed to split text into dictionary words
+func (t *Trie) CommonPrefixes(s string) []int {
+	var ids []int
+	node := 0
+	for i := 0; ; i++ {
+		if leaf := t.next(node, 0); leaf >= 0 {
+			ids = append(ids, -t.baseAt(leaf)-1)
+		}
+		if i == len(s) {
+			return ids
+		}
+		if node = t.next(node, int(s[i])+1); node < 0 {
+			return ids
+		}
+	}
+}
+
+// MarshalBinary encodes the trie as a 12-byte header, followed by the base
+// and check arrays as little-endian int32s
+func (t *Trie) MarshalBinary() ([]byte, error) {
+	data := make([]byte, 0, header+t.Size())
+	data = append(data, magic...)
+	data = binary.LittleEndian.AppendUint32(data, uint32(t.slots))
+	data = binary.LittleEndian.AppendUint32(data, uint32(t.words))
+	data = append(data, t.base...)
+	return append(data, t.check...), nil
+}
+
+// Load returns the Trie encoded in *data* by MarshalBinary. The Trie refers
+// to *data* rather than copying it, so *data* must not be modified while the
+// Trie is in use.
+func Load(data []byte) (*Trie, error) {
+	if len(data) < header || string(data[:4]) != magic {
+		return nil, ErrCorrupt
+	}
+	slots := int(binary.LittleEndian.Uint32(data[4:]))
+	words := int(binary.LittleEndian.Uint32(data[8:]))
+	if slots < 1 || len(data) != header+8*slots {
+		return nil, ErrCorrupt
+	}
+	data = data[header:]
+	return &Trie{
+		base:  data[: 4*slots : 4*slots],
+		check: data[4*slots:],
+		slots: slots,
+		words: words,
+	}, nil
+}
@@ -0,0 +1,125 @@
+package datrie
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestLookup(t *testing.T) {
+	words := []string{"", "a", "an", "ant", "any", "bat", "bath", "\xff"}
+	trie, err := Build(words)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if trie.Len() != len(words) {
+		t.Error(trie.Len())
+	}
+	for i, w := range words {
+		if id, ok := trie.Lookup(w); !ok || id != i {
+			t.Errorf("%q: %d %v", w, id, ok)
+		}
+	}
+	for _, w := range []string{"b", "ba", "antsy", "c", "\xfe", "bathe"} {
+		if trie.Contains(w) {
+			t.Errorf("%q found", w)
+		}
+	}
+	if ids := trie.CommonPrefixes("antelope"); fmt.Sprint(ids) != "[0 1 2 3]" {
+		t.Error(ids)
+	}
+	if ids := trie.CommonPrefixes("cat"); fmt.Sprint(ids) != "[0]" {
+		t.Error(ids)
+	}
+}
+
+func TestEmpty(t *testing.T) {
+	trie, err := Build(nil)
+	if err != nil || trie.Len() != 0 || trie.Contains("") || trie.Contains("a") {
+		t.Error(err)
+	}
+}
+
+func TestUnsorted(t *testing.T) {
+	for _, words := range [][]string{{"b", "a"}, {"a", "a"}} {
+		if _, err := Build(words); err != ErrUnsorted {
+			t.Error(words, err)
+		}
+	}
+}
+
+func randomWords(r *rand.Rand, n int) []string {
+	set := map[string]bool{}
+	for len(set) != n {
+		b := make([]byte, 1+r.Intn(10))
+		for i := range b {
+			b[i] = byte('a' + r.Intn(8))
+		}
+		set[string(b)] = true
+	}
+	words := make([]string, 0, n)
+	for w := range set {
+		words = append(words, w)
+	}
+	sort.Strings(words)
+	return words
+}
+
+func TestRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	words := randomWords(r, 20000)
+	trie, _ := Build(words)
+	for i, w := range words {
+		if id, ok := trie.Lookup(w); !ok || id != i {
+			t.Fatalf("%q: %d %v", w, id, ok)
+		}
+		if trie.Contains(w + "z") {
+			t.Fatalf("%q found", w+"z")
+		}
+	}
+	// The arrays are densely packed
+	if trie.slots > 2*len(words)*4 {
+		t.Error(trie.slots, "slots for", len(words), "words")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	words := []string{"alpha", "beta", "gamma"}
+	trie, _ := Build(words)
+	data, _ := trie.MarshalBinary()
+	loaded, err := Load(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, w := range words {
+		if id, ok := loaded.Lookup(w); !ok || id != i {
+			t.Error(w, id, ok)
+		}
+	}
+	for _, corrupt := range [][]byte{nil, data[:header], data[:len(data)-1], append([]byte("DAT2"), data[4:]...)} {
+		if _, err := Load(corrupt); err != ErrCorrupt {
+			t.Error(len(corrupt), err)
+		}
+	}
+}
+
+func BenchmarkLookup(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	words := randomWords(r, 100000)
+	trie, _ := Build(words)
+	m := make(map[string]int, len(words))
+	for i, w := range words {
+		m[w] = i
+	}
+	b.Run("Trie", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			trie.Lookup(words[i%len(words)])
+		}
+	})
+	b.Run("map", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_ = m[words[i%len(words)]]
+		}
+	})
+}
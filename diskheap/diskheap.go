@@ -0,0 +1,302 @@
+/*
+ * Package diskheap implements a priority queue stored in a file, for queues
+ * of jobs that are larger than memory or must survive a restart.
+ *
+ * The queue is a binary min-heap of fixed-size records, each a priority and
+ * a payload, stored in the file in heap order, so that the children of the
+ * record in slot i are in slots 2i+1 and 2i+2. Records are read and written
+ * in place with ReadAt and WriteAt (pread and pwrite), so memory use does not
+ * grow with the queue, and a push or pop reads and writes O(log n) slots.
+ *
+ *    header   magic "DHP1", record size uint32, count uint64
+ *    journal  see below
+ *    slots    [count]{priority float64, payload [size]byte}
+ *
+ * A push or pop moves records along one path of the heap, and a crash part
+ * way through would leave the heap out of order, or with a record lost or
+ * repeated. Each operation is therefore written twice. First the new contents
+ * of every slot it changes, and the new count, are written to a journal with
+ * a CRC-32C checksum, and synced; then they are written to the slots and the
+ * header, and synced again. Open replays a journal whose checksum matches,
+ * which repeats writes that may not have reached the slots, and ignores one
+ * whose checksum does not, since then the slots have not been touched. Either
+ * way the heap is left as it was before or after the interrupted operation.
+ *
+ *    journal  length uint32 (number of entries)
+ *             crc    uint32, CRC-32C of the rest of the journal
+ *             count  uint64
+ *             entries [length]{slot uint64, record}
+ *
+ * The journal has room for one entry per level of a heap of 2^64 records.
+ * The file is synced only if it has a Sync method, as *os.File does.
+ */
+
+package diskheap
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+)
+
+var (
+	ErrEmpty      = errors.New("empty heap")
+	ErrCorrupt    = errors.New("corrupt heap file")
+	ErrRecordSize = errors.New("payload has the wrong size")
+)
+
+const (
+	magic          = "DHP1"
+	headerSize     = 16
+	journalHeader  = 16
+	journalEntries = 64
+)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// File is the storage for a Heap, usually an *os.File
+type File interface {
+	io.ReaderAt
+	io.WriterAt
+}
+
+// Heap is a min-heap of records stored in a File
+type Heap struct {
+	f          File
+	size       int // payload size
+	count      int
+	slotsStart int64
+	journal    []byte
+}
+
+type write struct {
+	slot   int
+	record []byte
+}
+
+// Create writes an empty Heap for payloads of *size* bytes to *f*
+func Create(f File, size int) (*Heap, error) {
+	h := newHeap(f, size)
+	header := make([]byte, headerSize)
+	copy(header, magic)
+	binary.LittleEndian.PutUint32(header[4:], uint32(size))
+	if _, err := f.WriteAt(header, 0); err != nil {
+		return nil, err
+	}
+	// An empty journal has length 0, so that no stale bytes are replayed
+	if _, err := f.WriteAt(make([]byte, journalHeader), headerSize); err != nil {
+		return nil, err
+	}
+	return h, h.sync()
+}
+
+// Open opens a Heap written to *f* by Create, completing the last operation
+// if it was interrupted
+func Open(f File) (*Heap, error) {
+	header := make([]byte, headerSize)
+	if _, err := f.ReadAt(header, 0); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+	if string(header[:4]) != magic {
+		return nil, fmt.Errorf("%w: bad magic", ErrCorrupt)
+	}
+	h := newHeap(f, int(binary.LittleEndian.Uint32(header[4:])))
+	h.count = int(binary.LittleEndian.Uint64(header[8:]))
+	if err := h.recover(); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+func newHeap(f File, size int) *Heap {
+	journalSize := journalHeader + journalEntries*(8+8+size)
+	return &Heap{
+		f:          f,
+		size:       size,
+		slotsStart: int64(headerSize + journalSize),
+		journal:    make([]byte, journalSize),
+	}
+}
+
+// recover replays the journal if its checksum matches
+func (h *Heap) recover() error {
+	if _, err := h.f.ReadAt(h.journal, headerSize); err != nil && err != io.EOF {
+		return err
+	}
+	length := int(binary.LittleEndian.Uint32(h.journal))
+	if length == 0 || length > journalEntries {
+		return nil
+	}
+	end := journalHeader + length*(8+h.recordSize())
+	if crc32.Checksum(h.journal[8:end], castagnoli) != binary.LittleEndian.Uint32(h.journal[4:]) {
+		return nil
+	}
+	count := int(binary.LittleEndian.Uint64(h.journal[8:]))
+	writes := make([]write, length)
+	for i := range writes {
+		entry := h.journal[journalHeader+i*(8+h.recordSize()):]
+		writes[i] = write{int(binary.LittleEndian.Uint64(entry)), entry[8 : 8+h.recordSize()]}
+	}
+	return h.apply(writes, count)
+}
+
+func (h *Heap) recordSize() int {
+	return 8 + h.size
+}
+
+// Len returns the number of records in the heap
+func (h *Heap) Len() int {
+	return h.count
+}
+
+// PayloadSize returns the size of every payload
+func (h *Heap) PayloadSize() int {
+	return h.size
+}
+
+func (h *Heap) sync() error {
+	if s, ok := h.f.(interface{ Sync() error }); ok {
+		return s.Sync()
+	}
+	return nil
+}
+
+func (h *Heap) read(slot int) ([]byte, error) {
+	record := make([]byte, h.recordSize())
+	_, err := h.f.ReadAt(record, h.slotsStart+int64(slot*h.recordSize()))
+	return record, err
+}
+
+func priority(record []byte) float64 {
+	return math.Float64frombits(binary.LittleEndian.Uint64(record))
+}
+
+// commit journals *writes* and the new *count*, then applies them
+func (h *Heap) commit(writes []write, count int) error {
+	binary.LittleEndian.PutUint32(h.journal, uint32(len(writes)))
+	binary.LittleEndian.PutUint64(h.journal[8:], uint64(count))
+	end := journalHeader
+	for _, w := range writes {
+		binary.LittleEndian.PutUint64(h.journal[end:], uint64(w.slot))
+		end += 8 + copy(h.journal[end+8:], w.record)
+	}
+	binary.LittleEndian.PutUint32(h.journal[4:], crc32.Checksum(h.journal[8:end], castagnoli))
+	if _, err := h.f.WriteAt(h.journal[:end], headerSize); err != nil {
+		return err
+	}
+	if err := h.sync(); err != nil {
+		return err
+	}
+	return h.apply(writes, count)
+}
+
+// apply writes the journaled records to their slots and the count to the
+// header
+func (h *Heap) apply(writes []write, count int) error {
+	for _, w := range writes {
+		if _, err := h.f.WriteAt(w.record, h.slotsStart+int64(w.slot*h.recordSize())); err != nil {
+			return err
+		}
+	}
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], uint64(count))
+	if _, err := h.f.WriteAt(buf[:], 8); err != nil {
+		return err
+	}
+	if err := h.sync(); err != nil {
+		return err
+	}
+	h.count = count
+	return nil
+}
+
+// Push adds a record with *payload*, which must be PayloadSize() bytes long,
+// and *prio*, where smaller priorities are popped first
+func (h *Heap) Push(prio float64, payload []byte) error {
+	if len(payload) != h.size {
+		return ErrRecordSize
+	}
+	record := make([]byte, h.recordSize())
+	binary.LittleEndian.PutUint64(record, math.Float64bits(prio))
+	copy(record[8:], payload)
+
+	// Move parents down into the new slot until the record's place is found
+	var writes []write
+	i := h.count
+	for i > 0 {
+		parent, err := h.read((i - 1) / 2)
+		if err != nil {
+			return err
+		}
+		if priority(parent) <= prio {
+			break
+		}
+		writes = append(writes, write{i, parent})
+		i = (i - 1) / 2
+	}
+	writes = append(writes, write{i, record})
+	return h.commit(writes, h.count+1)
+}
+
+// Peek returns the priority and payload of the record with the smallest
+// priority
+func (h *Heap) Peek() (float64, []byte, error) {
+	if h.count == 0 {
+		return 0, nil, ErrEmpty
+	}
+	record, err := h.read(0)
+	if err != nil {
+		return 0, nil, err
+	}
+	return priority(record), record[8:], nil
+}
+
+// Pop removes and returns the record with the smallest priority
+func (h *Heap) Pop() (float64, []byte, error) {
+	prio, payload, err := h.Peek()
+	if err != nil {
+		return 0, nil, err
+	}
+	count := h.count - 1
+	if count == 0 {
+		return prio, payload, h.commit(nil, 0)
+	}
+	last, err := h.read(count)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// Move the last record down from the root, lifting the smaller child
+	// into its place, until neither child is smaller
+	var writes []write
+	i := 0
+	for {
+		child := 2*i + 1
+		if child >= count {
+			break
+		}
+		smaller, err := h.read(child)
+		if err != nil {
+			return 0, nil, err
+		}
+		if child+1 < count {
+			right, err := h.read(child + 1)
+			if err != nil {
+				return 0, nil, err
+			}
+			if priority(right) < priority(smaller) {
+				child, smaller = child+1, right
+			}
+		}
+		if priority(smaller) >= priority(last) {
+			break
+		}
+		writes = append(writes, write{i, smaller})
+		i = child
+	}
+	writes = append(writes, write{i, last})
+	return prio, payload, h.commit(writes, count)
+}
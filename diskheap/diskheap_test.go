@@ -0,0 +1,224 @@
+package diskheap
+
+import (
+	"errors"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+// memFile is a File in memory
+type memFile struct {
+	data []byte
+}
+
+func (m *memFile) ReadAt(p []byte, off int64) (int, error) {
+	if off >= int64(len(m.data)) {
+		return 0, errEOF
+	}
+	n := copy(p, m.data[off:])
+	if n < len(p) {
+		return n, errEOF
+	}
+	return n, nil
+}
+
+func (m *memFile) WriteAt(p []byte, off int64) (int, error) {
+	if end := int(off) + len(p); end > len(m.data) {
+		m.data = append(m.data, make([]byte, end-len(m.data))...)
+	}
+	return copy(m.data[off:], p), nil
+}
+
+var errEOF = errors.New("EOF")
+
+// crashFile passes writes through to a memFile until *limit* writes have been
+// made, and then writes only half of the next one and fails, as if the
+// process had died
+type crashFile struct {
+	*memFile
+	limit int
+}
+
+var errCrash = errors.New("crash")
+
+func (c *crashFile) WriteAt(p []byte, off int64) (int, error) {
+	if c.limit == 0 {
+		return 0, errCrash
+	}
+	c.limit--
+	if c.limit == 0 {
+		c.memFile.WriteAt(p[:len(p)/2], off)
+		return len(p) / 2, errCrash
+	}
+	return c.memFile.WriteAt(p, off)
+}
+
+// drain pops every record and returns the priorities, checking their order
+func drain(t *testing.T, h *Heap) []float64 {
+	var priorities []float64
+	for h.Len() != 0 {
+		prio, payload, err := h.Pop()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(priorities) != 0 && prio < priorities[len(priorities)-1] {
+			t.Fatal("out of order", priorities, prio)
+		}
+		if payload[0] != byte(prio) {
+			t.Fatal("payload does not match", prio, payload)
+		}
+		priorities = append(priorities, prio)
+	}
+	return priorities
+}
+
+func payload(prio float64) []byte {
+	return []byte{byte(prio), 0, 0}
+}
+
+func TestHeap(t *testing.T) {
+	h, err := Create(&memFile{}, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := h.Pop(); err != ErrEmpty {
+		t.Error(err)
+	}
+	if err := h.Push(1, []byte{1}); err != ErrRecordSize {
+		t.Error(err)
+	}
+	r := rand.New(rand.NewSource(1))
+	var expected []float64
+	for i := 0; i != 300; i++ {
+		prio := float64(r.Intn(100))
+		if err := h.Push(prio, payload(prio)); err != nil {
+			t.Fatal(err)
+		}
+		expected = append(expected, prio)
+	}
+	if prio, _, err := h.Peek(); err != nil || prio != 0 || h.Len() != 300 {
+		t.Error(prio, err, h.Len())
+	}
+	sort.Float64s(expected)
+	if got := drain(t, h); !equal(got, expected) {
+		t.Error(got)
+	}
+}
+
+func equal(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := Create(f, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, prio := range []float64{5, 3, 8, 1} {
+		h.Push(prio, payload(prio))
+	}
+	h.Pop()
+	f.Close()
+
+	f, err = os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	h, err = Open(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := drain(t, h); !equal(got, []float64{3, 5, 8}) {
+		t.Error(got)
+	}
+	if _, err := Open(&memFile{[]byte("not a heap file")}); !errors.Is(err, ErrCorrupt) {
+		t.Error(err)
+	}
+}
+
+func TestCrashRecovery(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for trial := 0; trial != 200; trial++ {
+		// Build a heap, then crash part way through one more operation
+		base := &memFile{}
+		h, _ := Create(base, 3)
+		var before []float64
+		n := 1 + r.Intn(40)
+		for i := 0; i != n; i++ {
+			prio := float64(r.Intn(50))
+			h.Push(prio, payload(prio))
+			before = append(before, prio)
+		}
+		sort.Float64s(before)
+		saved := append([]byte(nil), base.data...)
+
+		crash := &crashFile{&memFile{append([]byte(nil), saved...)}, 1 + r.Intn(8)}
+		h, _ = Open(crash.memFile)
+		h.f = crash
+		after := append([]float64(nil), before...)
+		if r.Intn(2) == 0 {
+			prio := float64(r.Intn(50))
+			h.Push(prio, payload(prio))
+			after = append(after, prio)
+			sort.Float64s(after)
+		} else {
+			h.Pop()
+			after = after[1:]
+		}
+
+		recovered, err := Open(crash.memFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := drain(t, recovered); !equal(got, before) && !equal(got, after) {
+			t.Fatal(trial, got, before, after)
+		}
+	}
+}
+
+func TestJournalIgnoredWhenTorn(t *testing.T) {
+	f := &memFile{}
+	h, _ := Create(f, 3)
+	h.Push(2, payload(2))
+	saved := append([]byte(nil), f.data...)
+	h.Push(1, payload(1))
+	// Restore the slots but keep a damaged copy of the new journal
+	journal := append([]byte(nil), f.data[headerSize:headerSize+journalHeader+8]...)
+	copy(f.data, saved)
+	copy(f.data[headerSize:], journal[:len(journal)-1])
+	recovered, err := Open(f)
+	if err != nil || recovered.Len() != 1 {
+		t.Fatal(err, recovered.Len())
+	}
+}
+
+func BenchmarkPushPop(b *testing.B) {
+	h, _ := Create(&memFile{}, 8)
+	r := rand.New(rand.NewSource(1))
+	p := make([]byte, 8)
+	for i := 0; i != 1000; i++ {
+		h.Push(r.Float64(), p)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		h.Push(r.Float64(), p)
+		h.Pop()
+	}
+}
@@ -0,0 +1,200 @@
+/*
+ * Package dlx implements Knuth's "dancing links" (DLX) and Algorithm X, which
+ * find every exact cover of a sparse 0/1 matrix.
+ *
+ * Given a matrix of zeros and ones, an exact cover is a set of rows which
+ * together have exactly one 1 in each column. Many puzzles reduce to it:
+ * in Sudoku, the columns are the constraints ("row 3 has a 7", "cell (3, 5)
+ * is filled", ...), and each row is one placement of a digit, with a 1 in
+ * each constraint the placement satisfies.
+ *
+ * Algorithm X is a plain backtracking search. It picks a column that is not
+ * yet covered, and tries each row with a 1 in that column in turn. Choosing a
+ * row covers its columns, so every other row with a 1 in those columns is
+ * removed from the matrix, and the search recurses on what is left. When a
+ * branch fails, the removals are undone.
+ *
+ * Dancing links makes removing and restoring rows cheap. Each 1 in the matrix
+ * is a node in two circular doubly-linked lists, one for its row and one for
+ * its column, and each column has a header node in a list of its own:
+ *
+ *    root <-> A <-> B <-> C <-> ...    column headers
+ *             |     |     |
+ *             x <---------x            a row with 1s in columns A and C
+ *             |     |
+ *             x <-> x                  a row with 1s in columns A and B
+ *
+ * A node x is removed from its list by linking its neighbours to each other,
+ *
+ *    x.left.right = x.right
+ *    x.right.left = x.left
+ *
+ * but x keeps its own pointers, so it can be put back in O(1) by
+ *
+ *    x.left.right = x
+ *    x.right.left = x
+ *
+ * as long as removals are undone in the reverse order, which is exactly what
+ * backtracking does. No memory is allocated during the search.
+ *
+ * The search picks the column with the fewest 1s, which keeps the number of
+ * branches small and detects dead ends early (a column with no 1s left can
+ * never be covered).
+ */
+
+package dlx
+
+import "fmt"
+
+// node is a 1 in the matrix, or a column header
+type node struct {
+	left, right, up, down *node
+	col                   *node
+	row                   int // row index, for 1s
+	size                  int // number of 1s in the column, for headers
+}
+
+// Matrix is a sparse 0/1 matrix of rows to choose from
+type Matrix struct {
+	root    node
+	columns []node
+	rows    int
+}
+
+// New creates a Matrix with *columns* columns and no rows
+func New(columns int) *Matrix {
+	m := &Matrix{columns: make([]node, columns)}
+	m.root.left, m.root.right = &m.root, &m.root
+	for i := range m.columns {
+		c := &m.columns[i]
+		c.up, c.down, c.col = c, c, c
+		c.left, c.right = m.root.left, &m.root
+		m.root.left.right = c
+		m.root.left = c
+	}
+	return m
+}
+
+// Rows returns the number of rows added
+func (m *Matrix) Rows() int {
+	return m.rows
+}
+
+// AddRow adds a row with 1s in the given columns, and returns its index. It
+// panics if a column is out of range or repeated.
+func (m *Matrix) AddRow(columns ...int) int {
+	row := m.rows
+	m.rows++
+	var first *node
+	seen := make(map[int]bool, len(columns))
+	for _, j := range columns {
+		if j < 0 || j >= len(m.columns) || seen[j] {
+			panic(fmt.Sprintf("dlx: invalid column %d in row %d", j, row))
+		}
+		seen[j] = true
+		c := &m.columns[j]
+		x := &node{col: c, row: row}
+		// Append x to the bottom of its column
+		x.up, x.down = c.up, c
+		c.up.down = x
+		c.up = x
+		c.size++
+		// Append x to the end of its row
+		if first == nil {
+			first = x
+			x.left, x.right = x, x
+		} else {
+			x.left, x.right = first.left, first
+			first.left.right = x
+			first.left = x
+		}
+	}
+	return row
+}
+
+// cover removes column *c* from the header list, and every row with a 1 in
+// *c* from the other columns
+func cover(c *node) {
+	c.right.left = c.left
+	c.left.right = c.right
+	for i := c.down; i != c; i = i.down {
+		for j := i.right; j != i; j = j.right {
+			j.down.up = j.up
+			j.up.down = j.down
+			j.col.size--
+		}
+	}
+}
+
+// uncover undoes cover, in exactly the reverse order
+func uncover(c *node) {
+	for i := c.up; i != c; i = i.up {
+		for j := i.left; j != i; j = j.left {
+			j.col.size++
+			j.down.up = j
+			j.up.down = j
+		}
+	}
+	c.right.left = c
+	c.left.right = c
+}
+
+// Solve calls *f* with the rows of each exact cover, until *f* returns false
+// or there are no more. The slice passed to *f* is reused, and must be copied
+// to be kept. Solve returns the number of covers found.
+func (m *Matrix) Solve(f func(rows []int) bool) int {
+	var rows []int
+	count := 0
+	m.search(&rows, &count, f)
+	return count
+}
+
+// search returns false if the search should stop
+func (m *Matrix) search(rows *[]int, count *int, f func([]int) bool) bool {
+	root := &m.root
+	if root.right == root {
+		*count++
+		return f(*rows)
+	}
+
+	// Choose the column with the fewest 1s
+	c := root.right
+	for j := c.right; j != root; j = j.right {
+		if j.size < c.size {
+			c = j
+		}
+	}
+	if c.size == 0 {
+		return true
+	}
+
+	cover(c)
+	for r := c.down; r != c; r = r.down {
+		*rows = append(*rows, r.row)
+		for j := r.right; j != r; j = j.right {
+			cover(j.col)
+		}
+		more := m.search(rows, count, f)
+		for j := r.left; j != r; j = j.left {
+			uncover(j.col)
+		}
+		*rows = (*rows)[:len(*rows)-1]
+		if !more {
+			uncover(c)
+			return false
+		}
+	}
+	uncover(c)
+	return true
+}
+
+// First returns the rows of the first exact cover found, or false if there is
+// none
+func (m *Matrix) First() ([]int, bool) {
+	var solution []int
+	found := m.Solve(func(rows []int) bool {
+		solution = append([]int(nil), rows...)
+		return false
+	})
+	return solution, found != 0
+}
@@ -0,0 +1,99 @@
+package dlx
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestKnuthExample(t *testing.T) {
+	// The example from Knuth's "Dancing Links" paper
+	m := New(7)
+	m.AddRow(2, 4, 5)
+	m.AddRow(0, 3, 6)
+	m.AddRow(1, 2, 5)
+	m.AddRow(0, 3)
+	m.AddRow(1, 6)
+	m.AddRow(3, 4, 6)
+
+	// Solving twice checks that the links are restored
+	for i := 0; i != 2; i++ {
+		rows, ok := m.First()
+		sort.Ints(rows)
+		if !ok || len(rows) != 3 || rows[0] != 0 || rows[1] != 3 || rows[2] != 4 {
+			t.Error(rows, ok)
+		}
+	}
+	if n := m.Solve(func([]int) bool { return true }); n != 1 {
+		t.Error(n)
+	}
+}
+
+func TestNoCover(t *testing.T) {
+	m := New(3)
+	m.AddRow(0, 1)
+	m.AddRow(1, 2)
+	if _, ok := m.First(); ok {
+		t.Fail()
+	}
+}
+
+func TestCountCovers(t *testing.T) {
+	// Covering n columns with rows holding one or two adjacent columns is
+	// counted by the Fibonacci numbers
+	n := 10
+	m := New(n)
+	for j := 0; j != n; j++ {
+		m.AddRow(j)
+		if j+1 != n {
+			m.AddRow(j, j+1)
+		}
+	}
+	if count := m.Solve(func([]int) bool { return true }); count != 89 {
+		t.Error(count)
+	}
+}
+
+// sudoku solves a puzzle given as 81 digits, with '.' for blanks. There are
+// four kinds of constraint, each with 81 columns: every cell is filled, and
+// every row, column and box has each digit.
+func sudoku(puzzle string) (string, bool) {
+	m := New(4 * 81)
+	type placement struct{ cell, digit int }
+	var placements []placement
+	for cell := 0; cell != 81; cell++ {
+		r, c := cell/9, cell%9
+		box := r/3*3 + c/3
+		for d := 0; d != 9; d++ {
+			if puzzle[cell] != '.' && int(puzzle[cell]-'1') != d {
+				continue
+			}
+			m.AddRow(cell, 81+r*9+d, 2*81+c*9+d, 3*81+box*9+d)
+			placements = append(placements, placement{cell, d})
+		}
+	}
+	rows, ok := m.First()
+	if !ok {
+		return "", false
+	}
+	grid := []byte(strings.Repeat(".", 81))
+	for _, row := range rows {
+		p := placements[row]
+		grid[p.cell] = byte('1' + p.digit)
+	}
+	return string(grid), true
+}
+
+func TestSudoku(t *testing.T) {
+	puzzle := "53..7....6..195....98....6.8...6...34..8.3..17...2...6.6....28....419..5....8..79"
+	solution := "534678912672195348198342567859761423426853791713924856961537284287419635345286179"
+	grid, ok := sudoku(puzzle)
+	if !ok || grid != solution {
+		t.Error(grid, ok)
+	}
+
+	// Two 5s in the first row
+	if _, ok := sudoku("55" + puzzle[2:]); ok {
+		t.Fail()
+	}
+}
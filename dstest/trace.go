@@ -0,0 +1,180 @@
+/*
+ * Package dstest provides tools for testing the data structures in this
+ * repository, and containers built on them.
+ *
+ * A bug that corrupts a data structure often shows itself only after a long
+ * and particular sequence of operations. To make such bugs reproducible, the
+ * operations on a container can be recorded as a trace, and the trace
+ * replayed later against the same or another container.
+ *
+ * A trace is a text file of JSON objects, one per line, each describing one
+ * operation on a set of int keys, and for deletions and lookups, whether the
+ * key was found when the trace was recorded:
+ *
+ *    {"op":"insert","key":5}
+ *    {"op":"lookup","key":5,"found":true}
+ *    {"op":"delete","key":7,"found":false}
+ *
+ * Being line-oriented, traces can be cut down by hand (or by bisection) to
+ * the operations that matter, and attached to a bug report. A container is
+ * recorded or replayed through the Set interface, so any container can take
+ * part by way of a small adapter (see cmd/dscompare for several).
+ */
+
+package dstest
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Kinds of operation
+const (
+	Insert = "insert"
+	Delete = "delete"
+	Lookup = "lookup"
+)
+
+// Set is a container of int keys. Inserting a key already present leaves the
+// container unchanged.
+type Set interface {
+	Insert(key int)
+	// Delete removes *key* and returns true if it was present
+	Delete(key int) bool
+	Contains(key int) bool
+}
+
+// Op is an operation in a trace. For deletions and lookups, Found records
+// whether the key was found, if known.
+type Op struct {
+	Kind  string `json:"op"`
+	Key   int    `json:"key"`
+	Found *bool  `json:"found,omitempty"`
+}
+
+// Apply performs an operation on *s*, and returns whether the key was found,
+// for deletions and lookups
+func Apply(s Set, op Op) (bool, error) {
+	switch op.Kind {
+	case Insert:
+		s.Insert(op.Key)
+		return false, nil
+	case Delete:
+		return s.Delete(op.Key), nil
+	case Lookup:
+		return s.Contains(op.Key), nil
+	}
+	return false, fmt.Errorf("unknown operation %q", op.Kind)
+}
+
+// Recorder is a Set that passes operations on to another Set, and writes
+// each of them to a trace
+type Recorder struct {
+	s   Set
+	enc *json.Encoder
+	err error
+}
+
+// Record returns a Recorder that passes operations on to *s* and writes the
+// trace to *w*
+func Record(s Set, w io.Writer) *Recorder {
+	return &Recorder{s: s, enc: json.NewEncoder(w)}
+}
+
+func (r *Recorder) record(op Op) bool {
+	found, _ := Apply(r.s, op)
+	if op.Kind != Insert {
+		op.Found = &found
+	}
+	if r.err == nil {
+		r.err = r.enc.Encode(op)
+	}
+	return found
+}
+
+func (r *Recorder) Insert(key int)        { r.record(Op{Kind: Insert, Key: key}) }
+func (r *Recorder) Delete(key int) bool   { return r.record(Op{Kind: Delete, Key: key}) }
+func (r *Recorder) Contains(key int) bool { return r.record(Op{Kind: Lookup, Key: key}) }
+
+// Err returns the first error writing the trace
+func (r *Recorder) Err() error {
+	return r.err
+}
+
+// WriteTrace writes operations as a trace
+func WriteTrace(w io.Writer, ops []Op) error {
+	enc := json.NewEncoder(w)
+	for _, op := range ops {
+		if err := enc.Encode(op); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadTrace reads all the operations of a trace
+func ReadTrace(r io.Reader) ([]Op, error) {
+	var ops []Op
+	err := scan(r, func(line int, op Op) error {
+		ops = append(ops, op)
+		return nil
+	})
+	return ops, err
+}
+
+// scan calls *f* with each operation of a trace and its line number, skipping
+// blank lines
+func scan(r io.Reader, f func(line int, op Op) error) error {
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var op Op
+		if err := json.Unmarshal(scanner.Bytes(), &op); err != nil {
+			return fmt.Errorf("line %d: %v", line, err)
+		}
+		if err := f(line, op); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// MismatchError reports an operation whose result differs from the one
+// recorded in the trace
+type MismatchError struct {
+	Line  int
+	Op    Op
+	Found bool
+}
+
+func (e *MismatchError) Error() string {
+	return fmt.Sprintf("line %d: %s %d found %v, but the trace recorded %v",
+		e.Line, e.Op.Kind, e.Op.Key, e.Found, *e.Op.Found)
+}
+
+// Replay applies the operations of a trace to *s* in order. It stops at the
+// first operation whose result differs from the one recorded, returning a
+// *MismatchError. If *check* is not nil, it is called after every operation
+// to verify the container's invariants, and replay stops at the first error
+// it returns.
+func Replay(r io.Reader, s Set, check func() error) error {
+	return scan(r, func(line int, op Op) error {
+		found, err := Apply(s, op)
+		if err != nil {
+			return fmt.Errorf("line %d: %v", line, err)
+		}
+		if op.Found != nil && *op.Found != found {
+			return &MismatchError{line, op, found}
+		}
+		if check != nil {
+			if err := check(); err != nil {
+				return fmt.Errorf("line %d: %s %d: %v", line, op.Kind, op.Key, err)
+			}
+		}
+		return nil
+	})
+}
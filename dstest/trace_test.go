@@ -0,0 +1,97 @@
+package dstest
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// mapSet is a Set backed by a map
+type mapSet map[int]bool
+
+func (s mapSet) Insert(key int) { s[key] = true }
+func (s mapSet) Delete(key int) bool {
+	found := s[key]
+	delete(s, key)
+	return found
+}
+func (s mapSet) Contains(key int) bool { return s[key] }
+
+// leakySet never deletes the key 3
+type leakySet struct {
+	mapSet
+}
+
+func (s leakySet) Delete(key int) bool {
+	if key == 3 {
+		return true
+	}
+	return s.mapSet.Delete(key)
+}
+
+func record(t *testing.T) string {
+	var buf bytes.Buffer
+	r := Record(mapSet{}, &buf)
+	r.Insert(3)
+	r.Insert(5)
+	if !r.Contains(3) || !r.Delete(3) || r.Delete(3) || r.Contains(3) {
+		t.Fatal("operations not passed on")
+	}
+	if r.Err() != nil {
+		t.Fatal(r.Err())
+	}
+	return buf.String()
+}
+
+func TestRecord(t *testing.T) {
+	trace := record(t)
+	expected := `{"op":"insert","key":3}
+{"op":"insert","key":5}
+{"op":"lookup","key":3,"found":true}
+{"op":"delete","key":3,"found":true}
+{"op":"delete","key":3,"found":false}
+{"op":"lookup","key":3,"found":false}
+`
+	if trace != expected {
+		t.Error(trace)
+	}
+	ops, err := ReadTrace(strings.NewReader(trace))
+	if err != nil || len(ops) != 6 || ops[3].Kind != Delete || !*ops[3].Found || ops[0].Found != nil {
+		t.Error(ops, err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTrace(&buf, ops); err != nil || buf.String() != trace {
+		t.Error(buf.String(), err)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	trace := record(t)
+	if err := Replay(strings.NewReader(trace), mapSet{}, nil); err != nil {
+		t.Error(err)
+	}
+
+	err := Replay(strings.NewReader(trace), leakySet{mapSet{}}, nil)
+	var mismatch *MismatchError
+	if !errors.As(err, &mismatch) || mismatch.Line != 5 || !mismatch.Found {
+		t.Error(err)
+	}
+
+	s := mapSet{}
+	err = Replay(strings.NewReader(trace), s, func() error {
+		if len(s) > 1 {
+			return errors.New("too many keys")
+		}
+		return nil
+	})
+	if err == nil || err.Error() != "line 2: insert 5: too many keys" {
+		t.Error(err)
+	}
+
+	err = Replay(strings.NewReader("\n{\"op\":\"insert\",\"key\":1}\n{\"op\":\"upsert\"}\n"), mapSet{}, nil)
+	if err == nil || !strings.HasPrefix(err.Error(), "line 3") {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,192 @@
+/*
+ * Package epoch implements epoch-based reclamation, which tells a lock-free
+ * structure when a node it has removed can no longer be reached by any
+ * reader, so that the node can be reused.
+ *
+ * In a lock-free structure, a reader may still be looking at a node after a
+ * writer has unlinked it. Go's garbage collector keeps such a node alive for
+ * as long as it is referenced, so it is always safe to drop it. But
+ * allocating a node per insertion and leaving each removed one to the
+ * collector costs allocation and collection work on every operation. Reusing
+ * nodes through a free list avoids that, but only once no reader can still
+ * hold the node: otherwise the reader would see it change under it, or a
+ * compare-and-swap could succeed against a recycled node that happens to
+ * hold the old pointer (the ABA problem).
+ *
+ * Epoch-based reclamation (Fraser, 2004) tracks this cheaply. A global epoch
+ * counter advances over time, and every goroutine announces the epoch it saw
+ * when it began an operation (it "pins" it) and when it finished. A node
+ * retired, after being unlinked, while the global epoch was e can only be
+ * held by operations pinned at epoch e or earlier. The epoch only advances
+ * when every pinned operation has seen the current epoch, so once it reaches
+ * e+2, every operation that could hold the node has finished, and it can be
+ * handed to the free function:
+ *
+ *    global epoch     e          e+1          e+2
+ *    reader A       [pinned at e ...]
+ *    writer B         unlink, retire(n)
+ *    reader C                  [pinned at e+1]
+ *                                             free(n): A and C cannot hold n
+ *
+ * Reader C cannot hold n, having started after n was unlinked, but it stops
+ * the epoch from passing e+2 until it finishes, which is why pinning must be
+ * short.
+ *
+ * Usage contract:
+ *
+ *  - Each goroutine uses its own Participant, from Domain.Register, and
+ *    never shares it.
+ *  - Every access to nodes of the structure happens between Pin and Unpin,
+ *    and no reference to a node is kept after Unpin.
+ *  - A node is retired only once it has been unlinked, so that no operation
+ *    that pins afterward can find it, and it is retired exactly once.
+ *  - A goroutine that stays pinned stops reclamation for every goroutine,
+ *    and one that stops using its Participant should Unregister it.
+ *
+ * Retired nodes wait in a per-participant queue, in the order they were
+ * retired, so recording one takes no locks. Advancing the epoch has to look
+ * at every participant, so it is attempted only every AdvanceInterval
+ * retirements rather than on every operation.
+ */
+
+package epoch
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/njwilson23/datastructures/queue"
+)
+
+// AdvanceInterval is the number of retirements by one participant between
+// its attempts to advance the epoch
+const AdvanceInterval = 64
+
+// pinned is set in a participant's announced epoch while it is pinned
+const pinned = 1
+
+type retired[T any] struct {
+	epoch uint64
+	item  T
+}
+
+// Domain is a global epoch shared by the participants in one or more
+// structures, and the function that frees the items they retire
+type Domain[T any] struct {
+	epoch        atomic.Uint64
+	free         func(T)
+	mu           sync.Mutex // guards participants and orphans
+	participants []*Participant[T]
+	orphans      queue.Ring[retired[T]]
+}
+
+// New creates a Domain, which passes each retired item to *free* once no
+// pinned participant can hold it
+func New[T any](free func(T)) *Domain[T] {
+	return &Domain[T]{free: free}
+}
+
+// Epoch returns the current global epoch
+func (d *Domain[T]) Epoch() uint64 {
+	return d.epoch.Load()
+}
+
+// Register returns a new Participant, for use by one goroutine
+func (d *Domain[T]) Register() *Participant[T] {
+	p := &Participant[T]{domain: d}
+	d.mu.Lock()
+	d.participants = append(d.participants, p)
+	d.mu.Unlock()
+	return p
+}
+
+// tryAdvance advances the global epoch if every pinned participant has seen
+// it, frees any orphaned items that have become safe, and returns the global
+// epoch
+func (d *Domain[T]) tryAdvance() uint64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	e := d.epoch.Load()
+	for _, p := range d.participants {
+		if local := p.local.Load(); local&pinned != 0 && local>>1 != e {
+			return e
+		}
+	}
+	// If another participant advanced the epoch meanwhile, it has done the
+	// work for us
+	if d.epoch.CompareAndSwap(e, e+1) {
+		e++
+	} else {
+		e = d.epoch.Load()
+	}
+	drain(&d.orphans, e, d.free)
+	return e
+}
+
+// drain frees the items at the front of *limbo* that were retired at least
+// two epochs before *e*
+func drain[T any](limbo *queue.Ring[retired[T]], e uint64, free func(T)) {
+	for {
+		r, err := limbo.Peek()
+		if err != nil || r.epoch+2 > e {
+			return
+		}
+		limbo.Pop()
+		free(r.item)
+	}
+}
+
+// Participant is one goroutine's view of a Domain
+type Participant[T any] struct {
+	domain *Domain[T]
+	local  atomic.Uint64 // announced epoch << 1, with the pinned bit
+	limbo  queue.Ring[retired[T]]
+	count  int // retirements since the last attempt to advance
+}
+
+// Pin announces that the goroutine is about to access the structure, and
+// frees items retired by this participant that have become safe to reuse
+func (p *Participant[T]) Pin() {
+	e := p.domain.epoch.Load()
+	p.local.Store(e<<1 | pinned)
+	drain(&p.limbo, e, p.domain.free)
+}
+
+// Unpin announces that the goroutine holds no references into the structure
+func (p *Participant[T]) Unpin() {
+	p.local.Store(p.local.Load() &^ pinned)
+}
+
+// Retire hands over *item*, which has been unlinked from the structure, to
+// be freed once no pinned participant can hold it
+func (p *Participant[T]) Retire(item T) {
+	p.limbo.Push(retired[T]{p.domain.epoch.Load(), item})
+	if p.count++; p.count == AdvanceInterval {
+		p.count = 0
+		drain(&p.limbo, p.domain.tryAdvance(), p.domain.free)
+	}
+}
+
+// Pending returns the number of items retired by this participant that have
+// not been freed yet
+func (p *Participant[T]) Pending() int {
+	return p.limbo.Len()
+}
+
+// Unregister removes the participant from its Domain. Its pending items are
+// freed by other participants once they are safe. The participant must be
+// unpinned, and must not be used afterward.
+func (p *Participant[T]) Unregister() {
+	d := p.domain
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for i, q := range d.participants {
+		if q == p {
+			d.participants = append(d.participants[:i], d.participants[i+1:]...)
+			break
+		}
+	}
+	for r, err := p.limbo.Pop(); err == nil; r, err = p.limbo.Pop() {
+		d.orphans.Push(r)
+	}
+}
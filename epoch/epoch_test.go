@@ -0,0 +1,178 @@
+package epoch
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestReclaim(t *testing.T) {
+	var freed []int
+	d := New(func(x int) { freed = append(freed, x) })
+	reader, writer := d.Register(), d.Register()
+
+	reader.Pin()
+	writer.Pin()
+	for i := 0; i != AdvanceInterval; i++ {
+		writer.Retire(i)
+	}
+	writer.Unpin()
+	// The epoch advanced once, since both were pinned at the current epoch,
+	// but the reader pins it there now
+	if d.Epoch() != 1 || len(freed) != 0 {
+		t.Fatal(d.Epoch(), freed)
+	}
+	for i := 0; i != AdvanceInterval; i++ {
+		writer.Retire(AdvanceInterval + i)
+	}
+	if d.Epoch() != 1 || len(freed) != 0 || writer.Pending() != 2*AdvanceInterval {
+		t.Fatal("advanced past a pinned reader", d.Epoch(), freed)
+	}
+
+	reader.Unpin()
+	for i := 0; i != 2*AdvanceInterval; i++ {
+		writer.Retire(-1)
+	}
+	// The epoch advanced twice, freeing everything retired at epochs 0 and 1,
+	// which includes the first batch of new items
+	if d.Epoch() != 3 || len(freed) != 3*AdvanceInterval {
+		t.Fatal(d.Epoch(), len(freed))
+	}
+	for i, x := range freed[:2*AdvanceInterval] {
+		if x != i {
+			t.Fatal("freed out of order", freed)
+		}
+	}
+}
+
+func TestUnregister(t *testing.T) {
+	freed := 0
+	d := New(func(int) { freed++ })
+	p, q := d.Register(), d.Register()
+	p.Pin()
+	p.Retire(1)
+	p.Unpin()
+	p.Unregister()
+	for i := 0; i != 3*AdvanceInterval; i++ {
+		q.Retire(0)
+	}
+	if freed < 1 || len(d.participants) != 1 {
+		t.Error(freed, len(d.participants))
+	}
+}
+
+// stack is a lock-free Treiber stack whose nodes are recycled through a free
+// list once reclaimed. Without reclamation, a popped node could be reused
+// while another goroutine is still looking at it.
+type stack struct {
+	head   atomic.Pointer[node]
+	domain *Domain[*node]
+	mu     sync.Mutex
+	spare  []*node
+}
+
+type node struct {
+	value int
+	next  *node
+}
+
+func newStack() *stack {
+	s := &stack{}
+	s.domain = New(func(n *node) {
+		s.mu.Lock()
+		s.spare = append(s.spare, n)
+		s.mu.Unlock()
+	})
+	return s
+}
+
+func (s *stack) alloc() *node {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.spare) == 0 {
+		return &node{}
+	}
+	n := s.spare[len(s.spare)-1]
+	s.spare = s.spare[:len(s.spare)-1]
+	return n
+}
+
+func (s *stack) push(p *Participant[*node], value int) {
+	n := s.alloc()
+	n.value = value
+	p.Pin()
+	defer p.Unpin()
+	for {
+		n.next = s.head.Load()
+		if s.head.CompareAndSwap(n.next, n) {
+			return
+		}
+	}
+}
+
+func (s *stack) pop(p *Participant[*node]) (int, bool) {
+	p.Pin()
+	defer p.Unpin()
+	for {
+		n := s.head.Load()
+		if n == nil {
+			return 0, false
+		}
+		if s.head.CompareAndSwap(n, n.next) {
+			value := n.value
+			p.Retire(n)
+			return value, true
+		}
+	}
+}
+
+func TestRecycling(t *testing.T) {
+	s := newStack()
+	const goroutines, rounds = 8, 20000
+	var sum atomic.Int64
+	var wg sync.WaitGroup
+	for g := 0; g != goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			p := s.domain.Register()
+			defer p.Unregister()
+			for i := 1; i <= rounds; i++ {
+				s.push(p, i)
+				if value, ok := s.pop(p); ok {
+					sum.Add(int64(value))
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	p := s.domain.Register()
+	for value, ok := s.pop(p); ok; value, ok = s.pop(p) {
+		sum.Add(int64(value))
+	}
+	// Every pushed value is popped exactly once
+	if expected := int64(goroutines * rounds * (rounds + 1) / 2); sum.Load() != expected {
+		t.Error(sum.Load(), expected)
+	}
+	if len(s.spare) == 0 {
+		t.Error("no nodes were recycled")
+	}
+}
+
+func BenchmarkPinUnpin(b *testing.B) {
+	p := New(func(int) {}).Register()
+	for i := 0; i < b.N; i++ {
+		p.Pin()
+		p.Unpin()
+	}
+}
+
+func BenchmarkRetire(b *testing.B) {
+	p := New(func(int) {}).Register()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		p.Pin()
+		p.Retire(i)
+		p.Unpin()
+	}
+}
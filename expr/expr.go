@@ -0,0 +1,397 @@
+/*
+ * Package expr parses arithmetic expressions with Dijkstra's shunting-yard
+ * algorithm, and evaluates them on a stack machine.
+ *
+ * Infix notation needs precedence and parentheses to say which operator
+ * applies first, but reverse Polish notation (RPN), in which each operator
+ * follows its operands, needs neither:
+ *
+ *    infix   2 * (x + 1) ^ 2
+ *    RPN     2 x 1 + 2 ^ *
+ *
+ * The shunting-yard algorithm converts one to the other in a single pass.
+ * Operands go straight to the output, and operators wait on a stack until
+ * the next operator to arrive binds less tightly, when they are moved to the
+ * output; a parenthesis holds back the operators below it until it is
+ * closed. Evaluating RPN then takes one more stack: each operand is pushed,
+ * and each operator pops its operands and pushes its result.
+ *
+ * The operators and functions are those registered with a Parser, each with
+ * its precedence and associativity, so the same machinery handles any
+ * arithmetic over float64. A symbol may be both a prefix and an infix
+ * operator, as "-" is, and which is meant is decided by its position: an
+ * operator where an operand is expected is a prefix operator.
+ */
+
+package expr
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	ErrSyntax  = errors.New("syntax error")
+	ErrUnknown = errors.New("unknown variable")
+)
+
+// Operator is an infix operator
+type Operator struct {
+	Precedence int  // higher binds more tightly
+	Right      bool // associates to the right, as a^b^c is a^(b^c)
+	Apply      func(x, y float64) (float64, error)
+}
+
+// Prefix is a prefix operator, such as negation
+type Prefix struct {
+	Precedence int
+	Apply      func(x float64) (float64, error)
+}
+
+// Function is a function called as name(arg, ...). An Arity of -1 accepts
+// any number of arguments.
+type Function struct {
+	Arity int
+	Apply func(args []float64) (float64, error)
+}
+
+// Parser converts infix expressions to Programs, using the operators and
+// functions registered with it
+type Parser struct {
+	infix     map[string]Operator
+	prefix    map[string]Prefix
+	functions map[string]Function
+	symbols   []string // operator symbols, sorted
+}
+
+// NewParser creates a Parser knowing the operators + - * / and ^ (power,
+// right associative), and prefix - and +
+func NewParser() *Parser {
+	p := &Parser{
+		infix:     make(map[string]Operator),
+		prefix:    make(map[string]Prefix),
+		functions: make(map[string]Function),
+	}
+	p.Infix("+", Operator{1, false, func(x, y float64) (float64, error) { return x + y, nil }})
+	p.Infix("-", Operator{1, false, func(x, y float64) (float64, error) { return x - y, nil }})
+	p.Infix("*", Operator{2, false, func(x, y float64) (float64, error) { return x * y, nil }})
+	p.Infix("/", Operator{2, false, func(x, y float64) (float64, error) { return x / y, nil }})
+	p.Infix("^", Operator{4, true, func(x, y float64) (float64, error) { return math.Pow(x, y), nil }})
+	// Negation binds less tightly than ^, so -2^2 is -4
+	p.Prefix("-", Prefix{3, func(x float64) (float64, error) { return -x, nil }})
+	p.Prefix("+", Prefix{3, func(x float64) (float64, error) { return x, nil }})
+	return p
+}
+
+// Infix registers *op* as the infix operator written *symbol*, replacing any
+// other. Symbols must not contain letters, digits, spaces, parentheses or
+// commas.
+func (p *Parser) Infix(symbol string, op Operator) {
+	p.addSymbol(symbol)
+	p.infix[symbol] = op
+}
+
+// Prefix registers *op* as the prefix operator written *symbol*, replacing
+// any other
+func (p *Parser) Prefix(symbol string, op Prefix) {
+	p.addSymbol(symbol)
+	p.prefix[symbol] = op
+}
+
+// Function registers *f* as the function called *name*, replacing any other
+func (p *Parser) Function(name string, f Function) {
+	p.functions[name] = f
+}
+
+// addSymbol records an operator symbol, so that Parse recognizes it
+func (p *Parser) addSymbol(symbol string) {
+	if symbol == "" || strings.ContainsFunc(symbol, func(r rune) bool {
+		return isIdent(r) || unicode.IsSpace(r) || r == '(' || r == ')' || r == ','
+	}) {
+		panic("expr: invalid operator symbol " + strconv.Quote(symbol))
+	}
+	i := sort.SearchStrings(p.symbols, symbol)
+	if i == len(p.symbols) || p.symbols[i] != symbol {
+		p.symbols = append(p.symbols, "")
+		copy(p.symbols[i+1:], p.symbols[i:])
+		p.symbols[i] = symbol
+	}
+}
+
+// isIdent returns true if *r* may appear in a variable or function name
+func isIdent(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// Instructions of a Program
+type opcode int
+
+const (
+	push opcode = iota
+	load
+	infix
+	prefix
+	call
+	paren // on the operator stack only
+)
+
+type instruction struct {
+	op     opcode
+	text   string
+	number float64
+	argc   int
+	infix  Operator
+	prefix Prefix
+	fn     Function
+}
+
+// Program is an expression in reverse Polish notation, ready to evaluate
+type Program struct {
+	code []instruction
+}
+
+// Parse converts the infix expression *s* to a Program. It returns an error
+// wrapping ErrSyntax, and giving the byte offset of the problem, if *s* is not
+// a well-formed expression.
+func (p *Parser) Parse(s string) (*Program, error) {
+	var out []instruction
+	// The operator stack holds operators waiting for their right operands,
+	// and open parentheses, each with the number of arguments seen so far
+	var ops []instruction
+	operand := true // whether an operand is expected next
+	opened := false // whether the last token was an opening parenthesis
+	fail := func(pos int, format string, args ...interface{}) (*Program, error) {
+		return nil, fmt.Errorf("%w at %d: %s", ErrSyntax, pos, fmt.Sprintf(format, args...))
+	}
+	for i := 0; i < len(s); {
+		c, size := utf8.DecodeRuneInString(s[i:])
+		if unicode.IsSpace(c) {
+			i += size
+			continue
+		}
+		wasOpened := opened
+		opened = false
+		switch {
+		case c >= '0' && c <= '9' || c == '.':
+			if !operand {
+				return fail(i, "unexpected number")
+			}
+			j := scanNumber(s, i)
+			x, err := strconv.ParseFloat(s[i:j], 64)
+			if err != nil {
+				return fail(i, "bad number %q", s[i:j])
+			}
+			out = append(out, instruction{op: push, text: s[i:j], number: x})
+			operand, i = false, j
+
+		case isIdent(c):
+			j := i
+			for j < len(s) {
+				r, size := utf8.DecodeRuneInString(s[j:])
+				if !isIdent(r) {
+					break
+				}
+				j += size
+			}
+			if !operand {
+				return fail(i, "unexpected %q", s[i:j])
+			}
+			name := s[i:j]
+			// A name followed by a parenthesis is a call
+			k := j
+			for k < len(s) && unicode.IsSpace(rune(s[k])) {
+				k++
+			}
+			if k < len(s) && s[k] == '(' {
+				fn, ok := p.functions[name]
+				if !ok {
+					return fail(i, "unknown function %q", name)
+				}
+				ops = append(ops, instruction{op: call, text: name, fn: fn})
+				i = k
+				continue
+			}
+			out = append(out, instruction{op: load, text: name})
+			operand, i = false, j
+
+		case c == '(':
+			if !operand {
+				return fail(i, "unexpected (")
+			}
+			ops = append(ops, instruction{op: paren, argc: 1})
+			opened, i = true, i+1
+
+		case c == ',' || c == ')':
+			if operand {
+				// Only a call may have an empty argument list
+				if c != ')' || !wasOpened || len(ops) < 2 || ops[len(ops)-2].op != call {
+					return fail(i, "missing operand before %q", c)
+				}
+				ops[len(ops)-1].argc = 0
+			}
+			for len(ops) != 0 && ops[len(ops)-1].op != paren {
+				out = append(out, ops[len(ops)-1])
+				ops = ops[:len(ops)-1]
+			}
+			if len(ops) == 0 {
+				return fail(i, "unmatched %q", c)
+			}
+			open := &ops[len(ops)-1]
+			if c == ',' {
+				if len(ops) < 2 || ops[len(ops)-2].op != call {
+					return fail(i, "unexpected ,")
+				}
+				open.argc++
+				operand, i = true, i+1
+				continue
+			}
+			argc := open.argc
+			ops = ops[:len(ops)-1]
+			if len(ops) != 0 && ops[len(ops)-1].op == call {
+				f := ops[len(ops)-1]
+				ops = ops[:len(ops)-1]
+				if f.fn.Arity >= 0 && f.fn.Arity != argc {
+					return fail(i, "%s takes %d arguments, not %d", f.text, f.fn.Arity, argc)
+				}
+				f.argc = argc
+				out = append(out, f)
+			} else if argc != 1 {
+				return fail(i, "unexpected )")
+			}
+			operand, i = false, i+1
+
+		default:
+			symbol := ""
+			for _, sym := range p.symbols {
+				if len(sym) > len(symbol) && strings.HasPrefix(s[i:], sym) {
+					symbol = sym
+				}
+			}
+			if operand {
+				op, ok := p.prefix[symbol]
+				if !ok {
+					return fail(i, "unexpected %q", c)
+				}
+				// A prefix operator applies to what follows, so it waits
+				// without moving others to the output
+				ops = append(ops, instruction{op: prefix, text: symbol, prefix: op})
+				i += len(symbol)
+				continue
+			}
+			op, ok := p.infix[symbol]
+			if !ok {
+				return fail(i, "unexpected %q", c)
+			}
+			// Operators that bind more tightly, or as tightly and associate
+			// to the left, are complete and move to the output
+			for len(ops) != 0 {
+				top := ops[len(ops)-1]
+				var precedence int
+				switch top.op {
+				case infix:
+					precedence = top.infix.Precedence
+				case prefix:
+					precedence = top.prefix.Precedence
+				default:
+					precedence = math.MinInt
+				}
+				if precedence < op.Precedence || precedence == op.Precedence && op.Right {
+					break
+				}
+				out = append(out, top)
+				ops = ops[:len(ops)-1]
+			}
+			ops = append(ops, instruction{op: infix, text: symbol, infix: op})
+			operand, i = true, i+len(symbol)
+		}
+	}
+	if operand {
+		return fail(len(s), "missing operand")
+	}
+	for len(ops) != 0 {
+		top := ops[len(ops)-1]
+		if top.op == paren {
+			return fail(len(s), "unclosed (")
+		}
+		out = append(out, top)
+		ops = ops[:len(ops)-1]
+	}
+	return &Program{out}, nil
+}
+
+// scanNumber returns the end of the number starting at *i* in *s*: digits
+// and points, then perhaps an exponent
+func scanNumber(s string, i int) int {
+	for i < len(s) && (s[i] >= '0' && s[i] <= '9' || s[i] == '.') {
+		i++
+	}
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		j := i + 1
+		if j < len(s) && (s[j] == '+' || s[j] == '-') {
+			j++
+		}
+		if j < len(s) && s[j] >= '0' && s[j] <= '9' {
+			for j < len(s) && s[j] >= '0' && s[j] <= '9' {
+				j++
+			}
+			i = j
+		}
+	}
+	return i
+}
+
+// String returns the program in reverse Polish notation, with each call
+// written name/argc
+func (prog *Program) String() string {
+	words := make([]string, len(prog.code))
+	for i, in := range prog.code {
+		words[i] = in.text
+		if in.op == call {
+			words[i] += "/" + strconv.Itoa(in.argc)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// Eval runs the program with the values of its variables taken from *vars*.
+// It returns an error wrapping ErrUnknown for a variable missing from *vars*,
+// and any error returned by an operator or function.
+func (prog *Program) Eval(vars map[string]float64) (float64, error) {
+	stack := make([]float64, 0, 8)
+	for _, in := range prog.code {
+		var x float64
+		var err error
+		switch in.op {
+		case push:
+			x = in.number
+		case load:
+			var ok bool
+			if x, ok = vars[in.text]; !ok {
+				return 0, fmt.Errorf("%w: %s", ErrUnknown, in.text)
+			}
+		case infix:
+			n := len(stack)
+			x, err = in.infix.Apply(stack[n-2], stack[n-1])
+			stack = stack[:n-2]
+		case prefix:
+			n := len(stack)
+			x, err = in.prefix.Apply(stack[n-1])
+			stack = stack[:n-1]
+		case call:
+			args := stack[len(stack)-in.argc:]
+			x, err = in.fn.Apply(args)
+			stack = stack[:len(stack)-in.argc]
+		}
+		if err != nil {
+			return 0, err
+		}
+		stack = append(stack, x)
+	}
+	return stack[0], nil
+}
@@ -0,0 +1,125 @@
+package expr
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	p := NewParser()
+	p.Function("max", Function{-1, func(args []float64) (float64, error) {
+		m := math.Inf(-1)
+		for _, x := range args {
+			m = math.Max(m, x)
+		}
+		return m, nil
+	}})
+	p.Function("pi", Function{0, func([]float64) (float64, error) { return math.Pi, nil }})
+	for _, c := range []struct {
+		expr, rpn string
+		value     float64
+	}{
+		{"1 + 2 * 3", "1 2 3 * +", 7},
+		{"(1 + 2) * 3", "1 2 + 3 *", 9},
+		{"8 - 4 - 2", "8 4 - 2 -", 2},
+		{"2 ^ 3 ^ 2", "2 3 2 ^ ^", 512},
+		{"-2 ^ 2", "2 2 ^ -", -4},
+		{"2 * -x", "2 x - *", -6},
+		{"- -x", "x - -", 3},
+		{"2 * (x + 1) ^ 2", "2 x 1 + 2 ^ *", 32},
+		{"max(1, x * 2, 4)", "1 x 2 * 4 max/3", 6},
+		{"max(x) + pi()", "x max/1 pi/0 +", 3 + math.Pi},
+		{"1.5e1 / .5", "1.5e1 .5 /", 30},
+		{"((x))", "x", 3},
+	} {
+		prog, err := p.Parse(c.expr)
+		if err != nil {
+			t.Error(c.expr, err)
+			continue
+		}
+		if prog.String() != c.rpn {
+			t.Error(c.expr, prog)
+		}
+		if v, err := prog.Eval(map[string]float64{"x": 3}); err != nil || v != c.value {
+			t.Error(c.expr, v, err)
+		}
+	}
+}
+
+func TestSyntaxErrors(t *testing.T) {
+	p := NewParser()
+	p.Function("f", Function{1, func(args []float64) (float64, error) { return args[0], nil }})
+	for _, expr := range []string{
+		"", "1 +", "* 1", "1 2", "(1", "1)", "()", "(1, 2)", "f()", "f(1, 2)",
+		"g(1)", "1 $ 2", "x y", "f(1,)", "1..2", "(1)(2)",
+	} {
+		if _, err := p.Parse(expr); !errors.Is(err, ErrSyntax) {
+			t.Errorf("%q: %v", expr, err)
+		}
+	}
+}
+
+func TestPluggableOperators(t *testing.T) {
+	p := NewParser()
+	failure := errors.New("division by zero")
+	p.Infix("/", Operator{2, false, func(x, y float64) (float64, error) {
+		if y == 0 {
+			return 0, failure
+		}
+		return x / y, nil
+	}})
+	// "**" is matched before "*"
+	p.Infix("**", Operator{4, true, func(x, y float64) (float64, error) { return math.Pow(x, y), nil }})
+	p.Infix("<", Operator{0, false, func(x, y float64) (float64, error) {
+		if x < y {
+			return 1, nil
+		}
+		return 0, nil
+	}})
+	p.Prefix("!", Prefix{3, func(x float64) (float64, error) {
+		if x == 0 {
+			return 1, nil
+		}
+		return 0, nil
+	}})
+	prog, err := p.Parse("2**3*2 < 17")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, err := prog.Eval(nil); err != nil || v != 1 || prog.String() != "2 3 ** 2 * 17 <" {
+		t.Error(prog, v, err)
+	}
+	prog, _ = p.Parse("!(1 < 0) / y")
+	if _, err := prog.Eval(map[string]float64{"y": 0}); err != failure {
+		t.Error(err)
+	}
+	if _, err := prog.Eval(nil); !errors.Is(err, ErrUnknown) {
+		t.Error(err)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("accepted a symbol with a letter")
+		}
+	}()
+	p.Infix("mod", Operator{})
+}
+
+func BenchmarkEval(b *testing.B) {
+	p := NewParser()
+	prog, err := p.Parse("(a + b) * (c - d) / (a ^ 2 + b ^ 2) - -c")
+	if err != nil {
+		b.Fatal(err)
+	}
+	vars := map[string]float64{"a": 1, "b": 2, "c": 3, "d": 4}
+	b.Run("Parse", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			p.Parse("(a + b) * (c - d) / (a ^ 2 + b ^ 2) - -c")
+		}
+	})
+	b.Run("Eval", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			prog.Eval(vars)
+		}
+	})
+}
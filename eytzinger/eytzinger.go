@@ -0,0 +1,145 @@
+/*
+ * Package eytzinger implements a static ordered map stored in an implicit
+ * binary tree in Eytzinger (breadth-first) order, for fast lookups in a key
+ * set that does not change.
+ *
+ * A red-black tree (see package rbtree) spends most of a lookup waiting for
+ * memory: each node is a separate allocation, so each step down the tree is a
+ * likely cache miss, and three pointers and a color per key waste space.
+ * Binary search over a sorted array needs no pointers, but its first few
+ * probes are spread across the whole array, and the branch on each
+ * comparison is unpredictable.
+ *
+ * The Eytzinger layout (after the 16th century genealogist's numbering of
+ * ancestors) stores a complete binary search tree in an array in breadth-first
+ * order: the root at index 1, and the children of index k at 2k and 2k+1, so
+ * no pointers are needed. The keys of the first levels, which every search
+ * visits, share a few cache lines, and the children of a node are adjacent:
+ *
+ *    sorted    1 2 3 4 5 6 7
+ *
+ *                 4
+ *               /   \          index   1 2 3 4 5 6 7
+ *              2     6         key     4 2 6 1 3 5 7
+ *             / \   / \
+ *            1   3 5   7
+ *
+ * A search only moves down, to 2k or 2k+1 depending on one comparison,
+ * which the compiler can turn into a conditional move rather than a branch,
+ * so mispredictions cost nothing. When the search falls off the bottom of the
+ * tree, the last node at which it went left holds the smallest key no less
+ * than the one searched for. Undoing the right turns taken since, which are
+ * the trailing one bits of k, recovers that node.
+ */
+
+package eytzinger
+
+import (
+	"errors"
+	"math/bits"
+)
+
+var ErrUnsorted = errors.New("keys are not sorted and unique")
+
+// Map is a static map from int keys to values of type V
+type Map[V any] struct {
+	keys   []int // keys[0] is unused
+	values []V
+}
+
+// FromSortedKeys creates a Map from *keys*, which must be sorted and unique,
+// and the corresponding *values*, which may be nil to map every key to the
+// zero value of V
+func FromSortedKeys[V any](keys []int, values []V) (*Map[V], error) {
+	for i := 1; i < len(keys); i++ {
+		if keys[i-1] >= keys[i] {
+			return nil, ErrUnsorted
+		}
+	}
+	if values != nil && len(values) != len(keys) {
+		panic("eytzinger: number of values differs from number of keys")
+	}
+	m := &Map[V]{keys: make([]int, len(keys)+1), values: make([]V, len(keys)+1)}
+	// An in-order walk of the implicit tree visits the positions in key order
+	i := 0
+	var fill func(k int)
+	fill = func(k int) {
+		if k >= len(m.keys) {
+			return
+		}
+		fill(2 * k)
+		m.keys[k] = keys[i]
+		if values != nil {
+			m.values[k] = values[i]
+		}
+		i++
+		fill(2*k + 1)
+	}
+	fill(1)
+	return m, nil
+}
+
+// Len returns the number of keys in the map
+func (m *Map[V]) Len() int {
+	return len(m.keys) - 1
+}
+
+// lowerBound returns the index of the smallest key no less than *key*, or 0
+// if there is none
+func (m *Map[V]) lowerBound(key int) int {
+	k := 1
+	for k < len(m.keys) {
+		right := 0
+		if m.keys[k] < key {
+			right = 1
+		}
+		k = 2*k + right
+	}
+	// Drop the trailing right turns, and the last left turn
+	return k >> (bits.TrailingZeros(^uint(k)) + 1)
+}
+
+// Get returns the value for *key*, or false if the key is not in the map
+func (m *Map[V]) Get(key int) (V, bool) {
+	if k := m.lowerBound(key); k != 0 && m.keys[k] == key {
+		return m.values[k], true
+	}
+	var zero V
+	return zero, false
+}
+
+// Contains returns true if *key* is in the map
+func (m *Map[V]) Contains(key int) bool {
+	k := m.lowerBound(key)
+	return k != 0 && m.keys[k] == key
+}
+
+// Ceiling returns the smallest key in the map that is no less than *key*, or
+// false if there is none
+func (m *Map[V]) Ceiling(key int) (int, bool) {
+	if k := m.lowerBound(key); k != 0 {
+		return m.keys[k], true
+	}
+	return 0, false
+}
+
+// Range calls *f* with each key and value in ascending order of key, until
+// *f* returns false
+func (m *Map[V]) Range(f func(key int, value V) bool) {
+	// Walk in order with an explicit stack of nodes whose right subtrees
+	// remain
+	var stack []int
+	for k := 1; k < len(m.keys) || len(stack) != 0; {
+		if k < len(m.keys) {
+			stack = append(stack, k)
+			k = 2 * k
+			continue
+		}
+		k = stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if !f(m.keys[k], m.values[k]) {
+			return
+		}
+		k = 2*k + 1
+	}
+}
@@ -0,0 +1,170 @@
+/*
+ * Package gapbuffer implements a gap buffer, the sequence type behind many
+ * text editors.
+ *
+ * Edits to a document tend to be clustered: a user types or deletes a run of
+ * characters in one place before moving elsewhere. A gap buffer stores the
+ * sequence in an array with a block of unused space, the "gap", at the
+ * cursor:
+ *
+ *    h e l l o _ _ _ _ w o r l d
+ *              ^ gap   ^
+ *
+ * Inserting at the cursor writes into the start of the gap, and deleting
+ * next to the cursor widens it, so both are O(1) per element. Moving the
+ * cursor moves the gap with it, by copying the elements in between from one
+ * side to the other, which costs O(d) for a move of distance d. When the gap
+ * fills up, the array is reallocated with twice the space, so insertion is
+ * O(1) amortized.
+ *
+ * A rope (a balanced tree of strings) makes edits O(log n) anywhere, but a
+ * gap buffer is far simpler, stores the sequence almost contiguously, and is
+ * just as fast for the local edits that dominate interactive editing.
+ */
+
+package gapbuffer
+
+// Buffer is a sequence with a cursor, at which insertions and deletions are
+// cheap. A Buffer[rune] can hold text.
+type Buffer[T any] struct {
+	data     []T
+	gapStart int // position of the cursor
+	gapEnd   int // first element after the gap
+}
+
+// New creates an empty Buffer with room for *capacity* elements before it
+// needs to grow
+func New[T any](capacity int) *Buffer[T] {
+	return &Buffer[T]{data: make([]T, capacity), gapEnd: capacity}
+}
+
+// FromSlice creates a Buffer holding a copy of *values*, with the cursor at
+// the end
+func FromSlice[T any](values []T) *Buffer[T] {
+	b := New[T](len(values))
+	b.Insert(values...)
+	return b
+}
+
+// Len returns the number of elements in the buffer
+func (b *Buffer[T]) Len() int {
+	return len(b.data) - (b.gapEnd - b.gapStart)
+}
+
+// Cursor returns the position of the cursor, between 0 and Len()
+func (b *Buffer[T]) Cursor() int {
+	return b.gapStart
+}
+
+// MoveTo moves the cursor to position *pos*, which must be between 0 and
+// Len()
+func (b *Buffer[T]) MoveTo(pos int) {
+	if pos < 0 || pos > b.Len() {
+		panic("gapbuffer: cursor out of range")
+	}
+	// The elements between the cursor and *pos* move to the other side of
+	// the gap, and the places they leave become part of the gap
+	if pos < b.gapStart {
+		n := b.gapStart - pos
+		copy(b.data[b.gapEnd-n:b.gapEnd], b.data[pos:b.gapStart])
+		b.clear(pos, pos+min(n, b.gapEnd-b.gapStart))
+		b.gapStart, b.gapEnd = pos, b.gapEnd-n
+	} else if pos > b.gapStart {
+		n := pos - b.gapStart
+		copy(b.data[b.gapStart:b.gapStart+n], b.data[b.gapEnd:b.gapEnd+n])
+		b.clear(b.gapEnd+n-min(n, b.gapEnd-b.gapStart), b.gapEnd+n)
+		b.gapStart, b.gapEnd = pos, b.gapEnd+n
+	}
+}
+
+// Move moves the cursor by *delta* positions, stopping at either end
+func (b *Buffer[T]) Move(delta int) {
+	pos := b.gapStart + delta
+	if pos < 0 {
+		pos = 0
+	} else if pos > b.Len() {
+		pos = b.Len()
+	}
+	b.MoveTo(pos)
+}
+
+// grow makes room for at least *n* more elements
+func (b *Buffer[T]) grow(n int) {
+	size := 2 * len(b.data)
+	if size < b.Len()+n {
+		size = b.Len() + n
+	}
+	data := make([]T, size)
+	copy(data, b.data[:b.gapStart])
+	after := len(b.data) - b.gapEnd
+	copy(data[size-after:], b.data[b.gapEnd:])
+	b.data, b.gapEnd = data, size-after
+}
+
+// Insert inserts *values* at the cursor, and moves the cursor past them
+func (b *Buffer[T]) Insert(values ...T) {
+	if b.gapEnd-b.gapStart < len(values) {
+		b.grow(len(values))
+	}
+	b.gapStart += copy(b.data[b.gapStart:], values)
+}
+
+// Delete removes up to *n* elements after the cursor, and returns the number
+// removed
+func (b *Buffer[T]) Delete(n int) int {
+	if n > len(b.data)-b.gapEnd {
+		n = len(b.data) - b.gapEnd
+	}
+	if n <= 0 {
+		return 0
+	}
+	b.gapEnd += n
+	b.clear(b.gapEnd-n, b.gapEnd)
+	return n
+}
+
+// Backspace removes up to *n* elements before the cursor, and returns the
+// number removed
+func (b *Buffer[T]) Backspace(n int) int {
+	if n > b.gapStart {
+		n = b.gapStart
+	}
+	if n <= 0 {
+		return 0
+	}
+	b.gapStart -= n
+	b.clear(b.gapStart, b.gapStart+n)
+	return n
+}
+
+// clear zeroes part of the gap, so that it does not keep values reachable
+func (b *Buffer[T]) clear(from, to int) {
+	var zero T
+	for i := from; i != to; i++ {
+		b.data[i] = zero
+	}
+}
+
+// At returns the element at position *i*
+func (b *Buffer[T]) At(i int) T {
+	if i < 0 || i >= b.Len() {
+		panic("gapbuffer: index out of range")
+	}
+	if i >= b.gapStart {
+		i += b.gapEnd - b.gapStart
+	}
+	return b.data[i]
+}
+
+// Parts returns the elements before and after the cursor, without copying.
+// The slices are only valid until the buffer is next modified.
+func (b *Buffer[T]) Parts() ([]T, []T) {
+	return b.data[:b.gapStart:b.gapStart], b.data[b.gapEnd:]
+}
+
+// Slice returns a copy of the contents of the buffer
+func (b *Buffer[T]) Slice() []T {
+	before, after := b.Parts()
+	values := make([]T, 0, len(before)+len(after))
+	return append(append(values, before...), after...)
+}
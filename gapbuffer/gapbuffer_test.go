@@ -0,0 +1,118 @@
+package gapbuffer
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestEditing(t *testing.T) {
+	b := FromSlice([]rune("hello world"))
+	if b.Cursor() != 11 || b.Len() != 11 {
+		t.Error(b.Cursor(), b.Len())
+	}
+	b.MoveTo(5)
+	b.Insert([]rune(",")...)
+	b.Move(100)
+	b.Insert('!')
+	if s := string(b.Slice()); s != "hello, world!" {
+		t.Error(s)
+	}
+	b.MoveTo(0)
+	if n := b.Delete(7); n != 7 {
+		t.Error(n)
+	}
+	b.Insert([]rune("goodbye, ")...)
+	if s := string(b.Slice()); s != "goodbye, world!" {
+		t.Error(s)
+	}
+	if n := b.Backspace(100); n != 9 || b.Cursor() != 0 {
+		t.Error(n, b.Cursor())
+	}
+	if b.At(0) != 'w' || b.At(5) != '!' {
+		t.Error(string(b.At(0)), string(b.At(5)))
+	}
+	before, after := b.Parts()
+	if len(before) != 0 || string(after) != "world!" {
+		t.Error(string(before), string(after))
+	}
+}
+
+func TestRandomEdits(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	b := New[int](0)
+	var expected []int
+	cursor := 0
+	for i := 0; i != 5000; i++ {
+		switch r.Intn(4) {
+		case 0:
+			values := make([]int, r.Intn(5))
+			for j := range values {
+				values[j] = r.Int()
+			}
+			b.Insert(values...)
+			expected = append(expected[:cursor], append(values, expected[cursor:]...)...)
+			cursor += len(values)
+		case 1:
+			n := b.Delete(r.Intn(4))
+			expected = append(expected[:cursor], expected[cursor+n:]...)
+		case 2:
+			n := b.Backspace(r.Intn(4))
+			expected = append(expected[:cursor-n], expected[cursor:]...)
+			cursor -= n
+		case 3:
+			cursor = r.Intn(len(expected) + 1)
+			b.MoveTo(cursor)
+		}
+		if b.Len() != len(expected) || b.Cursor() != cursor {
+			t.Fatal(b.Len(), len(expected), b.Cursor(), cursor)
+		}
+	}
+	got := b.Slice()
+	for i := range expected {
+		if got[i] != expected[i] || b.At(i) != expected[i] {
+			t.Fatal("mismatch at", i)
+		}
+	}
+}
+
+func TestGapCleared(t *testing.T) {
+	// Values that leave the buffer must not stay reachable through the gap
+	b := New[*int](4)
+	for i := 0; i != 4; i++ {
+		b.Insert(new(int))
+	}
+	b.MoveTo(1)
+	b.Delete(2)
+	b.MoveTo(2)
+	b.MoveTo(0)
+	for i := b.gapStart; i != b.gapEnd; i++ {
+		if b.data[i] != nil {
+			t.Fatal("gap holds a value at", i)
+		}
+	}
+}
+
+func TestMoveOutOfRange(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic")
+		}
+	}()
+	New[byte](4).MoveTo(1)
+}
+
+func BenchmarkTyping(b *testing.B) {
+	text := []rune("The quick brown fox jumps over the lazy dog. ")
+	buf := New[rune](0)
+	for i := 0; i != 10000; i++ {
+		buf.Insert(text...)
+	}
+	r := rand.New(rand.NewSource(1))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// Jump a short distance, type a word, and delete part of it
+		buf.Move(r.Intn(200) - 100)
+		buf.Insert(text[:10]...)
+		buf.Backspace(8)
+	}
+}
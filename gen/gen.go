@@ -0,0 +1,141 @@
+/*
+ * Package gen generates reproducible random datasets for tests and
+ * benchmarks.
+ *
+ * The performance of a data structure often depends on the shape of its
+ * input as much as on its size: a skip-list or tree behaves differently on
+ * keys drawn uniformly than on keys where a few values dominate, and a graph
+ * algorithm behaves differently on a graph where degrees are spread evenly
+ * than on one with a few heavily connected hubs. Each function here produces
+ * one such shape.
+ *
+ * A Generator is seeded explicitly, so the same seed always produces the same
+ * data and benchmark results can be compared between runs.
+ */
+
+package gen
+
+import (
+	"math/rand"
+
+	"github.com/njwilson23/datastructures/graph"
+)
+
+// Generator produces random datasets from a seeded source
+type Generator struct {
+	r *rand.Rand
+}
+
+// New creates a Generator. Generators with the same seed produce the same
+// sequence of datasets.
+func New(seed int64) *Generator {
+	return &Generator{rand.New(rand.NewSource(seed))}
+}
+
+// Uniform returns *n* integers drawn uniformly from [0, max)
+func (g *Generator) Uniform(n, max int) []int {
+	data := make([]int, n)
+	for i := range data {
+		data[i] = g.r.Intn(max)
+	}
+	return data
+}
+
+// Perm returns a random permutation of the integers [0, n)
+func (g *Generator) Perm(n int) []int {
+	return g.r.Perm(n)
+}
+
+// Zipf returns *n* integers in [0, max) following Zipf's law: the probability
+// of drawing k is proportional to 1/(k+1)^s, so small values are drawn far
+// more often than large ones. The exponent *s* must be greater than 1.
+func (g *Generator) Zipf(n, max int, s float64) []int {
+	z := rand.NewZipf(g.r, s, 1, uint64(max-1))
+	data := make([]int, n)
+	for i := range data {
+		data[i] = int(z.Uint64())
+	}
+	return data
+}
+
+// Clustered returns *n* points in *dims* dimensions, gathered around
+// *clusters* centres placed uniformly in the unit cube. Each coordinate is
+// offset from its centre by a normally distributed amount with standard
+// deviation *spread*.
+func (g *Generator) Clustered(n, dims, clusters int, spread float64) [][]float64 {
+	centres := make([][]float64, clusters)
+	for i := range centres {
+		centres[i] = make([]float64, dims)
+		for d := range centres[i] {
+			centres[i][d] = g.r.Float64()
+		}
+	}
+	points := make([][]float64, n)
+	for i := range points {
+		centre := centres[g.r.Intn(clusters)]
+		points[i] = make([]float64, dims)
+		for d := range points[i] {
+			points[i][d] = centre[d] + g.r.NormFloat64()*spread
+		}
+	}
+	return points
+}
+
+// ErdosRenyi returns a graph on vertices 0..n-1 in which every possible edge
+// is present independently with probability *p*, with a weight drawn
+// uniformly from [0, 1). Degrees are binomially distributed, so most vertices
+// have close to the average degree.
+func (g *Generator) ErdosRenyi(n int, p float64, directed bool) *graph.Graph[int] {
+	gr := graph.New[int](directed)
+	for i := 0; i != n; i++ {
+		gr.AddVertex(i)
+	}
+	for i := 0; i != n; i++ {
+		start := 0
+		if !directed {
+			start = i + 1
+		}
+		for j := start; j < n; j++ {
+			if i != j && g.r.Float64() < p {
+				gr.AddEdge(i, j, g.r.Float64())
+			}
+		}
+	}
+	return gr
+}
+
+// BarabasiAlbert returns an undirected graph on vertices 0..n-1 grown by
+// preferential attachment: starting from a complete graph on *m* + 1 vertices,
+// each new vertex is joined to *m* existing vertices chosen with probability
+// proportional to their degree. This produces a "scale-free" graph in which a
+// few hubs have very high degree, as in many real networks. Edge weights are
+// drawn uniformly from [0, 1).
+func (g *Generator) BarabasiAlbert(n, m int) *graph.Graph[int] {
+	gr := graph.New[int](false)
+	// Every vertex appears in ends once per incident edge, so drawing from it
+	// uniformly picks vertices in proportion to their degree
+	var ends []int
+	for i := 0; i <= m && i < n; i++ {
+		gr.AddVertex(i)
+		for j := 0; j != i; j++ {
+			gr.AddEdge(j, i, g.r.Float64())
+			ends = append(ends, i, j)
+		}
+	}
+	for i := m + 1; i < n; i++ {
+		gr.AddVertex(i)
+		chosen := make(map[int]bool, m)
+		targets := make([]int, 0, m)
+		for len(targets) != m {
+			if j := ends[g.r.Intn(len(ends))]; !chosen[j] {
+				chosen[j] = true
+				targets = append(targets, j)
+			}
+		}
+		for _, j := range targets {
+			gr.AddEdge(j, i, g.r.Float64())
+			ends = append(ends, i, j)
+		}
+	}
+	return gr
+}
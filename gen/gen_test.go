@@ -0,0 +1,139 @@
+package gen
+
+import (
+	"testing"
+)
+
+func slicesEqual(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestReproducible(t *testing.T) {
+	if !slicesEqual(New(3).Uniform(100, 50), New(3).Uniform(100, 50)) {
+		t.Error("same seed produced different data")
+	}
+	if slicesEqual(New(3).Uniform(100, 50), New(4).Uniform(100, 50)) {
+		t.Error("different seeds produced the same data")
+	}
+	g1, g2 := New(5).BarabasiAlbert(200, 3), New(5).BarabasiAlbert(200, 3)
+	e1, e2 := g1.Edges(), g2.Edges()
+	if len(e1) != len(e2) {
+		t.Fatal("same seed produced different graphs")
+	}
+	for i := range e1 {
+		if e1[i].From != e2[i].From || e1[i].To != e2[i].To || e1[i].Weight != e2[i].Weight {
+			t.Fatal("same seed produced different graphs")
+		}
+	}
+}
+
+func TestUniform(t *testing.T) {
+	for _, x := range New(1).Uniform(1000, 10) {
+		if x < 0 || x >= 10 {
+			t.Fatal(x)
+		}
+	}
+}
+
+func TestPerm(t *testing.T) {
+	seen := make([]bool, 100)
+	for _, x := range New(1).Perm(100) {
+		if seen[x] {
+			t.Fatal(x)
+		}
+		seen[x] = true
+	}
+}
+
+func TestZipf(t *testing.T) {
+	counts := make([]int, 100)
+	for _, x := range New(1).Zipf(10000, 100, 1.5) {
+		if x < 0 || x >= 100 {
+			t.Fatal(x)
+		}
+		counts[x]++
+	}
+	if counts[0] < counts[1] || counts[1] < counts[10] || counts[10] < counts[99] {
+		t.Error(counts)
+	}
+}
+
+func TestClustered(t *testing.T) {
+	points := New(1).Clustered(500, 3, 4, 0.01)
+	if len(points) != 500 || len(points[0]) != 3 {
+		t.Fatal(len(points))
+	}
+	// With a small spread, points fall into a few tight groups, so most
+	// pairs of points are either very close or far apart
+	near := 0
+	for _, p := range points[1:] {
+		dist := 0.0
+		for d := range p {
+			dist += (p[d] - points[0][d]) * (p[d] - points[0][d])
+		}
+		if dist < 0.01 {
+			near++
+		}
+	}
+	if near < 50 {
+		t.Error(near)
+	}
+}
+
+func TestErdosRenyi(t *testing.T) {
+	g := New(1).ErdosRenyi(200, 0.1, false)
+	if g.Order() != 200 || g.Directed() {
+		t.Fatal(g)
+	}
+	// The expected number of edges is 0.1 * 200*199/2 = 1990
+	if g.Size() < 1800 || g.Size() > 2200 {
+		t.Error(g.Size())
+	}
+	for _, e := range g.Edges() {
+		if e.From == e.To {
+			t.Fatal("loop generated")
+		}
+	}
+
+	d := New(1).ErdosRenyi(50, 1, true)
+	if d.Size() != 50*49 {
+		t.Error(d.Size())
+	}
+}
+
+func TestBarabasiAlbert(t *testing.T) {
+	n, m := 1000, 2
+	g := New(1).BarabasiAlbert(n, m)
+	if g.Order() != n {
+		t.Fatal(g.Order())
+	}
+	// The seed graph has m(m+1)/2 edges, and every later vertex adds m more
+	if g.Size() != m*(m+1)/2+(n-m-1)*m {
+		t.Error(g.Size())
+	}
+	maxDegree := 0
+	for _, v := range g.Vertices() {
+		if d := g.Degree(v); d > maxDegree {
+			maxDegree = d
+		}
+		if g.Degree(v) < m {
+			t.Fatalf("vertex %d has degree %d", v, g.Degree(v))
+		}
+	}
+	// Hubs are far more connected than the average degree of about 2m
+	if maxDegree < 10*m {
+		t.Error(maxDegree)
+	}
+
+	if g := New(1).BarabasiAlbert(5, 0); g.Order() != 5 || g.Size() != 0 {
+		t.Error(g)
+	}
+}
@@ -0,0 +1,166 @@
+package graph
+
+// Single-source shortest paths with negative edge weights
+//
+// The Bellman-Ford algorithm finds the shortest paths from a source vertex by
+// "relaxing" every edge u->v repeatedly: if the best known distance to u plus
+// the weight of the edge is less than the best known distance to v, the
+// distance to v is lowered and u is recorded as its predecessor. A shortest
+// path visits each vertex at most once, so has at most V-1 edges, and after
+// V-1 passes over all edges every distance is final. This takes O(VE) time,
+// more than Dijkstra's algorithm, but works when edge weights are negative.
+//
+// If a cycle whose weights sum to a negative number is reachable from the
+// source, distances around it can be lowered forever, and shortest paths are
+// not defined. This shows up as an edge that can still be relaxed after V-1
+// passes. The vertex at the end of that edge leads back to the cycle through
+// its chain of predecessors, so following predecessors V times from it is
+// guaranteed to land on the cycle, which can then be read off.
+//
+// In an undirected graph, every edge can be traversed both ways, so any
+// negative edge forms a negative cycle of length two.
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrNegativeCycle = errors.New("graph contains a negative cycle")
+
+// NegativeCycleError is returned when shortest paths are undefined because of
+// a negative cycle. It satisfies errors.Is(err, ErrNegativeCycle).
+type NegativeCycleError[V comparable] struct {
+	// Cycle lists the vertices of a negative cycle, in order
+	Cycle []V
+}
+
+func (e *NegativeCycleError[V]) Error() string {
+	return fmt.Sprintf("%s: %v", ErrNegativeCycle, e.Cycle)
+}
+
+func (e *NegativeCycleError[V]) Is(target error) bool {
+	return target == ErrNegativeCycle
+}
+
+// ShortestPaths holds the shortest paths from a single source vertex
+type ShortestPaths[V comparable] struct {
+	Source V
+	dist   map[V]float64
+	prev   map[V]V
+}
+
+// DistanceTo returns the length of the shortest path to *v*, or false if *v*
+// is unreachable
+func (sp *ShortestPaths[V]) DistanceTo(v V) (float64, bool) {
+	d, ok := sp.dist[v]
+	return d, ok
+}
+
+// PathTo returns the vertices along the shortest path from the source to *v*,
+// or false if *v* is unreachable
+func (sp *ShortestPaths[V]) PathTo(v V) ([]V, bool) {
+	if _, ok := sp.dist[v]; !ok {
+		return nil, false
+	}
+	path := []V{v}
+	for v != sp.Source {
+		v = sp.prev[v]
+		path = append(path, v)
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path, true
+}
+
+// arcs returns every edge as a directed arc, with undirected edges appearing
+// in both directions
+func (g *Graph[V]) arcs() []Edge[V] {
+	var arcs []Edge[V]
+	for _, e := range g.Edges() {
+		arcs = append(arcs, Edge[V]{From: e.From, To: e.To, Weight: e.Weight})
+		if !g.directed && e.From != e.To {
+			arcs = append(arcs, Edge[V]{From: e.To, To: e.From, Weight: e.Weight})
+		}
+	}
+	return arcs
+}
+
+// relax runs the passes of the Bellman-Ford algorithm. It returns a vertex
+// whose distance could still be lowered after V-1 passes, if any.
+func (g *Graph[V]) relax(arcs []Edge[V], dist map[V]float64, prev map[V]V) (V, bool) {
+	for pass := 0; pass != len(g.order); pass++ {
+		changed := false
+		for _, a := range arcs {
+			du, ok := dist[a.From]
+			if !ok {
+				continue
+			}
+			if dv, ok := dist[a.To]; !ok || du+a.Weight < dv {
+				dist[a.To] = du + a.Weight
+				prev[a.To] = a.From
+				changed = true
+				if pass == len(g.order)-1 {
+					return a.To, true
+				}
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+	var none V
+	return none, false
+}
+
+// cycleThrough recovers the negative cycle that *v* leads back to through its
+// predecessors
+func cycleThrough[V comparable](v V, prev map[V]V, n int) []V {
+	for i := 0; i != n; i++ {
+		v = prev[v]
+	}
+	cycle := []V{v}
+	for u := prev[v]; u != v; u = prev[u] {
+		cycle = append(cycle, u)
+	}
+	// Predecessors run backwards around the cycle
+	for i, j := 0, len(cycle)-1; i < j; i, j = i+1, j-1 {
+		cycle[i], cycle[j] = cycle[j], cycle[i]
+	}
+	return cycle
+}
+
+// BellmanFord computes the shortest paths from *source* to every reachable
+// vertex. If a negative cycle is reachable from the source, it returns a
+// *NegativeCycleError holding the cycle.
+func (g *Graph[V]) BellmanFord(source V) (*ShortestPaths[V], error) {
+	if !g.HasVertex(source) {
+		return nil, ErrNoVertex
+	}
+	sp := &ShortestPaths[V]{
+		Source: source,
+		dist:   map[V]float64{source: 0},
+		prev:   make(map[V]V),
+	}
+	if v, ok := g.relax(g.arcs(), sp.dist, sp.prev); ok {
+		return nil, &NegativeCycleError[V]{cycleThrough(v, sp.prev, len(g.order))}
+	}
+	return sp, nil
+}
+
+// FindNegativeCycle returns a negative cycle anywhere in the graph, or false
+// if there is none. This is equivalent to running BellmanFord from a virtual
+// source joined to every vertex by an edge of weight zero.
+func (g *Graph[V]) FindNegativeCycle() ([]V, bool) {
+	dist := make(map[V]float64, len(g.order))
+	for _, v := range g.order {
+		dist[v] = 0
+	}
+	// Setting every distance to zero stands in for the first pass from the
+	// virtual source, so the usual number of passes remains
+	prev := make(map[V]V)
+	if v, ok := g.relax(g.arcs(), dist, prev); ok {
+		return cycleThrough(v, prev, len(g.order)), true
+	}
+	return nil, false
+}
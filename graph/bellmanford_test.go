@@ -0,0 +1,112 @@
+package graph
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestBellmanFord(t *testing.T) {
+	// Figure 24.4 of Cormen et al., Introduction to Algorithms
+	g := New[string](true)
+	g.AddEdge("s", "t", 6)
+	g.AddEdge("s", "y", 7)
+	g.AddEdge("t", "x", 5)
+	g.AddEdge("t", "y", 8)
+	g.AddEdge("t", "z", -4)
+	g.AddEdge("x", "t", -2)
+	g.AddEdge("y", "x", -3)
+	g.AddEdge("y", "z", 9)
+	g.AddEdge("z", "x", 7)
+	g.AddEdge("z", "s", 2)
+	g.AddVertex("unreachable")
+
+	sp, err := g.BellmanFord("s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]float64{"s": 0, "t": 2, "x": 4, "y": 7, "z": -2}
+	for v, d := range expected {
+		if dist, ok := sp.DistanceTo(v); !ok || dist != d {
+			t.Errorf("distance to %s is %f, expected %f", v, dist, d)
+		}
+	}
+	path, ok := sp.PathTo("z")
+	if !ok || len(path) != 5 || path[0] != "s" || path[1] != "y" || path[4] != "z" {
+		t.Error(path)
+	}
+	if _, ok := sp.DistanceTo("unreachable"); ok {
+		t.Fail()
+	}
+	if _, ok := sp.PathTo("unreachable"); ok {
+		t.Fail()
+	}
+}
+
+// checkNegativeCycle verifies that *cycle* is a cycle with negative weight
+func checkNegativeCycle(t *testing.T, g *Graph[int], cycle []int) {
+	total := 0.0
+	for i := range cycle {
+		w, ok := g.Weight(cycle[i], cycle[(i+1)%len(cycle)])
+		if !ok {
+			t.Fatalf("%v is not a cycle", cycle)
+		}
+		total += w
+	}
+	if total >= 0 {
+		t.Errorf("cycle %v has weight %f", cycle, total)
+	}
+}
+
+func TestBellmanFordNegativeCycle(t *testing.T) {
+	g := New[int](true)
+	g.AddEdge(0, 1, 1)
+	g.AddEdge(1, 2, 1)
+	g.AddEdge(2, 3, -1)
+	g.AddEdge(3, 4, 1)
+	g.AddEdge(4, 2, -1)
+
+	_, err := g.BellmanFord(0)
+	if !errors.Is(err, ErrNegativeCycle) {
+		t.Fatal(err)
+	}
+	var cycleErr *NegativeCycleError[int]
+	if !errors.As(err, &cycleErr) || len(cycleErr.Cycle) != 3 {
+		t.Fatal(err)
+	}
+	checkNegativeCycle(t, g, cycleErr.Cycle)
+
+	// The cycle is not reachable from 3's other side
+	g2 := New[int](true)
+	g2.AddEdge(1, 2, -1)
+	g2.AddEdge(2, 1, -1)
+	g2.AddEdge(3, 1, 1)
+	g2.AddVertex(0)
+	if _, err := g2.BellmanFord(0); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFindNegativeCycle(t *testing.T) {
+	g := New[int](true)
+	g.AddEdge(1, 2, 2)
+	g.AddEdge(2, 3, -1)
+	g.AddEdge(3, 1, -0.5)
+	if cycle, ok := g.FindNegativeCycle(); ok {
+		t.Error(cycle)
+	}
+
+	g.AddEdge(3, 1, -1.5)
+	cycle, ok := g.FindNegativeCycle()
+	if !ok {
+		t.Fatal("no cycle found")
+	}
+	checkNegativeCycle(t, g, cycle)
+
+	u := New[int](false)
+	u.AddEdge(1, 2, 3)
+	u.AddEdge(2, 3, -1)
+	cycle, ok = u.FindNegativeCycle()
+	if !ok || len(cycle) != 2 {
+		t.Error(cycle)
+	}
+}
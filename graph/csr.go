@@ -0,0 +1,121 @@
+package graph
+
+import "sort"
+
+// CSR is a graph stored in compressed sparse row format. Vertices are numbered
+// from 0 to Order()-1 in the order they were added to the source Graph. The
+// neighbours of vertex i are targets[offsets[i]:offsets[i+1]], sorted by
+// index, with the corresponding edge weights in the same positions of
+// weights.
+//
+//	offsets: [0 2 3 3]           vertex 0 -> {1, 2}, vertex 1 -> {2},
+//	targets: [1 2 2]             vertex 2 has no neighbours
+//	weights: [.5 1 2]
+type CSR[V comparable] struct {
+	directed bool
+	ids      []V
+	index    map[V]int
+	offsets  []int
+	targets  []int
+	weights  []float64
+}
+
+// ToCSR converts a graph to compressed sparse row format
+func (g *Graph[V]) ToCSR() *CSR[V] {
+	ids, index := g.indexVertices()
+	c := &CSR[V]{directed: g.directed, ids: ids, index: index, offsets: make([]int, len(ids)+1)}
+
+	type arc struct {
+		to     int
+		weight float64
+	}
+	for i, v := range ids {
+		vtx := g.vertices[v]
+		arcs := make([]arc, len(vtx.out))
+		for k, e := range vtx.out {
+			arcs[k] = arc{index[vtx.other(e)], e.Weight}
+		}
+		sort.Slice(arcs, func(a, b int) bool { return arcs[a].to < arcs[b].to })
+		for _, a := range arcs {
+			c.targets = append(c.targets, a.to)
+			c.weights = append(c.weights, a.weight)
+		}
+		c.offsets[i+1] = len(c.targets)
+	}
+	return c
+}
+
+// Directed returns true for a directed graph
+func (c *CSR[V]) Directed() bool {
+	return c.directed
+}
+
+// Order returns the number of vertices
+func (c *CSR[V]) Order() int {
+	return len(c.ids)
+}
+
+// Vertices returns the vertices in index order
+func (c *CSR[V]) Vertices() []V {
+	ids := make([]V, len(c.ids))
+	copy(ids, c.ids)
+	return ids
+}
+
+// Index returns the index of a vertex
+func (c *CSR[V]) Index(v V) (int, bool) {
+	i, ok := c.index[v]
+	return i, ok
+}
+
+// Vertex returns the vertex with index *i*
+func (c *CSR[V]) Vertex(i int) V {
+	return c.ids[i]
+}
+
+// Row returns the indices of the neighbours of vertex index *i* and the
+// weights of the corresponding edges. The slices are shared with the CSR and
+// must not be modified.
+func (c *CSR[V]) Row(i int) ([]int, []float64) {
+	lo, hi := c.offsets[i], c.offsets[i+1]
+	return c.targets[lo:hi], c.weights[lo:hi]
+}
+
+// At returns the weight of the edge from vertex index *i* to vertex index *j*,
+// using a binary search of row *i*
+func (c *CSR[V]) At(i, j int) (float64, bool) {
+	targets, weights := c.Row(i)
+	k := sort.SearchInts(targets, j)
+	if k < len(targets) && targets[k] == j {
+		return weights[k], true
+	}
+	return 0, false
+}
+
+// Weight returns the weight of the edge from one vertex to another
+func (c *CSR[V]) Weight(from, to V) (float64, bool) {
+	i, ok := c.index[from]
+	if !ok {
+		return 0, false
+	}
+	j, ok := c.index[to]
+	if !ok {
+		return 0, false
+	}
+	return c.At(i, j)
+}
+
+// Neighbors returns the vertices reachable from *v* along one edge, in index
+// order
+func (c *CSR[V]) Neighbors(v V) []V {
+	i, ok := c.index[v]
+	if !ok {
+		return nil
+	}
+	targets, _ := c.Row(i)
+	neighbors := make([]V, len(targets))
+	for k, j := range targets {
+		neighbors[k] = c.ids[j]
+	}
+	return neighbors
+}
@@ -0,0 +1,142 @@
+package graph
+
+// Topological sorting and critical paths in directed acyclic graphs
+//
+// A topological order lists the vertices of a directed acyclic graph (DAG) so
+// that every edge points forward in the list. Kahn's algorithm builds one by
+// repeatedly removing a vertex with no remaining incoming edges. If vertices
+// remain but all of them have incoming edges, the graph has a cycle.
+//
+// Given a topological order, longest paths in a DAG are easy to compute (in
+// a general graph, the problem is NP-hard): visiting vertices in order, the
+// longest path ending at a vertex is the longest path ending at one of its
+// predecessors plus the connecting edge, and every predecessor has already
+// been visited.
+//
+// In project scheduling, vertices are events, edges are activities and edge
+// weights are their durations, and an activity can only start once every
+// activity leading into its starting event is done. The longest path through
+// the graph is the "critical path": it gives the minimum duration of the
+// project, and any delay to an activity on it delays the whole project. For
+// every event, CriticalPath also computes the earliest time it can happen, and
+// the latest time it can happen without delaying the project. The difference
+// is the "slack", which is zero along the critical path.
+
+import "errors"
+
+var ErrCycle = errors.New("graph contains a cycle")
+
+// TopologicalSort returns the vertices of a directed graph ordered so that
+// every edge leads from an earlier vertex to a later one, or ErrCycle if no
+// such order exists. Since every edge of an undirected graph can be traversed
+// both ways, an undirected graph can only be sorted if it has no edges.
+func (g *Graph[V]) TopologicalSort() ([]V, error) {
+	if !g.directed {
+		if g.edges != 0 {
+			return nil, ErrCycle
+		}
+		return g.Vertices(), nil
+	}
+
+	indegree := make(map[V]int, len(g.order))
+	for _, e := range g.Edges() {
+		indegree[e.To]++
+	}
+	var queue []V
+	for _, v := range g.order {
+		if indegree[v] == 0 {
+			queue = append(queue, v)
+		}
+	}
+
+	order := make([]V, 0, len(g.order))
+	for len(queue) != 0 {
+		u := queue[0]
+		queue = queue[1:]
+		order = append(order, u)
+		for _, e := range g.vertices[u].out {
+			indegree[e.To]--
+			if indegree[e.To] == 0 {
+				queue = append(queue, e.To)
+			}
+		}
+	}
+	if len(order) != len(g.order) {
+		return nil, ErrCycle
+	}
+	return order, nil
+}
+
+// Schedule is the result of a critical path analysis
+type Schedule[V comparable] struct {
+	// Path is a longest path through the graph, and Length its total weight
+	Path   []V
+	Length float64
+
+	// Earliest and Latest are the earliest and latest times at which each
+	// vertex can be reached without lengthening the schedule
+	Earliest map[V]float64
+	Latest   map[V]float64
+}
+
+// Slack returns how long reaching *v* can be delayed without lengthening the
+// schedule
+func (s *Schedule[V]) Slack(v V) float64 {
+	return s.Latest[v] - s.Earliest[v]
+}
+
+// CriticalPath computes a longest path through a directed acyclic graph,
+// using edge weights as durations, along with the earliest and latest times
+// of every vertex. It returns ErrCycle if the graph is not acyclic.
+func (g *Graph[V]) CriticalPath() (*Schedule[V], error) {
+	order, err := g.TopologicalSort()
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Schedule[V]{
+		Earliest: make(map[V]float64, len(order)),
+		Latest:   make(map[V]float64, len(order)),
+	}
+	if len(order) == 0 {
+		return s, nil
+	}
+
+	// Forward pass: the earliest time of a vertex is the longest path to it
+	predecessor := make(map[V]V)
+	end := order[0]
+	for _, u := range order {
+		for _, e := range g.vertices[u].out {
+			if t := s.Earliest[u] + e.Weight; t > s.Earliest[e.To] {
+				s.Earliest[e.To] = t
+				predecessor[e.To] = u
+			}
+		}
+		if s.Earliest[u] > s.Earliest[end] {
+			end = u
+		}
+	}
+	s.Length = s.Earliest[end]
+
+	s.Path = []V{end}
+	for v, ok := predecessor[end]; ok; v, ok = predecessor[v] {
+		s.Path = append(s.Path, v)
+	}
+	for i, j := 0, len(s.Path)-1; i < j; i, j = i+1, j-1 {
+		s.Path[i], s.Path[j] = s.Path[j], s.Path[i]
+	}
+
+	// Backward pass: the latest time of a vertex is the latest time it can be
+	// left along every outgoing edge
+	for i := len(order) - 1; i >= 0; i-- {
+		u := order[i]
+		latest := s.Length
+		for _, e := range g.vertices[u].out {
+			if t := s.Latest[e.To] - e.Weight; t < latest {
+				latest = t
+			}
+		}
+		s.Latest[u] = latest
+	}
+	return s, nil
+}
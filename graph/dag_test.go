@@ -0,0 +1,90 @@
+package graph
+
+import "testing"
+
+func TestTopologicalSort(t *testing.T) {
+	g := New[string](true)
+	g.AddEdge("shirt", "tie", 1)
+	g.AddEdge("tie", "jacket", 1)
+	g.AddEdge("trousers", "shoes", 1)
+	g.AddEdge("trousers", "belt", 1)
+	g.AddEdge("belt", "jacket", 1)
+	g.AddEdge("shirt", "belt", 1)
+	g.AddEdge("socks", "shoes", 1)
+	g.AddVertex("watch")
+
+	order, err := g.TopologicalSort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(order) != g.Order() {
+		t.Fatal(order)
+	}
+	position := make(map[string]int)
+	for i, v := range order {
+		position[v] = i
+	}
+	for _, e := range g.Edges() {
+		if position[e.From] >= position[e.To] {
+			t.Errorf("%s is not before %s in %v", e.From, e.To, order)
+		}
+	}
+
+	g.AddEdge("jacket", "shirt", 1)
+	if _, err := g.TopologicalSort(); err != ErrCycle {
+		t.Error(err)
+	}
+}
+
+func TestCriticalPath(t *testing.T) {
+	// Building needs both the design (3) and the parts (4), then building (5)
+	// and testing (2) follow. Testing also has a 2-day setup from the start.
+	g := New[string](true)
+	g.AddEdge("start", "designed", 3)
+	g.AddEdge("start", "parts", 4)
+	g.AddEdge("designed", "built", 5)
+	g.AddEdge("parts", "built", 0)
+	g.AddEdge("built", "tested", 2)
+	g.AddEdge("start", "tested", 2)
+
+	s, err := g.CriticalPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Length != 10 {
+		t.Error(s.Length)
+	}
+	expected := []string{"start", "designed", "built", "tested"}
+	if len(s.Path) != len(expected) {
+		t.Fatal(s.Path)
+	}
+	for i := range expected {
+		if s.Path[i] != expected[i] {
+			t.Fatal(s.Path)
+		}
+	}
+
+	for _, v := range expected {
+		if s.Slack(v) != 0 {
+			t.Errorf("critical vertex %s has slack %f", v, s.Slack(v))
+		}
+	}
+	// Parts can arrive as late as time 8 without delaying the build
+	if s.Earliest["parts"] != 4 || s.Latest["parts"] != 8 || s.Slack("parts") != 4 {
+		t.Error(s.Earliest["parts"], s.Latest["parts"])
+	}
+}
+
+func TestCriticalPathCycle(t *testing.T) {
+	g := New[int](true)
+	g.AddEdge(1, 2, 1)
+	g.AddEdge(2, 1, 1)
+	if _, err := g.CriticalPath(); err != ErrCycle {
+		t.Error(err)
+	}
+
+	s, err := New[int](true).CriticalPath()
+	if err != nil || s.Length != 0 || len(s.Path) != 0 {
+		t.Error(s, err)
+	}
+}
@@ -0,0 +1,58 @@
+package graph
+
+// Single-source shortest paths with non-negative edge weights
+//
+// Dijkstra's algorithm visits vertices in increasing order of distance from
+// the source. The unvisited vertex closest to the source is taken from a
+// priority queue, and its distance is then final: any other path to it would
+// have to leave the visited vertices through some vertex that is at least as
+// far away, and with no negative edges can only get longer from there. Each
+// edge leaving the vertex is then relaxed as in Bellman-Ford, which may lower
+// the priority of a vertex already in the queue.
+//
+// The queue is an indexed heap (see heap.Indexed) keyed directly on vertex
+// IDs, so that lowering a priority is O(log V) and the whole search is
+// O((V + E) log V).
+
+import (
+	"errors"
+
+	"github.com/njwilson23/datastructures/heap"
+)
+
+var ErrNegativeWeight = errors.New("graph contains a negative edge weight")
+
+// Dijkstra computes the shortest paths from *source* to every reachable
+// vertex. It returns ErrNegativeWeight if a negative edge is reached, in which
+// case BellmanFord should be used instead.
+func (g *Graph[V]) Dijkstra(source V) (*ShortestPaths[V], error) {
+	if !g.HasVertex(source) {
+		return nil, ErrNoVertex
+	}
+	sp := &ShortestPaths[V]{
+		Source: source,
+		dist:   make(map[V]float64),
+		prev:   make(map[V]V),
+	}
+	queue := heap.NewIndexed[V]()
+	queue.Set(source, 0)
+	for queue.Len() != 0 {
+		u, du, _ := queue.ExtractMinimum()
+		sp.dist[u] = du
+		vtx := g.vertices[u]
+		for _, e := range vtx.out {
+			if e.Weight < 0 {
+				return nil, ErrNegativeWeight
+			}
+			v := vtx.other(e)
+			if _, done := sp.dist[v]; done {
+				continue
+			}
+			if dv, ok := queue.Value(v); !ok || du+e.Weight < dv {
+				queue.Set(v, du+e.Weight)
+				sp.prev[v] = u
+			}
+		}
+	}
+	return sp, nil
+}
@@ -0,0 +1,45 @@
+package graph
+
+import "testing"
+
+func TestDijkstra(t *testing.T) {
+	// Figure 24.6 of Cormen et al., Introduction to Algorithms
+	g := New[string](true)
+	g.AddEdge("s", "t", 10)
+	g.AddEdge("s", "y", 5)
+	g.AddEdge("t", "x", 1)
+	g.AddEdge("t", "y", 2)
+	g.AddEdge("x", "z", 4)
+	g.AddEdge("y", "t", 3)
+	g.AddEdge("y", "x", 9)
+	g.AddEdge("y", "z", 2)
+	g.AddEdge("z", "s", 7)
+	g.AddEdge("z", "x", 6)
+	g.AddVertex("unreachable")
+
+	sp, err := g.Dijkstra("s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]float64{"s": 0, "t": 8, "x": 9, "y": 5, "z": 7}
+	for v, d := range expected {
+		if dist, ok := sp.DistanceTo(v); !ok || dist != d {
+			t.Errorf("distance to %s is %f, expected %f", v, dist, d)
+		}
+	}
+	path, ok := sp.PathTo("x")
+	if !ok || len(path) != 4 || path[1] != "y" || path[2] != "t" {
+		t.Error(path)
+	}
+	if _, ok := sp.DistanceTo("unreachable"); ok {
+		t.Fail()
+	}
+
+	if _, err := g.Dijkstra("missing"); err != ErrNoVertex {
+		t.Error(err)
+	}
+	g.AddEdge("x", "t", -1)
+	if _, err := g.Dijkstra("s"); err != ErrNegativeWeight {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,410 @@
+package graph
+
+// Reading and writing graphs in the DOT language
+//
+// DOT is the text format used by Graphviz and most other graph visualization
+// tools. A small directed graph looks like
+//
+//	digraph {
+//		"a" [color="red"];
+//		"a" -> "b" [weight=2, label="x"];
+//	}
+//
+// WriteDOT writes every vertex with its attributes, followed by every edge.
+// The weight of an edge is written as its "weight" attribute. ReadDOT accepts
+// the subset of DOT needed to read this back, plus edge chains ("a -> b -> c")
+// and comments. Default attribute statements ("node [shape=box]") and graph
+// attributes are skipped, and subgraphs and ports are not supported.
+//
+// Vertex IDs are written using fmt.Sprint, so reading a graph back requires a
+// function parsing them from a string, such as StringID or IntID.
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var ErrSyntax = errors.New("DOT syntax error")
+
+// StringID parses vertex IDs of type string
+func StringID(s string) (string, error) {
+	return s, nil
+}
+
+// IntID parses vertex IDs of type int
+func IntID(s string) (int, error) {
+	return strconv.Atoi(s)
+}
+
+// quote returns a DOT quoted string. DOT only treats \" as an escape
+// sequence, and leaves other backslashes for the renderer to interpret.
+func quote(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+func writeAttrs(w *bufio.Writer, attrs map[string]string, weight *float64) {
+	keys := make([]string, 0, len(attrs))
+	for key := range attrs {
+		if key != "weight" || weight == nil {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	if len(keys) == 0 && weight == nil {
+		return
+	}
+
+	w.WriteString(" [")
+	sep := ""
+	if weight != nil {
+		w.WriteString("weight=" + strconv.FormatFloat(*weight, 'g', -1, 64))
+		sep = ", "
+	}
+	for _, key := range keys {
+		w.WriteString(sep + quote(key) + "=" + quote(attrs[key]))
+		sep = ", "
+	}
+	w.WriteString("]")
+}
+
+// WriteDOT writes a graph in the DOT language
+func (g *Graph[V]) WriteDOT(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	edgeOp := " -- "
+	if g.directed {
+		bw.WriteString("digraph {\n")
+		edgeOp = " -> "
+	} else {
+		bw.WriteString("graph {\n")
+	}
+
+	for _, v := range g.order {
+		bw.WriteString("\t" + quote(fmt.Sprint(v)))
+		writeAttrs(bw, g.vertices[v].attrs, nil)
+		bw.WriteString(";\n")
+	}
+	for _, e := range g.Edges() {
+		bw.WriteString("\t" + quote(fmt.Sprint(e.From)) + edgeOp + quote(fmt.Sprint(e.To)))
+		weight := e.Weight
+		writeAttrs(bw, e.Attrs, &weight)
+		bw.WriteString(";\n")
+	}
+
+	bw.WriteString("}\n")
+	return bw.Flush()
+}
+
+// ReadDOT reads a graph in the DOT language, using *parseID* to convert vertex
+// IDs from strings
+func ReadDOT[V comparable](r io.Reader, parseID func(string) (V, error)) (*Graph[V], error) {
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	p := &dotParser[V]{lex: &dotLexer{src: string(src)}, parseID: parseID}
+	if err := p.parse(); err != nil {
+		return nil, err
+	}
+	return p.g, nil
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokID
+	tokPunct
+	tokEdgeOp
+)
+
+type token struct {
+	kind   tokenKind
+	text   string
+	quoted bool
+}
+
+// dotLexer splits DOT source into tokens
+type dotLexer struct {
+	src  string
+	pos  int
+	line int
+}
+
+func (l *dotLexer) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%w on line %d: %s", ErrSyntax, l.line+1, fmt.Sprintf(format, args...))
+}
+
+// skipSpace skips whitespace and comments
+func (l *dotLexer) skipSpace() {
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == '\n':
+			l.line++
+			l.pos++
+		case c == ' ' || c == '\t' || c == '\r':
+			l.pos++
+		case c == '#' || strings.HasPrefix(l.src[l.pos:], "//"):
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+				l.pos++
+			}
+		case strings.HasPrefix(l.src[l.pos:], "/*"):
+			end := strings.Index(l.src[l.pos+2:], "*/")
+			if end < 0 {
+				end = len(l.src) - l.pos - 4
+			}
+			l.line += strings.Count(l.src[l.pos:l.pos+end+4], "\n")
+			l.pos += end + 4
+		default:
+			return
+		}
+	}
+}
+
+func isIDChar(r rune) bool {
+	return r == '_' || r == '.' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func (l *dotLexer) next() (token, error) {
+	l.skipSpace()
+	if l.pos >= len(l.src) {
+		return token{kind: tokEOF}, nil
+	}
+	rest := l.src[l.pos:]
+	switch {
+	case strings.HasPrefix(rest, "->") || strings.HasPrefix(rest, "--"):
+		l.pos += 2
+		return token{kind: tokEdgeOp, text: rest[:2]}, nil
+	case strings.ContainsRune("{}[];,=", rune(rest[0])):
+		l.pos++
+		return token{kind: tokPunct, text: rest[:1]}, nil
+	case rest[0] == '"':
+		var sb strings.Builder
+		for i := 1; i < len(rest); i++ {
+			switch {
+			case rest[i] == '"':
+				l.pos += i + 1
+				return token{kind: tokID, text: sb.String(), quoted: true}, nil
+			case rest[i] == '\\' && i+1 < len(rest) && rest[i+1] == '"':
+				sb.WriteByte('"')
+				i++
+			default:
+				if rest[i] == '\n' {
+					l.line++
+				}
+				sb.WriteByte(rest[i])
+			}
+		}
+		return token{}, l.errorf("unterminated string")
+	}
+
+	end := 0
+	for i, r := range rest {
+		if !(isIDChar(r) || (i == 0 && r == '-')) {
+			break
+		}
+		end = i + len(string(r))
+	}
+	if end == 0 {
+		return token{}, l.errorf("unexpected character %q", rest[0])
+	}
+	l.pos += end
+	return token{kind: tokID, text: rest[:end]}, nil
+}
+
+// dotParser builds a graph from a stream of tokens, with one token of
+// lookahead
+type dotParser[V comparable] struct {
+	lex     *dotLexer
+	tok     token
+	parseID func(string) (V, error)
+	g       *Graph[V]
+}
+
+func (p *dotParser[V]) advance() error {
+	tok, err := p.lex.next()
+	p.tok = tok
+	return err
+}
+
+func (p *dotParser[V]) isKeyword(word string) bool {
+	return p.tok.kind == tokID && !p.tok.quoted && strings.EqualFold(p.tok.text, word)
+}
+
+func (p *dotParser[V]) expect(kind tokenKind, text string) error {
+	if p.tok.kind != kind || (text != "" && p.tok.text != text) {
+		return p.lex.errorf("expected %q, found %q", text, p.tok.text)
+	}
+	return p.advance()
+}
+
+func (p *dotParser[V]) parse() error {
+	if err := p.advance(); err != nil {
+		return err
+	}
+	if p.isKeyword("strict") {
+		if err := p.advance(); err != nil {
+			return err
+		}
+	}
+	switch {
+	case p.isKeyword("digraph"):
+		p.g = New[V](true)
+	case p.isKeyword("graph"):
+		p.g = New[V](false)
+	default:
+		return p.lex.errorf("expected graph or digraph")
+	}
+	if err := p.advance(); err != nil {
+		return err
+	}
+	if p.tok.kind == tokID {
+		// The graph name is ignored
+		if err := p.advance(); err != nil {
+			return err
+		}
+	}
+	if err := p.expect(tokPunct, "{"); err != nil {
+		return err
+	}
+
+	for !(p.tok.kind == tokPunct && p.tok.text == "}") {
+		if p.tok.kind == tokEOF {
+			return p.lex.errorf("unexpected end of input")
+		}
+		if err := p.statement(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// attrList parses a sequence of bracketed attribute lists
+func (p *dotParser[V]) attrList() (map[string]string, error) {
+	attrs := make(map[string]string)
+	for p.tok.kind == tokPunct && p.tok.text == "[" {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		for !(p.tok.kind == tokPunct && p.tok.text == "]") {
+			if p.tok.kind != tokID {
+				return nil, p.lex.errorf("expected attribute name, found %q", p.tok.text)
+			}
+			key := p.tok.text
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			if err := p.expect(tokPunct, "="); err != nil {
+				return nil, err
+			}
+			if p.tok.kind != tokID {
+				return nil, p.lex.errorf("expected attribute value, found %q", p.tok.text)
+			}
+			attrs[key] = p.tok.text
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			if p.tok.kind == tokPunct && (p.tok.text == "," || p.tok.text == ";") {
+				if err := p.advance(); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+	return attrs, nil
+}
+
+func (p *dotParser[V]) statement() error {
+	if p.tok.kind == tokPunct && p.tok.text == ";" {
+		return p.advance()
+	}
+	if p.tok.kind != tokID {
+		return p.lex.errorf("unexpected %q", p.tok.text)
+	}
+	if p.isKeyword("subgraph") {
+		return p.lex.errorf("subgraphs are not supported")
+	}
+	if p.isKeyword("graph") || p.isKeyword("node") || p.isKeyword("edge") {
+		// Default attributes are skipped
+		if err := p.advance(); err != nil {
+			return err
+		}
+		_, err := p.attrList()
+		return err
+	}
+
+	first := p.tok.text
+	if err := p.advance(); err != nil {
+		return err
+	}
+	if p.tok.kind == tokPunct && p.tok.text == "=" {
+		// A graph attribute, which is skipped
+		if err := p.advance(); err != nil {
+			return err
+		}
+		return p.advance()
+	}
+
+	ids := []string{first}
+	for p.tok.kind == tokEdgeOp {
+		if (p.tok.text == "->") != p.g.directed {
+			return p.lex.errorf("edge operator %s does not match graph type", p.tok.text)
+		}
+		if err := p.advance(); err != nil {
+			return err
+		}
+		if p.tok.kind != tokID {
+			return p.lex.errorf("expected vertex, found %q", p.tok.text)
+		}
+		ids = append(ids, p.tok.text)
+		if err := p.advance(); err != nil {
+			return err
+		}
+	}
+
+	attrs, err := p.attrList()
+	if err != nil {
+		return err
+	}
+
+	vertices := make([]V, len(ids))
+	for i, id := range ids {
+		if vertices[i], err = p.parseID(id); err != nil {
+			return fmt.Errorf("parsing vertex %q: %w", id, err)
+		}
+	}
+
+	if len(vertices) == 1 {
+		p.g.AddVertex(vertices[0])
+		vattrs := p.g.VertexAttrs(vertices[0])
+		for key, value := range attrs {
+			vattrs[key] = value
+		}
+		return nil
+	}
+
+	weight := 1.0
+	if w, ok := attrs["weight"]; ok {
+		if weight, err = strconv.ParseFloat(w, 64); err != nil {
+			return p.lex.errorf("invalid weight %q", w)
+		}
+	}
+	for i := 0; i+1 < len(vertices); i++ {
+		e := p.g.AddEdge(vertices[i], vertices[i+1], weight)
+		for key, value := range attrs {
+			if key != "weight" {
+				e.SetAttr(key, value)
+			}
+		}
+	}
+	return nil
+}
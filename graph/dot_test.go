@@ -0,0 +1,103 @@
+package graph
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestWriteDOT(t *testing.T) {
+	g := New[string](true)
+	g.AddVertex("a")
+	g.VertexAttrs("a")["color"] = "red"
+	e := g.AddEdge("a", "b", 2.5)
+	e.SetAttr("label", `say "hi"`)
+
+	var buf bytes.Buffer
+	if err := g.WriteDOT(&buf); err != nil {
+		t.Fatal(err)
+	}
+	expected := `digraph {
+	"a" ["color"="red"];
+	"b";
+	"a" -> "b" [weight=2.5, "label"="say \"hi\""];
+}
+`
+	if buf.String() != expected {
+		t.Error(buf.String())
+	}
+}
+
+func TestDOTRoundTrip(t *testing.T) {
+	g := New[int](false)
+	g.AddEdge(1, 2, 1)
+	g.AddEdge(2, 3, 0.5)
+	g.AddVertex(4)
+	g.VertexAttrs(4)["shape"] = "box"
+
+	var buf bytes.Buffer
+	g.WriteDOT(&buf)
+	g2, err := ReadDOT(&buf, IntID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g2.Directed() || g2.Order() != 4 || g2.Size() != 2 {
+		t.Error(g2)
+	}
+	if e, ok := g2.Edge(3, 2); !ok || e.Weight != 0.5 {
+		t.Fail()
+	}
+	if g2.VertexAttrs(4)["shape"] != "box" {
+		t.Fail()
+	}
+}
+
+func TestReadDOT(t *testing.T) {
+	src := `
+	/* A small dependency graph */
+	strict digraph deps {
+		rankdir = LR;
+		node [shape=box];
+		main -> parser -> lexer [weight=3, color=blue];
+		main -> "code gen"; // comment
+		# another comment
+		lexer [label="Lexer"]
+	}`
+	g, err := ReadDOT(strings.NewReader(src), StringID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !g.Directed() || g.Order() != 4 || g.Size() != 3 {
+		t.Error(g)
+	}
+	e, ok := g.Edge("parser", "lexer")
+	if !ok || e.Weight != 3 || e.Attrs["color"] != "blue" {
+		t.Fail()
+	}
+	if e, _ := g.Edge("main", "code gen"); e.Weight != 1 {
+		t.Fail()
+	}
+	if g.VertexAttrs("lexer")["label"] != "Lexer" {
+		t.Fail()
+	}
+}
+
+func TestReadDOTErrors(t *testing.T) {
+	for _, src := range []string{
+		`digraph { a -- b }`,
+		`graph { a -> b }`,
+		`digraph { a -> }`,
+		`digraph { "a }`,
+		`digraph { a`,
+		`tree { }`,
+	} {
+		if _, err := ReadDOT(strings.NewReader(src), StringID); !errors.Is(err, ErrSyntax) {
+			t.Errorf("expected syntax error for %q, got %v", src, err)
+		}
+	}
+
+	if _, err := ReadDOT(strings.NewReader(`graph { a -- 1 }`), IntID); err == nil {
+		t.Error("expected error parsing vertex ID")
+	}
+}
@@ -0,0 +1,7 @@
+package graph
+
+// Out returns the edges leaving *v* without copying them, for the benchmarks
+// in package graph_test
+func (g *Graph[V]) Out(v V) []*Edge[V] {
+	return g.vertices[v].out
+}
@@ -0,0 +1,240 @@
+package graph
+
+// Maximum flow and minimum cut
+//
+// Treating the weight of each edge as a capacity, a flow from a source to a
+// sink assigns an amount to every edge, no greater than its capacity, such
+// that the amount flowing into every other vertex equals the amount flowing
+// out. The maximum flow problem asks for the largest total amount that can
+// leave the source.
+//
+// Flow algorithms work on the residual network. For every edge u->v with
+// capacity c carrying flow f, the residual network has an arc u->v with
+// capacity c-f (the flow can still be increased by that much) and an arc v->u
+// with capacity f (the flow can be cancelled). Any path from source to sink in
+// the residual network can carry more flow, and the flow is maximal once no
+// such path exists.
+//
+// Dinic's algorithm finds augmenting paths in phases. Each phase runs a BFS to
+// label vertices by their distance from the source in the residual network,
+// then pushes as much flow as possible along shortest paths only (a "blocking
+// flow"), using a DFS that remembers which arcs out of each vertex are already
+// saturated. There are at most V phases, so the algorithm runs in O(V^2 E)
+// time, and much faster in practice.
+//
+// By the max-flow min-cut theorem, the value of the maximum flow equals the
+// capacity of the smallest set of edges whose removal disconnects the sink
+// from the source. Once the flow is maximal, the vertices still reachable from
+// the source in the residual network form the source side of such a cut.
+
+import (
+	"errors"
+	"math"
+)
+
+var ErrNegativeCapacity = errors.New("edge has negative capacity")
+
+var ErrSourceIsSink = errors.New("source and sink are the same vertex")
+
+// epsilon is the smallest residual capacity treated as non-zero, to avoid
+// following arcs that are only open because of rounding error
+const epsilon = 1e-12
+
+// Flow is the result of a maximum flow computation
+type Flow[V comparable] struct {
+	Value float64
+
+	g      *Graph[V]
+	source int
+	ids    []V
+	index  map[V]int
+	adj    [][]int // arcs leaving each vertex
+	to     []int
+	cap    []float64 // residual capacity of each arc; arc i^1 is the reverse of arc i
+	arc    map[*Edge[V]]int
+}
+
+// MaxFlow computes a maximum flow from *source* to *sink*, using edge weights
+// as capacities. In an undirected graph, each edge can carry flow in either
+// direction.
+func (g *Graph[V]) MaxFlow(source, sink V) (*Flow[V], error) {
+	if !g.HasVertex(source) || !g.HasVertex(sink) {
+		return nil, ErrNoVertex
+	}
+	if source == sink {
+		return nil, ErrSourceIsSink
+	}
+
+	f := &Flow[V]{
+		g:     g,
+		ids:   g.order,
+		index: make(map[V]int, len(g.order)),
+		adj:   make([][]int, len(g.order)),
+		arc:   make(map[*Edge[V]]int, g.edges),
+	}
+	for i, v := range g.order {
+		f.index[v] = i
+	}
+	for _, e := range g.Edges() {
+		if e.Weight < 0 {
+			return nil, ErrNegativeCapacity
+		}
+		reverse := 0.0
+		if !g.directed {
+			reverse = e.Weight
+		}
+		f.arc[e] = f.addArc(f.index[e.From], f.index[e.To], e.Weight, reverse)
+	}
+
+	f.source = f.index[source]
+	t := f.index[sink]
+	level := make([]int, len(f.ids))
+	next := make([]int, len(f.ids))
+	for f.levels(level, t) {
+		for i := range next {
+			next[i] = 0
+		}
+		for {
+			pushed := f.augment(f.source, t, math.Inf(1), level, next)
+			if pushed <= epsilon {
+				break
+			}
+			f.Value += pushed
+		}
+	}
+	return f, nil
+}
+
+// addArc adds an arc and its reverse to the residual network, returning the
+// index of the forward arc
+func (f *Flow[V]) addArc(u, v int, capacity, reverse float64) int {
+	i := len(f.to)
+	f.to = append(f.to, v, u)
+	f.cap = append(f.cap, capacity, reverse)
+	f.adj[u] = append(f.adj[u], i)
+	f.adj[v] = append(f.adj[v], i+1)
+	return i
+}
+
+// levels labels every vertex with its BFS distance from the source in the
+// residual network (or -1 if unreachable), and reports whether the sink is
+// reachable
+func (f *Flow[V]) levels(level []int, sink int) bool {
+	for i := range level {
+		level[i] = -1
+	}
+	level[f.source] = 0
+	queue := []int{f.source}
+	for len(queue) != 0 {
+		u := queue[0]
+		queue = queue[1:]
+		for _, a := range f.adj[u] {
+			if v := f.to[a]; level[v] < 0 && f.cap[a] > epsilon {
+				level[v] = level[u] + 1
+				queue = append(queue, v)
+			}
+		}
+	}
+	return level[sink] >= 0
+}
+
+// augment pushes up to *limit* units of flow from *u* to the sink along a path
+// of strictly increasing levels, and returns the amount pushed. next[u] is
+// the first arc out of u that may still have room, so saturated arcs are
+// never retried within a phase.
+func (f *Flow[V]) augment(u, sink int, limit float64, level, next []int) float64 {
+	if u == sink {
+		return limit
+	}
+	for ; next[u] < len(f.adj[u]); next[u]++ {
+		a := f.adj[u][next[u]]
+		v := f.to[a]
+		if f.cap[a] <= epsilon || level[v] != level[u]+1 {
+			continue
+		}
+		if pushed := f.augment(v, sink, math.Min(limit, f.cap[a]), level, next); pushed > epsilon {
+			f.cap[a] -= pushed
+			f.cap[a^1] += pushed
+			return pushed
+		}
+	}
+	return 0
+}
+
+// EdgeFlow returns the flow along the edge between two vertices, in the
+// direction from *from* to *to*. In an undirected graph, the flow is negative
+// if it runs the other way.
+func (f *Flow[V]) EdgeFlow(from, to V) (float64, error) {
+	e, ok := f.g.Edge(from, to)
+	if !ok {
+		return 0, ErrNoEdge
+	}
+	a := f.arc[e]
+	flow := e.Weight - f.cap[a]
+	if !f.g.directed {
+		// The reverse arc started with capacity e.Weight too, so the net flow
+		// is half the difference between the two arcs' residuals
+		flow = (f.cap[a^1] - f.cap[a]) / 2
+	}
+	if e.From != from {
+		flow = -flow
+	}
+	return flow, nil
+}
+
+// Residual returns the residual network as a directed graph, whose edge
+// weights are the residual capacities. Arcs with no residual capacity are
+// omitted.
+func (f *Flow[V]) Residual() *Graph[V] {
+	r := New[V](true)
+	for _, v := range f.ids {
+		r.AddVertex(v)
+	}
+	for u, arcs := range f.adj {
+		for _, a := range arcs {
+			if f.cap[a] > epsilon {
+				if e, ok := r.Edge(f.ids[u], f.ids[f.to[a]]); ok {
+					// Antiparallel edges merge into a single residual edge
+					e.Weight += f.cap[a]
+				} else {
+					r.AddEdge(f.ids[u], f.ids[f.to[a]], f.cap[a])
+				}
+			}
+		}
+	}
+	return r
+}
+
+// MinCut returns a minimum cut separating the source from the sink: the
+// vertices on the source side, and the edges crossing from the source side to
+// the sink side. The total weight of the cut edges equals the flow value.
+func (f *Flow[V]) MinCut() ([]V, []*Edge[V]) {
+	reachable := make([]bool, len(f.ids))
+	reachable[f.source] = true
+	queue := []int{f.source}
+	for len(queue) != 0 {
+		u := queue[0]
+		queue = queue[1:]
+		for _, a := range f.adj[u] {
+			if v := f.to[a]; !reachable[v] && f.cap[a] > epsilon {
+				reachable[v] = true
+				queue = append(queue, v)
+			}
+		}
+	}
+
+	var side []V
+	for i, v := range f.ids {
+		if reachable[i] {
+			side = append(side, v)
+		}
+	}
+	var cut []*Edge[V]
+	for _, e := range f.g.Edges() {
+		from, to := reachable[f.index[e.From]], reachable[f.index[e.To]]
+		if (from && !to) || (!f.g.directed && to && !from) {
+			cut = append(cut, e)
+		}
+	}
+	return side, cut
+}
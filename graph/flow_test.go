@@ -0,0 +1,136 @@
+package graph
+
+import (
+	"math"
+	"testing"
+)
+
+// clrsNetwork is the flow network from figure 26.1 of Cormen et al.,
+// Introduction to Algorithms, whose maximum flow is 23
+func clrsNetwork() *Graph[string] {
+	g := New[string](true)
+	g.AddEdge("s", "v1", 16)
+	g.AddEdge("s", "v2", 13)
+	g.AddEdge("v1", "v3", 12)
+	g.AddEdge("v2", "v1", 4)
+	g.AddEdge("v2", "v4", 14)
+	g.AddEdge("v3", "v2", 9)
+	g.AddEdge("v3", "t", 20)
+	g.AddEdge("v4", "v3", 7)
+	g.AddEdge("v4", "t", 4)
+	return g
+}
+
+func TestMaxFlow(t *testing.T) {
+	g := clrsNetwork()
+	f, err := g.MaxFlow("s", "t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Value != 23 {
+		t.Fatal(f.Value)
+	}
+
+	// Flow is conserved at every vertex other than the source and sink
+	for _, v := range g.Vertices() {
+		if v == "s" || v == "t" {
+			continue
+		}
+		net := 0.0
+		for _, e := range g.Edges() {
+			flow, _ := f.EdgeFlow(e.From, e.To)
+			if flow < -epsilon || flow > e.Weight+epsilon {
+				t.Errorf("flow %f on %v exceeds capacity", flow, e)
+			}
+			if e.To == v {
+				net += flow
+			}
+			if e.From == v {
+				net -= flow
+			}
+		}
+		if math.Abs(net) > 1e-9 {
+			t.Errorf("flow not conserved at %s: %f", v, net)
+		}
+	}
+}
+
+func TestMinCut(t *testing.T) {
+	g := clrsNetwork()
+	f, _ := g.MaxFlow("s", "t")
+	side, cut := f.MinCut()
+
+	inSide := make(map[string]bool)
+	for _, v := range side {
+		inSide[v] = true
+	}
+	if !inSide["s"] || inSide["t"] {
+		t.Error(side)
+	}
+	capacity := 0.0
+	for _, e := range cut {
+		capacity += e.Weight
+	}
+	if capacity != f.Value {
+		t.Errorf("cut capacity %f differs from flow %f", capacity, f.Value)
+	}
+}
+
+func TestResidual(t *testing.T) {
+	g := New[int](true)
+	g.AddEdge(1, 2, 3)
+	g.AddEdge(2, 3, 2)
+	f, _ := g.MaxFlow(1, 3)
+	if f.Value != 2 {
+		t.Fatal(f.Value)
+	}
+
+	r := f.Residual()
+	if e, ok := r.Edge(1, 2); !ok || e.Weight != 1 {
+		t.Fail()
+	}
+	if e, ok := r.Edge(2, 1); !ok || e.Weight != 2 {
+		t.Fail()
+	}
+	if _, ok := r.Edge(2, 3); ok {
+		t.Error("saturated edge in residual network")
+	}
+}
+
+func TestMaxFlowUndirected(t *testing.T) {
+	g := New[string](false)
+	g.AddEdge("s", "a", 2)
+	g.AddEdge("s", "b", 1)
+	g.AddEdge("b", "a", 1)
+	g.AddEdge("a", "t", 2)
+	g.AddEdge("t", "b", 2)
+
+	f, err := g.MaxFlow("s", "t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Value != 3 {
+		t.Fatal(f.Value)
+	}
+	if flow, _ := f.EdgeFlow("t", "b"); flow > 0 {
+		t.Errorf("expected flow from b to t, got %f from t to b", flow)
+	}
+	_, cut := f.MinCut()
+	if len(cut) != 2 {
+		t.Error(cut)
+	}
+}
+
+func TestMaxFlowErrors(t *testing.T) {
+	g := New[int](true)
+	g.AddEdge(1, 2, -1)
+	if _, err := g.MaxFlow(1, 2); err != ErrNegativeCapacity {
+		t.Error(err)
+	}
+	if _, err := g.MaxFlow(1, 1); err != ErrSourceIsSink {
+		t.Error(err)
+	}
+	if _, err := g.MaxFlow(1, 3); err != ErrNoVertex {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,154 @@
+package graph
+
+// All-pairs shortest paths
+//
+// The Floyd-Warshall algorithm finds the shortest path between every pair of
+// vertices by dynamic programming. Numbering the vertices 0..V-1, it computes
+// in turn for each k the shortest path from i to j whose intermediate
+// vertices are all less than k. Such a path either avoids vertex k, or goes
+// from i to k and then from k to j, so
+//
+//	dist[i][j] = min(dist[i][j], dist[i][k] + dist[k][j])
+//
+// updated in place for every pair. This takes O(V^3) time and O(V^2) memory,
+// regardless of the number of edges, so it suits small dense graphs where
+// running Bellman-Ford from every vertex would cost O(V^2 E). Like
+// Bellman-Ford, it works with negative edge weights.
+//
+// Paths are reconstructed from a second V x V table holding, for every pair,
+// the first vertex after i along the shortest path to j.
+//
+// A negative cycle through vertex i shows up as a negative distance from i to
+// itself. In that case shortest paths are undefined, and the cycle is
+// recovered using FindNegativeCycle.
+//
+// The algorithm reads the weight of every pair of vertices once, so it can be
+// run directly on a Matrix as well as on a Graph.
+
+import "math"
+
+// AllShortestPaths holds the shortest paths between every pair of vertices
+type AllShortestPaths[V comparable] struct {
+	ids   []V
+	index map[V]int
+	dist  []float64
+	next  []int
+}
+
+// floydWarshall computes all-pairs shortest paths over vertices numbered in
+// the order of *ids*, calling *edges* to fill in the weight of every edge. It
+// returns false if there is a negative cycle.
+func floydWarshall[V comparable](ids []V, index map[V]int, edges func(set func(i, j int, w float64))) (*AllShortestPaths[V], bool) {
+	n := len(ids)
+	ap := &AllShortestPaths[V]{
+		ids:   ids,
+		index: index,
+		dist:  make([]float64, n*n),
+		next:  make([]int, n*n),
+	}
+	for k := range ap.dist {
+		ap.dist[k] = math.Inf(1)
+		ap.next[k] = -1
+	}
+	for i := 0; i != n; i++ {
+		ap.dist[i*n+i] = 0
+		ap.next[i*n+i] = i
+	}
+	edges(func(i, j int, w float64) {
+		if w < ap.dist[i*n+j] {
+			ap.dist[i*n+j] = w
+			ap.next[i*n+j] = j
+		}
+	})
+
+	for k := 0; k != n; k++ {
+		for i := 0; i != n; i++ {
+			dik := ap.dist[i*n+k]
+			if math.IsInf(dik, 1) {
+				continue
+			}
+			for j := 0; j != n; j++ {
+				if d := dik + ap.dist[k*n+j]; d < ap.dist[i*n+j] {
+					ap.dist[i*n+j] = d
+					ap.next[i*n+j] = ap.next[i*n+k]
+				}
+			}
+		}
+	}
+
+	for i := 0; i != n; i++ {
+		if ap.dist[i*n+i] < 0 {
+			return nil, false
+		}
+	}
+	return ap, true
+}
+
+// FloydWarshall computes the shortest paths between every pair of vertices.
+// If the graph contains a negative cycle, it returns a *NegativeCycleError
+// holding the cycle.
+func (g *Graph[V]) FloydWarshall() (*AllShortestPaths[V], error) {
+	ids, index := g.indexVertices()
+	ap, ok := floydWarshall(ids, index, func(set func(i, j int, w float64)) {
+		for _, e := range g.Edges() {
+			set(index[e.From], index[e.To], e.Weight)
+			if !g.directed {
+				set(index[e.To], index[e.From], e.Weight)
+			}
+		}
+	})
+	if !ok {
+		cycle, _ := g.FindNegativeCycle()
+		return nil, &NegativeCycleError[V]{cycle}
+	}
+	return ap, nil
+}
+
+// FloydWarshall computes the shortest paths between every pair of vertices,
+// reading edge weights straight from the matrix. If the graph contains a
+// negative cycle, it returns a *NegativeCycleError holding the cycle.
+func (m *Matrix[V]) FloydWarshall() (*AllShortestPaths[V], error) {
+	ap, ok := floydWarshall(m.Vertices(), m.index, func(set func(i, j int, w float64)) {
+		for k, present := range m.present {
+			if present {
+				set(k/len(m.ids), k%len(m.ids), m.weights[k])
+			}
+		}
+	})
+	if !ok {
+		cycle, _ := FromInterface[V](m).FindNegativeCycle()
+		return nil, &NegativeCycleError[V]{cycle}
+	}
+	return ap, nil
+}
+
+// Distance returns the length of the shortest path from one vertex to
+// another, or false if there is no path
+func (ap *AllShortestPaths[V]) Distance(from, to V) (float64, bool) {
+	i, ok := ap.index[from]
+	if !ok {
+		return 0, false
+	}
+	j, ok := ap.index[to]
+	if !ok {
+		return 0, false
+	}
+	d := ap.dist[i*len(ap.ids)+j]
+	return d, !math.IsInf(d, 1)
+}
+
+// Path returns the vertices along the shortest path from one vertex to
+// another, or false if there is no path
+func (ap *AllShortestPaths[V]) Path(from, to V) ([]V, bool) {
+	if _, ok := ap.Distance(from, to); !ok {
+		return nil, false
+	}
+	n := len(ap.ids)
+	i, j := ap.index[from], ap.index[to]
+	path := []V{from}
+	for i != j {
+		i = ap.next[i*n+j]
+		path = append(path, ap.ids[i])
+	}
+	return path, true
+}
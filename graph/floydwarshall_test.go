@@ -0,0 +1,55 @@
+package graph
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFloydWarshall(t *testing.T) {
+	g := New[int](true)
+	g.AddEdge(1, 3, -2)
+	g.AddEdge(2, 1, 4)
+	g.AddEdge(2, 3, 3)
+	g.AddEdge(3, 4, 2)
+	g.AddEdge(4, 2, -1)
+	g.AddVertex(5)
+
+	for _, run := range []func() (*AllShortestPaths[int], error){g.FloydWarshall, g.ToMatrix().FloydWarshall} {
+		ap, err := run()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d, ok := ap.Distance(2, 4); !ok || d != 4 {
+			t.Error(d)
+		}
+		if d, ok := ap.Distance(4, 3); !ok || d != 1 {
+			t.Error(d)
+		}
+		path, ok := ap.Path(4, 3)
+		if !ok || len(path) != 4 || path[1] != 2 || path[2] != 1 {
+			t.Error(path)
+		}
+		if path, ok := ap.Path(1, 1); !ok || len(path) != 1 {
+			t.Error(path)
+		}
+		if _, ok := ap.Distance(1, 5); ok {
+			t.Fail()
+		}
+		if _, ok := ap.Path(5, 1); ok {
+			t.Fail()
+		}
+	}
+}
+
+func TestFloydWarshallNegativeCycle(t *testing.T) {
+	g := New[int](false)
+	g.AddEdge(1, 2, 1)
+	g.AddEdge(2, 3, -1)
+	for _, run := range []func() (*AllShortestPaths[int], error){g.FloydWarshall, g.ToMatrix().FloydWarshall} {
+		_, err := run()
+		var cycleErr *NegativeCycleError[int]
+		if !errors.Is(err, ErrNegativeCycle) || !errors.As(err, &cycleErr) || len(cycleErr.Cycle) != 2 {
+			t.Error(err)
+		}
+	}
+}
@@ -0,0 +1,287 @@
+/*
+ * Package graph implements weighted graphs with attributes.
+ *
+ * A graph is a set of vertices, joined by edges. In a directed graph an edge
+ * goes from one vertex to another, while in an undirected graph an edge joins
+ * two vertices symmetrically. Here each edge has a numerical weight (e.g. a
+ * distance or a capacity) and both vertices and edges can carry a map of
+ * string attributes (e.g. labels or colors for visualization).
+ *
+ * Vertices are identified by values of any comparable type, so a graph can be
+ * built directly over names, integer IDs, coordinates, etc. without keeping a
+ * separate table translating them to indices.
+ *
+ * The graph is stored as adjacency lists: every vertex holds a slice of its
+ * outgoing edges, plus a map from neighbour to position in that slice so that
+ * a particular edge can be found in O(1). This uses O(V + E) memory, and
+ * iterating over a vertex's neighbours is proportional to its degree, which
+ * suits the sparse graphs that most algorithms are run on.
+ *
+ * Vertices and edges are returned in the order they were added, so that
+ * algorithms and serializations are deterministic.
+ *
+ * Graphs can be written to and read from the DOT language used by Graphviz
+ * (see dot.go) and an adjacency-list JSON format (see json.go).
+ */
+
+package graph
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrNoVertex = errors.New("vertex not in graph")
+
+var ErrNoEdge = errors.New("edge not in graph")
+
+// Edge joins two vertices. In an undirected graph, From and To are the
+// vertices in the order that the edge was added.
+type Edge[V comparable] struct {
+	From   V
+	To     V
+	Weight float64
+	Attrs  map[string]string
+}
+
+// SetAttr sets an attribute of an edge
+func (e *Edge[V]) SetAttr(key, value string) {
+	if e.Attrs == nil {
+		e.Attrs = make(map[string]string)
+	}
+	e.Attrs[key] = value
+}
+
+type vertex[V comparable] struct {
+	id    V
+	attrs map[string]string
+	out   []*Edge[V]
+	index map[V]int // position of the edge to each neighbour in out
+}
+
+// Graph is a weighted graph whose vertices are identified by values of type V
+type Graph[V comparable] struct {
+	directed bool
+	vertices map[V]*vertex[V]
+	order    []V
+	edges    int
+}
+
+// New creates an empty Graph. If *directed* is false, every edge is traversable
+// in both directions.
+func New[V comparable](directed bool) *Graph[V] {
+	return &Graph[V]{directed: directed, vertices: make(map[V]*vertex[V])}
+}
+
+// Directed returns true for a directed graph
+func (g *Graph[V]) Directed() bool {
+	return g.directed
+}
+
+// Order returns the number of vertices in the graph
+func (g *Graph[V]) Order() int {
+	return len(g.order)
+}
+
+// Size returns the number of edges in the graph
+func (g *Graph[V]) Size() int {
+	return g.edges
+}
+
+// AddVertex adds a vertex to the graph, if it is not already present
+func (g *Graph[V]) AddVertex(v V) {
+	g.vertex(v)
+}
+
+// vertex returns the internal vertex for *v*, adding it if necessary
+func (g *Graph[V]) vertex(v V) *vertex[V] {
+	vtx, ok := g.vertices[v]
+	if !ok {
+		vtx = &vertex[V]{id: v, index: make(map[V]int)}
+		g.vertices[v] = vtx
+		g.order = append(g.order, v)
+	}
+	return vtx
+}
+
+// HasVertex returns true if *v* is in the graph
+func (g *Graph[V]) HasVertex(v V) bool {
+	_, ok := g.vertices[v]
+	return ok
+}
+
+// RemoveVertex removes a vertex and every edge incident to it
+func (g *Graph[V]) RemoveVertex(v V) error {
+	vtx, ok := g.vertices[v]
+	if !ok {
+		return ErrNoVertex
+	}
+	for _, u := range g.order {
+		if _, ok := g.vertices[u].index[v]; ok {
+			g.RemoveEdge(u, v)
+		}
+	}
+	for len(vtx.out) != 0 {
+		g.RemoveEdge(v, vtx.out[0].To)
+	}
+	delete(g.vertices, v)
+	for i, u := range g.order {
+		if u == v {
+			g.order = append(g.order[:i], g.order[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// Vertices returns the vertices of the graph in the order they were added
+func (g *Graph[V]) Vertices() []V {
+	vertices := make([]V, len(g.order))
+	copy(vertices, g.order)
+	return vertices
+}
+
+// VertexAttrs returns the attribute map of a vertex, which may be modified in
+// place. It returns nil if the vertex is not in the graph.
+func (g *Graph[V]) VertexAttrs(v V) map[string]string {
+	vtx, ok := g.vertices[v]
+	if !ok {
+		return nil
+	}
+	if vtx.attrs == nil {
+		vtx.attrs = make(map[string]string)
+	}
+	return vtx.attrs
+}
+
+// AddEdge joins two vertices with an edge of the given weight, adding the
+// vertices if necessary, and returns the edge. If the vertices are already
+// joined, the weight of the existing edge is updated.
+func (g *Graph[V]) AddEdge(from, to V, weight float64) *Edge[V] {
+	u := g.vertex(from)
+	v := g.vertex(to)
+	if i, ok := u.index[to]; ok {
+		u.out[i].Weight = weight
+		return u.out[i]
+	}
+
+	e := &Edge[V]{From: from, To: to, Weight: weight}
+	u.index[to] = len(u.out)
+	u.out = append(u.out, e)
+	if !g.directed && from != to {
+		v.index[from] = len(v.out)
+		v.out = append(v.out, e)
+	}
+	g.edges++
+	return e
+}
+
+// RemoveEdge removes the edge between two vertices
+func (g *Graph[V]) RemoveEdge(from, to V) error {
+	u, ok := g.vertices[from]
+	if !ok {
+		return ErrNoVertex
+	}
+	if !u.removeEdgeTo(to) {
+		return ErrNoEdge
+	}
+	if !g.directed && from != to {
+		g.vertices[to].removeEdgeTo(from)
+	}
+	g.edges--
+	return nil
+}
+
+// removeEdgeTo removes the edge to *to* from the adjacency list, preserving
+// the order of the remaining edges
+func (vtx *vertex[V]) removeEdgeTo(to V) bool {
+	i, ok := vtx.index[to]
+	if !ok {
+		return false
+	}
+	vtx.out = append(vtx.out[:i], vtx.out[i+1:]...)
+	delete(vtx.index, to)
+	for j := i; j != len(vtx.out); j++ {
+		vtx.index[vtx.other(vtx.out[j])] = j
+	}
+	return true
+}
+
+// other returns the endpoint of an edge that is not this vertex (or this
+// vertex, for a loop)
+func (vtx *vertex[V]) other(e *Edge[V]) V {
+	if e.From == vtx.id {
+		return e.To
+	}
+	return e.From
+}
+
+// Edge returns the edge from one vertex to another
+func (g *Graph[V]) Edge(from, to V) (*Edge[V], bool) {
+	u, ok := g.vertices[from]
+	if !ok {
+		return nil, false
+	}
+	i, ok := u.index[to]
+	if !ok {
+		return nil, false
+	}
+	return u.out[i], true
+}
+
+// Edges returns every edge of the graph. Edges of an undirected graph are
+// returned once each.
+func (g *Graph[V]) Edges() []*Edge[V] {
+	edges := make([]*Edge[V], 0, g.edges)
+	for _, v := range g.order {
+		for _, e := range g.vertices[v].out {
+			if g.directed || e.From == v {
+				edges = append(edges, e)
+			}
+		}
+	}
+	return edges
+}
+
+// Neighbors returns the vertices reachable from *v* along one edge, in the
+// order the edges were added
+func (g *Graph[V]) Neighbors(v V) []V {
+	vtx, ok := g.vertices[v]
+	if !ok {
+		return nil
+	}
+	neighbors := make([]V, len(vtx.out))
+	for i, e := range vtx.out {
+		neighbors[i] = vtx.other(e)
+	}
+	return neighbors
+}
+
+// OutEdges returns the edges leaving *v*. In an undirected graph, this is
+// every edge incident to *v*, and *v* may be either endpoint.
+func (g *Graph[V]) OutEdges(v V) []*Edge[V] {
+	vtx, ok := g.vertices[v]
+	if !ok {
+		return nil
+	}
+	edges := make([]*Edge[V], len(vtx.out))
+	copy(edges, vtx.out)
+	return edges
+}
+
+// Degree returns the number of edges leaving *v*
+func (g *Graph[V]) Degree(v V) int {
+	vtx, ok := g.vertices[v]
+	if !ok {
+		return 0
+	}
+	return len(vtx.out)
+}
+
+func (g *Graph[V]) String() string {
+	kind := "undirected"
+	if g.directed {
+		kind = "directed"
+	}
+	return fmt.Sprintf("%s graph with %d vertices and %d edges", kind, g.Order(), g.Size())
+}
@@ -0,0 +1,110 @@
+package graph
+
+import (
+	"testing"
+)
+
+func TestDirected(t *testing.T) {
+	g := New[string](true)
+	g.AddEdge("a", "b", 1)
+	g.AddEdge("a", "c", 2)
+	g.AddEdge("c", "a", 3)
+	g.AddVertex("d")
+
+	if g.Order() != 4 || g.Size() != 3 {
+		t.Error(g)
+	}
+	neighbors := g.Neighbors("a")
+	if len(neighbors) != 2 || neighbors[0] != "b" || neighbors[1] != "c" {
+		t.Error(neighbors)
+	}
+	if len(g.Neighbors("b")) != 0 {
+		t.Fail()
+	}
+
+	e, ok := g.Edge("c", "a")
+	if !ok || e.Weight != 3 {
+		t.Fail()
+	}
+	if _, ok := g.Edge("b", "a"); ok {
+		t.Fail()
+	}
+
+	// Adding an existing edge updates its weight
+	g.AddEdge("a", "b", 5)
+	if e, _ := g.Edge("a", "b"); e.Weight != 5 || g.Size() != 3 {
+		t.Fail()
+	}
+}
+
+func TestUndirected(t *testing.T) {
+	g := New[int](false)
+	g.AddEdge(1, 2, 1)
+	g.AddEdge(2, 3, 1)
+	g.AddEdge(3, 3, 1)
+
+	if g.Size() != 3 || len(g.Edges()) != 3 {
+		t.Error(g.Edges())
+	}
+	if _, ok := g.Edge(2, 1); !ok {
+		t.Fail()
+	}
+	if g.Degree(2) != 2 || g.Degree(3) != 2 {
+		t.Fail()
+	}
+
+	if err := g.RemoveEdge(2, 1); err != nil {
+		t.Error(err)
+	}
+	if _, ok := g.Edge(1, 2); ok {
+		t.Fail()
+	}
+	if g.RemoveEdge(1, 2) != ErrNoEdge {
+		t.Fail()
+	}
+}
+
+func TestRemoveVertex(t *testing.T) {
+	g := New[string](true)
+	g.AddEdge("a", "b", 1)
+	g.AddEdge("b", "c", 1)
+	g.AddEdge("c", "b", 1)
+	g.AddEdge("b", "b", 1)
+	g.AddEdge("a", "c", 1)
+
+	if err := g.RemoveVertex("b"); err != nil {
+		t.Error(err)
+	}
+	if g.Order() != 2 || g.Size() != 1 {
+		t.Error(g)
+	}
+	if g.HasVertex("b") || g.RemoveVertex("b") != ErrNoVertex {
+		t.Fail()
+	}
+	vertices := g.Vertices()
+	if len(vertices) != 2 || vertices[0] != "a" || vertices[1] != "c" {
+		t.Error(vertices)
+	}
+	neighbors := g.Neighbors("a")
+	if len(neighbors) != 1 || neighbors[0] != "c" {
+		t.Error(neighbors)
+	}
+}
+
+func TestAttrs(t *testing.T) {
+	g := New[string](false)
+	g.AddVertex("a")
+	g.VertexAttrs("a")["color"] = "red"
+	if g.VertexAttrs("a")["color"] != "red" {
+		t.Fail()
+	}
+	if g.VertexAttrs("missing") != nil {
+		t.Fail()
+	}
+
+	e := g.AddEdge("a", "b", 1)
+	e.SetAttr("label", "ab")
+	if e2, _ := g.Edge("b", "a"); e2.Attrs["label"] != "ab" {
+		t.Fail()
+	}
+}
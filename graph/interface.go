@@ -0,0 +1,69 @@
+package graph
+
+// Alternative graph representations
+//
+// Graph stores adjacency lists, which suit sparse graphs that change over
+// time. Two other representations are provided for graphs that are fixed once
+// built:
+//
+// - Matrix stores a dense V x V adjacency matrix. Checking for an edge is a
+//   single array lookup, but iterating over a vertex's neighbours costs O(V)
+//   regardless of its degree, and memory is O(V^2). This wins for small or
+//   dense graphs, and for algorithms such as Floyd-Warshall that inspect
+//   every pair of vertices anyway.
+//
+// - CSR ("compressed sparse row") stores every vertex's neighbours in one
+//   contiguous slice, sorted by vertex index, with a second slice giving the
+//   offset of each vertex's neighbours. It uses O(V + E) memory with no
+//   per-edge pointers or maps, so neighbour iteration is as fast as iterating
+//   over a slice, and an edge can be found by binary search. This wins for
+//   large sparse graphs that are traversed many times.
+//
+// All three implement Interface, so read-only algorithms can be written once
+// for any of them. The benchmarks in interface_test.go compare them.
+
+// Interface is the read-only view of a graph shared by Graph, Matrix and CSR
+type Interface[V comparable] interface {
+	Directed() bool
+	Order() int
+	Vertices() []V
+	Neighbors(v V) []V
+	Weight(from, to V) (float64, bool)
+}
+
+// Weight returns the weight of the edge from one vertex to another, and
+// whether the edge exists
+func (g *Graph[V]) Weight(from, to V) (float64, bool) {
+	e, ok := g.Edge(from, to)
+	if !ok {
+		return 0, false
+	}
+	return e.Weight, true
+}
+
+// FromInterface copies any graph representation into a Graph
+func FromInterface[V comparable](src Interface[V]) *Graph[V] {
+	g := New[V](src.Directed())
+	for _, v := range src.Vertices() {
+		g.AddVertex(v)
+	}
+	for _, u := range src.Vertices() {
+		for _, v := range src.Neighbors(u) {
+			if _, ok := g.Edge(u, v); !ok {
+				w, _ := src.Weight(u, v)
+				g.AddEdge(u, v, w)
+			}
+		}
+	}
+	return g
+}
+
+// indexVertices numbers the vertices of a graph in the order they were added
+func (g *Graph[V]) indexVertices() ([]V, map[V]int) {
+	ids := g.Vertices()
+	index := make(map[V]int, len(ids))
+	for i, v := range ids {
+		index[v] = i
+	}
+	return ids, index
+}
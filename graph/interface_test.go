@@ -0,0 +1,65 @@
+package graph
+
+import "testing"
+
+func sampleGraph(directed bool) *Graph[string] {
+	g := New[string](directed)
+	g.AddEdge("a", "b", 1)
+	g.AddEdge("a", "c", 2)
+	g.AddEdge("c", "b", 3)
+	g.AddEdge("c", "c", 4)
+	g.AddVertex("d")
+	return g
+}
+
+// checkInterface compares a representation against the Graph it was built
+// from
+func checkInterface(t *testing.T, g *Graph[string], rep Interface[string]) {
+	if rep.Directed() != g.Directed() || rep.Order() != g.Order() {
+		t.Fatal("mismatched graph properties")
+	}
+	for _, u := range g.Vertices() {
+		if len(rep.Neighbors(u)) != g.Degree(u) {
+			t.Errorf("%s: neighbours %v, expected %v", u, rep.Neighbors(u), g.Neighbors(u))
+		}
+		for _, v := range g.Vertices() {
+			w1, ok1 := g.Weight(u, v)
+			w2, ok2 := rep.Weight(u, v)
+			if ok1 != ok2 || w1 != w2 {
+				t.Errorf("weight %s-%s: %f %t, expected %f %t", u, v, w2, ok2, w1, ok1)
+			}
+		}
+	}
+	if _, ok := rep.Weight("a", "missing"); ok {
+		t.Fail()
+	}
+}
+
+func TestRepresentations(t *testing.T) {
+	for _, directed := range []bool{true, false} {
+		g := sampleGraph(directed)
+		checkInterface(t, g, g.ToMatrix())
+		checkInterface(t, g, g.ToCSR())
+		checkInterface(t, g, FromInterface[string](g.ToCSR()))
+		checkInterface(t, g, FromInterface[string](g.ToMatrix()))
+	}
+}
+
+func TestCSRRows(t *testing.T) {
+	g := New[int](true)
+	g.AddEdge(0, 2, 1)
+	g.AddEdge(0, 1, 0.5)
+	g.AddEdge(1, 2, 2)
+	g.AddVertex(3)
+	c := g.ToCSR()
+
+	// Vertices are indexed in the order they were added: 0, 2, 1, 3
+	i, _ := c.Index(0)
+	targets, weights := c.Row(i)
+	if len(targets) != 2 || c.Vertex(targets[0]) != 2 || weights[1] != 0.5 {
+		t.Error(targets, weights)
+	}
+	if targets, _ := c.Row(3); len(targets) != 0 {
+		t.Fail()
+	}
+}
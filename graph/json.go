@@ -0,0 +1,84 @@
+package graph
+
+// Reading and writing graphs as JSON
+//
+// Graphs are serialized as adjacency lists, with every vertex listing its
+// outgoing edges:
+//
+//	{
+//	  "directed": true,
+//	  "vertices": [
+//	    {"id": "a", "attrs": {"color": "red"}, "edges": [{"to": "b", "weight": 2}]},
+//	    {"id": "b"}
+//	  ]
+//	}
+//
+// An undirected edge is listed once, under the vertex it was added from.
+// Vertex IDs are encoded using encoding/json, so V must be a type that
+// encoding/json can represent.
+
+import (
+	"encoding/json"
+)
+
+type jsonEdge[V comparable] struct {
+	To     V                 `json:"to"`
+	Weight float64           `json:"weight"`
+	Attrs  map[string]string `json:"attrs,omitempty"`
+}
+
+type jsonVertex[V comparable] struct {
+	ID    V                 `json:"id"`
+	Attrs map[string]string `json:"attrs,omitempty"`
+	Edges []jsonEdge[V]     `json:"edges,omitempty"`
+}
+
+type jsonGraph[V comparable] struct {
+	Directed bool            `json:"directed"`
+	Vertices []jsonVertex[V] `json:"vertices"`
+}
+
+// MarshalJSON encodes a graph in the adjacency-list JSON format
+func (g *Graph[V]) MarshalJSON() ([]byte, error) {
+	jg := jsonGraph[V]{Directed: g.directed, Vertices: make([]jsonVertex[V], len(g.order))}
+	for i, v := range g.order {
+		vtx := g.vertices[v]
+		jv := jsonVertex[V]{ID: v}
+		if len(vtx.attrs) != 0 {
+			jv.Attrs = vtx.attrs
+		}
+		for _, e := range vtx.out {
+			if g.directed || e.From == v {
+				jv.Edges = append(jv.Edges, jsonEdge[V]{e.To, e.Weight, e.Attrs})
+			}
+		}
+		jg.Vertices[i] = jv
+	}
+	return json.Marshal(jg)
+}
+
+// UnmarshalJSON decodes a graph in the adjacency-list JSON format, replacing
+// the contents of *g*
+func (g *Graph[V]) UnmarshalJSON(data []byte) error {
+	var jg jsonGraph[V]
+	if err := json.Unmarshal(data, &jg); err != nil {
+		return err
+	}
+
+	*g = *New[V](jg.Directed)
+	// Add every vertex first, so that the vertex order is preserved even when
+	// an edge refers to a vertex listed later
+	for _, jv := range jg.Vertices {
+		g.AddVertex(jv.ID)
+		if len(jv.Attrs) != 0 {
+			g.vertices[jv.ID].attrs = jv.Attrs
+		}
+	}
+	for _, jv := range jg.Vertices {
+		for _, je := range jv.Edges {
+			e := g.AddEdge(jv.ID, je.To, je.Weight)
+			e.Attrs = je.Attrs
+		}
+	}
+	return nil
+}
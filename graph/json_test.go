@@ -0,0 +1,58 @@
+package graph
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestJSONRoundTrip(t *testing.T) {
+	g := New[string](true)
+	g.AddEdge("b", "a", 1.5).SetAttr("label", "ba")
+	g.AddEdge("a", "c", 2)
+	g.VertexAttrs("c")["color"] = "red"
+
+	data, err := json.Marshal(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"directed":true,"vertices":[` +
+		`{"id":"b","edges":[{"to":"a","weight":1.5,"attrs":{"label":"ba"}}]},` +
+		`{"id":"a","edges":[{"to":"c","weight":2}]},` +
+		`{"id":"c","attrs":{"color":"red"}}]}`
+	if string(data) != expected {
+		t.Error(string(data))
+	}
+
+	var g2 Graph[string]
+	if err := json.Unmarshal(data, &g2); err != nil {
+		t.Fatal(err)
+	}
+	vertices := g2.Vertices()
+	if len(vertices) != 3 || vertices[0] != "b" || vertices[1] != "a" {
+		t.Error(vertices)
+	}
+	if e, ok := g2.Edge("b", "a"); !ok || e.Weight != 1.5 || e.Attrs["label"] != "ba" {
+		t.Fail()
+	}
+	if g2.VertexAttrs("c")["color"] != "red" {
+		t.Fail()
+	}
+}
+
+func TestJSONUndirected(t *testing.T) {
+	g := New[int](false)
+	g.AddEdge(1, 2, 1)
+	g.AddEdge(3, 1, 1)
+	data, _ := json.Marshal(g)
+
+	g2 := New[int](true)
+	if err := json.Unmarshal(data, g2); err != nil {
+		t.Fatal(err)
+	}
+	if g2.Directed() || g2.Size() != 2 {
+		t.Error(g2)
+	}
+	if _, ok := g2.Edge(1, 3); !ok {
+		t.Fail()
+	}
+}
@@ -0,0 +1,196 @@
+package graph
+
+// Bipartite matching
+//
+// A graph is bipartite if its vertices can be split into two sides, left and
+// right, such that every edge joins a left vertex to a right vertex. A matching
+// is a set of edges no two of which share a vertex, and a maximum matching is
+// one with as many edges as possible. The classic application is assignment:
+// left vertices are workers, right vertices are tasks, and an edge means that
+// a worker can do a task.
+//
+// Matchings are grown along augmenting paths, which start at an unmatched left
+// vertex, alternate between unmatched and matched edges, and end at an
+// unmatched right vertex. Flipping every edge on such a path grows the
+// matching by one. The Hopcroft-Karp algorithm finds many of these paths at
+// once: a BFS from every free left vertex arranges the graph in layers, and a
+// DFS then finds a maximal set of vertex-disjoint shortest augmenting paths
+// through the layers. Only O(sqrt V) such phases are needed, so the algorithm
+// runs in O(E sqrt V) time.
+
+import "errors"
+
+var ErrNotBipartite = errors.New("graph is not bipartite")
+
+// hopcroftKarp computes a maximum matching where adj[u] lists the right
+// vertices (numbered 0 to nRight-1) adjacent to left vertex u. It returns the
+// right vertex matched to each left vertex, or -1.
+func hopcroftKarp(adj [][]int, nRight int) []int {
+	const unreachable = -1
+	matchL := make([]int, len(adj))
+	matchR := make([]int, nRight)
+	dist := make([]int, len(adj))
+	for i := range matchL {
+		matchL[i] = -1
+	}
+	for i := range matchR {
+		matchR[i] = -1
+	}
+
+	// bfs layers the left vertices by the length of the shortest alternating
+	// path from a free left vertex, and reports whether any augmenting path
+	// exists
+	bfs := func() bool {
+		var queue []int
+		for u := range adj {
+			if matchL[u] < 0 {
+				dist[u] = 0
+				queue = append(queue, u)
+			} else {
+				dist[u] = unreachable
+			}
+		}
+		found := false
+		for len(queue) != 0 {
+			u := queue[0]
+			queue = queue[1:]
+			for _, v := range adj[u] {
+				w := matchR[v]
+				if w < 0 {
+					found = true
+				} else if dist[w] == unreachable {
+					dist[w] = dist[u] + 1
+					queue = append(queue, w)
+				}
+			}
+		}
+		return found
+	}
+
+	// dfs looks for an augmenting path from u through successive layers, and
+	// flips it if found
+	var dfs func(u int) bool
+	dfs = func(u int) bool {
+		for _, v := range adj[u] {
+			w := matchR[v]
+			if w < 0 || (dist[w] == dist[u]+1 && dfs(w)) {
+				matchL[u] = v
+				matchR[v] = u
+				return true
+			}
+		}
+		// No path through u in this phase
+		dist[u] = unreachable
+		return false
+	}
+
+	for bfs() {
+		for u := range adj {
+			if matchL[u] < 0 {
+				dfs(u)
+			}
+		}
+	}
+	return matchL
+}
+
+// Bipartition splits the vertices of a graph into two sides such that every
+// edge joins the two sides, ignoring edge directions. It returns
+// ErrNotBipartite if this is impossible, i.e. the graph has an odd cycle.
+func (g *Graph[V]) Bipartition() ([]V, []V, error) {
+	neighbors := make(map[V][]V, len(g.order))
+	for _, e := range g.Edges() {
+		neighbors[e.From] = append(neighbors[e.From], e.To)
+		neighbors[e.To] = append(neighbors[e.To], e.From)
+	}
+
+	side := make(map[V]bool, len(g.order))
+	var left, right []V
+	for _, start := range g.order {
+		if _, ok := side[start]; ok {
+			continue
+		}
+		side[start] = true
+		queue := []V{start}
+		for len(queue) != 0 {
+			u := queue[0]
+			queue = queue[1:]
+			if side[u] {
+				left = append(left, u)
+			} else {
+				right = append(right, u)
+			}
+			for _, w := range neighbors[u] {
+				if s, ok := side[w]; !ok {
+					side[w] = !side[u]
+					queue = append(queue, w)
+				} else if s == side[u] {
+					return nil, nil, ErrNotBipartite
+				}
+			}
+		}
+	}
+	return left, right, nil
+}
+
+// MaximumMatching returns a maximum matching between the vertices in *left*
+// and the rest of the graph, mapping each matched left vertex to its partner.
+// Only edges leaving left vertices are considered (in an undirected graph,
+// every edge incident to one). Edges between two left vertices are ignored.
+func (g *Graph[V]) MaximumMatching(left []V) (map[V]V, error) {
+	isLeft := make(map[V]bool, len(left))
+	for _, v := range left {
+		if !g.HasVertex(v) {
+			return nil, ErrNoVertex
+		}
+		isLeft[v] = true
+	}
+
+	var right []V
+	rightIndex := make(map[V]int)
+	adj := make([][]int, len(left))
+	for i, u := range left {
+		for _, w := range g.Neighbors(u) {
+			if isLeft[w] {
+				continue
+			}
+			j, ok := rightIndex[w]
+			if !ok {
+				j = len(right)
+				rightIndex[w] = j
+				right = append(right, w)
+			}
+			adj[i] = append(adj[i], j)
+		}
+	}
+
+	matching := make(map[V]V)
+	for i, j := range hopcroftKarp(adj, len(right)) {
+		if j >= 0 {
+			matching[left[i]] = right[j]
+		}
+	}
+	return matching, nil
+}
+
+// Assign solves an unweighted assignment problem: it assigns as many workers
+// as possible to distinct tasks, such that allowed(worker, task) holds for
+// every assignment. Unassigned workers are absent from the result.
+func Assign[W, T comparable](workers []W, tasks []T, allowed func(W, T) bool) map[W]T {
+	adj := make([][]int, len(workers))
+	for i, w := range workers {
+		for j, t := range tasks {
+			if allowed(w, t) {
+				adj[i] = append(adj[i], j)
+			}
+		}
+	}
+
+	assignment := make(map[W]T)
+	for i, j := range hopcroftKarp(adj, len(tasks)) {
+		if j >= 0 {
+			assignment[workers[i]] = tasks[j]
+		}
+	}
+	return assignment
+}
@@ -0,0 +1,92 @@
+package graph
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMaximumMatching(t *testing.T) {
+	// A perfect matching exists (a-1, b-3, c-2, d-4), but the greedy choice of
+	// a-1, b-2 blocks it
+	g := New[string](false)
+	g.AddEdge("a", "1", 1)
+	g.AddEdge("a", "2", 1)
+	g.AddEdge("b", "2", 1)
+	g.AddEdge("b", "3", 1)
+	g.AddEdge("c", "2", 1)
+	g.AddEdge("d", "4", 1)
+	g.AddEdge("d", "1", 1)
+
+	matching, err := g.MaximumMatching([]string{"a", "b", "c", "d"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matching) != 4 {
+		t.Fatal(matching)
+	}
+	used := make(map[string]bool)
+	for u, v := range matching {
+		if _, ok := g.Edge(u, v); !ok {
+			t.Errorf("%s-%s is not an edge", u, v)
+		}
+		if used[v] {
+			t.Errorf("%s matched twice", v)
+		}
+		used[v] = true
+	}
+}
+
+func TestMatchingUnbalanced(t *testing.T) {
+	g := New[int](true)
+	g.AddEdge(1, 10, 1)
+	g.AddEdge(2, 10, 1)
+	g.AddEdge(3, 10, 1)
+	g.AddEdge(3, 11, 1)
+	matching, _ := g.MaximumMatching([]int{1, 2, 3})
+	if len(matching) != 2 || matching[3] != 11 {
+		t.Error(matching)
+	}
+
+	if _, err := g.MaximumMatching([]int{4}); err != ErrNoVertex {
+		t.Error(err)
+	}
+}
+
+func TestBipartition(t *testing.T) {
+	g := New[int](false)
+	g.AddEdge(1, 2, 1)
+	g.AddEdge(2, 3, 1)
+	g.AddEdge(3, 4, 1)
+	g.AddVertex(5)
+	left, right, err := g.Bipartition()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(left) != 3 || len(right) != 2 {
+		t.Error(left, right)
+	}
+
+	g.AddEdge(4, 2, 1)
+	if _, _, err := g.Bipartition(); err != ErrNotBipartite {
+		t.Error(err)
+	}
+}
+
+func TestAssign(t *testing.T) {
+	workers := []string{"alice", "bob", "carol"}
+	tasks := []string{"cooking", "baking", "cleaning"}
+	skills := map[string]string{
+		"alice": "cooking baking",
+		"bob":   "cooking",
+		"carol": "baking cleaning",
+	}
+	assignment := Assign(workers, tasks, func(w, t string) bool {
+		return strings.Contains(skills[w], t)
+	})
+	if len(assignment) != 3 {
+		t.Fatal(assignment)
+	}
+	if assignment["bob"] != "cooking" || assignment["alice"] != "baking" || assignment["carol"] != "cleaning" {
+		t.Error(assignment)
+	}
+}
@@ -0,0 +1,103 @@
+package graph
+
+// Matrix is a graph stored as a dense adjacency matrix. Vertices are numbered
+// from 0 to Order()-1 in the order they were added to the source Graph, and
+// the weight of the edge from vertex i to vertex j is held at position
+// i*Order()+j.
+type Matrix[V comparable] struct {
+	directed bool
+	ids      []V
+	index    map[V]int
+	weights  []float64
+	present  []bool
+}
+
+// ToMatrix converts a graph to an adjacency matrix
+func (g *Graph[V]) ToMatrix() *Matrix[V] {
+	ids, index := g.indexVertices()
+	n := len(ids)
+	m := &Matrix[V]{
+		directed: g.directed,
+		ids:      ids,
+		index:    index,
+		weights:  make([]float64, n*n),
+		present:  make([]bool, n*n),
+	}
+	for _, e := range g.Edges() {
+		i, j := index[e.From], index[e.To]
+		m.set(i, j, e.Weight)
+		if !g.directed {
+			m.set(j, i, e.Weight)
+		}
+	}
+	return m
+}
+
+func (m *Matrix[V]) set(i, j int, weight float64) {
+	m.weights[i*len(m.ids)+j] = weight
+	m.present[i*len(m.ids)+j] = true
+}
+
+// Directed returns true for a directed graph
+func (m *Matrix[V]) Directed() bool {
+	return m.directed
+}
+
+// Order returns the number of vertices
+func (m *Matrix[V]) Order() int {
+	return len(m.ids)
+}
+
+// Vertices returns the vertices in index order
+func (m *Matrix[V]) Vertices() []V {
+	ids := make([]V, len(m.ids))
+	copy(ids, m.ids)
+	return ids
+}
+
+// Index returns the index of a vertex
+func (m *Matrix[V]) Index(v V) (int, bool) {
+	i, ok := m.index[v]
+	return i, ok
+}
+
+// Vertex returns the vertex with index *i*
+func (m *Matrix[V]) Vertex(i int) V {
+	return m.ids[i]
+}
+
+// At returns the weight of the edge from vertex index *i* to vertex index *j*
+func (m *Matrix[V]) At(i, j int) (float64, bool) {
+	k := i*len(m.ids) + j
+	return m.weights[k], m.present[k]
+}
+
+// Weight returns the weight of the edge from one vertex to another
+func (m *Matrix[V]) Weight(from, to V) (float64, bool) {
+	i, ok := m.index[from]
+	if !ok {
+		return 0, false
+	}
+	j, ok := m.index[to]
+	if !ok {
+		return 0, false
+	}
+	return m.At(i, j)
+}
+
+// Neighbors returns the vertices reachable from *v* along one edge, in index
+// order. This scans a whole row of the matrix.
+func (m *Matrix[V]) Neighbors(v V) []V {
+	i, ok := m.index[v]
+	if !ok {
+		return nil
+	}
+	var neighbors []V
+	row := m.present[i*len(m.ids) : (i+1)*len(m.ids)]
+	for j, present := range row {
+		if present {
+			neighbors = append(neighbors, m.ids[j])
+		}
+	}
+	return neighbors
+}
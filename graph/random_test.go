@@ -0,0 +1,127 @@
+package graph_test
+
+// These tests run on graphs from package gen, which imports graph, so they
+// are in an external test package
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/njwilson23/datastructures/gen"
+	"github.com/njwilson23/datastructures/graph"
+)
+
+// Random weights are not exact in binary, and the algorithms add them up in
+// different orders, so distances are compared to within a rounding error
+func near(a, b float64) bool {
+	return a == b || math.Abs(a-b) < 1e-9
+}
+
+func TestDijkstraMatchesBellmanFord(t *testing.T) {
+	for _, directed := range []bool{true, false} {
+		g := gen.New(11).ErdosRenyi(60, 0.08, directed)
+		d, err := g.Dijkstra(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bf, err := g.BellmanFord(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, v := range g.Vertices() {
+			d1, ok1 := d.DistanceTo(v)
+			d2, ok2 := bf.DistanceTo(v)
+			if ok1 != ok2 || !near(d1, d2) {
+				t.Fatalf("vertex %d: %f %f", v, d1, d2)
+			}
+		}
+	}
+}
+
+func TestFloydWarshallMatchesBellmanFord(t *testing.T) {
+	// Non-negative weights rule out negative cycles
+	g := gen.New(7).ErdosRenyi(30, 0.2, true)
+	ap, err := g.FloydWarshall()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, u := range g.Vertices() {
+		sp, err := g.BellmanFord(u)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, v := range g.Vertices() {
+			d1, ok1 := ap.Distance(u, v)
+			d2, ok2 := sp.DistanceTo(v)
+			if ok1 != ok2 || !near(d1, d2) {
+				t.Fatalf("%d->%d: %f %f", u, v, d1, d2)
+			}
+		}
+	}
+}
+
+// Benchmarks comparing the representations
+//
+// The sparse graph has an average degree of 4, and the dense graph has an
+// edge between half of all pairs of vertices. Looking up an edge through
+// Interface is dominated by translating vertex IDs to indices, except on the
+// dense graph where the matrix is clearly fastest. Neighbour iteration is
+// fastest in CSR, and by far the slowest in the matrix on the sparse graph,
+// since it scans a whole row per vertex.
+
+var benchmarkGraphs = []struct {
+	name string
+	n    int
+	p    float64
+}{
+	{"sparse", 1000, 0.004},
+	{"dense", 300, 0.5},
+}
+
+func BenchmarkWeight(b *testing.B) {
+	for _, bg := range benchmarkGraphs {
+		g := gen.New(1).ErdosRenyi(bg.n, bg.p, true)
+		reps := map[string]graph.Interface[int]{"Graph": g, "Matrix": g.ToMatrix(), "CSR": g.ToCSR()}
+		for name, rep := range reps {
+			b.Run(fmt.Sprintf("%s/%s", bg.name, name), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					rep.Weight(i%bg.n, (i*7919)%bg.n)
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkNeighbors(b *testing.B) {
+	for _, bg := range benchmarkGraphs {
+		g := gen.New(1).ErdosRenyi(bg.n, bg.p, true)
+		m := g.ToMatrix()
+		c := g.ToCSR()
+		b.Run(bg.name+"/Graph", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				for _, e := range g.Out(i % bg.n) {
+					_ = e.Weight
+				}
+			}
+		})
+		b.Run(bg.name+"/Matrix", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				u := i % bg.n
+				for v := 0; v != bg.n; v++ {
+					if w, ok := m.At(u, v); ok {
+						_ = w
+					}
+				}
+			}
+		})
+		b.Run(bg.name+"/CSR", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_, weights := c.Row(i % bg.n)
+				for _, w := range weights {
+					_ = w
+				}
+			}
+		})
+	}
+}
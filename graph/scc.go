@@ -0,0 +1,133 @@
+package graph
+
+// Strongly connected components and cycle detection
+//
+// A strongly connected component (SCC) of a directed graph is a maximal set of
+// vertices in which every vertex can reach every other. Collapsing each SCC to
+// a single vertex leaves a directed acyclic graph, so SCCs are the natural
+// units for e.g. resolving a dependency graph that contains cycles.
+//
+// Tarjan's algorithm finds every SCC in a single depth-first search. Each
+// vertex is numbered in the order it is first visited (its index), and pushed
+// onto a stack. The "low-link" of a vertex is the smallest index reachable
+// from it through its DFS subtree plus at most one edge back to a vertex still
+// on the stack. A vertex whose low-link equals its own index is the root of an
+// SCC, and the SCC is every vertex above it on the stack. The algorithm runs
+// in O(V + E) time.
+//
+// In an undirected graph every edge can be followed both ways, so the SCCs are
+// simply the connected components.
+
+// StronglyConnectedComponents returns the strongly connected components of a
+// graph. Components are returned in reverse topological order: no edge leads
+// from a component to one listed after it.
+func (g *Graph[V]) StronglyConnectedComponents() [][]V {
+	var components [][]V
+	index := make(map[V]int, len(g.order))
+	low := make(map[V]int, len(g.order))
+	onStack := make(map[V]bool)
+	var stack []V
+
+	var strongConnect func(v V)
+	strongConnect = func(v V) {
+		index[v] = len(index)
+		low[v] = index[v]
+		stack = append(stack, v)
+		onStack[v] = true
+
+		for _, w := range g.Neighbors(v) {
+			if _, visited := index[w]; !visited {
+				strongConnect(w)
+				low[v] = min(low[v], low[w])
+			} else if onStack[w] {
+				low[v] = min(low[v], index[w])
+			}
+		}
+
+		if low[v] == index[v] {
+			var component []V
+			for {
+				w := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[w] = false
+				component = append(component, w)
+				if w == v {
+					break
+				}
+			}
+			components = append(components, component)
+		}
+	}
+
+	for _, v := range g.order {
+		if _, visited := index[v]; !visited {
+			strongConnect(v)
+		}
+	}
+	return components
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// FindCycle returns the vertices of a cycle in the graph, in order, such that
+// there is an edge from each vertex to the next and from the last back to the
+// first. The second return value is false if the graph has no cycles.
+//
+// In an undirected graph, an edge is not considered a cycle by itself (i.e.
+// the search never returns along the edge it arrived by), but loops are.
+func (g *Graph[V]) FindCycle() ([]V, bool) {
+	// Vertices are white (absent from state) until they are first visited,
+	// gray while their descendants are being searched, and black when done. An
+	// edge to a gray vertex closes a cycle.
+	const (
+		gray = iota + 1
+		black
+	)
+	state := make(map[V]int, len(g.order))
+	parent := make(map[V]V)
+
+	var cycle []V
+	var visit func(v V, via *Edge[V]) bool
+	visit = func(v V, via *Edge[V]) bool {
+		state[v] = gray
+		vtx := g.vertices[v]
+		for _, e := range vtx.out {
+			if e == via {
+				continue
+			}
+			w := vtx.other(e)
+			switch state[w] {
+			case 0:
+				parent[w] = v
+				if visit(w, e) {
+					return true
+				}
+			case gray:
+				// Follow parents back from v to w to recover the cycle
+				cycle = []V{v}
+				for u := v; u != w; {
+					u = parent[u]
+					cycle = append(cycle, u)
+				}
+				for i, j := 0, len(cycle)-1; i < j; i, j = i+1, j-1 {
+					cycle[i], cycle[j] = cycle[j], cycle[i]
+				}
+				return true
+			}
+		}
+		state[v] = black
+		return false
+	}
+
+	for _, v := range g.order {
+		if state[v] == 0 && visit(v, nil) {
+			return cycle, true
+		}
+	}
+	return nil, false
+}
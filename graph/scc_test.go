@@ -0,0 +1,104 @@
+package graph
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestStronglyConnectedComponents(t *testing.T) {
+	g := New[string](true)
+	g.AddEdge("a", "b", 1)
+	g.AddEdge("b", "c", 1)
+	g.AddEdge("c", "a", 1)
+	g.AddEdge("c", "d", 1)
+	g.AddEdge("d", "e", 1)
+	g.AddEdge("e", "d", 1)
+	g.AddVertex("f")
+
+	components := g.StronglyConnectedComponents()
+	if len(components) != 3 {
+		t.Fatal(components)
+	}
+	position := make(map[string]int)
+	for i, component := range components {
+		sort.Strings(component)
+		for _, v := range component {
+			position[v] = i
+		}
+	}
+	if position["a"] != position["b"] || position["b"] != position["c"] {
+		t.Error(components)
+	}
+	if position["d"] != position["e"] || position["d"] == position["a"] {
+		t.Error(components)
+	}
+	// Reverse topological order: {d, e} is reachable from {a, b, c}
+	if position["d"] > position["a"] {
+		t.Error(components)
+	}
+}
+
+func TestComponentsUndirected(t *testing.T) {
+	g := New[int](false)
+	g.AddEdge(1, 2, 1)
+	g.AddEdge(2, 3, 1)
+	g.AddEdge(4, 5, 1)
+	if components := g.StronglyConnectedComponents(); len(components) != 2 {
+		t.Error(components)
+	}
+}
+
+// checkCycle verifies that *cycle* is a cycle in *g*
+func checkCycle(t *testing.T, g *Graph[int], cycle []int) {
+	if len(cycle) == 0 {
+		t.Fatal("empty cycle")
+	}
+	for i := range cycle {
+		if _, ok := g.Edge(cycle[i], cycle[(i+1)%len(cycle)]); !ok {
+			t.Fatalf("%v is not a cycle", cycle)
+		}
+	}
+}
+
+func TestFindCycle(t *testing.T) {
+	g := New[int](true)
+	g.AddEdge(1, 2, 1)
+	g.AddEdge(2, 3, 1)
+	g.AddEdge(1, 3, 1)
+	if cycle, ok := g.FindCycle(); ok {
+		t.Error(cycle)
+	}
+
+	g.AddEdge(3, 4, 1)
+	g.AddEdge(4, 2, 1)
+	cycle, ok := g.FindCycle()
+	if !ok || len(cycle) != 3 {
+		t.Fatal(cycle)
+	}
+	checkCycle(t, g, cycle)
+
+	g = New[int](true)
+	g.AddEdge(7, 7, 1)
+	cycle, ok = g.FindCycle()
+	if !ok || len(cycle) != 1 {
+		t.Fatal(cycle)
+	}
+	checkCycle(t, g, cycle)
+}
+
+func TestFindCycleUndirected(t *testing.T) {
+	g := New[int](false)
+	g.AddEdge(1, 2, 1)
+	g.AddEdge(2, 3, 1)
+	g.AddEdge(2, 4, 1)
+	if cycle, ok := g.FindCycle(); ok {
+		t.Error(cycle)
+	}
+
+	g.AddEdge(4, 1, 1)
+	cycle, ok := g.FindCycle()
+	if !ok || len(cycle) != 3 {
+		t.Fatal(cycle)
+	}
+	checkCycle(t, g, cycle)
+}
@@ -0,0 +1,142 @@
+/*
+ * Package grid implements a uniform grid, the simplest spatial index for
+ * points in the plane.
+ *
+ * The plane is divided into square cells of a fixed size, and each point is
+ * filed under the cell it falls in. Finding the cell of a point is one
+ * division per axis, so inserting and removing points is O(1) (plus a scan
+ * of the cell when removing). A range query visits the cells that overlap the
+ * query box, and checks each of their points against the box. A radius query
+ * does the same for the square around the circle.
+ *
+ * Only occupied cells are stored, in a map, so the grid need not be bounded
+ * and empty regions cost nothing.
+ *
+ * A grid works well when the points are spread fairly evenly and queries are
+ * a few cells across. A dense cluster puts many points in one cell, and a
+ * query much larger than a cell visits many cells. A k-d tree (see package
+ * kdtree) adapts to both, at the cost of O(log n) insertion and a tree that
+ * must be rebuilt to stay balanced. The benchmarks in grid_test.go compare
+ * the two.
+ */
+
+package grid
+
+import (
+	"math"
+
+	"github.com/njwilson23/datastructures/kdtree"
+)
+
+type cell [2]int
+
+// Grid is a uniform grid of points in two dimensions, using the point and box
+// types of package kdtree
+type Grid struct {
+	size  float64
+	cells map[cell][]kdtree.Point
+	len   int
+}
+
+// New creates an empty Grid with square cells of side *cellSize*
+func New(cellSize float64) *Grid {
+	if !(cellSize > 0) {
+		panic("grid: cell size must be positive")
+	}
+	return &Grid{size: cellSize, cells: make(map[cell][]kdtree.Point)}
+}
+
+// index returns the index of the cell that a coordinate falls in
+func (g *Grid) index(x float64) int {
+	return int(math.Floor(x / g.size))
+}
+
+func (g *Grid) cellOf(p kdtree.Point) cell {
+	if len(p) != 2 {
+		panic("grid: points must have two dimensions")
+	}
+	return cell{g.index(p[0]), g.index(p[1])}
+}
+
+// Len returns the number of points in the grid
+func (g *Grid) Len() int {
+	return g.len
+}
+
+// Insert adds a point to the grid
+func (g *Grid) Insert(p kdtree.Point) {
+	c := g.cellOf(p)
+	g.cells[c] = append(g.cells[c], p)
+	g.len++
+}
+
+// Remove removes one copy of a point from the grid, and returns false if the
+// point is not present
+func (g *Grid) Remove(p kdtree.Point) bool {
+	c := g.cellOf(p)
+	points := g.cells[c]
+	for i, q := range points {
+		if q[0] == p[0] && q[1] == p[1] {
+			last := len(points) - 1
+			points[i] = points[last]
+			points[last] = nil
+			if last == 0 {
+				delete(g.cells, c)
+			} else {
+				g.cells[c] = points[:last]
+			}
+			g.len--
+			return true
+		}
+	}
+	return false
+}
+
+// visit calls *f* with the points in every occupied cell overlapping *box*,
+// until *f* returns false
+func (g *Grid) visit(box kdtree.BBox, f func(kdtree.Point) bool) {
+	lo, hi := g.cellOf(box.Min), g.cellOf(box.Max)
+	if lo[0] > hi[0] || lo[1] > hi[1] {
+		return
+	}
+	// When the box covers more cells than are occupied, it is cheaper to
+	// check every occupied cell than to look up every covered one
+	if float64(hi[0]-lo[0]+1)*float64(hi[1]-lo[1]+1) > float64(len(g.cells)) {
+		for c, points := range g.cells {
+			if lo[0] <= c[0] && c[0] <= hi[0] && lo[1] <= c[1] && c[1] <= hi[1] {
+				for _, p := range points {
+					if !f(p) {
+						return
+					}
+				}
+			}
+		}
+		return
+	}
+	for i := lo[0]; i <= hi[0]; i++ {
+		for j := lo[1]; j <= hi[1]; j++ {
+			for _, p := range g.cells[cell{i, j}] {
+				if !f(p) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// QueryRange calls *f* with each point inside *box*, until *f* returns false
+func (g *Grid) QueryRange(box kdtree.BBox, f func(kdtree.Point) bool) {
+	g.visit(box, func(p kdtree.Point) bool {
+		return !box.Contains(p) || f(p)
+	})
+}
+
+// QueryRadius calls *f* with each point within distance *r* of *p*, until *f*
+// returns false
+func (g *Grid) QueryRadius(p kdtree.Point, r float64, f func(kdtree.Point) bool) {
+	box := kdtree.BBox{Min: kdtree.Point{p[0] - r, p[1] - r}, Max: kdtree.Point{p[0] + r, p[1] + r}}
+	g.visit(box, func(q kdtree.Point) bool {
+		dx, dy := q[0]-p[0], q[1]-p[1]
+		return dx*dx+dy*dy > r*r || f(q)
+	})
+}
@@ -0,0 +1,155 @@
+package grid
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/njwilson23/datastructures/gen"
+	"github.com/njwilson23/datastructures/kdtree"
+)
+
+// clustered returns *n* points from gen.Clustered, scaled to about
+// [-100, 100) in each dimension
+func clustered(g *gen.Generator, n int) []kdtree.Point {
+	points := make([]kdtree.Point, n)
+	for i, p := range g.Clustered(n, 2, 10, 0.05) {
+		points[i] = kdtree.Point{p[0]*200 - 100, p[1]*200 - 100}
+	}
+	return points
+}
+
+func collect(query func(func(kdtree.Point) bool)) int {
+	n := 0
+	query(func(kdtree.Point) bool {
+		n++
+		return true
+	})
+	return n
+}
+
+func TestInsertRemove(t *testing.T) {
+	g := New(1)
+	g.Insert(kdtree.Point{0.5, 0.5})
+	g.Insert(kdtree.Point{-0.5, 0.5})
+	g.Insert(kdtree.Point{0.5, 0.5})
+	if g.Len() != 3 || len(g.cells) != 2 {
+		t.Error(g.Len(), len(g.cells))
+	}
+	if g.Remove(kdtree.Point{0.25, 0.5}) {
+		t.Error("removed a missing point")
+	}
+	for i := 0; i != 2; i++ {
+		if !g.Remove(kdtree.Point{0.5, 0.5}) {
+			t.Error("copy", i, "not removed")
+		}
+	}
+	if g.Len() != 1 || len(g.cells) != 1 {
+		t.Error(g.Len(), len(g.cells))
+	}
+}
+
+func TestQueries(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	points := clustered(gen.New(1), 2000)
+	for _, size := range []float64{0.5, 5, 500} {
+		g := New(size)
+		for _, p := range points {
+			g.Insert(p)
+		}
+		tree := kdtree.Build(2, append([]kdtree.Point{}, points...))
+		for i := 0; i != 50; i++ {
+			x, y := r.Float64()*240-120, r.Float64()*240-120
+			box := kdtree.BBox{Min: kdtree.Point{x, y}, Max: kdtree.Point{x + r.Float64()*50, y + r.Float64()*50}}
+			found := collect(func(f func(kdtree.Point) bool) { g.QueryRange(box, f) })
+			if expected := tree.Count(box); found != expected {
+				t.Fatalf("size %v: range query found %d points, expected %d", size, found, expected)
+			}
+
+			p, radius := kdtree.Point{x, y}, r.Float64()*20
+			found = collect(func(f func(kdtree.Point) bool) { g.QueryRadius(p, radius, f) })
+			expected := collect(func(f func(kdtree.Point) bool) { tree.QueryRadius(p, radius, f) })
+			if found != expected {
+				t.Fatalf("size %v: radius query found %d points, expected %d", size, found, expected)
+			}
+		}
+	}
+}
+
+func TestQueryStop(t *testing.T) {
+	g := New(1)
+	for i := 0; i != 5; i++ {
+		g.Insert(kdtree.Point{float64(i), 0})
+	}
+	n := 0
+	g.QueryRange(kdtree.BBox{Min: kdtree.Point{-1, -1}, Max: kdtree.Point{10, 1}}, func(kdtree.Point) bool {
+		n++
+		return n < 2
+	})
+	if n != 2 {
+		t.Error(n)
+	}
+}
+
+// The benchmarks compare a grid with a k-d tree on clustered points
+
+func BenchmarkInsert(b *testing.B) {
+	points := clustered(gen.New(1), 100000)
+	b.Run("Grid", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			g := New(2)
+			for _, p := range points {
+				g.Insert(p)
+			}
+		}
+	})
+	b.Run("KDTree", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			tree := kdtree.New(2)
+			for _, p := range points {
+				tree.Insert(p)
+			}
+		}
+	})
+}
+
+func BenchmarkQuery(b *testing.B) {
+	src := gen.New(1)
+	points := clustered(src, 100000)
+	g := New(2)
+	for _, p := range points {
+		g.Insert(p)
+	}
+	tree := kdtree.Build(2, points)
+	centres := clustered(src, 1000)
+	ignore := func(kdtree.Point) bool { return true }
+	for _, size := range []struct {
+		name string
+		side float64
+	}{{"Small", 2}, {"Large", 20}} {
+		side := size.side
+		boxes := make([]kdtree.BBox, len(centres))
+		for i, c := range centres {
+			boxes[i] = kdtree.BBox{Min: kdtree.Point{c[0], c[1]}, Max: kdtree.Point{c[0] + side, c[1] + side}}
+		}
+		b.Run("Grid/Range"+size.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				g.QueryRange(boxes[i%len(boxes)], ignore)
+			}
+		})
+		b.Run("KDTree/Range"+size.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				tree.Query(boxes[i%len(boxes)], ignore)
+			}
+		})
+		b.Run("Grid/Radius"+size.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				g.QueryRadius(centres[i%len(centres)], side/2, ignore)
+			}
+		})
+		b.Run("KDTree/Radius"+size.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				tree.QueryRadius(centres[i%len(centres)], side/2, ignore)
+			}
+		})
+	}
+}
@@ -0,0 +1,314 @@
+/*
+ * Package hamt implements a persistent hash array mapped trie (HAMT).
+ *
+ * A HAMT is a hash table arranged as a trie over the bits of each key's hash.
+ * Every node covers 5 bits of the hash, and so has up to 32 children. Rather
+ * than allocating 32 slots per node, a node stores a 32-bit bitmap saying
+ * which children exist, and a compact slice holding only those children. The
+ * position of a child in the slice is the number of bits set in the bitmap
+ * below its own bit, which is a single popcount instruction.
+ *
+ *    hash:   ...  01101  00010  10011
+ *                 level2 level1 level0
+ *
+ *    bitmap: 00000000000010000000000000100100   entries: [a, b, c]
+ *                        ^                ^  ^
+ *                    bit 19 -> c       5 -> b 2 -> a
+ *
+ * The map is persistent (immutable): Put and Delete return a new Map and leave
+ * the original untouched. Only the nodes on the path from the root to the
+ * changed entry are copied, and every other node is shared between the old and
+ * new versions ("structural sharing"). Since the trie is at most 13 levels
+ * deep for a 64-bit hash, and is usually far shallower, operations are
+ * effectively O(1).
+ *
+ * Keys with identical hashes are stored together in a "collision" node and
+ * searched linearly, as in a chained hash table (see package hashtable).
+ */
+
+package hamt
+
+import (
+	"math/bits"
+
+	"github.com/njwilson23/datastructures/hashtable"
+)
+
+const (
+	bitsPerLevel = 5
+	levelMask    = 1<<bitsPerLevel - 1
+)
+
+// entry is either a key-value pair (a leaf) or a pointer to a child node
+type entry struct {
+	hash  uint64
+	key   hashtable.Hashable
+	value interface{}
+	child *node
+}
+
+type node struct {
+	bitmap    uint32
+	entries   []entry
+	collision bool
+}
+
+// Map is a persistent hash map. The zero value is an empty map.
+type Map struct {
+	root *node
+	size int
+}
+
+// New returns an empty Map
+func New() *Map {
+	return &Map{}
+}
+
+// mix spreads the bits of a key's hash, since hashes such as the rune sum of
+// hashtable.HashString only vary in their lowest bits, while the trie needs
+// every group of 5 bits to vary
+func mix(h int) uint64 {
+	x := uint64(h)
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
+
+// index returns the position of the child for *hash* at level *shift* within
+// the entries of a node, and the bitmap bit for that child
+func (n *node) index(hash uint64, shift uint) (int, uint32) {
+	bit := uint32(1) << ((hash >> shift) & levelMask)
+	return bits.OnesCount32(n.bitmap & (bit - 1)), bit
+}
+
+// Len returns the number of keys in the map
+func (m *Map) Len() int {
+	return m.size
+}
+
+// Get returns the value associated with a key, and whether it was found
+func (m *Map) Get(key hashtable.Hashable) (interface{}, bool) {
+	hash := mix(key.Hash())
+	n := m.root
+	shift := uint(0)
+	for n != nil {
+		if n.collision {
+			for _, e := range n.entries {
+				if e.key == key {
+					return e.value, true
+				}
+			}
+			return nil, false
+		}
+		i, bit := n.index(hash, shift)
+		if n.bitmap&bit == 0 {
+			return nil, false
+		}
+		e := n.entries[i]
+		if e.child == nil {
+			if e.key == key {
+				return e.value, true
+			}
+			return nil, false
+		}
+		n = e.child
+		shift += bitsPerLevel
+	}
+	return nil, false
+}
+
+// Put returns a new Map in which *key* is associated with *value*
+func (m *Map) Put(key hashtable.Hashable, value interface{}) *Map {
+	leaf := entry{hash: mix(key.Hash()), key: key, value: value}
+	if m.root == nil {
+		return &Map{&node{bitmap: bitFor(leaf.hash, 0), entries: []entry{leaf}}, 1}
+	}
+	root, added := put(m.root, leaf, 0)
+	size := m.size
+	if added {
+		size++
+	}
+	return &Map{root, size}
+}
+
+func bitFor(hash uint64, shift uint) uint32 {
+	return uint32(1) << ((hash >> shift) & levelMask)
+}
+
+// put returns a copy of *n* with *leaf* added, and whether the key was new
+// (rather than replacing an existing value)
+func put(n *node, leaf entry, shift uint) (*node, bool) {
+	if n.collision {
+		if leaf.hash != n.entries[0].hash {
+			// The new key only shares part of its hash with the colliding keys,
+			// so this level has to distinguish between them
+			return nest(n, leaf, shift), true
+		}
+		for i, e := range n.entries {
+			if e.key == leaf.key {
+				return n.replace(i, leaf), false
+			}
+		}
+		return n.insert(len(n.entries), 0, leaf), true
+	}
+
+	i, bit := n.index(leaf.hash, shift)
+	if n.bitmap&bit == 0 {
+		return n.insert(i, bit, leaf), true
+	}
+
+	e := n.entries[i]
+	if e.child != nil {
+		child, added := put(e.child, leaf, shift+bitsPerLevel)
+		return n.replace(i, entry{child: child}), added
+	}
+	if e.key == leaf.key {
+		return n.replace(i, leaf), false
+	}
+	// Two different keys share this slot, so push both down a level
+	return n.replace(i, entry{child: split(e, leaf, shift+bitsPerLevel)}), true
+}
+
+// split creates a subtree containing two leaves that collided at the level
+// above *shift*
+func split(a, b entry, shift uint) *node {
+	if a.hash == b.hash {
+		return &node{entries: []entry{a, b}, collision: true}
+	}
+	ia := (a.hash >> shift) & levelMask
+	ib := (b.hash >> shift) & levelMask
+	if ia == ib {
+		return &node{
+			bitmap:  uint32(1) << ia,
+			entries: []entry{{child: split(a, b, shift+bitsPerLevel)}},
+		}
+	}
+	if ib < ia {
+		a, b = b, a
+	}
+	return &node{bitmap: bitFor(a.hash, shift) | bitFor(b.hash, shift), entries: []entry{a, b}}
+}
+
+// nest creates a node at level *shift* containing a collision node and a leaf
+// whose hash differs from the colliding hash
+func nest(collision *node, leaf entry, shift uint) *node {
+	hash := collision.entries[0].hash
+	ic := (hash >> shift) & levelMask
+	il := (leaf.hash >> shift) & levelMask
+	if ic == il {
+		return &node{
+			bitmap:  uint32(1) << ic,
+			entries: []entry{{child: nest(collision, leaf, shift+bitsPerLevel)}},
+		}
+	}
+	entries := []entry{{child: collision}, leaf}
+	if il < ic {
+		entries[0], entries[1] = entries[1], entries[0]
+	}
+	return &node{bitmap: bitFor(hash, shift) | bitFor(leaf.hash, shift), entries: entries}
+}
+
+// Delete returns a new Map without *key*. If the key is not present, the
+// original Map is returned.
+func (m *Map) Delete(key hashtable.Hashable) *Map {
+	if m.root == nil {
+		return m
+	}
+	root, removed := remove(m.root, mix(key.Hash()), key, 0)
+	if !removed {
+		return m
+	}
+	return &Map{root, m.size - 1}
+}
+
+// remove returns a copy of *n* without *key* (or nil if the copy would be
+// empty), and whether the key was found
+func remove(n *node, hash uint64, key hashtable.Hashable, shift uint) (*node, bool) {
+	if n.collision {
+		for i, e := range n.entries {
+			if e.key == key {
+				return n.delete(i, 0), true
+			}
+		}
+		return n, false
+	}
+
+	i, bit := n.index(hash, shift)
+	if n.bitmap&bit == 0 {
+		return n, false
+	}
+
+	e := n.entries[i]
+	if e.child == nil {
+		if e.key != key {
+			return n, false
+		}
+		return n.delete(i, bit), true
+	}
+
+	child, removed := remove(e.child, hash, key, shift+bitsPerLevel)
+	if !removed {
+		return n, false
+	}
+	if child == nil {
+		return n.delete(i, bit), true
+	}
+	if len(child.entries) == 1 && child.entries[0].child == nil {
+		// A subtree holding a single leaf is pulled up into its parent, so
+		// that the trie is no deeper than needed
+		return n.replace(i, child.entries[0]), true
+	}
+	return n.replace(i, entry{child: child}), true
+}
+
+// replace returns a copy of *n* with entry *i* replaced
+func (n *node) replace(i int, e entry) *node {
+	entries := make([]entry, len(n.entries))
+	copy(entries, n.entries)
+	entries[i] = e
+	return &node{n.bitmap, entries, n.collision}
+}
+
+// insert returns a copy of *n* with *e* inserted at position *i*, and *bit*
+// set in the bitmap
+func (n *node) insert(i int, bit uint32, e entry) *node {
+	entries := make([]entry, len(n.entries)+1)
+	copy(entries, n.entries[:i])
+	entries[i] = e
+	copy(entries[i+1:], n.entries[i:])
+	return &node{n.bitmap | bit, entries, n.collision}
+}
+
+// delete returns a copy of *n* with entry *i* removed and *bit* cleared from
+// the bitmap, or nil if no entries would remain
+func (n *node) delete(i int, bit uint32) *node {
+	if len(n.entries) == 1 {
+		return nil
+	}
+	entries := make([]entry, len(n.entries)-1)
+	copy(entries, n.entries[:i])
+	copy(entries[i:], n.entries[i+1:])
+	return &node{n.bitmap &^ bit, entries, n.collision}
+}
+
+// Items returns every key-value pair in the map, in no particular order
+func (m *Map) Items() []hashtable.KeyValuePair {
+	items := make([]hashtable.KeyValuePair, 0, m.size)
+	var walk func(n *node)
+	walk = func(n *node) {
+		for _, e := range n.entries {
+			if e.child != nil {
+				walk(e.child)
+			} else {
+				items = append(items, hashtable.KeyValuePair{Key: e.key, Value: e.value})
+			}
+		}
+	}
+	if m.root != nil {
+		walk(m.root)
+	}
+	return items
+}
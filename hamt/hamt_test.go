@@ -0,0 +1,134 @@
+package hamt
+
+import (
+	"math/rand"
+	"strconv"
+	"testing"
+
+	"github.com/njwilson23/datastructures/hashtable"
+)
+
+// fixedHash lets tests choose the hash of a key, to force collisions
+type fixedHash struct {
+	name string
+	hash int
+}
+
+func (f fixedHash) Hash() int { return f.hash }
+
+func TestPutGet(t *testing.T) {
+	m := New()
+	m = m.Put(hashtable.HashString("colour"), "#4682b4")
+	m = m.Put(hashtable.HashString("age"), "unknown")
+	m = m.Put(hashtable.HashString("age"), "42")
+
+	if m.Len() != 2 {
+		t.Error(m.Len())
+	}
+	value, ok := m.Get(hashtable.HashString("age"))
+	if !ok || value.(string) != "42" {
+		t.Fail()
+	}
+	if _, ok := m.Get(hashtable.HashString("size")); ok {
+		t.Fail()
+	}
+}
+
+func TestPersistence(t *testing.T) {
+	m1 := New().Put(hashtable.HashString("a"), 1)
+	m2 := m1.Put(hashtable.HashString("b"), 2)
+	m3 := m2.Delete(hashtable.HashString("a"))
+
+	if _, ok := m1.Get(hashtable.HashString("b")); ok {
+		t.Error("m1 was modified by Put")
+	}
+	if _, ok := m2.Get(hashtable.HashString("a")); !ok {
+		t.Error("m2 was modified by Delete")
+	}
+	if _, ok := m3.Get(hashtable.HashString("a")); ok {
+		t.Fail()
+	}
+	if m1.Len() != 1 || m2.Len() != 2 || m3.Len() != 1 {
+		t.Fail()
+	}
+	if m3.Delete(hashtable.HashString("missing")) != m3 {
+		t.Fail()
+	}
+}
+
+func TestCollisions(t *testing.T) {
+	// "ab" and "ba" have the same rune sum, so the same hash
+	m := New().Put(hashtable.HashString("ab"), 1).Put(hashtable.HashString("ba"), 2)
+	a, _ := m.Get(hashtable.HashString("ab"))
+	b, _ := m.Get(hashtable.HashString("ba"))
+	if a.(int) != 1 || b.(int) != 2 {
+		t.Fail()
+	}
+
+	// Keys with equal hashes, plus one sharing only the low bits of the mixed
+	// hash, which must be split from the collision node
+	m = New()
+	keys := []fixedHash{{"x", 7}, {"y", 7}, {"z", 7}}
+	for i, key := range keys {
+		m = m.Put(key, i)
+	}
+	other := fixedHash{"w", 8}
+	for mix(other.hash)&levelMask != mix(7)&levelMask {
+		other.hash++
+	}
+	m = m.Put(other, 99)
+	for i, key := range keys {
+		value, ok := m.Get(key)
+		if !ok || value.(int) != i {
+			t.Errorf("lost %v", key)
+		}
+	}
+	if value, ok := m.Get(other); !ok || value.(int) != 99 {
+		t.Fail()
+	}
+
+	for _, key := range keys {
+		m = m.Delete(key)
+	}
+	if m.Len() != 1 {
+		t.Fail()
+	}
+	if _, ok := m.Get(other); !ok {
+		t.Fail()
+	}
+}
+
+func TestRandomOperations(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	m := New()
+	reference := make(map[hashtable.Hashable]int)
+	for i := 0; i != 20000; i++ {
+		key := hashtable.HashString(strconv.Itoa(r.Intn(2000)))
+		if r.Intn(3) == 0 {
+			m = m.Delete(key)
+			delete(reference, key)
+		} else {
+			m = m.Put(key, i)
+			reference[key] = i
+		}
+	}
+
+	if m.Len() != len(reference) {
+		t.Fatalf("length %d, expected %d", m.Len(), len(reference))
+	}
+	for key, expected := range reference {
+		value, ok := m.Get(key)
+		if !ok || value.(int) != expected {
+			t.Fatalf("key %v: got %v, expected %d", key, value, expected)
+		}
+	}
+	items := m.Items()
+	if len(items) != len(reference) {
+		t.Fail()
+	}
+	for _, item := range items {
+		if reference[item.Key] != item.Value.(int) {
+			t.Fail()
+		}
+	}
+}
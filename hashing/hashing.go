@@ -0,0 +1,55 @@
+/*
+ * Package hashing provides the fixed, non-cryptographic hash functions used
+ * by the probabilistic structures in this repository.
+ *
+ * Filters and sketches that are saved and reloaded, or compared between
+ * processes, need a hash that does not change from run to run, which rules
+ * out the randomly seeded hash/maphash. Bytes is 64-bit FNV-1a, which is
+ * simple and fast for short keys, followed by the finalizer of MurmurHash3.
+ * FNV-1a alone mixes poorly: changing the last byte of a key barely changes
+ * the high bits of the hash, which matters to any user that takes
+ * fingerprints or bucket numbers from them. The finalizer is a bijection that
+ * makes every output bit depend on every input bit.
+ *
+ * Many algorithms need a family of independent hash functions rather than
+ * one. Seeded derives them from a single hash value, as Mix(h ^ seed), which
+ * is much cheaper than rehashing the key for each function.
+ */
+
+package hashing
+
+// Mix is the 64-bit finalizer of MurmurHash3
+func Mix(h uint64) uint64 {
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
+
+// Bytes returns a 64-bit hash of *key*
+func Bytes(key []byte) uint64 {
+	h := uint64(14695981039346656037)
+	for _, b := range key {
+		h ^= uint64(b)
+		h *= 1099511628211
+	}
+	return Mix(h)
+}
+
+// String returns the same hash as Bytes, without converting *s* to a byte
+// slice
+func String(s string) uint64 {
+	h := uint64(14695981039346656037)
+	for i := 0; i != len(s); i++ {
+		h ^= uint64(s[i])
+		h *= 1099511628211
+	}
+	return Mix(h)
+}
+
+// Seeded returns the hash *h* under the hash function numbered by *seed*
+func Seeded(h, seed uint64) uint64 {
+	return Mix(h ^ Mix(seed+0x9e3779b97f4a7c15))
+}
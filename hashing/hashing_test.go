@@ -0,0 +1,42 @@
+package hashing
+
+import (
+	"fmt"
+	"math/bits"
+	"testing"
+)
+
+func TestBytesString(t *testing.T) {
+	for _, s := range []string{"", "a", "hello, world"} {
+		if Bytes([]byte(s)) != String(s) {
+			t.Error(s)
+		}
+	}
+	if String("a") == String("b") {
+		t.Fail()
+	}
+}
+
+func TestAvalanche(t *testing.T) {
+	// Keys that differ in their last byte should differ in about half of the
+	// top 16 bits
+	total := 0
+	for i := 0; i != 1000; i++ {
+		a, b := String(fmt.Sprintf("key-%d", i)), String(fmt.Sprintf("key-%d!", i))
+		total += bits.OnesCount64((a ^ b) >> 48)
+	}
+	if mean := float64(total) / 1000; mean < 7 || mean > 9 {
+		t.Error(mean)
+	}
+}
+
+func TestSeeded(t *testing.T) {
+	h := String("key")
+	seen := map[uint64]bool{}
+	for seed := uint64(0); seed != 100; seed++ {
+		seen[Seeded(h, seed)] = true
+	}
+	if len(seen) != 100 {
+		t.Error(len(seen))
+	}
+}
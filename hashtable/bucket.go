@@ -0,0 +1,145 @@
+package hashtable
+
+// Buckets
+//
+// Each slot of the table holds a bucket of the entries whose keys hash to it.
+// Buckets are usually short (with as many buckets as entries, most hold zero,
+// one or two), so they are kept as slices stored directly in the table's
+// array. Finding a key scans a few adjacent entries, rather than following a
+// pointer to a list and then a pointer per entry, and adding an entry to a
+// bucket with room allocates nothing.
+//
+// A poor hash function, or keys chosen to collide, can make one bucket hold
+// most of the entries, and a scan of it O(n). As in Java's HashMap, once a
+// bucket holds more than treeThreshold entries, it is converted to a
+// red-black tree (see package rbtree) keyed by the keys' hashes, so that it
+// can be searched in O(log n) as long as the hashes differ. Entries with
+// equal hashes share a tree node, where they are scanned as before. When
+// deletions shrink the bucket to untreeThreshold entries, it is converted
+// back to a slice. The gap between the thresholds keeps a bucket whose size
+// hovers around one of them from being converted back and forth.
+//
+// Entries with the same key are kept in insertion order, and Get and Delete
+// act on the oldest.
+
+import "github.com/njwilson23/datastructures/rbtree"
+
+const (
+	// treeThreshold is the largest number of entries kept in a slice
+	treeThreshold = 8
+	// untreeThreshold is the number of entries at which a tree is converted
+	// back to a slice
+	untreeThreshold = 6
+)
+
+type bucket struct {
+	entries []KeyValuePair
+
+	// Once the bucket has been converted, tree maps each hash to a
+	// *[]KeyValuePair, and size counts the entries
+	tree *rbtree.RedBlackTree
+	size int
+}
+
+func (b *bucket) insert(kv KeyValuePair) {
+	if b.tree != nil {
+		b.treeInsert(kv)
+		return
+	}
+	b.entries = append(b.entries, kv)
+	if len(b.entries) > treeThreshold {
+		b.tree = rbtree.New()
+		for _, kv := range b.entries {
+			b.treeInsert(kv)
+		}
+		b.entries = nil
+	}
+}
+
+func (b *bucket) treeInsert(kv KeyValuePair) {
+	hash := kv.Key.Hash()
+	if entries, ok := b.tree.Get(hash); ok {
+		*entries.(*[]KeyValuePair) = append(*entries.(*[]KeyValuePair), kv)
+	} else {
+		b.tree.InsertValue(hash, &[]KeyValuePair{kv})
+	}
+	b.size++
+}
+
+// find returns the entries that may contain *key*
+func (b *bucket) find(key Hashable) *[]KeyValuePair {
+	if b.tree == nil {
+		return &b.entries
+	}
+	if entries, ok := b.tree.Get(key.Hash()); ok {
+		return entries.(*[]KeyValuePair)
+	}
+	return nil
+}
+
+func (b *bucket) get(key Hashable) (interface{}, bool) {
+	entries := b.find(key)
+	if entries == nil {
+		return nil, false
+	}
+	for _, kv := range *entries {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	return nil, false
+}
+
+// delete removes the oldest entry for *key*, and returns its value
+func (b *bucket) delete(key Hashable) (interface{}, bool) {
+	entries := b.find(key)
+	if entries == nil {
+		return nil, false
+	}
+	value, ok := deleteEntry(entries, key)
+	if !ok || b.tree == nil {
+		return value, ok
+	}
+
+	if len(*entries) == 0 {
+		b.tree.Delete(key.Hash())
+	}
+	b.size--
+	if b.size <= untreeThreshold {
+		b.each(func(kv KeyValuePair) {
+			b.entries = append(b.entries, kv)
+		})
+		b.tree, b.size = nil, 0
+	}
+	return value, true
+}
+
+// deleteEntry removes the first entry for *key* from a slice, keeping the
+// rest in order
+func deleteEntry(entries *[]KeyValuePair, key Hashable) (interface{}, bool) {
+	for i, kv := range *entries {
+		if kv.Key == key {
+			copy((*entries)[i:], (*entries)[i+1:])
+			(*entries)[len(*entries)-1] = KeyValuePair{}
+			*entries = (*entries)[:len(*entries)-1]
+			return kv.Value, true
+		}
+	}
+	return nil, false
+}
+
+// each calls *f* with every entry in the bucket
+func (b *bucket) each(f func(KeyValuePair)) {
+	for _, kv := range b.entries {
+		f(kv)
+	}
+	if b.tree == nil {
+		return
+	}
+	it := b.tree.Iter()
+	for _, ok := it.Next(); ok; _, ok = it.Next() {
+		for _, kv := range *it.Value().(*[]KeyValuePair) {
+			f(kv)
+		}
+	}
+}
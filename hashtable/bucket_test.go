@@ -0,0 +1,152 @@
+package hashtable
+
+import (
+	"testing"
+
+	"github.com/njwilson23/datastructures/linkedlist"
+)
+
+type intKey int
+
+func (k intKey) Hash() int { return int(k) }
+
+func TestBucketTree(t *testing.T) {
+	// With a single bucket, every key collides
+	ht := InitHashTable(1)
+	for i := 0; i != 100; i++ {
+		ht.Insert(intKey(i), i)
+	}
+	if ht.array[0].tree == nil {
+		t.Fatal("bucket was not converted to a tree")
+	}
+	ht.Insert(intKey(7), "newer")
+	for i := 0; i != 100; i++ {
+		if value, err := ht.Get(intKey(i)); err != nil || value != i {
+			t.Fatal(i, value, err)
+		}
+	}
+	if err := ht.Delete(intKey(7)); err != nil {
+		t.Fatal(err)
+	}
+	if value, _ := ht.Get(intKey(7)); value != "newer" {
+		t.Error(value)
+	}
+	for i := 0; i != 100; i += 2 {
+		if err := ht.Delete(intKey(i)); err != nil {
+			t.Fatal(i, err)
+		}
+	}
+	if _, err := ht.Get(intKey(10)); err != KEY_ERROR {
+		t.Error(err)
+	}
+	if value, err := ht.Get(intKey(11)); err != nil || value != 11 {
+		t.Error(value, err)
+	}
+	if m := ht.ToMap(); len(m) != 50 {
+		t.Error(len(m))
+	}
+}
+
+func TestBucketEqualHashes(t *testing.T) {
+	// Anagrams have the same hash, so they share a tree node
+	ht := InitHashTable(1)
+	words := []HashString{"listen", "silent", "enlist", "tinsel", "inlets",
+		"stone", "tones", "notes", "onset", "seton"}
+	for _, w := range words {
+		ht.Insert(w, string(w))
+	}
+	for _, w := range words {
+		if value, err := ht.Get(w); err != nil || value != string(w) {
+			t.Error(w, value, err)
+		}
+	}
+	for _, w := range words {
+		if err := ht.Delete(w); err != nil {
+			t.Error(w, err)
+		}
+	}
+}
+
+func TestBucketShrink(t *testing.T) {
+	ht := InitHashTable(1)
+	for i := 0; i != treeThreshold+1; i++ {
+		ht.Insert(intKey(i), i)
+	}
+	if ht.array[0].tree == nil {
+		t.Fatal("bucket was not converted to a tree")
+	}
+	for i := 0; i != treeThreshold+1-untreeThreshold; i++ {
+		ht.Delete(intKey(i))
+	}
+	b := ht.array[0]
+	if b.tree != nil || len(b.entries) != untreeThreshold {
+		t.Fatal("bucket was not converted back to a slice")
+	}
+	for i, kv := range b.entries {
+		if kv.Key != intKey(treeThreshold+1-untreeThreshold+i) {
+			t.Error(b.entries)
+		}
+	}
+}
+
+// listTable is the previous design of HashTable, with a linked list per
+// bucket, kept for comparison in benchmarks
+type listTable struct {
+	array    []*linkedlist.LinkedList
+	hashFunc func(int) int
+}
+
+func newListTable(size int) *listTable {
+	lt := &listTable{array: make([]*linkedlist.LinkedList, size)}
+	for i := range lt.array {
+		lt.array[i] = linkedlist.New()
+	}
+	lt.hashFunc = InitHashTable(size).hashFunc
+	return lt
+}
+
+func (lt *listTable) Insert(key Hashable, value interface{}) {
+	lt.array[lt.hashFunc(key.Hash())].Append(KeyValuePair{Key: key, Value: value})
+}
+
+func (lt *listTable) Get(key Hashable) (interface{}, error) {
+	for node := lt.array[lt.hashFunc(key.Hash())].Head; node != nil; node = node.Next {
+		if kv := node.Value.(KeyValuePair); kv.Key == key {
+			return kv.Value, nil
+		}
+	}
+	return nil, KEY_ERROR
+}
+
+func benchmarkGet(b *testing.B, size, keys int) {
+	b.Run("Slices", func(b *testing.B) {
+		ht := InitHashTable(size)
+		for i := 0; i != keys; i++ {
+			ht.Insert(intKey(i), i)
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			ht.Get(intKey(i % keys))
+		}
+	})
+	b.Run("LinkedLists", func(b *testing.B) {
+		lt := newListTable(size)
+		for i := 0; i != keys; i++ {
+			lt.Insert(intKey(i), i)
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			lt.Get(intKey(i % keys))
+		}
+	})
+}
+
+// One key per bucket on average
+func BenchmarkGet(b *testing.B) {
+	benchmarkGet(b, 1<<14, 1<<14)
+}
+
+// Chains of 256 keys, which are converted to trees
+func BenchmarkGetLongChains(b *testing.B) {
+	benchmarkGet(b, 16, 1<<12)
+}
@@ -0,0 +1,62 @@
+package hashtable
+
+// Frozen hash tables
+//
+// A HashTable keeps a separate slice (or tree) per bucket, so that any bucket
+// can grow. Once the table will no longer change, the buckets can be packed
+// end to end in a single slice of entries, with an array of offsets saying
+// where each bucket starts:
+//
+//    offsets: [0  2  2  3 ...]
+//    entries: [a1 a2 | c1 | ...]      bucket 0 is entries[0:2], bucket 1 is
+//                                     empty, bucket 2 is entries[2:3], ...
+//
+// A lookup then reads the offsets of one bucket and scans a short run of
+// adjacent entries, touching two places in memory.
+//
+// A Frozen table cannot be modified, so it can be read from any number of
+// goroutines without locking.
+
+// Frozen is a read-only copy of a HashTable
+type Frozen struct {
+	offsets  []int
+	entries  []KeyValuePair
+	hashFunc func(int) int
+}
+
+// Freeze returns a read-only copy of the table
+func (ht *HashTable) Freeze() *Frozen {
+	f := &Frozen{offsets: make([]int, len(ht.array)+1), hashFunc: ht.hashFunc}
+	for i := range ht.array {
+		ht.array[i].each(func(kv KeyValuePair) {
+			f.entries = append(f.entries, kv)
+		})
+		f.offsets[i+1] = len(f.entries)
+	}
+	return f
+}
+
+// Len returns the number of entries in the table
+func (f *Frozen) Len() int {
+	return len(f.entries)
+}
+
+// Get returns the value for *key*, or KEY_ERROR if it is not present
+func (f *Frozen) Get(key Hashable) (interface{}, error) {
+	i := f.hashFunc(key.Hash())
+	for _, kv := range f.entries[f.offsets[i]:f.offsets[i+1]] {
+		if kv.Key == key {
+			return kv.Value, nil
+		}
+	}
+	return nil, KEY_ERROR
+}
+
+// ToMap returns the entries of the table as a map
+func (f *Frozen) ToMap() map[Hashable]interface{} {
+	m := make(map[Hashable]interface{}, len(f.entries))
+	for _, kv := range f.entries {
+		m[kv.Key] = kv.Value
+	}
+	return m
+}
@@ -0,0 +1,38 @@
+package hashtable
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestFrozen(t *testing.T) {
+	ht := InitHashTable(64)
+	for i := 0; i != 500; i++ {
+		ht.Insert(intKey(i), i)
+	}
+	f := ht.Freeze()
+	ht.Delete(intKey(0))
+	ht.Insert(intKey(1000), 1000)
+
+	if f.Len() != 500 || len(f.ToMap()) != 500 {
+		t.Error(f.Len())
+	}
+	if _, err := f.Get(intKey(1000)); err != KEY_ERROR {
+		t.Error(err)
+	}
+
+	// Lookups need no locking
+	var wg sync.WaitGroup
+	for g := 0; g != 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i != 500; i++ {
+				if value, err := f.Get(intKey(i)); err != nil || value != i {
+					t.Error(i, value, err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -4,7 +4,8 @@ import (
 	"errors"
 	"math"
 
-	"github.com/njwilson23/datastructures/linkedlist"
+	"github.com/njwilson23/datastructures/observe"
+	"github.com/njwilson23/datastructures/pair"
 )
 
 var KEY_ERROR = errors.New("key not found")
@@ -15,14 +16,13 @@ type Hashable interface {
 
 type HashTable struct {
 	Size     int
-	array    []*linkedlist.LinkedList
+	array    []bucket
 	hashFunc func(int) int
+	events   observe.Subject[Hashable, interface{}]
 }
 
-type KeyValuePair struct {
-	key   Hashable
-	value interface{}
-}
+// KeyValuePair is the entry type stored in the hash table buckets
+type KeyValuePair = pair.KeyValue[Hashable, interface{}]
 
 func sumRune(summable []rune) int {
 	sum := 0
@@ -43,52 +43,60 @@ func multiplicationHash(val, size int, c float64) int {
 }
 
 func InitHashTable(size int) *HashTable {
-	array := make([]*linkedlist.LinkedList, size)
-	for i := range array {
-		array[i] = linkedlist.New()
-	}
+	array := make([]bucket, size)
 	c := 0.5*math.Sqrt(5) - 0.5 // suggested by Knuth
-	ht := HashTable{size, array, func(v int) int { return multiplicationHash(v, size, c) }}
+	ht := HashTable{Size: size, array: array, hashFunc: func(v int) int { return multiplicationHash(v, size, c) }}
 	return &ht
 }
 
 func (ht *HashTable) Insert(key Hashable, value interface{}) error {
 	arrayPos := ht.hashFunc(key.Hash())
-	lst := ht.array[arrayPos]
-	lst.Append(KeyValuePair{key, value})
+	ht.array[arrayPos].insert(KeyValuePair{Key: key, Value: value})
+	ht.events.Notify(observe.Put, key, value)
 	return nil
 }
 
 func (ht *HashTable) Get(key Hashable) (interface{}, error) {
-	var kv KeyValuePair
 	arrayPos := ht.hashFunc(key.Hash())
-	lst := ht.array[arrayPos]
-	node := lst.Head
-	for node != nil {
-		kv = node.Value.(KeyValuePair)
-		if kv.key == key {
-			return kv.value, nil
-		}
-		node = node.Next
+	if value, ok := ht.array[arrayPos].get(key); ok {
+		return value, nil
 	}
 	return nil, KEY_ERROR
 }
 
 func (ht *HashTable) Delete(key Hashable) error {
-	var kv KeyValuePair
 	arrayPos := ht.hashFunc(key.Hash())
+	value, ok := ht.array[arrayPos].delete(key)
+	if !ok {
+		return KEY_ERROR
+	}
+	ht.events.Notify(observe.Delete, key, value)
+	return nil
+}
+
+// Subscribe registers a callback that is called after every subsequent Insert
+// and Delete, and returns a function that cancels the subscription
+func (ht *HashTable) Subscribe(fn func(observe.Event[Hashable, interface{}])) (unsubscribe func()) {
+	return ht.events.Subscribe(fn)
+}
+
+// FromMap creates a HashTable with *size* buckets containing the entries of a
+// map
+func FromMap(m map[Hashable]interface{}, size int) *HashTable {
+	ht := InitHashTable(size)
+	for key, value := range m {
+		ht.Insert(key, value)
+	}
+	return ht
+}
 
-	lst := ht.array[arrayPos]
-	node := lst.Head
-	index := 0
-	for node != nil {
-		kv = node.Value.(KeyValuePair)
-		if kv.key == key {
-			lst.Delete(index)
-			return nil
-		}
-		index++
-		node = node.Next
+// ToMap returns the entries of a HashTable as a map
+func (ht *HashTable) ToMap() map[Hashable]interface{} {
+	m := make(map[Hashable]interface{})
+	for i := range ht.array {
+		ht.array[i].each(func(kv KeyValuePair) {
+			m[kv.Key] = kv.Value
+		})
 	}
-	return KEY_ERROR
+	return m
 }
@@ -3,6 +3,8 @@ package hashtable
 import (
 	"math"
 	"testing"
+
+	"github.com/njwilson23/datastructures/observe"
 )
 
 func TestSumRune(t *testing.T) {
@@ -73,3 +75,53 @@ func TestDelete(t *testing.T) {
 		t.Error()
 	}
 }
+
+func TestMapConversion(t *testing.T) {
+	m := map[Hashable]interface{}{
+		HashString("colour"): "#4682b4",
+		HashString("age"):    "unknown",
+		HashString("size"):   "large",
+	}
+	ht := FromMap(m, 64)
+	value, err := ht.Get(HashString("age"))
+	if err != nil || value.(string) != "unknown" {
+		t.Fail()
+	}
+
+	m2 := ht.ToMap()
+	if len(m2) != len(m) {
+		t.Fail()
+	}
+	for key, value := range m {
+		if m2[key] != value {
+			t.Fail()
+		}
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	ht := InitHashTable(64)
+	var events []observe.Event[Hashable, interface{}]
+	unsubscribe := ht.Subscribe(func(e observe.Event[Hashable, interface{}]) {
+		events = append(events, e)
+	})
+
+	ht.Insert(HashString("colour"), "#4682b4")
+	ht.Delete(HashString("colour"))
+	ht.Delete(HashString("missing"))
+	if len(events) != 2 {
+		t.Fatal(events)
+	}
+	if events[0].Op != observe.Put || events[1].Op != observe.Delete {
+		t.Fail()
+	}
+	if events[1].Key != HashString("colour") || events[1].Value.(string) != "#4682b4" {
+		t.Fail()
+	}
+
+	unsubscribe()
+	ht.Insert(HashString("size"), "large")
+	if len(events) != 2 {
+		t.Fail()
+	}
+}
@@ -0,0 +1,230 @@
+package heap
+
+// Bounded priority queues
+//
+// A Bounded queue holds at most a fixed number of values, and a Policy says
+// what happens when a value is pushed into a full queue:
+//
+// - Reject drops the new value, as in admission control, where work arriving
+//   at a full queue is turned away.
+// - EvictMin drops whichever is smaller of the new value and the current
+//   minimum. After a stream of pushes, the queue holds the largest values
+//   seen ("top-k").
+//
+// Evicting the minimum while also extracting the maximum requires access to
+// both ends of the queue, which a max-heap does not give: its minimum could be
+// any of the leaves. Bounded is therefore a min-max heap (Atkinson et al.,
+// 1986), a binary heap whose levels alternate between min levels and max
+// levels. Every value on a min level is no larger than its descendants, and
+// every value on a max level is no smaller than its descendants:
+//
+//	min level          1
+//	max level      9       8
+//	min level    2   3   4   5
+//	max level   7 6
+//
+// The minimum is the root, and the maximum is one of the root's children.
+// Inserting or removing at either end is O(log n), as in an ordinary heap,
+// except that values move up and down by two levels at a time, comparing
+// against grandparents and grandchildren.
+
+// Policy chooses what a full Bounded queue does with a pushed value
+type Policy int
+
+const (
+	Reject Policy = iota
+	EvictMin
+)
+
+// Bounded is a fixed-capacity double-ended priority queue of labelled values
+type Bounded struct {
+	value    []float64
+	label    []int
+	capacity int
+	policy   Policy
+}
+
+// NewBounded creates an empty Bounded queue
+func NewBounded(capacity int, policy Policy) *Bounded {
+	return &Bounded{
+		value:    make([]float64, 0, capacity),
+		label:    make([]int, 0, capacity),
+		capacity: capacity,
+		policy:   policy,
+	}
+}
+
+// Len returns the number of values in the queue
+func (b *Bounded) Len() int {
+	return len(b.value)
+}
+
+// Cap returns the maximum number of values the queue can hold
+func (b *Bounded) Cap() int {
+	return b.capacity
+}
+
+// Push adds a labelled value to the queue. If the queue was full, it returns
+// the label and value that were dropped according to the queue's Policy,
+// which may be the pushed value itself.
+func (b *Bounded) Push(label int, value float64) (int, float64, bool) {
+	if len(b.value) == b.capacity {
+		if b.policy == Reject || b.capacity == 0 || value <= b.value[0] {
+			return label, value, true
+		}
+		droppedLabel, droppedValue, _ := b.ExtractMinimum()
+		b.push(label, value)
+		return droppedLabel, droppedValue, true
+	}
+	b.push(label, value)
+	return 0, 0, false
+}
+
+func (b *Bounded) push(label int, value float64) {
+	b.value = append(b.value, value)
+	b.label = append(b.label, label)
+	b.bubbleUp(len(b.value) - 1)
+}
+
+// Minimum returns the smallest value in the queue
+func (b *Bounded) Minimum() (int, float64, error) {
+	if len(b.value) == 0 {
+		return 0, 0.0, ErrEmpty
+	}
+	return b.label[0], b.value[0], nil
+}
+
+// Maximum returns the largest value in the queue
+func (b *Bounded) Maximum() (int, float64, error) {
+	if len(b.value) == 0 {
+		return 0, 0.0, ErrEmpty
+	}
+	i := b.maxIndex()
+	return b.label[i], b.value[i], nil
+}
+
+// ExtractMinimum removes and returns the smallest value in the queue
+func (b *Bounded) ExtractMinimum() (int, float64, error) {
+	if len(b.value) == 0 {
+		return 0, 0.0, ErrEmpty
+	}
+	return b.remove(0)
+}
+
+// ExtractMaximum removes and returns the largest value in the queue
+func (b *Bounded) ExtractMaximum() (int, float64, error) {
+	if len(b.value) == 0 {
+		return 0, 0.0, ErrEmpty
+	}
+	return b.remove(b.maxIndex())
+}
+
+// maxIndex returns the position of the maximum, which is the root or one of
+// its children
+func (b *Bounded) maxIndex() int {
+	switch {
+	case len(b.value) == 1:
+		return 0
+	case len(b.value) == 2 || b.value[1] >= b.value[2]:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// remove takes out the value at *i*, which is the root or one of its
+// children, replacing it with the last leaf and trickling that down
+func (b *Bounded) remove(i int) (int, float64, error) {
+	label, value := b.label[i], b.value[i]
+	last := len(b.value) - 1
+	b.swap(i, last)
+	b.value = b.value[:last]
+	b.label = b.label[:last]
+	if i < last {
+		b.trickleDown(i)
+	}
+	return label, value, nil
+}
+
+func (b *Bounded) swap(i, j int) {
+	b.value[i], b.value[j] = b.value[j], b.value[i]
+	b.label[i], b.label[j] = b.label[j], b.label[i]
+}
+
+// isMinLevel returns true if position *i* is on a min level. Levels hold
+// positions [2^d - 1, 2^(d+1) - 1) for depth d, and even depths are min
+// levels.
+func isMinLevel(i int) bool {
+	depth := 0
+	for i > 0 {
+		i = (i - 1) / 2
+		depth++
+	}
+	return depth%2 == 0
+}
+
+// less compares values in the direction of a level: for a min level, it is
+// the ordinary less-than, and for a max level it is greater-than
+func (b *Bounded) less(i, j int, min bool) bool {
+	if min {
+		return b.value[i] < b.value[j]
+	}
+	return b.value[i] > b.value[j]
+}
+
+// bubbleUp moves a newly added leaf up to its place. The leaf is first
+// compared with its parent, which is on the opposite kind of level, to decide
+// whether it belongs among the min levels or the max levels above it. From
+// there it rises past grandparents only.
+func (b *Bounded) bubbleUp(i int) {
+	if i == 0 {
+		return
+	}
+	min := isMinLevel(i)
+	parent := (i - 1) / 2
+	if b.less(parent, i, min) {
+		b.swap(i, parent)
+		i = parent
+		min = !min
+	}
+	for i > 2 {
+		grandparent := ((i-1)/2 - 1) / 2
+		if !b.less(i, grandparent, min) {
+			break
+		}
+		b.swap(i, grandparent)
+		i = grandparent
+	}
+}
+
+// trickleDown moves the value at *i* down to its place. On a min level, the
+// value is swapped with the smallest of its children and grandchildren while
+// that is smaller than it, and conversely on a max level. After a swap with a
+// grandchild, the value may be out of order with the grandchild's parent,
+// which is on the opposite kind of level, and they are swapped if so.
+func (b *Bounded) trickleDown(i int) {
+	min := isMinLevel(i)
+	n := len(b.value)
+	for 2*i+1 < n {
+		// Find the extreme among the children and grandchildren
+		m := 2*i + 1
+		for _, j := range []int{2*i + 2, 4*i + 3, 4*i + 4, 4*i + 5, 4*i + 6} {
+			if j < n && b.less(j, m, min) {
+				m = j
+			}
+		}
+		if !b.less(m, i, min) {
+			return
+		}
+		b.swap(m, i)
+		if m <= 2*i+2 {
+			// The child is on the opposite kind of level, so everything below
+			// it is already in order with the value
+			return
+		}
+		if parent := (m - 1) / 2; b.less(parent, m, min) {
+			b.swap(m, parent)
+		}
+		i = m
+	}
+}
@@ -0,0 +1,130 @@
+package heap
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// verifyMinMaxHeap checks that every value is in order with its descendants
+func verifyMinMaxHeap(b *Bounded) bool {
+	for i := range b.value {
+		for j := i; j > 0; {
+			j = (j - 1) / 2
+			if b.less(i, j, isMinLevel(j)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func TestIsMinLevel(t *testing.T) {
+	for i, expected := range []bool{true, false, false, true, true, true, true, false} {
+		if isMinLevel(i) != expected {
+			t.Errorf("position %d", i)
+		}
+	}
+}
+
+func TestBoundedDoubleEnded(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	b := NewBounded(100, Reject)
+	var values []float64
+	for i := 0; i != 100; i++ {
+		v := float64(r.Intn(50))
+		values = append(values, v)
+		b.Push(i, v)
+		if !verifyMinMaxHeap(b) {
+			t.Fatalf("invalid after pushing %d values", i+1)
+		}
+	}
+	sort.Float64s(values)
+
+	// Alternate between the two ends
+	lo, hi := 0, len(values)-1
+	for b.Len() != 0 {
+		if b.Len()%2 == 0 {
+			_, v, _ := b.ExtractMinimum()
+			if v != values[lo] {
+				t.Fatalf("minimum %.0f, expected %.0f", v, values[lo])
+			}
+			lo++
+		} else {
+			_, v, _ := b.ExtractMaximum()
+			if v != values[hi] {
+				t.Fatalf("maximum %.0f, expected %.0f", v, values[hi])
+			}
+			hi--
+		}
+		if !verifyMinMaxHeap(b) {
+			t.Fatal("invalid after extraction")
+		}
+	}
+	if _, _, err := b.Maximum(); err != ErrEmpty {
+		t.Fail()
+	}
+	if _, _, err := b.ExtractMinimum(); err != ErrEmpty {
+		t.Fail()
+	}
+}
+
+func TestBoundedReject(t *testing.T) {
+	b := NewBounded(3, Reject)
+	for i, v := range []float64{5, 1, 3} {
+		if _, _, dropped := b.Push(i, v); dropped {
+			t.Fatal("dropped before full")
+		}
+	}
+	label, value, dropped := b.Push(3, 10)
+	if !dropped || label != 3 || value != 10 {
+		t.Error(label, value)
+	}
+	if l, v, _ := b.Maximum(); l != 0 || v != 5 {
+		t.Error(l, v)
+	}
+}
+
+func TestBoundedTopK(t *testing.T) {
+	r := rand.New(rand.NewSource(5))
+	k := 10
+	b := NewBounded(k, EvictMin)
+	values := make([]float64, 1000)
+	for i := range values {
+		values[i] = r.Float64()
+		label, value, dropped := b.Push(i, values[i])
+		if dropped != (i >= k) {
+			t.Fatalf("push %d: dropped=%t", i, dropped)
+		}
+		if dropped && values[label] != value {
+			t.Fatal("dropped label does not match value")
+		}
+	}
+	sort.Sort(sort.Reverse(sort.Float64Slice(values)))
+	for i := 0; i != k; i++ {
+		_, v, err := b.ExtractMaximum()
+		if err != nil || v != values[i] {
+			t.Fatalf("value %d is %f, expected %f", i, v, values[i])
+		}
+	}
+}
+
+func TestBoundedEvictMinKeepsLarger(t *testing.T) {
+	b := NewBounded(2, EvictMin)
+	b.Push(0, 4)
+	b.Push(1, 6)
+	// Smaller than everything, so rejected
+	if label, _, dropped := b.Push(2, 1); !dropped || label != 2 {
+		t.Error(label)
+	}
+	// Larger than the minimum, which is evicted
+	if label, value, dropped := b.Push(3, 5); !dropped || label != 0 || value != 4 {
+		t.Error(label, value)
+	}
+	if l, v, _ := b.Minimum(); l != 3 || v != 5 {
+		t.Error(l, v)
+	}
+	if b.Cap() != 2 || b.Len() != 2 {
+		t.Fail()
+	}
+}
@@ -68,13 +68,47 @@ func (h *Heap) ExtractMaximum() (int, float64, error) {
 		return 0, 0.0, ErrEmpty
 	}
 	labelMax, valueMax, _ := h.Maximum()
+	// The last leaf replaces the root and sinks to its place. Shifting the
+	// whole array down by one instead would break the parent-child
+	// relationships throughout the heap.
 	h.size--
-	h.value = append(h.value[1:], 0.0)
-	h.label = append(h.label[1:], 0)
+	h.value[0], h.label[0] = h.value[h.size], h.label[h.size]
 	h.MaxHeapify(0)
 	return labelMax, valueMax, nil
 }
 
+// Insert adds a labelled value to the heap, returning ErrOverflow if the heap
+// is at capacity. The new value is placed in the first free leaf and swapped
+// with its parent until the heap property holds, in O(log n).
+func (h *Heap) Insert(label int, value float64) error {
+	if h.size == h.capacity {
+		return ErrOverflow
+	}
+	i := h.size
+	h.value[i], h.label[i] = value, label
+	h.size++
+	for i != 0 {
+		parent := (i - 1) / 2
+		if h.value[parent] >= h.value[i] {
+			break
+		}
+		h.value[i], h.value[parent] = h.value[parent], h.value[i]
+		h.label[i], h.label[parent] = h.label[parent], h.label[i]
+		i = parent
+	}
+	return nil
+}
+
+// Len returns the number of values in the heap
+func (h *Heap) Len() int {
+	return h.size
+}
+
+// Cap returns the maximum number of values the heap can hold
+func (h *Heap) Cap() int {
+	return h.capacity
+}
+
 func BuildMaxHeap(values []float64, labels []int) *Heap {
 	h := New(len(values))
 	h.size = len(values)
@@ -85,3 +119,14 @@ func BuildMaxHeap(values []float64, labels []int) *Heap {
 	}
 	return h
 }
+
+// Slices returns copies of the labels and values in a heap, in the order in
+// which they are stored. Passing them back to BuildMaxHeap reconstructs an
+// equivalent heap.
+func (h *Heap) Slices() ([]int, []float64) {
+	labels := make([]int, h.size)
+	values := make([]float64, h.size)
+	copy(labels, h.label[:h.size])
+	copy(values, h.value[:h.size])
+	return labels, values
+}
@@ -70,3 +70,73 @@ func TestBuild(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestSlices(t *testing.T) {
+	value := []float64{16, 4, 10, 14, 7, 9, 3, 2, 8, 1}
+	label := []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
+	h := BuildMaxHeap(value, label)
+	h.ExtractMaximum()
+
+	labels, values := h.Slices()
+	if len(labels) != 9 || len(values) != 9 {
+		t.Fail()
+	}
+
+	h2 := BuildMaxHeap(values, labels)
+	if !verifyMaxHeap(h2) {
+		t.Fail()
+	}
+	l, v, _ := h2.Maximum()
+	if l != 3 || v != 14 {
+		t.Fail()
+	}
+}
+
+func TestExtractAll(t *testing.T) {
+	value := []float64{16, 4, 10, 14, 7, 9, 3, 2, 8, 1}
+	label := []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
+	h := BuildMaxHeap(value, label)
+	last := 17.0
+	for h.size != 0 {
+		_, v, _ := h.ExtractMaximum()
+		if v > last {
+			t.Fatalf("%.0f extracted after %.0f", v, last)
+		}
+		last = v
+		if !verifyMaxHeap(h) {
+			t.Fatal()
+		}
+	}
+	if _, _, err := h.ExtractMaximum(); err != ErrEmpty {
+		t.Fail()
+	}
+}
+
+func TestInsert(t *testing.T) {
+	h := New(5)
+	for i, v := range []float64{3, 1, 4, 1, 5} {
+		if err := h.Insert(i, v); err != nil {
+			t.Fatal(err)
+		}
+		if !verifyMaxHeap(h) {
+			t.Fatal()
+		}
+	}
+	if h.Insert(5, 9) != ErrOverflow {
+		t.Fail()
+	}
+	if h.Len() != 5 {
+		t.Fail()
+	}
+	l, v, _ := h.ExtractMaximum()
+	if l != 4 || v != 5 {
+		t.Fail()
+	}
+	h.Insert(6, 2)
+	expected := []float64{4, 3, 2, 1, 1}
+	for _, e := range expected {
+		if _, v, _ := h.ExtractMaximum(); v != e {
+			t.Errorf("extracted %.0f, expected %.0f", v, e)
+		}
+	}
+}
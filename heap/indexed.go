@@ -0,0 +1,148 @@
+package heap
+
+// Indexed heaps
+//
+// Heap and Bounded identify their values by int labels, and can only reach a
+// value through the root. Algorithms such as Dijkstra's shortest paths and
+// Prim's minimum spanning tree also need to change the priority of a value
+// that is already in the queue, when a shorter path to a vertex is found.
+//
+// Indexed is a min-heap whose values are identified by keys of any comparable
+// type, such as graph vertex IDs. Alongside the array holding the heap, it
+// keeps a map from each key to its current position in the array, which is
+// updated on every swap. The value for a key can then be found in O(1), and
+// changed in O(log n) by sifting it up or down from where it is.
+//
+// Unlike Heap, an Indexed heap grows as needed.
+
+// Indexed is a min-heap of float64 values addressed by key
+type Indexed[K comparable] struct {
+	keys   []K
+	values []float64
+	pos    map[K]int
+}
+
+// NewIndexed creates an empty Indexed heap
+func NewIndexed[K comparable]() *Indexed[K] {
+	return &Indexed[K]{pos: make(map[K]int)}
+}
+
+// Len returns the number of keys in the heap
+func (h *Indexed[K]) Len() int {
+	return len(h.keys)
+}
+
+// Contains returns true if *key* is in the heap
+func (h *Indexed[K]) Contains(key K) bool {
+	_, ok := h.pos[key]
+	return ok
+}
+
+// Value returns the value for *key*, or false if it is not in the heap
+func (h *Indexed[K]) Value(key K) (float64, bool) {
+	i, ok := h.pos[key]
+	if !ok {
+		return 0, false
+	}
+	return h.values[i], true
+}
+
+// Set adds *key* to the heap with the given value, or changes its value if it
+// is already present
+func (h *Indexed[K]) Set(key K, value float64) {
+	i, ok := h.pos[key]
+	if !ok {
+		i = len(h.keys)
+		h.keys = append(h.keys, key)
+		h.values = append(h.values, value)
+		h.pos[key] = i
+		h.up(i)
+		return
+	}
+	old := h.values[i]
+	h.values[i] = value
+	if value < old {
+		h.up(i)
+	} else {
+		h.down(i)
+	}
+}
+
+// Minimum returns the key with the smallest value
+func (h *Indexed[K]) Minimum() (K, float64, error) {
+	if len(h.keys) == 0 {
+		var none K
+		return none, 0.0, ErrEmpty
+	}
+	return h.keys[0], h.values[0], nil
+}
+
+// ExtractMinimum removes and returns the key with the smallest value
+func (h *Indexed[K]) ExtractMinimum() (K, float64, error) {
+	if len(h.keys) == 0 {
+		var none K
+		return none, 0.0, ErrEmpty
+	}
+	key, value := h.keys[0], h.values[0]
+	h.removeAt(0)
+	return key, value, nil
+}
+
+// Remove deletes *key* from the heap, returning false if it was not present
+func (h *Indexed[K]) Remove(key K) bool {
+	i, ok := h.pos[key]
+	if !ok {
+		return false
+	}
+	h.removeAt(i)
+	return true
+}
+
+// removeAt replaces the value at *i* with the last leaf, which is then sifted
+// up or down into place
+func (h *Indexed[K]) removeAt(i int) {
+	last := len(h.keys) - 1
+	h.swap(i, last)
+	delete(h.pos, h.keys[last])
+	h.keys = h.keys[:last]
+	h.values = h.values[:last]
+	if i < last {
+		h.up(i)
+		h.down(i)
+	}
+}
+
+func (h *Indexed[K]) swap(i, j int) {
+	h.keys[i], h.keys[j] = h.keys[j], h.keys[i]
+	h.values[i], h.values[j] = h.values[j], h.values[i]
+	h.pos[h.keys[i]] = i
+	h.pos[h.keys[j]] = j
+}
+
+func (h *Indexed[K]) up(i int) {
+	for i != 0 {
+		parent := (i - 1) / 2
+		if h.values[parent] <= h.values[i] {
+			return
+		}
+		h.swap(i, parent)
+		i = parent
+	}
+}
+
+func (h *Indexed[K]) down(i int) {
+	n := len(h.keys)
+	for {
+		smallest := i
+		for _, child := range []int{2*i + 1, 2*i + 2} {
+			if child < n && h.values[child] < h.values[smallest] {
+				smallest = child
+			}
+		}
+		if smallest == i {
+			return
+		}
+		h.swap(i, smallest)
+		i = smallest
+	}
+}
@@ -0,0 +1,74 @@
+package heap
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestIndexed(t *testing.T) {
+	h := NewIndexed[string]()
+	h.Set("a", 5)
+	h.Set("b", 3)
+	h.Set("c", 8)
+	h.Set("d", 1)
+	if k, v, _ := h.Minimum(); k != "d" || v != 1 {
+		t.Error(k, v)
+	}
+
+	// Decrease and increase existing keys
+	h.Set("c", 0)
+	h.Set("d", 10)
+	if v, ok := h.Value("d"); !ok || v != 10 {
+		t.Error(v)
+	}
+	if !h.Remove("b") || h.Remove("b") || h.Contains("b") {
+		t.Fail()
+	}
+
+	var keys []string
+	for h.Len() != 0 {
+		k, _, _ := h.ExtractMinimum()
+		keys = append(keys, k)
+	}
+	if len(keys) != 3 || keys[0] != "c" || keys[1] != "a" || keys[2] != "d" {
+		t.Error(keys)
+	}
+	if _, _, err := h.ExtractMinimum(); err != ErrEmpty {
+		t.Fail()
+	}
+	if _, ok := h.Value("a"); ok {
+		t.Fail()
+	}
+}
+
+func TestIndexedRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(9))
+	h := NewIndexed[int]()
+	reference := make(map[int]float64)
+	for i := 0; i != 5000; i++ {
+		key := r.Intn(200)
+		if r.Intn(4) == 0 {
+			h.Remove(key)
+			delete(reference, key)
+		} else {
+			v := r.Float64()
+			h.Set(key, v)
+			reference[key] = v
+		}
+	}
+	var values []float64
+	for _, v := range reference {
+		values = append(values, v)
+	}
+	sort.Float64s(values)
+	if h.Len() != len(values) {
+		t.Fatal(h.Len())
+	}
+	for _, expected := range values {
+		k, v, _ := h.ExtractMinimum()
+		if v != expected || reference[k] != v {
+			t.Fatalf("extracted %d=%f, expected %f", k, v, expected)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package heap
+
+// Stable heaps
+//
+// Heap orders values only by their size, so values that are equal come out in
+// whatever order the swaps happened to leave them. A scheduler or event queue
+// usually needs them in the order they went in (first in, first out), and
+// needs that order to be the same on every run.
+//
+// Stable is a max-heap whose entries have a composite Priority: a primary
+// value, and a secondary sequence number that breaks ties between equal
+// values, lowest first. By default the sequence number counts insertions, so
+// equal values leave in insertion order, but any tiebreaker that fits in a
+// uint64 can be supplied instead.
+
+// Priority is the composite priority of an entry in a Stable heap
+type Priority struct {
+	Value float64
+	Seq   uint64
+}
+
+// Before returns true if *p* has a higher priority than *q*
+func (p Priority) Before(q Priority) bool {
+	return p.Value > q.Value || p.Value == q.Value && p.Seq < q.Seq
+}
+
+// Stable is a fixed-capacity max-heap of labelled values that breaks ties in
+// insertion order
+type Stable struct {
+	priority []Priority
+	label    []int
+	capacity int
+	seq      uint64
+}
+
+// NewStable creates an empty Stable heap
+func NewStable(capacity int) *Stable {
+	return &Stable{
+		priority: make([]Priority, 0, capacity),
+		label:    make([]int, 0, capacity),
+		capacity: capacity,
+	}
+}
+
+// Len returns the number of values in the heap
+func (h *Stable) Len() int {
+	return len(h.priority)
+}
+
+// Cap returns the maximum number of values the heap can hold
+func (h *Stable) Cap() int {
+	return h.capacity
+}
+
+// Insert adds a labelled value to the heap, after any equal values already in
+// it. It returns ErrOverflow if the heap is at capacity.
+func (h *Stable) Insert(label int, value float64) error {
+	if err := h.InsertPriority(label, Priority{value, h.seq}); err != nil {
+		return err
+	}
+	h.seq++
+	return nil
+}
+
+// InsertPriority adds a labelled value with an explicit tiebreaker to the
+// heap, returning ErrOverflow if the heap is at capacity
+func (h *Stable) InsertPriority(label int, p Priority) error {
+	if len(h.priority) == h.capacity {
+		return ErrOverflow
+	}
+	h.priority = append(h.priority, p)
+	h.label = append(h.label, label)
+	h.up(len(h.priority) - 1)
+	return nil
+}
+
+// Maximum returns the entry with the highest priority
+func (h *Stable) Maximum() (int, Priority, error) {
+	if len(h.priority) == 0 {
+		return 0, Priority{}, ErrEmpty
+	}
+	return h.label[0], h.priority[0], nil
+}
+
+// ExtractMaximum removes and returns the entry with the highest priority
+func (h *Stable) ExtractMaximum() (int, Priority, error) {
+	if len(h.priority) == 0 {
+		return 0, Priority{}, ErrEmpty
+	}
+	label, p := h.label[0], h.priority[0]
+	last := len(h.priority) - 1
+	h.swap(0, last)
+	h.priority = h.priority[:last]
+	h.label = h.label[:last]
+	h.down(0)
+	return label, p, nil
+}
+
+// Filter removes every entry whose label *keep* returns false for, in O(n).
+// The remaining entries keep their priorities, and so their order.
+func (h *Stable) Filter(keep func(label int) bool) {
+	n := 0
+	for i, label := range h.label {
+		if keep(label) {
+			h.label[n], h.priority[n] = label, h.priority[i]
+			n++
+		}
+	}
+	h.label = h.label[:n]
+	h.priority = h.priority[:n]
+	for i := n/2 - 1; i >= 0; i-- {
+		h.down(i)
+	}
+}
+
+func (h *Stable) swap(i, j int) {
+	h.priority[i], h.priority[j] = h.priority[j], h.priority[i]
+	h.label[i], h.label[j] = h.label[j], h.label[i]
+}
+
+func (h *Stable) up(i int) {
+	for i != 0 {
+		parent := (i - 1) / 2
+		if !h.priority[i].Before(h.priority[parent]) {
+			return
+		}
+		h.swap(i, parent)
+		i = parent
+	}
+}
+
+func (h *Stable) down(i int) {
+	n := len(h.priority)
+	for {
+		first := i
+		for _, child := range []int{2*i + 1, 2*i + 2} {
+			if child < n && h.priority[child].Before(h.priority[first]) {
+				first = child
+			}
+		}
+		if first == i {
+			return
+		}
+		h.swap(i, first)
+		i = first
+	}
+}
@@ -0,0 +1,58 @@
+package heap
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestStableFIFO(t *testing.T) {
+	h := NewStable(100)
+	rng := rand.New(rand.NewSource(1))
+	for label := 0; label != 100; label++ {
+		h.Insert(label, float64(rng.Intn(5)))
+	}
+	if err := h.Insert(100, 0); err != ErrOverflow {
+		t.Error(err)
+	}
+
+	lastValue, lastLabel := 5.0, -1
+	for h.Len() != 0 {
+		label, p, _ := h.ExtractMaximum()
+		if p.Value > lastValue || p.Value == lastValue && label < lastLabel {
+			t.Fatalf("%d (%v) extracted after %d (%v)", label, p.Value, lastLabel, lastValue)
+		}
+		lastValue, lastLabel = p.Value, label
+	}
+	if _, _, err := h.ExtractMaximum(); err != ErrEmpty {
+		t.Error(err)
+	}
+}
+
+func TestStableTiebreaker(t *testing.T) {
+	h := NewStable(3)
+	h.InsertPriority(1, Priority{1, 30})
+	h.InsertPriority(2, Priority{1, 10})
+	h.InsertPriority(3, Priority{1, 20})
+	for _, expected := range []int{2, 3, 1} {
+		if label, _, _ := h.ExtractMaximum(); label != expected {
+			t.Error(label, expected)
+		}
+	}
+}
+
+func TestStableFilter(t *testing.T) {
+	h := NewStable(20)
+	for label := 0; label != 20; label++ {
+		h.Insert(label, float64(label%2))
+	}
+	h.Filter(func(label int) bool { return label%3 != 0 })
+	expected := []int{1, 5, 7, 11, 13, 17, 19, 2, 4, 8, 10, 14, 16}
+	if h.Len() != len(expected) {
+		t.Fatal(h.Len())
+	}
+	for _, e := range expected {
+		if label, _, _ := h.ExtractMaximum(); label != e {
+			t.Fatal(label, e)
+		}
+	}
+}
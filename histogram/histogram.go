@@ -0,0 +1,327 @@
+/*
+ * Package histogram records the distribution of a stream of values in a
+ * fixed set of buckets, as metrics systems do for latencies and sizes.
+ *
+ * A histogram keeps a count per bucket instead of the values themselves, so
+ * it takes constant space however many values it records, and two
+ * histograms with the same buckets can be merged by adding their counts,
+ * for instance to combine measurements from many servers. The price is
+ * precision: a percentile can only be located within a bucket, and is
+ * estimated by assuming the values are spread evenly across it.
+ *
+ * The buckets follow one of two layouts. Linear buckets all have the same
+ * width, which suits values in a known narrow range. Exponential buckets
+ * each grow by a constant factor,
+ *
+ *    [1, 2) [2, 4) [4, 8) [8, 16) ...
+ *
+ * so that the relative error of an estimate is the same at every scale,
+ * which suits values, like latencies, that span orders of magnitude. Values
+ * below the first bucket or above the last are counted in an underflow and
+ * an overflow bucket, which are bounded by the smallest and largest values
+ * seen.
+ */
+
+package histogram
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+var (
+	ErrCorrupt  = errors.New("corrupt histogram encoding")
+	ErrMismatch = errors.New("histograms have different layouts")
+)
+
+type scheme byte
+
+const (
+	linear scheme = iota + 1
+	exponential
+)
+
+// Layout describes the buckets of a histogram. Layouts can be compared with
+// ==.
+type Layout struct {
+	scheme scheme
+	start  float64
+	step   float64 // width of linear buckets, or growth factor of exponential ones
+	n      int
+}
+
+// Linear returns a layout of *n* buckets of width *width*, the first starting
+// at *start*
+func Linear(start, width float64, n int) Layout {
+	if !(width > 0) || n < 1 {
+		panic("histogram: width and number of buckets must be positive")
+	}
+	return Layout{linear, start, width, n}
+}
+
+// Exponential returns a layout of *n* buckets, the first covering [*start*,
+// *start* × *factor*) and each subsequent one *factor* times as wide
+func Exponential(start, factor float64, n int) Layout {
+	if !(start > 0) || !(factor > 1) || n < 1 {
+		panic("histogram: start must be positive, factor above 1, and number of buckets positive")
+	}
+	return Layout{exponential, start, factor, n}
+}
+
+// Buckets returns the number of buckets, not counting underflow and overflow
+func (l Layout) Buckets() int {
+	return l.n
+}
+
+// Bound returns the lower bound of bucket *i*, which is also the upper bound
+// of bucket i-1. Bound(Buckets()) is the upper bound of the last bucket.
+func (l Layout) Bound(i int) float64 {
+	if l.scheme == linear {
+		return l.start + float64(i)*l.step
+	}
+	return l.start * math.Pow(l.step, float64(i))
+}
+
+// index returns the bucket of *v*, which is -1 for underflow and n for
+// overflow. *v* must not be NaN, which RecordN drops before calling it.
+func (l Layout) index(v float64) int {
+	// Values outside the buckets, including infinities, are sorted out before
+	// any conversion to int, which would overflow for them
+	switch {
+	case v < l.start:
+		return -1
+	case !(v < l.Bound(l.n)):
+		return l.n
+	}
+	var i int
+	if l.scheme == linear {
+		i = int(math.Floor((v - l.start) / l.step))
+	} else {
+		i = int(math.Floor(math.Log(v/l.start) / math.Log(l.step)))
+	}
+	// Rounding in the division or logarithm can put a value next to a bound
+	// in the wrong bucket
+	if i < l.n && v < l.Bound(i) {
+		i--
+	} else if i+1 < l.n && v >= l.Bound(i+1) {
+		i++
+	}
+	if i < 0 {
+		return 0
+	}
+	if i >= l.n {
+		return l.n - 1
+	}
+	return i
+}
+
+// Histogram counts values in the buckets of a Layout
+type Histogram struct {
+	layout   Layout
+	counts   []uint64 // underflow, the buckets, then overflow
+	total    uint64
+	sum      float64
+	min, max float64
+}
+
+// New creates an empty Histogram with the buckets of *layout*
+func New(layout Layout) *Histogram {
+	return &Histogram{
+		layout: layout,
+		counts: make([]uint64, layout.n+2),
+		min:    math.Inf(1),
+		max:    math.Inf(-1),
+	}
+}
+
+// Layout returns the layout of the histogram's buckets
+func (h *Histogram) Layout() Layout {
+	return h.layout
+}
+
+// Record adds a value to the histogram. NaN belongs in no bucket, and is
+// ignored.
+func (h *Histogram) Record(v float64) {
+	h.RecordN(v, 1)
+}
+
+// RecordN adds *n* copies of a value to the histogram. NaN is ignored, as
+// by Record.
+func (h *Histogram) RecordN(v float64, n uint64) {
+	if n == 0 || math.IsNaN(v) {
+		return
+	}
+	h.counts[h.layout.index(v)+1] += n
+	h.total += n
+	h.sum += v * float64(n)
+	h.min = math.Min(h.min, v)
+	h.max = math.Max(h.max, v)
+}
+
+// Count returns the number of values recorded
+func (h *Histogram) Count() uint64 {
+	return h.total
+}
+
+// Sum returns the sum of the values recorded
+func (h *Histogram) Sum() float64 {
+	return h.sum
+}
+
+// Mean returns the mean of the values recorded, or NaN if there are none
+func (h *Histogram) Mean() float64 {
+	return h.sum / float64(h.total)
+}
+
+// Min returns the smallest value recorded, or +Inf if there are none
+func (h *Histogram) Min() float64 {
+	return h.min
+}
+
+// Max returns the largest value recorded, or -Inf if there are none
+func (h *Histogram) Max() float64 {
+	return h.max
+}
+
+// Bucket is a bucket of a histogram, covering [Lo, Hi)
+type Bucket struct {
+	Lo, Hi float64
+	Count  uint64
+}
+
+// bucket returns the bucket at position *i* of counts, bounding the underflow
+// and overflow buckets by the smallest and largest values
+func (h *Histogram) bucket(i int) Bucket {
+	switch i {
+	case 0:
+		return Bucket{h.min, h.layout.Bound(0), h.counts[0]}
+	case h.layout.n + 1:
+		return Bucket{h.layout.Bound(h.layout.n), h.max, h.counts[i]}
+	}
+	return Bucket{h.layout.Bound(i - 1), h.layout.Bound(i), h.counts[i]}
+}
+
+// Buckets returns the non-empty buckets, in order, including underflow and
+// overflow
+func (h *Histogram) Buckets() []Bucket {
+	var buckets []Bucket
+	for i, c := range h.counts {
+		if c != 0 {
+			buckets = append(buckets, h.bucket(i))
+		}
+	}
+	return buckets
+}
+
+// CountBelow returns the number of values recorded below *v*, which is exact
+// when *v* is a bucket bound
+func (h *Histogram) CountBelow(v float64) uint64 {
+	var n uint64
+	for i := range h.counts {
+		b := h.bucket(i)
+		if b.Count == 0 || b.Lo >= v {
+			continue
+		}
+		if b.Hi <= v {
+			n += b.Count
+		} else {
+			n += uint64(float64(b.Count) * (v - b.Lo) / (b.Hi - b.Lo))
+		}
+	}
+	return n
+}
+
+// Quantile estimates the value below which a fraction *q* of the recorded
+// values lie, such as 0.99 for the 99th percentile, by interpolating within
+// the bucket that contains it. It returns NaN if the histogram is empty.
+func (h *Histogram) Quantile(q float64) float64 {
+	if h.total == 0 {
+		return math.NaN()
+	}
+	rank := q * float64(h.total)
+	// Find the first bucket at which the cumulative count reaches the rank
+	below := 0.0
+	for i := range h.counts {
+		b := h.bucket(i)
+		if below+float64(b.Count) >= rank {
+			v := b.Lo
+			if b.Count != 0 {
+				v += (b.Hi - b.Lo) * (rank - below) / float64(b.Count)
+			}
+			return math.Max(h.min, math.Min(h.max, v))
+		}
+		below += float64(b.Count)
+	}
+	return h.max
+}
+
+// Merge adds the counts of *other* to the histogram, or returns ErrMismatch
+// if their layouts differ
+func (h *Histogram) Merge(other *Histogram) error {
+	if h.layout != other.layout {
+		return ErrMismatch
+	}
+	for i, c := range other.counts {
+		h.counts[i] += c
+	}
+	h.total += other.total
+	h.sum += other.sum
+	h.min = math.Min(h.min, other.min)
+	h.max = math.Max(h.max, other.max)
+	return nil
+}
+
+// MarshalBinary encodes the histogram as its layout, its sum, minimum and
+// maximum as float64s, and its counts as uvarints
+func (h *Histogram) MarshalBinary() ([]byte, error) {
+	data := []byte{byte(h.layout.scheme)}
+	for _, f := range []float64{h.layout.start, h.layout.step, h.sum, h.min, h.max} {
+		data = binary.LittleEndian.AppendUint64(data, math.Float64bits(f))
+	}
+	data = binary.AppendUvarint(data, uint64(h.layout.n))
+	for _, c := range h.counts {
+		data = binary.AppendUvarint(data, c)
+	}
+	return data, nil
+}
+
+// UnmarshalBinary decodes a histogram encoded by MarshalBinary
+func (h *Histogram) UnmarshalBinary(data []byte) error {
+	if len(data) < 41 {
+		return ErrCorrupt
+	}
+	s := scheme(data[0])
+	var f [5]float64
+	for i := range f {
+		f[i] = math.Float64frombits(binary.LittleEndian.Uint64(data[1+8*i:]))
+	}
+	data = data[41:]
+	n, k := binary.Uvarint(data)
+	if k <= 0 || n < 1 || n > uint64(len(data)) {
+		return ErrCorrupt
+	}
+	data = data[k:]
+	layout := Layout{s, f[0], f[1], int(n)}
+	switch {
+	case s == linear && f[1] > 0, s == exponential && f[0] > 0 && f[1] > 1:
+	default:
+		return ErrCorrupt
+	}
+	decoded := New(layout)
+	for i := range decoded.counts {
+		c, k := binary.Uvarint(data)
+		if k <= 0 {
+			return ErrCorrupt
+		}
+		decoded.counts[i] = c
+		decoded.total += c
+		data = data[k:]
+	}
+	if len(data) != 0 {
+		return ErrCorrupt
+	}
+	decoded.sum, decoded.min, decoded.max = f[2], f[3], f[4]
+	*h = *decoded
+	return nil
+}
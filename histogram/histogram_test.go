@@ -0,0 +1,178 @@
+package histogram
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestLayoutIndex(t *testing.T) {
+	l := Linear(0, 10, 5)
+	for v, expected := range map[float64]int{-1: -1, 0: 0, 9.99: 0, 10: 1, 49.9: 4, 50: 5, 1e9: 5} {
+		if i := l.index(v); i != expected {
+			t.Errorf("linear %v: bucket %d, expected %d", v, i, expected)
+		}
+	}
+	e := Exponential(1, 2, 10)
+	for v, expected := range map[float64]int{0.5: -1, 1: 0, 2: 1, 3.9: 1, 4: 2, 512: 9, 1023: 9, 1024: 10} {
+		if i := e.index(v); i != expected {
+			t.Errorf("exponential %v: bucket %d, expected %d", v, i, expected)
+		}
+	}
+	// Every bound of a layout with an inexact factor falls in the bucket it
+	// starts
+	e = Exponential(0.001, 1.1, 200)
+	for i := 0; i != 200; i++ {
+		if j := e.index(e.Bound(i)); j != i {
+			t.Fatal("bound", i, "in bucket", j)
+		}
+	}
+}
+
+func TestLayoutIndexExtremes(t *testing.T) {
+	for _, l := range []Layout{Linear(-5, 10, 5), Exponential(1, 2, 10)} {
+		for v, expected := range map[float64]int{
+			math.MaxFloat64:  l.n,
+			1e300:            l.n,
+			math.Inf(1):      l.n,
+			-math.MaxFloat64: -1,
+			math.Inf(-1):     -1,
+		} {
+			if i := l.index(v); i != expected {
+				t.Errorf("%v %v: bucket %d, expected %d", l.scheme, v, i, expected)
+			}
+		}
+		// Just below the upper bound is the last bucket
+		if i := l.index(math.Nextafter(l.Bound(l.n), 0)); i != l.n-1 {
+			t.Errorf("%v: bucket %d below the upper bound", l.scheme, i)
+		}
+	}
+	h := New(Exponential(1, 2, 10))
+	h.Record(math.Inf(1))
+	h.Record(math.Inf(-1))
+	if buckets := h.Buckets(); len(buckets) != 2 || buckets[0].Count != 1 || buckets[1].Count != 1 || buckets[1].Lo != 1024 {
+		t.Error(buckets)
+	}
+}
+
+func TestRecordNaN(t *testing.T) {
+	h := New(Linear(0, 1, 10))
+	h.Record(2)
+	h.Record(math.NaN())
+	h.RecordN(math.NaN(), 5)
+	if h.Count() != 1 || h.Sum() != 2 || h.Min() != 2 || h.Max() != 2 {
+		t.Error(h.Count(), h.Sum(), h.Min(), h.Max())
+	}
+	if buckets := h.Buckets(); len(buckets) != 1 || buckets[0].Lo != 2 {
+		t.Error("NaN counted in", buckets)
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	h := New(Linear(0, 10, 10))
+	for v := 0; v != 100; v++ {
+		h.Record(float64(v))
+	}
+	h.RecordN(150, 10)
+	if h.Count() != 110 || h.Min() != 0 || h.Max() != 150 {
+		t.Error(h.Count(), h.Min(), h.Max())
+	}
+	if mean := h.Mean(); mean != (4950.0+1500)/110 {
+		t.Error(mean)
+	}
+	buckets := h.Buckets()
+	if len(buckets) != 11 || buckets[3] != (Bucket{30, 40, 10}) || buckets[10] != (Bucket{100, 150, 10}) {
+		t.Error(buckets)
+	}
+	if n := h.CountBelow(50); n != 50 {
+		t.Error(n)
+	}
+	if n := h.CountBelow(55); n != 55 {
+		t.Error(n)
+	}
+	if q := h.Quantile(0.5); q != 55 {
+		t.Error(q)
+	}
+	if q := h.Quantile(1); q != 150 {
+		t.Error(q)
+	}
+	if q := h.Quantile(0); q != 0 {
+		t.Error(q)
+	}
+	if !math.IsNaN(New(Linear(0, 1, 1)).Quantile(0.5)) {
+		t.Error("quantile of an empty histogram")
+	}
+}
+
+func TestQuantileAccuracy(t *testing.T) {
+	// Exponential buckets growing by 10% keep the relative error of every
+	// quantile within 10%
+	r := rand.New(rand.NewSource(1))
+	h := New(Exponential(1e-6, 1.1, 300))
+	values := make([]float64, 100000)
+	for i := range values {
+		values[i] = math.Exp(r.NormFloat64() * 3) // spanning many orders of magnitude
+		h.Record(values[i])
+	}
+	sort.Float64s(values)
+	for _, q := range []float64{0.01, 0.5, 0.9, 0.99, 0.999} {
+		exact := values[int(q*float64(len(values)))]
+		if estimate := h.Quantile(q); math.Abs(estimate-exact) > 0.1*exact {
+			t.Errorf("q=%v: estimated %v, exact %v", q, estimate, exact)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	a, b := New(Exponential(1, 2, 8)), New(Exponential(1, 2, 8))
+	a.Record(3)
+	b.Record(100)
+	b.Record(0.5)
+	if err := a.Merge(b); err != nil {
+		t.Fatal(err)
+	}
+	if a.Count() != 3 || a.Min() != 0.5 || a.Max() != 100 || a.Sum() != 103.5 {
+		t.Error(a.Count(), a.Min(), a.Max(), a.Sum())
+	}
+	if err := a.Merge(New(Exponential(1, 2, 9))); err != ErrMismatch {
+		t.Error(err)
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	for _, layout := range []Layout{Linear(-5, 0.5, 40), Exponential(0.1, 1.5, 30)} {
+		h := New(layout)
+		for v := -10.0; v < 20; v += 0.7 {
+			h.Record(v)
+		}
+		data, _ := h.MarshalBinary()
+		var decoded Histogram
+		if err := decoded.UnmarshalBinary(data); err != nil {
+			t.Fatal(err)
+		}
+		if decoded.Layout() != layout || decoded.Count() != h.Count() || decoded.Sum() != h.Sum() ||
+			decoded.Quantile(0.3) != h.Quantile(0.3) {
+			t.Error(decoded.Layout(), decoded.Count(), decoded.Sum())
+		}
+		for _, corrupt := range [][]byte{nil, data[:41], data[:len(data)-1], append(data, 0)} {
+			if err := decoded.UnmarshalBinary(corrupt); err != ErrCorrupt {
+				t.Error(len(corrupt), err)
+			}
+		}
+	}
+}
+
+func BenchmarkRecord(b *testing.B) {
+	for _, layout := range []struct {
+		name   string
+		layout Layout
+	}{{"Linear", Linear(0, 1, 1000)}, {"Exponential", Exponential(1, 1.1, 200)}} {
+		h := New(layout.layout)
+		b.Run(layout.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				h.Record(float64(i % 1000))
+			}
+		})
+	}
+}
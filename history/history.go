@@ -0,0 +1,124 @@
+/*
+ * Package history implements an undo/redo history of edits, as kept by
+ * editors.
+ *
+ * The history is two stacks. Pushing an edit puts it on the undo stack, and
+ * undoing moves the latest edit from there to the redo stack, from which
+ * redoing moves it back:
+ *
+ *    undo  [a b c]  redo  []       after pushing a, b and c
+ *    undo  [a]      redo  [c b]    after undoing twice
+ *    undo  [a d]    redo  []       after pushing d
+ *
+ * A new edit after an undo starts a new line of history, so it clears the
+ * redo stack. The history may be limited to a number of edits, beyond which
+ * the oldest are forgotten; the undo stack is then a queue.Ring, from whose
+ * front old edits are dropped while new ones are pushed and popped at the
+ * back, each in O(1).
+ *
+ * Typing a word one character at a time should not take as many undos to
+ * remove again, so a History may be given a function that coalesces an edit
+ * with the one before it, such as two insertions of adjacent text. Edits are
+ * only coalesced while nothing else happens in between: an Undo or Redo, or a
+ * call of Seal (say, when the cursor moves or the user pauses), ends the run.
+ *
+ * The history records edits of any type T, and what an edit is, and how it is
+ * applied and reverted, is up to the caller.
+ */
+
+package history
+
+import "github.com/njwilson23/datastructures/queue"
+
+// History is a bounded undo/redo history of edits of type T
+type History[T any] struct {
+	undo     *queue.Ring[T] // oldest edit at the front
+	redo     []T            // next edit to redo at the end
+	limit    int
+	coalesce func(last, next T) (T, bool)
+	sealed   bool // the next edit starts a new run
+}
+
+// New creates an empty History that remembers at most *limit* edits, or any
+// number if *limit* is 0. If *coalesce* is not nil, it is called with the
+// latest edit and a new one, and returns the two combined and true, or false
+// if they must stay separate.
+func New[T any](limit int, coalesce func(last, next T) (T, bool)) *History[T] {
+	if limit < 0 {
+		panic("history: negative limit")
+	}
+	return &History[T]{undo: queue.NewRing[T](0), limit: limit, coalesce: coalesce}
+}
+
+// Push records *edit*, which has just been made, and forgets the edits that
+// could be redone. It returns true if the edit was coalesced with the one
+// before it.
+func (h *History[T]) Push(edit T) bool {
+	h.redo = nil
+	sealed := h.sealed
+	h.sealed = false
+	if last, err := h.undo.PeekBack(); err == nil && !sealed && h.coalesce != nil {
+		if combined, ok := h.coalesce(last, edit); ok {
+			h.undo.PopBack()
+			h.undo.Push(combined)
+			return true
+		}
+	}
+	if h.limit != 0 && h.undo.Len() == h.limit {
+		h.undo.Pop()
+	}
+	h.undo.Push(edit)
+	return false
+}
+
+// Undo returns the latest edit, for the caller to revert, and moves it to be
+// redone, or returns false if there is nothing to undo
+func (h *History[T]) Undo() (T, bool) {
+	edit, err := h.undo.PopBack()
+	if err != nil {
+		return edit, false
+	}
+	h.redo = append(h.redo, edit)
+	h.sealed = true
+	return edit, true
+}
+
+// Redo returns the latest edit undone, for the caller to apply again, and
+// moves it back to be undone, or returns false if there is nothing to redo
+func (h *History[T]) Redo() (T, bool) {
+	if len(h.redo) == 0 {
+		var none T
+		return none, false
+	}
+	edit := h.redo[len(h.redo)-1]
+	var zero T
+	h.redo[len(h.redo)-1] = zero
+	h.redo = h.redo[:len(h.redo)-1]
+	// The undo stack has room, since the edit came from it
+	h.undo.Push(edit)
+	h.sealed = true
+	return edit, true
+}
+
+// Seal ends the current run of edits, so that the next edit pushed is not
+// coalesced with the latest
+func (h *History[T]) Seal() {
+	h.sealed = true
+}
+
+// UndoLen returns the number of edits that can be undone
+func (h *History[T]) UndoLen() int {
+	return h.undo.Len()
+}
+
+// RedoLen returns the number of edits that can be redone
+func (h *History[T]) RedoLen() int {
+	return len(h.redo)
+}
+
+// Clear forgets every edit
+func (h *History[T]) Clear() {
+	h.undo = queue.NewRing[T](0)
+	h.redo = nil
+	h.sealed = false
+}
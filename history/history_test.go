@@ -0,0 +1,148 @@
+package history
+
+import (
+	"strings"
+	"testing"
+)
+
+// insert is an edit inserting text at a position in a document
+type insert struct {
+	pos  int
+	text string
+}
+
+// adjacent coalesces an insertion that continues the one before it
+func adjacent(last, next insert) (insert, bool) {
+	if next.pos != last.pos+len(last.text) {
+		return insert{}, false
+	}
+	return insert{last.pos, last.text + next.text}, true
+}
+
+// document applies and reverts insertions
+type document struct {
+	text string
+}
+
+func (d *document) apply(e insert) {
+	d.text = d.text[:e.pos] + e.text + d.text[e.pos:]
+}
+
+func (d *document) revert(e insert) {
+	d.text = d.text[:e.pos] + d.text[e.pos+len(e.text):]
+}
+
+func TestUndoRedo(t *testing.T) {
+	h := New[insert](0, nil)
+	if _, ok := h.Undo(); ok {
+		t.Error("undo of empty history")
+	}
+	var d document
+	for _, e := range []insert{{0, "a"}, {1, "b"}, {2, "c"}} {
+		d.apply(e)
+		h.Push(e)
+	}
+	for _, expected := range []string{"ab", "a"} {
+		e, _ := h.Undo()
+		d.revert(e)
+		if d.text != expected {
+			t.Error(d.text, expected)
+		}
+	}
+	e, _ := h.Redo()
+	d.apply(e)
+	if d.text != "ab" || h.UndoLen() != 2 || h.RedoLen() != 1 {
+		t.Error(d.text, h.UndoLen(), h.RedoLen())
+	}
+	// A new edit clears what could be redone
+	d.apply(insert{0, "d"})
+	h.Push(insert{0, "d"})
+	if _, ok := h.Redo(); ok || h.UndoLen() != 3 {
+		t.Error("redo after a new edit")
+	}
+	for h.UndoLen() != 0 {
+		e, _ := h.Undo()
+		d.revert(e)
+	}
+	if d.text != "" || h.RedoLen() != 3 {
+		t.Error(d.text, h.RedoLen())
+	}
+	h.Clear()
+	if h.UndoLen() != 0 || h.RedoLen() != 0 {
+		t.Error("not cleared")
+	}
+}
+
+func TestLimit(t *testing.T) {
+	h := New[int](3, nil)
+	for i := 0; i != 10; i++ {
+		h.Push(i)
+	}
+	for _, expected := range []int{9, 8, 7} {
+		if e, ok := h.Undo(); !ok || e != expected {
+			t.Error(e, expected)
+		}
+	}
+	if _, ok := h.Undo(); ok {
+		t.Error("undid a forgotten edit")
+	}
+	for i := 0; i != 3; i++ {
+		h.Redo()
+	}
+	h.Push(10)
+	if h.UndoLen() != 3 {
+		t.Error(h.UndoLen())
+	}
+}
+
+func TestCoalesce(t *testing.T) {
+	h := New[insert](0, adjacent)
+	var d document
+	typed := func(pos int, text string) {
+		for i, c := range text {
+			e := insert{pos + i, string(c)}
+			d.apply(e)
+			h.Push(e)
+		}
+	}
+	typed(0, "hello")
+	typed(5, " world")
+	if h.UndoLen() != 1 {
+		t.Fatal(h.UndoLen())
+	}
+	// Sealing the run, or editing elsewhere, starts a new edit
+	h.Seal()
+	typed(11, "!")
+	typed(0, ">>")
+	if h.UndoLen() != 3 || d.text != ">>hello world!" {
+		t.Fatal(h.UndoLen(), d.text)
+	}
+	e, _ := h.Undo()
+	d.revert(e)
+	// An edit after an undo is not coalesced with the one before it
+	if h.Push(insert{12, "?"}) {
+		t.Error("coalesced across an undo")
+	}
+	d.apply(insert{12, "?"})
+	var undone []string
+	for h.UndoLen() != 0 {
+		e, _ := h.Undo()
+		d.revert(e)
+		undone = append(undone, e.text)
+	}
+	if strings.Join(undone, "|") != "?|!|hello world" || d.text != "" {
+		t.Error(undone, d.text)
+	}
+}
+
+func BenchmarkPush(b *testing.B) {
+	h := New[insert](1000, adjacent)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		// Runs of 8 coalesced edits
+		if i%8 == 0 {
+			h.Seal()
+		}
+		h.Push(insert{i, "x"})
+	}
+}
@@ -0,0 +1,193 @@
+/*
+ * Package intern implements a string intern table, which stores one copy of
+ * each distinct string and hands out small, stable handles for them.
+ *
+ * Programs that read many repeated strings (field names, tags, identifiers in
+ * a compiler) can spend much of their memory on duplicates. Interning every
+ * string as it is read keeps one copy of each, and lets the rest of the
+ * program compare and hash the 4-byte handles instead of the strings.
+ *
+ * The table maps strings to handles with a hashtable.HashTable, which is grown
+ * (rebuilt with twice as many buckets) whenever it holds more strings than
+ * buckets, and maps handles back to strings with a slice indexed by handle.
+ *
+ * Each Go string allocated separately carries the overhead of a heap
+ * allocation, which for short strings can exceed the string itself. The bytes
+ * of interned strings are therefore stored in tiers by size:
+ *
+ *    tier 0: up to 16 bytes    packed into 4 KiB chunks
+ *    tier 1: up to 256 bytes   packed into 64 KiB chunks
+ *    tier 2: longer            one allocation each
+ *
+ * Chunks are only appended to, and never modified or freed, so the strings
+ * pointing into them stay valid for the life of the table. Packing wastes at
+ * most the unused tail of each chunk, and the largest strings, whose
+ * allocation overhead is negligible, do not waste chunk space.
+ */
+
+package intern
+
+import (
+	"hash/fnv"
+	"strings"
+	"unsafe"
+
+	"github.com/njwilson23/datastructures/hashtable"
+)
+
+// Handle identifies an interned string
+type Handle uint32
+
+type tier struct {
+	maxLen    int
+	chunkSize int // 0 for strings allocated individually
+	chunk     []byte
+
+	strings, bytes, allocated int
+}
+
+// store copies *s* into the tier, and returns the copy
+func (t *tier) store(s string) string {
+	t.strings++
+	t.bytes += len(s)
+	if t.chunkSize == 0 {
+		t.allocated += len(s)
+		return strings.Clone(s)
+	}
+	if len(s) == 0 {
+		return ""
+	}
+	if len(t.chunk)+len(s) > cap(t.chunk) {
+		t.chunk = make([]byte, 0, t.chunkSize)
+		t.allocated += t.chunkSize
+	}
+	start := len(t.chunk)
+	t.chunk = append(t.chunk, s...)
+	return unsafe.String(&t.chunk[start], len(s))
+}
+
+// key is a string used as a hash table key
+type key string
+
+// Hash returns the FNV-1a hash of the string. HashTable's multiplicative hash
+// computes in float64, which has no fractional bits to spare above 2^53, so
+// the hash is truncated to 31 bits.
+func (k key) Hash() int {
+	h := fnv.New32a()
+	h.Write([]byte(k))
+	return int(h.Sum32() & (1<<31 - 1))
+}
+
+// Table is a string intern table. It is not safe for concurrent use.
+type Table struct {
+	index   *hashtable.HashTable // key -> Handle
+	strings []string             // by Handle
+	tiers   [3]tier
+
+	calls, requested int
+}
+
+// New creates an empty Table
+func New() *Table {
+	return &Table{
+		index: hashtable.InitHashTable(64),
+		tiers: [3]tier{
+			{maxLen: 16, chunkSize: 4 << 10},
+			{maxLen: 256, chunkSize: 64 << 10},
+			{},
+		},
+	}
+}
+
+// Len returns the number of distinct strings interned
+func (t *Table) Len() int {
+	return len(t.strings)
+}
+
+// Intern returns the handle for *s*, storing a copy of it if it has not been
+// interned before
+func (t *Table) Intern(s string) Handle {
+	t.calls++
+	t.requested += len(s)
+	if h, err := t.index.Get(key(s)); err == nil {
+		return h.(Handle)
+	}
+
+	tr := &t.tiers[len(t.tiers)-1]
+	for i := range t.tiers[:len(t.tiers)-1] {
+		if len(s) <= t.tiers[i].maxLen {
+			tr = &t.tiers[i]
+			break
+		}
+	}
+	stored := tr.store(s)
+
+	h := Handle(len(t.strings))
+	t.strings = append(t.strings, stored)
+	if len(t.strings) > t.index.Size {
+		t.grow()
+	} else {
+		t.index.Insert(key(stored), h)
+	}
+	return h
+}
+
+// InternBytes is like Intern for a byte slice, which is not retained
+func (t *Table) InternBytes(b []byte) Handle {
+	return t.Intern(string(b))
+}
+
+// grow rebuilds the index with twice as many buckets
+func (t *Table) grow() {
+	t.index = hashtable.InitHashTable(2 * t.index.Size)
+	for h, s := range t.strings {
+		t.index.Insert(key(s), Handle(h))
+	}
+}
+
+// Lookup returns the handle for *s*, or false if it has not been interned
+func (t *Table) Lookup(s string) (Handle, bool) {
+	h, err := t.index.Get(key(s))
+	if err != nil {
+		return 0, false
+	}
+	return h.(Handle), true
+}
+
+// String returns the string for a handle
+func (t *Table) String(h Handle) string {
+	return t.strings[h]
+}
+
+// TierStats describes the strings stored in one size tier
+type TierStats struct {
+	MaxLen    int // 0 for the tier of the longest strings
+	Strings   int
+	Bytes     int // total length of the strings
+	Allocated int // bytes allocated to store them
+}
+
+// Stats describes how much memory interning has saved
+type Stats struct {
+	Calls     int // number of strings interned
+	Unique    int // number of distinct strings
+	Requested int // total length of the strings interned, with repeats
+	Stored    int // total length of the distinct strings
+	Tiers     []TierStats
+}
+
+// Saved returns the number of bytes of string data that would have been
+// duplicated without interning
+func (s Stats) Saved() int {
+	return s.Requested - s.Stored
+}
+
+// Stats returns statistics on the strings interned so far
+func (t *Table) Stats() Stats {
+	s := Stats{Calls: t.calls, Unique: len(t.strings), Requested: t.requested}
+	for _, tr := range t.tiers {
+		s.Stored += tr.bytes
+		s.Tiers = append(s.Tiers, TierStats{tr.maxLen, tr.strings, tr.bytes, tr.allocated})
+	}
+	return s
+}
@@ -0,0 +1,79 @@
+package intern
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestIntern(t *testing.T) {
+	table := New()
+	a := table.Intern("apple")
+	b := table.InternBytes([]byte("banana"))
+	if table.Intern("apple") != a || table.InternBytes([]byte("apple")) != a || table.Intern("banana") != b {
+		t.Fail()
+	}
+	if a == b || table.String(a) != "apple" || table.String(b) != "banana" || table.Len() != 2 {
+		t.Fail()
+	}
+	if h, ok := table.Lookup("banana"); !ok || h != b {
+		t.Fail()
+	}
+	if _, ok := table.Lookup("cherry"); ok || table.Len() != 2 {
+		t.Fail()
+	}
+	empty := table.Intern("")
+	if table.Intern("") != empty || table.String(empty) != "" {
+		t.Fail()
+	}
+}
+
+func TestHandlesStable(t *testing.T) {
+	table := New()
+	var handles []Handle
+	for i := 0; i != 5000; i++ {
+		handles = append(handles, table.Intern(fmt.Sprintf("key-%d", i)))
+	}
+	for i := 0; i != 5000; i++ {
+		s := fmt.Sprintf("key-%d", i)
+		if h := table.Intern(s); h != handles[i] || table.String(h) != s {
+			t.Fatal(i, h, handles[i])
+		}
+	}
+	if table.Len() != 5000 {
+		t.Error(table.Len())
+	}
+}
+
+func TestBytesNotRetained(t *testing.T) {
+	table := New()
+	b := []byte("mutable")
+	h := table.InternBytes(b)
+	copy(b, "MUTATED")
+	if table.String(h) != "mutable" {
+		t.Error(table.String(h))
+	}
+}
+
+func TestStats(t *testing.T) {
+	table := New()
+	long := strings.Repeat("x", 1000)
+	for i := 0; i != 3; i++ {
+		table.Intern("short")
+		table.Intern(strings.Repeat("m", 100))
+		table.Intern(long)
+	}
+	s := table.Stats()
+	if s.Calls != 9 || s.Unique != 3 || s.Requested != 3*1105 || s.Stored != 1105 || s.Saved() != 2*1105 {
+		t.Error(s)
+	}
+	for i, expected := range []TierStats{
+		{16, 1, 5, 4 << 10},
+		{256, 1, 100, 64 << 10},
+		{0, 1, 1000, 1000},
+	} {
+		if s.Tiers[i] != expected {
+			t.Error(i, s.Tiers[i])
+		}
+	}
+}
@@ -0,0 +1,145 @@
+/*
+ * Package intervalset implements a set of integers stored as disjoint
+ * intervals, such as booked time slots or allocated address ranges.
+ *
+ * Each interval is half-open, [Lo, Hi), and the intervals are kept disjoint
+ * and non-adjacent: adding an interval that overlaps or touches others merges
+ * them into one, and removing an interval from the middle of another splits
+ * it in two.
+ *
+ *    Add [3, 5)     [1, 3) [7, 9)   ->   [1, 5) [7, 9)
+ *    Remove [2, 8)  [1, 5) [7, 9)   ->   [1, 2) [8, 9)
+ *
+ * The intervals are stored in a red-black tree (see package rbtree), keyed by
+ * their start. Since they are disjoint, the only interval that can contain x
+ * is the one with the largest start no greater than x (the "floor" of x), so
+ * membership queries are O(log n). Adding or removing an interval is
+ * O((k + 1) log n), where k is the number of intervals it merges or removes.
+ */
+
+package intervalset
+
+import "github.com/njwilson23/datastructures/rbtree"
+
+// Interval is the half-open interval [Lo, Hi)
+type Interval struct {
+	Lo, Hi int
+}
+
+// Set is a set of integers, stored as disjoint intervals
+type Set struct {
+	tree *rbtree.RedBlackTree // start -> *Interval
+	len  int
+}
+
+// New creates an empty Set
+func New() *Set {
+	return &Set{tree: rbtree.New()}
+}
+
+// Len returns the number of disjoint intervals in the set
+func (s *Set) Len() int {
+	return s.len
+}
+
+func (s *Set) get(lo int) *Interval {
+	v, _ := s.tree.Get(lo)
+	return v.(*Interval)
+}
+
+func (s *Set) insert(lo, hi int) {
+	s.tree.InsertValue(lo, &Interval{lo, hi})
+	s.len++
+}
+
+func (s *Set) delete(lo int) {
+	s.tree.Delete(lo)
+	s.len--
+}
+
+// containing returns the interval containing or ending at *x*, or nil
+func (s *Set) containing(x int) *Interval {
+	lo, ok := s.tree.Floor(x)
+	if !ok {
+		return nil
+	}
+	if iv := s.get(lo); iv.Hi >= x {
+		return iv
+	}
+	return nil
+}
+
+// Add adds the integers in [lo, hi) to the set
+func (s *Set) Add(lo, hi int) {
+	if lo >= hi {
+		return
+	}
+	// Merge with an interval that starts before lo and reaches it
+	if iv := s.containing(lo); iv != nil {
+		if iv.Hi >= hi {
+			return
+		}
+		lo = iv.Lo
+		s.delete(lo)
+	}
+	// Absorb the intervals that start within [lo, hi]
+	for start, ok := s.tree.Ceiling(lo); ok && start <= hi; start, ok = s.tree.Ceiling(lo) {
+		if iv := s.get(start); iv.Hi > hi {
+			hi = iv.Hi
+		}
+		s.delete(start)
+	}
+	s.insert(lo, hi)
+}
+
+// Remove removes the integers in [lo, hi) from the set
+func (s *Set) Remove(lo, hi int) {
+	if lo >= hi {
+		return
+	}
+	// Trim an interval that starts before lo and overlaps [lo, hi)
+	if iv := s.containing(lo); iv != nil && iv.Lo < lo {
+		end := iv.Hi
+		iv.Hi = lo
+		if end > hi {
+			s.insert(hi, end)
+			return
+		}
+	}
+	// Remove the intervals that start within [lo, hi), keeping any part of the
+	// last one that extends beyond hi
+	for start, ok := s.tree.Ceiling(lo); ok && start < hi; start, ok = s.tree.Ceiling(lo) {
+		end := s.get(start).Hi
+		s.delete(start)
+		if end > hi {
+			s.insert(hi, end)
+			return
+		}
+	}
+}
+
+// Covers returns true if *x* is in the set
+func (s *Set) Covers(x int) bool {
+	iv := s.containing(x)
+	return iv != nil && iv.Hi > x
+}
+
+// NextFree returns the smallest integer no less than *x* that is not in the
+// set. Intervals are never adjacent, so it is either x or the end of the
+// interval containing x.
+func (s *Set) NextFree(x int) int {
+	if iv := s.containing(x); iv != nil && iv.Hi > x {
+		return iv.Hi
+	}
+	return x
+}
+
+// Intervals returns the intervals in the set, in ascending order
+func (s *Set) Intervals() []Interval {
+	intervals := make([]Interval, 0, s.len)
+	it := s.tree.Iter()
+	for _, ok := it.Next(); ok; _, ok = it.Next() {
+		intervals = append(intervals, *it.Value().(*Interval))
+	}
+	return intervals
+}
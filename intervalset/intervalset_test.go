@@ -0,0 +1,82 @@
+package intervalset
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestAddRemove(t *testing.T) {
+	s := New()
+	s.Add(1, 3)
+	s.Add(7, 9)
+	s.Add(3, 5)
+	if fmt.Sprint(s.Intervals()) != "[{1 5} {7 9}]" {
+		t.Error(s.Intervals())
+	}
+	s.Remove(2, 8)
+	if fmt.Sprint(s.Intervals()) != "[{1 2} {8 9}]" {
+		t.Error(s.Intervals())
+	}
+	s.Add(0, 20)
+	s.Remove(5, 6)
+	if fmt.Sprint(s.Intervals()) != "[{0 5} {6 20}]" || s.Len() != 2 {
+		t.Error(s.Intervals())
+	}
+	if !s.Covers(0) || s.Covers(5) || !s.Covers(19) || s.Covers(20) {
+		t.Fail()
+	}
+	if s.NextFree(3) != 5 || s.NextFree(5) != 5 || s.NextFree(6) != 20 || s.NextFree(-1) != -1 {
+		t.Fail()
+	}
+}
+
+func TestRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	s := New()
+	var present [100]bool
+	for i := 0; i != 1000; i++ {
+		lo := rng.Intn(100)
+		hi := lo + rng.Intn(100-lo) + 1
+		add := rng.Intn(2) == 0
+		if add {
+			s.Add(lo, hi)
+		} else {
+			s.Remove(lo, hi)
+		}
+		for x := lo; x != hi; x++ {
+			present[x] = add
+		}
+
+		// The intervals must be disjoint, non-adjacent, and cover exactly the
+		// integers present
+		intervals := s.Intervals()
+		if len(intervals) != s.Len() {
+			t.Fatal(s.Len(), intervals)
+		}
+		var covered [100]bool
+		for j, iv := range intervals {
+			if iv.Lo >= iv.Hi || j != 0 && intervals[j-1].Hi >= iv.Lo {
+				t.Fatal(intervals)
+			}
+			for x := iv.Lo; x != iv.Hi; x++ {
+				covered[x] = true
+			}
+		}
+		if covered != present {
+			t.Fatal(intervals)
+		}
+		for x := 0; x != 100; x++ {
+			if s.Covers(x) != present[x] {
+				t.Fatal(x)
+			}
+			free := x
+			for free < 100 && present[free] {
+				free++
+			}
+			if s.NextFree(x) != free {
+				t.Fatal(x, s.NextFree(x), free)
+			}
+		}
+	}
+}
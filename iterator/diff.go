@@ -0,0 +1,62 @@
+package iterator
+
+// Diffs of ordered maps
+//
+// To reconcile two versions of a keyed collection, for instance a local copy
+// and a copy received from a peer, it is enough to know which keys were
+// added, which were removed, and which have a new value. If both versions can
+// be walked in key order, this is one more merge: the smaller head key is
+// only in its own map, and equal head keys are in both, so their values are
+// compared. This costs O(n + m) and makes no copies of either map.
+
+import "reflect"
+
+// MapIterator yields the entries of an ordered map in ascending key order.
+// Value returns the value of the key most recently returned by Next.
+// rbtree.Iterator and skiplist.Iterator are MapIterators.
+type MapIterator interface {
+	Iterator
+	Value() interface{}
+}
+
+// Delta lists the differences between two ordered maps, each in ascending key
+// order
+type Delta struct {
+	Added   []int // keys only in the new map
+	Removed []int // keys only in the old map
+	Changed []int // keys in both maps, with different values
+}
+
+// Empty returns true if the maps were the same
+func (d Delta) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// Diff compares an old version of an ordered map, *before*, with a new one,
+// *after*. Keys must be unique. Values are compared with *equal*, or with
+// reflect.DeepEqual if *equal* is nil.
+func Diff(before, after MapIterator, equal func(a, b interface{}) bool) Delta {
+	if equal == nil {
+		equal = reflect.DeepEqual
+	}
+	var d Delta
+	a, okA := before.Next()
+	b, okB := after.Next()
+	for okA || okB {
+		switch {
+		case !okB || okA && a < b:
+			d.Removed = append(d.Removed, a)
+			a, okA = before.Next()
+		case !okA || b < a:
+			d.Added = append(d.Added, b)
+			b, okB = after.Next()
+		default:
+			if !equal(before.Value(), after.Value()) {
+				d.Changed = append(d.Changed, a)
+			}
+			a, okA = before.Next()
+			b, okB = after.Next()
+		}
+	}
+	return d
+}
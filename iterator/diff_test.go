@@ -0,0 +1,68 @@
+package iterator
+
+import (
+	"testing"
+
+	"github.com/njwilson23/datastructures/rbtree"
+	"github.com/njwilson23/datastructures/skiplist"
+)
+
+func TestDiff(t *testing.T) {
+	before := rbtree.New()
+	for i, v := range []string{"a", "b", "c", "d"} {
+		before.InsertValue(2*i, v) // 0 2 4 6
+	}
+	after := skiplist.New(skiplist.ItemSlice{
+		{Key: 1, Value: "x"},
+		{Key: 2, Value: "b"},
+		{Key: 4, Value: "z"},
+		{Key: 6, Value: "d"},
+		{Key: 7, Value: "y"},
+	}, 0.5)
+
+	d := Diff(before.Iter(), after.Iter(), nil)
+	if !slicesEqual(d.Added, []int{1, 7}) {
+		t.Error("added", d.Added)
+	}
+	if !slicesEqual(d.Removed, []int{0}) {
+		t.Error("removed", d.Removed)
+	}
+	if !slicesEqual(d.Changed, []int{4}) {
+		t.Error("changed", d.Changed)
+	}
+	if d.Empty() {
+		t.Error("delta should not be empty")
+	}
+
+	if d := Diff(before.Iter(), before.Iter(), nil); !d.Empty() {
+		t.Error("map differs from itself", d)
+	}
+}
+
+func TestDiffEmpty(t *testing.T) {
+	tree := rbtree.FromSlice([]int{1, 2, 3})
+	d := Diff(rbtree.New().Iter(), tree.Iter(), nil)
+	if !slicesEqual(d.Added, []int{1, 2, 3}) || len(d.Removed) != 0 {
+		t.Error(d)
+	}
+	d = Diff(tree.Iter(), rbtree.New().Iter(), nil)
+	if !slicesEqual(d.Removed, []int{1, 2, 3}) || len(d.Added) != 0 {
+		t.Error(d)
+	}
+}
+
+func TestDiffEqual(t *testing.T) {
+	a, b := rbtree.New(), rbtree.New()
+	a.InsertValue(1, 1.0)
+	b.InsertValue(1, 1.0001)
+	if d := Diff(a.Iter(), b.Iter(), nil); !slicesEqual(d.Changed, []int{1}) {
+		t.Error(d)
+	}
+	near := func(x, y interface{}) bool {
+		diff := x.(float64) - y.(float64)
+		return -0.01 < diff && diff < 0.01
+	}
+	if d := Diff(a.Iter(), b.Iter(), near); !d.Empty() {
+		t.Error(d)
+	}
+}
@@ -0,0 +1,261 @@
+/*
+ * Package iterator provides lazy operations over ascending sequences of keys.
+ *
+ * The ordered containers in this repository (rbtree, skiplist) and plain
+ * sorted slices can all be walked in ascending order. An Iterator captures
+ * this with a single method, so that any of them can be combined with any
+ * other without first copying into a slice.
+ *
+ * The set operations below are all variations on the merge step of merge sort
+ * (see package mergesort): the heads of two sorted sequences are compared, and
+ * the smaller is consumed. Each call to Next does O(1) work (amortized over the
+ * whole sequence), so combining sequences of length n and m costs O(n + m) in
+ * total and only O(1) memory, regardless of how much of the result is used.
+ *
+ * Inputs are treated as multisets: a key appearing i times in a and j times in
+ * b appears max(i, j) times in their union, min(i, j) times in their
+ * intersection, and max(i-j, 0) times in their difference.
+ */
+
+package iterator
+
+// Iterator yields keys in ascending order. Next returns the next key, or false
+// when the sequence is exhausted.
+type Iterator interface {
+	Next() (int, bool)
+}
+
+// SliceIterator is an Iterator over a sorted slice
+type SliceIterator struct {
+	keys []int
+	pos  int
+}
+
+// FromSlice returns an Iterator over a slice, which must already be sorted
+func FromSlice(keys []int) *SliceIterator {
+	return &SliceIterator{keys, 0}
+}
+
+// Next returns the next key in the slice
+func (it *SliceIterator) Next() (int, bool) {
+	if it.pos == len(it.keys) {
+		return 0, false
+	}
+	it.pos++
+	return it.keys[it.pos-1], true
+}
+
+// Collect exhausts an Iterator and returns its keys as a slice
+func Collect(it Iterator) []int {
+	var keys []int
+	for key, ok := it.Next(); ok; key, ok = it.Next() {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// peeker wraps an Iterator so that the next key can be inspected without
+// consuming it
+type peeker struct {
+	it  Iterator
+	key int
+	ok  bool
+}
+
+func newPeeker(it Iterator) *peeker {
+	p := &peeker{it: it}
+	p.advance()
+	return p
+}
+
+func (p *peeker) advance() {
+	p.key, p.ok = p.it.Next()
+}
+
+// merger holds the state shared by the two-sequence operations. Each
+// operation supplies a step function that consumes from a and/or b and
+// reports whether it produced a key.
+type merger struct {
+	a, b *peeker
+	step func(a, b *peeker) (int, bool, bool)
+}
+
+// Next advances the merge until the step function emits a key or both inputs
+// are exhausted
+func (m *merger) Next() (int, bool) {
+	for m.a.ok || m.b.ok {
+		key, emit, done := m.step(m.a, m.b)
+		if done {
+			break
+		}
+		if emit {
+			return key, true
+		}
+	}
+	return 0, false
+}
+
+func newMerger(a, b Iterator, step func(a, b *peeker) (int, bool, bool)) *merger {
+	return &merger{newPeeker(a), newPeeker(b), step}
+}
+
+// Merge returns an Iterator over every key of a and b, as in the merge step
+// of merge sort. Ties are broken in favour of a.
+func Merge(a, b Iterator) Iterator {
+	return newMerger(a, b, func(a, b *peeker) (int, bool, bool) {
+		var key int
+		if !b.ok || (a.ok && !(b.key < a.key)) {
+			key = a.key
+			a.advance()
+		} else {
+			key = b.key
+			b.advance()
+		}
+		return key, true, false
+	})
+}
+
+// UnionSorted returns an Iterator over the keys present in either a or b
+func UnionSorted(a, b Iterator) Iterator {
+	return newMerger(a, b, func(a, b *peeker) (int, bool, bool) {
+		var key int
+		switch {
+		case !b.ok || (a.ok && a.key < b.key):
+			key = a.key
+			a.advance()
+		case !a.ok || b.key < a.key:
+			key = b.key
+			b.advance()
+		default:
+			// Equal keys are emitted once
+			key = a.key
+			a.advance()
+			b.advance()
+		}
+		return key, true, false
+	})
+}
+
+// IntersectSorted returns an Iterator over the keys present in both a and b
+func IntersectSorted(a, b Iterator) Iterator {
+	return newMerger(a, b, func(a, b *peeker) (int, bool, bool) {
+		switch {
+		case !a.ok || !b.ok:
+			// Nothing more can be common to both
+			return 0, false, true
+		case a.key < b.key:
+			a.advance()
+		case b.key < a.key:
+			b.advance()
+		default:
+			key := a.key
+			a.advance()
+			b.advance()
+			return key, true, false
+		}
+		return 0, false, false
+	})
+}
+
+// DiffSorted returns an Iterator over the keys present in a but not in b
+func DiffSorted(a, b Iterator) Iterator {
+	return newMerger(a, b, func(a, b *peeker) (int, bool, bool) {
+		switch {
+		case !a.ok:
+			return 0, false, true
+		case !b.ok || a.key < b.key:
+			key := a.key
+			a.advance()
+			return key, true, false
+		case b.key < a.key:
+			b.advance()
+		default:
+			a.advance()
+			b.advance()
+		}
+		return 0, false, false
+	})
+}
+
+// MergeK returns an Iterator over every key of every input, in ascending
+// order. Ties are broken in favour of the input listed first, and Source
+// reports which input each key came from.
+//
+// Merging k inputs pairwise would compare each key up to k-1 times. Instead,
+// the heads of the inputs are kept in a binary min-heap, so that each key
+// costs O(log k) comparisons to produce. The heap is ordered on int keys, so
+// the float64 heaps of package heap are not used, since they cannot represent
+// every int exactly.
+func MergeK(its ...Iterator) *MultiMerge {
+	m := &MultiMerge{source: -1}
+	for i, it := range its {
+		p := newPeeker(it)
+		if p.ok {
+			m.heads = append(m.heads, kHead{p, i})
+		}
+	}
+	for i := len(m.heads)/2 - 1; i >= 0; i-- {
+		m.down(i)
+	}
+	return m
+}
+
+type kHead struct {
+	p     *peeker
+	index int
+}
+
+// MultiMerge is the Iterator returned by MergeK. It is a min-heap of the
+// inputs that are not yet exhausted, ordered by their next key and then by
+// their position in the argument list.
+type MultiMerge struct {
+	heads  []kHead
+	source int
+}
+
+func (m *MultiMerge) less(i, j int) bool {
+	a, b := m.heads[i], m.heads[j]
+	return a.p.key < b.p.key || (a.p.key == b.p.key && a.index < b.index)
+}
+
+func (m *MultiMerge) down(i int) {
+	n := len(m.heads)
+	for {
+		smallest := i
+		for _, child := range []int{2*i + 1, 2*i + 2} {
+			if child < n && m.less(child, smallest) {
+				smallest = child
+			}
+		}
+		if smallest == i {
+			return
+		}
+		m.heads[i], m.heads[smallest] = m.heads[smallest], m.heads[i]
+		i = smallest
+	}
+}
+
+// Next consumes the key at the root of the heap, then sifts that input down
+// to its new place, or removes it if it is exhausted
+func (m *MultiMerge) Next() (int, bool) {
+	if len(m.heads) == 0 {
+		return 0, false
+	}
+	p := m.heads[0].p
+	key := p.key
+	m.source = m.heads[0].index
+	p.advance()
+	if !p.ok {
+		last := len(m.heads) - 1
+		m.heads[0] = m.heads[last]
+		m.heads = m.heads[:last]
+	}
+	m.down(0)
+	return key, true
+}
+
+// Source returns the position in the argument list of MergeK of the input
+// that produced the key most recently returned by Next
+func (m *MultiMerge) Source() int {
+	return m.source
+}
@@ -0,0 +1,144 @@
+package iterator
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/njwilson23/datastructures/rbtree"
+	"github.com/njwilson23/datastructures/skiplist"
+)
+
+func slicesEqual(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestFromSlice(t *testing.T) {
+	keys := Collect(FromSlice([]int{1, 2, 3}))
+	if !slicesEqual(keys, []int{1, 2, 3}) {
+		t.Error(keys)
+	}
+	if len(Collect(FromSlice(nil))) != 0 {
+		t.Fail()
+	}
+}
+
+func TestMerge(t *testing.T) {
+	keys := Collect(Merge(FromSlice([]int{1, 3, 5, 5}), FromSlice([]int{2, 3, 6})))
+	if !slicesEqual(keys, []int{1, 2, 3, 3, 5, 5, 6}) {
+		t.Error(keys)
+	}
+}
+
+func TestMergeK(t *testing.T) {
+	keys := Collect(MergeK(
+		FromSlice([]int{1, 4, 7}),
+		FromSlice(nil),
+		FromSlice([]int{2, 4, 9, 10}),
+		FromSlice([]int{0, 5}),
+	))
+	if !slicesEqual(keys, []int{0, 1, 2, 4, 4, 5, 7, 9, 10}) {
+		t.Error(keys)
+	}
+	if len(Collect(MergeK())) != 0 {
+		t.Fail()
+	}
+
+	m := MergeK(FromSlice([]int{1, 3}), FromSlice([]int{1, 2}))
+	var sources []int
+	for _, ok := m.Next(); ok; _, ok = m.Next() {
+		sources = append(sources, m.Source())
+	}
+	if !slicesEqual(sources, []int{0, 1, 1, 0}) {
+		t.Error(sources)
+	}
+
+	// Merging many inputs matches merging them pairwise
+	var its, pairwise []Iterator
+	for i := 0; i != 20; i++ {
+		keys := make([]int, 10)
+		for j := range keys {
+			keys[j] = (i*7 + j*13) % 50
+		}
+		sort.Ints(keys)
+		its = append(its, FromSlice(keys))
+		pairwise = append(pairwise, FromSlice(keys))
+	}
+	expected := pairwise[0]
+	for _, it := range pairwise[1:] {
+		expected = Merge(expected, it)
+	}
+	if keys, exp := Collect(MergeK(its...)), Collect(expected); !slicesEqual(keys, exp) {
+		t.Error(keys, exp)
+	}
+}
+
+func TestUnionSorted(t *testing.T) {
+	keys := Collect(UnionSorted(FromSlice([]int{1, 3, 5, 5}), FromSlice([]int{2, 3, 5, 6})))
+	if !slicesEqual(keys, []int{1, 2, 3, 5, 5, 6}) {
+		t.Error(keys)
+	}
+	keys = Collect(UnionSorted(FromSlice(nil), FromSlice([]int{4})))
+	if !slicesEqual(keys, []int{4}) {
+		t.Error(keys)
+	}
+}
+
+func TestIntersectSorted(t *testing.T) {
+	keys := Collect(IntersectSorted(FromSlice([]int{1, 3, 5, 5, 7}), FromSlice([]int{2, 3, 5, 6, 7})))
+	if !slicesEqual(keys, []int{3, 5, 7}) {
+		t.Error(keys)
+	}
+	keys = Collect(IntersectSorted(FromSlice([]int{1, 2}), FromSlice(nil)))
+	if len(keys) != 0 {
+		t.Error(keys)
+	}
+}
+
+func TestDiffSorted(t *testing.T) {
+	keys := Collect(DiffSorted(FromSlice([]int{1, 3, 5, 5, 7}), FromSlice([]int{2, 3, 5, 6})))
+	if !slicesEqual(keys, []int{1, 5, 7}) {
+		t.Error(keys)
+	}
+	keys = Collect(DiffSorted(FromSlice(nil), FromSlice([]int{1})))
+	if len(keys) != 0 {
+		t.Error(keys)
+	}
+}
+
+func TestContainers(t *testing.T) {
+	tree := rbtree.FromSlice([]int{8, 2, 6, 4, 10})
+	list := skiplist.New(skiplist.ItemSlice{
+		{Key: 3, Value: nil},
+		{Key: 6, Value: nil},
+		{Key: 9, Value: nil},
+		{Key: 10, Value: nil},
+	}, 0.5)
+
+	keys := Collect(IntersectSorted(tree.Iter(), list.Iter()))
+	if !slicesEqual(keys, []int{6, 10}) {
+		t.Error(keys)
+	}
+
+	keys = Collect(DiffSorted(tree.Iter(), list.Iter()))
+	if !slicesEqual(keys, []int{2, 4, 8}) {
+		t.Error(keys)
+	}
+
+	keys = Collect(UnionSorted(UnionSorted(tree.Iter(), list.Iter()), FromSlice([]int{1})))
+	if !slicesEqual(keys, []int{1, 2, 3, 4, 6, 8, 9, 10}) {
+		t.Error(keys)
+	}
+
+	keys = Collect(MergeK(tree.Iter(), list.Iter(), FromSlice([]int{1, 6})))
+	if !slicesEqual(keys, []int{1, 2, 3, 4, 6, 6, 6, 8, 9, 10, 10}) {
+		t.Error(keys)
+	}
+}
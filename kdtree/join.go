@@ -0,0 +1,96 @@
+package kdtree
+
+// Spatial joins
+//
+// To find every pair of points, one from each of two trees, that lie within
+// a distance r of each other, one could query the second tree around each
+// point of the first. A dual-tree join (Gray and Moore, 2001) instead walks
+// both trees together, visiting pairs of subtrees. If the bounding boxes of
+// two subtrees are further apart than r, no pair of points drawn from them
+// can match, so the whole pair is skipped at once. Points in a dense region
+// of one tree then share the work of pruning against a distant region of the
+// other, rather than each rediscovering that it is too far away.
+//
+// Each node holds a point as well as two subtrees, so the pairs drawn from
+// subtrees A and B, whose roots hold points a and b, are split as
+//
+//    {a} x B                  a radius query around a in B
+//    (Al ∪ Ar) x {b}          radius queries around b in Al and Ar
+//    (Al ∪ Ar) x (Bl ∪ Br)    four joins of child subtrees
+//
+// which covers every pair exactly once. The saving is largest when the
+// points are clustered; for uniformly scattered points and a small radius,
+// BenchmarkJoin shows the join taking about two thirds of the time of one
+// radius query per point.
+
+import "math"
+
+// squaredDistance returns the square of the Euclidean distance between two
+// points
+func squaredDistance(a, b Point) float64 {
+	d := 0.0
+	for i := range a {
+		d += (a[i] - b[i]) * (a[i] - b[i])
+	}
+	return d
+}
+
+// squaredGap returns the square of the smallest distance between a point in
+// one box and a point in the other
+func squaredGap(a, b BBox) float64 {
+	d := 0.0
+	for i := range a.Min {
+		gap := math.Max(b.Min[i]-a.Max[i], a.Min[i]-b.Max[i])
+		if gap > 0 {
+			d += gap * gap
+		}
+	}
+	return d
+}
+
+// QueryRadius calls *f* with each point within distance *r* of *p*, until *f*
+// returns false
+func (t *KDTree) QueryRadius(p Point, r float64, f func(Point) bool) {
+	t.check(p)
+	pairs(p, t.root, r*r, false, func(_, q Point) bool {
+		return f(q)
+	})
+}
+
+// Join calls *f* with each pair of points, *a* from this tree and *b* from
+// *other*, that are within distance *maxDist* of each other, until *f*
+// returns false. Both trees must have the same dimension.
+func (t *KDTree) Join(other *KDTree, maxDist float64, f func(a, b Point) bool) {
+	if t.k != other.k {
+		panic("kdtree: joined trees have different dimensions")
+	}
+	join(t.root, other.root, maxDist*maxDist, f)
+}
+
+func join(a, b *node, r2 float64, f func(a, b Point) bool) bool {
+	if a == nil || b == nil || a.count == 0 || b.count == 0 || squaredGap(a.bounds, b.bounds) > r2 {
+		return true
+	}
+	if !a.deleted && !pairs(a.point, b, r2, false, f) {
+		return false
+	}
+	if !b.deleted && (!pairs(b.point, a.left, r2, true, f) || !pairs(b.point, a.right, r2, true, f)) {
+		return false
+	}
+	return join(a.left, b.left, r2, f) && join(a.left, b.right, r2, f) &&
+		join(a.right, b.left, r2, f) && join(a.right, b.right, r2, f)
+}
+
+// pairs is a radius query that calls *f* with *p* and each point found, in
+// the opposite order if *swap* is true
+func pairs(p Point, n *node, r2 float64, swap bool, f func(a, b Point) bool) bool {
+	if n == nil || n.count == 0 || squaredGap(BBox{p, p}, n.bounds) > r2 {
+		return true
+	}
+	if !n.deleted && squaredDistance(p, n.point) <= r2 {
+		if swap && !f(n.point, p) || !swap && !f(p, n.point) {
+			return false
+		}
+	}
+	return pairs(p, n.left, r2, swap, f) && pairs(p, n.right, r2, swap, f)
+}
@@ -0,0 +1,91 @@
+package kdtree
+
+import (
+	"math"
+	"testing"
+
+	"github.com/njwilson23/datastructures/gen"
+)
+
+func TestQueryRadius(t *testing.T) {
+	tree := Build(2, []Point{{0, 0}, {3, 4}, {1, 1}, {6, 8}, {-3, 4}})
+	n := 0
+	tree.QueryRadius(Point{0, 0}, 5, func(p Point) bool {
+		if math.Hypot(p[0], p[1]) > 5 {
+			t.Error(p)
+		}
+		n++
+		return true
+	})
+	if n != 4 {
+		t.Error(n)
+	}
+}
+
+func TestJoin(t *testing.T) {
+	g := gen.New(1)
+	for _, k := range []int{1, 2, 3} {
+		a, b := clustered(g, 300, k), clustered(g, 200, k)
+		ta, tb := Build(k, append([]Point{}, a...)), Build(k, append([]Point{}, b...))
+		// Tombstones must not be joined
+		for _, p := range a[:50] {
+			ta.Delete(p)
+		}
+		lo, hi := math.Inf(-1), math.Inf(1)
+		live := ta.Points(BBox{Point{lo, lo, lo}[:k], Point{hi, hi, hi}[:k]})
+
+		for _, maxDist := range []float64{0, 2, 10} {
+			expected := 0
+			for _, p := range live {
+				for _, q := range b {
+					if squaredDistance(p, q) <= maxDist*maxDist {
+						expected++
+					}
+				}
+			}
+			found := 0
+			ta.Join(tb, maxDist, func(p, q Point) bool {
+				if squaredDistance(p, q) > maxDist*maxDist {
+					t.Fatal("too far apart:", p, q)
+				}
+				found++
+				return true
+			})
+			if found != expected {
+				t.Errorf("k=%d, maxDist=%v: found %d pairs, expected %d", k, maxDist, found, expected)
+			}
+		}
+	}
+}
+
+func TestJoinStop(t *testing.T) {
+	tree := Build(1, []Point{{1}, {2}, {3}})
+	n := 0
+	tree.Join(tree, 10, func(p, q Point) bool {
+		n++
+		return n < 4
+	})
+	if n != 4 {
+		t.Error(n)
+	}
+}
+
+func BenchmarkJoin(b *testing.B) {
+	g := gen.New(1)
+	ta := Build(2, clustered(g, 20000, 2))
+	tb := Build(2, clustered(g, 20000, 2))
+	ignore := func(Point) bool { return true }
+	b.Run("Join", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			ta.Join(tb, 0.5, func(p, q Point) bool { return true })
+		}
+	})
+	b.Run("Queries", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			ta.Query(BBox{Point{0, 0}, Point{100, 100}}, func(p Point) bool {
+				tb.QueryRadius(p, 0.5, ignore)
+				return true
+			})
+		}
+	})
+}
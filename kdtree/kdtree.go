@@ -0,0 +1,356 @@
+/*
+ * Package kdtree implements a k-d tree, a binary search tree over points in k
+ * dimensions.
+ *
+ * Each level of the tree splits space along one axis, cycling through the
+ * axes with depth. A node at depth d holds a point p, and the points in its
+ * left subtree have a smaller coordinate on axis d mod k than p, while those
+ * in its right subtree have a coordinate at least as large:
+ *
+ *    depth 0 (x)         (5,4)
+ *                       /     \
+ *    depth 1 (y)    (2,3)     (8,1)
+ *                  /    \          \
+ *    depth 2 (x) (1,1)  (4,7)      (9,6)
+ *
+ * A range query descends into a subtree only if the box overlaps the side of
+ * the splitting plane it covers. Each node also records the number of points
+ * below it and their bounding box. A subtree whose bounding box lies entirely
+ * inside the query box can be counted in one step, and one whose bounding box
+ * misses the query box can be skipped, so Count does not visit every point
+ * it counts.
+ *
+ * Build creates a balanced tree by splitting at the median on each axis,
+ * found by quickselect, in O(n log n). Insert adds points at the leaves, like
+ * an unbalanced binary search tree, so a tree built by many insertions in
+ * sorted order can become deep.
+ *
+ * Removing a node from the middle of a k-d tree means finding a replacement
+ * with the smallest coordinate on that node's axis in its right subtree,
+ * which may be far from the search path, and repeating this all the way
+ * down. Instead, Delete marks the node as a "tombstone" and subtracts it from
+ * the counts on its path, leaving the shape of the tree alone. Tombstones
+ * still cost time during searches, so once they make up more than a set
+ * fraction of the nodes, the tree is rebuilt from its live points. A rebuild
+ * after Θ(n) deletions costs O(n log n), which keeps deletion O(log n)
+ * amortized in a balanced tree.
+ */
+
+package kdtree
+
+// Point is a point in k dimensions
+type Point []float64
+
+// BBox is the closed box with corners Min and Max
+type BBox struct {
+	Min, Max Point
+}
+
+// Contains returns true if *p* lies inside the box or on its boundary
+func (b BBox) Contains(p Point) bool {
+	for i := range p {
+		if p[i] < b.Min[i] || p[i] > b.Max[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// contains returns true if *other* lies entirely inside the box
+func (b BBox) contains(other BBox) bool {
+	return b.Contains(other.Min) && b.Contains(other.Max)
+}
+
+// overlaps returns true if the boxes have any point in common
+func (b BBox) overlaps(other BBox) bool {
+	for i := range b.Min {
+		if other.Max[i] < b.Min[i] || other.Min[i] > b.Max[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// extend grows the box to include *p*
+func (b BBox) extend(p Point) {
+	for i := range p {
+		if p[i] < b.Min[i] {
+			b.Min[i] = p[i]
+		}
+		if p[i] > b.Max[i] {
+			b.Max[i] = p[i]
+		}
+	}
+}
+
+type node struct {
+	point       Point
+	left, right *node
+	count       int  // number of live points in the subtree
+	bounds      BBox // bounding box of the points in the subtree, including tombstones
+	deleted     bool // true if the node is a tombstone
+}
+
+func newNode(p Point) *node {
+	corners := make(Point, 2*len(p))
+	copy(corners, p)
+	copy(corners[len(p):], p)
+	return &node{point: p, count: 1, bounds: BBox{corners[:len(p):len(p)], corners[len(p):]}}
+}
+
+// DefaultCompactThreshold is the fraction of tombstones above which a tree
+// is rebuilt
+const DefaultCompactThreshold = 0.25
+
+// KDTree is a k-d tree
+type KDTree struct {
+	root      *node
+	k         int
+	dead      int // number of tombstones
+	threshold float64
+}
+
+// New creates an empty KDTree for points in *k* dimensions
+func New(k int) *KDTree {
+	if k < 1 {
+		panic("kdtree: dimension must be positive")
+	}
+	return &KDTree{k: k, threshold: DefaultCompactThreshold}
+}
+
+// Build creates a balanced KDTree holding *points*, which must all have *k*
+// dimensions. The slice of points is reordered.
+func Build(k int, points []Point) *KDTree {
+	t := New(k)
+	for _, p := range points {
+		t.check(p)
+	}
+	t.root = build(points, 0, k)
+	return t
+}
+
+// build builds a balanced subtree splitting first on *axis*
+func build(points []Point, axis, k int) *node {
+	if len(points) == 0 {
+		return nil
+	}
+	mid := partition(points, axis)
+	n := newNode(points[mid])
+	next := (axis + 1) % k
+	n.left = build(points[:mid], next, k)
+	n.right = build(points[mid+1:], next, k)
+	for _, child := range []*node{n.left, n.right} {
+		if child != nil {
+			n.count += child.count
+			n.bounds.extend(child.bounds.Min)
+			n.bounds.extend(child.bounds.Max)
+		}
+	}
+	return n
+}
+
+// partition reorders *points* around their median on *axis*, and returns the
+// position of the first point equal to the median. Points before it are
+// smaller on that axis, and points after it are at least as large.
+//
+// This is quickselect with a three-way partition, which puts every point
+// equal to the pivot in one block. The block that contains the middle
+// position is exactly the set of points equal to the median, since the points
+// before and after the range still being searched are strictly smaller and
+// larger than those in it.
+func partition(points []Point, axis int) int {
+	nth := len(points) / 2
+	lo, hi := 0, len(points)
+	for {
+		pivot := points[lo+(hi-lo)/2][axis]
+		lt, i, gt := lo, lo, hi
+		for i < gt {
+			switch v := points[i][axis]; {
+			case v < pivot:
+				points[lt], points[i] = points[i], points[lt]
+				lt++
+				i++
+			case v > pivot:
+				gt--
+				points[i], points[gt] = points[gt], points[i]
+			default:
+				i++
+			}
+		}
+		switch {
+		case nth < lt:
+			hi = lt
+		case nth >= gt:
+			lo = gt
+		default:
+			return lt
+		}
+	}
+}
+
+func (t *KDTree) check(p Point) {
+	if len(p) != t.k {
+		panic("kdtree: point has the wrong dimension")
+	}
+}
+
+// Dim returns the number of dimensions
+func (t *KDTree) Dim() int {
+	return t.k
+}
+
+// Len returns the number of points in the tree, not counting tombstones
+func (t *KDTree) Len() int {
+	if t.root == nil {
+		return 0
+	}
+	return t.root.count
+}
+
+// Insert adds a point to the tree
+func (t *KDTree) Insert(p Point) {
+	t.check(p)
+	link := &t.root
+	for axis := 0; *link != nil; axis = (axis + 1) % t.k {
+		n := *link
+		n.count++
+		n.bounds.extend(p)
+		if p[axis] < n.point[axis] {
+			link = &n.left
+		} else {
+			link = &n.right
+		}
+	}
+	*link = newNode(p)
+}
+
+func equal(a, b Point) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Delete removes one copy of a point from the tree by marking it as a
+// tombstone, and returns false if the point is not present. The tree is
+// compacted if this brings the fraction of tombstones above the threshold.
+func (t *KDTree) Delete(p Point) bool {
+	t.check(p)
+	// Copies of p lie on the path taken by Insert, so find the first live
+	// one, and then take the path again to update the counts
+	var target *node
+	n := t.root
+	for axis := 0; n != nil; axis = (axis + 1) % t.k {
+		if !n.deleted && equal(n.point, p) {
+			target = n
+			break
+		}
+		if p[axis] < n.point[axis] {
+			n = n.left
+		} else {
+			n = n.right
+		}
+	}
+	if target == nil {
+		return false
+	}
+	target.deleted = true
+	for axis, n := 0, t.root; ; axis = (axis + 1) % t.k {
+		n.count--
+		if n == target {
+			break
+		}
+		if p[axis] < n.point[axis] {
+			n = n.left
+		} else {
+			n = n.right
+		}
+	}
+	t.dead++
+	if float64(t.dead) > t.threshold*float64(t.dead+t.Len()) {
+		t.Compact()
+	}
+	return true
+}
+
+// Tombstones returns the number of deleted points still held in the tree
+func (t *KDTree) Tombstones() int {
+	return t.dead
+}
+
+// SetCompactThreshold sets the fraction of tombstones among the nodes of the
+// tree above which Delete compacts it. A threshold of 1 or more turns off
+// automatic compaction.
+func (t *KDTree) SetCompactThreshold(fraction float64) {
+	t.threshold = fraction
+}
+
+// Compact rebuilds the tree as a balanced tree holding only the live points
+func (t *KDTree) Compact() {
+	points := make([]Point, 0, t.Len())
+	var collect func(n *node)
+	collect = func(n *node) {
+		if n == nil {
+			return
+		}
+		if !n.deleted {
+			points = append(points, n.point)
+		}
+		collect(n.left)
+		collect(n.right)
+	}
+	collect(t.root)
+	t.root = build(points, 0, t.k)
+	t.dead = 0
+}
+
+// Query calls *f* with each point inside *box*, until *f* returns false
+func (t *KDTree) Query(box BBox, f func(Point) bool) {
+	query(t.root, box, 0, t.k, f)
+}
+
+func query(n *node, box BBox, axis, k int, f func(Point) bool) bool {
+	if n == nil || !box.overlaps(n.bounds) {
+		return true
+	}
+	if !n.deleted && box.Contains(n.point) && !f(n.point) {
+		return false
+	}
+	next := (axis + 1) % k
+	if box.Min[axis] < n.point[axis] && !query(n.left, box, next, k, f) {
+		return false
+	}
+	return box.Max[axis] < n.point[axis] || query(n.right, box, next, k, f)
+}
+
+// Points returns the points inside *box*
+func (t *KDTree) Points(box BBox) []Point {
+	var points []Point
+	t.Query(box, func(p Point) bool {
+		points = append(points, p)
+		return true
+	})
+	return points
+}
+
+// Count returns the number of points inside *box*, without visiting the
+// subtrees that lie entirely inside or outside it
+func (t *KDTree) Count(box BBox) int {
+	return count(t.root, box)
+}
+
+func count(n *node, box BBox) int {
+	if n == nil || n.count == 0 || !box.overlaps(n.bounds) {
+		return 0
+	}
+	if box.contains(n.bounds) {
+		return n.count
+	}
+	c := count(n.left, box) + count(n.right, box)
+	if !n.deleted && box.Contains(n.point) {
+		c++
+	}
+	return c
+}
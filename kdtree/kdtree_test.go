@@ -0,0 +1,238 @@
+package kdtree
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/njwilson23/datastructures/gen"
+)
+
+// clustered returns *n* points in *k* dimensions from gen.Clustered, scaled
+// to about [0, 100) and rounded to tenths so that some points are repeated
+func clustered(g *gen.Generator, n, k int) []Point {
+	points := make([]Point, n)
+	for i, p := range g.Clustered(n, k, 10, 0.05) {
+		for j := range p {
+			p[j] = math.Round(p[j]*1000) / 10
+		}
+		points[i] = p
+	}
+	return points
+}
+
+func randomBox(g *gen.Generator, k int) BBox {
+	box := BBox{make(Point, k), make(Point, k)}
+	ends := g.Uniform(2*k, 1000)
+	for j := 0; j != k; j++ {
+		a, b := float64(ends[2*j])/10, float64(ends[2*j+1])/10
+		if a > b {
+			a, b = b, a
+		}
+		box.Min[j], box.Max[j] = a, b
+	}
+	return box
+}
+
+func TestBuild(t *testing.T) {
+	points := []Point{{2, 3}, {5, 4}, {9, 6}, {4, 7}, {8, 1}, {7, 2}}
+	tree := Build(2, points)
+	if tree.Len() != 6 || tree.Dim() != 2 {
+		t.Error(tree.Len(), tree.Dim())
+	}
+	if tree.root.point[0] != 7 {
+		t.Error("root", tree.root.point)
+	}
+	found := tree.Points(BBox{Point{3, 0}, Point{8, 5}})
+	if len(found) != 3 {
+		t.Error(found)
+	}
+	if n := tree.Count(BBox{Point{3, 0}, Point{8, 5}}); n != 3 {
+		t.Error(n)
+	}
+}
+
+func TestQueryAndCount(t *testing.T) {
+	g := gen.New(1)
+	for _, k := range []int{1, 2, 3} {
+		points := clustered(g, 500, k)
+		built := Build(k, append([]Point{}, points...))
+		inserted := New(k)
+		for _, p := range points {
+			inserted.Insert(p)
+		}
+		for i := 0; i != 100; i++ {
+			box := randomBox(g, k)
+			expected := 0
+			for _, p := range points {
+				if box.Contains(p) {
+					expected++
+				}
+			}
+			for _, tree := range []*KDTree{built, inserted} {
+				if n := len(tree.Points(box)); n != expected {
+					t.Fatalf("k=%d: query found %d points, expected %d", k, n, expected)
+				}
+				if n := tree.Count(box); n != expected {
+					t.Fatalf("k=%d: counted %d points, expected %d", k, n, expected)
+				}
+			}
+		}
+	}
+}
+
+func TestQueryStop(t *testing.T) {
+	tree := Build(1, []Point{{1}, {2}, {3}, {4}})
+	visited := 0
+	tree.Query(BBox{Point{0}, Point{5}}, func(Point) bool {
+		visited++
+		return false
+	})
+	if visited != 1 {
+		t.Error(visited)
+	}
+}
+
+func TestCountAllocations(t *testing.T) {
+	g := gen.New(1)
+	tree := Build(2, clustered(g, 1000, 2))
+	box := BBox{Point{10, 10}, Point{60, 60}}
+	if allocs := testing.AllocsPerRun(10, func() { tree.Count(box) }); allocs != 0 {
+		t.Error(allocs, "allocations")
+	}
+}
+
+func BenchmarkCount(b *testing.B) {
+	g := gen.New(1)
+	tree := Build(2, clustered(g, 100000, 2))
+	box := BBox{Point{10, 10}, Point{60, 60}}
+	b.Run("Count", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			tree.Count(box)
+		}
+	})
+	b.Run("Query", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			n := 0
+			tree.Query(box, func(Point) bool {
+				n++
+				return true
+			})
+		}
+	})
+}
+
+func TestDelete(t *testing.T) {
+	g := gen.New(1)
+	points := clustered(g, 1000, 2)
+	tree := Build(2, append([]Point{}, points...))
+	tree.SetCompactThreshold(1)
+
+	// Some points are repeated, so track the number of copies of each
+	copies := map[[2]float64]int{}
+	for _, p := range points {
+		copies[[2]float64{p[0], p[1]}]++
+	}
+	for _, i := range g.Uniform(600, len(points)) {
+		p := points[i]
+		key := [2]float64{p[0], p[1]}
+		if ok := tree.Delete(p); ok != (copies[key] != 0) {
+			t.Fatalf("deleting %v returned %v with %d copies", p, ok, copies[key])
+		} else if ok {
+			copies[key]--
+		}
+	}
+	n := 0
+	for _, c := range copies {
+		n += c
+	}
+	if tree.Tombstones() != len(points)-n {
+		t.Error(tree.Tombstones(), "tombstones")
+	}
+
+	for _, compact := range []bool{false, true} {
+		if compact {
+			tree.Compact()
+			if tree.Tombstones() != 0 {
+				t.Error(tree.Tombstones(), "tombstones after compacting")
+			}
+		}
+		if tree.Len() != n {
+			t.Fatal(tree.Len(), n)
+		}
+		for i := 0; i != 100; i++ {
+			box := randomBox(g, 2)
+			expected := 0
+			for key, c := range copies {
+				if box.Contains(key[:]) {
+					expected += c
+				}
+			}
+			if c := tree.Count(box); c != expected {
+				t.Fatalf("counted %d points, expected %d", c, expected)
+			}
+			if c := len(tree.Points(box)); c != expected {
+				t.Fatalf("query found %d points, expected %d", c, expected)
+			}
+		}
+	}
+}
+
+func TestDeleteDuplicates(t *testing.T) {
+	tree := New(2)
+	for i := 0; i != 3; i++ {
+		tree.Insert(Point{1, 1})
+	}
+	tree.Insert(Point{2, 2})
+	if tree.Delete(Point{3, 3}) {
+		t.Error("deleted a missing point")
+	}
+	for i := 0; i != 3; i++ {
+		if !tree.Delete(Point{1, 1}) {
+			t.Fatal("copy", i, "not deleted")
+		}
+	}
+	if tree.Delete(Point{1, 1}) {
+		t.Error("deleted a fourth copy")
+	}
+	if tree.Len() != 1 || tree.Count(BBox{Point{0, 0}, Point{5, 5}}) != 1 {
+		t.Error(tree.Len())
+	}
+}
+
+func TestAutoCompact(t *testing.T) {
+	g := gen.New(1)
+	points := clustered(g, 100, 2)
+	tree := Build(2, append([]Point{}, points...))
+	for i := 0; i != 25; i++ {
+		tree.Delete(points[i])
+	}
+	if tree.Tombstones() != 25 {
+		t.Error(tree.Tombstones())
+	}
+	// The 26th tombstone is more than a quarter of the 100 nodes
+	tree.Delete(points[25])
+	if tree.Tombstones() != 0 || tree.Len() != 74 {
+		t.Error(tree.Tombstones(), tree.Len())
+	}
+}
+
+func TestPartition(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for n := 1; n != 50; n++ {
+		points := make([]Point, n)
+		for i := range points {
+			points[i] = Point{float64(r.Intn(5))}
+		}
+		mid := partition(points, 0)
+		median := points[mid][0]
+		for i, p := range points {
+			if i < mid && p[0] >= median || i >= mid && p[0] < median {
+				t.Fatalf("n=%d: %v split at %d", n, points, mid)
+			}
+		}
+		if mid > n/2 || points[n/2][0] != median {
+			t.Fatalf("n=%d: %v split at %d", n, points, mid)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+// Heavy-light decomposition
+//
+// A query along the path between two nodes, such as the largest value on it,
+// can visit O(n) nodes. Heavy-light decomposition splits the tree into
+// chains so that any path crosses O(log n) of them. Each node's "heavy" child
+// is the one with the largest subtree, and the edges to heavy children join
+// the nodes into chains:
+//
+//	     a            chains: a-b-e, f, c, d-g
+//	   / | \
+//	  b  c  d         (heavy children: b of a, e of b, g of d)
+//	 / \     \
+//	e   f     g
+//
+// A light child's subtree is at most half its parent's, so a path from any
+// node up to the root leaves a chain at most log2(n) times. Numbering the
+// nodes in a preorder that visits each heavy child first places every chain,
+// and every subtree, in a contiguous range of positions, which a segment tree
+// (see package segtree) over the values answers in O(log n). A path query is
+// then O(log² n), and a subtree query O(log n).
+
+package lcrs
+
+import "github.com/njwilson23/datastructures/segtree"
+
+// HeavyLight answers queries combining the values along paths and within
+// subtrees of a tree, and allows the values to change. It decomposes the tree
+// as it was when created, and must be rebuilt if nodes are added, removed or
+// moved.
+type HeavyLight[T comparable, V any] struct {
+	index    map[*Node[T]]int // node -> index in preorder
+	parent   []int            // by index, or -1 for the root
+	depth    []int
+	head     []int // index of the top of the node's chain
+	pos      []int // position in the segment tree
+	size     []int
+	values   *segtree.Tree[V]
+	combine  func(a, b V) V
+	identity V
+}
+
+// NewHeavyLight decomposes the subtree rooted at *root* in O(n), giving each
+// node the value *value*(n.Value), combined by *combine*, whose identity
+// element is *identity*. *combine* must be associative and commutative, since
+// a path is not combined in order (for example, sum, min or max).
+func NewHeavyLight[T comparable, V any](root *Node[T], value func(T) V, combine func(a, b V) V, identity V) *HeavyLight[T, V] {
+	h := &HeavyLight[T, V]{index: make(map[*Node[T]]int), combine: combine, identity: identity}
+	var nodes []*Node[T]
+	root.WalkDepthFirst(func(n *Node[T]) bool {
+		h.index[n] = len(nodes)
+		nodes = append(nodes, n)
+		return true
+	})
+	count := len(nodes)
+	h.parent = make([]int, count)
+	h.depth = make([]int, count)
+	h.head = make([]int, count)
+	h.pos = make([]int, count)
+	h.size = make([]int, count)
+	h.parent[0] = -1
+	for i, n := range nodes[1:] {
+		p := h.index[n.parent]
+		h.parent[i+1] = p
+		h.depth[i+1] = h.depth[p] + 1
+	}
+	// Children follow their parents in preorder, so sizes are complete when
+	// summed in reverse
+	for i := count - 1; i >= 0; i-- {
+		h.size[i]++
+		if i > 0 {
+			h.size[h.parent[i]] += h.size[i]
+		}
+	}
+
+	// Number the nodes in preorder, pushing the heavy child last so that it
+	// is visited first and continues its parent's chain
+	data := make([]V, count)
+	stack := []int{0}
+	for next := 0; len(stack) != 0; next++ {
+		i := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		h.pos[i] = next
+		data[next] = value(nodes[i].Value)
+		heavy := -1
+		for c := nodes[i].child; c != nil; c = c.sibling {
+			j := h.index[c]
+			h.head[j] = j
+			if heavy == -1 || h.size[j] > h.size[heavy] {
+				heavy = j
+			}
+		}
+		for c := nodes[i].child; c != nil; c = c.sibling {
+			if j := h.index[c]; j != heavy {
+				stack = append(stack, j)
+			}
+		}
+		if heavy != -1 {
+			h.head[heavy] = h.head[i]
+			stack = append(stack, heavy)
+		}
+	}
+	h.values = segtree.New(data, combine, identity)
+	return h
+}
+
+func (h *HeavyLight[T, V]) lookup(n *Node[T]) int {
+	i, ok := h.index[n]
+	if !ok {
+		panic("lcrs: node not in decomposition")
+	}
+	return i
+}
+
+// Get returns the value of node *n*
+func (h *HeavyLight[T, V]) Get(n *Node[T]) V {
+	return h.values.Get(h.pos[h.lookup(n)])
+}
+
+// Update changes the value of node *n* to *value*, in O(log n)
+func (h *HeavyLight[T, V]) Update(n *Node[T], value V) {
+	h.values.Set(h.pos[h.lookup(n)], value)
+}
+
+// PathQuery returns the values of the nodes on the path between *u* and *v*,
+// including both, combined, in O(log² n)
+func (h *HeavyLight[T, V]) PathQuery(u, v *Node[T]) V {
+	i, j := h.lookup(u), h.lookup(v)
+	result := h.identity
+	// Climb from whichever node's chain starts deeper until both are on the
+	// same chain, which contains their lowest common ancestor
+	for h.head[i] != h.head[j] {
+		if h.depth[h.head[i]] < h.depth[h.head[j]] {
+			i, j = j, i
+		}
+		top := h.head[i]
+		result = h.combine(result, h.values.Query(h.pos[top], h.pos[i]+1))
+		i = h.parent[top]
+	}
+	if h.depth[i] > h.depth[j] {
+		i, j = j, i
+	}
+	return h.combine(result, h.values.Query(h.pos[i], h.pos[j]+1))
+}
+
+// SubtreeQuery returns the values of the nodes in the subtree rooted at *n*
+// combined, in O(log n)
+func (h *HeavyLight[T, V]) SubtreeQuery(n *Node[T]) V {
+	i := h.lookup(n)
+	return h.values.Query(h.pos[i], h.pos[i]+h.size[i])
+}
@@ -0,0 +1,99 @@
+package lcrs
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func add(a, b int) int { return a + b }
+
+// randomTree builds a tree of *n* nodes numbered 0..n-1, each attached below
+// a random earlier node, and returns the nodes
+func randomTree(r *rand.Rand, n int) []*Node[int] {
+	nodes := []*Node[int]{New(0)}
+	for i := 1; i != n; i++ {
+		nodes = append(nodes, nodes[r.Intn(i)].AddChild(i))
+	}
+	return nodes
+}
+
+// pathSum adds the values on the path between *u* and *v* by walking up from
+// the deeper node
+func pathSum(u, v *Node[int], values []int) int {
+	sum := 0
+	du, dv := u.Depth(), v.Depth()
+	for ; du > dv; du-- {
+		sum += values[u.Value]
+		u = u.parent
+	}
+	for ; dv > du; dv-- {
+		sum += values[v.Value]
+		v = v.parent
+	}
+	for u != v {
+		sum += values[u.Value] + values[v.Value]
+		u, v = u.parent, v.parent
+	}
+	return sum + values[u.Value]
+}
+
+func TestHeavyLight(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, n := range []int{1, 2, 10, 200} {
+		nodes := randomTree(r, n)
+		values := make([]int, n)
+		for i := range values {
+			values[i] = i
+		}
+		h := NewHeavyLight(nodes[0], func(v int) int { return v }, add, 0)
+		for step := 0; step != 200; step++ {
+			if r.Intn(3) == 0 {
+				i := r.Intn(n)
+				values[i] = r.Intn(100)
+				h.Update(nodes[i], values[i])
+			}
+			u, v := nodes[r.Intn(n)], nodes[r.Intn(n)]
+			if sum := h.PathQuery(u, v); sum != pathSum(u, v, values) {
+				t.Fatal(n, u.Value, v.Value, sum, pathSum(u, v, values))
+			}
+			sum := 0
+			u.WalkDepthFirst(func(m *Node[int]) bool {
+				sum += values[m.Value]
+				return true
+			})
+			if s := h.SubtreeQuery(u); s != sum || h.Get(u) != values[u.Value] {
+				t.Fatal(n, u.Value, s, sum)
+			}
+		}
+	}
+}
+
+func TestHeavyLightChains(t *testing.T) {
+	// A long chain is a single heavy path
+	root := New(0)
+	n := root
+	for i := 1; i != 100000; i++ {
+		n = n.AddChild(i)
+	}
+	h := NewHeavyLight(root, func(v int) int { return 1 }, add, 0)
+	if length := h.PathQuery(n, root); length != 100000 {
+		t.Error(length)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("no panic for a foreign node")
+		}
+	}()
+	h.Get(New(0))
+}
+
+func BenchmarkHeavyLight(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	nodes := randomTree(r, 1<<16)
+	h := NewHeavyLight(nodes[0], func(v int) int { return v }, add, 0)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		h.Update(nodes[r.Intn(len(nodes))], i)
+		h.PathQuery(nodes[r.Intn(len(nodes))], nodes[r.Intn(len(nodes))])
+	}
+}
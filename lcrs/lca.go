@@ -0,0 +1,129 @@
+// Lowest common ancestors
+//
+// The lowest common ancestor of two nodes is the deepest node with both in its
+// subtree. An Euler tour of the tree writes down each node when the walk
+// enters it and again each time it returns from one of its children, and
+// between the first visits of two nodes the tour passes through their lowest
+// common ancestor, but never above it:
+//
+//	     a
+//	   / | \          tour:  a b e b f b a c a d g d a
+//	  b  c  d         depth: 0 1 2 1 2 1 0 1 0 1 2 1 0
+//	 / \     \
+//	e   f     g       LCA(f, c): shallowest of  f b a c  is a
+//
+// The tour has 2n-1 entries, so a SparseTable (see package rmq) over it finds
+// the shallowest entry in any range in O(1), after O(n log n) preparation.
+// This is the same reduction that rmq.RMQ makes in the other direction.
+
+package lcrs
+
+import "github.com/njwilson23/datastructures/rmq"
+
+// LCA answers lowest common ancestor queries on a tree as it was when the
+// LCA was created, and must be rebuilt if nodes are added, removed or moved
+type LCA[T comparable] struct {
+	index map[*Node[T]]int // node -> index in nodes
+	nodes []*Node[T]
+	depth []int // by index
+	first []int // first position of each node on the tour
+	tour  []int // the index of the node at each position on the tour
+	table *rmq.SparseTable[int]
+}
+
+// NewLCA prepares the subtree rooted at *root* for queries in O(n log n)
+func NewLCA[T comparable](root *Node[T]) *LCA[T] {
+	l := &LCA[T]{index: make(map[*Node[T]]int)}
+	// Each frame is a node on the path from the root and its next child to
+	// enter, so the walk needs no recursion
+	type frame struct {
+		node  int
+		child *Node[T]
+	}
+	var stack []frame
+	enter := func(n *Node[T], depth int) {
+		i := len(l.nodes)
+		l.index[n] = i
+		l.nodes = append(l.nodes, n)
+		l.depth = append(l.depth, depth)
+		l.first = append(l.first, len(l.tour))
+		l.tour = append(l.tour, i)
+		stack = append(stack, frame{i, n.child})
+	}
+	enter(root, 0)
+	for len(stack) != 0 {
+		f := &stack[len(stack)-1]
+		if c := f.child; c != nil {
+			f.child = c.sibling
+			enter(c, l.depth[f.node]+1)
+			continue
+		}
+		stack = stack[:len(stack)-1]
+		if len(stack) != 0 {
+			l.tour = append(l.tour, stack[len(stack)-1].node)
+		}
+	}
+
+	// The table holds positions on the tour, and picks the shallowest
+	positions := make([]int, len(l.tour))
+	for i := range positions {
+		positions[i] = i
+	}
+	l.table = rmq.NewSparseTable(positions, func(a, b int) int {
+		if l.depth[l.tour[b]] < l.depth[l.tour[a]] {
+			return b
+		}
+		return a
+	})
+	return l
+}
+
+func (l *LCA[T]) lookup(n *Node[T]) int {
+	i, ok := l.index[n]
+	if !ok {
+		panic("lcrs: node not in LCA tree")
+	}
+	return i
+}
+
+// query returns the index of the lowest common ancestor of the nodes with
+// indices *i* and *j*
+func (l *LCA[T]) query(i, j int) int {
+	a, b := l.first[i], l.first[j]
+	if a > b {
+		a, b = b, a
+	}
+	return l.tour[l.table.Query(a, b+1)]
+}
+
+// Query returns the lowest common ancestor of *u* and *v*, in O(1)
+func (l *LCA[T]) Query(u, v *Node[T]) *Node[T] {
+	return l.nodes[l.query(l.lookup(u), l.lookup(v))]
+}
+
+// Distance returns the number of edges on the path between *u* and *v*, in
+// O(1)
+func (l *LCA[T]) Distance(u, v *Node[T]) int {
+	i, j := l.lookup(u), l.lookup(v)
+	return l.depth[i] + l.depth[j] - 2*l.depth[l.query(i, j)]
+}
+
+// QueryAll returns the lowest common ancestor of each pair of nodes in
+// *pairs*, in order
+func (l *LCA[T]) QueryAll(pairs [][2]*Node[T]) []*Node[T] {
+	ancestors := make([]*Node[T], len(pairs))
+	for k, p := range pairs {
+		ancestors[k] = l.Query(p[0], p[1])
+	}
+	return ancestors
+}
+
+// DistanceAll returns the distance between each pair of nodes in *pairs*, in
+// order
+func (l *LCA[T]) DistanceAll(pairs [][2]*Node[T]) []int {
+	distances := make([]int, len(pairs))
+	for k, p := range pairs {
+		distances[k] = l.Distance(p[0], p[1])
+	}
+	return distances
+}
@@ -0,0 +1,94 @@
+package lcrs
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// naiveLCA finds the lowest common ancestor by climbing from the deeper node
+func naiveLCA[T comparable](u, v *Node[T]) *Node[T] {
+	du, dv := u.Depth(), v.Depth()
+	for ; du > dv; du-- {
+		u = u.parent
+	}
+	for ; dv > du; dv-- {
+		v = v.parent
+	}
+	for u != v {
+		u, v = u.parent, v.parent
+	}
+	return u
+}
+
+func TestLCAExample(t *testing.T) {
+	a := example()
+	l := NewLCA(a)
+	f, c, g := a.Find("b", "f"), a.Find("c"), a.Find("d", "g")
+	e := a.Find("b", "e")
+	for _, q := range []struct {
+		u, v     *Node[string]
+		lca      string
+		distance int
+	}{
+		{f, c, "a", 3}, {e, f, "b", 2}, {g, g, "g", 0}, {g, a, "a", 2}, {e, g, "a", 4},
+	} {
+		if n := l.Query(q.u, q.v); n.Value != q.lca {
+			t.Error(q.u.Value, q.v.Value, n.Value)
+		}
+		if d := l.Distance(q.v, q.u); d != q.distance {
+			t.Error(q.u.Value, q.v.Value, d)
+		}
+	}
+	if all := l.QueryAll([][2]*Node[string]{{e, f}, {f, c}}); len(all) != 2 || all[0].Value != "b" || all[1].Value != "a" {
+		t.Error(all)
+	}
+	if all := l.DistanceAll([][2]*Node[string]{{e, f}, {f, c}}); len(all) != 2 || all[0] != 2 || all[1] != 3 {
+		t.Error(all)
+	}
+}
+
+func TestLCARandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, n := range []int{1, 2, 50, 500} {
+		nodes := randomTree(r, n)
+		l := NewLCA(nodes[0])
+		for k := 0; k != 500; k++ {
+			u, v := nodes[r.Intn(n)], nodes[r.Intn(n)]
+			if got, expected := l.Query(u, v), naiveLCA(u, v); got != expected {
+				t.Fatal(n, u.Value, v.Value, got.Value, expected.Value)
+			}
+		}
+	}
+}
+
+func TestLCADeep(t *testing.T) {
+	root := New(0)
+	n := root
+	for i := 1; i != 100000; i++ {
+		n = n.AddChild(i)
+	}
+	l := NewLCA(root)
+	if a := l.Query(n, n.parent.parent); a != n.parent.parent || l.Distance(n, root) != 99999 {
+		t.Error(a.Value)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("no panic for a foreign node")
+		}
+	}()
+	l.Query(n, New(0))
+}
+
+func BenchmarkLCA(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	nodes := randomTree(r, 1<<16)
+	l := NewLCA(nodes[0])
+	pairs := make([][2]*Node[int], 1024)
+	for i := range pairs {
+		pairs[i] = [2]*Node[int]{nodes[r.Intn(len(nodes))], nodes[r.Intn(len(nodes))]}
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		l.QueryAll(pairs)
+	}
+}
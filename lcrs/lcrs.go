@@ -0,0 +1,342 @@
+/*
+ * Package lcrs implements a general tree, in which a node may have any number
+ * of children, stored in left-child right-sibling form.
+ *
+ * Hierarchies such as file systems, documents or organization charts have no
+ * fixed number of children per node. Giving each node a slice of children
+ * costs a separate allocation per node with children, and slices that grow
+ * by copying. In left-child right-sibling form, every node instead has
+ * exactly two links, to its first child and to its next sibling, so the
+ * children of a node form a linked list, and the general tree is stored as a
+ * binary tree:
+ *
+ *         a                   a
+ *       / | \                /
+ *      b  c  d      ->      b -> c -> d
+ *     / \                  /
+ *    e   f                e -> f
+ *
+ * Each node also links to its parent and its last child, so that a child can
+ * be appended and a node's path from the root found without searching.
+ * Finding the n-th child, or removing a child, walks the list of siblings, in
+ * O(number of children).
+ *
+ * The walks use an explicit stack or queue rather than recursion, so they
+ * work on trees of any depth, such as long chains.
+ */
+
+package lcrs
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/njwilson23/datastructures/queue"
+)
+
+var ErrCorrupt = errors.New("corrupt tree encoding")
+
+// Node is a node in a general tree, holding a value of type T
+type Node[T comparable] struct {
+	Value   T
+	parent  *Node[T]
+	child   *Node[T] // first child
+	last    *Node[T] // last child
+	sibling *Node[T] // next sibling
+}
+
+// New creates a tree with a single node holding *value*
+func New[T comparable](value T) *Node[T] {
+	return &Node[T]{Value: value}
+}
+
+// Parent returns the parent of the node, or nil if it is a root
+func (n *Node[T]) Parent() *Node[T] {
+	return n.parent
+}
+
+// FirstChild returns the first child of the node, or nil if it is a leaf
+func (n *Node[T]) FirstChild() *Node[T] {
+	return n.child
+}
+
+// NextSibling returns the next child of the node's parent, or nil if the
+// node is the last
+func (n *Node[T]) NextSibling() *Node[T] {
+	return n.sibling
+}
+
+// Children returns the children of the node, in order
+func (n *Node[T]) Children() []*Node[T] {
+	var children []*Node[T]
+	for c := n.child; c != nil; c = c.sibling {
+		children = append(children, c)
+	}
+	return children
+}
+
+// AddChild appends a new child holding *value* to the node's children, and
+// returns it
+func (n *Node[T]) AddChild(value T) *Node[T] {
+	c := &Node[T]{Value: value, parent: n}
+	if n.last == nil {
+		n.child = c
+	} else {
+		n.last.sibling = c
+	}
+	n.last = c
+	return c
+}
+
+// Remove detaches the node, with its descendants, from its parent, making it
+// the root of a separate tree
+func (n *Node[T]) Remove() {
+	p := n.parent
+	if p == nil {
+		return
+	}
+	var prev *Node[T]
+	for c := p.child; c != n; c = c.sibling {
+		prev = c
+	}
+	if prev == nil {
+		p.child = n.sibling
+	} else {
+		prev.sibling = n.sibling
+	}
+	if p.last == n {
+		p.last = prev
+	}
+	n.parent, n.sibling = nil, nil
+}
+
+// Depth returns the number of edges between the node and the root
+func (n *Node[T]) Depth() int {
+	d := 0
+	for p := n.parent; p != nil; p = p.parent {
+		d++
+	}
+	return d
+}
+
+// Root returns the root of the tree containing the node
+func (n *Node[T]) Root() *Node[T] {
+	for n.parent != nil {
+		n = n.parent
+	}
+	return n
+}
+
+// Path returns the values of the nodes from the root down to the node
+func (n *Node[T]) Path() []T {
+	path := make([]T, n.Depth()+1)
+	for i := len(path) - 1; n != nil; i, n = i-1, n.parent {
+		path[i] = n.Value
+	}
+	return path
+}
+
+// Find follows *path* down from the node, at each step to the first child
+// holding the next value, and returns the node reached, or nil if there is
+// no such child. An empty path returns the node itself.
+func (n *Node[T]) Find(path ...T) *Node[T] {
+	for _, value := range path {
+		c := n.child
+		for c != nil && c.Value != value {
+			c = c.sibling
+		}
+		if c == nil {
+			return nil
+		}
+		n = c
+	}
+	return n
+}
+
+// FindByPath follows a slash-separated *path*, such as "a/b/c", down from the
+// node, at each step to the first child whose *name* is the next element, and
+// returns the node reached, or nil. Empty elements are skipped, so leading and
+// doubled slashes are allowed. If *name* is nil, values are named with
+// fmt.Sprint.
+func (n *Node[T]) FindByPath(path string, name func(T) string) *Node[T] {
+	if name == nil {
+		name = sprint[T]
+	}
+	for _, element := range strings.Split(path, "/") {
+		if element == "" {
+			continue
+		}
+		c := n.child
+		for c != nil && name(c.Value) != element {
+			c = c.sibling
+		}
+		if c == nil {
+			return nil
+		}
+		n = c
+	}
+	return n
+}
+
+func sprint[T any](value T) string {
+	return fmt.Sprint(value)
+}
+
+// MoveTo detaches the node, with its descendants, and appends it to the
+// children of *parent*. It panics if *parent* is in the node's subtree,
+// which would make a cycle.
+func (n *Node[T]) MoveTo(parent *Node[T]) {
+	for p := parent; p != nil; p = p.parent {
+		if p == n {
+			panic("lcrs: cannot move a node below itself")
+		}
+	}
+	n.Remove()
+	n.parent = parent
+	if parent.last == nil {
+		parent.child = n
+	} else {
+		parent.last.sibling = n
+	}
+	parent.last = n
+}
+
+// Copy returns a copy of the subtree rooted at the node, as a separate tree.
+// Values are copied by assignment.
+func (n *Node[T]) Copy() *Node[T] {
+	root := New(n.Value)
+	// Each entry is an original node whose children have not been copied yet,
+	// and its copy
+	type pair struct{ original, copy *Node[T] }
+	stack := []pair{{n, root}}
+	for len(stack) != 0 {
+		p := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for c := p.original.child; c != nil; c = c.sibling {
+			stack = append(stack, pair{c, p.copy.AddChild(c.Value)})
+		}
+	}
+	return root
+}
+
+// Len returns the number of nodes in the subtree rooted at the node
+func (n *Node[T]) Len() int {
+	count := 0
+	n.WalkDepthFirst(func(*Node[T]) bool {
+		count++
+		return true
+	})
+	return count
+}
+
+// WalkDepthFirst calls *f* with each node of the subtree rooted at the node,
+// in preorder (each node before its children, and children in order), until
+// *f* returns false
+func (n *Node[T]) WalkDepthFirst(f func(*Node[T]) bool) {
+	stack := []*Node[T]{n}
+	for len(stack) != 0 {
+		m := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if !f(m) {
+			return
+		}
+		// Only the first child is pushed below the sibling of m, so each
+		// sibling list is followed one node at a time
+		if m != n && m.sibling != nil {
+			stack = append(stack, m.sibling)
+		}
+		if m.child != nil {
+			stack = append(stack, m.child)
+		}
+	}
+}
+
+// WalkBreadthFirst calls *f* with each node of the subtree rooted at the
+// node, level by level, until *f* returns false
+func (n *Node[T]) WalkBreadthFirst(f func(*Node[T]) bool) {
+	var q queue.Ring[*Node[T]]
+	q.Push(n)
+	for m, err := q.Pop(); err == nil; m, err = q.Pop() {
+		if !f(m) {
+			return
+		}
+		for c := m.child; c != nil; c = c.sibling {
+			q.Push(c)
+		}
+	}
+}
+
+// Marshal encodes the subtree rooted at the node, using *encode* to encode
+// each value. Nodes are written in preorder, as
+//
+//	children uvarint
+//	length   uvarint
+//	value    [length]byte
+func (n *Node[T]) Marshal(encode func(T) ([]byte, error)) ([]byte, error) {
+	var data []byte
+	var err error
+	n.WalkDepthFirst(func(m *Node[T]) bool {
+		children := 0
+		for c := m.child; c != nil; c = c.sibling {
+			children++
+		}
+		var value []byte
+		if value, err = encode(m.Value); err != nil {
+			return false
+		}
+		data = binary.AppendUvarint(data, uint64(children))
+		data = binary.AppendUvarint(data, uint64(len(value)))
+		data = append(data, value...)
+		return true
+	})
+	return data, err
+}
+
+// Unmarshal decodes a tree encoded by Marshal, using *decode* to decode each
+// value, and returns its root
+func Unmarshal[T comparable](data []byte, decode func([]byte) (T, error)) (*Node[T], error) {
+	// Each entry is a node still expecting children, and how many
+	type pending struct {
+		node      *Node[T]
+		remaining uint64
+	}
+	var stack []pending
+	var root *Node[T]
+	for root == nil || len(stack) != 0 {
+		children, n := binary.Uvarint(data)
+		if n <= 0 {
+			return nil, ErrCorrupt
+		}
+		data = data[n:]
+		length, n := binary.Uvarint(data)
+		if n <= 0 || length > uint64(len(data)-n) {
+			return nil, ErrCorrupt
+		}
+		value, err := decode(data[n : n+int(length)])
+		if err != nil {
+			return nil, err
+		}
+		data = data[n+int(length):]
+
+		var node *Node[T]
+		if root == nil {
+			root = New(value)
+			node = root
+		} else {
+			top := &stack[len(stack)-1]
+			node = top.node.AddChild(value)
+			if top.remaining--; top.remaining == 0 {
+				stack = stack[:len(stack)-1]
+			}
+		}
+		if children != 0 {
+			stack = append(stack, pending{node, children})
+		}
+	}
+	if len(data) != 0 {
+		return nil, ErrCorrupt
+	}
+	return root, nil
+}
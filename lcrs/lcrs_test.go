@@ -0,0 +1,185 @@
+package lcrs
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// example builds the tree
+//
+//	     a
+//	   / | \
+//	  b  c  d
+//	 / \     \
+//	e   f     g
+func example() *Node[string] {
+	a := New("a")
+	b := a.AddChild("b")
+	a.AddChild("c")
+	d := a.AddChild("d")
+	b.AddChild("e")
+	b.AddChild("f")
+	d.AddChild("g")
+	return a
+}
+
+func values(walk func(func(*Node[string]) bool)) string {
+	var s []string
+	walk(func(n *Node[string]) bool {
+		s = append(s, n.Value)
+		return true
+	})
+	return strings.Join(s, "")
+}
+
+func TestWalks(t *testing.T) {
+	a := example()
+	if s := values(a.WalkDepthFirst); s != "abefcdg" {
+		t.Error(s)
+	}
+	if s := values(a.WalkBreadthFirst); s != "abcdefg" {
+		t.Error(s)
+	}
+	// A walk of a subtree does not leave it
+	if s := values(a.Find("b").WalkDepthFirst); s != "bef" {
+		t.Error(s)
+	}
+	if a.Len() != 7 {
+		t.Error(a.Len())
+	}
+}
+
+func TestPaths(t *testing.T) {
+	a := example()
+	g := a.Find("d", "g")
+	if g == nil || g.Value != "g" || g.Depth() != 2 || g.Root() != a {
+		t.Fatal(g)
+	}
+	if path := g.Path(); fmt.Sprint(path) != "[a d g]" {
+		t.Error(path)
+	}
+	if a.Find("b", "g") != nil || a.Find() != a {
+		t.Error("find")
+	}
+}
+
+func TestFindByPath(t *testing.T) {
+	a := example()
+	for path, expected := range map[string]string{"b/f": "f", "/d//g": "g", "": "a", "c/x": ""} {
+		n := a.FindByPath(path, nil)
+		if (n == nil) != (expected == "") || (n != nil && n.Value != expected) {
+			t.Error(path, n)
+		}
+	}
+	numbers := New(1)
+	numbers.AddChild(2).AddChild(3)
+	if n := numbers.FindByPath("2/3", nil); n == nil || n.Value != 3 {
+		t.Error(n)
+	}
+}
+
+func TestMoveCopy(t *testing.T) {
+	a := example()
+	copied := a.Copy()
+	a.Find("b").MoveTo(a.Find("d", "g"))
+	if s := values(a.WalkDepthFirst); s != "acdgbef" || a.Find("d", "g", "b", "f").Depth() != 4 {
+		t.Error(s)
+	}
+	// The copy is unaffected
+	if s := values(copied.WalkDepthFirst); s != "abefcdg" || copied.Find("b").Parent() != copied {
+		t.Error(s)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("moved a node below itself")
+		}
+	}()
+	a.Find("d").MoveTo(a.Find("d", "g", "b"))
+}
+
+func TestRemove(t *testing.T) {
+	a := example()
+	for _, c := range []struct{ remove, expected string }{
+		{"c", "abefdg"}, {"d", "abef"}, {"b", "a"},
+	} {
+		n := a.Find(c.remove)
+		n.Remove()
+		if s := values(a.WalkDepthFirst); s != c.expected {
+			t.Error(c.remove, s)
+		}
+		if n.Parent() != nil || n.NextSibling() != nil {
+			t.Error("removed node still linked")
+		}
+	}
+	// Removing the last child leaves appending consistent
+	a.AddChild("x")
+	a.AddChild("y")
+	a.Find("y").Remove()
+	a.AddChild("z")
+	if s := values(a.WalkDepthFirst); s != "axz" || len(a.Children()) != 2 {
+		t.Error(s)
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	encode := func(s string) ([]byte, error) { return []byte(s), nil }
+	decode := func(b []byte) (string, error) { return string(b), nil }
+	data, err := example().Marshal(encode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree, err := Unmarshal(data, decode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := values(tree.WalkDepthFirst); s != "abefcdg" || tree.Find("b", "f").Depth() != 2 {
+		t.Error(s)
+	}
+	for i := 0; i < len(data); i++ {
+		if _, err := Unmarshal(data[:i], decode); err != ErrCorrupt {
+			t.Error("truncated at", i, err)
+		}
+	}
+	failure := errors.New("failure")
+	if _, err := example().Marshal(func(string) ([]byte, error) { return nil, failure }); err != failure {
+		t.Error(err)
+	}
+}
+
+func TestDeepTree(t *testing.T) {
+	root := New(0)
+	n := root
+	for i := 1; i != 100000; i++ {
+		n = n.AddChild(i)
+	}
+	if root.Len() != 100000 || n.Depth() != 99999 {
+		t.Error(root.Len(), n.Depth())
+	}
+}
+
+func BenchmarkWalk(b *testing.B) {
+	// A tree with fanout 10 and 111111 nodes
+	root := New(0)
+	level := []*Node[int]{root}
+	for depth := 0; depth != 5; depth++ {
+		var next []*Node[int]
+		for _, n := range level {
+			for i := 0; i != 10; i++ {
+				next = append(next, n.AddChild(i))
+			}
+		}
+		level = next
+	}
+	b.Run("DepthFirst", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			root.WalkDepthFirst(func(*Node[int]) bool { return true })
+		}
+	})
+	b.Run("BreadthFirst", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			root.WalkBreadthFirst(func(*Node[int]) bool { return true })
+		}
+	})
+}
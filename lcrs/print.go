@@ -0,0 +1,77 @@
+// Printing trees
+//
+// Format draws a tree with Unicode box-drawing characters, as the tree(1)
+// command does for directories:
+//
+//	a
+//	├── b
+//	│   ├── e
+//	│   └── f
+//	├── c
+//	└── d
+//	    └── g
+//
+// Each line is the node's name after a prefix inherited from its ancestors:
+// "│   " below an ancestor with later siblings, whose vertical line continues
+// past the node, and blank below one that was the last child. FormatIndented
+// writes the plain indented outline instead, for output that must be ASCII.
+
+package lcrs
+
+import "strings"
+
+// Format returns a drawing of the subtree rooted at the node, one node per
+// line, naming values with *name*, or fmt.Sprint if it is nil
+func (n *Node[T]) Format(name func(T) string) string {
+	if name == nil {
+		name = sprint[T]
+	}
+	var b strings.Builder
+	b.WriteString(name(n.Value))
+	b.WriteByte('\n')
+	// Each entry is a node to draw and the prefix of its line
+	type line struct {
+		node   *Node[T]
+		prefix string
+	}
+	var stack []line
+	push := func(parent *Node[T], prefix string) {
+		// Children are pushed in reverse, so that the first is drawn first
+		children := parent.Children()
+		for i := len(children) - 1; i >= 0; i-- {
+			stack = append(stack, line{children[i], prefix})
+		}
+	}
+	push(n, "")
+	for len(stack) != 0 {
+		l := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		branch, below := "├── ", "│   "
+		if l.node.sibling == nil {
+			branch, below = "└── ", "    "
+		}
+		b.WriteString(l.prefix)
+		b.WriteString(branch)
+		b.WriteString(name(l.node.Value))
+		b.WriteByte('\n')
+		push(l.node, l.prefix+below)
+	}
+	return b.String()
+}
+
+// FormatIndented returns an outline of the subtree rooted at the node, one
+// node per line, each indented by *indent* once per level below the node
+func (n *Node[T]) FormatIndented(name func(T) string, indent string) string {
+	if name == nil {
+		name = sprint[T]
+	}
+	var b strings.Builder
+	depth := n.Depth()
+	n.WalkDepthFirst(func(m *Node[T]) bool {
+		b.WriteString(strings.Repeat(indent, m.Depth()-depth))
+		b.WriteString(name(m.Value))
+		b.WriteByte('\n')
+		return true
+	})
+	return b.String()
+}
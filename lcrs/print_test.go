@@ -0,0 +1,40 @@
+package lcrs
+
+import "testing"
+
+func TestFormat(t *testing.T) {
+	expected := `a
+├── b
+│   ├── e
+│   └── f
+├── c
+└── d
+    └── g
+`
+	if s := example().Format(nil); s != expected {
+		t.Errorf("\n%s", s)
+	}
+	expected = `b
+  e
+  f
+`
+	if s := example().Find("b").FormatIndented(nil, "  "); s != expected {
+		t.Errorf("\n%s", s)
+	}
+	if s := New(3).Format(func(v int) string { return "three" }); s != "three\n" {
+		t.Error(s)
+	}
+}
+
+func BenchmarkFormat(b *testing.B) {
+	root := New(0)
+	for i := 0; i != 100; i++ {
+		c := root.AddChild(i)
+		for j := 0; j != 10; j++ {
+			c.AddChild(j)
+		}
+	}
+	for i := 0; i < b.N; i++ {
+		root.Format(nil)
+	}
+}
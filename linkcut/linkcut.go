@@ -0,0 +1,305 @@
+/*
+ * Package linkcut implements link-cut trees (Sleator and Tarjan, 1983), which
+ * maintain a forest under adding and removing edges, and answer connectivity
+ * and path queries, all in O(log n) amortized time.
+ *
+ * Union-find (see package unionfind) can merge trees but never split them.
+ * A link-cut tree can do both, which suits problems where the forest changes
+ * in both directions: network flow algorithms that repeatedly augment and
+ * cut paths, or maintaining a minimum spanning tree as edges come and go.
+ *
+ * Each tree of the forest is divided into "preferred paths", chains of nodes
+ * from ancestor to descendant, and each path is stored as a splay tree keyed
+ * by depth: an in-order walk of the splay tree visits the path from top to
+ * bottom. The splay tree of a path has a "path-parent" link, from its root
+ * to the node above the path's top in the real tree, so a splay root's
+ * parent pointer does double duty: a node is the root of its splay tree if
+ * it is neither child of its parent.
+ *
+ *    real tree         preferred paths      splay trees
+ *
+ *        a              a                      b
+ *       / \             |                     / \
+ *      b   c            b      c             a   d    c ..> a
+ *      |                |                              (path-parent)
+ *      d                d
+ *
+ * The central operation, access(v), makes the path from v's root to v
+ * preferred, by splaying v and each path-parent above it in turn and
+ * splicing their splay trees together. Afterward, v is the root of a splay
+ * tree holding exactly the path from the root of its tree to v, so an
+ * aggregate over that path, such as its sum, is the aggregate stored at v.
+ * Splaying makes a sequence of accesses cost O(log n) each, amortized.
+ *
+ * Queries between any two nodes need one of them to be the root of its tree.
+ * Rerooting at u ("evert") accesses u, whose splay tree is then the path
+ * from the old root to u, and reverses that path, which turns the tree
+ * upside down along it. Reversal is recorded as a flag on the splay tree's
+ * root and pushed down lazily, so it is O(1).
+ *
+ *   - Link(u, v) reroots at u, and makes v the parent of u
+ *   - Cut(u, v) reroots at u, accesses v, and detaches u if it is the only
+ *     node above v on the path
+ *   - the path from u to v is the splay tree of v after rerooting at u and
+ *     accessing v
+ *
+ * Nodes are identified by the integers 0..n-1 and stored in a slice, so the
+ * forest makes no allocations after it is created.
+ */
+
+package linkcut
+
+import "math"
+
+// node is a forest node. Node indices are shifted by one, so that 0 is a nil
+// node, whose sum is 0 and whose max is below every value.
+type node struct {
+	child    [2]int // left (shallower) and right (deeper)
+	parent   int    // splay tree parent, or path-parent at a splay root
+	reversed bool   // the children of every node below need swapping
+	value    int
+	sum, max int // over the splay subtree
+	size     int // number of nodes in the splay subtree
+}
+
+// Forest is a forest of trees over the nodes 0..n-1
+type Forest struct {
+	nodes []node
+	stack []int // reused by splay
+}
+
+// New creates a Forest of *n* nodes, each alone in its tree, with value 0
+func New(n int) *Forest {
+	f := &Forest{nodes: make([]node, n+1)}
+	f.nodes[0].max = math.MinInt
+	for i := 1; i <= n; i++ {
+		f.nodes[i].size = 1
+	}
+	return f
+}
+
+// Len returns the number of nodes
+func (f *Forest) Len() int {
+	return len(f.nodes) - 1
+}
+
+// isRoot returns true if *x* is the root of its splay tree
+func (f *Forest) isRoot(x int) bool {
+	p := f.nodes[x].parent
+	return p == 0 || (f.nodes[p].child[0] != x && f.nodes[p].child[1] != x)
+}
+
+// push applies a pending reversal of *x*'s subtree to its children
+func (f *Forest) push(x int) {
+	n := &f.nodes[x]
+	if !n.reversed {
+		return
+	}
+	n.child[0], n.child[1] = n.child[1], n.child[0]
+	for _, c := range n.child {
+		if c != 0 {
+			f.nodes[c].reversed = !f.nodes[c].reversed
+		}
+	}
+	n.reversed = false
+}
+
+// pull recomputes the aggregates of *x* from its children
+func (f *Forest) pull(x int) {
+	n := &f.nodes[x]
+	l, r := &f.nodes[n.child[0]], &f.nodes[n.child[1]]
+	n.sum = l.sum + n.value + r.sum
+	n.size = l.size + 1 + r.size
+	n.max = n.value
+	if l.max > n.max {
+		n.max = l.max
+	}
+	if r.max > n.max {
+		n.max = r.max
+	}
+}
+
+// rotate moves *x* above its parent in their splay tree
+func (f *Forest) rotate(x int) {
+	y := f.nodes[x].parent
+	z := f.nodes[y].parent
+	dir := 0
+	if f.nodes[y].child[1] == x {
+		dir = 1
+	}
+	if !f.isRoot(y) {
+		if f.nodes[z].child[0] == y {
+			f.nodes[z].child[0] = x
+		} else {
+			f.nodes[z].child[1] = x
+		}
+	}
+	// A path-parent link at y passes to x, which becomes the splay root
+	f.nodes[x].parent = z
+	b := f.nodes[x].child[1-dir]
+	f.nodes[y].child[dir] = b
+	if b != 0 {
+		f.nodes[b].parent = y
+	}
+	f.nodes[x].child[1-dir] = y
+	f.nodes[y].parent = x
+	f.pull(y)
+	f.pull(x)
+}
+
+// splay makes *x* the root of its splay tree
+func (f *Forest) splay(x int) {
+	// Reversals are pushed down from the splay root first, so that the
+	// children seen by the rotations are the real ones
+	path := f.stack[:0]
+	for y := x; ; y = f.nodes[y].parent {
+		path = append(path, y)
+		if f.isRoot(y) {
+			break
+		}
+	}
+	for i := len(path) - 1; i >= 0; i-- {
+		f.push(path[i])
+	}
+	f.stack = path
+	for !f.isRoot(x) {
+		y := f.nodes[x].parent
+		if !f.isRoot(y) {
+			z := f.nodes[y].parent
+			// Zig-zig rotates the parent first, zig-zag rotates x twice
+			if (f.nodes[y].child[0] == x) == (f.nodes[z].child[0] == y) {
+				f.rotate(y)
+			} else {
+				f.rotate(x)
+			}
+		}
+		f.rotate(x)
+	}
+}
+
+// access makes the path from the root of *x*'s tree to *x* preferred, with
+// *x* at the root of its splay tree and nothing deeper in it
+func (f *Forest) access(x int) {
+	last := 0
+	for y := x; y != 0; y = f.nodes[y].parent {
+		f.splay(y)
+		f.nodes[y].child[1] = last
+		f.pull(y)
+		last = y
+	}
+	f.splay(x)
+}
+
+// evert makes *x* the root of its tree
+func (f *Forest) evert(x int) {
+	f.access(x)
+	f.nodes[x].reversed = !f.nodes[x].reversed
+}
+
+// findRoot returns the root of *x*'s tree
+func (f *Forest) findRoot(x int) int {
+	f.access(x)
+	for {
+		f.push(x)
+		if f.nodes[x].child[0] == 0 {
+			break
+		}
+		x = f.nodes[x].child[0]
+	}
+	// Splaying the root keeps repeated queries cheap
+	f.splay(x)
+	return x
+}
+
+// Value returns the value of node *v*
+func (f *Forest) Value(v int) int {
+	return f.nodes[v+1].value
+}
+
+// SetValue sets the value of node *v*
+func (f *Forest) SetValue(v, value int) {
+	x := v + 1
+	f.access(x)
+	f.nodes[x].value = value
+	f.pull(x)
+}
+
+// Connected returns true if *u* and *v* are in the same tree
+func (f *Forest) Connected(u, v int) bool {
+	return u == v || f.findRoot(u+1) == f.findRoot(v+1)
+}
+
+// Link adds an edge between *u* and *v*, and returns false if they are
+// already in the same tree, where the edge would make a cycle
+func (f *Forest) Link(u, v int) bool {
+	x, y := u+1, v+1
+	f.evert(x)
+	if f.findRoot(y) == x {
+		return false
+	}
+	f.nodes[x].parent = y
+	return true
+}
+
+// Cut removes the edge between *u* and *v*, and returns false if there is
+// none
+func (f *Forest) Cut(u, v int) bool {
+	x, y := u+1, v+1
+	if x == y {
+		return false
+	}
+	f.evert(x)
+	f.access(y)
+	// The path from x to y is y's splay tree, and they are adjacent if x is
+	// the only node on it before y
+	f.push(x)
+	if f.nodes[y].child[0] != x || f.nodes[x].child[1] != 0 {
+		return false
+	}
+	f.nodes[y].child[0] = 0
+	f.nodes[x].parent = 0
+	f.pull(y)
+	return true
+}
+
+// path makes the path from *u* to *v* the splay tree of *v*, and returns
+// false if they are in different trees
+func (f *Forest) path(u, v int) (int, bool) {
+	x, y := u+1, v+1
+	if !f.Connected(u, v) {
+		return 0, false
+	}
+	f.evert(x)
+	f.access(y)
+	return y, true
+}
+
+// PathSum returns the sum of the values on the path from *u* to *v*, or false
+// if they are in different trees
+func (f *Forest) PathSum(u, v int) (int, bool) {
+	y, ok := f.path(u, v)
+	if !ok {
+		return 0, false
+	}
+	return f.nodes[y].sum, true
+}
+
+// PathMax returns the largest value on the path from *u* to *v*, or false if
+// they are in different trees
+func (f *Forest) PathMax(u, v int) (int, bool) {
+	y, ok := f.path(u, v)
+	if !ok {
+		return 0, false
+	}
+	return f.nodes[y].max, true
+}
+
+// PathLen returns the number of edges on the path from *u* to *v*, or false
+// if they are in different trees
+func (f *Forest) PathLen(u, v int) (int, bool) {
+	y, ok := f.path(u, v)
+	if !ok {
+		return 0, false
+	}
+	return f.nodes[y].size - 1, true
+}
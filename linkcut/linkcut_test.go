@@ -0,0 +1,151 @@
+package linkcut
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// naive is a forest stored as adjacency sets, searched for every query
+type naive struct {
+	adj    []map[int]bool
+	values []int
+}
+
+// path returns the nodes on the path from *u* to *v*, or nil
+func (g *naive) path(u, v int) []int {
+	prev := map[int]int{u: -1}
+	queue := []int{u}
+	for len(queue) != 0 {
+		x := queue[0]
+		queue = queue[1:]
+		for y := range g.adj[x] {
+			if _, seen := prev[y]; !seen {
+				prev[y] = x
+				queue = append(queue, y)
+			}
+		}
+	}
+	if _, ok := prev[v]; !ok {
+		return nil
+	}
+	var path []int
+	for x := v; x != -1; x = prev[x] {
+		path = append(path, x)
+	}
+	return path
+}
+
+func TestRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	const n = 60
+	f := New(n)
+	g := &naive{make([]map[int]bool, n), make([]int, n)}
+	for i := range g.adj {
+		g.adj[i] = map[int]bool{}
+	}
+	var edges [][2]int
+	for step := 0; step != 5000; step++ {
+		u, v := r.Intn(n), r.Intn(n)
+		switch r.Intn(4) {
+		case 0:
+			connected := g.path(u, v) != nil
+			if f.Link(u, v) == connected {
+				t.Fatal("link", u, v, connected)
+			}
+			if !connected {
+				g.adj[u][v], g.adj[v][u] = true, true
+				edges = append(edges, [2]int{u, v})
+			}
+		case 1:
+			if len(edges) != 0 {
+				i := r.Intn(len(edges))
+				e := edges[i]
+				if r.Intn(2) == 0 {
+					e[0], e[1] = e[1], e[0]
+				}
+				if !f.Cut(e[0], e[1]) {
+					t.Fatal("cut", e)
+				}
+				delete(g.adj[e[0]], e[1])
+				delete(g.adj[e[1]], e[0])
+				edges = append(edges[:i], edges[i+1:]...)
+			}
+			if !g.adj[u][v] && f.Cut(u, v) {
+				t.Fatal("cut a missing edge", u, v)
+			}
+		case 2:
+			value := r.Intn(201) - 100
+			f.SetValue(u, value)
+			g.values[u] = value
+		default:
+			path := g.path(u, v)
+			if f.Connected(u, v) != (path != nil) {
+				t.Fatal("connected", u, v)
+			}
+			sum, ok := f.PathSum(u, v)
+			top, _ := f.PathMax(u, v)
+			length, _ := f.PathLen(u, v)
+			if ok != (path != nil) {
+				t.Fatal("path", u, v)
+			}
+			if path == nil {
+				continue
+			}
+			expectedSum, expectedMax := 0, math.MinInt
+			for _, x := range path {
+				expectedSum += g.values[x]
+				if g.values[x] > expectedMax {
+					expectedMax = g.values[x]
+				}
+			}
+			if sum != expectedSum || top != expectedMax || length != len(path)-1 {
+				t.Fatal("aggregates", u, v, sum, expectedSum, top, expectedMax, length, len(path)-1)
+			}
+		}
+	}
+	if f.Value(0) != g.values[0] || f.Len() != n {
+		t.Error(f.Value(0))
+	}
+}
+
+func TestPathGraph(t *testing.T) {
+	// A long path, which is the worst case for trees without splaying
+	const n = 100000
+	f := New(n)
+	for i := 1; i != n; i++ {
+		f.Link(i-1, i)
+		f.SetValue(i, 1)
+	}
+	if sum, ok := f.PathSum(0, n-1); !ok || sum != n-1 {
+		t.Error(sum, ok)
+	}
+	f.Cut(n/2, n/2-1)
+	if f.Connected(0, n-1) || !f.Connected(n/2, n-1) {
+		t.Error("cut")
+	}
+}
+
+func BenchmarkLinkCut(b *testing.B) {
+	const n = 1 << 16
+	r := rand.New(rand.NewSource(1))
+	f := New(n)
+	// A random tree, whose edges are then cut and relinked elsewhere
+	parent := make([]int, n)
+	for i := 1; i != n; i++ {
+		parent[i] = r.Intn(i)
+		f.Link(i, parent[i])
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		v := 1 + r.Intn(n-1)
+		f.Cut(v, parent[v])
+		u := r.Intn(n)
+		if !f.Link(v, u) {
+			u = parent[v]
+			f.Link(v, u)
+		}
+		parent[v] = u
+		f.PathSum(r.Intn(n), r.Intn(n))
+	}
+}
@@ -0,0 +1,187 @@
+package linkedlist
+
+// Chunked byte buffers
+//
+// A FIFO of bytes, such as the data received on a connection that has not
+// yet been parsed into messages, is written at one end and consumed from the
+// other. A single growing slice (as in bytes.Buffer) must occasionally be
+// copied to a larger one, or slid back to the start once its front has been
+// consumed, and these copies grow with the amount of data buffered.
+//
+// Buffer instead keeps its bytes in a linked list of fixed-size blocks,
+// with pointers to both ends. Writes fill the last block and add a new one
+// when it is full, and reads consume the first block and unlink it once it
+// is empty, so both are O(1) per byte and no byte is ever copied within the
+// buffer. One emptied block is kept aside and reused, so that a buffer whose
+// size stays roughly level does not allocate.
+//
+//    head                                 tail
+//    [....xxxx] -> [xxxxxxxx] -> [xxxx....]
+//         ^ read                     ^ write
+
+import "io"
+
+// DefaultBlockSize is the block size of a zero Buffer
+const DefaultBlockSize = 4096
+
+type block struct {
+	data []byte
+	r, w int // positions of the next byte to read and to write
+	next *block
+}
+
+// Buffer is a FIFO byte buffer that implements io.Reader and io.Writer. The
+// zero value is an empty Buffer using blocks of DefaultBlockSize.
+type Buffer struct {
+	head, tail *block
+	spare      *block
+	blockSize  int
+	len        int
+}
+
+// NewBuffer creates an empty Buffer that stores data in blocks of
+// *blockSize* bytes
+func NewBuffer(blockSize int) *Buffer {
+	if blockSize < 1 {
+		panic("linkedlist: block size must be positive")
+	}
+	return &Buffer{blockSize: blockSize}
+}
+
+// Len returns the number of unread bytes
+func (b *Buffer) Len() int {
+	return b.len
+}
+
+// grow adds an empty block at the tail
+func (b *Buffer) grow() {
+	blk := b.spare
+	if blk != nil {
+		b.spare = nil
+		blk.r, blk.w = 0, 0
+	} else {
+		if b.blockSize == 0 {
+			b.blockSize = DefaultBlockSize
+		}
+		blk = &block{data: make([]byte, b.blockSize)}
+	}
+	if b.tail == nil {
+		b.head = blk
+	} else {
+		b.tail.next = blk
+	}
+	b.tail = blk
+}
+
+// Write appends the contents of *p* to the buffer. It always returns len(p)
+// and a nil error.
+func (b *Buffer) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) != 0 {
+		if b.tail == nil || b.tail.w == len(b.tail.data) {
+			b.grow()
+		}
+		c := copy(b.tail.data[b.tail.w:], p)
+		b.tail.w += c
+		p = p[c:]
+	}
+	b.len += n
+	return n, nil
+}
+
+// WriteByte appends a byte to the buffer. It always returns nil.
+func (b *Buffer) WriteByte(c byte) error {
+	_, err := b.Write([]byte{c})
+	return err
+}
+
+// consume removes *n* bytes from the front of the buffer, which must hold at
+// least that many, unlinking blocks as they are emptied
+func (b *Buffer) consume(n int) {
+	b.len -= n
+	for n != 0 {
+		blk := b.head
+		c := blk.w - blk.r
+		if n < c {
+			blk.r += n
+			return
+		}
+		n -= c
+		blk.r = blk.w
+		if blk.next == nil {
+			// Keep the last block, which can still be written to
+			blk.r, blk.w = 0, 0
+			return
+		}
+		b.head = blk.next
+		blk.next = nil
+		b.spare = blk
+	}
+}
+
+// Peek copies the bytes at the front of the buffer into *p* without
+// consuming them, and returns the number of bytes copied
+func (b *Buffer) Peek(p []byte) int {
+	n := 0
+	for blk := b.head; blk != nil && n != len(p); blk = blk.next {
+		n += copy(p[n:], blk.data[blk.r:blk.w])
+	}
+	return n
+}
+
+// Read consumes bytes from the front of the buffer into *p*. It returns
+// io.EOF if the buffer is empty and *p* is not.
+func (b *Buffer) Read(p []byte) (int, error) {
+	if b.len == 0 {
+		if len(p) == 0 {
+			return 0, nil
+		}
+		return 0, io.EOF
+	}
+	n := b.Peek(p)
+	b.consume(n)
+	return n, nil
+}
+
+// ReadByte consumes and returns the byte at the front of the buffer, or
+// returns io.EOF if the buffer is empty
+func (b *Buffer) ReadByte() (byte, error) {
+	if b.len == 0 {
+		return 0, io.EOF
+	}
+	c := b.head.data[b.head.r]
+	b.consume(1)
+	return c, nil
+}
+
+// Discard consumes up to *n* bytes without copying them, and returns the
+// number of bytes discarded
+func (b *Buffer) Discard(n int) int {
+	if n > b.len {
+		n = b.len
+	}
+	if n > 0 {
+		b.consume(n)
+	}
+	return n
+}
+
+// WriteTo writes the contents of the buffer to *w*, one block at a time,
+// until the buffer is empty or a write fails. It implements io.WriterTo, so
+// io.Copy uses it.
+func (b *Buffer) WriteTo(w io.Writer) (int64, error) {
+	var total int64
+	for b.len != 0 {
+		blk := b.head
+		n, err := w.Write(blk.data[blk.r:blk.w])
+		b.consume(n)
+		total += int64(n)
+		if err != nil {
+			return total, err
+		}
+		if n == 0 {
+			return total, io.ErrShortWrite
+		}
+	}
+	return total, nil
+}
@@ -0,0 +1,146 @@
+package linkedlist
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+	"testing"
+)
+
+func TestBufferFIFO(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	buf := NewBuffer(7)
+	var expected bytes.Buffer
+	for i := 0; i != 1000; i++ {
+		if r.Intn(2) == 0 {
+			p := make([]byte, r.Intn(20))
+			r.Read(p)
+			n, err := buf.Write(p)
+			if n != len(p) || err != nil {
+				t.Fatal(n, err)
+			}
+			expected.Write(p)
+		} else {
+			p := make([]byte, r.Intn(20))
+			q := make([]byte, len(p))
+			n, err := buf.Read(p)
+			m, expectedErr := expected.Read(q)
+			if n != m || err != expectedErr || !bytes.Equal(p[:n], q[:m]) {
+				t.Fatalf("read %d bytes (%v), expected %d (%v)", n, err, m, expectedErr)
+			}
+		}
+		if buf.Len() != expected.Len() {
+			t.Fatal(buf.Len(), expected.Len())
+		}
+	}
+}
+
+func TestBufferZero(t *testing.T) {
+	var buf Buffer
+	if _, err := buf.Read(make([]byte, 1)); err != io.EOF {
+		t.Error(err)
+	}
+	if _, err := buf.ReadByte(); err != io.EOF {
+		t.Error(err)
+	}
+	data := bytes.Repeat([]byte("abc"), 5000)
+	buf.Write(data)
+	buf.WriteByte('d')
+	if buf.Len() != len(data)+1 || buf.blockSize != DefaultBlockSize {
+		t.Error(buf.Len(), buf.blockSize)
+	}
+	if c, err := buf.ReadByte(); c != 'a' || err != nil {
+		t.Error(c, err)
+	}
+}
+
+func TestBufferPeekDiscard(t *testing.T) {
+	buf := NewBuffer(4)
+	buf.Write([]byte("hello, world"))
+	p := make([]byte, 7)
+	if n := buf.Peek(p); n != 7 || string(p) != "hello, " || buf.Len() != 12 {
+		t.Error(n, string(p), buf.Len())
+	}
+	if n := buf.Discard(7); n != 7 {
+		t.Error(n)
+	}
+	if n := buf.Peek(p); n != 5 || string(p[:n]) != "world" {
+		t.Error(n, string(p[:n]))
+	}
+	if n := buf.Discard(100); n != 5 || buf.Len() != 0 {
+		t.Error(n, buf.Len())
+	}
+}
+
+func TestBufferCopy(t *testing.T) {
+	buf := NewBuffer(10)
+	data := bytes.Repeat([]byte("0123456789"), 100)
+	if n, err := io.Copy(buf, bytes.NewReader(data)); n != int64(len(data)) || err != nil {
+		t.Fatal(n, err)
+	}
+	var out bytes.Buffer
+	if n, err := io.Copy(&out, buf); n != int64(len(data)) || err != nil {
+		t.Fatal(n, err)
+	}
+	if !bytes.Equal(out.Bytes(), data) || buf.Len() != 0 {
+		t.Error(out.Len(), buf.Len())
+	}
+}
+
+type failingWriter struct{ limit int }
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if len(p) > w.limit {
+		n := w.limit
+		w.limit = 0
+		return n, errors.New("disk full")
+	}
+	w.limit -= len(p)
+	return len(p), nil
+}
+
+func TestBufferWriteToError(t *testing.T) {
+	buf := NewBuffer(4)
+	buf.Write([]byte("0123456789"))
+	n, err := buf.WriteTo(&failingWriter{6})
+	if n != 6 || err == nil || buf.Len() != 4 {
+		t.Error(n, err, buf.Len())
+	}
+	p := make([]byte, 4)
+	if buf.Read(p); string(p) != "6789" {
+		t.Error(string(p))
+	}
+}
+
+func TestBufferAllocations(t *testing.T) {
+	// A buffer that is drained as fast as it is filled reuses its blocks
+	buf := NewBuffer(64)
+	p, q := make([]byte, 100), make([]byte, 100)
+	allocs := testing.AllocsPerRun(100, func() {
+		buf.Write(p)
+		buf.Read(q)
+	})
+	if allocs != 0 {
+		t.Error(allocs, "allocations")
+	}
+}
+
+func BenchmarkBuffer(b *testing.B) {
+	frame := make([]byte, 1500)
+	out := make([]byte, 1000)
+	b.Run("Buffer", func(b *testing.B) {
+		var buf Buffer
+		for i := 0; i < b.N; i++ {
+			buf.Write(frame)
+			buf.Read(out)
+		}
+	})
+	b.Run("bytes.Buffer", func(b *testing.B) {
+		var buf bytes.Buffer
+		for i := 0; i < b.N; i++ {
+			buf.Write(frame)
+			buf.Read(out)
+		}
+	})
+}
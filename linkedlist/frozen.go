@@ -0,0 +1,42 @@
+package linkedlist
+
+// Frozen lists
+//
+// Reaching the i-th value of a linked list means following i pointers, to
+// nodes that may be scattered across memory. Once a list will no longer
+// change, its values can be copied into a single slice, where any value is
+// reached in O(1) and a walk through them reads memory in order.
+//
+// A Frozen list cannot be modified, so it can be read from any number of
+// goroutines without locking.
+
+// Frozen is a read-only copy of a LinkedList
+type Frozen struct {
+	values []interface{}
+}
+
+// Freeze returns a read-only copy of the list
+func (lst *LinkedList) Freeze() *Frozen {
+	return &Frozen{lst.ToSlice()}
+}
+
+// Length returns the length of the list
+func (f *Frozen) Length() int {
+	return len(f.values)
+}
+
+// Get returns the value at position *index*.
+// If *index* is out of bounds, returns an error.
+func (f *Frozen) Get(index int) (interface{}, error) {
+	if index < 0 || index >= len(f.values) {
+		return 0, INDEX_ERROR
+	}
+	return f.values[index], nil
+}
+
+// ToSlice returns the values in the list as a slice, in order
+func (f *Frozen) ToSlice() []interface{} {
+	values := make([]interface{}, len(f.values))
+	copy(values, f.values)
+	return values
+}
@@ -0,0 +1,27 @@
+package linkedlist
+
+import "testing"
+
+func TestFrozen(t *testing.T) {
+	lst := FromSlice([]interface{}{"a", "b", "c"})
+	f := lst.Freeze()
+	lst.Set(1, "changed")
+	lst.Append("d")
+
+	if f.Length() != 3 {
+		t.Error(f.Length())
+	}
+	for i, expected := range []string{"a", "b", "c"} {
+		if value, err := f.Get(i); err != nil || value != expected {
+			t.Error(i, value, err)
+		}
+	}
+	if _, err := f.Get(3); err != INDEX_ERROR {
+		t.Error(err)
+	}
+	values := f.ToSlice()
+	values[0] = "z"
+	if value, _ := f.Get(0); value != "a" {
+		t.Error("frozen list changed")
+	}
+}
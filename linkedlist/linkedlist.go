@@ -157,3 +157,29 @@ func (lst *LinkedList) Delete(index int) (interface{}, error) {
 	lst.length--
 	return node.Value, nil
 }
+
+// FromSlice creates a LinkedList containing the elements of *values*, in order
+func FromSlice(values []interface{}) *LinkedList {
+	lst := New()
+	var tail *Node
+	for _, value := range values {
+		node := &Node{tail, nil, value}
+		if tail == nil {
+			lst.Head = node
+		} else {
+			tail.Next = node
+		}
+		tail = node
+		lst.length++
+	}
+	return lst
+}
+
+// ToSlice returns the values in a linked list as a slice, in order
+func (lst *LinkedList) ToSlice() []interface{} {
+	values := make([]interface{}, 0, lst.length)
+	for node := lst.Head; node != nil; node = node.Next {
+		values = append(values, node.Value)
+	}
+	return values
+}
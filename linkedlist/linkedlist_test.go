@@ -203,3 +203,28 @@ func TestDelete(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestSliceConversion(t *testing.T) {
+	lst := FromSlice([]interface{}{1, 2, 3})
+	if lst.Length() != 3 {
+		t.Fail()
+	}
+	if lst.Head.Next.Next.Prev.Value.(int) != 2 {
+		t.Fail()
+	}
+
+	lst.Append(4)
+	values := lst.ToSlice()
+	if len(values) != 4 {
+		t.Fail()
+	}
+	for i, v := range values {
+		if v.(int) != i+1 {
+			t.Fail()
+		}
+	}
+
+	if len(FromSlice(nil).ToSlice()) != 0 {
+		t.Fail()
+	}
+}
@@ -0,0 +1,434 @@
+/*
+ * Package lsm implements a minimal log-structured merge tree (LSM-tree).
+ *
+ * An LSM-tree turns random writes into sequential ones. Writes go to a small
+ * ordered in-memory table (the "memtable"). When the memtable fills up, it is
+ * written out in key order as an immutable "sorted run", and a fresh memtable
+ * is started. Runs are never modified, so writing one is a single sequential
+ * pass, which is what makes LSM-trees fast on disks and SSDs.
+ *
+ *    Put/Delete -> memtable (skip-list)
+ *                     | flush
+ *                     v
+ *                  run 3 (newest)
+ *                  run 2
+ *                  run 1 (oldest)  --compaction--> merged run
+ *
+ * A key may appear in the memtable and in several runs, and the newest
+ * version wins, so Get searches the memtable and then each run from newest to
+ * oldest. Deleting a key cannot remove it from the runs, so Delete instead
+ * writes a "tombstone" that hides any older versions.
+ *
+ * As runs accumulate, reads slow down, so runs are periodically merged
+ * ("compaction"). The runs are already sorted, so they are combined with a
+ * k-way merge (see iterator.MergeK) in a single pass, keeping only the newest
+ * version of each key. Since the merged run contains every key, tombstones
+ * have nothing left to hide and are dropped.
+ *
+ * Here the memtable is a skiplist.ConcurrentOrderedMap, and each run is an
+ * SSTable (see sstable.go), written as blocks with an index and a Bloom
+ * filter, so that a lookup reads at most one block of each run. A Tree
+ * created by Open keeps its runs in files in a directory, and finds them
+ * there again when reopened; one created by New keeps them in memory, in the
+ * same form.
+ *
+ * Each run is written to a temporary file that is renamed into place once it
+ * is complete, so a crash never leaves half a run. A compacted run holds the
+ * newest version of every key but no tombstones, so the runs it replaced
+ * would bring deleted keys back if they outlived it; it is therefore named
+ * differently, and Open deletes whatever runs are older than the newest
+ * compacted one. The memtable is not written anywhere until it is flushed,
+ * so writes since the last flush are lost in a crash unless they are also
+ * logged (see package wal).
+ */
+
+package lsm
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/njwilson23/datastructures/iterator"
+	"github.com/njwilson23/datastructures/skiplist"
+)
+
+const (
+	blockSize = 4096
+	runExt    = ".sst"  // a run flushed from the memtable
+	baseExt   = ".base" // a run written by compaction
+	tmpExt    = ".tmp"  // a run being written
+)
+
+// tombstone is stored in the memtable for a deleted key
+type tombstone struct{}
+
+// Tree is an LSM-tree with int keys and byte slice values. It is not safe for
+// concurrent use.
+type Tree struct {
+	mem       *skiplist.ConcurrentOrderedMap
+	runs      []*table // newest first
+	dir       string   // the directory of the runs, or "" to keep them in memory
+	seq       int      // the number of the next run file
+	flushSize int
+	maxRuns   int
+}
+
+// table is a run of a Tree, and the file holding it, if any
+type table struct {
+	*SSTable
+	file *os.File
+}
+
+// New creates an empty Tree that keeps its runs in memory. The memtable is
+// flushed once it holds *flushSize* keys, and the runs are compacted once
+// there are more than *maxRuns* of them.
+func New(flushSize, maxRuns int) *Tree {
+	return &Tree{
+		mem:       skiplist.NewConcurrentOrderedMap(),
+		flushSize: flushSize,
+		maxRuns:   maxRuns,
+	}
+}
+
+// Open creates a Tree that keeps its runs in files in *dir*, which must
+// exist, starting with the runs already there. The Tree should be closed
+// with Close, which flushes the memtable.
+func Open(dir string, flushSize, maxRuns int) (*Tree, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	type run struct {
+		seq  int
+		path string
+	}
+	var runs []run
+	base := -1
+	for _, file := range files {
+		name := file.Name()
+		path := filepath.Join(dir, name)
+		ext := filepath.Ext(name)
+		if ext == tmpExt {
+			// An unfinished run
+			if err := os.Remove(path); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		seq, err := strconv.Atoi(strings.TrimSuffix(name, ext))
+		if err != nil || ext != runExt && ext != baseExt {
+			continue
+		}
+		if ext == baseExt && seq > base {
+			base = seq
+		}
+		runs = append(runs, run{seq, path})
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].seq > runs[j].seq })
+
+	t := New(flushSize, maxRuns)
+	t.dir = dir
+	for _, r := range runs {
+		if r.seq < base {
+			// Compaction replaced the run, but stopped before deleting it
+			if err := os.Remove(r.path); err != nil {
+				t.Close()
+				return nil, err
+			}
+			continue
+		}
+		tb, err := openTable(r.path)
+		if err != nil {
+			t.Close()
+			return nil, fmt.Errorf("%s: %w", r.path, err)
+		}
+		t.runs = append(t.runs, tb)
+		if r.seq >= t.seq {
+			t.seq = r.seq + 1
+		}
+	}
+	return t, nil
+}
+
+// openTable opens the run in the file at *path*
+func openTable(path string) (*table, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	s, err := OpenSSTable(f, info.Size())
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &table{s, f}, nil
+}
+
+// Close flushes the memtable and closes the files of the runs
+func (t *Tree) Close() error {
+	err := t.Flush()
+	for _, tb := range t.runs {
+		if tb.file != nil {
+			if closeErr := tb.file.Close(); err == nil {
+				err = closeErr
+			}
+		}
+	}
+	t.runs = nil
+	return err
+}
+
+// Put sets the value for a key. It returns an error only if a flush fails.
+func (t *Tree) Put(key int, value []byte) error {
+	return t.write(key, value)
+}
+
+// Delete removes a key. It returns an error only if a flush fails.
+func (t *Tree) Delete(key int) error {
+	return t.write(key, tombstone{})
+}
+
+func (t *Tree) write(key int, value interface{}) error {
+	t.mem.Store(key, value)
+	if t.mem.Len() >= t.flushSize {
+		return t.Flush()
+	}
+	return nil
+}
+
+// Get returns the value for a key, or false if it is absent
+func (t *Tree) Get(key int) ([]byte, bool, error) {
+	if v, ok := t.mem.Load(key); ok {
+		value, ok := v.([]byte)
+		return value, ok, nil
+	}
+	for _, tb := range t.runs {
+		e, ok, err := tb.lookup(key)
+		if err != nil {
+			return nil, false, err
+		}
+		if ok {
+			return e.value, !e.deleted, nil
+		}
+	}
+	return nil, false, nil
+}
+
+// Runs returns the number of sorted runs
+func (t *Tree) Runs() int {
+	return len(t.runs)
+}
+
+// Flush writes the memtable out as a new run, and compacts the runs if there
+// are too many
+func (t *Tree) Flush() error {
+	if t.mem.Len() == 0 {
+		return nil
+	}
+	tb, err := t.writeTable(runExt, func(w *SSTableWriter) error {
+		var err error
+		t.mem.Range(func(key int, v interface{}) bool {
+			if value, ok := v.([]byte); ok {
+				err = w.Put(key, value)
+			} else {
+				err = w.Delete(key)
+			}
+			return err == nil
+		})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	t.runs = append([]*table{tb}, t.runs...)
+	t.mem = skiplist.NewConcurrentOrderedMap()
+	if len(t.runs) > t.maxRuns {
+		return t.Compact()
+	}
+	return nil
+}
+
+// Compact merges every run into one, dropping overwritten values and
+// tombstones
+func (t *Tree) Compact() error {
+	if len(t.runs) < 2 {
+		return nil
+	}
+	tb, err := t.writeTable(baseExt, func(w *SSTableWriter) error {
+		return t.merge(false, func(e entry) error {
+			if e.deleted {
+				return nil
+			}
+			return w.Put(e.key, e.value)
+		})
+	})
+	if err != nil {
+		return err
+	}
+	old := t.runs
+	t.runs = []*table{tb}
+	for _, r := range old {
+		if r.file == nil {
+			continue
+		}
+		r.file.Close()
+		if err := os.Remove(r.file.Name()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeTable writes a new run, with the entries added by *fill*, to a file
+// with the extension *ext*, or to memory if the tree has no directory
+func (t *Tree) writeTable(ext string, fill func(w *SSTableWriter) error) (*table, error) {
+	if t.dir == "" {
+		var buf bytes.Buffer
+		if err := writeSSTable(&buf, fill); err != nil {
+			return nil, err
+		}
+		s, err := OpenSSTable(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		if err != nil {
+			return nil, err
+		}
+		return &table{SSTable: s}, nil
+	}
+	path := filepath.Join(t.dir, fmt.Sprintf("%06d%s", t.seq, ext))
+	f, err := os.Create(path + tmpExt)
+	if err != nil {
+		return nil, err
+	}
+	w := bufio.NewWriter(f)
+	err = writeSSTable(w, fill)
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(path+tmpExt, path)
+	}
+	if err != nil {
+		os.Remove(path + tmpExt)
+		return nil, err
+	}
+	t.seq++
+	return openTable(path)
+}
+
+// writeSSTable writes an SSTable of the entries added by *fill* to *w*
+func writeSSTable(w io.Writer, fill func(w *SSTableWriter) error) error {
+	s := NewSSTableWriter(w, blockSize)
+	if err := fill(s); err != nil {
+		return err
+	}
+	return s.Finish()
+}
+
+// source is an iterator.Iterator over the keys of a sorted sequence of
+// entries, which *next* produces. MergeK reads ahead of the key it returns,
+// so the entries read are queued until they are taken.
+type source struct {
+	next    func() (entry, bool)
+	pending []entry
+}
+
+func (s *source) Next() (int, bool) {
+	e, ok := s.next()
+	if !ok {
+		return 0, false
+	}
+	s.pending = append(s.pending, e)
+	return e.key, true
+}
+
+// take returns the oldest entry read and not yet taken
+func (s *source) take() entry {
+	e := s.pending[0]
+	s.pending = s.pending[1:]
+	return e
+}
+
+// merge calls *f* with the newest version of every key in the runs, and in
+// the memtable if *withMem* is true, in ascending key order, stopping at the
+// first error
+func (t *Tree) merge(withMem bool, f func(entry) error) error {
+	var sources []*source
+	if withMem {
+		var entries []entry
+		t.mem.Range(func(key int, v interface{}) bool {
+			value, ok := v.([]byte)
+			entries = append(entries, entry{key, value, !ok})
+			return true
+		})
+		sources = append(sources, &source{next: func() (entry, bool) {
+			if len(entries) == 0 {
+				return entry{}, false
+			}
+			e := entries[0]
+			entries = entries[1:]
+			return e, true
+		}})
+	}
+	var cursors []*cursor
+	for _, tb := range t.runs {
+		c := &cursor{table: tb.SSTable}
+		cursors = append(cursors, c)
+		sources = append(sources, &source{next: c.next})
+	}
+	its := make([]iterator.Iterator, len(sources))
+	for i, s := range sources {
+		its[i] = s
+	}
+
+	// Inputs are listed newest first, and ties go to the input listed first,
+	// so the first occurrence of each key is its newest version
+	m := iterator.MergeK(its...)
+	first := true
+	last := 0
+	for key, ok := m.Next(); ok; key, ok = m.Next() {
+		e := sources[m.Source()].take()
+		if !first && key == last {
+			continue
+		}
+		if err := f(e); err != nil {
+			return err
+		}
+		first, last = false, key
+	}
+	// A run that could not be read ends early, so the merge is incomplete
+	for _, c := range cursors {
+		if c.err != nil {
+			return c.err
+		}
+	}
+	return nil
+}
+
+// Keys returns the keys present in the tree, in ascending order
+func (t *Tree) Keys() ([]int, error) {
+	var keys []int
+	err := t.merge(true, func(e entry) error {
+		if !e.deleted {
+			keys = append(keys, e.key)
+		}
+		return nil
+	})
+	return keys, err
+}
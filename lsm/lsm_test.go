@@ -0,0 +1,223 @@
+package lsm
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestRunEncoding(t *testing.T) {
+	entries := []entry{
+		{-5, []byte("minus five"), false},
+		{0, nil, true},
+		{300, []byte{}, false},
+		{1 << 40, []byte("big"), false},
+	}
+	r, err := decodeRun(encodeRun(entries))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range entries {
+		e, ok := r.get(expected.key)
+		if !ok || e.deleted != expected.deleted || string(e.value) != string(expected.value) {
+			t.Errorf("key %d: %v", expected.key, e)
+		}
+	}
+	if _, ok := r.get(1); ok {
+		t.Fail()
+	}
+
+	data := encodeRun(entries)
+	if _, err := decodeRun(data[:len(data)-2]); err != ErrCorrupt {
+		t.Error(err)
+	}
+	if _, err := decodeRun(encodeRun([]entry{{2, nil, false}, {1, nil, false}})); err != ErrCorrupt {
+		t.Error("unsorted run accepted")
+	}
+}
+
+func TestPutGetDelete(t *testing.T) {
+	tree := New(4, 3)
+	for i := 0; i != 10; i++ {
+		tree.Put(i, []byte(strconv.Itoa(i)))
+	}
+	if tree.Runs() != 2 {
+		t.Error(tree.Runs())
+	}
+	tree.Put(3, []byte("three"))
+	tree.Delete(5)
+	tree.Delete(9)
+
+	for i := 0; i != 10; i++ {
+		value, ok, err := tree.Get(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch i {
+		case 3:
+			if !ok || string(value) != "three" {
+				t.Error(string(value))
+			}
+		case 5, 9:
+			if ok {
+				t.Errorf("deleted key %d found", i)
+			}
+		default:
+			if !ok || string(value) != strconv.Itoa(i) {
+				t.Errorf("key %d: %q", i, value)
+			}
+		}
+	}
+	if keys, err := tree.Keys(); fmt.Sprint(keys) != "[0 1 2 3 4 6 7 8]" {
+		t.Error(keys, err)
+	}
+}
+
+func TestCompaction(t *testing.T) {
+	tree := New(10, 100)
+	for i := 0; i != 50; i++ {
+		tree.Put(i, []byte{byte(i)})
+	}
+	for i := 0; i != 50; i += 2 {
+		tree.Delete(i)
+	}
+	tree.Flush()
+	if tree.Runs() != 8 {
+		t.Fatal(tree.Runs())
+	}
+
+	if err := tree.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	if tree.Runs() != 1 {
+		t.Fatal(tree.Runs())
+	}
+	// Tombstones are dropped, leaving only live keys
+	c := &cursor{table: tree.runs[0].SSTable}
+	n := 0
+	for _, ok := c.next(); ok; _, ok = c.next() {
+		n++
+	}
+	if n != 25 || c.err != nil {
+		t.Error(n, c.err)
+	}
+	for i := 0; i != 50; i++ {
+		value, ok, _ := tree.Get(i)
+		if ok != (i%2 == 1) || (ok && value[0] != byte(i)) {
+			t.Errorf("key %d: %v %t", i, value, ok)
+		}
+	}
+}
+
+func TestRandomOperations(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	tree := New(32, 4)
+	reference := make(map[int]string)
+	for i := 0; i != 5000; i++ {
+		key := r.Intn(500)
+		if r.Intn(4) == 0 {
+			tree.Delete(key)
+			delete(reference, key)
+		} else {
+			value := strconv.Itoa(i)
+			tree.Put(key, []byte(value))
+			reference[key] = value
+		}
+	}
+	if tree.Runs() > 4 {
+		t.Error(tree.Runs())
+	}
+	for key := 0; key != 500; key++ {
+		value, ok, _ := tree.Get(key)
+		expected, present := reference[key]
+		if ok != present || string(value) != expected {
+			t.Fatalf("key %d: %q %t, expected %q %t", key, value, ok, expected, present)
+		}
+	}
+	if keys, _ := tree.Keys(); len(keys) != len(reference) {
+		t.Error(len(keys))
+	}
+}
+
+func TestOpen(t *testing.T) {
+	dir := t.TempDir()
+	tree, err := Open(dir, 10, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i != 35; i++ {
+		if err := tree.Put(i, []byte(strconv.Itoa(i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tree.Delete(7)
+	// Three runs were flushed, and the memtable holds the rest
+	files, _ := filepath.Glob(filepath.Join(dir, "*"))
+	if len(files) != 3 || tree.Runs() != 3 {
+		t.Fatal(files)
+	}
+	if err := tree.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Closing flushed the memtable, so a fourth run was compacted with the
+	// others into one
+	files, _ = filepath.Glob(filepath.Join(dir, "*"))
+	if len(files) != 1 || filepath.Ext(files[0]) != baseExt {
+		t.Fatal(files)
+	}
+	tree, err = Open(dir, 10, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+	for i := 0; i != 35; i++ {
+		value, ok, err := tree.Get(i)
+		if err != nil || ok != (i != 7) || ok && string(value) != strconv.Itoa(i) {
+			t.Errorf("key %d: %q %t %v", i, value, ok, err)
+		}
+	}
+	tree.Put(100, nil)
+	tree.Flush()
+	if keys, err := tree.Keys(); len(keys) != 35 || keys[34] != 100 || err != nil {
+		t.Error(keys, err)
+	}
+}
+
+func TestOpenAfterCrash(t *testing.T) {
+	dir := t.TempDir()
+	tree, _ := Open(dir, 100, 100)
+	tree.Put(1, []byte("one"))
+	tree.Flush()
+	tree.Delete(1)
+	tree.Put(2, []byte("two"))
+	tree.Flush()
+	old, _ := filepath.Glob(filepath.Join(dir, "*"+runExt))
+	data, _ := os.ReadFile(old[0])
+	tree.Compact()
+	tree.Put(3, []byte("three"))
+	tree.Close()
+
+	// A crash left a run that compaction had replaced, and a run half
+	// written
+	if err := os.WriteFile(old[0], data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "000009"+runExt+tmpExt), data[:10], 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tree, err := Open(dir, 100, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+	if keys, _ := tree.Keys(); fmt.Sprint(keys) != "[2 3]" {
+		t.Error(keys)
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "*")); len(files) != 2 {
+		t.Error(files)
+	}
+}
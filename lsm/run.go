@@ -0,0 +1,110 @@
+package lsm
+
+// Sorted runs
+//
+// A run is a sequence of entries in ascending key order, serialized as
+//
+//	count   uvarint
+//	entries [count]{
+//		key    varint
+//		flags  byte (1 for a tombstone)
+//		length uvarint
+//		value  [length]byte
+//	}
+//
+// Variable-length integers (see encoding/binary) keep small keys and lengths
+// to a byte or two. Entries vary in length, so they cannot be found by
+// position alone. When a run is decoded, the keys and the offset of every
+// entry are read into an index, so that lookups are a binary search over the
+// keys followed by decoding a single entry.
+
+import (
+	"encoding/binary"
+	"errors"
+	"sort"
+)
+
+var ErrCorrupt = errors.New("corrupt sorted run")
+
+type entry struct {
+	key     int
+	value   []byte
+	deleted bool
+}
+
+type run struct {
+	data    []byte
+	keys    []int
+	offsets []int
+}
+
+// encodeRun serializes entries, which must be in ascending key order
+func encodeRun(entries []entry) []byte {
+	data := binary.AppendUvarint(nil, uint64(len(entries)))
+	for _, e := range entries {
+		data = binary.AppendVarint(data, int64(e.key))
+		var flags byte
+		if e.deleted {
+			flags = 1
+		}
+		data = append(data, flags)
+		data = binary.AppendUvarint(data, uint64(len(e.value)))
+		data = append(data, e.value...)
+	}
+	return data
+}
+
+// decodeRun builds the index of a serialized run
+func decodeRun(data []byte) (*run, error) {
+	count, n := binary.Uvarint(data)
+	if n <= 0 {
+		return nil, ErrCorrupt
+	}
+	r := &run{data: data, keys: make([]int, 0, count), offsets: make([]int, 0, count)}
+	pos := n
+	for i := uint64(0); i != count; i++ {
+		e, next, err := r.decodeAt(pos)
+		if err != nil {
+			return nil, err
+		}
+		if len(r.keys) != 0 && e.key <= r.keys[len(r.keys)-1] {
+			return nil, ErrCorrupt
+		}
+		r.keys = append(r.keys, e.key)
+		r.offsets = append(r.offsets, pos)
+		pos = next
+	}
+	return r, nil
+}
+
+// decodeAt decodes the entry starting at *pos*, and returns the position of
+// the next entry
+func (r *run) decodeAt(pos int) (entry, int, error) {
+	key, n := binary.Varint(r.data[pos:])
+	if n <= 0 || pos+n >= len(r.data) {
+		return entry{}, 0, ErrCorrupt
+	}
+	pos += n
+	flags := r.data[pos]
+	pos++
+	length, n := binary.Uvarint(r.data[pos:])
+	if n <= 0 || uint64(len(r.data)-pos-n) < length {
+		return entry{}, 0, ErrCorrupt
+	}
+	pos += n
+	e := entry{key: int(key), deleted: flags&1 != 0}
+	if !e.deleted {
+		e.value = r.data[pos : pos+int(length) : pos+int(length)]
+	}
+	return e, pos + int(length), nil
+}
+
+// get returns the entry for *key*, or false if the run does not contain it
+func (r *run) get(key int) (entry, bool) {
+	i := sort.SearchInts(r.keys, key)
+	if i == len(r.keys) || r.keys[i] != key {
+		return entry{}, false
+	}
+	e, _, _ := r.decodeAt(r.offsets[i])
+	return e, true
+}
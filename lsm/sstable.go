@@ -0,0 +1,316 @@
+package lsm
+
+// Sorted string tables
+//
+// An SSTable stores a sorted run in a file, in a form that can be searched
+// without reading the whole file into memory:
+//
+//	blocks  [...]run, each of about blockSize bytes (see run.go)
+//	index   count uvarint, [count]{first key varint, offset uvarint, length uvarint}
+//	filter  a bloom.Filter of the keys, by MarshalBinary
+//	footer  index offset, index length, filter offset, filter length as
+//	        uint64s, little-endian, and the magic "SST1"
+//
+// Opening a table reads the footer, the index and the filter, which are small
+// next to the data. A lookup first asks the filter, which rules out most
+// absent keys without touching the blocks; otherwise it finds the one block
+// whose range of keys could hold the key by binary search of the index, and
+// reads and searches that block alone. The index is "sparse", with one key
+// per block rather than one per entry, which keeps it small enough to hold in
+// memory for tables much larger than memory.
+//
+// Tombstones are stored like any other entry, so that a table written from
+// the newer runs of a Tree still hides older versions of deleted keys.
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"sort"
+
+	"github.com/njwilson23/datastructures/bloom"
+)
+
+var ErrUnsorted = errors.New("keys are not in ascending order")
+
+const (
+	sstableMagic      = "SST1"
+	sstableFooterSize = 36
+	filterRate        = 0.01
+)
+
+// SSTableWriter writes an SSTable, one entry at a time in ascending key order
+type SSTableWriter struct {
+	w         io.Writer
+	blockSize int
+	offset    int
+	block     []entry
+	blockLen  int
+	index     []byte
+	blocks    int
+	keys      []int
+	err       error
+}
+
+// NewSSTableWriter creates an SSTableWriter writing to *w*, which starts a new
+// block once the current one holds at least *blockSize* bytes of keys and
+// values
+func NewSSTableWriter(w io.Writer, blockSize int) *SSTableWriter {
+	return &SSTableWriter{w: w, blockSize: blockSize}
+}
+
+// Put adds the entry *key* with *value*
+func (s *SSTableWriter) Put(key int, value []byte) error {
+	return s.add(entry{key: key, value: value})
+}
+
+// Delete adds a tombstone for *key*
+func (s *SSTableWriter) Delete(key int) error {
+	return s.add(entry{key: key, deleted: true})
+}
+
+func (s *SSTableWriter) add(e entry) error {
+	if s.err != nil {
+		return s.err
+	}
+	if len(s.keys) != 0 && e.key <= s.keys[len(s.keys)-1] {
+		return ErrUnsorted
+	}
+	s.keys = append(s.keys, e.key)
+	// The value is copied, since the caller may reuse it
+	e.value = append([]byte(nil), e.value...)
+	s.block = append(s.block, e)
+	s.blockLen += binary.MaxVarintLen64 + len(e.value)
+	if s.blockLen >= s.blockSize {
+		return s.flush()
+	}
+	return nil
+}
+
+// flush writes the current block and indexes it
+func (s *SSTableWriter) flush() error {
+	if len(s.block) == 0 {
+		return nil
+	}
+	data := encodeRun(s.block)
+	if _, err := s.w.Write(data); err != nil {
+		s.err = err
+		return err
+	}
+	s.index = binary.AppendVarint(s.index, int64(s.block[0].key))
+	s.index = binary.AppendUvarint(s.index, uint64(s.offset))
+	s.index = binary.AppendUvarint(s.index, uint64(len(data)))
+	s.offset += len(data)
+	s.blocks++
+	s.block, s.blockLen = s.block[:0], 0
+	return nil
+}
+
+// Finish writes the last block, the index, the filter and the footer,
+// completing the table
+func (s *SSTableWriter) Finish() error {
+	if err := s.flush(); err != nil {
+		return err
+	}
+	index := binary.AppendUvarint(nil, uint64(s.blocks))
+	index = append(index, s.index...)
+	f := bloom.NewFilter(len(s.keys), filterRate)
+	for _, key := range s.keys {
+		f.Add(keyBytes(key))
+	}
+	filter, err := f.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	footer := make([]byte, 0, sstableFooterSize)
+	for _, v := range []int{s.offset, len(index), s.offset + len(index), len(filter)} {
+		footer = binary.LittleEndian.AppendUint64(footer, uint64(v))
+	}
+	footer = append(footer, sstableMagic...)
+	for _, p := range [][]byte{index, filter, footer} {
+		if _, err := s.w.Write(p); err != nil {
+			s.err = err
+			return err
+		}
+	}
+	return nil
+}
+
+// keyBytes encodes a key for the filter
+func keyBytes(key int) []byte {
+	return binary.LittleEndian.AppendUint64(nil, uint64(key))
+}
+
+// SSTable reads an SSTable
+type SSTable struct {
+	r       io.ReaderAt
+	first   []int // the first key of each block
+	offsets []int
+	lengths []int
+	filter  *bloom.Filter
+}
+
+// OpenSSTable reads the index and filter of the SSTable in the first *size*
+// bytes of *r*, usually an *os.File
+func OpenSSTable(r io.ReaderAt, size int64) (*SSTable, error) {
+	if size < sstableFooterSize {
+		return nil, ErrCorrupt
+	}
+	footer := make([]byte, sstableFooterSize)
+	if _, err := r.ReadAt(footer, size-sstableFooterSize); err != nil {
+		return nil, err
+	}
+	if string(footer[32:]) != sstableMagic {
+		return nil, ErrCorrupt
+	}
+	var fields [4]uint64
+	for i := range fields {
+		fields[i] = binary.LittleEndian.Uint64(footer[8*i:])
+	}
+	indexOffset, indexLen, filterOffset, filterLen := fields[0], fields[1], fields[2], fields[3]
+	end := uint64(size - sstableFooterSize)
+	if indexOffset > end || indexLen > end-indexOffset || filterOffset != indexOffset+indexLen ||
+		filterLen != end-filterOffset {
+		return nil, ErrCorrupt
+	}
+	meta := make([]byte, indexLen+filterLen)
+	if _, err := r.ReadAt(meta, int64(indexOffset)); err != nil {
+		return nil, err
+	}
+
+	t := &SSTable{r: r, filter: &bloom.Filter{}}
+	index := meta[:indexLen]
+	count, n := binary.Uvarint(index)
+	if n <= 0 || count > uint64(len(index)) {
+		return nil, ErrCorrupt
+	}
+	index = index[n:]
+	for i := uint64(0); i != count; i++ {
+		key, n := binary.Varint(index)
+		if n <= 0 {
+			return nil, ErrCorrupt
+		}
+		index = index[n:]
+		var fields [2]uint64
+		for j := range fields {
+			if fields[j], n = binary.Uvarint(index); n <= 0 {
+				return nil, ErrCorrupt
+			}
+			index = index[n:]
+		}
+		offset, length := fields[0], fields[1]
+		if offset > indexOffset || length > indexOffset-offset ||
+			len(t.first) != 0 && int(key) <= t.first[len(t.first)-1] {
+			return nil, ErrCorrupt
+		}
+		t.first = append(t.first, int(key))
+		t.offsets = append(t.offsets, int(offset))
+		t.lengths = append(t.lengths, int(length))
+	}
+	if err := t.filter.UnmarshalBinary(meta[indexLen:]); err != nil {
+		return nil, ErrCorrupt
+	}
+	return t, nil
+}
+
+// Blocks returns the number of data blocks
+func (t *SSTable) Blocks() int {
+	return len(t.first)
+}
+
+// readBlock reads and indexes block *i*
+func (t *SSTable) readBlock(i int) (*run, error) {
+	data := make([]byte, t.lengths[i])
+	if _, err := t.r.ReadAt(data, int64(t.offsets[i])); err != nil {
+		return nil, err
+	}
+	return decodeRun(data)
+}
+
+// blockFor returns the block whose keys could include *key*, or -1
+func (t *SSTable) blockFor(key int) int {
+	return sort.Search(len(t.first), func(i int) bool { return t.first[i] > key }) - 1
+}
+
+// lookup returns the entry for *key*, which may be a tombstone, or false if
+// the table does not contain it
+func (t *SSTable) lookup(key int) (entry, bool, error) {
+	if !t.filter.Contains(keyBytes(key)) {
+		return entry{}, false, nil
+	}
+	i := t.blockFor(key)
+	if i < 0 {
+		return entry{}, false, nil
+	}
+	b, err := t.readBlock(i)
+	if err != nil {
+		return entry{}, false, err
+	}
+	e, ok := b.get(key)
+	return e, ok, nil
+}
+
+// Get returns the value for *key*, or false if the table does not contain
+// it or holds a tombstone for it
+func (t *SSTable) Get(key int) ([]byte, bool, error) {
+	e, ok, err := t.lookup(key)
+	if !ok || e.deleted {
+		return nil, false, err
+	}
+	return e.value, true, nil
+}
+
+// cursor reads every entry of an SSTable, including tombstones, in
+// ascending key order, one block at a time
+type cursor struct {
+	table *SSTable
+	block int  // the next block to read
+	run   *run // the current block
+	pos   int
+	err   error
+}
+
+// next returns the next entry, or false at the end of the table or after an
+// error, which is left in err
+func (c *cursor) next() (entry, bool) {
+	for c.err == nil {
+		if c.run != nil && c.pos < len(c.run.keys) {
+			var e entry
+			e, _, c.err = c.run.decodeAt(c.run.offsets[c.pos])
+			c.pos++
+			return e, c.err == nil
+		}
+		if c.block == len(c.table.first) {
+			break
+		}
+		c.run, c.err = c.table.readBlock(c.block)
+		c.block, c.pos = c.block+1, 0
+	}
+	return entry{}, false
+}
+
+// Range calls *f* with each key in [*lo*, *hi*) and its value, in ascending
+// order and skipping tombstones, until *f* returns false. Only the blocks
+// that overlap the range are read.
+func (t *SSTable) Range(lo, hi int, f func(key int, value []byte) bool) error {
+	i := t.blockFor(lo)
+	if i < 0 {
+		i = 0
+	}
+	for ; i < len(t.first) && t.first[i] < hi; i++ {
+		b, err := t.readBlock(i)
+		if err != nil {
+			return err
+		}
+		for j := sort.SearchInts(b.keys, lo); j < len(b.keys) && b.keys[j] < hi; j++ {
+			e, _, err := b.decodeAt(b.offsets[j])
+			if err != nil {
+				return err
+			}
+			if !e.deleted && !f(e.key, e.value) {
+				return nil
+			}
+		}
+	}
+	return nil
+}
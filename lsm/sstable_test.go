@@ -0,0 +1,129 @@
+package lsm
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+// writeTable writes the keys 0, 3, 6, ... below 3n, deleting every tenth
+func writeTable(t testing.TB, n, blockSize int) []byte {
+	var buf bytes.Buffer
+	w := NewSSTableWriter(&buf, blockSize)
+	for i := 0; i != n; i++ {
+		var err error
+		if i%10 == 9 {
+			err = w.Delete(3 * i)
+		} else {
+			err = w.Put(3*i, []byte(strconv.Itoa(i)))
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestSSTableGet(t *testing.T) {
+	for _, n := range []int{0, 1, 1000} {
+		data := writeTable(t, n, 256)
+		table, err := OpenSSTable(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n == 1000 && table.Blocks() < 10 {
+			t.Error(table.Blocks(), "blocks")
+		}
+		for key := -1; key <= 3*n; key++ {
+			value, ok, err := table.Get(key)
+			i := key / 3
+			expected := key%3 == 0 && key >= 0 && i < n && i%10 != 9
+			if err != nil || ok != expected || ok && string(value) != strconv.Itoa(i) {
+				t.Fatal(n, key, string(value), ok, err)
+			}
+		}
+		// Tombstones are kept, for use by a Tree
+		if e, ok, _ := table.lookup(27); n == 1000 && (!ok || !e.deleted) {
+			t.Error("tombstone lost")
+		}
+	}
+}
+
+func TestSSTableRange(t *testing.T) {
+	data := writeTable(t, 1000, 256)
+	table, _ := OpenSSTable(bytes.NewReader(data), int64(len(data)))
+	for _, c := range [][2]int{{-10, 20}, {100, 200}, {2990, 4000}, {50, 50}, {5000, 6000}} {
+		var got, expected []string
+		table.Range(c[0], c[1], func(key int, value []byte) bool {
+			got = append(got, fmt.Sprint(key, "=", string(value)))
+			return true
+		})
+		for key := 0; key < 3000; key += 3 {
+			if key >= c[0] && key < c[1] && key/3%10 != 9 {
+				expected = append(expected, fmt.Sprint(key, "=", key/3))
+			}
+		}
+		if fmt.Sprint(got) != fmt.Sprint(expected) {
+			t.Error(c, got)
+		}
+	}
+	count := 0
+	table.Range(0, 3000, func(int, []byte) bool {
+		count++
+		return count < 5
+	})
+	if count != 5 {
+		t.Error(count)
+	}
+}
+
+func TestSSTableFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "table")
+	if err := os.WriteFile(path, writeTable(t, 100, 128), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	info, _ := f.Stat()
+	table, err := OpenSSTable(f, info.Size())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value, ok, err := table.Get(150); !ok || string(value) != "50" {
+		t.Error(string(value), err)
+	}
+}
+
+func TestSSTableErrors(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewSSTableWriter(&buf, 100)
+	w.Put(5, nil)
+	if err := w.Put(5, nil); err != ErrUnsorted {
+		t.Error(err)
+	}
+	data := writeTable(t, 100, 128)
+	for _, damaged := range [][]byte{data[:len(data)-1], data[:10], append([]byte{}, data[1:]...)} {
+		if _, err := OpenSSTable(bytes.NewReader(damaged), int64(len(damaged))); err == nil {
+			t.Error("damaged table opened")
+		}
+	}
+}
+
+func BenchmarkSSTableGet(b *testing.B) {
+	data := writeTable(b, 100000, 4096)
+	table, _ := OpenSSTable(bytes.NewReader(data), int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// Half of the lookups are for absent keys
+		table.Get(i % 300000)
+	}
+}
@@ -15,19 +15,36 @@
  * Both versions of this algorithm have O(n log n) time performance, however
  * the bottom-up version below is implemented without needing a stack of calling
  * frames can so may be preferable in a language such as Go.
+ *
+ * A third, "natural" version takes advantage of order that is already present
+ * in the input. Rather than starting from segments of length one, it scans the
+ * array for runs that are already sorted (reversing runs that are strictly
+ * descending) and only merges those. This is the core idea behind Timsort, and
+ * means that already-sorted input is handled in O(n) time.
+ *
+ * All of the above allocate new slices while merging, so they use O(n)
+ * auxiliary memory. Finally, MergeSortInPlace shows that merging can be done
+ * using only rotations within the original array (the "SymMerge" algorithm of
+ * Kim and Kutzner). This trades memory for time: each merge costs
+ * O(n log n) element moves rather than O(n), giving O(n log^2 n) overall, but
+ * no scratch space beyond a logarithmic number of stack frames is needed.
  */
 
 package mergesort
 
 // RecursiveMergeSort implements a "top-down" recursive merge sort algorithm
 func RecursiveMergeSort(sortable []int) []int {
+	return recursiveMergeSort(sortable, nil)
+}
+
+func recursiveMergeSort(sortable []int, stats *Stats) []int {
 	n := len(sortable)
-	if n == 1 {
+	if n <= 1 {
 		return sortable
 	}
-	left := RecursiveMergeSort(sortable[:n/2])
-	right := RecursiveMergeSort(sortable[n/2:])
-	return merge(left, right)
+	left := recursiveMergeSort(sortable[:n/2], stats)
+	right := recursiveMergeSort(sortable[n/2:], stats)
+	return merge(left, right, stats)
 }
 
 func min(a, b int) int {
@@ -39,15 +56,19 @@ func min(a, b int) int {
 
 // MergeSort implements an "bottom-up" non-recursive merge sort algorithm
 func MergeSort(sortable []int) []int {
+	return mergeSort(sortable, nil)
+}
+
+func mergeSort(sortable []int, stats *Stats) []int {
 	var left, right []int
 	mergeSize := 1
 	n := len(sortable)
 	for mergeSize <= n {
 		i := 0
 		for i < n {
-			left = sortable[i : i+mergeSize]
-			right = sortable[i+mergeSize : min(n, i+2*mergeSize)]
-			sortable = append(sortable[:i], append(merge(left, right), sortable[min(n, i+2*mergeSize):]...)...)
+			left = sortable[i:min(n, i+mergeSize)]
+			right = sortable[min(n, i+mergeSize):min(n, i+2*mergeSize)]
+			sortable = append(sortable[:i], append(merge(left, right, stats), sortable[min(n, i+2*mergeSize):]...)...)
 			i = i + 2*mergeSize
 		}
 		mergeSize = mergeSize * 2
@@ -55,8 +76,183 @@ func MergeSort(sortable []int) []int {
 	return sortable
 }
 
-// merge combines two sorted slices into a single sorted slice
-func merge(left, right []int) []int {
+// NaturalMergeSort implements an adaptive merge sort that merges pre-existing
+// sorted runs rather than fixed-size segments
+func NaturalMergeSort(sortable []int) []int {
+	return naturalMergeSort(sortable, nil)
+}
+
+func naturalMergeSort(sortable []int, stats *Stats) []int {
+	runs := findRuns(sortable, stats)
+	if len(runs) == 0 {
+		return sortable
+	}
+
+	// Merge neighbouring runs pairwise until a single run remains. Each pass
+	// halves the number of runs, so for r runs there are O(log r) passes.
+	for len(runs) != 1 {
+		merged := make([][]int, 0, (len(runs)+1)/2)
+		for i := 0; i < len(runs); i += 2 {
+			if i+1 == len(runs) {
+				merged = append(merged, runs[i])
+			} else {
+				merged = append(merged, merge(runs[i], runs[i+1], stats))
+			}
+		}
+		runs = merged
+	}
+	return runs[0]
+}
+
+// findRuns splits a slice into maximal sorted runs. Strictly descending runs
+// are reversed in place so that every returned run is ascending. Only strictly
+// descending runs are reversed, because reversing a run with equal elements
+// would change their relative order.
+func findRuns(sortable []int, stats *Stats) [][]int {
+	var runs [][]int
+	n := len(sortable)
+	start := 0
+	for start < n {
+		end := start + 1
+		if end < n && stats.less(sortable[end], sortable[start]) {
+			end++
+			for end < n && stats.less(sortable[end], sortable[end-1]) {
+				end++
+			}
+			reverse(sortable[start:end], stats)
+		} else if end < n {
+			end++
+			for end < n && !stats.less(sortable[end], sortable[end-1]) {
+				end++
+			}
+		}
+		runs = append(runs, sortable[start:end])
+		start = end
+	}
+	return runs
+}
+
+// reverse reverses a slice in place
+func reverse(s []int, stats *Stats) {
+	for i, j := 0, len(s)-1; i < j; i, j = i+1, j-1 {
+		s[i], s[j] = s[j], s[i]
+		stats.swap()
+	}
+}
+
+// MergeSortInPlace implements a bottom-up merge sort that merges segments by
+// rotating them within the input slice, rather than copying into new slices
+func MergeSortInPlace(sortable []int) []int {
+	return mergeSortInPlace(sortable, nil)
+}
+
+func mergeSortInPlace(sortable []int, stats *Stats) []int {
+	n := len(sortable)
+	for mergeSize := 1; mergeSize < n; mergeSize *= 2 {
+		for i := 0; i+mergeSize < n; i += 2 * mergeSize {
+			symMerge(sortable, i, i+mergeSize, min(n, i+2*mergeSize), stats)
+		}
+	}
+	return sortable
+}
+
+// symMerge merges the sorted segments data[a:m] and data[m:b] in place.
+//
+// The idea is to pick the midpoint of the combined segment and find the
+// position *start* in the left segment such that rotating data[start:end]
+// (where *end* is placed symmetrically in the right segment) puts every element
+// that belongs in the left half of the output to the left of the midpoint. The
+// two halves are then merged recursively.
+//
+//	a        start     m        end        b
+//	|  left1  | left2  | right1  | right2  |
+//
+// becomes, after rotating [start:end],
+//
+//	a        start    mid       end        b
+//	|  left1  | right1 | left2   | right2  |
+//
+// and left1/right1 and left2/right2 are merged independently.
+func symMerge(data []int, a, m, b int, stats *Stats) {
+	if m-a == 1 {
+		// Insert data[a] into the right segment with a binary search
+		i, j := m, b
+		for i < j {
+			h := (i + j) / 2
+			if stats.less(data[h], data[a]) {
+				i = h + 1
+			} else {
+				j = h
+			}
+		}
+		for k := a; k < i-1; k++ {
+			data[k], data[k+1] = data[k+1], data[k]
+			stats.swap()
+		}
+		return
+	}
+	if b-m == 1 {
+		// Insert data[m] into the left segment with a binary search
+		i, j := a, m
+		for i < j {
+			h := (i + j) / 2
+			if !stats.less(data[m], data[h]) {
+				i = h + 1
+			} else {
+				j = h
+			}
+		}
+		for k := m; k > i; k-- {
+			data[k], data[k-1] = data[k-1], data[k]
+			stats.swap()
+		}
+		return
+	}
+
+	mid := (a + b) / 2
+	n := mid + m
+	var start, r int
+	if m > mid {
+		start = n - b
+		r = mid
+	} else {
+		start = a
+		r = m
+	}
+	p := n - 1
+	for start < r {
+		c := (start + r) / 2
+		if !stats.less(data[p-c], data[c]) {
+			start = c + 1
+		} else {
+			r = c
+		}
+	}
+
+	end := n - start
+	if start < m && m < end {
+		rotate(data[start:end], m-start, stats)
+	}
+	if a < start && start < mid {
+		symMerge(data, a, start, mid, stats)
+	}
+	if mid < end && end < b {
+		symMerge(data, mid, end, b, stats)
+	}
+}
+
+// rotate moves the first k elements of s to the end by three reversals,
+// without any auxiliary storage
+func rotate(s []int, k int, stats *Stats) {
+	reverse(s[:k], stats)
+	reverse(s[k:], stats)
+	reverse(s, stats)
+}
+
+// merge combines two sorted slices into a single sorted slice. When elements
+// compare equal, the one from the left slice is taken first, which makes the
+// merge (and every sort built on it) stable.
+func merge(left, right []int, stats *Stats) []int {
 	posLeft := 0
 	posRight := 0
 	n := len(left) + len(right)
@@ -69,13 +265,14 @@ func merge(left, right []int) []int {
 		} else if posRight == len(right) {
 			merged = append(merged, left[posLeft])
 			posLeft++
-		} else if left[posLeft] < right[posRight] {
-			merged = append(merged, left[posLeft])
-			posLeft++
-		} else {
+		} else if stats.less(right[posRight], left[posLeft]) {
 			merged = append(merged, right[posRight])
 			posRight++
+		} else {
+			merged = append(merged, left[posLeft])
+			posLeft++
 		}
 	}
+	stats.move(n)
 	return merged
 }
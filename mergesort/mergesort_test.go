@@ -2,7 +2,10 @@ package mergesort
 
 import (
 	"fmt"
+	"sort"
 	"testing"
+
+	"github.com/njwilson23/datastructures/gen"
 )
 
 func slicesEqual(a, b []int) bool {
@@ -22,7 +25,7 @@ func slicesEqual(a, b []int) bool {
 func TestMerge(t *testing.T) {
 	left := []int{2, 5, 8, 13, 18}
 	right := []int{1, 4, 8, 11, 12, 16, 21}
-	merged := merge(left, right)
+	merged := merge(left, right, nil)
 	if !slicesEqual(merged, []int{1, 2, 4, 5, 8, 8, 11, 12, 13, 16, 18, 21}) {
 		fmt.Println(merged)
 		t.Fail()
@@ -30,7 +33,7 @@ func TestMerge(t *testing.T) {
 
 	left = []int{5}
 	right = []int{7}
-	merged = merge(left, right)
+	merged = merge(left, right, nil)
 	if !slicesEqual(merged, []int{5, 7}) {
 		fmt.Println(merged)
 		t.Fail()
@@ -45,6 +48,15 @@ func TestMergeSort(t *testing.T) {
 	}
 }
 
+func TestMergeSortUnevenLength(t *testing.T) {
+	data := []int{9, 4, 7, 1, 8, 2, 6, 3, 5, 0, 11, 10, 12, 14, 13, 15, 16, 19, 17, 18}
+	sortedData := MergeSort(data)
+	if !slicesEqual(sortedData, sortedInput(20)) {
+		fmt.Println(sortedData)
+		t.Fail()
+	}
+}
+
 func TestRecursiveMergeSort(t *testing.T) {
 	data := []int{43, 27, 8, 3, 75, 6, 32, 61, 3, 12, 6, 3}
 	sortedData := RecursiveMergeSort(data)
@@ -52,3 +64,186 @@ func TestRecursiveMergeSort(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestNaturalMergeSort(t *testing.T) {
+	data := []int{43, 27, 8, 3, 75, 6, 32, 61, 3, 12, 6, 3}
+	sortedData := NaturalMergeSort(data)
+	if !slicesEqual(sortedData, []int{3, 3, 3, 6, 6, 8, 12, 27, 32, 43, 61, 75}) {
+		fmt.Println(sortedData)
+		t.Fail()
+	}
+
+	data = []int{1, 2, 3, 9, 8, 7, 4, 5, 6, 6, 0}
+	sortedData = NaturalMergeSort(data)
+	if !slicesEqual(sortedData, []int{0, 1, 2, 3, 4, 5, 6, 6, 7, 8, 9}) {
+		fmt.Println(sortedData)
+		t.Fail()
+	}
+
+	if len(NaturalMergeSort([]int{})) != 0 {
+		t.Fail()
+	}
+}
+
+func TestFindRuns(t *testing.T) {
+	runs := findRuns([]int{1, 2, 2, 5, 4, 3, 3, 7}, nil)
+	if len(runs) != 3 {
+		fmt.Println(runs)
+		t.Fail()
+	}
+	if !slicesEqual(runs[1], []int{3, 4}) {
+		fmt.Println(runs)
+		t.Fail()
+	}
+}
+
+func TestMergeSortInPlace(t *testing.T) {
+	data := []int{43, 27, 8, 3, 75, 6, 32, 61, 3, 12, 6, 3}
+	sortedData := MergeSortInPlace(data)
+	if !slicesEqual(sortedData, []int{3, 3, 3, 6, 6, 8, 12, 27, 32, 43, 61, 75}) {
+		fmt.Println(sortedData)
+		t.Fail()
+	}
+}
+
+func TestRotate(t *testing.T) {
+	data := []int{1, 2, 3, 4, 5}
+	rotate(data, 2, nil)
+	if !slicesEqual(data, []int{3, 4, 5, 1, 2}) {
+		fmt.Println(data)
+		t.Fail()
+	}
+}
+
+// TestSortsRandom checks every sort against the standard library on random
+// inputs of assorted lengths
+func TestSortsRandom(t *testing.T) {
+	sorts := map[string]func([]int) []int{
+		"RecursiveMergeSort": RecursiveMergeSort,
+		"MergeSort":          MergeSort,
+		"NaturalMergeSort":   NaturalMergeSort,
+		"MergeSortInPlace":   MergeSortInPlace,
+	}
+	g := gen.New(7)
+	for _, n := range []int{0, 1, 2, 3, 17, 64, 100, 1000} {
+		input := g.Uniform(n, 50)
+		expected := append([]int{}, input...)
+		sort.Ints(expected)
+		for name, sorter := range sorts {
+			data := append([]int{}, input...)
+			if !slicesEqual(sorter(data), expected) {
+				t.Errorf("%s failed on input of length %d", name, n)
+			}
+		}
+	}
+}
+
+// Benchmarks
+//
+// Each sort is run on sorted, reverse-sorted, and random input of the same
+// length. The input is copied on every iteration because MergeSort and
+// NaturalMergeSort reuse the memory of the slice they are given.
+
+const benchmarkSize = 10000
+
+func sortedInput(n int) []int {
+	data := make([]int, n)
+	for i := range data {
+		data[i] = i
+	}
+	return data
+}
+
+func reversedInput(n int) []int {
+	data := make([]int, n)
+	for i := range data {
+		data[i] = n - i
+	}
+	return data
+}
+
+func randomInput(n int) []int {
+	return gen.New(42).Uniform(n, n)
+}
+
+func benchmarkSort(b *testing.B, sort func([]int) []int, input []int) {
+	data := make([]int, len(input))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		copy(data, input)
+		sort(data)
+	}
+}
+
+func BenchmarkRecursiveMergeSortSorted(b *testing.B) {
+	benchmarkSort(b, RecursiveMergeSort, sortedInput(benchmarkSize))
+}
+
+func BenchmarkRecursiveMergeSortReversed(b *testing.B) {
+	benchmarkSort(b, RecursiveMergeSort, reversedInput(benchmarkSize))
+}
+
+func BenchmarkRecursiveMergeSortRandom(b *testing.B) {
+	benchmarkSort(b, RecursiveMergeSort, randomInput(benchmarkSize))
+}
+
+func BenchmarkMergeSortSorted(b *testing.B) {
+	benchmarkSort(b, MergeSort, sortedInput(benchmarkSize))
+}
+
+func BenchmarkMergeSortReversed(b *testing.B) {
+	benchmarkSort(b, MergeSort, reversedInput(benchmarkSize))
+}
+
+func BenchmarkMergeSortRandom(b *testing.B) {
+	benchmarkSort(b, MergeSort, randomInput(benchmarkSize))
+}
+
+func BenchmarkNaturalMergeSortSorted(b *testing.B) {
+	benchmarkSort(b, NaturalMergeSort, sortedInput(benchmarkSize))
+}
+
+func BenchmarkNaturalMergeSortReversed(b *testing.B) {
+	benchmarkSort(b, NaturalMergeSort, reversedInput(benchmarkSize))
+}
+
+func BenchmarkNaturalMergeSortRandom(b *testing.B) {
+	benchmarkSort(b, NaturalMergeSort, randomInput(benchmarkSize))
+}
+
+func BenchmarkMergeSortInPlaceSorted(b *testing.B) {
+	benchmarkSort(b, MergeSortInPlace, sortedInput(benchmarkSize))
+}
+
+func BenchmarkMergeSortInPlaceReversed(b *testing.B) {
+	benchmarkSort(b, MergeSortInPlace, reversedInput(benchmarkSize))
+}
+
+func BenchmarkMergeSortInPlaceRandom(b *testing.B) {
+	benchmarkSort(b, MergeSortInPlace, randomInput(benchmarkSize))
+}
+
+func TestInstrumentedSort(t *testing.T) {
+	for _, alg := range Algorithms {
+		sorted, stats := InstrumentedSort(alg, randomInput(100))
+		if !sort.IntsAreSorted(sorted) {
+			t.Errorf("%s did not sort its input", alg)
+		}
+		if stats.Comparisons == 0 || stats.Moves == 0 {
+			t.Errorf("%s reported no work: %+v", alg, stats)
+		}
+	}
+
+	// Sorted input is a single run, so the natural sort does n-1 comparisons
+	// and never moves anything
+	_, stats := InstrumentedSort(Natural, sortedInput(100))
+	if stats.Comparisons != 99 || stats.Moves != 0 {
+		t.Errorf("unexpected natural sort stats on sorted input: %+v", stats)
+	}
+
+	// Merging two slices of length 1 takes one comparison and two moves
+	_, stats = InstrumentedSort(TopDown, []int{2, 1})
+	if stats.Comparisons != 1 || stats.Moves != 2 {
+		t.Errorf("unexpected top-down stats: %+v", stats)
+	}
+}
@@ -0,0 +1,85 @@
+package mergesort
+
+import "fmt"
+
+// Stats records the work done by a sort. Comparisons counts every comparison
+// between two elements, and Moves counts every element written to a new
+// position (so a swap counts as two moves).
+//
+// Comparing these counts across algorithms on the same input is a useful way
+// to see the asymptotic differences described at the top of this package. For
+// example, NaturalMergeSort does n-1 comparisons and no merging on sorted
+// input, while MergeSortInPlace does fewer comparisons but many more moves
+// than the other sorts on random input.
+type Stats struct {
+	Comparisons int
+	Moves       int
+}
+
+// less compares two elements, counting the comparison. A nil *Stats counts
+// nothing, which lets the uninstrumented sorts share the same code.
+func (stats *Stats) less(a, b int) bool {
+	if stats != nil {
+		stats.Comparisons++
+	}
+	return a < b
+}
+
+// move records that *n* elements were written to new positions
+func (stats *Stats) move(n int) {
+	if stats != nil {
+		stats.Moves += n
+	}
+}
+
+// swap records an exchange of two elements
+func (stats *Stats) swap() {
+	stats.move(2)
+}
+
+// Algorithm identifies one of the sorts in this package
+type Algorithm int
+
+const (
+	TopDown Algorithm = iota
+	BottomUp
+	Natural
+	InPlace
+)
+
+// Algorithms lists every Algorithm, for iterating over them in comparisons
+var Algorithms = []Algorithm{TopDown, BottomUp, Natural, InPlace}
+
+func (alg Algorithm) String() string {
+	switch alg {
+	case TopDown:
+		return "RecursiveMergeSort"
+	case BottomUp:
+		return "MergeSort"
+	case Natural:
+		return "NaturalMergeSort"
+	case InPlace:
+		return "MergeSortInPlace"
+	}
+	return fmt.Sprintf("Algorithm(%d)", int(alg))
+}
+
+// InstrumentedSort sorts *sortable* using *alg*, and returns the sorted slice
+// along with the number of comparisons and moves performed
+func InstrumentedSort(alg Algorithm, sortable []int) ([]int, Stats) {
+	var stats Stats
+	var sorted []int
+	switch alg {
+	case TopDown:
+		sorted = recursiveMergeSort(sortable, &stats)
+	case BottomUp:
+		sorted = mergeSort(sortable, &stats)
+	case Natural:
+		sorted = naturalMergeSort(sortable, &stats)
+	case InPlace:
+		sorted = mergeSortInPlace(sortable, &stats)
+	default:
+		panic("unknown sort algorithm: " + alg.String())
+	}
+	return sorted, stats
+}
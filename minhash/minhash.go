@@ -0,0 +1,162 @@
+/*
+ * Package minhash estimates the similarity of sets from small fixed-size
+ * signatures, and finds similar sets by locality-sensitive hashing.
+ *
+ * The Jaccard similarity of two sets is |A ∩ B| / |A ∪ B|. Computing it
+ * exactly needs both sets, but MinHash (Broder, 1997) estimates it from a
+ * signature of k numbers per set. For a random hash function h, the element
+ * of A ∪ B with the smallest hash is equally likely to be any of them, and
+ * it lies in both sets with probability equal to their Jaccard similarity.
+ * That is exactly when min h(A) = min h(B). A signature records the minimum
+ * hash under each of k hash functions, and the fraction of positions at which
+ * two signatures agree estimates the similarity, with a standard error of
+ * about 1/sqrt(k).
+ *
+ *    A = {a, b, c, d}, B = {b, c, d, e}           J(A, B) = 3/5
+ *    sig(A) = [17  4  9 23 ...]
+ *    sig(B) = [17  4 12 23 ...]   agree in about 3/5 of positions
+ *
+ * Comparing one signature with every other is still O(n). Locality-sensitive
+ * hashing (LSH) splits each signature into b bands of r rows, and files the
+ * set under the hash of each band. Sets that agree on every row of some band
+ * collide, and are reported as candidates. Two sets with similarity s agree
+ * on a band with probability s^r, so they become candidates with probability
+ * 1 - (1 - s^r)^b, an S-shaped curve that rises most steeply near the
+ * threshold (1/b)^(1/r). Pairs well above the threshold are almost always
+ * found, and pairs well below it are rarely compared.
+ */
+
+package minhash
+
+import (
+	"math"
+
+	"github.com/njwilson23/datastructures/hashing"
+)
+
+// Signature is the MinHash signature of a set
+type Signature []uint64
+
+// Hasher computes MinHash signatures with a fixed family of hash functions.
+// Signatures can only be compared if they were made by Hashers with the same
+// size and seed.
+type Hasher struct {
+	seeds []uint64
+}
+
+// New creates a Hasher that makes signatures of *k* values, using the hash
+// functions chosen by *seed*
+func New(k int, seed uint64) *Hasher {
+	seeds := make([]uint64, k)
+	for i := range seeds {
+		seeds[i] = hashing.Mix(seed + uint64(i))
+	}
+	return &Hasher{seeds}
+}
+
+// Size returns the number of values in a signature
+func (h *Hasher) Size() int {
+	return len(h.seeds)
+}
+
+// Sign returns the signature of the set of strings *set*. Repeated elements
+// do not change the signature.
+func (h *Hasher) Sign(set []string) Signature {
+	hashes := make([]uint64, len(set))
+	for i, s := range set {
+		hashes[i] = hashing.String(s)
+	}
+	return h.SignHashes(hashes)
+}
+
+// SignHashes returns the signature of a set whose elements have already been
+// hashed to *hashes*
+func (h *Hasher) SignHashes(hashes []uint64) Signature {
+	sig := make(Signature, len(h.seeds))
+	for i := range sig {
+		sig[i] = math.MaxUint64
+	}
+	for _, x := range hashes {
+		for i, seed := range h.seeds {
+			if v := hashing.Seeded(x, seed); v < sig[i] {
+				sig[i] = v
+			}
+		}
+	}
+	return sig
+}
+
+// EstimateJaccard estimates the Jaccard similarity of the sets with
+// signatures *a* and *b*, which must have the same size
+func EstimateJaccard(a, b Signature) float64 {
+	if len(a) != len(b) {
+		panic("minhash: signatures have different sizes")
+	}
+	if len(a) == 0 {
+		return 0
+	}
+	equal := 0
+	for i := range a {
+		if a[i] == b[i] {
+			equal++
+		}
+	}
+	return float64(equal) / float64(len(a))
+}
+
+// LSH is an index of signatures that finds those similar to a query
+type LSH struct {
+	bands, rows int
+	tables      []map[uint64][]int // bucket key -> IDs, for each band
+}
+
+// NewLSH creates an index for signatures of *bands* × *rows* values
+func NewLSH(bands, rows int) *LSH {
+	tables := make([]map[uint64][]int, bands)
+	for i := range tables {
+		tables[i] = make(map[uint64][]int)
+	}
+	return &LSH{bands, rows, tables}
+}
+
+// Threshold returns the similarity at which two sets are about as likely to
+// be reported as candidates as not
+func (l *LSH) Threshold() float64 {
+	return math.Pow(1/float64(l.bands), 1/float64(l.rows))
+}
+
+// band returns the bucket key of band *i* of a signature
+func (l *LSH) band(sig Signature, i int) uint64 {
+	if len(sig) != l.bands*l.rows {
+		panic("minhash: signature does not match the bands of the index")
+	}
+	h := uint64(i)
+	for _, v := range sig[i*l.rows : (i+1)*l.rows] {
+		h = hashing.Mix(h ^ v)
+	}
+	return h
+}
+
+// Add adds the signature of the set identified by *id* to the index
+func (l *LSH) Add(id int, sig Signature) {
+	for i, table := range l.tables {
+		key := l.band(sig, i)
+		table[key] = append(table[key], id)
+	}
+}
+
+// Candidates returns the IDs of the sets that share at least one band with
+// *sig*, each once, in the order they were first found
+func (l *LSH) Candidates(sig Signature) []int {
+	var ids []int
+	seen := make(map[int]bool)
+	for i, table := range l.tables {
+		for _, id := range table[l.band(sig, i)] {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
@@ -0,0 +1,66 @@
+package minhash
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+// set returns the strings "0" ... "n-1", starting from *from*
+func set(from, n int) []string {
+	s := make([]string, n)
+	for i := range s {
+		s[i] = fmt.Sprint(from + i)
+	}
+	return s
+}
+
+func TestEstimateJaccard(t *testing.T) {
+	h := New(400, 1)
+	for _, overlap := range []int{0, 25, 50, 75, 100} {
+		a, b := set(0, 100), set(100-overlap, 100)
+		expected := float64(overlap) / float64(200-overlap)
+		estimate := EstimateJaccard(h.Sign(a), h.Sign(b))
+		// The standard error is at most 1/sqrt(400) = 0.05
+		if math.Abs(estimate-expected) > 0.1 {
+			t.Errorf("overlap %d: estimated %v, expected %v", overlap, estimate, expected)
+		}
+	}
+}
+
+func TestSignDuplicates(t *testing.T) {
+	h := New(16, 1)
+	if EstimateJaccard(h.Sign([]string{"a", "b"}), h.Sign([]string{"b", "a", "a"})) != 1 {
+		t.Error("signatures differ")
+	}
+	if EstimateJaccard(New(16, 1).Sign([]string{"a"}), New(16, 2).Sign([]string{"a"})) == 1 {
+		t.Error("seed does not change the hash functions")
+	}
+}
+
+func TestLSH(t *testing.T) {
+	const bands, rows = 20, 5
+	h := New(bands*rows, 1)
+	index := NewLSH(bands, rows)
+	if th := index.Threshold(); th < 0.5 || th > 0.6 {
+		t.Error(th)
+	}
+
+	// Disjoint sets of 100 elements, except for set 100, which shares 80 of
+	// its elements with set 42 (a similarity of 2/3)
+	for i := 0; i != 100; i++ {
+		index.Add(i, h.Sign(set(i*100, 100)))
+	}
+	index.Add(100, h.Sign(set(4220, 100)))
+
+	// The query has a similarity of 0.9 with set 42, 0.74 with set 100, and
+	// 0.03 with set 43
+	candidates := index.Candidates(h.Sign(set(4205, 100)))
+	found := map[int]bool{}
+	for _, id := range candidates {
+		found[id] = true
+	}
+	if !found[42] || !found[100] || len(candidates) > 3 {
+		t.Error(candidates)
+	}
+}
@@ -0,0 +1,93 @@
+/*
+ * Package observe provides change notification for the containers in this
+ * repository.
+ *
+ * A container that supports observation embeds a Subject, and calls Notify
+ * after each mutation. Callers Subscribe a callback to receive an Event
+ * describing the change, which can be used e.g. to invalidate a cache built
+ * on top of the container. Callbacks are run synchronously, in the order they
+ * were subscribed, by the goroutine that mutated the container, so they should
+ * be quick and must not mutate the container themselves. Channel adapts a
+ * subscription into a channel for consumers that would rather receive events
+ * asynchronously.
+ */
+
+package observe
+
+// Op identifies the kind of mutation an Event describes
+type Op int
+
+const (
+	Put Op = iota
+	Delete
+)
+
+func (op Op) String() string {
+	switch op {
+	case Put:
+		return "Put"
+	case Delete:
+		return "Delete"
+	}
+	return "Unknown"
+}
+
+// Event describes a single mutation of a container. For a Delete, Value is the
+// value that was removed.
+type Event[K comparable, V any] struct {
+	Op    Op
+	Key   K
+	Value V
+}
+
+// Subject keeps track of the callbacks subscribed to a container. The zero
+// value has no subscribers and is ready to use.
+type Subject[K comparable, V any] struct {
+	observers []observer[K, V]
+	nextID    int
+}
+
+type observer[K comparable, V any] struct {
+	id int
+	fn func(Event[K, V])
+}
+
+// Subscribe registers *fn* to be called with every subsequent Event, and
+// returns a function that cancels the subscription
+func (s *Subject[K, V]) Subscribe(fn func(Event[K, V])) (unsubscribe func()) {
+	id := s.nextID
+	s.nextID++
+	s.observers = append(s.observers, observer[K, V]{id, fn})
+	return func() {
+		for i, o := range s.observers {
+			if o.id == id {
+				s.observers = append(s.observers[:i:i], s.observers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Notify calls every subscribed callback with an Event
+func (s *Subject[K, V]) Notify(op Op, key K, value V) {
+	if len(s.observers) == 0 {
+		return
+	}
+	e := Event[K, V]{op, key, value}
+	for _, o := range s.observers {
+		o.fn(e)
+	}
+}
+
+// Channel subscribes to a Subject and delivers events on a channel with the
+// given buffer size. Note that mutations of the container block when the
+// buffer is full, until the events are received. The returned function cancels
+// the subscription and closes the channel.
+func Channel[K comparable, V any](s *Subject[K, V], buffer int) (<-chan Event[K, V], func()) {
+	ch := make(chan Event[K, V], buffer)
+	unsubscribe := s.Subscribe(func(e Event[K, V]) { ch <- e })
+	return ch, func() {
+		unsubscribe()
+		close(ch)
+	}
+}
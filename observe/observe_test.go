@@ -0,0 +1,59 @@
+package observe
+
+import "testing"
+
+func TestSubscribe(t *testing.T) {
+	var s Subject[string, int]
+	var received []Event[string, int]
+	unsubscribe := s.Subscribe(func(e Event[string, int]) {
+		received = append(received, e)
+	})
+
+	s.Notify(Put, "a", 1)
+	s.Notify(Delete, "a", 1)
+	if len(received) != 2 {
+		t.Fatal(received)
+	}
+	if received[0].Op != Put || received[1].Op != Delete || received[1].Key != "a" {
+		t.Fail()
+	}
+
+	unsubscribe()
+	s.Notify(Put, "b", 2)
+	if len(received) != 2 {
+		t.Fail()
+	}
+}
+
+func TestUnsubscribeOrder(t *testing.T) {
+	var s Subject[int, int]
+	var calls []int
+	u1 := s.Subscribe(func(Event[int, int]) { calls = append(calls, 1) })
+	s.Subscribe(func(Event[int, int]) { calls = append(calls, 2) })
+	s.Subscribe(func(Event[int, int]) { calls = append(calls, 3) })
+	u1()
+	s.Notify(Put, 0, 0)
+	if len(calls) != 2 || calls[0] != 2 || calls[1] != 3 {
+		t.Error(calls)
+	}
+}
+
+func TestChannel(t *testing.T) {
+	var s Subject[int, string]
+	ch, cancel := Channel(&s, 2)
+	s.Notify(Put, 1, "one")
+	s.Notify(Put, 2, "two")
+	e := <-ch
+	if e.Key != 1 || e.Value != "one" {
+		t.Fail()
+	}
+	e = <-ch
+	if e.Key != 2 {
+		t.Fail()
+	}
+
+	cancel()
+	if _, ok := <-ch; ok {
+		t.Fail()
+	}
+}
@@ -0,0 +1,42 @@
+/*
+ * Package pair provides small generic tuple types that are shared by the
+ * containers in this repository.
+ *
+ * Several of the containers associate a key with a value (hashtable,
+ * skiplist, rbtree), and they previously each declared their own struct for
+ * this. Using a single KeyValue type means that the entries of one container
+ * can be handed directly to another, e.g. when building a skip-list from the
+ * contents of a hash table.
+ */
+
+package pair
+
+// Pair groups two values of arbitrary types
+type Pair[A, B any] struct {
+	First  A
+	Second B
+}
+
+// KeyValue associates a value with a key
+type KeyValue[K comparable, V any] struct {
+	Key   K
+	Value V
+}
+
+// Keys returns the keys of a slice of KeyValues, in order
+func Keys[K comparable, V any](kvs []KeyValue[K, V]) []K {
+	keys := make([]K, len(kvs))
+	for i, kv := range kvs {
+		keys[i] = kv.Key
+	}
+	return keys
+}
+
+// Values returns the values of a slice of KeyValues, in order
+func Values[K comparable, V any](kvs []KeyValue[K, V]) []V {
+	values := make([]V, len(kvs))
+	for i, kv := range kvs {
+		values[i] = kv.Value
+	}
+	return values
+}
@@ -0,0 +1,15 @@
+package pair
+
+import "testing"
+
+func TestKeysValues(t *testing.T) {
+	kvs := []KeyValue[string, int]{{"a", 1}, {"b", 2}, {"c", 3}}
+	keys := Keys(kvs)
+	values := Values(kvs)
+	if len(keys) != 3 || len(values) != 3 {
+		t.Fail()
+	}
+	if keys[1] != "b" || values[2] != 3 {
+		t.Fail()
+	}
+}
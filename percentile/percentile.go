@@ -0,0 +1,114 @@
+/*
+ * Package percentile tracks percentiles, such as the median and the 99th
+ * percentile, over a sliding window of the most recent values.
+ *
+ * Percentiles of a window that slides cannot be maintained in O(1) space, as
+ * a mean can: when the oldest value leaves, any value may become the new
+ * median. Window keeps the values twice over. A FIFO queue (a queue.Ring)
+ * holds them in arrival order, so that the oldest can be found and expired,
+ * and an order-statistic tree (a red-black tree with subtree sizes, see
+ * rbtree.Select) holds them in sorted order, so that the value of any rank
+ * can be found. Adding, expiring, and querying a percentile are all
+ * O(log n).
+ *
+ * The tree has int keys, so values are stored as the int whose order is the
+ * same as that of the float64. For non-negative floats, the IEEE 754 bit
+ * pattern already sorts as an integer. Negative floats are stored as sign and
+ * magnitude, so their order is reversed, which is undone by flipping every
+ * bit but the sign.
+ */
+
+package percentile
+
+import (
+	"math"
+
+	"github.com/njwilson23/datastructures/queue"
+	"github.com/njwilson23/datastructures/rbtree"
+)
+
+// toKey maps a float64 to an int with the same order
+func toKey(v float64) int {
+	b := int64(math.Float64bits(v))
+	if b < 0 {
+		b ^= math.MaxInt64
+	}
+	return int(b)
+}
+
+// fromKey is the inverse of toKey
+func fromKey(k int) float64 {
+	b := int64(k)
+	if b < 0 {
+		b ^= math.MaxInt64
+	}
+	return math.Float64frombits(uint64(b))
+}
+
+// Window holds recent values and finds their percentiles
+type Window struct {
+	size   int
+	values queue.Ring[float64]
+	sorted *rbtree.RedBlackTree
+}
+
+// New creates an empty Window of the *size* most recent values. If *size* is
+// 0, values are kept until they are expired with Expire.
+func New(size int) *Window {
+	if size < 0 {
+		panic("percentile: negative window size")
+	}
+	return &Window{size: size, sorted: rbtree.New()}
+}
+
+// Len returns the number of values in the window
+func (w *Window) Len() int {
+	return w.values.Len()
+}
+
+// Add adds a value to the window, expiring the oldest value if the window is
+// full. NaN values are ignored.
+func (w *Window) Add(v float64) {
+	if math.IsNaN(v) {
+		return
+	}
+	w.values.Push(v)
+	w.sorted.Insert(toKey(v))
+	if w.size != 0 && w.values.Len() > w.size {
+		w.Expire()
+	}
+}
+
+// Expire removes and returns the oldest value, or returns false if the window
+// is empty
+func (w *Window) Expire() (float64, bool) {
+	v, err := w.values.Pop()
+	if err != nil {
+		return 0, false
+	}
+	w.sorted.Delete(toKey(v))
+	return v, true
+}
+
+// Quantile returns the smallest value in the window that is at least as large
+// as a fraction *q* of the values (the "nearest rank" definition), such as
+// 0.95 for the 95th percentile. It returns false if the window is empty.
+func (w *Window) Quantile(q float64) (float64, bool) {
+	n := w.values.Len()
+	if n == 0 {
+		return 0, false
+	}
+	rank := int(math.Ceil(q*float64(n))) - 1
+	if rank < 0 {
+		rank = 0
+	} else if rank >= n {
+		rank = n - 1
+	}
+	k, _ := w.sorted.Select(rank)
+	return fromKey(k), true
+}
+
+// Median returns the median value, or false if the window is empty
+func (w *Window) Median() (float64, bool) {
+	return w.Quantile(0.5)
+}
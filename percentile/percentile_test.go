@@ -0,0 +1,86 @@
+package percentile
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestKeyOrder(t *testing.T) {
+	values := []float64{math.Inf(-1), -1e300, -2.5, -1, -1e-300, math.Copysign(0, -1), 0, 1e-300, 1, 2.5, 1e300, math.Inf(1)}
+	for i, v := range values {
+		if fromKey(toKey(v)) != v {
+			t.Error("round trip", v)
+		}
+		if i != 0 && toKey(values[i-1]) >= toKey(v) {
+			t.Error(values[i-1], "not below", v)
+		}
+	}
+}
+
+func TestQuantile(t *testing.T) {
+	w := New(0)
+	if _, ok := w.Median(); ok {
+		t.Error("median of an empty window")
+	}
+	for v := 1; v <= 100; v++ {
+		w.Add(float64(v))
+	}
+	for q, expected := range map[float64]float64{0: 1, 0.5: 50, 0.95: 95, 0.99: 99, 1: 100} {
+		if v, _ := w.Quantile(q); v != expected {
+			t.Errorf("q=%v: %v, expected %v", q, v, expected)
+		}
+	}
+	if v, ok := w.Expire(); !ok || v != 1 {
+		t.Error(v, ok)
+	}
+	if v, _ := w.Quantile(0); v != 2 {
+		t.Error(v)
+	}
+}
+
+func TestSlidingWindow(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	const size = 200
+	w := New(size)
+	var all []float64
+	for i := 0; i != 2000; i++ {
+		// Values repeat, and drift upwards over time
+		v := float64(r.Intn(100) + i/10)
+		if r.Intn(10) == 0 {
+			v = -v
+		}
+		w.Add(v)
+		all = append(all, v)
+
+		recent := append([]float64{}, all[max(0, len(all)-size):]...)
+		sort.Float64s(recent)
+		if w.Len() != len(recent) {
+			t.Fatal(w.Len(), len(recent))
+		}
+		for _, q := range []float64{0.5, 0.95, 0.99} {
+			expected := recent[int(math.Ceil(q*float64(len(recent))))-1]
+			if v, _ := w.Quantile(q); v != expected {
+				t.Fatalf("step %d, q=%v: %v, expected %v", i, q, v, expected)
+			}
+		}
+	}
+}
+
+func TestNaN(t *testing.T) {
+	w := New(10)
+	w.Add(math.NaN())
+	if w.Len() != 0 {
+		t.Error(w.Len())
+	}
+}
+
+func BenchmarkAdd(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	w := New(10000)
+	for i := 0; i < b.N; i++ {
+		w.Add(r.ExpFloat64())
+		w.Quantile(0.99)
+	}
+}
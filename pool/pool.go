@@ -0,0 +1,147 @@
+/*
+ * Package pool implements a typed pool of reusable objects, with hooks to
+ * reset, validate and dispose of them, and a limit on how many are kept.
+ *
+ * sync.Pool is the standard way to recycle objects in Go, but it gives no
+ * control over what it keeps: its contents may be dropped at any garbage
+ * collection, it cannot be bounded, and objects come back exactly as they
+ * were put. That suits scratch buffers, but not objects that are expensive
+ * to create (whose loss at every collection defeats the purpose), objects
+ * holding resources that must be released when dropped, or objects that can
+ * go stale while idle, such as connections.
+ *
+ * A Pool keeps idle objects on a stack, so the most recently used object,
+ * which is the most likely to still be in the processor's cache, is reused
+ * first. Its hooks run at fixed points in an object's life:
+ *
+ *    Get:  pop an idle object, Check it (Discard it and retry if it fails),
+ *          or call New if none are idle
+ *    Put:  Reset the object, then keep it, or Discard it if MaxIdle objects
+ *          are already idle
+ *
+ * A single mutex guards the stack, which is cheap when uncontended but, unlike
+ * sync.Pool's per-processor caches, serializes goroutines that use the pool
+ * heavily at the same time.
+ */
+
+package pool
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Pool is a set of idle objects of type T that are safe to reuse. The zero
+// value is an unbounded Pool that creates zero values of T. A Pool is safe
+// for concurrent use, but its fields must not be changed once it is in use.
+type Pool[T any] struct {
+	// New creates an object when none are idle; if nil, Get returns the zero
+	// value of T
+	New func() T
+	// Reset, if not nil, prepares an object for reuse when it is Put
+	Reset func(T)
+	// Check, if not nil, reports whether an idle object can still be used.
+	// Objects failing the check are discarded by Get.
+	Check func(T) bool
+	// Discard, if not nil, releases an object that the pool drops
+	Discard func(T)
+	// MaxIdle is the largest number of idle objects kept, or unlimited if
+	// zero
+	MaxIdle int
+
+	mu   sync.Mutex
+	idle []T
+
+	created, reused, discarded atomic.Int64
+}
+
+// Stats counts what a Pool has done with its objects
+type Stats struct {
+	Created   int // by New
+	Reused    int // returned by Get from the idle objects
+	Discarded int // failed Check, exceeded MaxIdle, or removed by Clear
+}
+
+// Get returns an idle object, or a new one if none are idle
+func (p *Pool[T]) Get() T {
+	for {
+		x, ok := p.pop()
+		if !ok {
+			break
+		}
+		// The hooks run without the lock, so they may be slow or use the pool
+		if p.Check == nil || p.Check(x) {
+			p.reused.Add(1)
+			return x
+		}
+		p.discard(x)
+	}
+	p.created.Add(1)
+	if p.New == nil {
+		var zero T
+		return zero
+	}
+	return p.New()
+}
+
+// pop removes the most recently idle object, or returns false if there are
+// none
+func (p *Pool[T]) pop() (T, bool) {
+	var zero T
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.idle) == 0 {
+		return zero, false
+	}
+	x := p.idle[len(p.idle)-1]
+	// Clear the slot, so that the pool holds no reference to the object
+	p.idle[len(p.idle)-1] = zero
+	p.idle = p.idle[:len(p.idle)-1]
+	return x, true
+}
+
+// Put returns an object to the pool
+func (p *Pool[T]) Put(x T) {
+	if p.Reset != nil {
+		p.Reset(x)
+	}
+	p.mu.Lock()
+	if p.MaxIdle == 0 || len(p.idle) < p.MaxIdle {
+		p.idle = append(p.idle, x)
+		p.mu.Unlock()
+		return
+	}
+	p.mu.Unlock()
+	p.discard(x)
+}
+
+func (p *Pool[T]) discard(x T) {
+	p.discarded.Add(1)
+	if p.Discard != nil {
+		p.Discard(x)
+	}
+}
+
+// Idle returns the number of idle objects in the pool
+func (p *Pool[T]) Idle() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.idle)
+}
+
+// Stats returns counts of the objects the pool has created, reused and
+// discarded
+func (p *Pool[T]) Stats() Stats {
+	return Stats{int(p.created.Load()), int(p.reused.Load()), int(p.discarded.Load())}
+}
+
+// Clear discards every idle object
+func (p *Pool[T]) Clear() {
+	p.mu.Lock()
+	idle := p.idle
+	p.idle = nil
+	p.mu.Unlock()
+	for _, x := range idle {
+		p.discard(x)
+	}
+}
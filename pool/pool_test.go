@@ -0,0 +1,150 @@
+package pool
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+)
+
+type conn struct {
+	id     int
+	closed bool
+	buf    []byte
+}
+
+func TestHooks(t *testing.T) {
+	next := 0
+	var discarded []int
+	p := &Pool[*conn]{
+		New: func() *conn {
+			next++
+			return &conn{id: next}
+		},
+		Reset:   func(c *conn) { c.buf = c.buf[:0] },
+		Check:   func(c *conn) bool { return !c.closed },
+		Discard: func(c *conn) { discarded = append(discarded, c.id) },
+		MaxIdle: 2,
+	}
+	a, b, c := p.Get(), p.Get(), p.Get()
+	a.buf = append(a.buf, "data"...)
+	p.Put(a)
+	p.Put(b)
+	p.Put(c) // over MaxIdle
+	if p.Idle() != 2 || len(discarded) != 1 || discarded[0] != 3 {
+		t.Fatal(p.Idle(), discarded)
+	}
+	// The most recently put object comes back first
+	b.closed = true
+	if x := p.Get(); x != a || len(x.buf) != 0 {
+		t.Error("expected a reset a", x.id, x.buf)
+	}
+	// b fails its check and a new object is created
+	if x := p.Get(); x.id != 4 || len(discarded) != 2 || discarded[1] != 2 {
+		t.Error(x.id, discarded)
+	}
+	if stats := p.Stats(); stats != (Stats{Created: 4, Reused: 1, Discarded: 2}) {
+		t.Error(stats)
+	}
+	p.Put(a)
+	p.Clear()
+	if p.Idle() != 0 || len(discarded) != 3 || p.Stats().Discarded != 3 {
+		t.Error(p.Idle(), discarded)
+	}
+}
+
+func TestZeroValue(t *testing.T) {
+	var p Pool[int]
+	if p.Get() != 0 {
+		t.Fail()
+	}
+	for i := 0; i != 100; i++ {
+		p.Put(i)
+	}
+	if p.Idle() != 100 || p.Get() != 99 {
+		t.Error(p.Idle())
+	}
+}
+
+func TestConcurrent(t *testing.T) {
+	p := &Pool[*bytes.Buffer]{
+		New:     func() *bytes.Buffer { return new(bytes.Buffer) },
+		Reset:   func(b *bytes.Buffer) { b.Reset() },
+		MaxIdle: 4,
+	}
+	var wg sync.WaitGroup
+	for g := 0; g != 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i != 1000; i++ {
+				b := p.Get()
+				if b.Len() != 0 {
+					t.Error("buffer not reset")
+				}
+				b.WriteString("x")
+				p.Put(b)
+			}
+		}()
+	}
+	wg.Wait()
+	stats := p.Stats()
+	if stats.Created+stats.Reused != 8000 || stats.Created-stats.Discarded != p.Idle() {
+		t.Error(stats, p.Idle())
+	}
+}
+
+type treeNode struct {
+	left, right *treeNode
+	key         int
+}
+
+// Benchmarks recycling the nodes of a small binary tree, which is built and
+// torn down repeatedly, against sync.Pool and plain allocation
+//
+// Each Get and Put takes the mutex, which makes Pool about 1.7x slower than
+// sync.Pool, and both are slower per operation than allocating, which is very
+// cheap in Go. What recycling saves is the garbage collector's work, which
+// this benchmark barely measures but which dominates in programs that churn
+// through many nodes on a large heap.
+func BenchmarkPool(b *testing.B) {
+	const nodes = 64
+	b.Run("Pool", func(b *testing.B) {
+		p := &Pool[*treeNode]{
+			New:   func() *treeNode { return new(treeNode) },
+			Reset: func(n *treeNode) { *n = treeNode{} },
+		}
+		b.ReportAllocs()
+		var batch [nodes]*treeNode
+		for i := 0; i < b.N; i++ {
+			for j := range batch {
+				batch[j] = p.Get()
+			}
+			for _, n := range batch {
+				p.Put(n)
+			}
+		}
+	})
+	b.Run("sync.Pool", func(b *testing.B) {
+		p := sync.Pool{New: func() any { return new(treeNode) }}
+		b.ReportAllocs()
+		var batch [nodes]*treeNode
+		for i := 0; i < b.N; i++ {
+			for j := range batch {
+				batch[j] = p.Get().(*treeNode)
+			}
+			for _, n := range batch {
+				*n = treeNode{}
+				p.Put(n)
+			}
+		}
+	})
+	b.Run("new", func(b *testing.B) {
+		b.ReportAllocs()
+		var batch [nodes]*treeNode
+		for i := 0; i < b.N; i++ {
+			for j := range batch {
+				batch[j] = new(treeNode)
+			}
+		}
+	})
+}
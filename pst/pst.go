@@ -0,0 +1,160 @@
+/*
+ * Package pst implements a priority search tree, which finds the points in a
+ * region bounded on three sides: x in [x1, x2] and y >= y1.
+ *
+ * A binary search tree on x can find the points with x in a range, and a max
+ * heap on y can find the points with y above a threshold, each in O(log n +
+ * k) for k results. A priority search tree (McCreight, 1985) is both at once.
+ * Its root holds the point with the largest y, and the remaining points are
+ * split at their median x into the left and right subtrees, which are built
+ * the same way:
+ *
+ *    points (x, y): (1,3) (2,8) (4,1) (5,6) (7,4) (8,5) (9,2)
+ *
+ *                    (2,8)  split 5
+ *                   /              \
+ *          (5,6)  split 1      (8,5)  split 7
+ *          /         \          /        \
+ *       (1,3)      (4,1)     (7,4)     (9,2)
+ *
+ * A query walks down the search paths for x1 and x2 like a BST range query,
+ * but stops at any node whose y is below y1, since by the heap order every
+ * point below it is lower still. Every node visited off the two search paths
+ * is either reported or is the child of a reported node, so a query costs
+ * O(log n + k).
+ *
+ * Such "1.5-dimensional" queries come up often. For example, the intervals
+ * [lo, hi] containing q are the points (lo, hi) with lo <= q and hi >= q, and
+ * the events in a time window with at least a given priority are the points
+ * (time, priority) in a three-sided region.
+ *
+ * The tree here is static: it is built in O(n log n) from a fixed set of
+ * points. A dynamic version stores the points on the nodes of a balanced
+ * search tree and restores the heap order after each rotation.
+ */
+
+package pst
+
+import (
+	"math"
+	"sort"
+)
+
+// Point is a point in the plane
+type Point struct {
+	X, Y int
+}
+
+type node struct {
+	point       Point
+	split       int // points on the left have x <= split, and on the right x >= split
+	left, right *node
+}
+
+// Tree is a priority search tree
+type Tree struct {
+	root *node
+	len  int
+}
+
+// New builds a Tree holding *points*, which may include duplicates
+func New(points []Point) *Tree {
+	sorted := make([]Point, len(points))
+	copy(sorted, points)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].X < sorted[j].X })
+	return &Tree{build(sorted), len(points)}
+}
+
+// build builds a subtree from points sorted by x, reordering them in place
+func build(points []Point) *node {
+	if len(points) == 0 {
+		return nil
+	}
+	top := 0
+	for i, p := range points {
+		if p.Y > points[top].Y {
+			top = i
+		}
+	}
+	n := &node{point: points[top]}
+	// Close the gap left by the top point, keeping the rest in order
+	copy(points[top:], points[top+1:])
+	rest := points[:len(points)-1]
+	if len(rest) == 0 {
+		return n
+	}
+	mid := (len(rest) - 1) / 2
+	n.split = rest[mid].X
+	n.left = build(rest[:mid+1])
+	n.right = build(rest[mid+1:])
+	return n
+}
+
+// Len returns the number of points in the tree
+func (t *Tree) Len() int {
+	return t.len
+}
+
+// Query returns the points with x in [*x1*, *x2*] and y >= *y1*, in no
+// particular order
+func (t *Tree) Query(x1, x2, y1 int) []Point {
+	var points []Point
+	t.Visit(x1, x2, y1, func(p Point) bool {
+		points = append(points, p)
+		return true
+	})
+	return points
+}
+
+// Visit calls *f* with each point with x in [*x1*, *x2*] and y >= *y1*, in
+// no particular order, until *f* returns false
+func (t *Tree) Visit(x1, x2, y1 int, f func(Point) bool) {
+	if x1 <= x2 {
+		visit(t.root, x1, x2, y1, f)
+	}
+}
+
+func visit(n *node, x1, x2, y1 int, f func(Point) bool) bool {
+	if n == nil || n.point.Y < y1 {
+		return true
+	}
+	if x1 <= n.point.X && n.point.X <= x2 && !f(n.point) {
+		return false
+	}
+	if x1 <= n.split && !visit(n.left, x1, x2, y1, f) {
+		return false
+	}
+	if n.split <= x2 && !visit(n.right, x1, x2, y1, f) {
+		return false
+	}
+	return true
+}
+
+// Highest returns the point with the largest y among those with x in [*x1*,
+// *x2*], or false if there are none. It costs O(log n).
+func (t *Tree) Highest(x1, x2 int) (Point, bool) {
+	best := Point{Y: math.MinInt}
+	found := false
+	var search func(n *node)
+	search = func(n *node) {
+		// Every point below n is no higher than n, so once n is no higher
+		// than the best point so far, the subtree can be skipped
+		if n == nil || found && n.point.Y <= best.Y {
+			return
+		}
+		if x1 <= n.point.X && n.point.X <= x2 {
+			best, found = n.point, true
+			return
+		}
+		if x1 <= n.split {
+			search(n.left)
+		}
+		if n.split <= x2 {
+			search(n.right)
+		}
+	}
+	if x1 <= x2 {
+		search(t.root)
+	}
+	return best, found
+}
@@ -0,0 +1,123 @@
+package pst
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func sortPoints(points []Point) {
+	sort.Slice(points, func(i, j int) bool {
+		if points[i].X != points[j].X {
+			return points[i].X < points[j].X
+		}
+		return points[i].Y < points[j].Y
+	})
+}
+
+func pointsEqual(a, b []Point) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	sortPoints(a)
+	sortPoints(b)
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestQuery(t *testing.T) {
+	tree := New([]Point{{1, 3}, {2, 8}, {4, 1}, {5, 6}, {7, 4}, {8, 5}, {9, 2}})
+	if tree.root.point != (Point{2, 8}) || tree.root.split != 5 {
+		t.Error("root", tree.root)
+	}
+	points := tree.Query(3, 8, 4)
+	if !pointsEqual(points, []Point{{5, 6}, {7, 4}, {8, 5}}) {
+		t.Error(points)
+	}
+	if points := tree.Query(3, 8, 9); len(points) != 0 {
+		t.Error(points)
+	}
+	if points := tree.Query(8, 3, 0); len(points) != 0 {
+		t.Error(points)
+	}
+	if points := New(nil).Query(0, 10, 0); len(points) != 0 {
+		t.Error(points)
+	}
+}
+
+func TestQueryRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	points := make([]Point, 500)
+	for i := range points {
+		// A small range of x gives many duplicates
+		points[i] = Point{r.Intn(50), r.Intn(1000)}
+	}
+	tree := New(points)
+	if tree.Len() != len(points) {
+		t.Error(tree.Len())
+	}
+	for i := 0; i != 200; i++ {
+		x1, x2, y1 := r.Intn(60)-5, r.Intn(60)-5, r.Intn(1000)
+		var expected []Point
+		for _, p := range points {
+			if x1 <= p.X && p.X <= x2 && p.Y >= y1 {
+				expected = append(expected, p)
+			}
+		}
+		if found := tree.Query(x1, x2, y1); !pointsEqual(found, expected) {
+			t.Fatalf("[%d, %d] x [%d, inf): found %v, expected %v", x1, x2, y1, found, expected)
+		}
+
+		highest, ok := tree.Highest(x1, x2)
+		best, exists := Point{}, false
+		for _, p := range points {
+			if x1 <= p.X && p.X <= x2 && (!exists || p.Y > best.Y) {
+				best, exists = p, true
+			}
+		}
+		if ok != exists || ok && highest.Y != best.Y {
+			t.Fatalf("[%d, %d]: highest %v %v, expected %v %v", x1, x2, highest, ok, best, exists)
+		}
+	}
+}
+
+func TestVisitStop(t *testing.T) {
+	tree := New([]Point{{1, 1}, {2, 2}, {3, 3}, {4, 4}})
+	count := 0
+	tree.Visit(0, 5, 0, func(Point) bool {
+		count++
+		return count < 2
+	})
+	if count != 2 {
+		t.Error(count)
+	}
+}
+
+func TestStabbing(t *testing.T) {
+	// The intervals [lo, hi] containing q are the points with lo <= q <= hi
+	intervals := []Point{{0, 10}, {2, 4}, {3, 8}, {9, 12}, {5, 5}}
+	tree := New(intervals)
+	found := tree.Query(math.MinInt, 5, 5)
+	if !pointsEqual(found, []Point{{0, 10}, {3, 8}, {5, 5}}) {
+		t.Error(found)
+	}
+}
+
+func BenchmarkQuery(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	points := make([]Point, 100000)
+	for i := range points {
+		points[i] = Point{r.Intn(1 << 20), r.Intn(1 << 20)}
+	}
+	tree := New(points)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		x := r.Intn(1 << 20)
+		tree.Query(x, x+1<<16, 1<<20-1<<12)
+	}
+}
@@ -0,0 +1,107 @@
+package queue
+
+// Fair queueing
+//
+// When several classes of work share one server, such as tenants sharing a
+// worker pool, a plain FIFO lets a class that submits a burst delay everyone
+// else, and strict priorities let a busy high-priority class starve the
+// rest. Weighted fair queueing (Demers, Keshav and Shenker, 1989) instead
+// gives each class a share of the service in proportion to its weight,
+// whenever it has work waiting.
+//
+// Each item is stamped with a "virtual finish time" when it arrives, the time
+// at which it would finish if every class were served at its share:
+//
+//    finish = max(now, finish of the previous item of its class) + cost / weight
+//
+// and items are served in order of finish time. A class with twice the
+// weight stamps its items twice as close together, so it is served twice as
+// often. Here "now" is the finish time of the last item served (the
+// self-clocked variant of Golestani, 1994), so a class that was idle starts
+// level with the classes being served, rather than with credit for the time
+// it was idle.
+//
+// Items within a class are served in FIFO order, so only the item at the
+// front of each class competes. The classes are held in an indexed min-heap
+// (see heap.Indexed) keyed by the finish time of their front item, and each
+// class's items wait in a Ring. Serving an item costs O(log n) for n classes.
+
+import "github.com/njwilson23/datastructures/heap"
+
+type stamped[T any] struct {
+	item   T
+	finish float64
+}
+
+// Fair is a queue that serves classes of items in proportion to their weights
+type Fair[T any] struct {
+	weights []float64
+	classes []Ring[stamped[T]]
+	last    []float64 // finish time of the last item pushed to each class
+	heads   *heap.Indexed[int]
+	now     float64
+	len     int
+}
+
+// NewFair creates an empty Fair queue with one class for each of *weights*,
+// which must be positive
+func NewFair[T any](weights ...float64) *Fair[T] {
+	for _, w := range weights {
+		if !(w > 0) {
+			panic("queue: class weights must be positive")
+		}
+	}
+	return &Fair[T]{
+		weights: weights,
+		classes: make([]Ring[stamped[T]], len(weights)),
+		last:    make([]float64, len(weights)),
+		heads:   heap.NewIndexed[int](),
+	}
+}
+
+// Len returns the number of items waiting in every class
+func (q *Fair[T]) Len() int {
+	return q.len
+}
+
+// ClassLen returns the number of items waiting in class *class*
+func (q *Fair[T]) ClassLen(class int) int {
+	return q.classes[class].Len()
+}
+
+// Push adds an item with a cost of 1 to the back of class *class*
+func (q *Fair[T]) Push(class int, item T) {
+	q.PushCost(class, item, 1)
+}
+
+// PushCost adds an item to the back of class *class*. Its *cost*, such as its
+// size in bytes, determines how much of the class's share it uses.
+func (q *Fair[T]) PushCost(class int, item T, cost float64) {
+	start := q.now
+	if q.last[class] > start {
+		start = q.last[class]
+	}
+	finish := start + cost/q.weights[class]
+	q.last[class] = finish
+	q.classes[class].Push(stamped[T]{item, finish})
+	if q.classes[class].Len() == 1 {
+		q.heads.Set(class, finish)
+	}
+	q.len++
+}
+
+// Pop removes and returns the next item to serve and its class
+func (q *Fair[T]) Pop() (T, int, error) {
+	class, finish, err := q.heads.ExtractMinimum()
+	if err != nil {
+		var none T
+		return none, 0, ErrEmpty
+	}
+	s, _ := q.classes[class].Pop()
+	if next, err := q.classes[class].Peek(); err == nil {
+		q.heads.Set(class, next.finish)
+	}
+	q.now = finish
+	q.len--
+	return s.item, class, nil
+}
@@ -0,0 +1,97 @@
+package queue
+
+import "testing"
+
+func TestFairShares(t *testing.T) {
+	q := NewFair[int](1, 2, 5)
+	for i := 0; i != 800; i++ {
+		for class := 0; class != 3; class++ {
+			q.Push(class, i)
+		}
+	}
+	// While every class is busy, they are served in proportion 1:2:5
+	served := make([]int, 3)
+	for i := 0; i != 800; i++ {
+		_, class, err := q.Pop()
+		if err != nil {
+			t.Fatal(err)
+		}
+		served[class]++
+	}
+	if served[0] != 100 || served[1] != 200 || served[2] != 500 {
+		t.Error(served)
+	}
+	if q.Len() != 1600 || q.ClassLen(2) != 300 {
+		t.Error(q.Len(), q.ClassLen(2))
+	}
+}
+
+func TestFairFIFOWithinClass(t *testing.T) {
+	q := NewFair[int](1, 1)
+	for i := 0; i != 10; i++ {
+		q.Push(i%2, i)
+	}
+	next := []int{0, 1}
+	for q.Len() != 0 {
+		item, class, _ := q.Pop()
+		if item != next[class] {
+			t.Fatal(item, next[class])
+		}
+		next[class] += 2
+	}
+	if _, _, err := q.Pop(); err != ErrEmpty {
+		t.Error(err)
+	}
+}
+
+func TestFairCost(t *testing.T) {
+	// Class 0 sends items four times as large, so with equal weights it gets
+	// a quarter as many items served
+	q := NewFair[int](1, 1)
+	for i := 0; i != 100; i++ {
+		q.PushCost(0, i, 4)
+		q.PushCost(1, i, 1)
+	}
+	served := make([]int, 2)
+	for i := 0; i != 50; i++ {
+		_, class, _ := q.Pop()
+		served[class]++
+	}
+	if served[0] != 10 || served[1] != 40 {
+		t.Error(served)
+	}
+}
+
+func TestFairIdleClass(t *testing.T) {
+	// A class that was idle gets no credit for the time it was idle
+	q := NewFair[int](1, 1)
+	for i := 0; i != 100; i++ {
+		q.Push(0, i)
+	}
+	for i := 0; i != 50; i++ {
+		q.Pop()
+	}
+	for i := 0; i != 100; i++ {
+		q.Push(1, i)
+	}
+	served := make([]int, 2)
+	for i := 0; i != 20; i++ {
+		_, class, _ := q.Pop()
+		served[class]++
+	}
+	if served[0] < 9 || served[1] > 11 {
+		t.Error(served)
+	}
+}
+
+func BenchmarkFair(b *testing.B) {
+	q := NewFair[int](1, 2, 3, 4, 5, 6, 7, 8)
+	for i := 0; i != 1000; i++ {
+		q.Push(i%8, i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, class, _ := q.Pop()
+		q.Push(class, i)
+	}
+}
@@ -0,0 +1,129 @@
+/*
+ * Package queue implements first-in first-out queues: a ring buffer, and a
+ * fair queue that shares service between classes of items.
+ *
+ * A ring buffer holds a queue in an array, treating the end of the array as
+ * joined to its start. Two indices mark the front of the queue and the
+ * number of items, so that pushing at the back and popping from the front
+ * are both O(1) and never move the other items:
+ *
+ *    [d e _ _ _ a b c]      front = 5, len = 5
+ *         ^back  ^front
+ *
+ * When the array is full, a growable Ring copies its items in order to an
+ * array of twice the size, so pushing is O(1) amortized. A bounded Ring
+ * refuses new items instead, which suits buffers that must not grow without
+ * limit, such as a queue of requests waiting for a rate limiter.
+ *
+ * Items can also be taken from the back, so that a Ring serves as a stack,
+ * or as a double-ended queue that drops its oldest items when it is too long.
+ */
+
+package queue
+
+import "errors"
+
+var (
+	ErrEmpty = errors.New("queue is empty")
+	ErrFull  = errors.New("queue is full")
+)
+
+// Ring is a FIFO queue stored in a circular array. The zero value is an empty
+// growable Ring.
+type Ring[T any] struct {
+	items   []T
+	front   int
+	len     int
+	bounded bool
+}
+
+// NewRing creates an empty growable Ring with room for *capacity* items
+// before it first grows
+func NewRing[T any](capacity int) *Ring[T] {
+	return &Ring[T]{items: make([]T, capacity)}
+}
+
+// NewBoundedRing creates an empty Ring that holds at most *capacity* items
+func NewBoundedRing[T any](capacity int) *Ring[T] {
+	return &Ring[T]{items: make([]T, capacity), bounded: true}
+}
+
+// Len returns the number of items in the queue
+func (r *Ring[T]) Len() int {
+	return r.len
+}
+
+// Cap returns the number of items the queue can hold before it grows, or, if
+// it is bounded, at all
+func (r *Ring[T]) Cap() int {
+	return len(r.items)
+}
+
+// Push adds an item at the back of the queue. It returns ErrFull if the queue
+// is bounded and full.
+func (r *Ring[T]) Push(item T) error {
+	if r.len == len(r.items) {
+		if r.bounded {
+			return ErrFull
+		}
+		r.grow()
+	}
+	r.items[(r.front+r.len)%len(r.items)] = item
+	r.len++
+	return nil
+}
+
+// grow moves the items, in order, to the start of an array twice as large
+func (r *Ring[T]) grow() {
+	size := 2 * len(r.items)
+	if size == 0 {
+		size = 4
+	}
+	items := make([]T, size)
+	n := copy(items, r.items[r.front:])
+	copy(items[n:], r.items[:r.front])
+	r.items, r.front = items, 0
+}
+
+// Peek returns the item at the front of the queue
+func (r *Ring[T]) Peek() (T, error) {
+	if r.len == 0 {
+		var none T
+		return none, ErrEmpty
+	}
+	return r.items[r.front], nil
+}
+
+// Pop removes and returns the item at the front of the queue
+func (r *Ring[T]) Pop() (T, error) {
+	item, err := r.Peek()
+	if err != nil {
+		return item, err
+	}
+	var zero T
+	r.items[r.front] = zero
+	r.front = (r.front + 1) % len(r.items)
+	r.len--
+	return item, nil
+}
+
+// PeekBack returns the item at the back of the queue, the one pushed last
+func (r *Ring[T]) PeekBack() (T, error) {
+	if r.len == 0 {
+		var none T
+		return none, ErrEmpty
+	}
+	return r.items[(r.front+r.len-1)%len(r.items)], nil
+}
+
+// PopBack removes and returns the item at the back of the queue
+func (r *Ring[T]) PopBack() (T, error) {
+	item, err := r.PeekBack()
+	if err != nil {
+		return item, err
+	}
+	var zero T
+	r.items[(r.front+r.len-1)%len(r.items)] = zero
+	r.len--
+	return item, nil
+}
@@ -0,0 +1,100 @@
+package queue
+
+import "testing"
+
+func TestRing(t *testing.T) {
+	var r Ring[int]
+	if _, err := r.Pop(); err != ErrEmpty {
+		t.Error(err)
+	}
+	// Interleave pushes and pops so that the queue wraps around as it grows
+	next, expected := 0, 0
+	for round := 0; round != 100; round++ {
+		for i := 0; i != 3; i++ {
+			r.Push(next)
+			next++
+		}
+		item, err := r.Pop()
+		if err != nil || item != expected {
+			t.Fatal(item, err, expected)
+		}
+		expected++
+	}
+	if r.Len() != 200 {
+		t.Error(r.Len())
+	}
+	for r.Len() != 0 {
+		if item, _ := r.Pop(); item != expected {
+			t.Fatal(item, expected)
+		}
+		expected++
+	}
+}
+
+func TestBoundedRing(t *testing.T) {
+	r := NewBoundedRing[string](2)
+	r.Push("a")
+	r.Push("b")
+	if err := r.Push("c"); err != ErrFull || r.Cap() != 2 {
+		t.Error(err, r.Cap())
+	}
+	if item, _ := r.Peek(); item != "a" || r.Len() != 2 {
+		t.Error(item, r.Len())
+	}
+	r.Pop()
+	if err := r.Push("c"); err != nil {
+		t.Error(err)
+	}
+	for _, expected := range []string{"b", "c"} {
+		if item, _ := r.Pop(); item != expected {
+			t.Error(item, expected)
+		}
+	}
+}
+
+func TestRingReleasesItems(t *testing.T) {
+	r := NewRing[*int](2)
+	r.Push(new(int))
+	r.Pop()
+	for _, item := range r.items {
+		if item != nil {
+			t.Error("popped item still referenced")
+		}
+	}
+}
+
+func TestRingBack(t *testing.T) {
+	var r Ring[int]
+	if _, err := r.PopBack(); err != ErrEmpty {
+		t.Error(err)
+	}
+	// Pop from both ends of a queue that wraps around
+	for i := 0; i != 6; i++ {
+		r.Push(i)
+	}
+	r.Pop()
+	r.Pop()
+	r.Push(6)
+	r.Push(7)
+	if item, _ := r.PeekBack(); item != 7 {
+		t.Error(item)
+	}
+	for _, expected := range []int{7, 6, 5} {
+		if item, err := r.PopBack(); err != nil || item != expected {
+			t.Error(item, err, expected)
+		}
+	}
+	for _, expected := range []int{2, 3, 4} {
+		if item, _ := r.Pop(); item != expected {
+			t.Error(item, expected)
+		}
+	}
+	if r.Len() != 0 {
+		t.Error(r.Len())
+	}
+	for _, item := range r.items {
+		if item != 0 {
+			t.Error("popped item still stored", item)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+/*
+ * Package rangetree implements a static two-dimensional range tree, which
+ * counts or reports the points in an axis-aligned rectangle.
+ *
+ * A balanced binary tree over the points sorted by x divides any range of x
+ * into O(log n) subtrees, as a segment tree divides a range of an array. If
+ * each subtree also keeps its points sorted by y, the points of each subtree
+ * that lie in the range of y are found by binary search, so that counting
+ * the points in a rectangle costs O(log² n), and reporting them O(log² n + k)
+ * for k points:
+ *
+ *    sorted by x:   (1,7) (2,3) (4,5) (6,1) (7,8) (9,2)
+ *
+ *    level 0:       [6,1  9,2  2,3  4,5  1,7  7,8]      each block sorted by y
+ *    level 1:       [2,3  4,5  1,7] [6,1  9,2  7,8]
+ *    level 2:       [1,7] [2,3  4,5] [6,1] [9,2  7,8]
+ *    level 3:             [2,3] [4,5]        [7,8] [9,2]
+ *
+ * Each level holds every point once, so the tree takes O(n log n) space, and
+ * it is built in O(n log n) by merging each level's blocks from the level
+ * below, as in merge sort. (Fractional cascading links each block to the
+ * positions in its children, which saves the binary searches below the top
+ * and brings queries down to O(log n + k), at the cost of more space.)
+ *
+ * Unlike a kd-tree (see package kdtree), whose queries cost O(√n + k) and
+ * depend on how the points fall, a range tree guarantees its query time. A
+ * priority search tree (see package pst) answers only regions open on one
+ * side, but in O(n) space.
+ */
+
+package rangetree
+
+import "sort"
+
+// Point is a point in the plane
+type Point struct {
+	X, Y int
+}
+
+// Tree is a static range tree
+type Tree struct {
+	xs     []int     // the x coordinates, sorted
+	levels [][]Point // blocks of points sorted by y, at each depth
+}
+
+// New builds a Tree holding *points*, which may include duplicates
+func New(points []Point) *Tree {
+	sorted := make([]Point, len(points))
+	copy(sorted, points)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].X < sorted[j].X })
+	t := &Tree{xs: make([]int, len(sorted))}
+	for i, p := range sorted {
+		t.xs[i] = p.X
+	}
+	if len(sorted) == 0 {
+		return t
+	}
+	// A block of n points has children of at most (n+1)/2, so the leaves are
+	// at most ceil(log2 n) levels down
+	depth := 1
+	for size := len(sorted); size > 1; size = (size + 1) / 2 {
+		depth++
+	}
+	t.levels = make([][]Point, depth)
+	for d := range t.levels {
+		t.levels[d] = make([]Point, len(sorted))
+	}
+	t.build(sorted, 0, 0, len(sorted))
+	return t
+}
+
+// build fills the block [lo, hi) at depth *d* and the blocks below it
+func (t *Tree) build(sorted []Point, d, lo, hi int) {
+	block := t.levels[d][lo:hi]
+	if hi-lo == 1 {
+		block[0] = sorted[lo]
+		return
+	}
+	mid := (lo + hi) / 2
+	t.build(sorted, d+1, lo, mid)
+	t.build(sorted, d+1, mid, hi)
+	left, right := t.levels[d+1][lo:mid], t.levels[d+1][mid:hi]
+	i, j := 0, 0
+	for k := range block {
+		if j == len(right) || i < len(left) && left[i].Y <= right[j].Y {
+			block[k] = left[i]
+			i++
+		} else {
+			block[k] = right[j]
+			j++
+		}
+	}
+}
+
+// Len returns the number of points in the tree
+func (t *Tree) Len() int {
+	return len(t.xs)
+}
+
+// blocks calls *f* with each block of points sorted by y that together hold
+// the points with x in [*x1*, *x2*], until *f* returns false
+func (t *Tree) blocks(x1, x2 int, f func([]Point) bool) {
+	// The points with x in range are a contiguous run [i, j) of the points
+	// sorted by x, however many share each coordinate
+	i := sort.SearchInts(t.xs, x1)
+	j := sort.Search(len(t.xs), func(k int) bool { return t.xs[k] > x2 })
+	if i < j {
+		t.visitBlocks(0, 0, len(t.xs), i, j, f)
+	}
+}
+
+// visitBlocks visits the blocks within the block [lo, hi) at depth *d* that
+// cover its overlap with [i, j)
+func (t *Tree) visitBlocks(d, lo, hi, i, j int, f func([]Point) bool) bool {
+	if i <= lo && hi <= j {
+		return f(t.levels[d][lo:hi])
+	}
+	mid := (lo + hi) / 2
+	if i < mid && !t.visitBlocks(d+1, lo, mid, i, j, f) {
+		return false
+	}
+	if j > mid && !t.visitBlocks(d+1, mid, hi, i, j, f) {
+		return false
+	}
+	return true
+}
+
+// yRange returns the run of points in *block*, which is sorted by y, with y
+// in [*y1*, *y2*]
+func yRange(block []Point, y1, y2 int) []Point {
+	i := sort.Search(len(block), func(k int) bool { return block[k].Y >= y1 })
+	j := sort.Search(len(block), func(k int) bool { return block[k].Y > y2 })
+	if i >= j {
+		return nil
+	}
+	return block[i:j]
+}
+
+// Count returns the number of points with x in [*x1*, *x2*] and y in [*y1*,
+// *y2*], in O(log² n)
+func (t *Tree) Count(x1, x2, y1, y2 int) int {
+	count := 0
+	t.blocks(x1, x2, func(block []Point) bool {
+		count += len(yRange(block, y1, y2))
+		return true
+	})
+	return count
+}
+
+// Visit calls *f* with each point with x in [*x1*, *x2*] and y in [*y1*,
+// *y2*], in no particular order, until *f* returns false
+func (t *Tree) Visit(x1, x2, y1, y2 int, f func(Point) bool) {
+	t.blocks(x1, x2, func(block []Point) bool {
+		for _, p := range yRange(block, y1, y2) {
+			if !f(p) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// Query returns the points with x in [*x1*, *x2*] and y in [*y1*, *y2*], in
+// no particular order
+func (t *Tree) Query(x1, x2, y1, y2 int) []Point {
+	var points []Point
+	t.Visit(x1, x2, y1, y2, func(p Point) bool {
+		points = append(points, p)
+		return true
+	})
+	return points
+}
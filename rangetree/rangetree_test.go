@@ -0,0 +1,103 @@
+package rangetree
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func sortPoints(points []Point) {
+	sort.Slice(points, func(i, j int) bool {
+		if points[i].X != points[j].X {
+			return points[i].X < points[j].X
+		}
+		return points[i].Y < points[j].Y
+	})
+}
+
+func pointsEqual(a, b []Point) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	sortPoints(a)
+	sortPoints(b)
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestQuery(t *testing.T) {
+	tree := New([]Point{{1, 7}, {2, 3}, {4, 5}, {6, 1}, {7, 8}, {9, 2}})
+	points := tree.Query(2, 7, 2, 6)
+	if !pointsEqual(points, []Point{{2, 3}, {4, 5}}) {
+		t.Error(points)
+	}
+	if n := tree.Count(0, 10, 0, 10); n != 6 {
+		t.Error(n)
+	}
+	if n := tree.Count(7, 2, 0, 10); n != 0 {
+		t.Error("empty x range", n)
+	}
+	if points := New(nil).Query(0, 10, 0, 10); len(points) != 0 || New(nil).Count(0, 1, 0, 1) != 0 {
+		t.Error(points)
+	}
+}
+
+func TestQueryRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, n := range []int{1, 2, 3, 17, 500} {
+		points := make([]Point, n)
+		for i := range points {
+			// A small range of coordinates gives many duplicates
+			points[i] = Point{r.Intn(40), r.Intn(40)}
+		}
+		tree := New(points)
+		if tree.Len() != n {
+			t.Fatal(tree.Len())
+		}
+		for q := 0; q != 200; q++ {
+			x1, x2, y1, y2 := r.Intn(45)-2, r.Intn(45)-2, r.Intn(45)-2, r.Intn(45)-2
+			var expected []Point
+			for _, p := range points {
+				if x1 <= p.X && p.X <= x2 && y1 <= p.Y && p.Y <= y2 {
+					expected = append(expected, p)
+				}
+			}
+			if c := tree.Count(x1, x2, y1, y2); c != len(expected) {
+				t.Fatal(n, x1, x2, y1, y2, c, len(expected))
+			}
+			if got := tree.Query(x1, x2, y1, y2); !pointsEqual(got, expected) {
+				t.Fatal(n, x1, x2, y1, y2, got)
+			}
+		}
+	}
+}
+
+func TestVisitStop(t *testing.T) {
+	tree := New([]Point{{1, 1}, {2, 2}, {3, 3}, {4, 4}})
+	count := 0
+	tree.Visit(0, 5, 0, 5, func(Point) bool {
+		count++
+		return count < 2
+	})
+	if count != 2 {
+		t.Error(count)
+	}
+}
+
+func BenchmarkCount(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	points := make([]Point, 100000)
+	for i := range points {
+		points[i] = Point{r.Intn(1 << 20), r.Intn(1 << 20)}
+	}
+	tree := New(points)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		x, y := r.Intn(1<<20), r.Intn(1<<20)
+		tree.Count(x, x+1<<16, y, y+1<<16)
+	}
+}
@@ -0,0 +1,161 @@
+/*
+ * Package ratelimit implements the two classic rate limiters, the token
+ * bucket and the leaky bucket.
+ *
+ * A token bucket holds up to b tokens, and gains r tokens per second until
+ * it is full. Each request takes a token, and is refused (or must wait) if
+ * there is none. A client that has been quiet can therefore send a burst of
+ * up to b requests at once, but over any longer period cannot average more
+ * than r per second. The bucket need not be refilled by a timer: it records
+ * the token count at the time it last changed, and adds the tokens earned
+ * since then whenever it is consulted.
+ *
+ * A leaky bucket smooths traffic instead of admitting bursts. Requests join
+ * a bounded queue (a bounded queue.Ring), and leave it at a steady r per
+ * second, like water from a hole in a bucket; requests that arrive when the
+ * queue is full overflow and are refused. Output never exceeds the rate,
+ * even momentarily, at the cost of delaying requests that a token bucket
+ * would have let through at once.
+ *
+ * Both take the current time as an argument rather than reading the clock,
+ * so they can be driven by a simulated clock (and tested deterministically).
+ * Both are safe for concurrent use.
+ */
+
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/njwilson23/datastructures/queue"
+)
+
+// TokenBucket is a token bucket rate limiter
+type TokenBucket struct {
+	mu     sync.Mutex
+	rate   float64 // tokens per second
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewTokenBucket creates a full TokenBucket that gains *rate* tokens per
+// second, up to *burst*
+func NewTokenBucket(rate, burst float64, now time.Time) *TokenBucket {
+	if !(rate > 0) || !(burst >= 1) {
+		panic("ratelimit: rate must be positive and burst at least 1")
+	}
+	return &TokenBucket{rate: rate, burst: burst, tokens: burst, last: now}
+}
+
+// refill adds the tokens earned since the bucket was last updated
+func (b *TokenBucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(b.burst, b.tokens+elapsed*b.rate)
+		b.last = now
+	}
+}
+
+// Tokens returns the number of tokens available at *now*
+func (b *TokenBucket) Tokens(now time.Time) float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(now)
+	return b.tokens
+}
+
+// Allow takes a token and returns true, or returns false if there is none
+func (b *TokenBucket) Allow(now time.Time) bool {
+	return b.AllowN(now, 1)
+}
+
+// AllowN takes *n* tokens and returns true, or takes none and returns false
+// if there are not enough
+func (b *TokenBucket) AllowN(now time.Time, n float64) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(now)
+	if b.tokens < n {
+		return false
+	}
+	b.tokens -= n
+	return true
+}
+
+// Delay returns how long after *now* the bucket will hold *n* tokens, or -1
+// if it never will because *n* exceeds the burst size. It takes no tokens.
+func (b *TokenBucket) Delay(now time.Time, n float64) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if n > b.burst {
+		return -1
+	}
+	b.refill(now)
+	if b.tokens >= n {
+		return 0
+	}
+	return time.Duration(math.Ceil((n - b.tokens) / b.rate * float64(time.Second)))
+}
+
+// LeakyBucket is a leaky bucket rate limiter, which releases queued items at
+// a steady rate
+type LeakyBucket[T any] struct {
+	mu       sync.Mutex
+	interval time.Duration // time between releases
+	queue    *queue.Ring[T]
+	next     time.Time // earliest time at which the next item may leave
+}
+
+// NewLeakyBucket creates an empty LeakyBucket that releases *rate* items per
+// second, and holds up to *capacity* waiting items
+func NewLeakyBucket[T any](rate float64, capacity int) *LeakyBucket[T] {
+	if !(rate > 0) || capacity < 1 {
+		panic("ratelimit: rate and capacity must be positive")
+	}
+	return &LeakyBucket[T]{
+		interval: time.Duration(float64(time.Second) / rate),
+		queue:    queue.NewBoundedRing[T](capacity),
+	}
+}
+
+// Len returns the number of items waiting
+func (b *LeakyBucket[T]) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.queue.Len()
+}
+
+// Offer adds an item to the queue at *now*, or returns queue.ErrFull if the
+// bucket overflows
+func (b *LeakyBucket[T]) Offer(now time.Time, item T) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.queue.Len() == 0 && b.next.Before(now) {
+		// The bucket has been empty, so the item can leave at once
+		b.next = now
+	}
+	return b.queue.Push(item)
+}
+
+// Leak removes and returns the items due to leave by *now*, in order
+func (b *LeakyBucket[T]) Leak(now time.Time) []T {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var items []T
+	for b.queue.Len() != 0 && !b.next.After(now) {
+		item, _ := b.queue.Pop()
+		items = append(items, item)
+		b.next = b.next.Add(b.interval)
+	}
+	return items
+}
+
+// Next returns the time at which the next item is due to leave, or false if
+// the bucket is empty
+func (b *LeakyBucket[T]) Next() (time.Time, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.next, b.queue.Len() != 0
+}
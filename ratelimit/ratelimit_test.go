@@ -0,0 +1,121 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/njwilson23/datastructures/queue"
+)
+
+var start = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestTokenBucketBurst(t *testing.T) {
+	b := NewTokenBucket(10, 5, start)
+	for i := 0; i != 5; i++ {
+		if !b.Allow(start) {
+			t.Fatal("burst refused at", i)
+		}
+	}
+	if b.Allow(start) {
+		t.Error("allowed beyond the burst")
+	}
+	// One token is earned every 100ms
+	if d := b.Delay(start, 1); d != 100*time.Millisecond {
+		t.Error(d)
+	}
+	if !b.Allow(start.Add(100 * time.Millisecond)) {
+		t.Error("token not refilled")
+	}
+	if tokens := b.Tokens(start.Add(time.Hour)); tokens != 5 {
+		t.Error("bucket overfilled", tokens)
+	}
+	if d := b.Delay(start, 6); d != -1 {
+		t.Error(d)
+	}
+}
+
+func TestTokenBucketRate(t *testing.T) {
+	// Requests every millisecond for 10 seconds are limited to the rate, plus
+	// the initial burst
+	b := NewTokenBucket(50, 10, start)
+	allowed := 0
+	for ms := 0; ms != 10000; ms++ {
+		if b.Allow(start.Add(time.Duration(ms) * time.Millisecond)) {
+			allowed++
+		}
+	}
+	if allowed < 505 || allowed > 510 {
+		t.Error(allowed)
+	}
+}
+
+func TestTokenBucketAllowN(t *testing.T) {
+	b := NewTokenBucket(1, 3, start)
+	if b.AllowN(start, 4) || b.Tokens(start) != 3 {
+		t.Error("AllowN took tokens it refused")
+	}
+	if !b.AllowN(start, 2.5) || b.Tokens(start) != 0.5 {
+		t.Error(b.Tokens(start))
+	}
+}
+
+func TestLeakyBucket(t *testing.T) {
+	b := NewLeakyBucket[int](10, 3)
+	for i := 0; i != 3; i++ {
+		if err := b.Offer(start, i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := b.Offer(start, 3); err != queue.ErrFull {
+		t.Error(err)
+	}
+	if items := b.Leak(start); len(items) != 1 || items[0] != 0 {
+		t.Error(items)
+	}
+	if next, ok := b.Next(); !ok || !next.Equal(start.Add(100*time.Millisecond)) {
+		t.Error(next, ok)
+	}
+	if items := b.Leak(start.Add(250 * time.Millisecond)); len(items) != 2 || items[1] != 2 {
+		t.Error(items)
+	}
+	if _, ok := b.Next(); ok || b.Len() != 0 {
+		t.Error("bucket not empty")
+	}
+
+	// After being idle, the next item leaves at once, but no earlier than
+	// the interval after the last one
+	later := start.Add(time.Second)
+	b.Offer(later, 4)
+	b.Offer(later, 5)
+	if items := b.Leak(later); len(items) != 1 || items[0] != 4 {
+		t.Error(items)
+	}
+	if items := b.Leak(later.Add(99 * time.Millisecond)); len(items) != 0 {
+		t.Error(items)
+	}
+}
+
+func TestLeakyBucketSteady(t *testing.T) {
+	// However bursty the input, the output never exceeds the rate
+	b := NewLeakyBucket[int](20, 100)
+	released := 0
+	var last time.Time
+	for ms := 0; ms <= 5000; ms += 10 {
+		now := start.Add(time.Duration(ms) * time.Millisecond)
+		if ms%1000 == 0 {
+			for i := 0; i != 50; i++ {
+				b.Offer(now, i)
+			}
+		}
+		for range b.Leak(now) {
+			if released != 0 && now.Sub(last) < 50*time.Millisecond {
+				t.Fatal("released too soon at", ms)
+			}
+			last = now
+			released++
+		}
+	}
+	if released != 101 {
+		t.Error(released)
+	}
+}
@@ -0,0 +1,83 @@
+// Augmentation
+//
+// Many problems are solved by storing in each node a summary of its subtree,
+// such as the sum of its values or the largest endpoint of the intervals in
+// it (CLRS chapter 14). Such an annotation can be maintained cheaply if it is
+// a function of the node and the annotations of its two children alone: a
+// change to the tree only changes the annotations of the nodes whose subtrees
+// changed, which are the ancestors of the inserted or removed node and the
+// two nodes of each rotation. Each operation recomputes them bottom-up, at a
+// cost of O(log n) calls of the function.
+//
+// The subtree sizes behind Rank and Select are maintained in the same way,
+// but are built in so that they cost nothing extra when no other annotation
+// is needed.
+//
+// Annotations are recomputed after every change to the tree and after Put
+// changes a value, but not if a value is mutated in place, and they must not
+// depend on anything else, such as the colors of nodes.
+
+package rbtree
+
+// Augment computes the annotation of a node from its key, its value, and the
+// annotations of its children (see Node.Left and Node.Right)
+type Augment func(n *Node) interface{}
+
+// NewAugmented creates an empty red-black tree that stores the result of
+// *augment* in every node, and keeps it up to date
+func NewAugmented(augment Augment) *RedBlackTree {
+	tree := New()
+	tree.augment = augment
+	return tree
+}
+
+// refresh recomputes the annotations of *n* and its ancestors, which must
+// be all the nodes with stale annotations
+func (tree *RedBlackTree) refresh(n *Node) {
+	if tree.augment == nil {
+		return
+	}
+	for ; !n.isSentinel(); n = n.p {
+		n.annotation = tree.augment(n)
+	}
+}
+
+// Root returns the root node of the tree, or nil if it is empty, as a
+// starting point for searches guided by annotations
+func (tree *RedBlackTree) Root() *Node {
+	if tree.root.isSentinel() {
+		return nil
+	}
+	return tree.root
+}
+
+// Annotation returns the annotation of the whole tree, which is that of its
+// root, or nil if the tree is empty
+func (tree *RedBlackTree) Annotation() interface{} {
+	return tree.root.annotation
+}
+
+// Left returns the left child of a node, or nil if it has none
+func (n *Node) Left() *Node {
+	if n.left.isSentinel() {
+		return nil
+	}
+	return n.left
+}
+
+// Right returns the right child of a node, or nil if it has none
+func (n *Node) Right() *Node {
+	if n.right.isSentinel() {
+		return nil
+	}
+	return n.right
+}
+
+// Annotation returns the annotation computed for a node by the tree's
+// Augment function, or nil for a nil node
+func (n *Node) Annotation() interface{} {
+	if n == nil {
+		return nil
+	}
+	return n.annotation
+}
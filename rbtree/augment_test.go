@@ -0,0 +1,89 @@
+package rbtree
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// subtreeSum annotates each node with the sum of the values in its subtree
+func subtreeSum(n *Node) interface{} {
+	sum := n.Value().(int)
+	for _, c := range []*Node{n.Left(), n.Right()} {
+		if c != nil {
+			sum += c.Annotation().(int)
+		}
+	}
+	return sum
+}
+
+// height annotates each node with the height of its subtree, which depends
+// on the shape of the tree and so changes in rotations
+func height(n *Node) interface{} {
+	h := 0
+	for _, c := range []*Node{n.Left(), n.Right()} {
+		if c != nil && c.Annotation().(int) > h {
+			h = c.Annotation().(int)
+		}
+	}
+	return h + 1
+}
+
+// checkAnnotations verifies every annotation below *n* against *augment*
+func checkAnnotations(t *testing.T, n *Node, augment Augment) {
+	if n == nil {
+		return
+	}
+	checkAnnotations(t, n.Left(), augment)
+	checkAnnotations(t, n.Right(), augment)
+	if n.Annotation() != augment(n) {
+		t.Fatalf("stale annotation at %d: %v, expected %v", n.Key(), n.Annotation(), augment(n))
+	}
+}
+
+func TestAugment(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, augment := range []Augment{subtreeSum, height} {
+		tree := NewAugmented(augment)
+		if tree.Annotation() != nil || tree.Root() != nil {
+			t.Error("empty tree")
+		}
+		var keys []int
+		for i := 0; i != 2000; i++ {
+			switch {
+			case len(keys) != 0 && r.Intn(3) == 0:
+				j := r.Intn(len(keys))
+				tree.Delete(keys[j])
+				keys = append(keys[:j], keys[j+1:]...)
+			case len(keys) != 0 && r.Intn(5) == 0:
+				old, _ := tree.Get(keys[0])
+				tree.Put(keys[0], old.(int)+1)
+			default:
+				k := r.Intn(1000)
+				tree.InsertValue(k, k)
+				keys = append(keys, k)
+			}
+			if i%100 == 0 {
+				checkAnnotations(t, tree.Root(), augment)
+			}
+		}
+		checkAnnotations(t, tree.Root(), augment)
+		checkTree(t, tree.root)
+	}
+	tree := NewAugmented(subtreeSum)
+	for i := 1; i <= 100; i++ {
+		tree.InsertValue(i, i)
+	}
+	if tree.Annotation() != 5050 {
+		t.Error(tree.Annotation())
+	}
+}
+
+func BenchmarkAugmentedInsert(b *testing.B) {
+	keys := rand.New(rand.NewSource(1)).Perm(1024)
+	for i := 0; i < b.N; i++ {
+		tree := NewAugmented(subtreeSum)
+		for _, key := range keys {
+			tree.InsertValue(key, key)
+		}
+	}
+}
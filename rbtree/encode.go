@@ -0,0 +1,258 @@
+// Encoding
+//
+// Encode writes the tree node by node in preorder, each node before its
+// subtrees, with its shape and color:
+//
+//	magic "RBT1"
+//	count uvarint
+//	nodes [count]{
+//		flags byte (1 red, 2 has a left child, 4 has a right child, 8 has a value,
+//		            16 has more than one copy)
+//		key   varint
+//		value length uvarint and bytes, if it has one
+//		count uvarint less 1, if more than one
+//	}
+//
+// Decode links the nodes back together in the same order, so restoring a
+// tree is O(n), with no comparisons or rotations, and gives exactly the tree
+// that was written. Since the input may be damaged, the restored tree is
+// checked with Validate, which is also O(n).
+//
+// The Policy of the tree is not written: like the Augment function, it belongs
+// to the tree decoded into, and an encoding of duplicate keys or counts the
+// policy does not allow fails validation.
+//
+// Values are encoded by a function given by the caller. MarshalBinary and
+// UnmarshalBinary use encoding/gob for the values, so a tree can be written
+// with gob directly, provided the types of its values are registered with
+// gob.Register (basic types such as int and string always are).
+
+package rbtree
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+var ErrCorrupt = errors.New("corrupt tree encoding")
+
+const encodingMagic = "RBT1"
+
+const (
+	flagRed = 1 << iota
+	flagLeft
+	flagRight
+	flagValue
+	flagCount
+)
+
+// Encode writes the tree to *w*, using *encode* to encode the values of the
+// nodes that have them. If *encode* is nil, values are not written.
+func (tree *RedBlackTree) Encode(w io.Writer, encode func(value interface{}) ([]byte, error)) error {
+	bw := bufio.NewWriter(w)
+	buf := []byte(encodingMagic)
+	buf = binary.AppendUvarint(buf, uint64(tree.Len()))
+	if _, err := bw.Write(buf); err != nil {
+		return err
+	}
+	stack := []*Node{tree.root}
+	for len(stack) != 0 {
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if n.isSentinel() {
+			continue
+		}
+		var flags byte
+		if n.color == red {
+			flags |= flagRed
+		}
+		if !n.left.isSentinel() {
+			flags |= flagLeft
+		}
+		if !n.right.isSentinel() {
+			flags |= flagRight
+		}
+		var value []byte
+		if n.value != nil && encode != nil {
+			var err error
+			if value, err = encode(n.value); err != nil {
+				return err
+			}
+			flags |= flagValue
+		}
+		if n.extra != 0 {
+			flags |= flagCount
+		}
+		buf = append(buf[:0], flags)
+		buf = binary.AppendVarint(buf, int64(n.key))
+		if flags&flagValue != 0 {
+			buf = binary.AppendUvarint(buf, uint64(len(value)))
+			buf = append(buf, value...)
+		}
+		if flags&flagCount != 0 {
+			buf = binary.AppendUvarint(buf, uint64(n.extra))
+		}
+		if _, err := bw.Write(buf); err != nil {
+			return err
+		}
+		// The right subtree is pushed first, so that the left is written first
+		stack = append(stack, n.right, n.left)
+	}
+	return bw.Flush()
+}
+
+// Decode reads a tree written by Encode from *r*, using *decode* to decode
+// the values. If *decode* is nil, values are skipped. It returns an error
+// wrapping ErrCorrupt if the encoding is invalid.
+func Decode(r io.Reader, decode func(data []byte) (interface{}, error)) (*RedBlackTree, error) {
+	tree := New()
+	if err := tree.decode(r, decode); err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
+// decode replaces the contents of the tree with those read from *r*, keeping
+// its Augment function and Policy
+func (tree *RedBlackTree) decode(r io.Reader, decode func(data []byte) (interface{}, error)) error {
+	br := bufio.NewReader(r)
+	magic := make([]byte, len(encodingMagic))
+	if _, err := io.ReadFull(br, magic); err != nil || string(magic) != encodingMagic {
+		return fmt.Errorf("%w: bad header", ErrCorrupt)
+	}
+	count, err := binary.ReadUvarint(br)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+
+	// Each node is linked to the slot left for it by its parent, and leaves
+	// slots for its own children, the left one to be filled first
+	type slot struct {
+		parent *Node
+		link   **Node
+	}
+	root := tree.sentinel
+	var slots []slot
+	if count != 0 {
+		slots = append(slots, slot{tree.sentinel, &root})
+	}
+	// Nodes are allocated in slabs, in preorder, but not all at once, since a
+	// damaged count could be huge
+	const slabSize = 1024
+	var slabs [][]Node
+	var slab []Node
+	decoded := uint64(0)
+	for len(slots) != 0 {
+		if decoded == count {
+			return fmt.Errorf("%w: more nodes than the count", ErrCorrupt)
+		}
+		s := slots[len(slots)-1]
+		slots = slots[:len(slots)-1]
+		flags, err := br.ReadByte()
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrCorrupt, err)
+		}
+		key, err := binary.ReadVarint(br)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrCorrupt, err)
+		}
+		if len(slab) == 0 {
+			slab = make([]Node, min(count-decoded, slabSize))
+			slabs = append(slabs, slab)
+		}
+		n := &slab[0]
+		slab = slab[1:]
+		n.left, n.right, n.p, n.key = tree.sentinel, tree.sentinel, s.parent, int(key)
+		n.color = black
+		if flags&flagRed != 0 {
+			n.color = red
+		}
+		if flags&flagValue != 0 {
+			length, err := binary.ReadUvarint(br)
+			if err != nil {
+				return fmt.Errorf("%w: %v", ErrCorrupt, err)
+			}
+			// The value is copied in pieces, so that a damaged length cannot
+			// demand a huge allocation before the input runs out
+			var value bytes.Buffer
+			if _, err := io.CopyN(&value, br, int64(length)); err != nil {
+				return fmt.Errorf("%w: %v", ErrCorrupt, err)
+			}
+			if decode != nil {
+				if n.value, err = decode(value.Bytes()); err != nil {
+					return err
+				}
+			}
+		}
+		if flags&flagCount != 0 {
+			extra, err := binary.ReadUvarint(br)
+			if err != nil || extra == 0 || extra > math.MaxInt32 {
+				return fmt.Errorf("%w: bad count", ErrCorrupt)
+			}
+			n.extra = int(extra)
+		}
+		*s.link = n
+		decoded++
+		if flags&flagRight != 0 {
+			slots = append(slots, slot{n, &n.right})
+		}
+		if flags&flagLeft != 0 {
+			slots = append(slots, slot{n, &n.left})
+		}
+	}
+	if decoded != count {
+		return fmt.Errorf("%w: fewer nodes than the count", ErrCorrupt)
+	}
+
+	// Children follow their parents, so sizes and annotations are complete
+	// when computed in reverse
+	for i := len(slabs) - 1; i >= 0; i-- {
+		for j := len(slabs[i]) - 1; j >= 0; j-- {
+			n := &slabs[i][j]
+			n.size = n.left.size + n.right.size + 1
+			if tree.augment != nil {
+				n.annotation = tree.augment(n)
+			}
+		}
+	}
+	previous := tree.root
+	tree.root = root
+	if err := tree.Validate(); err != nil {
+		tree.root = previous
+		return fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+	return nil
+}
+
+// MarshalBinary encodes the tree with Encode, encoding values with gob
+func (tree *RedBlackTree) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	err := tree.Encode(&buf, func(value interface{}) ([]byte, error) {
+		var b bytes.Buffer
+		err := gob.NewEncoder(&b).Encode(&value)
+		return b.Bytes(), err
+	})
+	return buf.Bytes(), err
+}
+
+// UnmarshalBinary replaces the contents of the tree with a tree encoded by
+// MarshalBinary. The tree keeps its Augment function and Policy, and the
+// annotations are computed afresh.
+func (tree *RedBlackTree) UnmarshalBinary(data []byte) error {
+	if tree.sentinel == nil {
+		*tree = *New()
+	}
+	return tree.decode(bytes.NewReader(data), func(data []byte) (interface{}, error) {
+		var value interface{}
+		if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&value); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrCorrupt, err)
+		}
+		return value, nil
+	})
+}
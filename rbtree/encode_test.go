@@ -0,0 +1,115 @@
+package rbtree
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, n := range []int{0, 1, 2, 100, 1000} {
+		tree := New()
+		for i := 0; i != n; i++ {
+			key := r.Intn(2*n) - n
+			if i%3 == 0 {
+				tree.Insert(key)
+			} else {
+				tree.InsertValue(key, strconv.Itoa(key))
+			}
+		}
+		var buf bytes.Buffer
+		err := tree.Encode(&buf, func(value interface{}) ([]byte, error) {
+			return []byte(value.(string)), nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		restored, err := Decode(&buf, func(data []byte) (interface{}, error) {
+			return string(data), nil
+		})
+		if err != nil {
+			t.Fatal(n, err)
+		}
+		// The restored tree has the same shape, so it draws the same
+		if restored.String() != tree.String() {
+			t.Fatal(n, "trees differ")
+		}
+		restored.Insert(n)
+		if err := restored.Validate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestGob(t *testing.T) {
+	tree := NewAugmented(subtreeSum)
+	for i := 1; i <= 10; i++ {
+		tree.InsertValue(i, i)
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(tree); err != nil {
+		t.Fatal(err)
+	}
+	restored := NewAugmented(subtreeSum)
+	if err := gob.NewDecoder(&buf).Decode(restored); err != nil {
+		t.Fatal(err)
+	}
+	if restored.String() != tree.String() || restored.Annotation() != 55 {
+		t.Error(restored)
+	}
+	if value, ok := restored.Get(7); !ok || value != 7 {
+		t.Error(value)
+	}
+	// A zero RedBlackTree can be decoded into too
+	var zero RedBlackTree
+	data, _ := tree.MarshalBinary()
+	if err := zero.UnmarshalBinary(data); err != nil || zero.Len() != 10 {
+		t.Error(err, zero.Len())
+	}
+}
+
+func TestDecodeCorrupt(t *testing.T) {
+	tree := FromSlice([]int{5, 3, 8, 1, 4, 7, 9})
+	data, _ := tree.MarshalBinary()
+	for end := 0; end < len(data); end++ {
+		if _, err := Decode(bytes.NewReader(data[:end]), nil); !errors.Is(err, ErrCorrupt) {
+			t.Fatal("truncated at", end, err)
+		}
+	}
+	r := rand.New(rand.NewSource(1))
+	for trial := 0; trial != 1000; trial++ {
+		damaged := append([]byte(nil), data...)
+		damaged[4+r.Intn(len(damaged)-4)] ^= byte(1 + r.Intn(255))
+		restored, err := Decode(bytes.NewReader(damaged), nil)
+		if err == nil {
+			// The damage may have changed only keys, without breaking order
+			if err := restored.Validate(); err != nil {
+				t.Fatal(err)
+			}
+		} else if !errors.Is(err, ErrCorrupt) {
+			t.Fatal(err)
+		}
+	}
+	// A failed UnmarshalBinary leaves the tree as it was
+	if err := tree.UnmarshalBinary(data[:len(data)-1]); err == nil || tree.Len() != 7 {
+		t.Error(err, tree.Len())
+	}
+}
+
+func BenchmarkDecode(b *testing.B) {
+	keys := make([]int, 1<<16)
+	for i := range keys {
+		keys[i] = i
+	}
+	var buf bytes.Buffer
+	FromSorted(keys).Encode(&buf, nil)
+	data := buf.Bytes()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Decode(bytes.NewReader(data), nil)
+	}
+}
@@ -0,0 +1,172 @@
+// Joining and splitting
+//
+// Two trees whose keys do not overlap can be joined in O(log n), rather than
+// by inserting the keys of one into the other. Joining tree L with a middle
+// node m and tree R, where L has at least as great a black height, descends
+// the right spine of L to the first black node t with the black height of R,
+// and puts m, painted red, in t's place, with t and R as its children:
+//
+//          L                        L
+//         / \                      / \
+//        .   .          ->        .   .
+//             \                        \
+//              t                        m (red)
+//                                      / \
+//                                     t   R
+//
+// Every path through m passes as many black nodes as before, so the only
+// possible violation is a red m below a red parent, which the rebalancing
+// after an insertion repairs. The descent and the repair are both O(difference
+// in black heights + 1).
+//
+// Splitting at a key walks down the search path, cutting off the subtrees to
+// either side of it, and joins them, with the nodes on the path as middle
+// nodes, into a tree of the smaller keys and a tree of the others. The black
+// heights of the joined trees grow as the walk returns upward, so the costs of
+// the joins telescope to O(log n) in all (Tarjan, 1983).
+//
+// The trees are taken apart and their nodes reused, so the trees given to
+// Join and Split are left empty.
+
+package rbtree
+
+// Join returns a tree holding the keys of *left* and *right*, leaving both
+// empty, in O(log n). Every key in left must be no greater than every key in
+// right, or Join panics, and unless left allows duplicates (see Policy),
+// the keys must not meet either. The new tree uses the Augment function and
+// Policy of left, which must be the same as those of right.
+func Join(left, right *RedBlackTree) *RedBlackTree {
+	tree := &RedBlackTree{left.root, left.sentinel, left.augment, left.policy, nil}
+	if right.root.isSentinel() {
+		left.root = left.sentinel
+		return tree
+	}
+	if !left.root.isSentinel() {
+		hi, _ := left.Max()
+		lo, _ := right.Min()
+		if hi > lo || hi == lo && left.policy != AllowDuplicates {
+			panic("rbtree: cannot join overlapping trees")
+		}
+	}
+	// The smallest node of right becomes the middle node
+	m := right.root
+	for !m.left.isSentinel() {
+		m = m.left
+	}
+	right.deleteNode(m)
+	tree.root, _ = tree.join(left.root, blackHeight(left.root), m, right.root, blackHeight(right.root))
+	left.root, right.root = left.sentinel, right.sentinel
+	return tree
+}
+
+// Split moves the keys less than *key* to one new tree, and the rest to
+// another, leaving the tree empty, in O(log n). The new trees use the tree's
+// Augment function and Policy.
+func (tree *RedBlackTree) Split(key int) (*RedBlackTree, *RedBlackTree) {
+	less, _, rest, _ := tree.split(tree.root, blackHeight(tree.root), key)
+	tree.root = tree.sentinel
+	return &RedBlackTree{less, tree.sentinel, tree.augment, tree.policy, nil},
+		&RedBlackTree{rest, tree.sentinel, tree.augment, tree.policy, nil}
+}
+
+// blackHeight returns the number of black nodes on every path down from the
+// subtree root *n*, not counting the sentinel
+func blackHeight(n *Node) int {
+	h := 0
+	for ; !n.isSentinel(); n = n.left {
+		if n.color == black {
+			h++
+		}
+	}
+	return h
+}
+
+// detach makes *n*, with black height *h*, the black root of a separate
+// subtree, and returns it with its new black height
+func (tree *RedBlackTree) detach(n *Node, h int) (*Node, int) {
+	if n.isSentinel() {
+		return n, h
+	}
+	n.p = tree.sentinel
+	if n.color == red {
+		n.color = black
+		h++
+	}
+	return n, h
+}
+
+// split divides the subtree rooted at *n*, with black height *h*, into
+// subtrees of the keys less than *key* and of the rest, and returns their
+// roots and black heights
+func (tree *RedBlackTree) split(n *Node, h int, key int) (*Node, int, *Node, int) {
+	if n.isSentinel() {
+		return n, 0, n, 0
+	}
+	if n.color == black {
+		h--
+	}
+	left, lh := tree.detach(n.left, h)
+	right, rh := tree.detach(n.right, h)
+	if key <= n.key {
+		less, nl, rest, nr := tree.split(left, lh, key)
+		rest, nr = tree.join(rest, nr, n, right, rh)
+		return less, nl, rest, nr
+	}
+	less, nl, rest, nr := tree.split(right, rh, key)
+	less, nl = tree.join(left, lh, n, less, nl)
+	return less, nl, rest, nr
+}
+
+// join joins the subtrees rooted at *l* and *r*, which are black and have
+// black heights *lh* and *rh*, with *m* between them, and returns the root
+// and black height of the result
+func (tree *RedBlackTree) join(l *Node, lh int, m *Node, r *Node, rh int) (*Node, int) {
+	// The rotations and rebalancing work on a tree holding just the subtrees
+	joined := &RedBlackTree{l, tree.sentinel, tree.augment, tree.policy, nil}
+	// Descend the taller subtree to the first black node as high as the other
+	parent, t, h := tree.sentinel, l, lh
+	if lh < rh {
+		joined.root, t, h = r, r, rh
+	}
+	for t.color == red || h > min(lh, rh) {
+		if t.color == black {
+			h--
+		}
+		parent = t
+		if lh >= rh {
+			t = t.right
+		} else {
+			t = t.left
+		}
+	}
+
+	m.color, m.p = red, parent
+	if lh >= rh {
+		m.left, m.right = t, r
+	} else {
+		m.left, m.right = l, t
+	}
+	for _, child := range []*Node{m.left, m.right} {
+		if !child.isSentinel() {
+			child.p = m
+		}
+	}
+	m.size = m.left.size + m.right.size + 1
+	switch {
+	case parent.isSentinel():
+		joined.root = m
+	case lh >= rh:
+		parent.right = m
+	default:
+		parent.left = m
+	}
+	for n := parent; !n.isSentinel(); n = n.p {
+		n.size = n.left.size + n.right.size + 1
+	}
+	joined.refresh(m)
+	h = max(lh, rh)
+	if joined.rebalanceInsert(m) {
+		h++
+	}
+	return joined.root, h
+}
@@ -0,0 +1,105 @@
+package rbtree
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestSplitJoin(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, n := range []int{0, 1, 2, 10, 500} {
+		for trial := 0; trial != 20; trial++ {
+			keys := make([]int, n)
+			tree := New()
+			for i := range keys {
+				keys[i] = r.Intn(n + 1)
+				tree.InsertValue(keys[i], keys[i]*10)
+			}
+			sort.Ints(keys)
+			key := r.Intn(n + 2)
+			i := sort.SearchInts(keys, key)
+
+			less, rest := tree.Split(key)
+			if tree.Len() != 0 {
+				t.Fatal("split tree not empty")
+			}
+			for _, part := range []*RedBlackTree{less, rest} {
+				if err := part.Validate(); err != nil {
+					t.Fatal(n, key, err)
+				}
+			}
+			if fmt.Sprint(less.Keys()) != fmt.Sprint(keys[:i]) || fmt.Sprint(rest.Keys()) != fmt.Sprint(keys[i:]) {
+				t.Fatal(n, key, less.Keys(), rest.Keys())
+			}
+
+			joined := Join(less, rest)
+			if err := joined.Validate(); err != nil {
+				t.Fatal(n, key, err)
+			}
+			if fmt.Sprint(joined.Keys()) != fmt.Sprint(keys) || less.Len() != 0 || rest.Len() != 0 {
+				t.Fatal(n, key, joined.Keys())
+			}
+			// The joined tree, with leaves from both halves, still works
+			for _, k := range keys[:len(keys)/2] {
+				if value, ok := joined.Get(k); !ok || value != k*10 || !joined.Delete(k) {
+					t.Fatal("lost", k)
+				}
+			}
+			joined.Insert(-1)
+			if err := joined.Validate(); err != nil {
+				t.Fatal(n, key, err)
+			}
+		}
+	}
+}
+
+func TestJoinUneven(t *testing.T) {
+	small := FromSlice([]int{0, 1})
+	large := New()
+	for i := 2; i != 1000; i++ {
+		large.Insert(i)
+	}
+	joined := Join(small, large)
+	if err := joined.Validate(); err != nil || joined.Len() != 1000 {
+		t.Fatal(err, joined.Len())
+	}
+	left, right := joined.Split(998)
+	joined = Join(right, New())
+	joined = Join(left, joined)
+	if err := joined.Validate(); err != nil || joined.Len() != 1000 {
+		t.Fatal(err, joined.Len())
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("overlapping trees joined")
+		}
+	}()
+	Join(FromSlice([]int{5}), FromSlice([]int{4}))
+}
+
+func TestSplitAugmented(t *testing.T) {
+	tree := NewAugmented(subtreeSum)
+	for i := 1; i <= 100; i++ {
+		tree.InsertValue(i, i)
+	}
+	less, rest := tree.Split(51)
+	if less.Annotation() != 1275 || rest.Annotation() != 3775 {
+		t.Fatal(less.Annotation(), rest.Annotation())
+	}
+	if joined := Join(less, rest); joined.Annotation() != 5050 {
+		t.Error(joined.Annotation())
+	}
+}
+
+func BenchmarkSplitJoin(b *testing.B) {
+	tree := New()
+	for i := 0; i != 1<<16; i++ {
+		tree.Insert(i)
+	}
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < b.N; i++ {
+		tree = Join(tree.Split(r.Intn(1 << 16)))
+	}
+}
@@ -0,0 +1,88 @@
+// Duplicate keys
+//
+// By default, inserting a key that is already in the tree adds another node
+// with the same key, beside the first, and Get and Delete find whichever of
+// them the search reaches first. A Policy given to NewWithPolicy chooses
+// another behavior instead:
+//
+// - RejectDuplicates leaves the tree unchanged, keeping the first value.
+// - ReplaceDuplicates attaches the new value to the existing node, as Put
+//   does, so that the tree is a sorted map.
+// - CountDuplicates counts the copies of each key in its node, making the
+//   tree a multiset. Delete removes one copy at a time.
+//
+// Under the last three, the keys of the nodes are distinct, so Len, Rank,
+// Select and the iterators count each key once, whatever its count; Count
+// gives the number of copies.
+
+package rbtree
+
+import "github.com/njwilson23/datastructures/observe"
+
+// Policy chooses what inserting a key already in a RedBlackTree does
+type Policy int
+
+const (
+	AllowDuplicates Policy = iota
+	RejectDuplicates
+	ReplaceDuplicates
+	CountDuplicates
+)
+
+// NewWithPolicy creates an empty red-black tree that treats duplicate keys
+// according to *policy*, and maintains annotations with *augment* unless it
+// is nil
+func NewWithPolicy(policy Policy, augment Augment) *RedBlackTree {
+	tree := New()
+	tree.policy, tree.augment = policy, augment
+	return tree
+}
+
+// Policy returns the tree's policy for duplicate keys
+func (tree *RedBlackTree) Policy() Policy {
+	return tree.policy
+}
+
+// duplicate applies the tree's policy to the insertion of *value* with the
+// key of *n*, which is already in the tree
+func (tree *RedBlackTree) duplicate(n *Node, value interface{}) {
+	switch tree.policy {
+	case ReplaceDuplicates:
+		n.value = value
+	case CountDuplicates:
+		n.extra++
+	default:
+		return
+	}
+	tree.refresh(n)
+	tree.notify(observe.Put, n.key, value)
+}
+
+// Count returns the number of copies of *key* in the tree
+func (tree *RedBlackTree) Count(key int) int {
+	if tree.policy != AllowDuplicates {
+		n := tree.search(key)
+		if n.isSentinel() {
+			return 0
+		}
+		return n.Count()
+	}
+	// Each copy is a node, so the count is the number of keys no greater
+	// than key less the number less than it
+	count := -tree.Rank(key)
+	for n := tree.root; !n.isSentinel(); {
+		if key < n.key {
+			n = n.left
+		} else {
+			count += n.left.size + 1
+			n = n.right
+		}
+	}
+	return count
+}
+
+// Count returns the number of copies of the key that a node holds, which is
+// one unless the tree counts duplicates
+func (n *Node) Count() int {
+	return n.extra + 1
+}
@@ -0,0 +1,139 @@
+package rbtree
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func TestPolicy(t *testing.T) {
+	for _, c := range []struct {
+		policy Policy
+		value  interface{}
+		count  int
+		len    int
+	}{
+		{AllowDuplicates, "a", 3, 4},
+		{RejectDuplicates, "a", 1, 2},
+		{ReplaceDuplicates, "c", 1, 2},
+		{CountDuplicates, "a", 3, 2},
+	} {
+		tree := NewWithPolicy(c.policy, nil)
+		if tree.Policy() != c.policy {
+			t.Error(tree.Policy())
+		}
+		added := []bool{tree.InsertValue(1, "a"), tree.InsertValue(1, "b"), tree.Insert(2)}
+		tree.InsertValue(1, "c")
+		if !added[0] || added[1] != (c.policy == AllowDuplicates) || !added[2] {
+			t.Error(c.policy, added)
+		}
+		if value, ok := tree.Get(1); !ok || (c.policy != AllowDuplicates && value != c.value) {
+			t.Error(c.policy, value)
+		}
+		if tree.Count(1) != c.count || tree.Count(2) != 1 || tree.Count(3) != 0 || tree.Len() != c.len {
+			t.Error(c.policy, tree.Count(1), tree.Len())
+		}
+		if err := tree.Validate(); err != nil {
+			t.Error(c.policy, err)
+		}
+		// Each deletion removes one copy
+		for i := c.count; i != 0; i-- {
+			if !tree.Delete(1) || tree.Count(1) != i-1 {
+				t.Error(c.policy, i, tree.Count(1))
+			}
+		}
+		if tree.Delete(1) || tree.Contains(1) {
+			t.Error(c.policy, "key not deleted")
+		}
+	}
+}
+
+func TestCountDuplicates(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	tree := NewWithPolicy(CountDuplicates, subtreeSum)
+	counts := map[int]int{}
+	for i := 0; i != 2000; i++ {
+		k := r.Intn(50)
+		if r.Intn(3) == 0 {
+			if tree.Delete(k) != (counts[k] != 0) {
+				t.Fatal("delete", k)
+			}
+			if counts[k] != 0 {
+				counts[k]--
+			}
+		} else {
+			tree.InsertValue(k, k)
+			counts[k]++
+		}
+	}
+	if err := tree.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	checkAnnotations(t, tree.Root(), subtreeSum)
+	distinct := 0
+	for k, count := range counts {
+		if tree.Count(k) != count {
+			t.Error(k, tree.Count(k), count)
+		}
+		if count != 0 {
+			distinct++
+		}
+	}
+	if tree.Len() != distinct {
+		t.Error(tree.Len(), distinct)
+	}
+
+	// Counts survive encoding, and splitting and joining
+	data, err := tree.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored := NewWithPolicy(CountDuplicates, nil)
+	if err := restored.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := New().UnmarshalBinary(data); err == nil {
+		t.Error("counts decoded into a tree that does not count")
+	}
+	restored = Join(restored.Split(25))
+	for k, count := range counts {
+		if restored.Count(k) != count {
+			t.Error(k, restored.Count(k), count)
+		}
+	}
+	var buf bytes.Buffer
+	restored.Dump(&buf)
+	if !bytes.Contains(buf.Bytes(), []byte(" x")) {
+		t.Error("counts not drawn")
+	}
+}
+
+func TestJoinDistinct(t *testing.T) {
+	left, right := NewWithPolicy(RejectDuplicates, nil), NewWithPolicy(RejectDuplicates, nil)
+	left.Insert(1)
+	right.Insert(1)
+	defer func() {
+		if recover() == nil {
+			t.Error("joined trees sharing a key")
+		}
+	}()
+	Join(left, right)
+}
+
+func BenchmarkPolicy(b *testing.B) {
+	keys := make([]int, 1024)
+	r := rand.New(rand.NewSource(1))
+	for i := range keys {
+		keys[i] = r.Intn(256)
+	}
+	for name, policy := range map[string]Policy{"Allow": AllowDuplicates, "Count": CountDuplicates} {
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				tree := NewWithPolicy(policy, nil)
+				for _, key := range keys {
+					tree.Insert(key)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+// Printing trees
+//
+// Dump draws the tree on its side, in ASCII, with each node's key and color,
+// and its children below it, left before right:
+//
+//	8 B
+//	+-- 3 B
+//	|   +-- 1 R
+//	|   `-- 6 R
+//	`-- 10 B
+//	    +-- .
+//	    `-- 14 R
+//
+// A missing child is drawn as "." when its sibling is present, so that a lone
+// child's side is clear. The number of copies of a key counted more than
+// once, values, and annotations if the tree is augmented, follow the color:
+// "4 R x2 = four".
+
+package rbtree
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// String returns the drawing written by Dump
+func (tree *RedBlackTree) String() string {
+	var b strings.Builder
+	tree.Dump(&b)
+	return b.String()
+}
+
+// Dump writes a drawing of the tree to *w*, one node per line
+func (tree *RedBlackTree) Dump(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	if tree.root.isSentinel() {
+		bw.WriteString("(empty)\n")
+		return bw.Flush()
+	}
+	// Each entry is a node to draw, the prefix of its line, and whether it is
+	// the last child of its parent
+	type line struct {
+		node   *Node
+		prefix string
+		last   bool
+	}
+	stack := []line{{tree.root, "", true}}
+	for len(stack) != 0 {
+		l := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		below := ""
+		if l.node != tree.root {
+			branch := "+-- "
+			below = "|   "
+			if l.last {
+				branch, below = "`-- ", "    "
+			}
+			bw.WriteString(l.prefix + branch)
+		}
+		below = l.prefix + below
+		if l.node.isSentinel() {
+			bw.WriteString(".\n")
+			continue
+		}
+		bw.WriteString(tree.label(l.node) + "\n")
+		// The right child is pushed first, so that the left is drawn first
+		if !l.node.left.isSentinel() || !l.node.right.isSentinel() {
+			stack = append(stack, line{l.node.right, below, true}, line{l.node.left, below, false})
+		}
+	}
+	return bw.Flush()
+}
+
+// label describes a node in one line
+func (tree *RedBlackTree) label(n *Node) string {
+	s := strconv.Itoa(n.key) + " B"
+	if n.color == red {
+		s = strconv.Itoa(n.key) + " R"
+	}
+	if n.extra != 0 {
+		s += " x" + strconv.Itoa(n.Count())
+	}
+	if n.value != nil {
+		s += " = " + fmt.Sprint(n.value)
+	}
+	if tree.augment != nil {
+		s += " [" + fmt.Sprint(n.annotation) + "]"
+	}
+	return s
+}
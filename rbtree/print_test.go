@@ -0,0 +1,48 @@
+package rbtree
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestDump(t *testing.T) {
+	tree := New()
+	for _, key := range []int{8, 3, 10, 1, 6, 14} {
+		tree.Insert(key)
+	}
+	expected := strings.Join([]string{
+		"8 B",
+		"+-- 3 B",
+		"|   +-- 1 R",
+		"|   `-- 6 R",
+		"`-- 10 B",
+		"    +-- .",
+		"    `-- 14 R",
+		"",
+	}, "\n")
+	if s := tree.String(); s != expected {
+		t.Errorf("got\n%s\nexpected\n%s", s, expected)
+	}
+	if s := New().String(); s != "(empty)\n" {
+		t.Error(s)
+	}
+
+	tree = NewAugmented(subtreeSum)
+	tree.InsertValue(2, 20)
+	tree.InsertValue(1, 10)
+	if s := tree.String(); s != "2 B = 20 [30]\n+-- 1 R = 10 [10]\n`-- .\n" {
+		t.Error(s)
+	}
+}
+
+func BenchmarkDump(b *testing.B) {
+	tree := New()
+	for i := 0; i != 10000; i++ {
+		tree.Insert(i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tree.Dump(io.Discard)
+	}
+}
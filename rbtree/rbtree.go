@@ -18,12 +18,26 @@
  * and so is more suited for volatile data.
  *
  * The implementation below works for integer keys, but is easily modified
- * for any other orderable key. No special attention is given to duplicate
- * keys.
+ * for any other orderable key. Duplicate keys are kept in separate nodes,
+ * unless the tree is created with another Policy.
+ *
+ * Each node also records the size of its subtree, which makes the tree an
+ * "order-statistic tree" (CLRS chapter 14): the rank of a key and the key of
+ * a given rank can be found in O(log n), by counting the nodes in the left
+ * subtrees passed on the way down. Sizes change only along the search path
+ * on insertion and deletion, and in the two nodes of each rotation, so they
+ * cost O(1) extra per step.
  */
 
 package rbtree
 
+import (
+	"math/bits"
+
+	"github.com/njwilson23/datastructures/observe"
+	"github.com/njwilson23/datastructures/pair"
+)
+
 const (
 	red   = iota
 	black = iota
@@ -39,16 +53,420 @@ type Node struct {
 	right *Node
 	p     *Node
 	key   int
+	value interface{}
+	size  int // number of nodes in the subtree, which is 0 for the sentinel
+	// annotation is computed by the tree's Augment function, if it has one
+	annotation interface{}
+	extra      int // further copies of the key, under CountDuplicates
 }
 
 // RedBlackTree represents a red-black tree
+//
+// As in CLRS, every leaf and the parent of the root are a single black
+// sentinel node shared by the whole tree (T.nil), rather than a separate node
+// per leaf, so an inserted key allocates just one node. Unlike in CLRS, the
+// sentinel is never written, so trees made by Split and Join may share or
+// mix sentinels.
 type RedBlackTree struct {
-	root *Node
+	root     *Node
+	sentinel *Node
+	augment  Augment
+	policy   Policy
+	// events is only set once something has subscribed to changes
+	events *observe.Subject[int, interface{}]
+}
+
+// New creates an empty red-black tree, whose root is the sentinel node
+func New() *RedBlackTree {
+	sentinel := &Node{black, nil, nil, nil, 0, nil, 0, nil, 0}
+	return &RedBlackTree{sentinel, sentinel, nil, AllowDuplicates, nil}
+}
+
+// FromSlice creates a red-black tree containing the keys in *keys*, which need
+// not be sorted
+func FromSlice(keys []int) *RedBlackTree {
+	tree := New()
+	for _, key := range keys {
+		tree.Insert(key)
+	}
+	return tree
+}
+
+// FromSorted creates a red-black tree containing *keys*, which must be in
+// ascending order, in O(n) time rather than the O(n log n) of inserting them
+// one by one. It panics if the keys are not sorted.
+//
+// The middle key becomes the root, and the halves on either side its
+// subtrees, recursively, so the depths of the leaves differ by at most one.
+// Every level is then full except perhaps the deepest, whose nodes are
+// colored red, so that every path has the same number of black nodes. The
+// nodes are allocated together, in a single slice.
+func FromSorted(keys []int) *RedBlackTree {
+	for i := 1; i < len(keys); i++ {
+		if keys[i-1] > keys[i] {
+			panic("rbtree: keys are not sorted")
+		}
+	}
+	tree := New()
+	nodes := make([]Node, len(keys))
+	// Levels 0..full-1 are full, for the largest full with 2^full - 1 <= n
+	full := bits.Len(uint(len(keys)+1)) - 1
+	var build func(keys []int, parent *Node, depth int) *Node
+	build = func(keys []int, parent *Node, depth int) *Node {
+		if len(keys) == 0 {
+			return tree.sentinel
+		}
+		mid := len(keys) / 2
+		n := &nodes[0]
+		nodes = nodes[1:]
+		n.p, n.key, n.size = parent, keys[mid], len(keys)
+		n.color = black
+		if depth == full {
+			n.color = red
+		}
+		n.left = build(keys[:mid], n, depth+1)
+		n.right = build(keys[mid+1:], n, depth+1)
+		return n
+	}
+	tree.root = build(keys, tree.sentinel, 0)
+	return tree
+}
+
+// Keys returns the keys in the tree as a sorted slice
+func (tree *RedBlackTree) Keys() []int {
+	var keys []int
+	it := tree.Iter()
+	for key, ok := it.Next(); ok; key, ok = it.Next() {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// Item is a key and its attached value, as returned by Items
+type Item = pair.KeyValue[int, interface{}]
+
+// Items returns the keys in the tree and their values as a slice sorted by
+// key
+func (tree *RedBlackTree) Items() []Item {
+	var items []Item
+	it := tree.Iter()
+	for key, ok := it.Next(); ok; key, ok = it.Next() {
+		items = append(items, Item{Key: key, Value: it.Value()})
+	}
+	return items
+}
+
+// Iterator walks the keys of a RedBlackTree in ascending order, or in
+// descending order if made by IterReverse or RangeReverse.
+//
+// The walk is in-order (left subtree, node, right subtree), and is done lazily
+// using an explicit stack of the nodes whose right subtrees have not been
+// visited yet. The stack never holds more than one node per level of the tree,
+// so an Iterator uses O(log n) memory. The tree must not be modified while an
+// Iterator is in use. A descending walk is the mirror image, visiting right
+// subtrees first.
+type Iterator struct {
+	stack   []*Node
+	node    *Node
+	last    *Node
+	lo, hi  int
+	bounded bool // stop at keys no less than hi, or less than lo in reverse
+	reverse bool
+}
+
+// Iter returns an Iterator positioned before the smallest key in the tree
+func (tree *RedBlackTree) Iter() *Iterator {
+	return &Iterator{node: tree.root}
+}
+
+// Range returns an Iterator over the keys in [*lo*, *hi*), in ascending order.
+//
+// The stack is seeded with the nodes on the search path for lo whose keys are
+// no less than lo, which are exactly the nodes an in-order walk from the
+// smallest key would still have on its stack on reaching lo. Keys are then
+// produced lazily until one reaches hi, so iterating over k keys costs
+// O(log n + k), however large the tree.
+func (tree *RedBlackTree) Range(lo, hi int) *Iterator {
+	it := &Iterator{node: tree.sentinel, hi: hi, bounded: true}
+	for n := tree.root; !n.isSentinel(); {
+		if n.key >= lo {
+			it.stack = append(it.stack, n)
+			n = n.left
+		} else {
+			n = n.right
+		}
+	}
+	return it
+}
+
+// IterReverse returns an Iterator positioned after the largest key in the
+// tree, which visits the keys in descending order
+func (tree *RedBlackTree) IterReverse() *Iterator {
+	return &Iterator{node: tree.root, reverse: true}
+}
+
+// RangeReverse returns an Iterator over the keys in [*lo*, *hi*), in
+// descending order, which is seeded in the same way as by Range, from the
+// search path for hi, and costs O(log n + k) for k keys
+func (tree *RedBlackTree) RangeReverse(lo, hi int) *Iterator {
+	it := &Iterator{node: tree.sentinel, lo: lo, bounded: true, reverse: true}
+	for n := tree.root; !n.isSentinel(); {
+		if n.key < hi {
+			it.stack = append(it.stack, n)
+			n = n.right
+		} else {
+			n = n.left
+		}
+	}
+	return it
+}
+
+// Next returns the next key in the tree, or false if all keys have been
+// visited
+func (it *Iterator) Next() (int, bool) {
+	for len(it.stack) != 0 || !it.node.isSentinel() {
+		if !it.node.isSentinel() {
+			it.stack = append(it.stack, it.node)
+			if it.reverse {
+				it.node = it.node.right
+			} else {
+				it.node = it.node.left
+			}
+		} else {
+			n := it.stack[len(it.stack)-1]
+			if it.bounded && (!it.reverse && n.key >= it.hi || it.reverse && n.key < it.lo) {
+				it.stack = it.stack[:0]
+				return 0, false
+			}
+			it.stack = it.stack[:len(it.stack)-1]
+			if it.reverse {
+				it.node = n.left
+			} else {
+				it.node = n.right
+			}
+			it.last = n
+			return n.key, true
+		}
+	}
+	return 0, false
+}
+
+// Value returns the value of the key most recently returned by Next
+func (it *Iterator) Value() interface{} {
+	return it.last.value
+}
+
+// Contains returns true if *key* is in the tree
+func (tree *RedBlackTree) Contains(key int) bool {
+	return !tree.search(key).isSentinel()
+}
+
+// Search returns a node with *key*, or false if the key is not in the tree.
+// The sentinel is never returned: a missing key gives a nil node.
+func (tree *RedBlackTree) Search(key int) (*Node, bool) {
+	n := tree.search(key)
+	if n.isSentinel() {
+		return nil, false
+	}
+	return n, true
+}
+
+// Key returns the key of a node
+func (n *Node) Key() int {
+	return n.key
+}
+
+// Value returns the value attached to a node
+func (n *Node) Value() interface{} {
+	return n.value
+}
+
+// Get returns the value inserted with *key*, or false if the key is not in
+// the tree
+func (tree *RedBlackTree) Get(key int) (interface{}, bool) {
+	n := tree.search(key)
+	return n.value, !n.isSentinel()
+}
+
+// Floor returns the largest key in the tree that is no greater than *key*, or
+// false if there is none
+func (tree *RedBlackTree) Floor(key int) (int, bool) {
+	var found *Node
+	for n := tree.root; !n.isSentinel(); {
+		if n.key <= key {
+			found, n = n, n.right
+		} else {
+			n = n.left
+		}
+	}
+	if found == nil {
+		return 0, false
+	}
+	return found.key, true
+}
+
+// Ceiling returns the smallest key in the tree that is no less than *key*, or
+// false if there is none
+func (tree *RedBlackTree) Ceiling(key int) (int, bool) {
+	var found *Node
+	for n := tree.root; !n.isSentinel(); {
+		if n.key >= key {
+			found, n = n, n.left
+		} else {
+			n = n.right
+		}
+	}
+	if found == nil {
+		return 0, false
+	}
+	return found.key, true
+}
+
+// Successor returns the smallest key in the tree greater than *key*, which
+// need not be in the tree itself, or false if there is none
+func (tree *RedBlackTree) Successor(key int) (int, bool) {
+	var found *Node
+	for n := tree.root; !n.isSentinel(); {
+		if n.key > key {
+			found, n = n, n.left
+		} else {
+			n = n.right
+		}
+	}
+	if found == nil {
+		return 0, false
+	}
+	return found.key, true
+}
+
+// Predecessor returns the largest key in the tree less than *key*, which need
+// not be in the tree itself, or false if there is none
+func (tree *RedBlackTree) Predecessor(key int) (int, bool) {
+	var found *Node
+	for n := tree.root; !n.isSentinel(); {
+		if n.key < key {
+			found, n = n, n.right
+		} else {
+			n = n.left
+		}
+	}
+	if found == nil {
+		return 0, false
+	}
+	return found.key, true
 }
 
-// isSentinel returns true when a node represents a sentinal node
+// Min returns the smallest key in the tree, or false if the tree is empty
+func (tree *RedBlackTree) Min() (int, bool) {
+	n := tree.root
+	if n.isSentinel() {
+		return 0, false
+	}
+	for !n.left.isSentinel() {
+		n = n.left
+	}
+	return n.key, true
+}
+
+// Max returns the largest key in the tree, or false if the tree is empty
+func (tree *RedBlackTree) Max() (int, bool) {
+	n := tree.root
+	if n.isSentinel() {
+		return 0, false
+	}
+	for !n.right.isSentinel() {
+		n = n.right
+	}
+	return n.key, true
+}
+
+// Len returns the number of keys in the tree
+func (tree *RedBlackTree) Len() int {
+	return tree.root.size
+}
+
+// Height returns the number of nodes on the longest path from the root to a
+// leaf, which is 0 for an empty tree. A red-black tree of n nodes has height
+// at most 2 log2(n+1), since red nodes never follow each other and so make up
+// at most half of any path. Every node is visited, so Height is O(n).
+func (tree *RedBlackTree) Height() int {
+	type entry struct {
+		node  *Node
+		depth int
+	}
+	height := 0
+	stack := []entry{{tree.root, 1}}
+	for len(stack) != 0 {
+		e := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if e.node.isSentinel() {
+			continue
+		}
+		height = max(height, e.depth)
+		stack = append(stack, entry{e.node.left, e.depth + 1}, entry{e.node.right, e.depth + 1})
+	}
+	return height
+}
+
+// BlackHeight returns the number of black nodes on every path from the root
+// to a leaf, not counting the sentinel, in O(log n). The tree's height is at
+// least the black height and at most twice it.
+func (tree *RedBlackTree) BlackHeight() int {
+	return blackHeight(tree.root)
+}
+
+// Rank returns the number of keys in the tree less than *key*
+func (tree *RedBlackTree) Rank(key int) int {
+	rank := 0
+	for n := tree.root; !n.isSentinel(); {
+		if key <= n.key {
+			n = n.left
+		} else {
+			rank += n.left.size + 1
+			n = n.right
+		}
+	}
+	return rank
+}
+
+// Select returns the key with rank *i*, the (i+1)th smallest, or false if *i*
+// is not between 0 and Len()-1
+func (tree *RedBlackTree) Select(i int) (int, bool) {
+	if i < 0 || i >= tree.root.size {
+		return 0, false
+	}
+	n := tree.root
+	for {
+		switch left := n.left.size; {
+		case i < left:
+			n = n.left
+		case i == left:
+			return n.key, true
+		default:
+			i -= left + 1
+			n = n.right
+		}
+	}
+}
+
+// search returns a node with *key*, or the sentinel if there is none
+func (tree *RedBlackTree) search(key int) *Node {
+	n := tree.root
+	for !n.isSentinel() && key != n.key {
+		if key < n.key {
+			n = n.left
+		} else {
+			n = n.right
+		}
+	}
+	return n
+}
+
+// isSentinel returns true when a node represents a sentinal node. The sentinel
+// is the only node without children, which identifies it without a reference
+// to the tree.
 func (n *Node) isSentinel() bool {
-	return n.left == nil && n.right == nil && n.p == nil
+	return n.left == nil && n.right == nil
 }
 
 // Rotations
@@ -58,11 +476,11 @@ func (n *Node) isSentinel() bool {
 // operations. Rotations can be to the "left" or to the "right". In a left
 // rotation:
 //
-//       [n]                          [y]
-//      /   \                        /   \
-//     a    [y]        becomes     [n]    c
-//         /   \                  /   \
-//        b     c                a     b
+//	  [n]                          [y]
+//	 /   \                        /   \
+//	a    [y]        becomes     [n]    c
+//	    /   \                  /   \
+//	   b     c                a     b
 //
 // and in a right rotation, the operation is reversed. Note that the order
 // of child nodes a, b, and c remains the same, but that a is now deeper, and c
@@ -94,6 +512,8 @@ func (n *Node) rotateLeft() *Node {
 	}
 	y.left = n
 	n.p = y
+	y.size = n.size
+	n.size = n.left.size + n.right.size + 1
 	return root
 }
 
@@ -114,28 +534,64 @@ func (n *Node) rotateRight() *Node {
 	}
 	y.right = n
 	n.p = y
+	y.size = n.size
+	n.size = n.left.size + n.right.size + 1
 	return root
 }
 
+// rotateLeft rotates the tree left at *n*, keeping the root and annotations
+// up to date
+func (tree *RedBlackTree) rotateLeft(n *Node) {
+	if root := n.rotateLeft(); root != nil {
+		tree.root = root
+	}
+	tree.refresh(n)
+}
+
+// rotateRight rotates the tree right at *n*, keeping the root and annotations
+// up to date
+func (tree *RedBlackTree) rotateRight(n *Node) {
+	if root := n.rotateRight(); root != nil {
+		tree.root = root
+	}
+	tree.refresh(n)
+}
+
 // Insert adds a node with value *key* to a red black tree
 // This proceeds exactly the same as in an ordinary binary search tree, except
 // that the inserted node is given a color (red) and the tree is rebalanced
 // afterward to restore red-black properties by calling
 // `RedBlackTree.rebalanceInsert()`
-func (tree *RedBlackTree) Insert(key int) {
+func (tree *RedBlackTree) Insert(key int) bool {
+	return tree.InsertValue(key, nil)
+}
+
+// InsertValue adds a node with value *key* to a red black tree, and attaches
+// *value* to it, to be returned by Get. If the key is already in the tree,
+// the tree's Policy decides what happens, and InsertValue returns false
+// unless a node was added.
+func (tree *RedBlackTree) InsertValue(key int, value interface{}) bool {
+	if tree.policy != AllowDuplicates {
+		if n := tree.search(key); !n.isSentinel() {
+			tree.duplicate(n, value)
+			return false
+		}
+	}
 	childNode := tree.root
-	parentNode := &Node{black, nil, nil, nil, 0}
+	parentNode := tree.sentinel
 	var newNode *Node
 	// Follow tree until a leaf node is found
 	for !childNode.isSentinel() {
 		parentNode = childNode
+		childNode.size++
 		if key < childNode.key {
 			childNode = childNode.left
 		} else {
 			childNode = childNode.right
 		}
 	}
-	newNode = &Node{red, nil, nil, parentNode, key}
+	// The leaves below newNode are the sentinel
+	newNode = &Node{red, tree.sentinel, tree.sentinel, parentNode, key, value, 1, nil, 0}
 	if parentNode.isSentinel() {
 		// This can only happen when childNode is the root node, i.e. the tree is empty
 		tree.root = newNode
@@ -144,10 +600,42 @@ func (tree *RedBlackTree) Insert(key int) {
 	} else {
 		parentNode.right = newNode
 	}
-	// Place sentinel nodes below newNode
-	newNode.left = &Node{black, nil, nil, nil, 0}
-	newNode.right = &Node{black, nil, nil, nil, 0}
+	tree.refresh(newNode)
 	tree.rebalanceInsert(newNode)
+	tree.notify(observe.Put, key, value)
+	return true
+}
+
+// Put sets the value attached to *key*, inserting the key if it is not in the
+// tree, and returns true if it was inserted. Unlike InsertValue, it never
+// creates a duplicate key, so the tree can be used as a sorted map.
+func (tree *RedBlackTree) Put(key int, value interface{}) bool {
+	if n := tree.search(key); !n.isSentinel() {
+		n.value = value
+		tree.refresh(n)
+		tree.notify(observe.Put, key, value)
+		return false
+	}
+	tree.InsertValue(key, value)
+	return true
+}
+
+// Subscribe registers a callback that is called after every subsequent
+// change by InsertValue (or Insert), Put and Delete, and returns a function
+// that cancels the subscription. The trees made by Split and Join have no
+// subscribers.
+func (tree *RedBlackTree) Subscribe(fn func(observe.Event[int, interface{}])) (unsubscribe func()) {
+	if tree.events == nil {
+		tree.events = &observe.Subject[int, interface{}]{}
+	}
+	return tree.events.Subscribe(fn)
+}
+
+// notify tells the subscribers, if there are any, of a change
+func (tree *RedBlackTree) notify(op observe.Op, key int, value interface{}) {
+	if tree.events != nil {
+		tree.events.Notify(op, key, value)
+	}
 }
 
 // rebalanceInsert restores red-black properties to a tree following the
@@ -163,8 +651,11 @@ func (tree *RedBlackTree) Insert(key int) {
 // In the first case, the loop is skipped, the root color is set to black, and
 // the tree is now valid. In the second case, restoration of red-black
 // properties is somewhat more involved.
-func (tree *RedBlackTree) rebalanceInsert(z *Node) {
-	var y, t *Node
+//
+// It returns true if it painted a red root black, which adds a black node to
+// every path, as Join needs to know.
+func (tree *RedBlackTree) rebalanceInsert(z *Node) bool {
+	var y *Node
 
 	// With every cycle of this loop, one of two things will happen.
 	//
@@ -214,10 +705,7 @@ func (tree *RedBlackTree) rebalanceInsert(z *Node) {
 				//
 				// which is case 3.
 				z = z.p
-				t = z.rotateLeft()
-				if t != nil {
-					tree.root = t
-				}
+				tree.rotateLeft(z)
 			} else {
 				// In the third case, the uncle is black and z is a left child.
 				//
@@ -239,10 +727,7 @@ func (tree *RedBlackTree) rebalanceInsert(z *Node) {
 				// This completes the rebalancing.
 				z.p.color = black
 				z.p.p.color = red
-				t = z.p.p.rotateRight()
-				if t != nil {
-					tree.root = t
-				}
+				tree.rotateRight(z.p.p)
 			}
 		} else {
 			// This mirrors the logic from above with the tree flipped
@@ -254,30 +739,175 @@ func (tree *RedBlackTree) rebalanceInsert(z *Node) {
 				z = z.p.p
 			} else if z == z.p.left {
 				z = z.p
-				t = z.rotateRight()
-				if t != nil {
-					tree.root = t
-				}
+				tree.rotateRight(z)
 			} else {
 				z.p.color = black
 				z.p.p.color = red
-				t = z.p.p.rotateLeft()
-				if t != nil {
-					tree.root = t
-				}
+				tree.rotateLeft(z.p.p)
 			}
 		}
 	}
+	grew := tree.root.color == red
 	tree.root.color = black
+	return grew
 }
 
-// Delete removes a value from the red-black tree
-func (tree *RedBlackTree) Delete(key int) {
-	// Not implemented
-	var z *Node
-	tree.rebalanceDelete(z)
+// Delete removes a node with value *key* from the red-black tree, returning
+// false if there is none. Under CountDuplicates, it removes one copy of the
+// key, and the node only with the last.
+//
+// As in an ordinary binary search tree, a node with at most one child is
+// replaced by that child, and a node with two children is replaced by its
+// successor (the leftmost node of its right subtree), which has no left child
+// and so is itself easily removed from where it was. If the node that was
+// removed or moved was black, the paths through its old position have lost a
+// black node, and `RedBlackTree.rebalanceDelete()` restores the balance.
+func (tree *RedBlackTree) Delete(key int) bool {
+	z := tree.search(key)
+	if z.isSentinel() {
+		return false
+	}
+	value := z.value
+	if z.extra != 0 {
+		z.extra--
+		tree.refresh(z)
+	} else {
+		tree.deleteNode(z)
+	}
+	tree.notify(observe.Delete, key, value)
+	return true
 }
 
-func (tree *RedBlackTree) rebalanceDelete(z *Node) {
-	// Not implemented
+// deleteNode removes node *z* from the tree
+func (tree *RedBlackTree) deleteNode(z *Node) {
+	// y is the node removed from its position, and x the node that moves into
+	// it, which may be the sentinel, below parent
+	y := z
+	if !z.left.isSentinel() && !z.right.isSentinel() {
+		y = z.right
+		for !y.left.isSentinel() {
+			y = y.left
+		}
+	}
+	// Every node above y loses one node from its subtree
+	for n := y.p; !n.isSentinel(); n = n.p {
+		n.size--
+	}
+
+	removedColor := y.color
+	var x, parent *Node
+	if z.left.isSentinel() {
+		x, parent = z.right, z.p
+		tree.transplant(z, z.right)
+	} else if z.right.isSentinel() {
+		x, parent = z.left, z.p
+		tree.transplant(z, z.left)
+	} else {
+		removedColor = y.color
+		x, parent = y.right, y
+		if y.p != z {
+			parent = y.p
+			tree.transplant(y, y.right)
+			y.right = z.right
+			y.right.p = y
+		}
+		tree.transplant(z, y)
+		y.left = z.left
+		y.left.p = y
+		y.color = z.color
+		y.size = z.size
+	}
+	// The nodes whose subtrees changed are those from x's parent up
+	tree.refresh(parent)
+	if removedColor == black {
+		tree.rebalanceDelete(x, parent)
+	}
+}
+
+// transplant replaces the subtree rooted at *u* with the subtree rooted at *v*
+func (tree *RedBlackTree) transplant(u, v *Node) {
+	if u.p.isSentinel() {
+		tree.root = v
+	} else if u == u.p.left {
+		u.p.left = v
+	} else {
+		u.p.right = v
+	}
+	if !v.isSentinel() {
+		v.p = u.p
+	}
+}
+
+// rebalanceDelete restores red-black properties to a tree following the
+// removal of a black node.
+//
+// Node x has taken the place of the removed node, below *parent* (passed
+// separately, since x may be the sentinel), and is treated as carrying an
+// "extra" black to make up for the missing one. If x is red, it is simply
+// painted black. Otherwise, the extra black is moved up the tree, or resolved
+// with rotations, depending on x's sibling w:
+//
+//  1. w is red: a rotation makes x's sibling black, giving case 2, 3 or 4
+//  2. w is black with two black children: w is painted red, and the extra
+//     black moves to x's parent
+//  3. w is black, with a red child on x's side: a rotation gives case 4
+//  4. w is black, with a red child on the far side: a rotation and recoloring
+//     absorb the extra black, completing the rebalancing
+func (tree *RedBlackTree) rebalanceDelete(x, parent *Node) {
+	var w *Node
+	for x != tree.root && x.color == black {
+		if x == parent.left {
+			w = parent.right
+			if w.color == red {
+				w.color = black
+				parent.color = red
+				tree.rotateLeft(parent)
+				w = parent.right
+			}
+			if w.left.color == black && w.right.color == black {
+				w.color = red
+				x, parent = parent, parent.p
+			} else {
+				if w.right.color == black {
+					w.left.color = black
+					w.color = red
+					tree.rotateRight(w)
+					w = parent.right
+				}
+				w.color = parent.color
+				parent.color = black
+				w.right.color = black
+				tree.rotateLeft(parent)
+				x = tree.root
+			}
+		} else {
+			// This mirrors the logic from above with the tree flipped
+			w = parent.left
+			if w.color == red {
+				w.color = black
+				parent.color = red
+				tree.rotateRight(parent)
+				w = parent.left
+			}
+			if w.right.color == black && w.left.color == black {
+				w.color = red
+				x, parent = parent, parent.p
+			} else {
+				if w.left.color == black {
+					w.right.color = black
+					w.color = red
+					tree.rotateLeft(w)
+					w = parent.left
+				}
+				w.color = parent.color
+				parent.color = black
+				w.left.color = black
+				tree.rotateRight(parent)
+				x = tree.root
+			}
+		}
+	}
+	if !x.isSentinel() {
+		x.color = black
+	}
 }
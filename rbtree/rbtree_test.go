@@ -2,11 +2,17 @@ package rbtree
 
 import (
 	"fmt"
+	"math"
+	"math/rand"
+	"sort"
 	"testing"
+
+	"github.com/njwilson23/datastructures/observe"
+	"github.com/njwilson23/datastructures/pair"
 )
 
 func TestInsert1(t *testing.T) {
-	tree := RedBlackTree{&Node{black, nil, nil, nil, 0}}
+	tree := New()
 
 	tree.Insert(1)
 	tree.Insert(2)
@@ -19,22 +25,22 @@ func TestInsert1(t *testing.T) {
 }
 
 func TestInsert2(t *testing.T) {
-	tree := RedBlackTree{&Node{black, nil, nil, nil, 0}}
+	tree := New()
 	for i := 0; i != 100; i++ {
 		tree.Insert(i)
 	}
 
-	tree = RedBlackTree{&Node{black, nil, nil, nil, 0}}
+	tree = New()
 	for i := 100; i != 0; i-- {
 		tree.Insert(i)
 	}
 }
 
 func TestRebalance1(t *testing.T) {
-	sentinel := &Node{black, nil, nil, nil, 0}
-	A := &Node{red, nil, nil, nil, 1}
-	B := &Node{red, nil, nil, nil, 2}
-	C := &Node{black, nil, nil, nil, 3}
+	sentinel := &Node{black, nil, nil, nil, 0, nil, 0, nil, 0}
+	A := &Node{red, nil, nil, nil, 1, nil, 0, nil, 0}
+	B := &Node{red, nil, nil, nil, 2, nil, 0, nil, 0}
+	C := &Node{black, nil, nil, nil, 3, nil, 0, nil, 0}
 	C.left = A
 	C.right = sentinel
 	C.p = sentinel
@@ -44,6 +50,509 @@ func TestRebalance1(t *testing.T) {
 	A.left = sentinel
 	A.right = B
 	A.p = C
-	tree := RedBlackTree{C}
+	tree := RedBlackTree{C, sentinel, nil, AllowDuplicates, nil}
 	tree.rebalanceInsert(B)
 }
+
+func TestSliceConversion(t *testing.T) {
+	tree := FromSlice([]int{5, 3, 8, -1, 4, 4, 10})
+	keys := tree.Keys()
+	expected := []int{-1, 3, 4, 4, 5, 8, 10}
+	if len(keys) != len(expected) {
+		t.Fatal(keys)
+	}
+	for i := range keys {
+		if keys[i] != expected[i] {
+			t.Fatal(keys)
+		}
+	}
+
+	if len(New().Keys()) != 0 {
+		t.Fail()
+	}
+}
+
+func TestFromSorted(t *testing.T) {
+	for n := 0; n != 300; n++ {
+		keys := make([]int, n)
+		for i := range keys {
+			keys[i] = i / 2 // with duplicates
+		}
+		tree := FromSorted(keys)
+		if err := tree.Validate(); err != nil {
+			t.Fatal(n, err)
+		}
+		if fmt.Sprint(tree.Keys()) != fmt.Sprint(keys) {
+			t.Fatal(n, tree.Keys())
+		}
+		// The tree stays valid as it changes
+		tree.Insert(n)
+		tree.Delete(0)
+		if err := tree.Validate(); err != nil {
+			t.Fatal(n, err)
+		}
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("unsorted keys accepted")
+		}
+	}()
+	FromSorted([]int{2, 1})
+}
+
+func BenchmarkFromSorted(b *testing.B) {
+	keys := make([]int, 1<<16)
+	for i := range keys {
+		keys[i] = i
+	}
+	b.Run("FromSorted", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			FromSorted(keys)
+		}
+	})
+	b.Run("Insert", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			FromSlice(keys)
+		}
+	})
+}
+
+func TestIterator(t *testing.T) {
+	tree := FromSlice([]int{3, 1, 2})
+	it := tree.Iter()
+	for expected := 1; expected <= 3; expected++ {
+		key, ok := it.Next()
+		if !ok || key != expected {
+			t.Fail()
+		}
+	}
+	if _, ok := it.Next(); ok {
+		t.Fail()
+	}
+}
+
+func TestRange(t *testing.T) {
+	keys := []int{8, 3, 10, 1, 6, 14, 4, 7, 13, 6}
+	tree := FromSlice(keys)
+	sort.Ints(keys)
+	for lo := -1; lo != 16; lo++ {
+		for hi := lo - 1; hi != 17; hi++ {
+			var expected, got []int
+			for _, k := range keys {
+				if k >= lo && k < hi {
+					expected = append(expected, k)
+				}
+			}
+			it := tree.Range(lo, hi)
+			for key, ok := it.Next(); ok; key, ok = it.Next() {
+				got = append(got, key)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(expected) {
+				t.Fatal(lo, hi, got, expected)
+			}
+		}
+	}
+	tree = New()
+	tree.InsertValue(1, "a")
+	it := tree.Range(0, 2)
+	if key, ok := it.Next(); !ok || key != 1 || it.Value() != "a" {
+		t.Error(key, ok)
+	}
+	if _, ok := New().Range(0, 10).Next(); ok {
+		t.Error("empty tree")
+	}
+}
+
+func TestContains(t *testing.T) {
+	tree := FromSlice([]int{8, 3, 10, 1, 6, 14, 4, 7, 13})
+	for _, key := range []int{8, 3, 10, 1, 6, 14, 4, 7, 13} {
+		if !tree.Contains(key) {
+			t.Errorf("key %d not found", key)
+		}
+	}
+	for _, key := range []int{0, 2, 5, 9, 15} {
+		if tree.Contains(key) {
+			t.Errorf("key %d found", key)
+		}
+	}
+	if New().Contains(0) {
+		t.Fail()
+	}
+}
+
+func TestSearch(t *testing.T) {
+	tree := New()
+	tree.InsertValue(4, "four")
+	tree.Insert(2)
+	if n, ok := tree.Search(4); !ok || n.Key() != 4 || n.Value() != "four" {
+		t.Error(n, ok)
+	}
+	if n, ok := tree.Search(3); ok || n != nil {
+		t.Error("found missing key", n)
+	}
+	if n, ok := New().Search(0); ok || n != nil {
+		t.Error("found key in empty tree", n)
+	}
+}
+
+func TestFloorCeiling(t *testing.T) {
+	tree := FromSlice([]int{10, 20, 30})
+	for _, c := range []struct{ key, floor, ceiling int }{
+		{5, -1, 10}, {10, 10, 10}, {15, 10, 20}, {30, 30, 30}, {35, 30, -1},
+	} {
+		floor, ok := tree.Floor(c.key)
+		if !ok {
+			floor = -1
+		}
+		ceiling, ok := tree.Ceiling(c.key)
+		if !ok {
+			ceiling = -1
+		}
+		if floor != c.floor || ceiling != c.ceiling {
+			t.Error(c.key, floor, ceiling)
+		}
+	}
+}
+
+func TestSuccessorPredecessor(t *testing.T) {
+	tree := FromSlice([]int{10, 20, 20, 30})
+	for _, c := range []struct{ key, pred, succ int }{
+		{5, -1, 10}, {10, -1, 20}, {15, 10, 20}, {20, 10, 30}, {30, 20, -1}, {35, 30, -1},
+	} {
+		pred, ok := tree.Predecessor(c.key)
+		if !ok {
+			pred = -1
+		}
+		succ, ok := tree.Successor(c.key)
+		if !ok {
+			succ = -1
+		}
+		if pred != c.pred || succ != c.succ {
+			t.Error(c.key, pred, succ)
+		}
+	}
+	if _, ok := New().Successor(0); ok {
+		t.Error("successor in empty tree")
+	}
+}
+
+func TestMinMax(t *testing.T) {
+	tree := New()
+	if _, ok := tree.Min(); ok {
+		t.Error("min of empty tree")
+	}
+	if _, ok := tree.Max(); ok {
+		t.Error("max of empty tree")
+	}
+	for _, key := range []int{5, -3, 12, 7, -3} {
+		tree.Insert(key)
+	}
+	lo, ok1 := tree.Min()
+	hi, ok2 := tree.Max()
+	if !ok1 || !ok2 || lo != -3 || hi != 12 {
+		t.Error(lo, hi)
+	}
+	tree.Delete(12)
+	if hi, _ := tree.Max(); hi != 7 {
+		t.Error(hi)
+	}
+}
+
+// checkTree verifies the red-black properties and parent links below *n*, and
+// returns the number of black nodes on every path down from it
+func checkTree(t *testing.T, n *Node) int {
+	if n.isSentinel() {
+		return 1
+	}
+	for _, child := range []*Node{n.left, n.right} {
+		if !child.isSentinel() && child.p != n {
+			t.Fatalf("bad parent link below %d", n.key)
+		}
+		if n.color == red && child.color == red {
+			t.Fatalf("red node %d has a red child", n.key)
+		}
+	}
+	if n.size != n.left.size+n.right.size+1 {
+		t.Fatalf("wrong subtree size at %d", n.key)
+	}
+	left, right := checkTree(t, n.left), checkTree(t, n.right)
+	if left != right {
+		t.Fatalf("unequal black heights below %d", n.key)
+	}
+	if n.color == black {
+		left++
+	}
+	return left
+}
+
+func TestDelete(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	keys := rng.Perm(500)
+	tree := New()
+	for _, key := range keys {
+		tree.InsertValue(key, key*10)
+	}
+	if tree.Delete(500) {
+		t.Error("deleted a missing key")
+	}
+	for i, key := range keys {
+		if !tree.Delete(key) {
+			t.Fatalf("key %d not deleted", key)
+		}
+		if i%50 == 0 {
+			if tree.root.color != black {
+				t.Fatal("red root")
+			}
+			checkTree(t, tree.root)
+			for _, rest := range keys[i+1:] {
+				if value, ok := tree.Get(rest); !ok || value != rest*10 {
+					t.Fatalf("key %d lost", rest)
+				}
+			}
+		}
+	}
+	if !tree.root.isSentinel() || tree.sentinel.p != nil {
+		t.Error("tree not empty")
+	}
+}
+
+func TestIteratorValue(t *testing.T) {
+	tree := New()
+	tree.InsertValue(2, "b")
+	tree.InsertValue(1, "a")
+	it := tree.Iter()
+	it.Next()
+	if it.Value() != "a" {
+		t.Error(it.Value())
+	}
+	if _, ok := tree.Get(3); ok {
+		t.Fail()
+	}
+}
+
+func TestPut(t *testing.T) {
+	tree := New()
+	if !tree.Put(1, "a") || !tree.Put(2, "b") {
+		t.Error("new keys not inserted")
+	}
+	if tree.Put(1, "c") {
+		t.Error("existing key inserted")
+	}
+	if value, ok := tree.Get(1); !ok || value != "c" || tree.Len() != 2 {
+		t.Error(value, tree.Len())
+	}
+	tree.Delete(1)
+	if _, ok := tree.Get(1); ok || !tree.Put(1, "d") {
+		t.Error("key not deleted")
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	tree := NewWithPolicy(CountDuplicates, nil)
+	var events []observe.Event[int, interface{}]
+	unsubscribe := tree.Subscribe(func(e observe.Event[int, interface{}]) {
+		events = append(events, e)
+	})
+	tree.InsertValue(1, "a")
+	tree.InsertValue(1, "b")
+	tree.Put(2, "c")
+	tree.Put(2, "d")
+	tree.Delete(1)
+	tree.Delete(3)
+	if fmt.Sprint(events) != "[{Put 1 a} {Put 1 b} {Put 2 c} {Put 2 d} {Delete 1 a}]" {
+		t.Error(events)
+	}
+	unsubscribe()
+	tree.Delete(2)
+	if len(events) != 5 {
+		t.Error(events)
+	}
+
+	// A rejected duplicate changes nothing, so it is not reported
+	tree = NewWithPolicy(RejectDuplicates, nil)
+	events = nil
+	tree.Subscribe(func(e observe.Event[int, interface{}]) {
+		events = append(events, e)
+	})
+	tree.Insert(1)
+	tree.Insert(1)
+	if len(events) != 1 {
+		t.Error(events)
+	}
+}
+
+func TestItems(t *testing.T) {
+	tree := New()
+	tree.Put(3, "c")
+	tree.Put(1, "a")
+	tree.Insert(2)
+	items := tree.Items()
+	if fmt.Sprint(items) != "[{1 a} {2 <nil>} {3 c}]" {
+		t.Error(items)
+	}
+	if fmt.Sprint(pair.Keys(items)) != fmt.Sprint(tree.Keys()) {
+		t.Error(pair.Keys(items))
+	}
+}
+
+func TestInsertAllocations(t *testing.T) {
+	tree := New()
+	key := 0
+	allocs := testing.AllocsPerRun(100, func() {
+		tree.Insert(key)
+		key++
+	})
+	if allocs != 1 {
+		t.Errorf("%v allocations per Insert", allocs)
+	}
+	if !tree.sentinel.isSentinel() || tree.sentinel.color != black {
+		t.Error("sentinel was modified")
+	}
+}
+
+// Before the sentinel was shared, Insert allocated two sentinel nodes besides
+// the new node
+func BenchmarkInsert(b *testing.B) {
+	keys := rand.New(rand.NewSource(1)).Perm(1024)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		tree := New()
+		for _, key := range keys {
+			tree.Insert(key)
+		}
+	}
+}
+
+func TestRankSelect(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	tree := New()
+	var keys []int
+	for i := 0; i != 2000; i++ {
+		if len(keys) != 0 && r.Intn(3) == 0 {
+			j := r.Intn(len(keys))
+			tree.Delete(keys[j])
+			keys = append(keys[:j], keys[j+1:]...)
+		} else {
+			// Keys repeat, which Rank and Select must count
+			k := r.Intn(500)
+			tree.Insert(k)
+			keys = append(keys, k)
+		}
+	}
+	checkTree(t, tree.root)
+	sort.Ints(keys)
+	if tree.Len() != len(keys) {
+		t.Fatal(tree.Len(), len(keys))
+	}
+	for i, k := range keys {
+		if selected, ok := tree.Select(i); !ok || selected != k {
+			t.Fatal("select", i, selected, k)
+		}
+	}
+	for k := -1; k != 501; k++ {
+		if rank := tree.Rank(k); rank != sort.SearchInts(keys, k) {
+			t.Fatal("rank", k, rank)
+		}
+	}
+	if _, ok := tree.Select(len(keys)); ok {
+		t.Error("selected beyond the end")
+	}
+	if _, ok := tree.Select(-1); ok {
+		t.Error("selected before the start")
+	}
+}
+
+func TestHeight(t *testing.T) {
+	tree := New()
+	if tree.Height() != 0 || tree.BlackHeight() != 0 {
+		t.Error(tree.Height(), tree.BlackHeight())
+	}
+	r := rand.New(rand.NewSource(1))
+	for n := 1; n <= 4096; n++ {
+		tree.Insert(r.Intn(1000))
+		if n&(n-1) != 0 {
+			continue
+		}
+		h, bh := tree.Height(), tree.BlackHeight()
+		if bh != checkTree(t, tree.root)-1 {
+			t.Fatal(n, "black height", bh)
+		}
+		if h < bh || h > 2*bh || float64(h) > 2*math.Log2(float64(n+1)) {
+			t.Fatal(n, h, bh)
+		}
+	}
+	// Ascending insertions leave a tree no taller than the bound either
+	tree = New()
+	for i := 0; i != 1000; i++ {
+		tree.Insert(i)
+	}
+	if h := tree.Height(); float64(h) > 2*math.Log2(1001) || h < 10 {
+		t.Error(h)
+	}
+}
+
+func TestReverse(t *testing.T) {
+	keys := []int{8, 3, 10, 1, 6, 14, 4, 7, 13, 6}
+	tree := FromSlice(keys)
+	sort.Sort(sort.Reverse(sort.IntSlice(keys)))
+	var got []int
+	it := tree.IterReverse()
+	for key, ok := it.Next(); ok; key, ok = it.Next() {
+		got = append(got, key)
+	}
+	if fmt.Sprint(got) != fmt.Sprint(keys) {
+		t.Fatal(got)
+	}
+	for lo := -1; lo != 16; lo++ {
+		for hi := lo - 1; hi != 17; hi++ {
+			var expected []int
+			got = nil
+			for _, k := range keys {
+				if k >= lo && k < hi {
+					expected = append(expected, k)
+				}
+			}
+			it := tree.RangeReverse(lo, hi)
+			for key, ok := it.Next(); ok; key, ok = it.Next() {
+				got = append(got, key)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(expected) {
+				t.Fatal(lo, hi, got, expected)
+			}
+		}
+	}
+	tree = New()
+	tree.InsertValue(1, "a")
+	tree.InsertValue(2, "b")
+	it = tree.RangeReverse(0, 2)
+	if key, ok := it.Next(); !ok || key != 1 || it.Value() != "a" {
+		t.Error(key, ok)
+	}
+	if _, ok := New().IterReverse().Next(); ok {
+		t.Error("empty tree")
+	}
+}
+
+func BenchmarkRangeReverse(b *testing.B) {
+	tree := FromSlice(rand.New(rand.NewSource(1)).Perm(1 << 16))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// A page of 20 keys, from the end of the tree
+		it := tree.RangeReverse(0, 1<<16-1000)
+		for j := 0; j != 20; j++ {
+			it.Next()
+		}
+	}
+}
+
+func TestLen(t *testing.T) {
+	tree := New()
+	for i := 0; i != 100; i++ {
+		tree.Insert(i % 10)
+	}
+	tree.Delete(3)
+	tree.Delete(100)
+	if tree.Len() != 99 {
+		t.Error(tree.Len())
+	}
+}
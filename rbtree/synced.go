@@ -0,0 +1,132 @@
+// Safe concurrent use
+//
+// A RedBlackTree must not be used by several goroutines at once. SyncTree
+// wraps one behind a sync.RWMutex: any number of goroutines may read it at
+// the same time, while writes take the lock exclusively. Lookups never modify
+// the tree, so they are safe to run in parallel under the read lock.
+//
+// Choosing a wrapper:
+//
+//  - SyncTree suits read-mostly workloads on many cores, since readers do not
+//    wait for each other. Taking the read lock still writes to a counter
+//    shared by all readers, so the gain over a plain mutex shrinks as
+//    lookups get cheaper, and when writes are frequent readers queue behind
+//    them anyway.
+//  - A plain sync.Mutex around a RedBlackTree is a little cheaper per
+//    operation than SyncTree, and is the better choice on few cores or for
+//    write-heavy use.
+//  - skiplist.ConcurrentOrderedMap takes no locks for lookups and locks only
+//    the neighbors of a key for writes, so it scales best when both reads and
+//    writes are frequent, at the cost of slower single-threaded operations.
+//
+// The deterministic skip-list, skiplist.Deterministic, cannot be shared in
+// this way even by readers only, because Get writes the search key into its
+// bottom sentinel; it needs a plain mutex.
+
+package rbtree
+
+import "sync"
+
+// SyncTree is a RedBlackTree that is safe for concurrent use. The zero value
+// is not usable; use NewSync.
+type SyncTree struct {
+	mu   sync.RWMutex
+	tree *RedBlackTree
+}
+
+// SyncRedBlackTree is another name for SyncTree
+type SyncRedBlackTree = SyncTree
+
+// NewSync creates an empty SyncTree
+func NewSync() *SyncTree {
+	return &SyncTree{tree: New()}
+}
+
+// Insert adds *key* to the tree, and returns false unless a node was added
+// (see RedBlackTree.InsertValue)
+func (s *SyncTree) Insert(key int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tree.Insert(key)
+}
+
+// Put sets the value attached to *key*, and returns true if the key was not
+// already in the tree (see RedBlackTree.Put)
+func (s *SyncTree) Put(key int, value interface{}) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tree.Put(key, value)
+}
+
+// Delete removes *key* from the tree, and returns false if it was not there
+func (s *SyncTree) Delete(key int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tree.Delete(key)
+}
+
+// Contains returns true if *key* is in the tree
+func (s *SyncTree) Contains(key int) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.tree.Contains(key)
+}
+
+// Get returns the value attached to *key*, or false if the key is not in the
+// tree
+func (s *SyncTree) Get(key int) (interface{}, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.tree.Get(key)
+}
+
+// Floor returns the largest key no greater than *key*, or false if there is
+// none
+func (s *SyncTree) Floor(key int) (int, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.tree.Floor(key)
+}
+
+// Ceiling returns the smallest key no less than *key*, or false if there is
+// none
+func (s *SyncTree) Ceiling(key int) (int, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.tree.Ceiling(key)
+}
+
+// Min returns the smallest key, or false if the tree is empty
+func (s *SyncTree) Min() (int, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.tree.Min()
+}
+
+// Max returns the largest key, or false if the tree is empty
+func (s *SyncTree) Max() (int, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.tree.Max()
+}
+
+// Len returns the number of keys in the tree
+func (s *SyncTree) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.tree.Len()
+}
+
+// Range calls *f* with each key in [*lo*, *hi*) and its value, in ascending
+// order, until *f* returns false. The read lock is held throughout, so *f*
+// sees a consistent snapshot, but must not modify the tree.
+func (s *SyncTree) Range(lo, hi int, f func(key int, value interface{}) bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	it := s.tree.Range(lo, hi)
+	for key, ok := it.Next(); ok; key, ok = it.Next() {
+		if !f(key, it.Value()) {
+			return
+		}
+	}
+}
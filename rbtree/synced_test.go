@@ -0,0 +1,136 @@
+package rbtree
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+func TestSyncTree(t *testing.T) {
+	s := NewSync()
+	var wg sync.WaitGroup
+	for g := 0; g != 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := g; i < 1000; i += 4 {
+				s.Put(i, i*10)
+				if value, ok := s.Get(i); !ok || value != i*10 {
+					t.Error(i, value)
+				}
+				s.Contains(i + 1)
+			}
+		}(g)
+	}
+	wg.Wait()
+	if s.Len() != 1000 {
+		t.Fatal(s.Len())
+	}
+	for i := 0; i < 1000; i += 2 {
+		s.Delete(i)
+	}
+	count := 0
+	s.Range(100, 200, func(key int, value interface{}) bool {
+		if key%2 == 0 || value != key*10 {
+			t.Error(key, value)
+		}
+		count++
+		return true
+	})
+	if count != 50 {
+		t.Error(count)
+	}
+	if lo, _ := s.Min(); lo != 1 {
+		t.Error(lo)
+	}
+	if hi, _ := s.Max(); hi != 999 {
+		t.Error(hi)
+	}
+	if key, _ := s.Floor(500); key != 499 {
+		t.Error(key)
+	}
+	if key, _ := s.Ceiling(500); key != 501 {
+		t.Error(key)
+	}
+}
+
+func TestSyncRedBlackTreeInsert(t *testing.T) {
+	var s *SyncRedBlackTree = NewSync()
+	if !s.Insert(1) || !s.Insert(1) || s.Len() != 2 {
+		t.Error(s.Len())
+	}
+	s.tree.policy = RejectDuplicates
+	if s.Insert(1) || !s.Insert(2) || s.Len() != 3 {
+		t.Error(s.Len())
+	}
+}
+
+// Benchmarks comparing SyncTree with a RedBlackTree behind a plain mutex
+//
+// Every goroutine looks up random keys in a tree of 2^16 keys, and inserts or
+// deletes one in *writePercent* of operations; run with -cpu to vary the
+// number of goroutines. On a single core the plain mutex is about 5% faster,
+// as it does less bookkeeping. Only with several cores can the readers of a
+// SyncTree actually run in parallel, so compare the two on the machine that
+// will run them, with the expected share of writes.
+
+type mutexTree struct {
+	mu   sync.Mutex
+	tree *RedBlackTree
+}
+
+func benchmarkSync(b *testing.B, writePercent int) {
+	const keys = 1 << 16
+	b.Run("SyncTree", func(b *testing.B) {
+		s := NewSync()
+		for i := 0; i < keys; i += 2 {
+			s.Put(i, nil)
+		}
+		b.RunParallel(func(pb *testing.PB) {
+			r := rand.New(rand.NewSource(rand.Int63()))
+			for pb.Next() {
+				key := r.Intn(keys)
+				if r.Intn(100) < writePercent {
+					if !s.Delete(key) {
+						s.Put(key, nil)
+					}
+				} else {
+					s.Contains(key)
+				}
+			}
+		})
+	})
+	b.Run("Mutex", func(b *testing.B) {
+		m := &mutexTree{tree: New()}
+		for i := 0; i < keys; i += 2 {
+			m.tree.Put(i, nil)
+		}
+		b.RunParallel(func(pb *testing.PB) {
+			r := rand.New(rand.NewSource(rand.Int63()))
+			for pb.Next() {
+				key := r.Intn(keys)
+				m.mu.Lock()
+				if r.Intn(100) < writePercent {
+					if !m.tree.Delete(key) {
+						m.tree.Put(key, nil)
+					}
+				} else {
+					m.tree.Contains(key)
+				}
+				m.mu.Unlock()
+			}
+		})
+	})
+}
+
+func BenchmarkSyncReadOnly(b *testing.B) {
+	benchmarkSync(b, 0)
+}
+
+func BenchmarkSyncReadHeavy(b *testing.B) {
+	benchmarkSync(b, 5)
+}
+
+func BenchmarkSyncWriteHeavy(b *testing.B) {
+	benchmarkSync(b, 50)
+}
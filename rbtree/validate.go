@@ -0,0 +1,91 @@
+// Validation
+//
+// Validate checks every invariant the tree relies on, so that tests can call
+// it after sequences of random operations and report the first violation:
+//
+//   - the root is black, and the sentinels black and unmodified
+//   - no red node has a red child
+//   - every path from a node down to a leaf passes the same number of black
+//     nodes
+//   - every child links back to its parent
+//   - keys are in order, so an in-order walk never decreases, and increases
+//     if the tree's Policy keeps keys distinct
+//   - only a tree with CountDuplicates counts copies of keys
+//   - every node's size is that of its subtree
+//
+// It visits every node, so it is O(n), and meant for testing only.
+
+package rbtree
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrInvalid = errors.New("invalid red-black tree")
+
+// Validate returns an error wrapping ErrInvalid, and describing the first
+// violated invariant found, or nil if the tree is valid
+func (tree *RedBlackTree) Validate() error {
+	if tree.sentinel.color != black || !tree.sentinel.isSentinel() || tree.sentinel.p != nil {
+		return fmt.Errorf("%w: sentinel modified", ErrInvalid)
+	}
+	if tree.root.isSentinel() {
+		return nil
+	}
+	if tree.root.color != black {
+		return fmt.Errorf("%w: red root %d", ErrInvalid, tree.root.key)
+	}
+	if !tree.root.p.isSentinel() {
+		return fmt.Errorf("%w: root %d has a parent", ErrInvalid, tree.root.key)
+	}
+	first := true
+	last := 0
+	_, err := tree.validate(tree.root, &first, &last)
+	return err
+}
+
+// validate checks the subtree of *n* in order, with *last* the previous key
+// visited, and returns its black height
+func (tree *RedBlackTree) validate(n *Node, first *bool, last *int) (int, error) {
+	if n.isSentinel() {
+		// Split and Join may leave leaves from more than one tree
+		if n.color != black || n.size != 0 || n.p != nil {
+			return 0, fmt.Errorf("%w: sentinel modified", ErrInvalid)
+		}
+		return 1, nil
+	}
+	for _, child := range []*Node{n.left, n.right} {
+		if !child.isSentinel() && child.p != n {
+			return 0, fmt.Errorf("%w: child of %d links to the wrong parent", ErrInvalid, n.key)
+		}
+		if n.color == red && child.color == red {
+			return 0, fmt.Errorf("%w: red node %d has a red child", ErrInvalid, n.key)
+		}
+	}
+	left, err := tree.validate(n.left, first, last)
+	if err != nil {
+		return 0, err
+	}
+	if !*first && (n.key < *last || n.key == *last && tree.policy != AllowDuplicates) {
+		return 0, fmt.Errorf("%w: key %d follows %d", ErrInvalid, n.key, *last)
+	}
+	if n.extra < 0 || n.extra != 0 && tree.policy != CountDuplicates {
+		return 0, fmt.Errorf("%w: count %d of key %d", ErrInvalid, n.Count(), n.key)
+	}
+	*first, *last = false, n.key
+	right, err := tree.validate(n.right, first, last)
+	if err != nil {
+		return 0, err
+	}
+	if left != right {
+		return 0, fmt.Errorf("%w: unequal black heights below %d", ErrInvalid, n.key)
+	}
+	if n.size != n.left.size+n.right.size+1 {
+		return 0, fmt.Errorf("%w: wrong subtree size at %d", ErrInvalid, n.key)
+	}
+	if n.color == black {
+		left++
+	}
+	return left, nil
+}
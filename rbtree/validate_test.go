@@ -0,0 +1,48 @@
+package rbtree
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	tree := New()
+	if err := tree.Validate(); err != nil {
+		t.Error(err)
+	}
+	for i := 0; i != 1000; i++ {
+		if r.Intn(3) == 0 {
+			tree.Delete(r.Intn(100))
+		} else {
+			tree.Insert(r.Intn(100))
+		}
+		if err := tree.Validate(); err != nil {
+			t.Fatal(i, err)
+		}
+	}
+
+	// Each corruption is caught
+	for name, corrupt := range map[string]func(tree *RedBlackTree){
+		"red root":     func(tree *RedBlackTree) { tree.root.color = red },
+		"red-red":      func(tree *RedBlackTree) { tree.root.left.color, tree.root.left.left.color = red, red },
+		"black height": func(tree *RedBlackTree) { tree.root.left.color = red ^ black ^ tree.root.left.color },
+		"parent link":  func(tree *RedBlackTree) { tree.root.right.p = tree.root.left },
+		"order":        func(tree *RedBlackTree) { tree.root.key = -1 },
+		"size":         func(tree *RedBlackTree) { tree.root.left.size++ },
+	} {
+		tree := FromSlice(r.Perm(100))
+		corrupt(tree)
+		if err := tree.Validate(); !errors.Is(err, ErrInvalid) {
+			t.Error(name, err)
+		}
+	}
+}
+
+func BenchmarkValidate(b *testing.B) {
+	tree := FromSlice(rand.New(rand.NewSource(1)).Perm(1 << 16))
+	for i := 0; i < b.N; i++ {
+		tree.Validate()
+	}
+}
@@ -0,0 +1,162 @@
+package rmq
+
+// Cartesian trees and the reduction from RMQ to lowest common ancestors
+//
+// The Cartesian tree of an array is a binary tree with one node per element.
+// Its root is the minimum element, the left subtree is the Cartesian tree of
+// the elements before it, and the right subtree that of the elements after
+// it. An in-order walk gives back the array, and every node is smaller than
+// its descendants, so the tree is both a binary search tree on positions and
+// a heap on values ("treap").
+//
+//    data:   3  1  4  0  5  2            0
+//                                       / \
+//                                      1   2
+//                                     / \  /
+//                                    3  4  5
+//
+// The minimum of data[l..r] is the lowest common ancestor of positions l and
+// r, since that is the first node at which l and r fall on different sides
+// (or are the node itself). Conversely, lowest common ancestors can be found
+// with RMQ: an Euler tour writes down every node each time the walk passes
+// through it, and between the visits of two nodes the shallowest node on the
+// tour is their lowest common ancestor.
+//
+// RMQ combines the two: it builds the Cartesian tree, tours it, and answers
+// queries with a SparseTable over the depths along the tour. Unlike a
+// SparseTable directly over the data, this returns the position of the
+// minimum, not just its value. (Adjacent depths on the tour differ by exactly
+// one, which Bender and Farach-Colton exploit to build the table in O(n)
+// rather than O(n log n), but that refinement is not implemented here.)
+
+import "cmp"
+
+// CartesianTree is the Cartesian tree of an array, with nodes identified by
+// their positions in the array. Missing children and the root's parent are -1.
+type CartesianTree struct {
+	Root   int
+	Left   []int
+	Right  []int
+	Parent []int
+}
+
+// NewCartesianTree builds the Cartesian tree of *data* in O(n). When a value
+// is repeated, the leftmost occurrence is the ancestor.
+//
+// Elements are added from left to right, and a stack holds the right spine of
+// the tree so far (the path from the root down through right children). A new
+// element belongs at the bottom of the spine, below every element that is
+// not larger than it; the elements above it on the stack that are larger
+// become its left subtree.
+func NewCartesianTree[T cmp.Ordered](data []T) *CartesianTree {
+	n := len(data)
+	t := &CartesianTree{Root: -1, Left: make([]int, n), Right: make([]int, n), Parent: make([]int, n)}
+	var spine []int
+	for i := range data {
+		t.Left[i], t.Right[i], t.Parent[i] = -1, -1, -1
+		last := -1
+		for len(spine) != 0 && data[spine[len(spine)-1]] > data[i] {
+			last = spine[len(spine)-1]
+			spine = spine[:len(spine)-1]
+		}
+		if last != -1 {
+			t.Left[i] = last
+			t.Parent[last] = i
+		}
+		if len(spine) != 0 {
+			top := spine[len(spine)-1]
+			t.Right[top] = i
+			t.Parent[i] = top
+		}
+		spine = append(spine, i)
+	}
+	if len(spine) != 0 {
+		t.Root = spine[0]
+	}
+	return t
+}
+
+// RMQ answers range-minimum queries with the position of the minimum
+type RMQ struct {
+	first []int // first position of each node on the tour
+	nodes []int // the node at each position on the tour
+	table *SparseTable[int]
+}
+
+// NewRMQ builds an RMQ over *data* in O(n log n)
+func NewRMQ[T cmp.Ordered](data []T) *RMQ {
+	t := NewCartesianTree(data)
+	r := &RMQ{first: make([]int, len(data))}
+	if len(data) == 0 {
+		return r
+	}
+
+	// The tour records a node when it is entered, and again after returning
+	// from each of its children (or from where a missing child would be). It
+	// is walked with an explicit stack, since a sorted array gives a tree as
+	// deep as the array is long.
+	type frame struct {
+		node, depth int
+		visits      int
+	}
+	var depths []int
+	stack := []frame{{t.Root, 0, 0}}
+	for len(stack) != 0 {
+		f := &stack[len(stack)-1]
+		if f.visits == 0 {
+			r.first[f.node] = len(r.nodes)
+		}
+		r.nodes = append(r.nodes, f.node)
+		depths = append(depths, f.depth)
+		f.visits++
+		child := -1
+		switch f.visits {
+		case 1:
+			child = t.Left[f.node]
+		case 2:
+			child = t.Right[f.node]
+		default:
+			stack = stack[:len(stack)-1]
+		}
+		if child != -1 {
+			stack = append(stack, frame{child, f.depth + 1, 0})
+		}
+	}
+
+	// The table holds positions on the tour, and picks the shallowest
+	positions := make([]int, len(r.nodes))
+	for i := range positions {
+		positions[i] = i
+	}
+	r.table = NewSparseTable(positions, func(a, b int) int {
+		if depths[b] < depths[a] {
+			return b
+		}
+		return a
+	})
+	return r
+}
+
+// Query returns the position of the minimum in [l, r), which must be
+// non-empty. If the minimum is repeated, the leftmost position is returned.
+func (r *RMQ) Query(l, rr int) int {
+	if l < 0 || rr > len(r.first) || l >= rr {
+		panic("rmq: invalid range")
+	}
+	// The first visits of l and r-1 may be in either order, since one may be
+	// the ancestor of the other
+	a, b := r.first[l], r.first[rr-1]
+	if a > b {
+		a, b = b, a
+	}
+	return r.nodes[r.table.Query(a, b+1)]
+}
+
+// LCA returns the lowest common ancestor of two nodes of the Cartesian tree
+// that an RMQ was built from
+func (r *RMQ) LCA(u, v int) int {
+	if u > v {
+		u, v = v, u
+	}
+	return r.Query(u, v+1)
+}
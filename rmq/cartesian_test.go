@@ -0,0 +1,61 @@
+package rmq
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestCartesianTree(t *testing.T) {
+	tree := NewCartesianTree([]int{3, 1, 4, 0, 5, 2})
+	if tree.Root != 3 {
+		t.Fatal(tree.Root)
+	}
+	expected := []struct{ left, right, parent int }{
+		{-1, -1, 1}, {0, 2, 3}, {-1, -1, 1}, {1, 5, -1}, {-1, -1, 5}, {4, -1, 3},
+	}
+	for i, e := range expected {
+		if tree.Left[i] != e.left || tree.Right[i] != e.right || tree.Parent[i] != e.parent {
+			t.Errorf("node %d: %d %d %d", i, tree.Left[i], tree.Right[i], tree.Parent[i])
+		}
+	}
+	if NewCartesianTree([]int{}).Root != -1 {
+		t.Fail()
+	}
+}
+
+func TestRMQ(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, n := range []int{1, 2, 10, 200} {
+		data := make([]int, n)
+		for i := range data {
+			data[i] = r.Intn(20)
+		}
+		rmq := NewRMQ(data)
+		for l := 0; l != n; l++ {
+			best := l
+			for rr := l + 1; rr <= n; rr++ {
+				if data[rr-1] < data[best] {
+					best = rr - 1
+				}
+				if pos := rmq.Query(l, rr); pos != best {
+					t.Fatalf("n=%d: minimum of [%d, %d) at %d, expected %d", n, l, rr, pos, best)
+				}
+			}
+		}
+	}
+}
+
+func TestRMQSorted(t *testing.T) {
+	// A sorted array gives a tree that is a single path
+	data := make([]int, 10000)
+	for i := range data {
+		data[i] = i
+	}
+	rmq := NewRMQ(data)
+	if rmq.Query(500, 9000) != 500 {
+		t.Fail()
+	}
+	if rmq.LCA(9000, 500) != 500 {
+		t.Fail()
+	}
+}
@@ -0,0 +1,103 @@
+/*
+ * Package rmq answers range queries, such as range-minimum queries (RMQ),
+ * over arrays that do not change.
+ *
+ * A SparseTable precomputes the answer for every range whose length is a
+ * power of two: level j holds, for every position i, the answer over
+ * [i, i+2^j). Each level is built from the one below, by combining two
+ * adjacent ranges of half the length, so building takes O(n log n) time and
+ * space.
+ *
+ *    data:     5  2  7  1  8  3
+ *    level 0:  5  2  7  1  8  3     min over [i, i+1)
+ *    level 1:  2  2  1  1  3        min over [i, i+2)
+ *    level 2:  1  1  1              min over [i, i+4)
+ *
+ * Any range [l, r) is covered by two power-of-two ranges of the same length
+ * 2^j <= r-l, one starting at l and one ending at r. They may overlap, which
+ * is harmless for operations where combining a value with itself changes
+ * nothing ("idempotent" operations, such as min, max, gcd, and bitwise and and
+ * or), so a query combines just two table entries in O(1). Operations that
+ * are not idempotent, such as sum, would count the overlap twice and cannot
+ * be used.
+ */
+
+package rmq
+
+import (
+	"cmp"
+	"math/bits"
+)
+
+// SparseTable answers queries combining the elements of any range of an
+// array with an associative, idempotent operation
+type SparseTable[T any] struct {
+	levels [][]T
+	op     func(a, b T) T
+}
+
+// NewSparseTable builds a SparseTable over *data* for the operation *op*,
+// which must be associative and idempotent
+func NewSparseTable[T any](data []T, op func(a, b T) T) *SparseTable[T] {
+	level := make([]T, len(data))
+	copy(level, data)
+	s := &SparseTable[T]{levels: [][]T{level}, op: op}
+	for width := 1; 2*width <= len(data); width *= 2 {
+		prev := s.levels[len(s.levels)-1]
+		next := make([]T, len(prev)-width)
+		for i := range next {
+			next[i] = op(prev[i], prev[i+width])
+		}
+		s.levels = append(s.levels, next)
+	}
+	return s
+}
+
+// NewMin builds a SparseTable answering range-minimum queries
+func NewMin[T cmp.Ordered](data []T) *SparseTable[T] {
+	return NewSparseTable(data, func(a, b T) T {
+		if b < a {
+			return b
+		}
+		return a
+	})
+}
+
+// NewMax builds a SparseTable answering range-maximum queries
+func NewMax[T cmp.Ordered](data []T) *SparseTable[T] {
+	return NewSparseTable(data, func(a, b T) T {
+		if b > a {
+			return b
+		}
+		return a
+	})
+}
+
+// GCD returns the greatest common divisor of two integers, for use as the
+// operation of a SparseTable
+func GCD(a, b int) int {
+	if a < 0 {
+		a = -a
+	}
+	if b < 0 {
+		b = -b
+	}
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// Len returns the length of the array
+func (s *SparseTable[T]) Len() int {
+	return len(s.levels[0])
+}
+
+// Query combines the elements in positions [l, r), which must be non-empty
+func (s *SparseTable[T]) Query(l, r int) T {
+	if l < 0 || r > s.Len() || l >= r {
+		panic("rmq: invalid range")
+	}
+	j := bits.Len(uint(r-l)) - 1
+	return s.op(s.levels[j][l], s.levels[j][r-1<<j])
+}
@@ -0,0 +1,65 @@
+package rmq
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestSparseTableExample(t *testing.T) {
+	s := NewMin([]int{5, 2, 7, 1, 8, 3})
+	cases := []struct{ l, r, expected int }{
+		{0, 1, 5}, {0, 2, 2}, {2, 3, 7}, {2, 4, 1}, {4, 6, 3}, {0, 6, 1}, {4, 5, 8},
+	}
+	for _, c := range cases {
+		if v := s.Query(c.l, c.r); v != c.expected {
+			t.Errorf("min over [%d, %d) = %d, expected %d", c.l, c.r, v, c.expected)
+		}
+	}
+}
+
+func TestSparseTableRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	data := make([]int, 300)
+	for i := range data {
+		data[i] = r.Intn(1000) * 6
+	}
+	mins, maxs, gcds := NewMin(data), NewMax(data), NewSparseTable(data, GCD)
+	for l := 0; l != len(data); l++ {
+		lo, hi, g := data[l], data[l], data[l]
+		for rr := l + 1; rr <= len(data); rr++ {
+			x := data[rr-1]
+			if x < lo {
+				lo = x
+			}
+			if x > hi {
+				hi = x
+			}
+			g = GCD(g, x)
+			if mins.Query(l, rr) != lo || maxs.Query(l, rr) != hi || gcds.Query(l, rr) != g {
+				t.Fatalf("range [%d, %d)", l, rr)
+			}
+		}
+	}
+}
+
+func TestSparseTableFloats(t *testing.T) {
+	s := NewMax([]float64{0.5, -1, 2.5})
+	if s.Query(0, 2) != 0.5 || s.Query(0, 3) != 2.5 || s.Len() != 3 {
+		t.Fail()
+	}
+}
+
+func TestGCD(t *testing.T) {
+	if GCD(12, 18) != 6 || GCD(-4, 6) != 2 || GCD(0, 5) != 5 || GCD(0, 0) != 0 {
+		t.Fail()
+	}
+}
+
+func TestSparseTableInvalidRange(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("empty range did not panic")
+		}
+	}()
+	NewMin([]int{1, 2}).Query(1, 1)
+}
@@ -0,0 +1,242 @@
+/*
+ * Package router implements a radix tree that maps URL path patterns to
+ * values, as an HTTP router maps routes to handlers.
+ *
+ * A pattern is a path made of static text, named parameters and, at the end,
+ * a wildcard:
+ *
+ *    /users/:id/posts      ":id" matches one segment, up to the next "/"
+ *    /static/*file         "*file" matches the rest of the path
+ *
+ * Patterns share long prefixes, so they are stored in a radix tree: a trie in
+ * which every chain of nodes with a single child is merged into one node
+ * holding the whole string. The static text of each pattern is a path
+ * through the tree, and parameters and wildcards are special children:
+ *
+ *    /
+ *    ├── users/
+ *    │   ├── :id            /users/:id
+ *    │   │   └── /posts     /users/:id/posts
+ *    │   └── new            /users/new
+ *    └── static/
+ *        └── *file          /static/*file
+ *
+ * A node's static children begin with different bytes, so matching a path
+ * compares each byte of the path at most once on the way down. Where several
+ * children could match, static text is tried first, then a parameter, then a
+ * wildcard, backtracking if a choice leads nowhere: "/users/new" matches the
+ * static route even though ":id" could match "new" too.
+ *
+ * The nodes are kept in one slice (an "arena") and refer to each other by
+ * position rather than by pointer, which keeps the tree compact, and means
+ * the garbage collector has only the slice to scan, however many routes
+ * there are.
+ */
+
+package router
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrInvalidPattern = errors.New("invalid pattern")
+	ErrConflict       = errors.New("conflicting pattern")
+)
+
+const (
+	static = iota
+	param
+	wildcard
+)
+
+type node struct {
+	kind     uint8
+	text     string  // the static text, or the name of a parameter or wildcard
+	indices  string  // the first byte of each static child
+	children []int32 // static children, in the order of indices
+	param    int32   // the parameter child, or 0 for none
+	wildcard int32   // the wildcard child, or 0 for none
+	value    int32   // the position of the node's value + 1, or 0 for none
+}
+
+// Param is a parameter captured by a match
+type Param struct {
+	Key, Value string
+}
+
+// Params are the parameters captured by a match, in the order they appear in
+// the pattern
+type Params []Param
+
+// Get returns the value of the parameter named *key*, or "" if there is none
+func (ps Params) Get(key string) string {
+	for _, p := range ps {
+		if p.Key == key {
+			return p.Value
+		}
+	}
+	return ""
+}
+
+// Map maps path patterns to values of type V
+type Map[V any] struct {
+	nodes  []node // the root is nodes[0]
+	values []V
+}
+
+// New creates an empty Map
+func New[V any]() *Map[V] {
+	return &Map[V]{nodes: []node{{}}}
+}
+
+// Len returns the number of patterns in the map
+func (m *Map[V]) Len() int {
+	return len(m.values)
+}
+
+// add appends a node to the arena and returns its position
+func (m *Map[V]) add(n node) int32 {
+	m.nodes = append(m.nodes, n)
+	return int32(len(m.nodes) - 1)
+}
+
+// Insert maps *pattern* to *value*. It returns an error wrapping
+// ErrInvalidPattern if a parameter or wildcard has no name or does not start
+// a segment, or a wildcard is not last, and one wrapping ErrConflict if the
+// pattern is already present, or names a parameter or wildcard differently
+// from a pattern that shares its position.
+func (m *Map[V]) Insert(pattern string, value V) error {
+	i := int32(0)
+	for rest := pattern; rest != ""; {
+		switch rest[0] {
+		case ':', '*':
+			if len(rest) != len(pattern) && pattern[len(pattern)-len(rest)-1] != '/' {
+				return fmt.Errorf("%w: %q: %c not at the start of a segment", ErrInvalidPattern, pattern, rest[0])
+			}
+			end := strings.IndexByte(rest, '/')
+			if end == -1 {
+				end = len(rest)
+			}
+			kind := uint8(param)
+			if rest[0] == '*' {
+				if end != len(rest) {
+					return fmt.Errorf("%w: %q: wildcard not at the end", ErrInvalidPattern, pattern)
+				}
+				kind = wildcard
+			}
+			name := rest[1:end]
+			if name == "" || strings.ContainsAny(name, ":*") {
+				return fmt.Errorf("%w: %q: bad name %q", ErrInvalidPattern, pattern, name)
+			}
+			c := m.nodes[i].param
+			if kind == wildcard {
+				c = m.nodes[i].wildcard
+			}
+			if c == 0 {
+				c = m.add(node{kind: kind, text: name})
+				if kind == param {
+					m.nodes[i].param = c
+				} else {
+					m.nodes[i].wildcard = c
+				}
+			} else if m.nodes[c].text != name {
+				return fmt.Errorf("%w: %q: %q was named %q", ErrConflict, pattern, name, m.nodes[c].text)
+			}
+			i, rest = c, rest[end:]
+		default:
+			end := strings.IndexAny(rest, ":*")
+			if end == -1 {
+				end = len(rest)
+			}
+			i = m.insertStatic(i, rest[:end])
+			rest = rest[end:]
+		}
+	}
+	if m.nodes[i].value != 0 {
+		return fmt.Errorf("%w: %q already present", ErrConflict, pattern)
+	}
+	m.values = append(m.values, value)
+	m.nodes[i].value = int32(len(m.values))
+	return nil
+}
+
+// insertStatic adds the static text *s* below node *i*, splitting nodes where
+// it diverges from text already present, and returns the node it ends at
+func (m *Map[V]) insertStatic(i int32, s string) int32 {
+	for s != "" {
+		k := strings.IndexByte(m.nodes[i].indices, s[0])
+		if k == -1 {
+			c := m.add(node{text: s})
+			m.nodes[i].indices += s[:1]
+			m.nodes[i].children = append(m.nodes[i].children, c)
+			return c
+		}
+		c := m.nodes[i].children[k]
+		text := m.nodes[c].text
+		common := 0
+		for common < len(text) && common < len(s) && text[common] == s[common] {
+			common++
+		}
+		if common < len(text) {
+			// Split c, so that its text up to the divergence is a node of its
+			// own, with the rest of c below it
+			mid := m.add(node{text: text[:common], indices: text[common : common+1], children: []int32{c}})
+			m.nodes[c].text = text[common:]
+			m.nodes[i].children[k] = mid
+			c = mid
+		}
+		i, s = c, s[common:]
+	}
+	return i
+}
+
+// Match returns the value of the pattern that matches *path*, and the
+// parameters it captures, or false if no pattern matches
+func (m *Map[V]) Match(path string) (V, Params, bool) {
+	var params Params
+	if i := m.match(0, path, &params); i != 0 {
+		return m.values[i-1], params, true
+	}
+	var none V
+	return none, nil, false
+}
+
+// match matches *path* below node *i*, whose own text has been matched, and
+// returns the position of the value found + 1, or 0
+func (m *Map[V]) match(i int32, path string, params *Params) int32 {
+	n := &m.nodes[i]
+	if path == "" && n.value != 0 {
+		return n.value
+	}
+	if path != "" {
+		if k := strings.IndexByte(n.indices, path[0]); k != -1 {
+			c := n.children[k]
+			if text := m.nodes[c].text; strings.HasPrefix(path, text) {
+				if v := m.match(c, path[len(text):], params); v != 0 {
+					return v
+				}
+			}
+		}
+	}
+	if n.param != 0 {
+		end := strings.IndexByte(path, '/')
+		if end == -1 {
+			end = len(path)
+		}
+		if end != 0 {
+			*params = append(*params, Param{m.nodes[n.param].text, path[:end]})
+			if v := m.match(n.param, path[end:], params); v != 0 {
+				return v
+			}
+			*params = (*params)[:len(*params)-1]
+		}
+	}
+	if n.wildcard != 0 {
+		*params = append(*params, Param{m.nodes[n.wildcard].text, path})
+		return m.nodes[n.wildcard].value
+	}
+	return 0
+}
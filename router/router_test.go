@@ -0,0 +1,93 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	m := New[string]()
+	for _, pattern := range []string{
+		"/", "/users/:id", "/users/:id/posts", "/users/new", "/static/*file",
+		"/users/:id/posts/:post", "/use", "/search/:query/*rest",
+	} {
+		if err := m.Insert(pattern, pattern); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if m.Len() != 8 {
+		t.Error(m.Len())
+	}
+	for _, c := range []struct{ path, pattern, params string }{
+		{"/", "/", "[]"},
+		{"/users/42", "/users/:id", "[{id 42}]"},
+		{"/users/new", "/users/new", "[]"},
+		{"/users/newer", "/users/:id", "[{id newer}]"},
+		{"/users/7/posts", "/users/:id/posts", "[{id 7}]"},
+		{"/users/7/posts/9", "/users/:id/posts/:post", "[{id 7} {post 9}]"},
+		{"/use", "/use", "[]"},
+		{"/static/css/site.css", "/static/*file", "[{file css/site.css}]"},
+		{"/static/", "/static/*file", "[{file }]"},
+		{"/search/go/a/b", "/search/:query/*rest", "[{query go} {rest a/b}]"},
+	} {
+		value, params, ok := m.Match(c.path)
+		if !ok || value != c.pattern || fmt.Sprint(params) != c.params {
+			t.Errorf("%s: %q %v %v", c.path, value, params, ok)
+		}
+	}
+	for _, path := range []string{"", "/users", "/users/", "/users/7/post", "/us", "/users/7/posts/", "/static"} {
+		if value, _, ok := m.Match(path); ok {
+			t.Errorf("%s matched %s", path, value)
+		}
+	}
+}
+
+func TestBacktracking(t *testing.T) {
+	// "/a/b/d" follows the static "/a/b" until it fails at "/c", and then
+	// matches ":x" instead
+	m := New[int]()
+	m.Insert("/a/b/c", 1)
+	m.Insert("/a/:x/d", 2)
+	if value, params, ok := m.Match("/a/b/d"); !ok || value != 2 || params.Get("x") != "b" {
+		t.Error(value, params, ok)
+	}
+	if value, params, ok := m.Match("/a/b/c"); !ok || value != 1 || len(params) != 0 {
+		t.Error(value, params, ok)
+	}
+}
+
+func TestInsertErrors(t *testing.T) {
+	m := New[int]()
+	m.Insert("/users/:id", 1)
+	for _, c := range []struct {
+		pattern string
+		err     error
+	}{
+		{"/users/:id", ErrConflict},
+		{"/users/:name/x", ErrConflict},
+		{"/users/x:id", ErrInvalidPattern},
+		{"/files/*path/x", ErrInvalidPattern},
+		{"/files/:", ErrInvalidPattern},
+		{"/files/*", ErrInvalidPattern},
+	} {
+		if err := m.Insert(c.pattern, 0); !errors.Is(err, c.err) {
+			t.Error(c.pattern, err)
+		}
+	}
+	if m.Len() != 1 {
+		t.Error(m.Len())
+	}
+}
+
+func BenchmarkMatch(b *testing.B) {
+	m := New[int]()
+	for i := 0; i != 100; i++ {
+		m.Insert(fmt.Sprintf("/api/v1/resource%d/:id", i), i)
+		m.Insert(fmt.Sprintf("/api/v1/resource%d/:id/items/:item", i), i)
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		m.Match("/api/v1/resource57/1234/items/99")
+	}
+}
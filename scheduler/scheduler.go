@@ -0,0 +1,194 @@
+/*
+ * Package scheduler runs functions at given times, using a heap as a priority
+ * queue.
+ *
+ * Pending tasks are kept in a max-heap (see package heap) keyed on the
+ * negated time at which they are due, so the root of the heap is always the
+ * next task to run. The heap is a heap.Stable, which breaks ties in insertion
+ * order, so tasks due at the same instant run in the order they were
+ * scheduled. Scheduling a task and running the next one both cost O(log n),
+ * and finding when the next task is due is O(1), so a loop can sleep until
+ * exactly that moment rather than polling.
+ *
+ * The heap stores float64 values, which cannot represent nanosecond Unix
+ * timestamps exactly. Times are therefore stored relative to the moment the
+ * Scheduler was created, which is exact for schedules spanning up to about
+ * 100 days.
+ *
+ * Removing an arbitrary element from a binary heap requires knowing its
+ * position, which changes as the heap is rearranged. Instead, Cancel only
+ * forgets the task's function, and the entry is discarded when it reaches
+ * the root ("lazy deletion"). If the heap fills up with cancelled entries,
+ * it is rebuilt from the live ones.
+ */
+
+package scheduler
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/njwilson23/datastructures/heap"
+)
+
+var ErrFull = errors.New("scheduler is at capacity")
+
+// Handle identifies a scheduled task, so that it can be cancelled
+type Handle int
+
+// Scheduler holds functions waiting to run. It is safe for concurrent use.
+type Scheduler struct {
+	mu    sync.Mutex
+	epoch time.Time
+	queue *heap.Stable
+	tasks map[Handle]func()
+	next  Handle
+	wake  chan struct{}
+}
+
+// New creates a Scheduler that can hold up to *capacity* pending tasks
+func New(capacity int) *Scheduler {
+	return &Scheduler{
+		epoch: time.Now(),
+		queue: heap.NewStable(capacity),
+		tasks: make(map[Handle]func()),
+		wake:  make(chan struct{}, 1),
+	}
+}
+
+// priority converts a time to a heap value, so that earlier times have higher
+// priority
+func (s *Scheduler) priority(at time.Time) float64 {
+	return -float64(at.Sub(s.epoch))
+}
+
+func (s *Scheduler) time(priority float64) time.Time {
+	return s.epoch.Add(time.Duration(-priority))
+}
+
+// Schedule arranges for *fn* to be run at or soon after *at*. It returns
+// ErrFull if the scheduler is at capacity.
+func (s *Scheduler) Schedule(at time.Time, fn func()) (Handle, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.queue.Len() == s.queue.Cap() {
+		s.compact()
+	}
+	h := s.next
+	if err := s.queue.Insert(int(h), s.priority(at)); err != nil {
+		return 0, ErrFull
+	}
+	s.next++
+	s.tasks[h] = fn
+
+	// Wake Run, in case this task is due before the one it is waiting for
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return h, nil
+}
+
+// Cancel prevents a task from running, and returns false if it has already
+// run or been cancelled
+func (s *Scheduler) Cancel(h Handle) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.tasks[h]; !ok {
+		return false
+	}
+	delete(s.tasks, h)
+	return true
+}
+
+// Len returns the number of tasks waiting to run
+func (s *Scheduler) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.tasks)
+}
+
+// compact rebuilds the heap without the entries of cancelled tasks
+func (s *Scheduler) compact() {
+	s.queue.Filter(func(label int) bool {
+		_, ok := s.tasks[Handle(label)]
+		return ok
+	})
+}
+
+// pop removes and returns the next task due at or before *now*, discarding
+// cancelled entries on the way
+func (s *Scheduler) pop(now time.Time) (func(), bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for {
+		label, priority, err := s.queue.Maximum()
+		if err != nil || s.time(priority.Value).After(now) {
+			return nil, false
+		}
+		s.queue.ExtractMaximum()
+		if fn, ok := s.tasks[Handle(label)]; ok {
+			delete(s.tasks, Handle(label))
+			return fn, true
+		}
+	}
+}
+
+// Next returns the time at which the next task is due, or false if no tasks
+// are waiting
+func (s *Scheduler) Next() (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for {
+		label, priority, err := s.queue.Maximum()
+		if err != nil {
+			return time.Time{}, false
+		}
+		if _, ok := s.tasks[Handle(label)]; ok {
+			return s.time(priority.Value), true
+		}
+		s.queue.ExtractMaximum()
+	}
+}
+
+// Tick runs every task due at or before *now*, in order, and returns the
+// number of tasks run. Tasks are run without holding the scheduler's lock, so
+// they may schedule or cancel other tasks.
+func (s *Scheduler) Tick(now time.Time) int {
+	n := 0
+	for fn, ok := s.pop(now); ok; fn, ok = s.pop(now) {
+		fn()
+		n++
+	}
+	return n
+}
+
+// Run runs tasks as they come due, sleeping in between, until *stop* is
+// closed
+func (s *Scheduler) Run(stop <-chan struct{}) {
+	timer := time.NewTimer(time.Hour)
+	timer.Stop()
+	for {
+		s.Tick(time.Now())
+
+		// Sleep until the next task is due, or until a new task is scheduled
+		var wait <-chan time.Time
+		if at, ok := s.Next(); ok {
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+			timer.Reset(time.Until(at))
+			wait = timer.C
+		}
+		select {
+		case <-stop:
+			return
+		case <-s.wake:
+		case <-wait:
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTick(t *testing.T) {
+	s := New(10)
+	start := time.Now()
+	var order []int
+	for _, i := range []int{3, 1, 4, 2, 5} {
+		i := i
+		s.Schedule(start.Add(time.Duration(i)*time.Second), func() { order = append(order, i) })
+	}
+
+	if n := s.Tick(start); n != 0 {
+		t.Error(n)
+	}
+	if n := s.Tick(start.Add(3 * time.Second)); n != 3 {
+		t.Error(n)
+	}
+	if at, ok := s.Next(); !ok || !at.Equal(start.Add(4*time.Second)) {
+		t.Error(at)
+	}
+	s.Tick(start.Add(time.Minute))
+	for i, v := range order {
+		if v != i+1 {
+			t.Fatal(order)
+		}
+	}
+	if len(order) != 5 || s.Len() != 0 {
+		t.Error(order)
+	}
+	if _, ok := s.Next(); ok {
+		t.Fail()
+	}
+}
+
+func TestSameTimeFIFO(t *testing.T) {
+	s := New(8)
+	at := time.Now()
+	var order []int
+	handles := make([]Handle, 8)
+	for i := range handles {
+		i := i
+		handles[i], _ = s.Schedule(at, func() { order = append(order, i) })
+	}
+	// Cancelling makes room, and the next Schedule compacts the heap
+	s.Cancel(handles[2])
+	s.Cancel(handles[5])
+	s.Schedule(at, func() { order = append(order, 8) })
+
+	s.Tick(at)
+	expected := []int{0, 1, 3, 4, 6, 7, 8}
+	if len(order) != len(expected) {
+		t.Fatal(order)
+	}
+	for i := range order {
+		if order[i] != expected[i] {
+			t.Fatal(order)
+		}
+	}
+}
+
+func TestCancel(t *testing.T) {
+	s := New(10)
+	start := time.Now()
+	ran := make(map[int]bool)
+	var handles []Handle
+	for i := 0; i != 5; i++ {
+		i := i
+		h, _ := s.Schedule(start.Add(time.Duration(i)*time.Second), func() { ran[i] = true })
+		handles = append(handles, h)
+	}
+	if !s.Cancel(handles[0]) || !s.Cancel(handles[3]) {
+		t.Fail()
+	}
+	if s.Cancel(handles[3]) {
+		t.Error("cancelled twice")
+	}
+	if at, ok := s.Next(); !ok || !at.Equal(start.Add(time.Second)) {
+		t.Error(at)
+	}
+	if n := s.Tick(start.Add(time.Minute)); n != 3 {
+		t.Error(n)
+	}
+	if ran[0] || ran[3] || !ran[4] {
+		t.Error(ran)
+	}
+	if s.Cancel(handles[4]) {
+		t.Error("cancelled after running")
+	}
+}
+
+func TestCapacity(t *testing.T) {
+	s := New(3)
+	at := time.Now()
+	var handles []Handle
+	for i := 0; i != 3; i++ {
+		h, err := s.Schedule(at, func() {})
+		if err != nil {
+			t.Fatal(err)
+		}
+		handles = append(handles, h)
+	}
+	if _, err := s.Schedule(at, func() {}); err != ErrFull {
+		t.Error(err)
+	}
+	// Cancelled entries make room once the heap is compacted
+	s.Cancel(handles[1])
+	if _, err := s.Schedule(at, func() {}); err != nil {
+		t.Error(err)
+	}
+	if n := s.Tick(at); n != 3 {
+		t.Error(n)
+	}
+}
+
+func TestTaskSchedulesTask(t *testing.T) {
+	s := New(10)
+	start := time.Now()
+	count := 0
+	var fn func()
+	fn = func() {
+		count++
+		if count < 3 {
+			s.Schedule(start, fn)
+		}
+	}
+	s.Schedule(start, fn)
+	if n := s.Tick(start); n != 3 || count != 3 {
+		t.Error(n, count)
+	}
+}
+
+func TestRun(t *testing.T) {
+	s := New(10)
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		s.Run(stop)
+		close(done)
+	}()
+
+	results := make(chan int, 2)
+	s.Schedule(time.Now().Add(20*time.Millisecond), func() { results <- 2 })
+	// Scheduled while Run is asleep, but due first
+	s.Schedule(time.Now().Add(5*time.Millisecond), func() { results <- 1 })
+
+	for expected := 1; expected <= 2; expected++ {
+		select {
+		case v := <-results:
+			if v != expected {
+				t.Errorf("task %d ran, expected %d", v, expected)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("task did not run")
+		}
+	}
+	close(stop)
+	<-done
+}
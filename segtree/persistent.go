@@ -0,0 +1,214 @@
+// Persistent segment trees
+//
+// A persistent data structure keeps every version of itself: an update makes
+// a new version, and the old ones can still be queried. A segment tree is
+// made persistent by path copying. An update changes only the nodes on the
+// path from the root to one leaf, so it copies those O(log n) nodes and
+// shares the rest with the previous version:
+//
+//	version 0        version 1 = Set(version 0, 2, x)
+//	    r0                r1
+//	   /  \              /  \
+//	  a    b            a    b'      (a, c shared)
+//	      / \               / \
+//	     c   d             c   d'
+//
+// Each version is identified by its number, and its root. The nodes of every
+// version are stored together in one array, and refer to their children by
+// position in it, so an update allocates only when the array grows.
+//
+// One use is answering queries about prefixes of an array after the fact.
+// OrderStatistics builds version i from version i-1 by counting the i-th
+// element of an array, so that the difference between versions r and l
+// counts the elements of [l, r), and the k-th smallest among them is found
+// by walking down both versions at once.
+
+package segtree
+
+import (
+	"cmp"
+	"sort"
+)
+
+type persistentNode[T any] struct {
+	value       T
+	left, right int // positions of the children, for nodes above the leaves
+}
+
+// Persistent is a segment tree in which every update makes a new version,
+// leaving the earlier versions unchanged and available to query
+type Persistent[T any] struct {
+	nodes    []persistentNode[T]
+	roots    []int // by version, or -1 for an empty tree
+	n        int
+	op       func(a, b T) T
+	identity T
+}
+
+// NewPersistent builds version 0 of a Persistent tree over *data* in O(n),
+// for the associative operation *op* whose identity element is *identity*
+func NewPersistent[T any](data []T, op func(a, b T) T, identity T) *Persistent[T] {
+	p := &Persistent[T]{n: len(data), op: op, identity: identity}
+	root := -1
+	if len(data) != 0 {
+		root = p.build(data)
+	}
+	p.roots = append(p.roots, root)
+	return p
+}
+
+func (p *Persistent[T]) build(data []T) int {
+	if len(data) == 1 {
+		p.nodes = append(p.nodes, persistentNode[T]{value: data[0]})
+		return len(p.nodes) - 1
+	}
+	mid := len(data) / 2
+	left, right := p.build(data[:mid]), p.build(data[mid:])
+	return p.add(left, right)
+}
+
+// add adds a node combining the nodes at *left* and *right*
+func (p *Persistent[T]) add(left, right int) int {
+	value := p.op(p.nodes[left].value, p.nodes[right].value)
+	p.nodes = append(p.nodes, persistentNode[T]{value, left, right})
+	return len(p.nodes) - 1
+}
+
+// Len returns the number of elements in each version
+func (p *Persistent[T]) Len() int {
+	return p.n
+}
+
+// Versions returns the number of versions, which are numbered from 0
+func (p *Persistent[T]) Versions() int {
+	return len(p.roots)
+}
+
+func (p *Persistent[T]) root(version, i int) int {
+	if version < 0 || version >= len(p.roots) {
+		panic("segtree: invalid version")
+	}
+	if i < 0 || i >= p.n {
+		panic("segtree: index out of range")
+	}
+	return p.roots[version]
+}
+
+// Get returns the element at position *i* in *version*
+func (p *Persistent[T]) Get(version, i int) T {
+	node, lo, hi := p.root(version, i), 0, p.n
+	for hi-lo > 1 {
+		if mid := (lo + hi) / 2; i < mid {
+			node, hi = p.nodes[node].left, mid
+		} else {
+			node, lo = p.nodes[node].right, mid
+		}
+	}
+	return p.nodes[node].value
+}
+
+// Set makes a new version, equal to *version* but with *value* at position
+// *i*, and returns its number, in O(log n) time and space
+func (p *Persistent[T]) Set(version, i int, value T) int {
+	root := p.set(p.root(version, i), 0, p.n, i, value)
+	p.roots = append(p.roots, root)
+	return len(p.roots) - 1
+}
+
+// set returns a copy of the subtree at *node*, covering [lo, hi), with
+// *value* at position *i*
+func (p *Persistent[T]) set(node, lo, hi, i int, value T) int {
+	if hi-lo == 1 {
+		p.nodes = append(p.nodes, persistentNode[T]{value: value})
+		return len(p.nodes) - 1
+	}
+	left, right := p.nodes[node].left, p.nodes[node].right
+	if mid := (lo + hi) / 2; i < mid {
+		left = p.set(left, lo, mid, i, value)
+	} else {
+		right = p.set(right, mid, hi, i, value)
+	}
+	return p.add(left, right)
+}
+
+// Query returns the elements of [*l*, *r*) in *version* combined in order,
+// or the identity if the range is empty, in O(log n). It panics unless
+// 0 <= l <= r <= Len().
+func (p *Persistent[T]) Query(version, l, r int) T {
+	if version < 0 || version >= len(p.roots) {
+		panic("segtree: invalid version")
+	}
+	if l < 0 || r > p.n || l > r {
+		panic("segtree: invalid range")
+	}
+	if l == r {
+		return p.identity
+	}
+	return p.query(p.roots[version], 0, p.n, l, r)
+}
+
+// query combines the elements of [l, r) within the subtree at *node*, which
+// covers [lo, hi) and overlaps [l, r)
+func (p *Persistent[T]) query(node, lo, hi, l, r int) T {
+	if l <= lo && hi <= r {
+		return p.nodes[node].value
+	}
+	mid := (lo + hi) / 2
+	switch {
+	case r <= mid:
+		return p.query(p.nodes[node].left, lo, mid, l, r)
+	case l >= mid:
+		return p.query(p.nodes[node].right, mid, hi, l, r)
+	}
+	return p.op(p.query(p.nodes[node].left, lo, mid, l, r), p.query(p.nodes[node].right, mid, hi, l, r))
+}
+
+// OrderStatistics finds the k-th smallest element of any range of an array
+type OrderStatistics[T cmp.Ordered] struct {
+	values []T              // the distinct values, sorted
+	counts *Persistent[int] // version i counts the values of data[:i]
+}
+
+// NewOrderStatistics prepares *data* for queries in O(n log n) time and space
+func NewOrderStatistics[T cmp.Ordered](data []T) *OrderStatistics[T] {
+	sorted := append([]T(nil), data...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	var values []T
+	for i, v := range sorted {
+		if i == 0 || v != sorted[i-1] {
+			values = append(values, v)
+		}
+	}
+	s := &OrderStatistics[T]{values: values}
+	s.counts = NewPersistent(make([]int, len(values)), func(a, b int) int { return a + b }, 0)
+	for i, v := range data {
+		rank := sort.Search(len(values), func(j int) bool { return values[j] >= v })
+		s.counts.Set(i, rank, s.counts.Get(i, rank)+1)
+	}
+	return s
+}
+
+// Kth returns the *k*-th smallest element of data[*l*:*r*], counting from 0,
+// in O(log n). It panics unless 0 <= k < r-l.
+func (s *OrderStatistics[T]) Kth(l, r, k int) T {
+	if l < 0 || r >= s.counts.Versions() || l > r {
+		panic("segtree: invalid range")
+	}
+	if k < 0 || k >= r-l {
+		panic("segtree: k out of range")
+	}
+	// The counts of data[l:r] are those of version r less those of version l
+	nodes := s.counts.nodes
+	a, b := s.counts.roots[l], s.counts.roots[r]
+	lo, hi := 0, len(s.values)
+	for hi-lo > 1 {
+		mid := (lo + hi) / 2
+		if left := nodes[nodes[b].left].value - nodes[nodes[a].left].value; k < left {
+			a, b, hi = nodes[a].left, nodes[b].left, mid
+		} else {
+			k -= left
+			a, b, lo = nodes[a].right, nodes[b].right, mid
+		}
+	}
+	return s.values[lo]
+}
@@ -0,0 +1,103 @@
+package segtree
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestPersistent(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, n := range []int{0, 1, 2, 7, 64, 100} {
+		data := make([]int, n)
+		for i := range data {
+			data[i] = r.Intn(100)
+		}
+		p := NewPersistent(data, add, 0)
+		history := [][]int{append([]int(nil), data...)}
+		for step := 0; step != 50 && n != 0; step++ {
+			version := r.Intn(p.Versions())
+			next := append([]int(nil), history[version]...)
+			i, v := r.Intn(n), r.Intn(100)
+			next[i] = v
+			if p.Set(version, i, v) != len(history) {
+				t.Fatal("wrong version number")
+			}
+			history = append(history, next)
+		}
+		// Every version keeps its own values
+		for version, values := range history {
+			for i, v := range values {
+				if p.Get(version, i) != v {
+					t.Fatal(n, version, i)
+				}
+			}
+			for l := 0; l <= n; l++ {
+				rr := l + r.Intn(n+1-l)
+				sum := 0
+				for _, v := range values[l:rr] {
+					sum += v
+				}
+				if q := p.Query(version, l, rr); q != sum {
+					t.Fatal(n, version, l, rr, q, sum)
+				}
+			}
+		}
+		if p.Len() != n {
+			t.Error(p.Len())
+		}
+	}
+}
+
+func TestPersistentInvalidVersion(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("no panic")
+		}
+	}()
+	NewPersistent([]int{1}, add, 0).Query(1, 0, 1)
+}
+
+func TestOrderStatistics(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	data := make([]int, 200)
+	for i := range data {
+		data[i] = r.Intn(50) - 25
+	}
+	s := NewOrderStatistics(data)
+	for step := 0; step != 1000; step++ {
+		l := r.Intn(len(data))
+		rr := l + 1 + r.Intn(len(data)-l)
+		sorted := append([]int(nil), data[l:rr]...)
+		sort.Ints(sorted)
+		k := r.Intn(len(sorted))
+		if kth := s.Kth(l, rr, k); kth != sorted[k] {
+			t.Fatal(l, rr, k, kth, sorted[k])
+		}
+	}
+	words := NewOrderStatistics([]string{"pear", "fig", "apple", "fig", "kiwi"})
+	if w := words.Kth(1, 5, 2); w != "fig" {
+		t.Error(w)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("no panic")
+		}
+	}()
+	s.Kth(3, 3, 0)
+}
+
+func BenchmarkOrderStatistics(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	data := make([]int, 1<<16)
+	for i := range data {
+		data[i] = r.Int()
+	}
+	s := NewOrderStatistics(data)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		l := r.Intn(len(data))
+		rr := l + 1 + r.Intn(len(data)-l)
+		s.Kth(l, rr, r.Intn(rr-l))
+	}
+}
@@ -0,0 +1,93 @@
+/*
+ * Package segtree implements a segment tree, which answers range queries
+ * over an array while its elements change.
+ *
+ * A SparseTable (see package rmq) answers queries in O(1), but must be
+ * rebuilt when the data changes, and only supports idempotent operations. A
+ * segment tree stores the answer for a hierarchy of ranges, each the union of
+ * two halves, so that any range is covered by O(log n) stored ranges, and
+ * changing one element changes only the O(log n) ranges containing it. The
+ * operation need only be associative, so sums work as well as minimums.
+ *
+ * The tree is stored in an array of 2n entries, with the data in the leaves
+ * at n..2n-1 and node i combining its children 2i and 2i+1, like a binary
+ * heap:
+ *
+ *    [1] = sum of all                        15
+ *    [2], [3]                           10          5
+ *    leaves [4..7] = data             3    7     1     4
+ *
+ * A query walks up from the two ends of the range at once, combining the
+ * nodes that lie entirely inside it, so it is O(log n). Results from the left
+ * end and the right end are kept separately and joined at the end, which
+ * keeps the operands in order, so the operation need not be commutative
+ * (matrix products and string concatenation work). The same walk works for
+ * any n, not just powers of two (Al.Cash, 2015).
+ *
+ * Persistent is a segment tree whose updates make new versions, leaving the
+ * old ones to be queried.
+ */
+
+package segtree
+
+// Tree answers queries combining the elements of any range of an array with
+// an associative operation, and allows the elements to change
+type Tree[T any] struct {
+	nodes    []T
+	n        int
+	op       func(a, b T) T
+	identity T
+}
+
+// New builds a Tree over *data* in O(n) for the associative operation *op*,
+// whose identity element is *identity* (for example, 0 for sums)
+func New[T any](data []T, op func(a, b T) T, identity T) *Tree[T] {
+	n := len(data)
+	t := &Tree[T]{nodes: make([]T, 2*n), n: n, op: op, identity: identity}
+	copy(t.nodes[n:], data)
+	for i := n - 1; i > 0; i-- {
+		t.nodes[i] = op(t.nodes[2*i], t.nodes[2*i+1])
+	}
+	return t
+}
+
+// Len returns the number of elements
+func (t *Tree[T]) Len() int {
+	return t.n
+}
+
+// Get returns the element at position *i*
+func (t *Tree[T]) Get(i int) T {
+	return t.nodes[t.n+i]
+}
+
+// Set changes the element at position *i* to *value*, in O(log n)
+func (t *Tree[T]) Set(i int, value T) {
+	i += t.n
+	t.nodes[i] = value
+	for i /= 2; i > 0; i /= 2 {
+		t.nodes[i] = t.op(t.nodes[2*i], t.nodes[2*i+1])
+	}
+}
+
+// Query returns the elements of [*l*, *r*) combined in order, or the
+// identity if the range is empty. It panics unless 0 <= l <= r <= Len().
+func (t *Tree[T]) Query(l, r int) T {
+	if l < 0 || r > t.n || l > r {
+		panic("segtree: invalid range")
+	}
+	left, right := t.identity, t.identity
+	for l, r = l+t.n, r+t.n; l < r; l, r = l/2, r/2 {
+		// A left end that is a right child, or a right end after a left
+		// child, is not covered by its parent
+		if l%2 == 1 {
+			left = t.op(left, t.nodes[l])
+			l++
+		}
+		if r%2 == 1 {
+			r--
+			right = t.op(t.nodes[r], right)
+		}
+	}
+	return t.op(left, right)
+}
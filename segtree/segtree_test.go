@@ -0,0 +1,75 @@
+package segtree
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func add(a, b int) int { return a + b }
+
+func TestSum(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for n := 0; n != 40; n++ {
+		data := make([]int, n)
+		for i := range data {
+			data[i] = r.Intn(100)
+		}
+		tree := New(data, add, 0)
+		for step := 0; step != 100; step++ {
+			if n != 0 && r.Intn(2) == 0 {
+				i, v := r.Intn(n), r.Intn(100)
+				data[i] = v
+				tree.Set(i, v)
+			}
+			l := r.Intn(n + 1)
+			rr := l + r.Intn(n+1-l)
+			sum := 0
+			for _, v := range data[l:rr] {
+				sum += v
+			}
+			if q := tree.Query(l, rr); q != sum {
+				t.Fatal(n, l, rr, q, sum)
+			}
+		}
+		if tree.Len() != n {
+			t.Error(tree.Len())
+		}
+	}
+}
+
+func TestNonCommutative(t *testing.T) {
+	data := strings.Split("abcdefghijk", "")
+	tree := New(data, func(a, b string) string { return a + b }, "")
+	for l := 0; l <= len(data); l++ {
+		for r := l; r <= len(data); r++ {
+			if q := tree.Query(l, r); q != strings.Join(data[l:r], "") {
+				t.Fatal(l, r, q)
+			}
+		}
+	}
+	tree.Set(3, "X")
+	if q := tree.Query(2, 5); q != "cXe" || tree.Get(3) != "X" {
+		t.Error(q)
+	}
+}
+
+func TestInvalidRange(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("no panic")
+		}
+	}()
+	New([]int{1, 2}, add, 0).Query(1, 3)
+}
+
+func BenchmarkSegmentTree(b *testing.B) {
+	const n = 1 << 16
+	r := rand.New(rand.NewSource(1))
+	tree := New(make([]int, n), add, 0)
+	for i := 0; i < b.N; i++ {
+		tree.Set(r.Intn(n), i)
+		l := r.Intn(n)
+		tree.Query(l, l+r.Intn(n-l))
+	}
+}
@@ -0,0 +1,139 @@
+/*
+ * Package simhash computes SimHash fingerprints of documents, and indexes them
+ * to find near-duplicates.
+ *
+ * A SimHash (Charikar, 2002) is a 64-bit fingerprint in which similar
+ * documents get similar bit patterns, unlike an ordinary hash, where a
+ * one-word change flips half the bits. Each token of the document is hashed,
+ * and every bit position keeps a tally: +w if the token's hash has a 1
+ * there, and -w if it has a 0, where w is the token's weight. The
+ * fingerprint has a 1 wherever the tally is positive. A small edit changes
+ * only a few tallies, and flips only the bits whose tallies were close to
+ * zero, so the Hamming distance between fingerprints tracks the cosine
+ * distance between the documents' token weights.
+ *
+ * Where MinHash (see package minhash) estimates the overlap of two sets, a
+ * SimHash is a single word per document, and suits web-scale deduplication,
+ * where near-duplicates are those within a few bits (Manku et al., 2007,
+ * used 3 of 64).
+ *
+ * To find every stored fingerprint within distance k of a query, Index
+ * splits the 64 bits into k+1 blocks. If two fingerprints differ in at most
+ * k bits, then by the pigeonhole principle at least one block has no
+ * differing bits. Index keeps a map per block from the block's value to the
+ * fingerprints that have it, and checks the full distance only for
+ * fingerprints that match the query exactly on some block.
+ */
+
+package simhash
+
+import (
+	"math/bits"
+
+	"github.com/njwilson23/datastructures/hashing"
+)
+
+// Fingerprint returns the SimHash of a document with the given tokens, each
+// with weight 1. Repeated tokens count as many times as they appear.
+func Fingerprint(tokens []string) uint64 {
+	var tally [64]float64
+	for _, token := range tokens {
+		add(&tally, hashing.String(token), 1)
+	}
+	return fold(&tally)
+}
+
+// Weighted returns the SimHash of a document made up of the tokens in
+// *weights*, such as term frequencies or TF-IDF scores
+func Weighted(weights map[string]float64) uint64 {
+	var tally [64]float64
+	for token, w := range weights {
+		add(&tally, hashing.String(token), w)
+	}
+	return fold(&tally)
+}
+
+func add(tally *[64]float64, h uint64, w float64) {
+	for i := range tally {
+		if h&(1<<i) != 0 {
+			tally[i] += w
+		} else {
+			tally[i] -= w
+		}
+	}
+}
+
+func fold(tally *[64]float64) uint64 {
+	var fp uint64
+	for i, t := range tally {
+		if t > 0 {
+			fp |= 1 << i
+		}
+	}
+	return fp
+}
+
+// Distance returns the number of bits in which two fingerprints differ
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+type entry struct {
+	id int
+	fp uint64
+}
+
+// Index finds stored fingerprints within a fixed distance of a query
+type Index struct {
+	k      int
+	blocks []uint64 // mask of each block
+	tables []map[uint64][]entry
+	len    int
+}
+
+// NewIndex creates an empty Index for finding fingerprints that differ in at
+// most *k* bits. Queries are fastest for small k, since the blocks are then
+// long and few fingerprints match a block by chance.
+func NewIndex(k int) *Index {
+	if k < 0 || k > 63 {
+		panic("simhash: distance must be between 0 and 63")
+	}
+	idx := &Index{k: k}
+	for b := 0; b <= k; b++ {
+		// Spread the 64 bits over k+1 blocks as evenly as possible
+		lo, hi := b*64/(k+1), (b+1)*64/(k+1)
+		mask := ^uint64(0) >> (64 - (hi - lo)) << lo
+		idx.blocks = append(idx.blocks, mask)
+		idx.tables = append(idx.tables, make(map[uint64][]entry))
+	}
+	return idx
+}
+
+// Len returns the number of fingerprints in the index
+func (idx *Index) Len() int {
+	return idx.len
+}
+
+// Add adds the fingerprint of the document identified by *id*
+func (idx *Index) Add(id int, fp uint64) {
+	for b, mask := range idx.blocks {
+		idx.tables[b][fp&mask] = append(idx.tables[b][fp&mask], entry{id, fp})
+	}
+	idx.len++
+}
+
+// Near returns the IDs of the documents whose fingerprints differ from *fp*
+// in at most k bits, each once
+func (idx *Index) Near(fp uint64) []int {
+	var ids []int
+	seen := make(map[int]bool)
+	for b, mask := range idx.blocks {
+		for _, e := range idx.tables[b][fp&mask] {
+			if !seen[e.id] && Distance(fp, e.fp) <= idx.k {
+				seen[e.id] = true
+				ids = append(ids, e.id)
+			}
+		}
+	}
+	return ids
+}
@@ -0,0 +1,92 @@
+package simhash
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func words(r *rand.Rand, n int) []string {
+	w := make([]string, n)
+	for i := range w {
+		w[i] = fmt.Sprint("w", r.Intn(5000))
+	}
+	return w
+}
+
+func TestFingerprint(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	doc := words(r, 500)
+	edited := append([]string{}, doc...)
+	for i := 0; i != 10; i++ {
+		edited[r.Intn(len(edited))] = "edit"
+	}
+	other := words(r, 500)
+
+	a, b, c := Fingerprint(doc), Fingerprint(edited), Fingerprint(other)
+	if d := Distance(a, b); d > 8 {
+		t.Error("edited document at distance", d)
+	}
+	if d := Distance(a, c); d < 16 {
+		t.Error("unrelated document at distance", d)
+	}
+	if Fingerprint(strings.Fields("a b c")) != Fingerprint(strings.Fields("c a b")) {
+		t.Error("token order changed the fingerprint")
+	}
+}
+
+func TestWeighted(t *testing.T) {
+	if Weighted(map[string]float64{"a": 2, "b": 1}) != Fingerprint([]string{"a", "a", "b"}) {
+		t.Error("weights differ from repeated tokens")
+	}
+	if Weighted(map[string]float64{"x": 1}) == Weighted(map[string]float64{"x": -1}) {
+		t.Error("negative weight has no effect")
+	}
+}
+
+func TestIndex(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	fps := make([]uint64, 5000)
+	for _, k := range []int{0, 3, 7} {
+		idx := NewIndex(k)
+		for i := range fps {
+			fps[i] = r.Uint64()
+			idx.Add(i, fps[i])
+		}
+		if idx.Len() != len(fps) {
+			t.Error(idx.Len())
+		}
+		for q := 0; q != 50; q++ {
+			// Flip up to k+1 bits of a stored fingerprint
+			query := fps[r.Intn(len(fps))]
+			for _, bit := range r.Perm(64)[:r.Intn(k+2)] {
+				query ^= 1 << bit
+			}
+			var expected []int
+			for i, fp := range fps {
+				if Distance(fp, query) <= k {
+					expected = append(expected, i)
+				}
+			}
+			found := idx.Near(query)
+			sort.Ints(found)
+			if fmt.Sprint(found) != fmt.Sprint(expected) {
+				t.Fatalf("k=%d: found %v, expected %v", k, found, expected)
+			}
+		}
+	}
+}
+
+func BenchmarkNear(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	idx := NewIndex(3)
+	for i := 0; i != 100000; i++ {
+		idx.Add(i, r.Uint64())
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		idx.Near(r.Uint64())
+	}
+}
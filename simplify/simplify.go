@@ -0,0 +1,174 @@
+/*
+ * Package simplify reduces the number of points in a polyline, such as a GPS
+ * track or a coastline, while keeping its shape.
+ *
+ * Two classic algorithms are provided. Both keep the first and last points,
+ * and both work in any number of dimensions, using the point type of package
+ * kdtree.
+ *
+ * Douglas-Peucker (1973) works top-down. It starts with the single segment
+ * from the first point to the last, and finds the point furthest from it. If
+ * that point is within the tolerance, every point in between can be dropped;
+ * otherwise it is kept, and the two halves are simplified in turn. This
+ * bounds the distance between the original and simplified lines, and usually
+ * costs O(n log n), although a line that splits unevenly every time costs
+ * O(n^2).
+ *
+ * Visvalingam-Whyatt (1993) works bottom-up. The "effective area" of a point
+ * is the area of the triangle it forms with its two neighbours, which
+ * measures how much the line would change without it. The point with the
+ * smallest area is removed repeatedly, and the areas of its neighbours are
+ * recomputed, until every remaining point is significant enough. The points
+ * are kept in an indexed min-heap (see heap.Indexed), so that a neighbour's
+ * area can be updated in place, and the whole process costs O(n log n). It
+ * tends to remove small wiggles more evenly than Douglas-Peucker, which can
+ * leave spikes.
+ */
+
+package simplify
+
+import (
+	"math"
+
+	"github.com/njwilson23/datastructures/heap"
+	"github.com/njwilson23/datastructures/kdtree"
+)
+
+// segmentDistance returns the distance from *p* to the segment from *a* to
+// *b*
+func segmentDistance(p, a, b kdtree.Point) float64 {
+	// Project p onto the line through a and b, and clamp to the segment
+	ab, ap := 0.0, 0.0
+	for i := range p {
+		ab += (b[i] - a[i]) * (b[i] - a[i])
+		ap += (p[i] - a[i]) * (b[i] - a[i])
+	}
+	t := 0.0
+	if ab > 0 {
+		t = math.Max(0, math.Min(1, ap/ab))
+	}
+	d := 0.0
+	for i := range p {
+		x := p[i] - (a[i] + t*(b[i]-a[i]))
+		d += x * x
+	}
+	return math.Sqrt(d)
+}
+
+// triangleArea returns the area of the triangle *abc*
+func triangleArea(a, b, c kdtree.Point) float64 {
+	// By Lagrange's identity, |u x v|^2 = |u|^2 |v|^2 - (u.v)^2 in any number
+	// of dimensions
+	uu, vv, uv := 0.0, 0.0, 0.0
+	for i := range a {
+		u, v := b[i]-a[i], c[i]-a[i]
+		uu += u * u
+		vv += v * v
+		uv += u * v
+	}
+	return math.Sqrt(math.Max(0, uu*vv-uv*uv)) / 2
+}
+
+// DouglasPeucker simplifies a polyline so that no removed point is further
+// than *tolerance* from the simplified line
+func DouglasPeucker(points []kdtree.Point, tolerance float64) []kdtree.Point {
+	if len(points) < 3 {
+		return append([]kdtree.Point{}, points...)
+	}
+	keep := make([]bool, len(points))
+	keep[0], keep[len(points)-1] = true, true
+
+	// Segments still to be checked are kept on a stack rather than by
+	// recursion, since a line that splits unevenly recurses O(n) deep
+	type segment struct{ first, last int }
+	stack := []segment{{0, len(points) - 1}}
+	for len(stack) != 0 {
+		s := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		furthest, dist := -1, tolerance
+		for i := s.first + 1; i < s.last; i++ {
+			if d := segmentDistance(points[i], points[s.first], points[s.last]); d > dist {
+				furthest, dist = i, d
+			}
+		}
+		if furthest != -1 {
+			keep[furthest] = true
+			stack = append(stack, segment{s.first, furthest}, segment{furthest, s.last})
+		}
+	}
+
+	var simplified []kdtree.Point
+	for i, p := range points {
+		if keep[i] {
+			simplified = append(simplified, p)
+		}
+	}
+	return simplified
+}
+
+// Visvalingam simplifies a polyline by removing points whose effective area
+// is less than *minArea*
+func Visvalingam(points []kdtree.Point, minArea float64) []kdtree.Point {
+	return visvalingam(points, func(area float64, _ int) bool {
+		return area < minArea
+	})
+}
+
+// VisvalingamN simplifies a polyline to at most *n* points, but never fewer
+// than the first and last, by removing the least significant points
+func VisvalingamN(points []kdtree.Point, n int) []kdtree.Point {
+	return visvalingam(points, func(_ float64, remaining int) bool {
+		return remaining > n
+	})
+}
+
+// visvalingam removes the point with the smallest effective area as long as
+// *remove* returns true, given that area and the number of points remaining
+func visvalingam(points []kdtree.Point, remove func(area float64, remaining int) bool) []kdtree.Point {
+	if len(points) < 3 {
+		return append([]kdtree.Point{}, points...)
+	}
+	// The remaining points form a doubly linked list
+	prev := make([]int, len(points))
+	next := make([]int, len(points))
+	for i := range points {
+		prev[i], next[i] = i-1, i+1
+	}
+	areas := heap.NewIndexed[int]()
+	for i := 1; i < len(points)-1; i++ {
+		areas.Set(i, triangleArea(points[i-1], points[i], points[i+1]))
+	}
+
+	removed := make([]bool, len(points))
+	remaining := len(points)
+	for areas.Len() != 0 {
+		i, area, _ := areas.Minimum()
+		if !remove(area, remaining) {
+			break
+		}
+		areas.ExtractMinimum()
+		removed[i] = true
+		remaining--
+		p, n := prev[i], next[i]
+		next[p], prev[n] = n, p
+
+		// A neighbour's new area may be smaller than that of the point just
+		// removed. It is raised to match, so that removal order follows the
+		// areas, and points are not removed just because an earlier removal
+		// happened to flatten them.
+		for _, j := range []int{p, n} {
+			if areas.Contains(j) {
+				a := triangleArea(points[prev[j]], points[j], points[next[j]])
+				areas.Set(j, math.Max(a, area))
+			}
+		}
+	}
+
+	simplified := make([]kdtree.Point, 0, remaining)
+	for i, p := range points {
+		if !removed[i] {
+			simplified = append(simplified, p)
+		}
+	}
+	return simplified
+}
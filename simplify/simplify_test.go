@@ -0,0 +1,141 @@
+package simplify
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/njwilson23/datastructures/kdtree"
+)
+
+func pointsEqual(a, b []kdtree.Point) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		for j := range a[i] {
+			if a[i][j] != b[i][j] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// randomWalk returns a wiggly line of *n* points
+func randomWalk(r *rand.Rand, n int) []kdtree.Point {
+	points := make([]kdtree.Point, n)
+	x, y := 0.0, 0.0
+	for i := range points {
+		points[i] = kdtree.Point{x, y}
+		x += 1
+		y += r.NormFloat64()
+	}
+	return points
+}
+
+func TestGeometry(t *testing.T) {
+	if d := segmentDistance(kdtree.Point{1, 1}, kdtree.Point{0, 0}, kdtree.Point{2, 0}); d != 1 {
+		t.Error(d)
+	}
+	if d := segmentDistance(kdtree.Point{5, 4}, kdtree.Point{0, 0}, kdtree.Point{2, 0}); d != 5 {
+		t.Error("beyond the end", d)
+	}
+	if d := segmentDistance(kdtree.Point{3, 4}, kdtree.Point{0, 0}, kdtree.Point{0, 0}); d != 5 {
+		t.Error("empty segment", d)
+	}
+	if a := triangleArea(kdtree.Point{0, 0}, kdtree.Point{4, 0}, kdtree.Point{0, 3}); a != 6 {
+		t.Error(a)
+	}
+	if a := triangleArea(kdtree.Point{0, 0, 0}, kdtree.Point{0, 4, 0}, kdtree.Point{0, 0, 3}); a != 6 {
+		t.Error("3d", a)
+	}
+}
+
+func TestDouglasPeucker(t *testing.T) {
+	line := []kdtree.Point{{0, 0}, {1, 0.1}, {2, -0.1}, {3, 5}, {4, 6}, {5, 7}, {6, 8.1}, {7, 9}, {8, 9}, {9, 9}}
+	simplified := DouglasPeucker(line, 0.5)
+	expected := []kdtree.Point{{0, 0}, {2, -0.1}, {3, 5}, {7, 9}, {9, 9}}
+	if !pointsEqual(simplified, expected) {
+		t.Error(simplified)
+	}
+	if simplified := DouglasPeucker(line, 100); !pointsEqual(simplified, []kdtree.Point{{0, 0}, {9, 9}}) {
+		t.Error(simplified)
+	}
+	// A tolerance of zero removes only collinear points
+	simplified = DouglasPeucker(line, 0)
+	expected = []kdtree.Point{{0, 0}, {1, 0.1}, {2, -0.1}, {3, 5}, {5, 7}, {6, 8.1}, {7, 9}, {9, 9}}
+	if !pointsEqual(simplified, expected) {
+		t.Error(simplified)
+	}
+	if simplified := DouglasPeucker(line[:2], 1); !pointsEqual(simplified, line[:2]) {
+		t.Error(simplified)
+	}
+}
+
+func TestDouglasPeuckerTolerance(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	line := randomWalk(r, 1000)
+	simplified := DouglasPeucker(line, 2)
+	if len(simplified) >= len(line)/2 {
+		t.Error(len(simplified), "points remain")
+	}
+	// Every original point is within the tolerance of the simplified line
+	for _, p := range line {
+		best := math.Inf(1)
+		for i := 1; i != len(simplified); i++ {
+			best = math.Min(best, segmentDistance(p, simplified[i-1], simplified[i]))
+		}
+		if best > 2 {
+			t.Fatal(p, "is", best, "from the simplified line")
+		}
+	}
+}
+
+func TestVisvalingam(t *testing.T) {
+	line := []kdtree.Point{{0, 0}, {1, 0.1}, {2, 0}, {3, 3}, {4, 0}, {5, 0}}
+	simplified := Visvalingam(line, 0.5)
+	expected := []kdtree.Point{{0, 0}, {2, 0}, {3, 3}, {4, 0}, {5, 0}}
+	if !pointsEqual(simplified, expected) {
+		t.Error(simplified)
+	}
+	if simplified := VisvalingamN(line, 3); !pointsEqual(simplified, []kdtree.Point{{0, 0}, {3, 3}, {5, 0}}) {
+		t.Error(simplified)
+	}
+	if simplified := VisvalingamN(line, 0); len(simplified) != 2 {
+		t.Error(simplified)
+	}
+}
+
+func TestVisvalingamN(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	line := randomWalk(r, 1000)
+	for _, n := range []int{2, 10, 500, 1000, 2000} {
+		simplified := VisvalingamN(line, n)
+		expected := n
+		if n > len(line) {
+			expected = len(line)
+		}
+		if len(simplified) != expected {
+			t.Error(n, len(simplified))
+		}
+		if !pointsEqual(simplified[:1], line[:1]) || !pointsEqual(simplified[len(simplified)-1:], line[len(line)-1:]) {
+			t.Error("endpoints not kept")
+		}
+	}
+}
+
+func BenchmarkSimplify(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	line := randomWalk(r, 100000)
+	b.Run("DouglasPeucker", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			DouglasPeucker(line, 2)
+		}
+	})
+	b.Run("Visvalingam", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			Visvalingam(line, 2)
+		}
+	})
+}
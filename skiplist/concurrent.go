@@ -0,0 +1,548 @@
+package skiplist
+
+// A concurrent ordered map
+//
+// ConcurrentOrderedMap is a skip-list that can be read and written by many
+// goroutines at once, following the "lazy" skip-list of Herlihy, Lev,
+// Luchangco and Shavit (2007). Unlike Node above, every node holds a tower of
+// next pointers, one per level it appears on, so a node is a single
+// allocation and can be locked as a unit.
+//
+// Lookups take no locks at all: they follow next pointers, which are read and
+// written atomically, just as in a sequential skip-list. Writers lock only the
+// predecessors of the node they are changing, at each of its levels, so
+// writers to different parts of the map proceed in parallel. A writer
+// re-checks after locking that nothing changed between finding the
+// predecessors and locking them, and starts over if something did.
+//
+// Two flags on each node keep readers consistent without locks:
+//
+// - fullyLinked is set once a new node is linked in at every level. Until
+//   then, it is treated as absent.
+// - marked is set when a node is being removed, before it is unlinked. From
+//   then on, it is treated as absent, and writers will not link new nodes
+//   next to it.
+//
+// The API mirrors sync.Map, with int keys, plus RangeBetween to visit the keys
+// in an interval in order, which a hash-based map cannot do. Like the other
+// containers, the map reports its changes to subscribers (see package
+// observe), but here the callbacks are called by whichever goroutines write
+// to the map, possibly at the same time, so they must be safe for concurrent
+// use. Two writes to the same key may be reported in the opposite order to
+// the one in which they took effect.
+//
+// Snapshots
+//
+// Load, Store and the other single-key operations are linearizable: each
+// takes effect at one instant between its call and its return. Range and
+// RangeBetween see a snapshot of the map as it was when they were called,
+// however long the walk takes and whatever is written meanwhile: every write
+// that returned before the call is seen, and no write that began after it.
+// Writes made by *f* itself are not seen either.
+//
+// Snapshots are versioned. Every write takes the next version from a clock,
+// and stamps the new node, the new value, or the deletion with it. A snapshot
+// is the version of the last write when it began, and it sees a node if the
+// node was inserted at or before that version and not deleted by then, with
+// the newest value no later than it. To make the snapshot's version a
+// consistent cut, writers hold a read-write lock in shared mode from taking a
+// version until their write is visible, and beginning a snapshot holds it
+// exclusively for an instant, so that no write is half done.
+//
+// Values and nodes that a snapshot may need are kept for as long as it lasts:
+//
+// - A new value keeps a pointer to the value it replaced if an active
+//   snapshot is as new as that value, and the chain is cut beyond the value
+//   that the oldest snapshot sees. So a chain holds at most one value per
+//   active snapshot, besides the newest.
+// - A node deleted while an active snapshot includes it is put in a
+//   "graveyard", sorted by key, before it is unlinked. Walking from one node
+//   to the next, a snapshot visits the graveyard nodes with keys in between,
+//   or with the next node's key, that it should see. A key that is deleted
+//   and stored again has several nodes, whose lifetimes do not overlap, and
+//   the snapshot sees the one alive at its version. When the last snapshot
+//   that includes a node finishes, the node is dropped.
+//
+// So writes pay for snapshots only while one is active, and a long walk over
+// a map that is being rewritten holds on to the old contents it sees until it
+// ends, but not to the versions written and overwritten since.
+
+import (
+	"math"
+	"math/rand"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/njwilson23/datastructures/observe"
+)
+
+const (
+	maxLevel = 32
+	// Each node appears on the level above with probability 1/2
+	levelProbability = 0.5
+)
+
+type entry struct {
+	value   interface{}
+	version uint64
+	prev    atomic.Pointer[entry] // the value replaced, while a snapshot may need it
+}
+
+type cnode struct {
+	key         int
+	value       atomic.Pointer[entry]
+	next        []atomic.Pointer[cnode]
+	mu          sync.Mutex
+	marked      atomic.Bool
+	fullyLinked atomic.Bool
+	born        uint64        // the version of the insertion
+	deleted     atomic.Uint64 // the version of the deletion, or 0
+}
+
+// at returns the node's value in the snapshot at *version*, or false if the
+// node is not in it
+func (n *cnode) at(version uint64) (interface{}, bool) {
+	if d := n.deleted.Load(); n.born > version || d != 0 && d <= version {
+		return nil, false
+	}
+	for e := n.value.Load(); e != nil; e = e.prev.Load() {
+		if e.version <= version {
+			return e.value, true
+		}
+	}
+	return nil, false
+}
+
+// ConcurrentOrderedMap is a map with int keys that is safe for concurrent use
+// and can be walked in key order. The zero value is not usable; use
+// NewConcurrentOrderedMap.
+type ConcurrentOrderedMap struct {
+	head  *cnode
+	len   atomic.Int64
+	clock atomic.Uint64 // the version of the last write
+	gate  sync.RWMutex  // shared by writers, exclusive to begin a snapshot
+
+	mu        sync.Mutex     // guards snapshots and graveyard
+	snapshots map[uint64]int // the number of active snapshots at each version
+	oldest    atomic.Uint64  // the oldest active snapshot, or MaxUint64
+	newest    atomic.Uint64  // the newest active snapshot, or 0
+	graveyard []*cnode       // deleted nodes that snapshots include, by key
+	buried    atomic.Int64   // len(graveyard)
+
+	// The subscribers, which Subscribe changes under the write lock, and
+	// writers notify under the read lock
+	eventsMu sync.RWMutex
+	events   observe.Subject[int, interface{}]
+	watched  atomic.Bool // whether anything has subscribed
+}
+
+// NewConcurrentOrderedMap creates an empty ConcurrentOrderedMap
+func NewConcurrentOrderedMap() *ConcurrentOrderedMap {
+	head := &cnode{next: make([]atomic.Pointer[cnode], maxLevel)}
+	head.fullyLinked.Store(true)
+	m := &ConcurrentOrderedMap{head: head, snapshots: make(map[uint64]int)}
+	m.oldest.Store(math.MaxUint64)
+	return m
+}
+
+func randomLevels() int {
+	levels := 1
+	for levels < maxLevel && rand.Float64() < levelProbability {
+		levels++
+	}
+	return levels
+}
+
+// find fills *preds* and *succs* with the nodes either side of *key* at every
+// level, and returns the highest level at which a node with *key* was found,
+// or -1
+func (m *ConcurrentOrderedMap) find(key int, preds, succs *[maxLevel]*cnode) int {
+	found := -1
+	pred := m.head
+	for level := maxLevel - 1; level >= 0; level-- {
+		curr := pred.next[level].Load()
+		for curr != nil && curr.key < key {
+			pred = curr
+			curr = pred.next[level].Load()
+		}
+		if found == -1 && curr != nil && curr.key == key {
+			found = level
+		}
+		preds[level] = pred
+		succs[level] = curr
+	}
+	return found
+}
+
+// lockPreds locks the distinct predecessors on levels [0, levels), and
+// returns a function that unlocks them
+func lockPreds(preds *[maxLevel]*cnode, levels int) (unlock func()) {
+	var locked []*cnode
+	for level := 0; level != levels; level++ {
+		if level == 0 || preds[level] != preds[level-1] {
+			preds[level].mu.Lock()
+			locked = append(locked, preds[level])
+		}
+	}
+	return func() {
+		for _, n := range locked {
+			n.mu.Unlock()
+		}
+	}
+}
+
+// Load returns the value stored for *key*, or false if there is none
+func (m *ConcurrentOrderedMap) Load(key int) (value interface{}, ok bool) {
+	pred := m.head
+	for level := maxLevel - 1; level >= 0; level-- {
+		curr := pred.next[level].Load()
+		for curr != nil && curr.key < key {
+			pred = curr
+			curr = pred.next[level].Load()
+		}
+		if curr != nil && curr.key == key {
+			if !curr.fullyLinked.Load() || curr.marked.Load() {
+				return nil, false
+			}
+			return curr.value.Load().value, true
+		}
+	}
+	return nil, false
+}
+
+// Store sets the value for *key*
+func (m *ConcurrentOrderedMap) Store(key int, value interface{}) {
+	m.store(key, value, true)
+	m.notify(observe.Put, key, value)
+}
+
+// LoadOrStore returns the existing value for *key* if present. Otherwise, it
+// stores and returns *value*. The loaded result is true if the value was
+// loaded, false if stored.
+func (m *ConcurrentOrderedMap) LoadOrStore(key int, value interface{}) (actual interface{}, loaded bool) {
+	actual, loaded = m.store(key, value, false)
+	if !loaded {
+		m.notify(observe.Put, key, value)
+	}
+	return actual, loaded
+}
+
+// store inserts a node for *key*, or if one exists, replaces its value when
+// *replace* is true. It returns the previous value, and whether there was one.
+func (m *ConcurrentOrderedMap) store(key int, value interface{}, replace bool) (interface{}, bool) {
+	var preds, succs [maxLevel]*cnode
+	levels := randomLevels()
+	for {
+		if level := m.find(key, &preds, &succs); level != -1 {
+			n := succs[level]
+			if n.marked.Load() {
+				// The node is being removed, so try again once it is gone
+				runtime.Gosched()
+				continue
+			}
+			for !n.fullyLinked.Load() {
+				runtime.Gosched()
+			}
+			if replace {
+				return m.replace(n, value), true
+			}
+			return n.value.Load().value, true
+		}
+
+		unlock := lockPreds(&preds, levels)
+		valid := true
+		for level := 0; valid && level != levels; level++ {
+			pred, succ := preds[level], succs[level]
+			valid = !pred.marked.Load() && (succ == nil || !succ.marked.Load()) &&
+				pred.next[level].Load() == succ
+		}
+		if !valid {
+			unlock()
+			continue
+		}
+
+		m.gate.RLock()
+		n := &cnode{key: key, next: make([]atomic.Pointer[cnode], levels), born: m.clock.Add(1)}
+		n.value.Store(&entry{value: value, version: n.born})
+		for level := 0; level != levels; level++ {
+			n.next[level].Store(succs[level])
+		}
+		for level := 0; level != levels; level++ {
+			preds[level].next[level].Store(n)
+		}
+		n.fullyLinked.Store(true)
+		m.gate.RUnlock()
+		m.len.Add(1)
+		unlock()
+		return value, false
+	}
+}
+
+// replace gives *n* a new value, and returns the old one
+func (m *ConcurrentOrderedMap) replace(n *cnode, value interface{}) interface{} {
+	m.gate.RLock()
+	defer m.gate.RUnlock()
+	e := &entry{value: value, version: m.clock.Add(1)}
+	var old *entry
+	for {
+		old = n.value.Load()
+		if m.newest.Load() >= old.version {
+			e.prev.Store(old)
+		} else {
+			// No snapshot sees the old value
+			e.prev.Store(old.prev.Load())
+		}
+		if n.value.CompareAndSwap(old, e) {
+			break
+		}
+	}
+	// Older values are only needed by snapshots that are older still. No
+	// snapshot can begin until the gate is released, and one that does will
+	// see e or a newer value.
+	oldest := m.oldest.Load()
+	for c := e; c != nil; c = c.prev.Load() {
+		if c.version <= oldest {
+			c.prev.Store(nil)
+			break
+		}
+	}
+	return old.value
+}
+
+// Delete removes the value for *key*
+func (m *ConcurrentOrderedMap) Delete(key int) {
+	m.LoadAndDelete(key)
+}
+
+// LoadAndDelete removes the value for *key*, returning the previous value if
+// any. The loaded result reports whether the key was present.
+func (m *ConcurrentOrderedMap) LoadAndDelete(key int) (value interface{}, loaded bool) {
+	value, loaded = m.remove(key)
+	if loaded {
+		m.notify(observe.Delete, key, value)
+	}
+	return value, loaded
+}
+
+// remove removes the node for *key*, and returns its value and true, or
+// false if there is none
+func (m *ConcurrentOrderedMap) remove(key int) (interface{}, bool) {
+	var preds, succs [maxLevel]*cnode
+	var victim *cnode
+	for {
+		level := m.find(key, &preds, &succs)
+		if victim == nil {
+			// Only a node that is fully linked, and was found at its top
+			// level (so that every predecessor is known), can be removed
+			if level == -1 {
+				return nil, false
+			}
+			n := succs[level]
+			if !n.fullyLinked.Load() || len(n.next)-1 != level || n.marked.Load() {
+				return nil, false
+			}
+			n.mu.Lock()
+			if n.marked.Load() {
+				n.mu.Unlock()
+				return nil, false
+			}
+			n.marked.Store(true)
+			victim = n
+		}
+
+		levels := len(victim.next)
+		unlock := lockPreds(&preds, levels)
+		valid := true
+		for level := 0; valid && level != levels; level++ {
+			valid = !preds[level].marked.Load() && preds[level].next[level].Load() == victim
+		}
+		if !valid {
+			unlock()
+			continue
+		}
+
+		m.gate.RLock()
+		victim.deleted.Store(m.clock.Add(1))
+		if m.newest.Load() >= victim.born {
+			// A snapshot includes the node, and may reach it only once it
+			// is unlinked
+			m.bury(victim)
+		}
+		for level := levels - 1; level >= 0; level-- {
+			preds[level].next[level].Store(victim.next[level].Load())
+		}
+		m.gate.RUnlock()
+		m.len.Add(-1)
+		victim.mu.Unlock()
+		unlock()
+		return victim.value.Load().value, true
+	}
+}
+
+// Subscribe registers a callback that is called after every subsequent Store,
+// LoadOrStore that stores, and Delete or LoadAndDelete of a key that was
+// present, and returns a function that cancels the subscription
+func (m *ConcurrentOrderedMap) Subscribe(fn func(observe.Event[int, interface{}])) (unsubscribe func()) {
+	m.eventsMu.Lock()
+	defer m.eventsMu.Unlock()
+	m.watched.Store(true)
+	cancel := m.events.Subscribe(fn)
+	return func() {
+		m.eventsMu.Lock()
+		defer m.eventsMu.Unlock()
+		cancel()
+	}
+}
+
+// notify tells the subscribers, if there are any, of a change
+func (m *ConcurrentOrderedMap) notify(op observe.Op, key int, value interface{}) {
+	if !m.watched.Load() {
+		return
+	}
+	m.eventsMu.RLock()
+	defer m.eventsMu.RUnlock()
+	m.events.Notify(op, key, value)
+}
+
+// Len returns the number of keys in the map
+func (m *ConcurrentOrderedMap) Len() int {
+	return int(m.len.Load())
+}
+
+// Range calls *f* for every key and value in ascending key order, stopping if
+// *f* returns false. It sees a snapshot of the map as it was when Range was
+// called.
+func (m *ConcurrentOrderedMap) Range(f func(key int, value interface{}) bool) {
+	version := m.begin()
+	defer m.end(version)
+	m.walk(version, m.head.next[0].Load(), math.MinInt, nil, f)
+}
+
+// RangeBetween calls *f* for every key in [lo, hi) and its value, in
+// ascending key order, stopping if *f* returns false. It sees a snapshot of
+// the map as it was when RangeBetween was called.
+func (m *ConcurrentOrderedMap) RangeBetween(lo, hi int, f func(key int, value interface{}) bool) {
+	version := m.begin()
+	defer m.end(version)
+	pred := m.head
+	for level := maxLevel - 1; level >= 0; level-- {
+		for curr := pred.next[level].Load(); curr != nil && curr.key < lo; curr = pred.next[level].Load() {
+			pred = curr
+		}
+	}
+	m.walk(version, pred.next[0].Load(), lo, &hi, f)
+}
+
+// begin starts a snapshot, and returns its version
+func (m *ConcurrentOrderedMap) begin() uint64 {
+	m.gate.Lock()
+	defer m.gate.Unlock()
+	version := m.clock.Load()
+	m.mu.Lock()
+	m.snapshots[version]++
+	if version < m.oldest.Load() {
+		m.oldest.Store(version)
+	}
+	m.newest.Store(version)
+	m.mu.Unlock()
+	return version
+}
+
+// end finishes the snapshot at *version*, and drops the values and nodes
+// that no remaining snapshot needs
+func (m *ConcurrentOrderedMap) end(version uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.snapshots[version]--; m.snapshots[version] == 0 {
+		delete(m.snapshots, version)
+	}
+	versions := make([]uint64, 0, len(m.snapshots))
+	for v := range m.snapshots {
+		versions = append(versions, v)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+	if len(versions) == 0 {
+		m.oldest.Store(math.MaxUint64)
+		m.newest.Store(0)
+	} else {
+		m.oldest.Store(versions[0])
+		m.newest.Store(versions[len(versions)-1])
+	}
+	// A deleted node is needed by a snapshot that began after it was
+	// inserted and before it was deleted
+	kept := m.graveyard[:0]
+	for _, n := range m.graveyard {
+		i := sort.Search(len(versions), func(i int) bool { return versions[i] >= n.born })
+		if i != len(versions) && versions[i] < n.deleted.Load() {
+			kept = append(kept, n)
+		}
+	}
+	for i := len(kept); i != len(m.graveyard); i++ {
+		m.graveyard[i] = nil
+	}
+	m.graveyard = kept
+	m.buried.Store(int64(len(kept)))
+}
+
+// bury adds *n*, which is being deleted, to the graveyard
+func (m *ConcurrentOrderedMap) bury(n *cnode) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	i := sort.Search(len(m.graveyard), func(i int) bool { return m.graveyard[i].key > n.key })
+	m.graveyard = append(m.graveyard, nil)
+	copy(m.graveyard[i+1:], m.graveyard[i:])
+	m.graveyard[i] = n
+	m.buried.Add(1)
+}
+
+// exhume returns the nodes in the graveyard with keys from *lo* up to *hi*, if
+// it is non-nil. If *after* is true, *lo* itself is excluded, and if *through*
+// is true, *hi* itself is included.
+func (m *ConcurrentOrderedMap) exhume(lo int, after bool, hi *int, through bool) []*cnode {
+	if m.buried.Load() == 0 {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	i := sort.Search(len(m.graveyard), func(i int) bool {
+		return m.graveyard[i].key > lo || !after && m.graveyard[i].key == lo
+	})
+	var found []*cnode
+	for ; i < len(m.graveyard) && (hi == nil || m.graveyard[i].key < *hi || through && m.graveyard[i].key == *hi); i++ {
+		found = append(found, m.graveyard[i])
+	}
+	return found
+}
+
+// walk visits the nodes in the snapshot at *version*, from *lo* up to but not
+// including *hi* if it is non-nil, starting along the bottom level from *n*,
+// which is the first node at or after *lo*
+func (m *ConcurrentOrderedMap) walk(version uint64, n *cnode, lo int, hi *int, f func(key int, value interface{}) bool) {
+	after := false
+	for {
+		end := n == nil || hi != nil && n.key >= *hi
+		// A node in the snapshot but missing from the list between the
+		// last key and n has been deleted, and so was buried before n was
+		// reached. So has one with n's key if the key was deleted and stored
+		// again, making n a node that the snapshot does not see.
+		bound, through := hi, false
+		if !end {
+			bound, through = &n.key, true
+		}
+		for _, dead := range m.exhume(lo, after, bound, through) {
+			if value, ok := dead.at(version); ok && !f(dead.key, value) {
+				return
+			}
+		}
+		if end {
+			return
+		}
+		if value, ok := n.at(version); ok && !f(n.key, value) {
+			return
+		}
+		lo, after = n.key, true
+		n = n.next[0].Load()
+	}
+}
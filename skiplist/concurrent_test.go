@@ -0,0 +1,460 @@
+package skiplist
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/njwilson23/datastructures/observe"
+	"github.com/njwilson23/datastructures/rbtree"
+)
+
+func TestConcurrentOrderedMap(t *testing.T) {
+	m := NewConcurrentOrderedMap()
+	for _, key := range []int{5, 1, 9, 3, 7} {
+		m.Store(key, key*10)
+	}
+	m.Store(3, "three")
+	if v, ok := m.Load(3); !ok || v != "three" {
+		t.Error(v)
+	}
+	if _, ok := m.Load(4); ok {
+		t.Fail()
+	}
+	if v, loaded := m.LoadOrStore(5, 0); !loaded || v != 50 {
+		t.Error(v)
+	}
+	if v, loaded := m.LoadOrStore(4, 40); loaded || v != 40 {
+		t.Error(v)
+	}
+	if v, loaded := m.LoadAndDelete(9); !loaded || v != 90 {
+		t.Error(v)
+	}
+	if _, loaded := m.LoadAndDelete(9); loaded {
+		t.Fail()
+	}
+	m.Delete(1)
+	if m.Len() != 4 {
+		t.Error(m.Len())
+	}
+
+	var keys []int
+	m.Range(func(key int, value interface{}) bool {
+		keys = append(keys, key)
+		return true
+	})
+	if fmt.Sprint(keys) != "[3 4 5 7]" {
+		t.Error(keys)
+	}
+
+	keys = nil
+	m.RangeBetween(4, 7, func(key int, value interface{}) bool {
+		keys = append(keys, key)
+		return true
+	})
+	if fmt.Sprint(keys) != "[4 5]" {
+		t.Error(keys)
+	}
+
+	keys = nil
+	m.Range(func(key int, value interface{}) bool {
+		keys = append(keys, key)
+		return len(keys) != 2
+	})
+	if len(keys) != 2 {
+		t.Error(keys)
+	}
+}
+
+func TestConcurrentOrderedMapSubscribe(t *testing.T) {
+	m := NewConcurrentOrderedMap()
+	var mu sync.Mutex
+	var events []observe.Event[int, interface{}]
+	unsubscribe := m.Subscribe(func(e observe.Event[int, interface{}]) {
+		mu.Lock()
+		events = append(events, e)
+		mu.Unlock()
+	})
+	m.Store(1, "a")
+	m.Store(1, "b")
+	m.LoadOrStore(1, "c")
+	m.LoadOrStore(2, "d")
+	m.Delete(1)
+	m.Delete(3)
+	if fmt.Sprint(events) != "[{Put 1 a} {Put 1 b} {Put 2 d} {Delete 1 b}]" {
+		t.Error(events)
+	}
+
+	// Writers notify concurrently. Run with -race.
+	events = nil
+	var wg sync.WaitGroup
+	for w := 0; w != 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i != 100; i++ {
+				m.Store(w*100+i, i)
+				m.Delete(w*100 + i)
+			}
+		}(w)
+	}
+	wg.Wait()
+	unsubscribe()
+	m.Store(5, nil)
+	if len(events) != 800 {
+		t.Error(len(events))
+	}
+}
+
+// TestConcurrentOrderedMapParallel has goroutines insert and delete disjoint
+// sets of keys at once, and checks the final contents
+func TestConcurrentOrderedMapParallel(t *testing.T) {
+	m := NewConcurrentOrderedMap()
+	workers, perWorker := 8, 2000
+	var wg sync.WaitGroup
+	for w := 0; w != workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			r := rand.New(rand.NewSource(int64(w)))
+			// Keys are interleaved between workers, so that they contend for
+			// the same predecessors
+			for _, i := range r.Perm(perWorker) {
+				m.Store(i*workers+w, w)
+			}
+			for i := 0; i < perWorker; i += 2 {
+				if _, ok := m.LoadAndDelete(i*workers + w); !ok {
+					t.Errorf("key %d not found", i*workers+w)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	if m.Len() != workers*perWorker/2 {
+		t.Errorf("length %d", m.Len())
+	}
+	count, last := 0, -1
+	m.Range(func(key int, value interface{}) bool {
+		if key <= last || (key/workers)%2 != 1 || value != key%workers {
+			t.Fatalf("unexpected key %d after %d", key, last)
+		}
+		last = key
+		count++
+		return true
+	})
+	if count != m.Len() {
+		t.Error(count)
+	}
+}
+
+func TestConcurrentOrderedMapSnapshot(t *testing.T) {
+	m := NewConcurrentOrderedMap()
+	for key := 0; key != 10; key++ {
+		m.Store(key, 0)
+	}
+	var keys []int
+	var values []interface{}
+	m.Range(func(key int, value interface{}) bool {
+		if key == 2 {
+			// Changes made during the walk are not seen
+			m.Delete(5)
+			m.Store(3, 1)
+			m.Store(6, 1)
+			m.Delete(6)
+			m.Store(20, 1)
+			m.Delete(0)
+		}
+		keys = append(keys, key)
+		values = append(values, value)
+		return true
+	})
+	if fmt.Sprint(keys) != "[0 1 2 3 4 5 6 7 8 9]" || fmt.Sprint(values) != "[0 0 0 0 0 0 0 0 0 0]" {
+		t.Error(keys, values)
+	}
+	keys = nil
+	m.RangeBetween(3, 100, func(key int, value interface{}) bool {
+		keys = append(keys, key)
+		return true
+	})
+	if fmt.Sprint(keys) != "[3 4 7 8 9 20]" {
+		t.Error(keys)
+	}
+	// Values and nodes that no snapshot sees are not kept
+	m.Range(func(key int, value interface{}) bool {
+		for i := 0; i != 1000; i++ {
+			m.Store(8, i)
+			m.Store(100+i, i)
+			m.Delete(100 + i)
+		}
+		// The newest value, and the one the snapshot sees
+		n := m.head.next[0].Load()
+		for n.key != 8 {
+			n = n.next[0].Load()
+		}
+		chain := 0
+		for e := n.value.Load(); e != nil; e = e.prev.Load() {
+			chain++
+		}
+		if chain != 2 || len(m.graveyard) != 0 {
+			t.Error(chain, len(m.graveyard))
+		}
+		return false
+	})
+	// Nothing is kept once the snapshots are over
+	if len(m.graveyard) != 0 || len(m.snapshots) != 0 {
+		t.Error(m.graveyard, m.snapshots)
+	}
+	if v, _ := m.Load(3); v != 1 {
+		t.Error(v)
+	}
+	m.Store(3, 2)
+	if e, _ := m.Load(3); e != 2 || m.head.next[0].Load().value.Load().prev.Load() != nil {
+		t.Error("old values kept")
+	}
+}
+
+// TestConcurrentOrderedMapSnapshotParallel checks that walks see consistent
+// snapshots while writers change the map. Run it with -race.
+func TestConcurrentOrderedMapSnapshotRestore(t *testing.T) {
+	m := NewConcurrentOrderedMap()
+	for key := 0; key != 5; key++ {
+		m.Store(key, "old")
+	}
+	// A key deleted and stored again during the walk keeps its old value,
+	// even if it happens twice
+	restore := func(key int) {
+		for i := 0; i != 2; i++ {
+			m.Delete(key)
+			m.Store(key, "new")
+		}
+	}
+	var seen []string
+	m.Range(func(key int, value interface{}) bool {
+		if key == 0 {
+			restore(2)
+			restore(0)
+		}
+		seen = append(seen, fmt.Sprintf("%d=%v", key, value))
+		return true
+	})
+	if fmt.Sprint(seen) != "[0=old 1=old 2=old 3=old 4=old]" {
+		t.Error(seen)
+	}
+	seen = nil
+	m.RangeBetween(1, 4, func(key int, value interface{}) bool {
+		if key == 1 {
+			restore(3)
+		}
+		seen = append(seen, fmt.Sprintf("%d=%v", key, value))
+		return true
+	})
+	if fmt.Sprint(seen) != "[1=old 2=new 3=old]" {
+		t.Error(seen)
+	}
+	for _, key := range []int{0, 2, 3} {
+		if value, _ := m.Load(key); value != "new" {
+			t.Error(key, value)
+		}
+	}
+}
+
+func TestConcurrentOrderedMapSnapshotParallel(t *testing.T) {
+	const sweep, token = 100, 1000
+	m := NewConcurrentOrderedMap()
+	for key := 0; key != sweep; key++ {
+		m.Store(key, 0)
+	}
+	m.Store(token, nil)
+
+	stop := make(chan struct{})
+	var writers sync.WaitGroup
+	writers.Add(2)
+	// One writer sweeps over the first keys again and again, storing the
+	// number of the sweep, so a snapshot sees values that fall by at most
+	// one, and only once, from key to key
+	go func() {
+		defer writers.Done()
+		for generation := 1; ; generation++ {
+			for key := 0; key != sweep; key++ {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				m.Store(key, generation)
+				runtime.Gosched()
+			}
+		}
+	}()
+	// Another moves a single key upward by inserting the next key before
+	// deleting the last, so a snapshot sees one or two adjacent keys
+	go func() {
+		defer writers.Done()
+		for key := token; ; key++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			m.Store(key+1, nil)
+			m.Delete(key)
+			runtime.Gosched()
+		}
+	}()
+
+	var readers sync.WaitGroup
+	for r := 0; r != 4; r++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for i := 0; i != 50; i++ {
+				var values []int
+				var tokens []int
+				m.Range(func(key int, value interface{}) bool {
+					// Yield, so that the writers make progress during the walk
+					runtime.Gosched()
+					if key < sweep {
+						values = append(values, value.(int))
+					} else {
+						tokens = append(tokens, key)
+					}
+					return true
+				})
+				if len(values) != sweep {
+					t.Errorf("%d sweep keys", len(values))
+					return
+				}
+				drops := 0
+				for j := 1; j != sweep; j++ {
+					if values[j] != values[j-1] {
+						drops++
+						if values[j] != values[j-1]-1 || drops > 1 {
+							t.Errorf("inconsistent sweep %v", values)
+							return
+						}
+					}
+				}
+				if len(tokens) == 0 || len(tokens) > 2 || len(tokens) == 2 && tokens[1] != tokens[0]+1 {
+					t.Errorf("tokens %v", tokens)
+					return
+				}
+				tokens = nil
+				m.RangeBetween(token, math.MaxInt, func(key int, value interface{}) bool {
+					tokens = append(tokens, key)
+					return true
+				})
+				if len(tokens) == 0 || len(tokens) > 2 {
+					t.Errorf("tokens %v", tokens)
+					return
+				}
+			}
+		}()
+	}
+	readers.Wait()
+	close(stop)
+	writers.Wait()
+}
+
+// Benchmarks comparing ConcurrentOrderedMap with sync.Map and a red-black tree
+// behind a mutex
+//
+// Every goroutine runs a mix of lookups and insertions over 2^16 keys; run
+// with -cpu to vary the number of goroutines. On a single core, the tree is
+// about twice as fast as the skip-list, which follows more pointers per
+// lookup, and sync.Map is several times faster than either. The tree
+// serializes every operation on its lock, whereas lookups in the skip-list
+// never block and insertions only block each other when they are close
+// together, so the skip-list gains ground as cores are added. Only the two
+// ordered structures can answer range queries.
+
+const benchmarkKeys = 1 << 16
+
+type lockedTree struct {
+	mu   sync.Mutex
+	tree *rbtree.RedBlackTree
+}
+
+func benchmarkMixed(b *testing.B, writePercent int) {
+	keys := rand.New(rand.NewSource(1)).Perm(benchmarkKeys)
+
+	b.Run("ConcurrentOrderedMap", func(b *testing.B) {
+		m := NewConcurrentOrderedMap()
+		for _, key := range keys[:benchmarkKeys/2] {
+			m.Store(key, key)
+		}
+		b.RunParallel(func(pb *testing.PB) {
+			r := rand.New(rand.NewSource(rand.Int63()))
+			for pb.Next() {
+				key := keys[r.Intn(benchmarkKeys)]
+				if r.Intn(100) < writePercent {
+					m.Store(key, key)
+				} else {
+					m.Load(key)
+				}
+			}
+		})
+	})
+
+	b.Run("sync.Map", func(b *testing.B) {
+		var m sync.Map
+		for _, key := range keys[:benchmarkKeys/2] {
+			m.Store(key, key)
+		}
+		b.RunParallel(func(pb *testing.PB) {
+			r := rand.New(rand.NewSource(rand.Int63()))
+			for pb.Next() {
+				key := keys[r.Intn(benchmarkKeys)]
+				if r.Intn(100) < writePercent {
+					m.Store(key, key)
+				} else {
+					m.Load(key)
+				}
+			}
+		})
+	})
+
+	b.Run("RedBlackTree", func(b *testing.B) {
+		lt := &lockedTree{tree: rbtree.FromSlice(keys[:benchmarkKeys/2])}
+		b.RunParallel(func(pb *testing.PB) {
+			r := rand.New(rand.NewSource(rand.Int63()))
+			for pb.Next() {
+				key := keys[r.Intn(benchmarkKeys)]
+				lt.mu.Lock()
+				// The tree does not skip duplicate keys, so only absent keys
+				// are inserted
+				if r.Intn(100) < writePercent && !lt.tree.Contains(key) {
+					lt.tree.Insert(key)
+				} else {
+					lt.tree.Contains(key)
+				}
+				lt.mu.Unlock()
+			}
+		})
+	})
+}
+
+func BenchmarkMixedReadHeavy(b *testing.B) {
+	benchmarkMixed(b, 10)
+}
+
+func BenchmarkMixedWriteHeavy(b *testing.B) {
+	benchmarkMixed(b, 50)
+}
+
+func BenchmarkRangeBetween(b *testing.B) {
+	m := NewConcurrentOrderedMap()
+	for _, key := range rand.New(rand.NewSource(1)).Perm(benchmarkKeys) {
+		m.Store(key, key)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		lo := i % (benchmarkKeys - 100)
+		m.RangeBetween(lo, lo+100, func(key int, value interface{}) bool {
+			return true
+		})
+	}
+}
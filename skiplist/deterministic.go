@@ -0,0 +1,150 @@
+package skiplist
+
+// A deterministic skip-list
+//
+// The skip-list above is balanced only on average: with bad luck, the coin
+// flips can leave long runs of nodes without an index above them, and a
+// search degrades towards a linear scan. The 1-2-3 skip-list of Munro,
+// Papadakis and Sedgewick (1992) removes the randomness by enforcing a rule
+// instead: between any two adjacent nodes on one level, there are between
+// one and three nodes on the level below (a "gap" of size 1, 2 or 3). This
+// is the same invariant as a 2-3-4 tree, and gives the same O(log n)
+// worst-case bound.
+//
+// Each node is labelled with the largest key in the gap below it, and the
+// rightmost node on every level is labelled with +infinity:
+//
+//    L2  [ 13                         +inf ]
+//    L1  [ 3      8     13 ] [ 20     +inf ]
+//    L0  [1 3] [5 8] [10 13] [17 20] [25 +inf]
+//
+// Insertion works top-down. While descending, any gap of size 3 is split by
+// raising its middle node to the current level, so that there is always room
+// to add a node to the gap below. At the bottom, the new key is added to a
+// gap that has at most two nodes, which cannot break the invariant. If the
+// top level gains a second node, a new top level is added above it.
+//
+// This follows the presentation in Weiss, "Data Structures and Algorithm
+// Analysis", including its use of a bottom sentinel whose key is set to the
+// key being searched for, so that the search loops need no end checks.
+// Deletion requires merging or borrowing from adjacent gaps, as in a 2-3-4
+// tree, and is not implemented.
+
+import "errors"
+
+var ErrNotFound = errors.New("key not found")
+
+type dnode struct {
+	key   int
+	inf   bool // true if the key is +infinity
+	item  *Item
+	right *dnode
+	down  *dnode
+}
+
+// lessThan compares the keys of two nodes
+func (n *dnode) lessThan(other *dnode) bool {
+	return !n.inf && (other.inf || n.key < other.key)
+}
+
+// Deterministic is a 1-2-3 skip-list, whose shape depends only on the keys
+// inserted. Every copy of a key on the different levels points to the same
+// Item.
+type Deterministic struct {
+	header *dnode
+	bottom *dnode
+	tail   *dnode
+	len    int
+}
+
+// NewDeterministic creates an empty Deterministic skip-list
+func NewDeterministic() *Deterministic {
+	bottom := &dnode{}
+	bottom.right, bottom.down = bottom, bottom
+	tail := &dnode{inf: true}
+	tail.right = tail
+	return &Deterministic{
+		header: &dnode{inf: true, right: tail, down: bottom},
+		bottom: bottom,
+		tail:   tail,
+	}
+}
+
+// Len returns the number of items in the skip-list
+func (s *Deterministic) Len() int {
+	return s.len
+}
+
+// Height returns the number of levels, including the single node at the top
+func (s *Deterministic) Height() int {
+	h := 0
+	for n := s.header; n != s.bottom; n = n.down {
+		h++
+	}
+	return h
+}
+
+// Get returns the item with the given key
+func (s *Deterministic) Get(key int) (*Item, error) {
+	s.bottom.key = key
+	n := s.header
+	for n.inf || n.key != key {
+		if n.inf || key < n.key {
+			n = n.down
+		} else {
+			n = n.right
+		}
+	}
+	if n == s.bottom {
+		return nil, ErrNotFound
+	}
+	return n.item, nil
+}
+
+// Insert adds an item to the skip-list, or replaces the value of the item with
+// the same key
+func (s *Deterministic) Insert(key int, value interface{}) {
+	s.bottom.key = key
+	s.bottom.item = &Item{Key: key, Value: value}
+	n := s.header
+	for n != s.bottom {
+		for !n.inf && n.key < key {
+			n = n.right
+		}
+		if n.down == s.bottom && !n.inf && n.key == key {
+			// The key is on the bottom level, whether it was already present
+			// or has just been added
+			n.item.Value = value
+			break
+		}
+		// If the gap below has three nodes, raise the middle one. On the
+		// bottom level, the bottom sentinel stands in for every node of the
+		// gap, so this adds the new key.
+		if mid := n.down.right; mid.right.lessThan(n) {
+			n.right = &dnode{n.key, n.inf, n.item, n.right, mid.right}
+			n.key, n.inf, n.item = mid.key, mid.inf, mid.item
+			if n.down == s.bottom {
+				s.len++
+			}
+		} else {
+			n = n.down
+		}
+	}
+	s.bottom.item = nil
+	if s.header.right != s.tail {
+		s.header = &dnode{inf: true, right: s.tail, down: s.header}
+	}
+}
+
+// Items returns the items in the skip-list as a slice sorted by key
+func (s *Deterministic) Items() []Item {
+	n := s.header
+	for n.down != s.bottom {
+		n = n.down
+	}
+	items := make([]Item, 0, s.len)
+	for ; !n.inf; n = n.right {
+		items = append(items, *n.item)
+	}
+	return items
+}
@@ -0,0 +1,132 @@
+package skiplist
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestDeterministic(t *testing.T) {
+	s := NewDeterministic()
+	if _, err := s.Get(1); err != ErrNotFound {
+		t.Error(err)
+	}
+	for _, key := range []int{13, 3, 8, 20, 1, 5, 10, 17, 25} {
+		s.Insert(key, fmt.Sprint(key))
+	}
+	s.Insert(8, "eight")
+
+	if s.Len() != 9 {
+		t.Error(s.Len())
+	}
+	item, err := s.Get(8)
+	if err != nil || item.Value != "eight" {
+		t.Error(item, err)
+	}
+	item, err = s.Get(25)
+	if err != nil || item.Value != "25" {
+		t.Error(item, err)
+	}
+	if _, err := s.Get(9); err != ErrNotFound {
+		t.Error(err)
+	}
+
+	keys := []int{}
+	for _, item := range s.Items() {
+		keys = append(keys, item.Key)
+	}
+	if fmt.Sprint(keys) != "[1 3 5 8 10 13 17 20 25]" {
+		t.Error(keys)
+	}
+}
+
+// checkGaps verifies that every gap has between one and three nodes
+func checkGaps(t *testing.T, s *Deterministic) {
+	for level := s.header; level.down != s.bottom; level = level.down {
+		for n := level; ; n = n.right {
+			size := 0
+			for m := n.down; m.lessThan(n); m = m.right {
+				size++
+			}
+			if size > 3 {
+				t.Fatalf("gap of size %d", size)
+			}
+			if n.inf {
+				break
+			}
+		}
+	}
+}
+
+func TestDeterministicBalance(t *testing.T) {
+	for _, name := range []string{"ascending", "descending", "random"} {
+		s := NewDeterministic()
+		n := 4096
+		perm := rand.New(rand.NewSource(1)).Perm(n)
+		for i := 0; i != n; i++ {
+			switch name {
+			case "ascending":
+				s.Insert(i, i)
+			case "descending":
+				s.Insert(n-i, i)
+			case "random":
+				s.Insert(perm[i], i)
+			}
+		}
+		checkGaps(t, s)
+		if s.Len() != n || len(s.Items()) != n {
+			t.Fatal(s.Len())
+		}
+		// A gap of at least two nodes on every level would give log2(n)
+		// levels, plus the top level
+		if s.Height() > 14 {
+			t.Errorf("%s: height %d", name, s.Height())
+		}
+		for _, key := range perm[:100] {
+			if name == "descending" {
+				key++
+			}
+			if _, err := s.Get(key); err != nil {
+				t.Fatalf("%s: key %d not found", name, key)
+			}
+		}
+	}
+}
+
+// Benchmarks comparing the deterministic and probabilistic skip-lists
+//
+// Lookups cost about the same in both, but the deterministic skip-list also
+// bounds the worst case.
+
+func BenchmarkDeterministicInsert(b *testing.B) {
+	keys := rand.New(rand.NewSource(1)).Perm(benchmarkKeys)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s := NewDeterministic()
+		for _, key := range keys[:1024] {
+			s.Insert(key, nil)
+		}
+	}
+}
+
+func BenchmarkGet(b *testing.B) {
+	keys := rand.New(rand.NewSource(1)).Perm(benchmarkKeys)
+	d := NewDeterministic()
+	items := make(ItemSlice, len(keys))
+	for i, key := range keys {
+		d.Insert(key, nil)
+		items[i] = Item{Key: key}
+	}
+	p := New(items, 0.5)
+
+	b.Run("Deterministic", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			d.Get(keys[i%len(keys)])
+		}
+	})
+	b.Run("Probabilistic", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			p.Get(keys[i%len(keys)])
+		}
+	})
+}
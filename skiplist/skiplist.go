@@ -23,14 +23,16 @@ package skiplist
 import (
 	"errors"
 	"fmt"
+	"math"
 	"math/rand"
 	"sort"
+
+	"github.com/njwilson23/datastructures/observe"
+	"github.com/njwilson23/datastructures/pair"
 )
 
-type Item struct {
-	key   int
-	value interface{}
-}
+// Item is a key-value pair stored in the skip-list
+type Item = pair.KeyValue[int, interface{}]
 
 type ItemSlice []Item
 
@@ -43,13 +45,21 @@ func (items ItemSlice) Swap(i, j int) {
 }
 
 func (items ItemSlice) Less(i, j int) bool {
-	return items[i].key < items[j].key
+	return items[i].Key < items[j].Key
 }
 
 type Node struct {
 	next  *Node
 	below *Node
 	item  *Item
+
+	// events is only set on the head node, once something has subscribed to
+	// changes in the skip-list
+	events *observe.Subject[int, interface{}]
+
+	// arena is only set on the head node of a skip-list created by
+	// NewWithCapacity, and provides the nodes for Insert
+	arena *arena
 }
 
 // Depth indicates what level a node is on in the skip-list, with 0 denoting the base (data) level
@@ -64,51 +74,175 @@ func (n *Node) Depth() int {
 // in the layer above with probability p. The final layer contains a single head
 // node, which is the return value.
 func New(items ItemSlice, p float64) *Node {
+	return newSkipList(items, p, nil)
+}
+
+// NewWithCapacity is like New, but preallocates the nodes for up to *capacity*
+// items in a single block. Inserting up to that many items then allocates no
+// further nodes, and the nodes lie close together in memory. The block is
+// freed only once none of its nodes are in use.
+func NewWithCapacity(items ItemSlice, p float64, capacity int) *Node {
+	if capacity < len(items) {
+		capacity = len(items)
+	}
+	// Each item has 1/(1-p) nodes on average, and each level has a head node
+	a := &arena{make([]Node, int(float64(capacity)/(1-p))+64)}
+	head := newSkipList(items, p, a)
+	head.arena = a
+	return head
+}
+
+func newSkipList(items ItemSlice, p float64, a *arena) *Node {
 	if !sort.IsSorted(items) {
 		sort.Sort(items)
 	}
 
 	// build the bottom layer
-	nodes := make([]*Node, len(items)+1)
-	nodes[0] = &Node{nil, nil, nil}
-
+	bottom := a.alloc(nil, nil, nil)
+	last := bottom
 	for i := range items {
-		nodes[i+1] = &Node{nil, nil, &items[i]}
-		nodes[i].next = nodes[i+1]
-	}
-
-	// Build layers until left with only a head node
-	var node *Node
-	for len(nodes) != 1 {
-
-		node = nodes[1]
-		nodesAbove := []*Node{&Node{nil, nodes[0], nil}}
-
-		pos := 1
-		for node != nil {
-			if rand.Float64() < p {
-				nodesAbove = append(nodesAbove, &Node{
-					next:  nil,
-					below: node,
-					item:  node.item,
-				})
-				nodesAbove[len(nodesAbove)-2].next = nodesAbove[len(nodesAbove)-1]
+		last.next = a.alloc(nil, nil, &items[i])
+		last = last.next
+	}
+
+	return build(bottom, func(int) bool { return rand.Float64() < p }, a)
+}
+
+// build adds layers above the data layer whose head node is *bottom*, until
+// left with only a head node, which is returned. The i-th node of a layer
+// (counting from 1, after the head node) is included in the layer above if
+// *promote(i)* is true.
+func build(bottom *Node, promote func(i int) bool, a *arena) *Node {
+	head := bottom
+	for head.next != nil {
+		above := a.alloc(nil, head, nil)
+		last := above
+		i := 1
+		for n := head.next; n != nil; n = n.next {
+			if promote(i) {
+				last.next = a.alloc(nil, n, n.item)
+				last = last.next
 			}
-			node = node.next
-			pos++
+			i++
 		}
+		head = above
+	}
+	return head
+}
+
+// Compact rebuilds the skip-list in place, with the nodes for each layer
+// allocated together in key order, and with every 1/p-th node of each layer
+// included in the layer above. This is the ideal shape that random inclusion
+// only approximates, and after many insertions, Compact restores it and
+// removes the fragmentation left by nodes allocated one at a time. It must be
+// called on the head node.
+func (head *Node) Compact(p float64) {
+	var items []*Item
+	n := head
+	for n.below != nil {
+		n = n.below
+	}
+	for n = n.next; n != nil; n = n.next {
+		items = append(items, n.item)
+	}
+
+	step := int(math.Round(1 / p))
+	if step < 2 {
+		step = 2
+	}
+	size := len(items) + 1
+	for m := len(items); m != 0; m /= step {
+		size += m/step + 1
+	}
+	a := &arena{make([]Node, size)}
+
+	bottom := a.alloc(nil, nil, nil)
+	last := bottom
+	for _, item := range items {
+		last.next = a.alloc(nil, nil, item)
+		last = last.next
+	}
+	top := build(bottom, func(i int) bool { return i%step == 0 }, a)
+	head.next, head.below, head.item = top.next, top.below, top.item
+	head.arena = nil
+}
+
+// arena holds a preallocated block of nodes
+type arena struct {
+	nodes []Node
+}
 
-		nodes = nodesAbove
+// alloc returns a node from the arena, or a newly allocated node if the arena
+// is nil or used up
+func (a *arena) alloc(next, below *Node, item *Item) *Node {
+	if a == nil || len(a.nodes) == 0 {
+		return &Node{next: next, below: below, item: item}
 	}
+	n := &a.nodes[0]
+	a.nodes = a.nodes[1:]
+	n.next, n.below, n.item = next, below, item
+	return n
+}
+
+// Items returns the items in the skip-list as a slice sorted by key. Since the
+// data layer is an ordinary linked list, this is a simple walk along the bottom
+// of the skip-list from the head node.
+func (head *Node) Items() []Item {
+	n := head
+	for n.below != nil {
+		n = n.below
+	}
+	var items []Item
+	for n = n.next; n != nil; n = n.next {
+		items = append(items, *n.item)
+	}
+	return items
+}
+
+// Iterator walks the items of a skip-list in ascending key order
+type Iterator struct {
+	node *Node
+}
 
-	return nodes[0]
+// Iter returns an Iterator positioned before the first item in the skip-list
+func (head *Node) Iter() *Iterator {
+	n := head
+	for n.below != nil {
+		n = n.below
+	}
+	return &Iterator{n}
+}
+
+// Next returns the next key in the skip-list, or false if all keys have been
+// visited
+func (it *Iterator) Next() (int, bool) {
+	if it.node.next == nil {
+		return 0, false
+	}
+	it.node = it.node.next
+	return it.node.item.Key, true
+}
+
+// Item returns the item most recently visited by Next
+func (it *Iterator) Item() *Item {
+	return it.node.item
+}
+
+// Value returns the value of the item most recently visited by Next
+func (it *Iterator) Value() interface{} {
+	return it.node.item.Value
+}
+
+// Keys returns the keys in the skip-list as a sorted slice
+func (head *Node) Keys() []int {
+	return pair.Keys(head.Items())
 }
 
 func (n *Node) PrintKeys() {
 	fmt.Print("*")
 	node := n.next
 	for node != nil {
-		fmt.Printf("%5d ", node.item.key)
+		fmt.Printf("%5d ", node.item.Key)
 		node = node.next
 	}
 	fmt.Print("\n")
@@ -120,7 +254,7 @@ func (n *Node) PrintKeys() {
 
 // Get returns an item from the skip-list by key, or a non-nil error if the item is not found
 func (n *Node) Get(key int) (*Item, error) {
-	if n.below.next.item.key > key {
+	if n.below.next.item.Key > key {
 		return n.below.Get(key)
 	}
 	return get(n.below.next, key)
@@ -128,20 +262,20 @@ func (n *Node) Get(key int) (*Item, error) {
 
 func get(n *Node, key int) (*Item, error) {
 	// check for a direct shortcut
-	if n.item.key == key {
+	if n.item.Key == key {
 		return n.item, nil
 	}
 
 	// linear search on data layer
 	if n.below == nil {
-		if n.next == nil || n.next.item.key > key {
+		if n.next == nil || n.next.item.Key > key {
 			return nil, errors.New("index error")
 		}
 		return get(n.next, key) // TODO: expand as loop
 	}
 
 	// choose whether to move down or right
-	if n.next == nil || n.next.item.key > key {
+	if n.next == nil || n.next.item.Key > key {
 		return get(n.below, key)
 	} else {
 		return get(n.next, key)
@@ -162,40 +296,54 @@ func get(n *Node, key int) (*Item, error) {
 func (head *Node) Insert(item *Item, p float64) error {
 
 	// Handle the second case
-	nodeInsertedBelow := insert(item, head.below, p)
-	if nodeInsertedBelow != nil {
-		// The head node needs to be replaced because we permit only one node at the
-		// top level (why?)
-		head.below = &Node{head.next, head.below, head.item}
-		head.next = nil
+	promoted := insert(item, head.below, p, head.arena)
+	if promoted != nil {
+		// We permit only one node at the top level (why?), so instead of adding
+		// the promoted node beside the head node, a new level is added below the
+		// head node to hold it
+		head.below = head.arena.alloc(head.arena.alloc(nil, promoted, item), head.below, nil)
+	}
+	if head.events != nil {
+		head.events.Notify(observe.Put, item.Key, item.Value)
 	}
 	return nil
 }
 
+// Subscribe registers a callback that is called after every subsequent Insert
+// into the skip-list, and returns a function that cancels the subscription.
+// It must be called on the head node.
+func (head *Node) Subscribe(fn func(observe.Event[int, interface{}])) (unsubscribe func()) {
+	if head.events == nil {
+		head.events = &observe.Subject[int, interface{}]{}
+	}
+	return head.events.Subscribe(fn)
+}
+
 // insert is the recursive helper function called by Insert. It takes an item to
-// insert, a node to the left of where the item should go, and a probability
-// that the node will appear in the list index above.
+// insert, a node to the left of where the item should go, a probability that
+// the node will appear in the list index above, and an arena to allocate nodes
+// from.
 //
 // It returns a non-nil pointer to the inserted node iff a reference to the node
 // should be added to the index list above.
-func insert(item *Item, n *Node, p float64) (nodeInsertedBelow *Node) {
-	if n.below != nil {
-		nodeInsertedBelow = insert(item, n.below, p)
+func insert(item *Item, n *Node, p float64, a *arena) (nodeInserted *Node) {
+	for n.next != nil && n.next.item.Key < item.Key {
+		n = n.next
 	}
 
-	if n.below == nil {
-		// This is the data level, so create node to insert here
-		nodeInsertedBelow = &Node{nil, nil, item}
+	// On index levels, the item is only added if it was promoted from the level
+	// below
+	var below *Node
+	if n.below != nil {
+		below = insert(item, n.below, p, a)
+		if below == nil {
+			return nil
+		}
 	}
 
-	if nodeInsertedBelow != nil {
-		for n.next != nil && n.next.item.key < item.key {
-			n = n.next
-		}
-		n.next = &Node{n.next, nodeInsertedBelow.below, item}
-		if rand.Float64() >= p {
-			nodeInsertedBelow = nil
-		}
+	n.next = a.alloc(n.next, below, item)
+	if rand.Float64() >= p {
+		return nil
 	}
-	return
+	return n.next
 }
@@ -4,22 +4,24 @@ import (
 	"fmt"
 	"math/rand"
 	"testing"
+
+	"github.com/njwilson23/datastructures/observe"
 )
 
 func TestSkipListBuild(t *testing.T) {
 	items := ItemSlice([]Item{
-		Item{3, "a"},
-		Item{5, "a"},
-		Item{30, "a"},
-		Item{13, "a"},
-		Item{8, "a"},
-		Item{1, "a"},
-		Item{23, "a"},
-		Item{6, "a"},
-		Item{17, "b"}, // this one's not ike the others!
-		Item{11, "a"},
-		Item{10, "a"},
-		Item{2, "a"},
+		Item{Key: 3, Value: "a"},
+		Item{Key: 5, Value: "a"},
+		Item{Key: 30, Value: "a"},
+		Item{Key: 13, Value: "a"},
+		Item{Key: 8, Value: "a"},
+		Item{Key: 1, Value: "a"},
+		Item{Key: 23, Value: "a"},
+		Item{Key: 6, Value: "a"},
+		Item{Key: 17, Value: "b"}, // this one's not ike the others!
+		Item{Key: 11, Value: "a"},
+		Item{Key: 10, Value: "a"},
+		Item{Key: 2, Value: "a"},
 	})
 
 	rand.Seed(17)
@@ -32,18 +34,18 @@ func TestSkipListBuild(t *testing.T) {
 
 func TestSkipListGet(t *testing.T) {
 	items := ItemSlice([]Item{
-		Item{3, "a"},
-		Item{5, "a"},
-		Item{30, "a"},
-		Item{13, "a"},
-		Item{8, "a"},
-		Item{1, "a"},
-		Item{23, "a"},
-		Item{6, "a"},
-		Item{17, "b"}, // this one's not ike the others!
-		Item{11, "a"},
-		Item{10, "a"},
-		Item{2, "a"},
+		Item{Key: 3, Value: "a"},
+		Item{Key: 5, Value: "a"},
+		Item{Key: 30, Value: "a"},
+		Item{Key: 13, Value: "a"},
+		Item{Key: 8, Value: "a"},
+		Item{Key: 1, Value: "a"},
+		Item{Key: 23, Value: "a"},
+		Item{Key: 6, Value: "a"},
+		Item{Key: 17, Value: "b"}, // this one's not ike the others!
+		Item{Key: 11, Value: "a"},
+		Item{Key: 10, Value: "a"},
+		Item{Key: 2, Value: "a"},
 	})
 
 	rand.Seed(17)
@@ -53,47 +55,163 @@ func TestSkipListGet(t *testing.T) {
 		t.Error()
 	}
 
-	if val.value.(string) != "b" {
+	if val.Value.(string) != "b" {
 		t.Fail()
 	}
 }
 
 func TestSkipListInsert(t *testing.T) {
 	items := ItemSlice([]Item{
-		Item{3, "a"},
-		Item{5, "a"},
-		Item{30, "a"},
-		Item{13, "a"},
-		Item{8, "a"},
-		Item{1, "a"},
-		Item{23, "a"},
-		Item{6, "a"},
-		Item{17, "a"},
-		Item{11, "a"},
-		Item{10, "a"},
-		Item{2, "a"},
+		Item{Key: 3, Value: "a"},
+		Item{Key: 5, Value: "a"},
+		Item{Key: 30, Value: "a"},
+		Item{Key: 13, Value: "a"},
+		Item{Key: 8, Value: "a"},
+		Item{Key: 1, Value: "a"},
+		Item{Key: 23, Value: "a"},
+		Item{Key: 6, Value: "a"},
+		Item{Key: 17, Value: "a"},
+		Item{Key: 11, Value: "a"},
+		Item{Key: 10, Value: "a"},
+		Item{Key: 2, Value: "a"},
 	})
 
 	rand.Seed(17)
 	headNode := New(items, 0.1)
-	headNode.Insert(&Item{12, "you found it!"}, 0.8)
+	headNode.Insert(&Item{Key: 12, Value: "you found it!"}, 0.8)
 	item, err := headNode.Get(12)
 
 	if err != nil {
 		t.Error()
-	} else if item.value != "you found it!" {
+	} else if item.Value != "you found it!" {
 		t.Fail()
 	}
 
 	// insert a new minimum value
-	headNode.Insert(&Item{0, "another!"}, 0.1)
+	headNode.Insert(&Item{Key: 0, Value: "another!"}, 0.1)
 	headNode.PrintKeys()
 	item, err = headNode.Get(0)
 
 	if err != nil {
 		fmt.Println(err)
 		t.Error()
-	} else if item.value != "another!" {
+	} else if item.Value != "another!" {
+		t.Fail()
+	}
+}
+
+func TestSkipListItems(t *testing.T) {
+	items := ItemSlice([]Item{
+		Item{Key: 3, Value: "a"},
+		Item{Key: 1, Value: "b"},
+		Item{Key: 2, Value: "c"},
+	})
+
+	headNode := New(items, 0.5)
+	headNode.Insert(&Item{Key: 4, Value: "d"}, 0.5)
+	result := headNode.Items()
+	if len(result) != 4 {
+		t.Fatal(result)
+	}
+	for i, item := range result {
+		if item.Key != i+1 {
+			t.Fail()
+		}
+	}
+	if result[1].Value != "c" {
+		t.Fail()
+	}
+
+	keys := headNode.Keys()
+	if len(keys) != 4 || keys[3] != 4 {
+		t.Fail()
+	}
+}
+
+func TestSkipListSubscribe(t *testing.T) {
+	headNode := New(ItemSlice{Item{Key: 1, Value: "a"}, Item{Key: 5, Value: "b"}}, 0.5)
+	var events []observe.Event[int, interface{}]
+	headNode.Subscribe(func(e observe.Event[int, interface{}]) {
+		events = append(events, e)
+	})
+
+	headNode.Insert(&Item{Key: 3, Value: "c"}, 0.5)
+	if len(events) != 1 {
+		t.Fatal(events)
+	}
+	if events[0].Op != observe.Put || events[0].Key != 3 || events[0].Value != "c" {
 		t.Fail()
 	}
 }
+
+func TestSkipListCapacity(t *testing.T) {
+	headNode := NewWithCapacity(ItemSlice{Item{Key: 0, Value: "a"}}, 0.5, 1000)
+	items := make([]Item, 999)
+	for i := range items {
+		items[i] = Item{Key: i + 1, Value: "b"}
+	}
+	i := 0
+	allocs := testing.AllocsPerRun(len(items)-1, func() {
+		headNode.Insert(&items[i], 0.5)
+		i++
+	})
+	if allocs != 0 {
+		t.Errorf("%v allocations per Insert", allocs)
+	}
+	if keys := headNode.Keys(); len(keys) != 1000 || keys[999] != 999 {
+		t.Error(len(keys))
+	}
+}
+
+func TestSkipListCompact(t *testing.T) {
+	rand.Seed(3)
+	headNode := New(ItemSlice{Item{Key: 0, Value: 0}}, 0.5)
+	for _, key := range rand.Perm(1000) {
+		headNode.Insert(&Item{Key: key + 1, Value: key + 1}, 0.5)
+	}
+	headNode.Compact(0.5)
+
+	// Halving 1001 items leaves a layer holding one item after nine times, and
+	// an empty layer with only the head node after ten
+	if headNode.Depth() != 10 {
+		t.Error(headNode.Depth())
+	}
+	items := headNode.Items()
+	if len(items) != 1001 {
+		t.Fatal(len(items))
+	}
+	for i, item := range items {
+		if item.Key != i {
+			t.Fatal(item)
+		}
+		if found, err := headNode.Get(i); err != nil || found.Value != i {
+			t.Fatal(i, found, err)
+		}
+	}
+}
+
+func BenchmarkSkipListGetAfterChurn(b *testing.B) {
+	build := func() *Node {
+		rand.Seed(1)
+		headNode := New(ItemSlice{Item{Key: 0}}, 0.5)
+		for _, key := range rand.Perm(benchmarkKeys) {
+			headNode.Insert(&Item{Key: key + 1}, 0.5)
+		}
+		return headNode
+	}
+	b.Run("Inserted", func(b *testing.B) {
+		headNode := build()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			headNode.Get(i%benchmarkKeys + 1)
+		}
+	})
+	b.Run("Compacted", func(b *testing.B) {
+		headNode := build()
+		headNode.Compact(0.5)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			headNode.Get(i%benchmarkKeys + 1)
+		}
+	})
+}
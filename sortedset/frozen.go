@@ -0,0 +1,80 @@
+package sortedset
+
+// Frozen sets
+//
+// A binary search over a sorted slice jumps between distant positions for its
+// first several steps, and each jump is likely to miss the CPU cache. A
+// Frozen set stores the same keys in "Eytzinger" order instead, the order of
+// a breadth-first walk of the implicit balanced search tree: the root, then
+// both nodes of the second level, then the four of the third, and so on.
+//
+//    sorted:     1  2  3  4  5  6  7
+//    Eytzinger:  4  2  6  1  3  5  7     children of position i: 2i+1, 2i+2
+//
+// A search descends from position 0, and the first levels of the tree, which
+// every search visits, are packed together at the start of the slice where
+// they stay in cache, and the two children of a node are adjacent, so they
+// usually share a cache line.
+//
+// A Frozen set cannot be modified, so it can be read from any number of
+// goroutines without locking.
+
+// Frozen is a read-only set of integers laid out for fast lookup
+type Frozen struct {
+	keys []int // in Eytzinger order
+}
+
+// Freeze returns a read-only copy of the set
+func (s *SortedSet) Freeze() *Frozen {
+	f := &Frozen{make([]int, len(s.keys))}
+	f.fill(s.keys, 0, 0)
+	return f
+}
+
+// fill places the sorted keys at the positions of an in-order walk from
+// position *i*, starting with sorted[next], and returns the index of the next
+// key to place
+func (f *Frozen) fill(sorted []int, i, next int) int {
+	if i >= len(f.keys) {
+		return next
+	}
+	next = f.fill(sorted, 2*i+1, next)
+	f.keys[i] = sorted[next]
+	return f.fill(sorted, 2*i+2, next+1)
+}
+
+// Len returns the number of keys in the set
+func (f *Frozen) Len() int {
+	return len(f.keys)
+}
+
+// Contains returns true if *key* is in the set
+func (f *Frozen) Contains(key int) bool {
+	i := 0
+	for i < len(f.keys) {
+		k := f.keys[i]
+		if k == key {
+			return true
+		}
+		i = 2*i + 1
+		if k < key {
+			i++
+		}
+	}
+	return false
+}
+
+// Keys returns the keys in the set in ascending order
+func (f *Frozen) Keys() []int {
+	keys := make([]int, 0, len(f.keys))
+	var walk func(i int)
+	walk = func(i int) {
+		if i < len(f.keys) {
+			walk(2*i + 1)
+			keys = append(keys, f.keys[i])
+			walk(2*i + 2)
+		}
+	}
+	walk(0)
+	return keys
+}
@@ -0,0 +1,59 @@
+package sortedset
+
+import (
+	"testing"
+
+	"github.com/njwilson23/datastructures/gen"
+)
+
+func TestFrozen(t *testing.T) {
+	s := FromSlice([]int{1, 2, 3, 4, 5, 6, 7})
+	f := s.Freeze()
+	for i, expected := range []int{4, 2, 6, 1, 3, 5, 7} {
+		if f.keys[i] != expected {
+			t.Fatal(f.keys)
+		}
+	}
+	s.Insert(8)
+	if f.Len() != 7 || f.Contains(8) {
+		t.Error("frozen set changed")
+	}
+
+	for n := 0; n != 40; n++ {
+		keys := gen.New(int64(n)).Uniform(n, 100)
+		s := FromSlice(keys)
+		f := s.Freeze()
+		for key := -1; key != 101; key++ {
+			if f.Contains(key) != s.Contains(key) {
+				t.Fatal(n, key)
+			}
+		}
+		sorted := f.Keys()
+		if len(sorted) != s.Len() {
+			t.Fatal(sorted)
+		}
+		for i, key := range s.Keys() {
+			if sorted[i] != key {
+				t.Fatal(sorted)
+			}
+		}
+	}
+}
+
+func BenchmarkFrozenContains(b *testing.B) {
+	g := gen.New(1)
+	s := FromSlice(g.Perm(1 << 20))
+	probes := g.Uniform(1<<16, 1<<21)
+	b.Run("SortedSet", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			s.Contains(probes[i%len(probes)])
+		}
+	})
+	b.Run("Frozen", func(b *testing.B) {
+		f := s.Freeze()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			f.Contains(probes[i%len(probes)])
+		}
+	})
+}
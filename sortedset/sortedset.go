@@ -0,0 +1,119 @@
+/*
+ * Package sortedset implements a set of integers stored in a sorted slice.
+ *
+ * Lookups use binary search, which is O(log n) like a balanced tree, but the
+ * keys are stored contiguously in memory. For small collections this makes
+ * lookup and iteration considerably faster than chasing pointers through a
+ * tree (see package rbtree). The price is that inserting or deleting a key
+ * costs O(n), because the keys after it have to move.
+ *
+ * Mutation is copy-on-write: the backing slice is never modified once it has
+ * been created, and Insert and Delete build a new slice instead. Since the
+ * cost of a mutation is O(n) anyway, this costs little extra, and it means that
+ * a Clone is O(1), and that a clone, an Iterator or a slice returned by Keys
+ * is an immutable snapshot that can be read from other goroutines while the
+ * original set continues to be modified.
+ *
+ * This makes a SortedSet a good fit for read-heavy collections of up to a few
+ * thousand keys. The benchmarks in the tests show where the crossover with
+ * package rbtree lies.
+ */
+
+package sortedset
+
+import (
+	"sort"
+
+	"github.com/njwilson23/datastructures/iterator"
+)
+
+// SortedSet is a set of integers backed by an immutable sorted slice. The
+// zero value is an empty set.
+type SortedSet struct {
+	keys []int
+}
+
+// New creates an empty SortedSet
+func New() *SortedSet {
+	return &SortedSet{}
+}
+
+// FromSlice creates a SortedSet containing the keys in a slice, which need not
+// be sorted and may contain duplicates. The slice is not modified.
+func FromSlice(keys []int) *SortedSet {
+	sorted := make([]int, len(keys))
+	copy(sorted, keys)
+	sort.Ints(sorted)
+
+	// Remove duplicates
+	n := 0
+	for i, key := range sorted {
+		if i == 0 || key != sorted[n-1] {
+			sorted[n] = key
+			n++
+		}
+	}
+	return &SortedSet{sorted[:n:n]}
+}
+
+// Len returns the number of keys in the set
+func (s *SortedSet) Len() int {
+	return len(s.keys)
+}
+
+// search returns the position of *key* in the set, or the position where it
+// would be inserted
+func (s *SortedSet) search(key int) (int, bool) {
+	i := sort.SearchInts(s.keys, key)
+	return i, i < len(s.keys) && s.keys[i] == key
+}
+
+// Contains returns true if *key* is in the set
+func (s *SortedSet) Contains(key int) bool {
+	_, found := s.search(key)
+	return found
+}
+
+// Insert adds a key to the set, returning false if it was already present
+func (s *SortedSet) Insert(key int) bool {
+	i, found := s.search(key)
+	if found {
+		return false
+	}
+	keys := make([]int, len(s.keys)+1)
+	copy(keys, s.keys[:i])
+	keys[i] = key
+	copy(keys[i+1:], s.keys[i:])
+	s.keys = keys
+	return true
+}
+
+// Delete removes a key from the set, returning false if it was not present
+func (s *SortedSet) Delete(key int) bool {
+	i, found := s.search(key)
+	if !found {
+		return false
+	}
+	keys := make([]int, len(s.keys)-1)
+	copy(keys, s.keys[:i])
+	copy(keys[i:], s.keys[i+1:])
+	s.keys = keys
+	return true
+}
+
+// Clone returns a copy of the set in O(1) time. The copy shares its backing
+// slice with the original until either of them is modified.
+func (s *SortedSet) Clone() *SortedSet {
+	return &SortedSet{s.keys}
+}
+
+// Keys returns the keys in the set in ascending order. The returned slice is a
+// snapshot that is shared with the set, and must not be modified.
+func (s *SortedSet) Keys() []int {
+	return s.keys
+}
+
+// Iter returns an Iterator over a snapshot of the keys in the set
+func (s *SortedSet) Iter() *iterator.SliceIterator {
+	return iterator.FromSlice(s.keys)
+}
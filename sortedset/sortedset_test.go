@@ -0,0 +1,152 @@
+package sortedset
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/njwilson23/datastructures/gen"
+	"github.com/njwilson23/datastructures/iterator"
+	"github.com/njwilson23/datastructures/rbtree"
+)
+
+func slicesEqual(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestFromSlice(t *testing.T) {
+	input := []int{5, 1, 3, 1, 5, 2}
+	s := FromSlice(input)
+	if !slicesEqual(s.Keys(), []int{1, 2, 3, 5}) {
+		t.Error(s.Keys())
+	}
+	if input[0] != 5 {
+		t.Error("input was modified")
+	}
+	if s.Len() != 4 {
+		t.Fail()
+	}
+}
+
+func TestInsertDelete(t *testing.T) {
+	s := New()
+	for _, key := range []int{4, 2, 6, 2} {
+		s.Insert(key)
+	}
+	if !slicesEqual(s.Keys(), []int{2, 4, 6}) {
+		t.Error(s.Keys())
+	}
+	if !s.Contains(4) || s.Contains(5) {
+		t.Fail()
+	}
+
+	if !s.Delete(4) || s.Delete(4) {
+		t.Fail()
+	}
+	if !slicesEqual(s.Keys(), []int{2, 6}) {
+		t.Error(s.Keys())
+	}
+}
+
+func TestCopyOnWrite(t *testing.T) {
+	s := FromSlice([]int{1, 2, 3})
+	snapshot := s.Keys()
+	clone := s.Clone()
+	it := s.Iter()
+
+	s.Insert(0)
+	s.Delete(2)
+
+	if !slicesEqual(snapshot, []int{1, 2, 3}) {
+		t.Error(snapshot)
+	}
+	if !slicesEqual(clone.Keys(), []int{1, 2, 3}) {
+		t.Error(clone.Keys())
+	}
+	if !slicesEqual(iterator.Collect(it), []int{1, 2, 3}) {
+		t.Fail()
+	}
+	if !slicesEqual(s.Keys(), []int{0, 1, 3}) {
+		t.Error(s.Keys())
+	}
+
+	clone.Insert(10)
+	if s.Contains(10) {
+		t.Fail()
+	}
+}
+
+// Benchmarks comparing SortedSet with rbtree
+//
+// Building a SortedSet key-by-key costs O(n^2) in total, so it loses to the
+// tree for all but small n. Iteration over the contiguous slice is much faster
+// than walking the tree at every size.
+
+var benchmarkSizes = []int{16, 128, 1024, 8192}
+
+func randomKeys(n int) []int {
+	return gen.New(int64(n)).Perm(n)
+}
+
+func BenchmarkInsert(b *testing.B) {
+	for _, n := range benchmarkSizes {
+		keys := randomKeys(n)
+		b.Run(fmt.Sprintf("SortedSet/n=%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				s := New()
+				for _, key := range keys {
+					s.Insert(key)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("RedBlackTree/n=%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				tree := rbtree.New()
+				for _, key := range keys {
+					tree.Insert(key)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkIterate(b *testing.B) {
+	for _, n := range benchmarkSizes {
+		keys := randomKeys(n)
+		s := FromSlice(keys)
+		tree := rbtree.FromSlice(keys)
+		b.Run(fmt.Sprintf("SortedSet/n=%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				it := s.Iter()
+				for _, ok := it.Next(); ok; _, ok = it.Next() {
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("RedBlackTree/n=%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				it := tree.Iter()
+				for _, ok := it.Next(); ok; _, ok = it.Next() {
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkContains(b *testing.B) {
+	for _, n := range benchmarkSizes {
+		keys := randomKeys(n)
+		s := FromSlice(keys)
+		b.Run(fmt.Sprintf("SortedSet/n=%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				s.Contains(keys[i%n])
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+/*
+ * Package sparseset implements a set of small non-negative integers with O(1)
+ * insertion, removal, membership and clearing (Briggs and Torczon, 1993).
+ *
+ * The set is two arrays. *dense* lists the members, packed at the front, and
+ * *sparse* maps each member back to its position in *dense*:
+ *
+ *    dense:  [ 7  2  5 | .  .  . ]      n = 3
+ *    sparse: [ .  .  1  .  .  2  .  0 ]
+ *              0  1  2  3  4  5  6  7
+ *
+ * x is a member if sparse[x] points to a position below n that holds x.
+ * Entries of *sparse* for non-members may hold anything, since they fail that
+ * check, so nothing needs to be reset when members are removed: clearing the
+ * set just sets n to 0. A member is removed by moving the last member into
+ * its place in *dense*.
+ *
+ * Compared with a bit set, a sparse set uses far more memory (two ints per
+ * element of the universe), but iterating over it visits only the members,
+ * and clearing it does not touch the whole universe. This suits algorithms
+ * that repeatedly fill and empty a set of, say, graph vertices, such as a
+ * visited set reused between searches.
+ */
+
+package sparseset
+
+import "fmt"
+
+// Set is a set of integers in [0, universe)
+type Set struct {
+	dense  []int
+	sparse []int
+}
+
+// New creates an empty Set for integers in [0, universe)
+func New(universe int) *Set {
+	return &Set{
+		dense:  make([]int, 0, universe),
+		sparse: make([]int, universe),
+	}
+}
+
+// Universe returns the size of the universe the set was created for
+func (s *Set) Universe() int {
+	return len(s.sparse)
+}
+
+// Len returns the number of members
+func (s *Set) Len() int {
+	return len(s.dense)
+}
+
+// Contains returns true if *x* is a member
+func (s *Set) Contains(x int) bool {
+	if x < 0 || x >= len(s.sparse) {
+		return false
+	}
+	i := s.sparse[x]
+	return i < len(s.dense) && s.dense[i] == x
+}
+
+// Add adds *x* to the set, returning false if it was already a member. It
+// panics if *x* is outside the universe.
+func (s *Set) Add(x int) bool {
+	if x < 0 || x >= len(s.sparse) {
+		panic(fmt.Sprintf("sparseset: %d outside universe [0, %d)", x, len(s.sparse)))
+	}
+	if s.Contains(x) {
+		return false
+	}
+	s.sparse[x] = len(s.dense)
+	s.dense = append(s.dense, x)
+	return true
+}
+
+// Remove removes *x* from the set, returning false if it was not a member
+func (s *Set) Remove(x int) bool {
+	if !s.Contains(x) {
+		return false
+	}
+	i, last := s.sparse[x], s.dense[len(s.dense)-1]
+	s.dense[i] = last
+	s.sparse[last] = i
+	s.dense = s.dense[:len(s.dense)-1]
+	return true
+}
+
+// Clear removes every member
+func (s *Set) Clear() {
+	s.dense = s.dense[:0]
+}
+
+// Members returns the members, in no particular order. The slice is shared
+// with the set, and is only valid until the set is next modified.
+func (s *Set) Members() []int {
+	return s.dense
+}
@@ -0,0 +1,63 @@
+package sparseset
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestSet(t *testing.T) {
+	s := New(10)
+	for _, x := range []int{7, 2, 5} {
+		if !s.Add(x) {
+			t.Error(x)
+		}
+	}
+	if s.Add(2) || s.Len() != 3 || s.Contains(3) || s.Contains(-1) || s.Contains(10) {
+		t.Fail()
+	}
+	if !s.Remove(7) || s.Remove(7) || s.Contains(7) || !s.Contains(5) {
+		t.Fail()
+	}
+	s.Clear()
+	if s.Len() != 0 || s.Contains(2) || s.Contains(5) {
+		t.Fail()
+	}
+	// Stale entries of sparse must not make 5 appear to be a member
+	s.Add(9)
+	s.Add(2)
+	if s.Contains(5) || !s.Contains(2) || len(s.Members()) != 2 {
+		t.Fail()
+	}
+}
+
+func TestRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	s := New(64)
+	present := make(map[int]bool)
+	for i := 0; i != 10000; i++ {
+		x := rng.Intn(64)
+		switch rng.Intn(10) {
+		case 0:
+			s.Clear()
+			present = make(map[int]bool)
+		case 1, 2, 3, 4:
+			if s.Remove(x) != present[x] {
+				t.Fatal("Remove", x)
+			}
+			delete(present, x)
+		default:
+			if s.Add(x) == present[x] {
+				t.Fatal("Add", x)
+			}
+			present[x] = true
+		}
+		if s.Len() != len(present) {
+			t.Fatal(s.Len(), len(present))
+		}
+		for _, m := range s.Members() {
+			if !present[m] {
+				t.Fatal(m)
+			}
+		}
+	}
+}
@@ -0,0 +1,133 @@
+// Rolling hash
+//
+// A polynomial hash treats a window of bytes as the digits of a number in
+// some base B, modulo a prime P:
+//
+//    h(c[0..w)) = c[0]·B^(w-1) + c[1]·B^(w-2) + ... + c[w-1]   (mod P)
+//
+// so sliding the window one byte along removes the oldest digit and shifts
+// in a new one in O(1):
+//
+//    h' = (h - out·B^(w-1))·B + in = h·B + in - out·B^w   (mod P)
+//
+// P is the Mersenne prime 2^61-1, which makes reduction a shift and an add,
+// and gives a collision probability of about w/2^61 for two different
+// windows. B is fixed rather than random, so hashes can be compared between
+// Rolling values and between runs, as content-defined chunking needs; code
+// that must resist crafted input should confirm matches, as RabinKarp does.
+
+package strsearch
+
+import (
+	"bytes"
+	"math/bits"
+)
+
+const (
+	modulus = 1<<61 - 1
+	base    = 0x5bd1e9955bd1e99 % modulus
+)
+
+// mulMod returns a*b mod 2^61-1, for a, b < 2^61-1
+func mulMod(a, b uint64) uint64 {
+	hi, lo := bits.Mul64(a, b)
+	// a*b = hi·2^64 + lo = (hi·2^3 + lo>>61)·2^61 + lo&modulus, and 2^61 = 1
+	r := (hi<<3 | lo>>61) + lo&modulus
+	if r >= modulus {
+		r -= modulus
+	}
+	return r
+}
+
+// Hash returns the polynomial hash of *data*, which equals the Sum of a
+// Rolling hash whose window holds exactly *data*
+func Hash(data []byte) uint64 {
+	var h uint64
+	for _, c := range data {
+		h = (mulMod(h, base) + uint64(c)) % modulus
+	}
+	return h
+}
+
+// Rolling is a hash of the last *w* bytes written to it
+type Rolling struct {
+	window []byte // ring buffer of the bytes in the window
+	next   int    // position of the oldest byte, once the window is full
+	len    int
+	pow    uint64 // B^w, the weight of the byte leaving the window
+	hash   uint64
+}
+
+// NewRolling creates a Rolling hash over a window of *w* bytes
+func NewRolling(w int) *Rolling {
+	pow := uint64(1)
+	for i := 0; i != w; i++ {
+		pow = mulMod(pow, base)
+	}
+	return &Rolling{window: make([]byte, w), pow: pow}
+}
+
+// Roll adds *c* to the window, dropping the oldest byte if the window is
+// full, and returns the new hash
+func (r *Rolling) Roll(c byte) uint64 {
+	if len(r.window) == 0 {
+		return 0
+	}
+	h := mulMod(r.hash, base) + uint64(c)
+	if r.len == len(r.window) {
+		// Subtract out·B^w by adding its complement
+		h += modulus - mulMod(uint64(r.window[r.next]), r.pow)
+	} else {
+		r.len++
+	}
+	// h < 3P, so at most two subtractions reduce it
+	for h >= modulus {
+		h -= modulus
+	}
+	r.hash = h
+	r.window[r.next] = c
+	if r.next++; r.next == len(r.window) {
+		r.next = 0
+	}
+	return r.hash
+}
+
+// Sum returns the hash of the bytes in the window
+func (r *Rolling) Sum() uint64 {
+	return r.hash
+}
+
+// Full returns true once the window holds *w* bytes
+func (r *Rolling) Full() bool {
+	return r.len == len(r.window)
+}
+
+// Reset empties the window
+func (r *Rolling) Reset() {
+	r.next, r.len, r.hash = 0, 0, 0
+}
+
+// RabinKarp calls *f* with the start of every match of *pattern* in *text*,
+// in order, until *f* returns false. Windows whose hash equals the pattern's
+// are compared byte by byte, so hash collisions never give false matches.
+func RabinKarp(pattern, text []byte, f func(i int) bool) {
+	if len(pattern) == 0 {
+		for i := 0; i <= len(text); i++ {
+			if !f(i) {
+				return
+			}
+		}
+		return
+	}
+	target := Hash(pattern)
+	r := NewRolling(len(pattern))
+	for i, c := range text {
+		r.Roll(c)
+		start := i + 1 - len(pattern)
+		if r.Full() && r.Sum() == target && bytes.Equal(text[start:i+1], pattern) {
+			if !f(start) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package strsearch
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestMulMod(t *testing.T) {
+	for _, c := range []struct{ a, b, product uint64 }{
+		{0, 5, 0}, {3, 5, 15}, {modulus - 1, modulus - 1, 1}, {1 << 60, 2, 1}, {1 << 60, 4, 2},
+	} {
+		if p := mulMod(c.a, c.b); p != c.product {
+			t.Error(c.a, c.b, p)
+		}
+	}
+}
+
+func TestRolling(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	data := make([]byte, 1000)
+	r.Read(data)
+	for _, w := range []int{1, 4, 48} {
+		h := NewRolling(w)
+		for i, c := range data {
+			sum := h.Roll(c)
+			if h.Full() != (i+1 >= w) {
+				t.Fatal(w, i, "full")
+			}
+			start := i + 1 - w
+			if start < 0 {
+				start = 0
+			}
+			if sum != Hash(data[start:i+1]) {
+				t.Fatal(w, i, sum)
+			}
+		}
+		h.Reset()
+		if h.Full() || h.Roll(data[0]) != Hash(data[:1]) {
+			t.Error("reset", w)
+		}
+	}
+	if NewRolling(0).Roll(1) != 0 {
+		t.Error("empty window")
+	}
+}
+
+func BenchmarkRoll(b *testing.B) {
+	h := NewRolling(48)
+	b.SetBytes(1)
+	for i := 0; i < b.N; i++ {
+		h.Roll(byte(i))
+	}
+}
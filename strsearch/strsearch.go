@@ -0,0 +1,150 @@
+/*
+ * Package strsearch implements linear-time algorithms for finding a pattern
+ * in a text: Knuth-Morris-Pratt, the Z-algorithm, and Rabin-Karp with a
+ * rolling hash.
+ *
+ * Searching naively compares the pattern at every position of the text, and
+ * can cost O(nm) when partial matches are long. KMP and the Z-algorithm both
+ * precompute how the pattern overlaps itself, so that no character of the
+ * text is compared more than a constant number of times, for O(n + m) in the
+ * worst case.
+ *
+ * KMP uses the failure function of the pattern: fail[i] is the length of the
+ * longest proper prefix of pattern[:i+1] that is also a suffix of it. When a
+ * partial match of length k fails, the next candidate match is the one of
+ * length fail[k-1], which is already known to match, so the text is never
+ * reread:
+ *
+ *    pattern   a b a b c      fail   0 0 1 2 0
+ *
+ *    text      a b a b a b c
+ *              a b a b x          mismatch after "abab"
+ *                  a b a b c      resume after "ab", the longest border
+ *
+ * The Z-array of s holds, at each i, the length of the longest substring
+ * starting at i that is also a prefix of s. Computed over pattern+text, every
+ * position in the text with a Z-value of at least m starts a match. It is
+ * also useful in its own right, for finding periods and borders of strings.
+ *
+ * Rabin-Karp compares hashes of the pattern and of each window of the text,
+ * where the hash of the next window is computed from that of the previous in
+ * O(1) (see Rolling). It needs O(n + m) expected time and, unlike the others,
+ * extends to searching for many patterns of the same length at once.
+ */
+
+package strsearch
+
+// Failure returns the KMP failure function of *pattern*
+func Failure(pattern []byte) []int {
+	fail := make([]int, len(pattern))
+	k := 0
+	for i := 1; i < len(pattern); i++ {
+		for k > 0 && pattern[i] != pattern[k] {
+			k = fail[k-1]
+		}
+		if pattern[i] == pattern[k] {
+			k++
+		}
+		fail[i] = k
+	}
+	return fail
+}
+
+// KMP is a pattern prepared for searching with the Knuth-Morris-Pratt
+// algorithm
+type KMP struct {
+	pattern []byte
+	fail    []int
+}
+
+// NewKMP prepares *pattern* for searching
+func NewKMP(pattern []byte) *KMP {
+	return &KMP{pattern, Failure(pattern)}
+}
+
+// Find calls *f* with the start of every match of the pattern in *text*, in
+// order, including overlapping matches, until *f* returns false. An empty
+// pattern matches at every position.
+func (m *KMP) Find(text []byte, f func(i int) bool) {
+	if len(m.pattern) == 0 {
+		for i := 0; i <= len(text); i++ {
+			if !f(i) {
+				return
+			}
+		}
+		return
+	}
+	k := 0
+	for i, c := range text {
+		for k > 0 && c != m.pattern[k] {
+			k = m.fail[k-1]
+		}
+		if c == m.pattern[k] {
+			k++
+		}
+		if k == len(m.pattern) {
+			if !f(i - k + 1) {
+				return
+			}
+			k = m.fail[k-1]
+		}
+	}
+}
+
+// Index returns the start of the first match of the pattern in *text*, or -1
+func (m *KMP) Index(text []byte) int {
+	index := -1
+	m.Find(text, func(i int) bool {
+		index = i
+		return false
+	})
+	return index
+}
+
+// ZArray returns the Z-array of *s*. By convention z[0] = len(s).
+//
+// The algorithm keeps the rightmost window [l, r) found so far that matches a
+// prefix of s. Inside the window, s[i:] starts with a copy of s[i-l:], so z[i]
+// is at least min(z[i-l], r-i), and characters are only compared beyond r,
+// which moves right each time.
+func ZArray(s []byte) []int {
+	z := make([]int, len(s))
+	if len(s) == 0 {
+		return z
+	}
+	z[0] = len(s)
+	l, r := 0, 0
+	for i := 1; i < len(s); i++ {
+		if i < r {
+			z[i] = z[i-l]
+			if z[i] > r-i {
+				z[i] = r - i
+			}
+		}
+		for i+z[i] < len(s) && s[z[i]] == s[i+z[i]] {
+			z[i]++
+		}
+		if i+z[i] > r {
+			l, r = i, i+z[i]
+		}
+	}
+	return z
+}
+
+// ZIndexAll returns the start of every match of *pattern* in *text*, using
+// the Z-array of their concatenation
+func ZIndexAll(pattern, text []byte) []int {
+	var matches []int
+	if len(pattern) > len(text) {
+		return matches
+	}
+	s := make([]byte, 0, len(pattern)+len(text))
+	s = append(append(s, pattern...), text...)
+	z := ZArray(s)
+	for i := 0; i+len(pattern) <= len(text); i++ {
+		if z[len(pattern)+i] >= len(pattern) {
+			matches = append(matches, i)
+		}
+	}
+	return matches
+}
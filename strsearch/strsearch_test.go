@@ -0,0 +1,110 @@
+package strsearch
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// naive returns the start of every match of *pattern* in *text*
+func naive(pattern, text []byte) []int {
+	var matches []int
+	for i := 0; i+len(pattern) <= len(text); i++ {
+		if bytes.Equal(text[i:i+len(pattern)], pattern) {
+			matches = append(matches, i)
+		}
+	}
+	return matches
+}
+
+func collect(find func(f func(int) bool)) []int {
+	var matches []int
+	find(func(i int) bool {
+		matches = append(matches, i)
+		return true
+	})
+	return matches
+}
+
+func TestFailure(t *testing.T) {
+	if fail := Failure([]byte("ababcabab")); fmt.Sprint(fail) != "[0 0 1 2 0 1 2 3 4]" {
+		t.Error(fail)
+	}
+}
+
+func TestZArray(t *testing.T) {
+	if z := ZArray([]byte("aabxaab")); fmt.Sprint(z) != "[7 1 0 0 3 1 0]" {
+		t.Error(z)
+	}
+	if z := ZArray(nil); len(z) != 0 {
+		t.Error(z)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for trial := 0; trial != 500; trial++ {
+		// A small alphabet makes long partial matches common
+		text := make([]byte, r.Intn(200))
+		for i := range text {
+			text[i] = byte('a' + r.Intn(2))
+		}
+		pattern := make([]byte, 1+r.Intn(6))
+		for i := range pattern {
+			pattern[i] = byte('a' + r.Intn(2))
+		}
+		expected := fmt.Sprint(naive(pattern, text))
+		kmp := NewKMP(pattern)
+		if matches := collect(func(f func(int) bool) { kmp.Find(text, f) }); fmt.Sprint(matches) != expected {
+			t.Fatal("KMP", string(pattern), string(text), matches)
+		}
+		if matches := ZIndexAll(pattern, text); fmt.Sprint(matches) != expected {
+			t.Fatal("Z", string(pattern), string(text), matches)
+		}
+		if matches := collect(func(f func(int) bool) { RabinKarp(pattern, text, f) }); fmt.Sprint(matches) != expected {
+			t.Fatal("Rabin-Karp", string(pattern), string(text), matches)
+		}
+	}
+}
+
+func TestIndex(t *testing.T) {
+	kmp := NewKMP([]byte("abab"))
+	if i := kmp.Index([]byte("abaababab")); i != 3 {
+		t.Error(i)
+	}
+	if i := kmp.Index([]byte("aba")); i != -1 {
+		t.Error(i)
+	}
+	if matches := collect(func(f func(int) bool) { NewKMP(nil).Find([]byte("ab"), f) }); fmt.Sprint(matches) != "[0 1 2]" {
+		t.Error(matches)
+	}
+}
+
+func BenchmarkSearch(b *testing.B) {
+	// A text of a's with a pattern that almost matches everywhere is the
+	// worst case for naive search
+	text := bytes.Repeat([]byte("a"), 1<<16)
+	pattern := append(bytes.Repeat([]byte("a"), 64), 'b')
+	b.Run("KMP", func(b *testing.B) {
+		kmp := NewKMP(pattern)
+		for i := 0; i < b.N; i++ {
+			kmp.Index(text)
+		}
+	})
+	b.Run("Z", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			ZIndexAll(pattern, text)
+		}
+	})
+	b.Run("RabinKarp", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			RabinKarp(pattern, text, func(int) bool { return false })
+		}
+	})
+	b.Run("naive", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			naive(pattern, text)
+		}
+	})
+}
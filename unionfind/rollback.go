@@ -0,0 +1,86 @@
+// Union-find with rollback
+//
+// Some offline algorithms explore a tree of states, adding unions on the way
+// down and removing them on the way back up; offline dynamic connectivity,
+// for example, answers connectivity queries over a graph whose edges come
+// and go by assigning each edge to the time segments in which it exists and
+// walking a segment tree. Union-find cannot remove a union in general, but
+// it can undo the most recent ones, in reverse order, by recording what each
+// one changed.
+//
+// Path compression changes many links per query, which would all have to be
+// recorded, so Rollback uses union by size alone. A union changes one parent
+// link and one size, which fit in a single record, and Find costs O(log n).
+
+package unionfind
+
+// Rollback is a DisjointSets whose unions can be undone, most recent first
+type Rollback struct {
+	parent  []int
+	size    []int
+	sets    int
+	history []int // the roots linked below another, in order
+}
+
+// NewRollback creates a Rollback with each of the integers 0..n-1 in a set
+// of its own
+func NewRollback(n int) *Rollback {
+	d := &Rollback{parent: make([]int, n), size: make([]int, n), sets: n}
+	for i := range d.parent {
+		d.parent[i] = i
+		d.size[i] = 1
+	}
+	return d
+}
+
+// Sets returns the number of disjoint sets
+func (d *Rollback) Sets() int {
+	return d.sets
+}
+
+// Find returns the representative of the set containing *x*
+func (d *Rollback) Find(x int) int {
+	for d.parent[x] != x {
+		x = d.parent[x]
+	}
+	return x
+}
+
+// Connected returns true if *x* and *y* are in the same set
+func (d *Rollback) Connected(x, y int) bool {
+	return d.Find(x) == d.Find(y)
+}
+
+// Union merges the sets containing *x* and *y*, and returns false if they
+// were already the same set. Only unions that merge sets are recorded.
+func (d *Rollback) Union(x, y int) bool {
+	x, y = d.Find(x), d.Find(y)
+	if x == y {
+		return false
+	}
+	if d.size[x] < d.size[y] {
+		x, y = y, x
+	}
+	d.parent[y] = x
+	d.size[x] += d.size[y]
+	d.sets--
+	d.history = append(d.history, y)
+	return true
+}
+
+// Snapshot returns a mark to which Undo can return the sets
+func (d *Rollback) Snapshot() int {
+	return len(d.history)
+}
+
+// Undo reverts the unions made since *snapshot* was taken, most recent first
+func (d *Rollback) Undo(snapshot int) {
+	for len(d.history) > snapshot {
+		y := d.history[len(d.history)-1]
+		d.history = d.history[:len(d.history)-1]
+		x := d.parent[y]
+		d.size[x] -= d.size[y]
+		d.parent[y] = y
+		d.sets++
+	}
+}
@@ -0,0 +1,63 @@
+package unionfind
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestRollback(t *testing.T) {
+	d := NewRollback(5)
+	d.Union(0, 1)
+	mark := d.Snapshot()
+	d.Union(2, 3)
+	d.Union(1, 3)
+	if d.Union(0, 2) || !d.Connected(0, 3) || d.Sets() != 2 {
+		t.Fatal(d.Sets())
+	}
+	d.Undo(mark)
+	if d.Connected(0, 2) || !d.Connected(0, 1) || d.Sets() != 4 {
+		t.Error(d.Sets())
+	}
+	d.Undo(0)
+	if d.Connected(0, 1) || d.Sets() != 5 {
+		t.Error(d.Sets())
+	}
+}
+
+func TestRollbackRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	const n = 50
+	d := NewRollback(n)
+	// Compare each state after undoing with a copy taken at the snapshot
+	for round := 0; round != 20; round++ {
+		mark := d.Snapshot()
+		before := make([]int, n)
+		for i := range before {
+			before[i] = d.Find(i)
+		}
+		for i := 0; i != 30; i++ {
+			d.Union(r.Intn(n), r.Intn(n))
+		}
+		if r.Intn(2) == 0 {
+			d.Undo(mark)
+			for i := range before {
+				if d.Find(i) != before[i] {
+					t.Fatal("not restored", round, i)
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkRollback(b *testing.B) {
+	const n = 1 << 16
+	r := rand.New(rand.NewSource(1))
+	d := NewRollback(n)
+	for i := 0; i < b.N; i++ {
+		mark := d.Snapshot()
+		for j := 0; j != 16; j++ {
+			d.Union(r.Intn(n), r.Intn(n))
+		}
+		d.Undo(mark)
+	}
+}
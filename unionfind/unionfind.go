@@ -0,0 +1,104 @@
+/*
+ * Package unionfind implements disjoint-set forests ("union-find"), which
+ * track a partition of the integers 0..n-1 into sets under merging.
+ *
+ * Each set is a tree of parent links, identified by the element at its root.
+ * Find follows the links to the root, and Union links one root below the
+ * other. Two heuristics keep the trees shallow:
+ *
+ * - union by size links the root of the smaller tree below that of the
+ *   larger, so that an element's depth only grows when the size of its set
+ *   at least doubles, bounding depths by log n
+ * - path compression points every element visited by Find directly at the
+ *   root, flattening the tree for later queries
+ *
+ * Together, they make any sequence of m operations cost O(m α(n)), where α is
+ * the inverse Ackermann function, which is below 5 for any practical n.
+ *
+ *    Union(1, 2), Union(3, 4), Union(2, 4)
+ *
+ *        1           1
+ *        |         / | \
+ *        2   ->   2  3  4     after Find(4) compresses the path
+ *        |
+ *        3
+ *        |
+ *        4
+ *
+ * Weighted keeps, along with each link, the difference between the values of
+ * an element and its parent, answering questions such as "how much heavier is
+ * a than b" for constraints that arrive piecemeal, and Parity is the special
+ * case of differences modulo 2. Rollback gives up path compression so that
+ * unions can be undone in reverse order, which offline algorithms over
+ * changing graphs rely on.
+ */
+
+package unionfind
+
+// DisjointSets is a partition of the integers 0..n-1 into disjoint sets
+type DisjointSets struct {
+	parent []int
+	size   []int // size of the set, valid at roots only
+	sets   int
+}
+
+// New creates a DisjointSets with each of the integers 0..n-1 in a set of its
+// own
+func New(n int) *DisjointSets {
+	d := &DisjointSets{parent: make([]int, n), size: make([]int, n), sets: n}
+	for i := range d.parent {
+		d.parent[i] = i
+		d.size[i] = 1
+	}
+	return d
+}
+
+// Len returns the number of elements
+func (d *DisjointSets) Len() int {
+	return len(d.parent)
+}
+
+// Sets returns the number of disjoint sets
+func (d *DisjointSets) Sets() int {
+	return d.sets
+}
+
+// Find returns the representative of the set containing *x*, which is the
+// same for all elements of a set until it is merged with another
+func (d *DisjointSets) Find(x int) int {
+	root := x
+	for d.parent[root] != root {
+		root = d.parent[root]
+	}
+	// Point every element on the path directly at the root
+	for d.parent[x] != root {
+		x, d.parent[x] = d.parent[x], root
+	}
+	return root
+}
+
+// Union merges the sets containing *x* and *y*, and returns false if they
+// were already the same set
+func (d *DisjointSets) Union(x, y int) bool {
+	x, y = d.Find(x), d.Find(y)
+	if x == y {
+		return false
+	}
+	if d.size[x] < d.size[y] {
+		x, y = y, x
+	}
+	d.parent[y] = x
+	d.size[x] += d.size[y]
+	d.sets--
+	return true
+}
+
+// Connected returns true if *x* and *y* are in the same set
+func (d *DisjointSets) Connected(x, y int) bool {
+	return d.Find(x) == d.Find(y)
+}
+
+// Size returns the number of elements in the set containing *x*
+func (d *DisjointSets) Size(x int) int {
+	return d.size[d.Find(x)]
+}
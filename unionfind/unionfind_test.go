@@ -0,0 +1,61 @@
+package unionfind
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestUnion(t *testing.T) {
+	d := New(6)
+	if !d.Union(1, 2) || !d.Union(3, 4) || !d.Union(2, 4) || d.Union(1, 3) {
+		t.Fatal("union")
+	}
+	if !d.Connected(1, 4) || d.Connected(0, 1) || d.Size(3) != 4 || d.Sets() != 3 || d.Len() != 6 {
+		t.Error(d.Size(3), d.Sets())
+	}
+}
+
+func TestRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	const n = 200
+	d := New(n)
+	// Compare against labels that are relabeled on every union
+	label := make([]int, n)
+	for i := range label {
+		label[i] = i
+	}
+	for i := 0; i != 500; i++ {
+		x, y := r.Intn(n), r.Intn(n)
+		if d.Union(x, y) != (label[x] != label[y]) {
+			t.Fatal("union", x, y)
+		}
+		old := label[y]
+		for j := range label {
+			if label[j] == old {
+				label[j] = label[x]
+			}
+		}
+		for k := 0; k != 10; k++ {
+			a, b := r.Intn(n), r.Intn(n)
+			if d.Connected(a, b) != (label[a] == label[b]) {
+				t.Fatal("connected", a, b)
+			}
+		}
+	}
+}
+
+func BenchmarkUnionFind(b *testing.B) {
+	const n = 1 << 20
+	r := rand.New(rand.NewSource(1))
+	pairs := make([][2]int, 1<<16)
+	for i := range pairs {
+		pairs[i] = [2]int{r.Intn(n), r.Intn(n)}
+	}
+	d := New(n)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p := pairs[i%len(pairs)]
+		d.Union(p[0], p[1])
+		d.Connected(p[1], p[0])
+	}
+}
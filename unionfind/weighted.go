@@ -0,0 +1,157 @@
+// Weighted union-find
+//
+// Weighted records constraints of the form value(y) - value(x) = w between
+// elements whose values are unknown. Each element stores the difference
+// between its value and its parent's, so the difference from the root is the
+// sum along the path, and path compression adds up the differences it skips:
+//
+//    a --3--> b --2--> root      becomes      a --5--> root
+//
+// Linking root ry below root rx to record value(y) - value(x) = w sets the
+// difference of ry so that the paths from x and y agree:
+//
+//    diff(ry) = diff(x) + w - diff(y)
+//
+// A constraint between two elements already in the same set is checked
+// against the difference already implied, which detects inconsistencies.
+// Parity does the same with differences modulo 2, combined by exclusive or,
+// which is the check for whether a graph can be two-colored as its edges
+// arrive.
+
+package unionfind
+
+// Weighted is a DisjointSets in which the elements of each set have known
+// differences in value
+type Weighted struct {
+	parent []int
+	size   []int
+	diff   []int // value of the element minus the value of its parent
+}
+
+// NewWeighted creates a Weighted with each of the integers 0..n-1 in a set of
+// its own
+func NewWeighted(n int) *Weighted {
+	d := &Weighted{parent: make([]int, n), size: make([]int, n), diff: make([]int, n)}
+	for i := range d.parent {
+		d.parent[i] = i
+		d.size[i] = 1
+	}
+	return d
+}
+
+// find returns the root of *x* and the value of *x* minus that of the root
+func (d *Weighted) find(x int) (int, int) {
+	root, total := x, 0
+	for d.parent[root] != root {
+		total += d.diff[root]
+		root = d.parent[root]
+	}
+	// Point every element on the path at the root, with the difference of the
+	// rest of the path
+	for rest := total; d.parent[x] != root; {
+		next, step := d.parent[x], d.diff[x]
+		d.parent[x], d.diff[x] = root, rest
+		x, rest = next, rest-step
+	}
+	return root, total
+}
+
+// Find returns the representative of the set containing *x*
+func (d *Weighted) Find(x int) int {
+	root, _ := d.find(x)
+	return root
+}
+
+// Union records that value(*y*) - value(*x*) = *w*, merging their sets. It
+// returns false, and changes nothing, if *x* and *y* are already in the same
+// set with a different difference.
+func (d *Weighted) Union(x, y, w int) bool {
+	rx, dx := d.find(x)
+	ry, dy := d.find(y)
+	if rx == ry {
+		return dy-dx == w
+	}
+	// Link the smaller tree below the larger, negating the difference if the
+	// roots swap roles
+	if d.size[rx] < d.size[ry] {
+		rx, ry, dx, dy, w = ry, rx, dy, dx, -w
+	}
+	d.parent[ry] = rx
+	d.diff[ry] = dx + w - dy
+	d.size[rx] += d.size[ry]
+	return true
+}
+
+// Diff returns value(*y*) - value(*x*), or false if *x* and *y* are in
+// different sets, so that their difference is unknown
+func (d *Weighted) Diff(x, y int) (int, bool) {
+	rx, dx := d.find(x)
+	ry, dy := d.find(y)
+	if rx != ry {
+		return 0, false
+	}
+	return dy - dx, true
+}
+
+// Parity is a DisjointSets in which the elements of each set are known to
+// have equal or opposite parity, or colors in a two-coloring
+type Parity struct {
+	parent []int
+	size   []int
+	odd    []bool // whether the element differs from its parent
+}
+
+// NewParity creates a Parity with each of the integers 0..n-1 in a set of its
+// own
+func NewParity(n int) *Parity {
+	d := &Parity{parent: make([]int, n), size: make([]int, n), odd: make([]bool, n)}
+	for i := range d.parent {
+		d.parent[i] = i
+		d.size[i] = 1
+	}
+	return d
+}
+
+// find returns the root of *x*, and whether *x* differs from the root
+func (d *Parity) find(x int) (int, bool) {
+	root, total := x, false
+	for d.parent[root] != root {
+		total = total != d.odd[root]
+		root = d.parent[root]
+	}
+	for rest := total; d.parent[x] != root; {
+		next, step := d.parent[x], d.odd[x]
+		d.parent[x], d.odd[x] = root, rest
+		x, rest = next, rest != step
+	}
+	return root, total
+}
+
+// Union records that *x* and *y* differ if *odd* is true, or are the same
+// otherwise, merging their sets. It returns false, and changes nothing, if
+// this contradicts what is already known.
+func (d *Parity) Union(x, y int, odd bool) bool {
+	rx, px := d.find(x)
+	ry, py := d.find(y)
+	if rx == ry {
+		return (px != py) == odd
+	}
+	if d.size[rx] < d.size[ry] {
+		rx, ry = ry, rx
+	}
+	d.parent[ry] = rx
+	d.odd[ry] = px != py != odd
+	d.size[rx] += d.size[ry]
+	return true
+}
+
+// Differ returns whether *x* and *y* differ, or false for known if they are
+// in different sets
+func (d *Parity) Differ(x, y int) (differ, known bool) {
+	rx, px := d.find(x)
+	ry, py := d.find(y)
+	if rx != ry {
+		return false, false
+	}
+	return px != py, true
+}
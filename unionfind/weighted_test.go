@@ -0,0 +1,68 @@
+package unionfind
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestWeighted(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	const n = 300
+	// Hidden values, revealed only through differences
+	values := make([]int, n)
+	for i := range values {
+		values[i] = r.Intn(1000)
+	}
+	d := NewWeighted(n)
+	for i := 0; i != 1000; i++ {
+		x, y := r.Intn(n), r.Intn(n)
+		if !d.Union(x, y, values[y]-values[x]) {
+			t.Fatal("consistent constraint rejected", x, y)
+		}
+		a, b := r.Intn(n), r.Intn(n)
+		if diff, ok := d.Diff(a, b); ok && diff != values[b]-values[a] {
+			t.Fatal(a, b, diff)
+		}
+	}
+	if diff, ok := d.Diff(0, 1); !ok || diff != values[1]-values[0] {
+		t.Error("not all connected", diff, ok)
+	}
+	if d.Union(0, 1, values[1]-values[0]+1) {
+		t.Error("inconsistent constraint accepted")
+	}
+	if d.Find(5) != d.Find(6) {
+		t.Error("find")
+	}
+}
+
+func TestParity(t *testing.T) {
+	// A cycle of even length can be two-colored, and an odd one cannot
+	d := NewParity(5)
+	for i := 0; i != 3; i++ {
+		d.Union(i, i+1, true)
+	}
+	if differ, known := d.Differ(0, 3); !known || !differ {
+		t.Error(differ, known)
+	}
+	if !d.Union(3, 0, true) {
+		t.Error("even cycle rejected")
+	}
+	if _, known := d.Differ(0, 4); known {
+		t.Error("unrelated elements")
+	}
+	if d.Union(0, 2, true) {
+		t.Error("odd cycle accepted")
+	}
+}
+
+func BenchmarkWeighted(b *testing.B) {
+	const n = 1 << 20
+	r := rand.New(rand.NewSource(1))
+	d := NewWeighted(n)
+	for i := 0; i < b.N; i++ {
+		x, y := r.Intn(n), r.Intn(n)
+		if _, ok := d.Diff(x, y); !ok {
+			d.Union(x, y, 1)
+		}
+	}
+}
@@ -0,0 +1,321 @@
+/*
+ * Package veb implements a van Emde Boas tree, a set of integer keys from a
+ * bounded universe [0, U) with O(log log U) predecessor and successor queries.
+ *
+ * Comparison-based search trees need O(log n) time to find the successor of a
+ * key. When the keys are integers of a fixed width, a van Emde Boas tree does
+ * better by searching over the bits of the key rather than over the keys. A
+ * w-bit key x is split into its high half h and low half l, and the tree
+ * holds a "cluster" for each value of h, which is itself a van Emde Boas tree
+ * over the w/2-bit values of l. A "summary" tree over the w/2-bit values of h
+ * records which clusters are non-empty:
+ *
+ *    node (w bits): min, max
+ *      summary (w/2 bits): which h have a cluster
+ *      clusters[h] (w/2 bits): the l of every key h:l
+ *
+ * Each node also stores its minimum and maximum. To find the successor of
+ * h:l, if l is below the maximum of cluster h, the answer is in that cluster;
+ * otherwise it is the minimum of the next non-empty cluster, found from the
+ * summary. Either way there is one recursive call on half as many bits, so a
+ * query makes O(log w) = O(log log U) steps. The minimum of a node is kept
+ * only in the node, not in a cluster, which makes inserting into an empty
+ * cluster O(1) and lets Insert and Delete make a single deep recursive call
+ * too.
+ *
+ * A textbook van Emde Boas tree allocates every cluster up front, which takes
+ * O(U) space. Here clusters are held in maps and created only when a key
+ * falls into them, so space is O(n log w), closer to that of a y-fast trie.
+ * Recursion also stops at 64-key universes, whose keys are kept as the bits
+ * of a single word and searched with bit operations.
+ */
+
+package veb
+
+import "math/bits"
+
+// leafBits is the width of keys held directly in a word
+const leafBits = 6
+
+// Tree is a set of keys below 2^Bits
+type Tree struct {
+	root *node
+	bits uint
+	len  int
+}
+
+// New creates an empty Tree for keys of up to *bits* bits, between 1 and 64
+func New(bits uint) *Tree {
+	if bits == 0 || bits > 64 {
+		panic("veb: key width must be between 1 and 64 bits")
+	}
+	return &Tree{bits: bits}
+}
+
+// Bits returns the width of the keys
+func (t *Tree) Bits() uint {
+	return t.bits
+}
+
+// Len returns the number of keys in the tree
+func (t *Tree) Len() int {
+	return t.len
+}
+
+// inUniverse returns true if *x* fits in the key width
+func (t *Tree) inUniverse(x uint64) bool {
+	return t.bits == 64 || x>>t.bits == 0
+}
+
+// Contains returns true if *x* is in the tree
+func (t *Tree) Contains(x uint64) bool {
+	return t.root != nil && t.inUniverse(x) && t.root.contains(x)
+}
+
+// Insert adds *x* to the tree, returning false if it was already present. It
+// panics if *x* does not fit in the key width.
+func (t *Tree) Insert(x uint64) bool {
+	if !t.inUniverse(x) {
+		panic("veb: key out of range")
+	}
+	if t.root == nil {
+		t.root = newNode(t.bits, x)
+	} else if t.root.contains(x) {
+		return false
+	} else {
+		t.root.insert(x)
+	}
+	t.len++
+	return true
+}
+
+// Delete removes *x* from the tree, returning false if it was not present
+func (t *Tree) Delete(x uint64) bool {
+	if !t.Contains(x) {
+		return false
+	}
+	if t.root.delete(x) {
+		t.root = nil
+	}
+	t.len--
+	return true
+}
+
+// Min returns the smallest key, or false if the tree is empty
+func (t *Tree) Min() (uint64, bool) {
+	if t.root == nil {
+		return 0, false
+	}
+	return t.root.minimum(), true
+}
+
+// Max returns the largest key, or false if the tree is empty
+func (t *Tree) Max() (uint64, bool) {
+	if t.root == nil {
+		return 0, false
+	}
+	return t.root.maximum(), true
+}
+
+// Successor returns the smallest key greater than *x*, or false if there is
+// none
+func (t *Tree) Successor(x uint64) (uint64, bool) {
+	if t.root == nil || !t.inUniverse(x) {
+		return 0, false
+	}
+	return t.root.successor(x)
+}
+
+// Predecessor returns the largest key less than *x*, or false if there is none
+func (t *Tree) Predecessor(x uint64) (uint64, bool) {
+	if t.root == nil {
+		return 0, false
+	}
+	if !t.inUniverse(x) {
+		return t.root.maximum(), true
+	}
+	return t.root.predecessor(x)
+}
+
+// node is a non-empty van Emde Boas tree. Nodes of up to leafBits bits hold
+// their keys in *word*; larger nodes use the other fields.
+type node struct {
+	bits     uint
+	word     uint64
+	min, max uint64
+	summary  *node // nil if there are no clusters
+	clusters map[uint64]*node
+}
+
+// newNode creates a node holding the single key *x*
+func newNode(bits uint, x uint64) *node {
+	if bits <= leafBits {
+		return &node{bits: bits, word: 1 << x}
+	}
+	return &node{bits: bits, min: x, max: x, clusters: make(map[uint64]*node)}
+}
+
+func (n *node) leaf() bool {
+	return n.bits <= leafBits
+}
+
+// split returns the high and low halves of *x*. The low half has bits/2 bits,
+// and the high half has the rest.
+func (n *node) split(x uint64) (uint64, uint64) {
+	low := n.bits / 2
+	return x >> low, x & (1<<low - 1)
+}
+
+// join is the inverse of split
+func (n *node) join(h, l uint64) uint64 {
+	return h<<(n.bits/2) | l
+}
+
+func (n *node) minimum() uint64 {
+	if n.leaf() {
+		return uint64(bits.TrailingZeros64(n.word))
+	}
+	return n.min
+}
+
+func (n *node) maximum() uint64 {
+	if n.leaf() {
+		return uint64(63 - bits.LeadingZeros64(n.word))
+	}
+	return n.max
+}
+
+func (n *node) contains(x uint64) bool {
+	if n.leaf() {
+		return n.word>>x&1 == 1
+	}
+	if x == n.min || x == n.max {
+		return true
+	}
+	if x < n.min || x > n.max {
+		return false
+	}
+	h, l := n.split(x)
+	c := n.clusters[h]
+	return c != nil && c.contains(l)
+}
+
+// insert adds *x*, which must not be present
+func (n *node) insert(x uint64) {
+	if n.leaf() {
+		n.word |= 1 << x
+		return
+	}
+	if x < n.min {
+		// The new key becomes the minimum, which is not stored in a cluster,
+		// and the old minimum is inserted in its place
+		x, n.min = n.min, x
+	}
+	if x > n.max {
+		n.max = x
+	}
+	h, l := n.split(x)
+	if c := n.clusters[h]; c != nil {
+		c.insert(l)
+		return
+	}
+	// Creating a cluster is O(1), so only the summary needs a recursive call
+	n.clusters[h] = newNode(n.bits/2, l)
+	if n.summary == nil {
+		n.summary = newNode(n.bits-n.bits/2, h)
+	} else {
+		n.summary.insert(h)
+	}
+}
+
+// delete removes *x*, which must be present, and returns true if the node is
+// left empty
+func (n *node) delete(x uint64) bool {
+	if n.leaf() {
+		n.word &^= 1 << x
+		return n.word == 0
+	}
+	if n.summary == nil {
+		// The only key is the minimum
+		return true
+	}
+	if x == n.min {
+		// Replace the minimum with the smallest key in the clusters, and
+		// delete that from its cluster instead
+		h := n.summary.minimum()
+		x = n.join(h, n.clusters[h].minimum())
+		n.min = x
+	}
+	h, l := n.split(x)
+	if n.clusters[h].delete(l) {
+		// Emptying a cluster was O(1), so only the summary needs a recursive
+		// call
+		delete(n.clusters, h)
+		if n.summary.delete(h) {
+			n.summary = nil
+		}
+	}
+	if x == n.max {
+		if n.summary == nil {
+			n.max = n.min
+		} else {
+			h := n.summary.maximum()
+			n.max = n.join(h, n.clusters[h].maximum())
+		}
+	}
+	return false
+}
+
+func (n *node) successor(x uint64) (uint64, bool) {
+	if n.leaf() {
+		// 2<<x is zero for x = 63, leaving no bits above x
+		above := n.word &^ (2<<x - 1)
+		if above == 0 {
+			return 0, false
+		}
+		return uint64(bits.TrailingZeros64(above)), true
+	}
+	if x < n.min {
+		return n.min, true
+	}
+	if x >= n.max {
+		return 0, false
+	}
+	// The maximum is above the minimum, so it is in a cluster and the summary
+	// is not empty
+	h, l := n.split(x)
+	if c := n.clusters[h]; c != nil && l < c.maximum() {
+		s, _ := c.successor(l)
+		return n.join(h, s), true
+	}
+	h, _ = n.summary.successor(h)
+	return n.join(h, n.clusters[h].minimum()), true
+}
+
+func (n *node) predecessor(x uint64) (uint64, bool) {
+	if n.leaf() {
+		below := n.word & (1<<x - 1)
+		if below == 0 {
+			return 0, false
+		}
+		return uint64(63 - bits.LeadingZeros64(below)), true
+	}
+	if x > n.max {
+		return n.max, true
+	}
+	if x <= n.min {
+		return 0, false
+	}
+	h, l := n.split(x)
+	if c := n.clusters[h]; c != nil && l > c.minimum() {
+		p, _ := c.predecessor(l)
+		return n.join(h, p), true
+	}
+	// The minimum is not in a cluster, so if no earlier cluster is
+	// non-empty, it is the predecessor
+	h, ok := n.summary.predecessor(h)
+	if !ok {
+		return n.min, true
+	}
+	return n.join(h, n.clusters[h].maximum()), true
+}
@@ -0,0 +1,121 @@
+package veb
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestTree(t *testing.T) {
+	tree := New(16)
+	for _, x := range []uint64{300, 2, 65535, 64, 1000} {
+		if !tree.Insert(x) {
+			t.Errorf("%d not inserted", x)
+		}
+	}
+	if tree.Insert(64) || tree.Len() != 5 {
+		t.Error(tree.Len())
+	}
+	if min, _ := tree.Min(); min != 2 {
+		t.Error(min)
+	}
+	if max, _ := tree.Max(); max != 65535 {
+		t.Error(max)
+	}
+	if s, ok := tree.Successor(64); !ok || s != 300 {
+		t.Error(s, ok)
+	}
+	if p, ok := tree.Predecessor(64); !ok || p != 2 {
+		t.Error(p, ok)
+	}
+	if _, ok := tree.Successor(65535); ok {
+		t.Fail()
+	}
+	if _, ok := tree.Predecessor(2); ok {
+		t.Fail()
+	}
+	if p, ok := tree.Predecessor(1 << 20); !ok || p != 65535 {
+		t.Error(p, ok)
+	}
+	if !tree.Delete(2) || tree.Delete(2) || tree.Contains(2) {
+		t.Fail()
+	}
+	if min, _ := tree.Min(); min != 64 {
+		t.Error(min)
+	}
+}
+
+// check compares every query against a sorted slice of the keys
+func check(t *testing.T, tree *Tree, keys []uint64, probes []uint64) {
+	if tree.Len() != len(keys) {
+		t.Fatalf("Len() = %d, expected %d", tree.Len(), len(keys))
+	}
+	for _, x := range probes {
+		i := sort.Search(len(keys), func(i int) bool { return keys[i] > x })
+		s, ok := tree.Successor(x)
+		if (i < len(keys)) != ok || ok && s != keys[i] {
+			t.Fatalf("Successor(%d) = %d, %v", x, s, ok)
+		}
+		i = sort.Search(len(keys), func(i int) bool { return keys[i] >= x })
+		p, ok := tree.Predecessor(x)
+		if (i > 0) != ok || ok && p != keys[i-1] {
+			t.Fatalf("Predecessor(%d) = %d, %v", x, p, ok)
+		}
+		present := i < len(keys) && keys[i] == x
+		if tree.Contains(x) != present {
+			t.Fatalf("Contains(%d) = %v", x, !present)
+		}
+	}
+}
+
+func TestRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, width := range []uint{4, 7, 16, 33, 64} {
+		random := func() uint64 {
+			if width == 64 {
+				return rng.Uint64()
+			}
+			return rng.Uint64() & (1<<width - 1)
+		}
+		tree := New(width)
+		set := make(map[uint64]bool)
+		for round := 0; round != 20; round++ {
+			for i := 0; i != 100; i++ {
+				x := random()
+				if tree.Insert(x) == set[x] {
+					t.Fatalf("Insert(%d)", x)
+				}
+				set[x] = true
+			}
+			for x := range set {
+				if rng.Intn(3) == 0 {
+					if !tree.Delete(x) {
+						t.Fatalf("Delete(%d)", x)
+					}
+					delete(set, x)
+				}
+			}
+
+			keys := make([]uint64, 0, len(set))
+			probes := []uint64{0, 1<<width - 1}
+			for x := range set {
+				keys = append(keys, x)
+				probes = append(probes, x, x-1, x+1, random())
+			}
+			sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+			check(t, tree, keys, probes)
+		}
+	}
+}
+
+func BenchmarkSuccessor(b *testing.B) {
+	rng := rand.New(rand.NewSource(1))
+	tree := New(32)
+	for i := 0; i != 100000; i++ {
+		tree.Insert(uint64(rng.Uint32()))
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tree.Successor(uint64(rng.Uint32()))
+	}
+}
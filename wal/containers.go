@@ -0,0 +1,262 @@
+package wal
+
+// Logging for the containers in this repository
+//
+// A logged container wraps an ordinary one, and appends each mutation to the
+// log before performing it. If the append fails, the container is left
+// unchanged, so the log never lags behind the container. Recovering replays
+// the log into a new container.
+//
+// The wrapped container is kept in an unexported field, and a logged
+// container offers only its logged mutators and read-only accessors. A
+// mutator added to the container later is therefore not reachable through the
+// wrapper until it is given a logged version too, rather than silently
+// changing the container behind the log's back.
+
+import (
+	"encoding/binary"
+	"io"
+
+	"github.com/njwilson23/datastructures/hashtable"
+	"github.com/njwilson23/datastructures/rbtree"
+)
+
+// Kind identifies the mutation that an Op records
+type Kind byte
+
+const (
+	Put    Kind = iota // set the value of a key
+	Delete             // remove a key
+	Insert             // add a key without a value, to a tree only
+)
+
+// KeyValueOp records an insertion into or deletion from a HashTable with
+// string keys and values. Its Kind is Put or Delete.
+type KeyValueOp struct {
+	Kind  Kind
+	Key   string
+	Value string
+}
+
+// MarshalBinary encodes the Op as its kind followed by the length-prefixed
+// key and value
+func (op KeyValueOp) MarshalBinary() ([]byte, error) {
+	data := []byte{byte(op.Kind)}
+	data = binary.AppendUvarint(data, uint64(len(op.Key)))
+	data = append(data, op.Key...)
+	data = binary.AppendUvarint(data, uint64(len(op.Value)))
+	return append(data, op.Value...), nil
+}
+
+// UnmarshalBinary decodes an Op encoded by MarshalBinary
+func (op *KeyValueOp) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 || Kind(data[0]) > Delete {
+		return ErrCorrupt
+	}
+	op.Kind = Kind(data[0])
+	data = data[1:]
+	for _, field := range []*string{&op.Key, &op.Value} {
+		length, n := binary.Uvarint(data)
+		if n <= 0 || uint64(len(data)-n) < length {
+			return ErrCorrupt
+		}
+		*field = string(data[n : n+int(length)])
+		data = data[n+int(length):]
+	}
+	if len(data) != 0 {
+		return ErrCorrupt
+	}
+	return nil
+}
+
+// LoggedHashTable is a HashTable with string keys and values whose mutations
+// are written to a Log
+type LoggedHashTable struct {
+	ht  *hashtable.HashTable
+	log *Log
+}
+
+// NewLoggedHashTable wraps a HashTable so that its mutations are written to
+// *log*
+func NewLoggedHashTable(ht *hashtable.HashTable, log *Log) *LoggedHashTable {
+	return &LoggedHashTable{ht, log}
+}
+
+// Insert logs and then performs an insertion
+func (t *LoggedHashTable) Insert(key, value string) error {
+	if err := t.log.Append(KeyValueOp{Put, key, value}); err != nil {
+		return err
+	}
+	return t.ht.Insert(hashtable.HashString(key), value)
+}
+
+// Delete logs and then performs a deletion. Deleting a missing key is not
+// logged.
+func (t *LoggedHashTable) Delete(key string) error {
+	if _, err := t.ht.Get(hashtable.HashString(key)); err != nil {
+		return err
+	}
+	if err := t.log.Append(KeyValueOp{Kind: Delete, Key: key}); err != nil {
+		return err
+	}
+	return t.ht.Delete(hashtable.HashString(key))
+}
+
+// Get returns the value of *key*, or hashtable.KEY_ERROR if it is missing
+func (t *LoggedHashTable) Get(key string) (string, error) {
+	value, err := t.ht.Get(hashtable.HashString(key))
+	if err != nil {
+		return "", err
+	}
+	return value.(string), nil
+}
+
+// Freeze returns a read-only copy of the table (see HashTable.Freeze)
+func (t *LoggedHashTable) Freeze() *hashtable.Frozen {
+	return t.ht.Freeze()
+}
+
+// RecoverHashTable rebuilds a HashTable with *size* buckets from a log written
+// by a LoggedHashTable
+func RecoverHashTable(r io.Reader, size int) (*hashtable.HashTable, error) {
+	ht := hashtable.InitHashTable(size)
+	_, err := Replay(r, func(payload []byte) error {
+		var op KeyValueOp
+		if err := op.UnmarshalBinary(payload); err != nil {
+			return err
+		}
+		if op.Kind == Put {
+			return ht.Insert(hashtable.HashString(op.Key), op.Value)
+		}
+		return ht.Delete(hashtable.HashString(op.Key))
+	})
+	return ht, err
+}
+
+// TreeOp records an insertion into, a Put into, or a deletion from a
+// RedBlackTree with string values. Its Kind is Insert, Put or Delete.
+type TreeOp struct {
+	Kind  Kind
+	Key   int
+	Value string
+}
+
+// MarshalBinary encodes the Op as its kind, the key as a varint, and the
+// length-prefixed value
+func (op TreeOp) MarshalBinary() ([]byte, error) {
+	data := binary.AppendVarint([]byte{byte(op.Kind)}, int64(op.Key))
+	data = binary.AppendUvarint(data, uint64(len(op.Value)))
+	return append(data, op.Value...), nil
+}
+
+// UnmarshalBinary decodes an Op encoded by MarshalBinary
+func (op *TreeOp) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 || Kind(data[0]) > Insert {
+		return ErrCorrupt
+	}
+	op.Kind = Kind(data[0])
+	key, n := binary.Varint(data[1:])
+	if n <= 0 {
+		return ErrCorrupt
+	}
+	op.Key = int(key)
+	data = data[1+n:]
+	length, n := binary.Uvarint(data)
+	if n <= 0 || uint64(len(data)-n) != length {
+		return ErrCorrupt
+	}
+	op.Value = string(data[n:])
+	return nil
+}
+
+// LoggedTree is a RedBlackTree with string values whose mutations are written
+// to a Log
+type LoggedTree struct {
+	tree *rbtree.RedBlackTree
+	log  *Log
+}
+
+// NewLoggedTree wraps a RedBlackTree so that its mutations are written to
+// *log*
+func NewLoggedTree(tree *rbtree.RedBlackTree, log *Log) *LoggedTree {
+	return &LoggedTree{tree, log}
+}
+
+// Insert logs and then performs an insertion of *key* without a value
+func (t *LoggedTree) Insert(key int) error {
+	if err := t.log.Append(TreeOp{Kind: Insert, Key: key}); err != nil {
+		return err
+	}
+	t.tree.Insert(key)
+	return nil
+}
+
+// Put logs and then sets the value attached to *key* (see RedBlackTree.Put)
+func (t *LoggedTree) Put(key int, value string) error {
+	if err := t.log.Append(TreeOp{Put, key, value}); err != nil {
+		return err
+	}
+	t.tree.Put(key, value)
+	return nil
+}
+
+// Delete logs and then performs a deletion, returning false if *key* is not in
+// the tree. Deleting a missing key is not logged.
+func (t *LoggedTree) Delete(key int) (bool, error) {
+	if !t.tree.Contains(key) {
+		return false, nil
+	}
+	if err := t.log.Append(TreeOp{Kind: Delete, Key: key}); err != nil {
+		return false, err
+	}
+	return t.tree.Delete(key), nil
+}
+
+// Get returns the value attached to *key*, and false if the key is not in the
+// tree. A key inserted without a value has the value "".
+func (t *LoggedTree) Get(key int) (string, bool) {
+	value, ok := t.tree.Get(key)
+	s, _ := value.(string)
+	return s, ok
+}
+
+// Len returns the number of keys in the tree
+func (t *LoggedTree) Len() int {
+	return t.tree.Len()
+}
+
+// Contains returns true if *key* is in the tree
+func (t *LoggedTree) Contains(key int) bool {
+	return t.tree.Contains(key)
+}
+
+// Keys returns the keys in the tree as a sorted slice
+func (t *LoggedTree) Keys() []int {
+	return t.tree.Keys()
+}
+
+// Iter returns an Iterator over the keys in the tree, in ascending order
+func (t *LoggedTree) Iter() *rbtree.Iterator {
+	return t.tree.Iter()
+}
+
+// RecoverTree rebuilds a RedBlackTree from a log written by a LoggedTree
+func RecoverTree(r io.Reader) (*rbtree.RedBlackTree, error) {
+	tree := rbtree.New()
+	_, err := Replay(r, func(payload []byte) error {
+		var op TreeOp
+		if err := op.UnmarshalBinary(payload); err != nil {
+			return err
+		}
+		switch op.Kind {
+		case Insert:
+			tree.Insert(op.Key)
+		case Put:
+			tree.Put(op.Key, op.Value)
+		case Delete:
+			tree.Delete(op.Key)
+		}
+		return nil
+	})
+	return tree, err
+}
@@ -0,0 +1,128 @@
+/*
+ * Package wal implements a write-ahead log for recovering the state of
+ * in-memory containers after a crash.
+ *
+ * Before a container is mutated, a record describing the mutation is appended
+ * to the log. If the process dies, replaying the log into an empty container
+ * repeats every mutation in order and rebuilds the state it had. Appending to
+ * the end of a file is the cheapest kind of durable write, which is why
+ * databases use the same technique (see also package lsm).
+ *
+ * Each record is framed as
+ *
+ *    length  uint32, little-endian
+ *    crc     uint32, little-endian, CRC-32C of the payload
+ *    payload [length]byte
+ *
+ * A crash can happen part way through an append, leaving a partial frame at
+ * the end of the log. Replay treats a frame cut short by the end of the log
+ * as never having been written, since the mutation it describes was never
+ * applied either. A complete frame whose checksum does not match cannot be
+ * explained by a crash, and Replay reports it as ErrCorrupt.
+ *
+ * Records are at most MaxRecordSize bytes long. Replay reports a header
+ * giving a greater length as ErrCorrupt without reading further, so that a
+ * damaged length cannot make it allocate gigabytes before the checksum is
+ * checked.
+ *
+ * Records are written for any value implementing Op, and replayed by a
+ * function that decodes and applies them. containers.go provides Ops and
+ * logged wrappers for package hashtable and package rbtree.
+ */
+
+package wal
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+var (
+	ErrCorrupt  = errors.New("corrupt log record")
+	ErrTooLarge = errors.New("log record too large")
+)
+
+// MaxRecordSize is the greatest length of a record's payload
+const MaxRecordSize = 16 << 20
+
+const headerSize = 8
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// Op is a mutation of a container that can be recorded in a log
+type Op interface {
+	MarshalBinary() ([]byte, error)
+}
+
+// Log appends records to an underlying writer, usually a file opened for
+// appending
+type Log struct {
+	w io.Writer
+}
+
+// New creates a Log writing to *w*
+func New(w io.Writer) *Log {
+	return &Log{w}
+}
+
+// Append writes an Op to the log. If the underlying writer has a Sync method,
+// as *os.File does, it is called so that the record is durable before Append
+// returns. It returns an error wrapping ErrTooLarge if the Op encodes to more
+// than MaxRecordSize bytes.
+func (l *Log) Append(op Op) error {
+	payload, err := op.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	if len(payload) > MaxRecordSize {
+		return fmt.Errorf("%w: %d bytes", ErrTooLarge, len(payload))
+	}
+	// The frame is written with a single call, so that a crash leaves at
+	// most one partial frame
+	frame := make([]byte, headerSize+len(payload))
+	binary.LittleEndian.PutUint32(frame, uint32(len(payload)))
+	binary.LittleEndian.PutUint32(frame[4:], crc32.Checksum(payload, castagnoli))
+	copy(frame[headerSize:], payload)
+	if _, err := l.w.Write(frame); err != nil {
+		return err
+	}
+	if s, ok := l.w.(interface{ Sync() error }); ok {
+		return s.Sync()
+	}
+	return nil
+}
+
+// Replay reads every complete record from a log and calls *apply* with its
+// payload, stopping at the first error. It returns the number of records
+// applied.
+func Replay(r io.Reader, apply func(payload []byte) error) (int, error) {
+	header := make([]byte, headerSize)
+	n := 0
+	for {
+		if _, err := io.ReadFull(r, header); err == io.EOF || err == io.ErrUnexpectedEOF {
+			return n, nil
+		} else if err != nil {
+			return n, err
+		}
+		length := binary.LittleEndian.Uint32(header)
+		if length > MaxRecordSize {
+			return n, ErrCorrupt
+		}
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(r, payload); err == io.EOF || err == io.ErrUnexpectedEOF {
+			return n, nil
+		} else if err != nil {
+			return n, err
+		}
+		if crc32.Checksum(payload, castagnoli) != binary.LittleEndian.Uint32(header[4:]) {
+			return n, ErrCorrupt
+		}
+		if err := apply(payload); err != nil {
+			return n, err
+		}
+		n++
+	}
+}
@@ -0,0 +1,216 @@
+package wal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/njwilson23/datastructures/hashtable"
+	"github.com/njwilson23/datastructures/rbtree"
+)
+
+type rawOp []byte
+
+func (op rawOp) MarshalBinary() ([]byte, error) {
+	return op, nil
+}
+
+func replayAll(t *testing.T, data []byte) ([]string, error) {
+	var records []string
+	_, err := Replay(bytes.NewReader(data), func(payload []byte) error {
+		records = append(records, string(payload))
+		return nil
+	})
+	return records, err
+}
+
+func TestReplay(t *testing.T) {
+	var buf bytes.Buffer
+	log := New(&buf)
+	for _, s := range []string{"one", "", "three"} {
+		if err := log.Append(rawOp(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	records, err := replayAll(t, buf.Bytes())
+	if err != nil || fmt.Sprint(records) != "[one  three]" {
+		t.Error(records, err)
+	}
+}
+
+func TestReplayTornWrite(t *testing.T) {
+	var buf bytes.Buffer
+	log := New(&buf)
+	log.Append(rawOp("first"))
+	log.Append(rawOp("second"))
+	data := buf.Bytes()
+
+	// Cutting the log anywhere within the second frame loses only that record
+	for end := len(data) - 1; end > len(data)-headerSize-len("second"); end-- {
+		records, err := replayAll(t, data[:end])
+		if err != nil || len(records) != 1 || records[0] != "first" {
+			t.Fatalf("cut at %d: %v %v", end, records, err)
+		}
+	}
+}
+
+func TestReplayCorrupt(t *testing.T) {
+	var buf bytes.Buffer
+	log := New(&buf)
+	log.Append(rawOp("first"))
+	log.Append(rawOp("second"))
+	data := buf.Bytes()
+	data[headerSize+1] ^= 0xff
+
+	records, err := replayAll(t, data)
+	if err != ErrCorrupt || len(records) != 0 {
+		t.Error(records, err)
+	}
+}
+
+func TestReplayHugeLength(t *testing.T) {
+	var buf bytes.Buffer
+	New(&buf).Append(rawOp("first"))
+	// A header claiming a 4 GiB payload must not be believed
+	buf.Write([]byte{0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0})
+	buf.WriteString("short")
+
+	records, err := replayAll(t, buf.Bytes())
+	if err != ErrCorrupt || len(records) != 1 {
+		t.Error(records, err)
+	}
+	if _, err := RecoverTree(bytes.NewReader(buf.Bytes()[headerSize+5:])); err != ErrCorrupt {
+		t.Error(err)
+	}
+}
+
+func TestAppendTooLarge(t *testing.T) {
+	var buf bytes.Buffer
+	err := New(&buf).Append(rawOp(make([]byte, MaxRecordSize+1)))
+	if !errors.Is(err, ErrTooLarge) || buf.Len() != 0 {
+		t.Error(err, buf.Len())
+	}
+}
+
+func TestReplayApplyError(t *testing.T) {
+	var buf bytes.Buffer
+	log := New(&buf)
+	log.Append(rawOp("a"))
+	log.Append(rawOp("b"))
+	stop := errors.New("stop")
+	n, err := Replay(&buf, func(payload []byte) error {
+		if string(payload) == "b" {
+			return stop
+		}
+		return nil
+	})
+	if n != 1 || err != stop {
+		t.Error(n, err)
+	}
+}
+
+func TestRecoverHashTable(t *testing.T) {
+	var buf bytes.Buffer
+	ht := NewLoggedHashTable(hashtable.InitHashTable(8), New(&buf))
+	ht.Insert("apple", "red")
+	ht.Insert("banana", "yellow")
+	ht.Insert("cherry", "red")
+	ht.Delete("banana")
+	if err := ht.Delete("durian"); err != hashtable.KEY_ERROR {
+		t.Error(err)
+	}
+
+	recovered, err := RecoverHashTable(&buf, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := recovered.ToMap()
+	if len(m) != 2 || m[hashtable.HashString("apple")] != "red" || m[hashtable.HashString("cherry")] != "red" {
+		t.Error(m)
+	}
+	if value, err := ht.Get("cherry"); err != nil || value != "red" || ht.Freeze().Len() != 2 {
+		t.Error(value, err)
+	}
+}
+
+func TestRecoverTree(t *testing.T) {
+	var buf bytes.Buffer
+	tree := NewLoggedTree(rbtree.New(), New(&buf))
+	for _, key := range []int{5, -3, 8, 1} {
+		tree.Insert(key)
+	}
+
+	recovered, err := RecoverTree(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(recovered.Keys()) != fmt.Sprint(tree.Keys()) {
+		t.Error(recovered.Keys())
+	}
+}
+
+func TestRecoverTreeAfterDelete(t *testing.T) {
+	var buf bytes.Buffer
+	tree := NewLoggedTree(rbtree.New(), New(&buf))
+	tree.Insert(1)
+	tree.Insert(2)
+	tree.Put(3, "x")
+	if ok, err := tree.Delete(1); !ok || err != nil {
+		t.Fatal(ok, err)
+	}
+	if ok, _ := tree.Delete(4); ok {
+		t.Error("deleted a missing key")
+	}
+	tree.Put(3, "y")
+
+	recovered, err := RecoverTree(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(recovered.Keys()) != "[2 3]" {
+		t.Error(recovered.Keys())
+	}
+	if value, ok := recovered.Get(3); !ok || value != "y" {
+		t.Error(value, ok)
+	}
+	if value, ok := tree.Get(3); !ok || value != "y" {
+		t.Error(value, ok)
+	}
+}
+
+func TestTreeOpEncoding(t *testing.T) {
+	op := TreeOp{Kind: Put, Key: -300, Value: "value"}
+	data, _ := op.MarshalBinary()
+	var decoded TreeOp
+	if err := decoded.UnmarshalBinary(data); err != nil || decoded != op {
+		t.Error(decoded, err)
+	}
+	if err := decoded.UnmarshalBinary(data[:len(data)-1]); err != ErrCorrupt {
+		t.Error(err)
+	}
+	data[0] = byte(Insert) + 1
+	if err := decoded.UnmarshalBinary(data); err != ErrCorrupt {
+		t.Error("kind:", err)
+	}
+}
+
+func TestKeyValueOpEncoding(t *testing.T) {
+	op := KeyValueOp{Kind: Delete, Key: "k", Value: "a longer value"}
+	data, _ := op.MarshalBinary()
+	var decoded KeyValueOp
+	if err := decoded.UnmarshalBinary(data); err != nil || decoded != op {
+		t.Error(decoded, err)
+	}
+	if err := decoded.UnmarshalBinary(data[:len(data)-1]); err != ErrCorrupt {
+		t.Error(err)
+	}
+	if err := decoded.UnmarshalBinary(append(data, 0)); err != ErrCorrupt {
+		t.Error("trailing byte:", err)
+	}
+	// A tree's Insert is not a mutation of a hash table
+	data[0] = byte(Insert)
+	if err := decoded.UnmarshalBinary(data); err != ErrCorrupt {
+		t.Error("kind:", err)
+	}
+}
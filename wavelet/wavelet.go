@@ -0,0 +1,193 @@
+/*
+ * Package wavelet implements a wavelet tree over a sequence of integers.
+ *
+ * A wavelet tree answers questions about a sequence such as "how many times
+ * does 7 occur among the first i elements?" (rank), "where is the k-th 7?"
+ * (select), and "what is the k-th smallest element between positions l and
+ * r?" (quantile), each in O(log σ) time, where σ is the size of the alphabet,
+ * and in about n log σ bits of space -- the same as the sequence itself.
+ *
+ * The root splits the alphabet in half, and stores one bit per element of the
+ * sequence: 0 if the element is in the lower half, and 1 if it is in the
+ * upper half. The left child is built in the same way from the subsequence of
+ * elements in the lower half, and the right child from the rest, until each
+ * leaf holds a single symbol:
+ *
+ *                 3 1 4 1 5 2 6            symbols 1-6, split 1-3 | 4-6
+ *                 0 0 1 0 1 0 1
+ *              /                 \
+ *        3 1 1 2                4 5 6      1-2 | 3      4-5 | 6
+ *        1 0 0 0                0 0 1
+ *        /    \                 /    \
+ *     1 1 2    3             4 5      6
+ *     0 0 1                  0 1
+ *
+ * The position of an element in a child is the number of elements before it
+ * that went the same way, which is the rank of its bit at the parent. So
+ * every operation walks down (or up) the tree, translating positions with
+ * Rank and Select on the bit vector of each node (see package bitvector).
+ */
+
+package wavelet
+
+import "github.com/njwilson23/datastructures/bitvector"
+
+type node struct {
+	lo, hi      int // the node holds symbols in [lo, hi]
+	size        int // the length of the node's subsequence
+	bits        *bitvector.BitVector
+	left, right *node
+}
+
+// Tree is a wavelet tree over a fixed sequence of integers
+type Tree struct {
+	root *node
+	n    int
+}
+
+// New builds a wavelet tree over a sequence
+func New(seq []int) *Tree {
+	if len(seq) == 0 {
+		return &Tree{}
+	}
+	lo, hi := seq[0], seq[0]
+	for _, x := range seq {
+		if x < lo {
+			lo = x
+		}
+		if x > hi {
+			hi = x
+		}
+	}
+	return &Tree{build(seq, lo, hi), len(seq)}
+}
+
+// mid returns the largest symbol sent to the left child. Since hi-lo is never
+// negative, this rounds down even for negative symbols.
+func mid(lo, hi int) int {
+	return lo + (hi-lo)/2
+}
+
+func build(seq []int, lo, hi int) *node {
+	if len(seq) == 0 {
+		return nil
+	}
+	n := &node{lo: lo, hi: hi, size: len(seq)}
+	if lo == hi {
+		return n
+	}
+	m := mid(lo, hi)
+	n.bits = bitvector.New(len(seq))
+	var left, right []int
+	for i, x := range seq {
+		if x <= m {
+			left = append(left, x)
+		} else {
+			n.bits.Set(i)
+			right = append(right, x)
+		}
+	}
+	n.left = build(left, lo, m)
+	n.right = build(right, m+1, hi)
+	return n
+}
+
+// Len returns the length of the sequence
+func (t *Tree) Len() int {
+	return t.n
+}
+
+// Access returns the element at position *i*
+func (t *Tree) Access(i int) int {
+	n := t.root
+	for n.lo != n.hi {
+		if n.bits.Get(i) {
+			i = n.bits.Rank(i)
+			n = n.right
+		} else {
+			i = n.bits.Rank0(i)
+			n = n.left
+		}
+	}
+	return n.lo
+}
+
+// Rank returns the number of occurrences of *symbol* in positions [0, i)
+func (t *Tree) Rank(symbol, i int) int {
+	if i > t.n {
+		i = t.n
+	}
+	n := t.root
+	for n != nil && symbol >= n.lo && symbol <= n.hi {
+		if n.lo == n.hi {
+			return i
+		}
+		if symbol <= mid(n.lo, n.hi) {
+			i = n.bits.Rank0(i)
+			n = n.left
+		} else {
+			i = n.bits.Rank(i)
+			n = n.right
+		}
+	}
+	return 0
+}
+
+// Select returns the position of the occurrence of *symbol* with rank *k*
+// (counting from zero), or false if there are not that many occurrences
+func (t *Tree) Select(symbol, k int) (int, bool) {
+	if k < 0 {
+		return 0, false
+	}
+	return selectIn(t.root, symbol, k)
+}
+
+// selectIn descends to the leaf for *symbol*, then translates the position
+// back up through each ancestor
+func selectIn(n *node, symbol, k int) (int, bool) {
+	if n == nil || symbol < n.lo || symbol > n.hi {
+		return 0, false
+	}
+	if n.lo == n.hi {
+		// Every element of a leaf is the symbol
+		if k >= n.size {
+			return 0, false
+		}
+		return k, true
+	}
+	if symbol <= mid(n.lo, n.hi) {
+		pos, ok := selectIn(n.left, symbol, k)
+		if !ok {
+			return 0, false
+		}
+		return n.bits.Select0(pos)
+	}
+	pos, ok := selectIn(n.right, symbol, k)
+	if !ok {
+		return 0, false
+	}
+	return n.bits.Select(pos)
+}
+
+// Quantile returns the element of rank *k* (counting from zero) among
+// positions [l, r) when they are sorted, so that k = 0 gives the minimum and
+// k = (r-l)/2 the median. It returns false if k is out of range.
+func (t *Tree) Quantile(l, r, k int) (int, bool) {
+	if l < 0 || r > t.n || k < 0 || k >= r-l {
+		return 0, false
+	}
+	n := t.root
+	for n.lo != n.hi {
+		// The elements of [l, r) sent left come first in sorted order
+		l0, r0 := n.bits.Rank0(l), n.bits.Rank0(r)
+		if k < r0-l0 {
+			l, r = l0, r0
+			n = n.left
+		} else {
+			k -= r0 - l0
+			l, r = l-l0, r-r0
+			n = n.right
+		}
+	}
+	return n.lo, true
+}
@@ -0,0 +1,91 @@
+package wavelet
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestExample(t *testing.T) {
+	seq := []int{3, 1, 4, 1, 5, 2, 6}
+	tree := New(seq)
+	for i, x := range seq {
+		if tree.Access(i) != x {
+			t.Errorf("Access(%d) = %d", i, tree.Access(i))
+		}
+	}
+	if tree.Rank(1, 4) != 2 || tree.Rank(1, 3) != 1 || tree.Rank(7, 7) != 0 || tree.Rank(0, 7) != 0 {
+		t.Fail()
+	}
+	if pos, ok := tree.Select(1, 1); !ok || pos != 3 {
+		t.Error(pos)
+	}
+	if _, ok := tree.Select(1, 2); ok {
+		t.Fail()
+	}
+	// Positions 1-5 hold 1 4 1 5 2, whose median is 2
+	if x, ok := tree.Quantile(1, 6, 2); !ok || x != 2 {
+		t.Error(x)
+	}
+	if _, ok := tree.Quantile(1, 6, 5); ok {
+		t.Fail()
+	}
+}
+
+func TestRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	seq := make([]int, 2000)
+	for i := range seq {
+		seq[i] = r.Intn(100) - 30
+	}
+	tree := New(seq)
+	if tree.Len() != len(seq) {
+		t.Fatal(tree.Len())
+	}
+
+	counts := make(map[int]int)
+	for i, x := range seq {
+		if tree.Access(i) != x {
+			t.Fatalf("Access(%d)", i)
+		}
+		if tree.Rank(x, i) != counts[x] {
+			t.Fatalf("Rank(%d, %d) = %d, expected %d", x, i, tree.Rank(x, i), counts[x])
+		}
+		if pos, ok := tree.Select(x, counts[x]); !ok || pos != i {
+			t.Fatalf("Select(%d, %d) = %d, expected %d", x, counts[x], pos, i)
+		}
+		counts[x]++
+	}
+
+	for trial := 0; trial != 200; trial++ {
+		l := r.Intn(len(seq))
+		rr := l + 1 + r.Intn(len(seq)-l)
+		sorted := append([]int{}, seq[l:rr]...)
+		sort.Ints(sorted)
+		k := r.Intn(len(sorted))
+		if x, ok := tree.Quantile(l, rr, k); !ok || x != sorted[k] {
+			t.Fatalf("Quantile(%d, %d, %d) = %d, expected %d", l, rr, k, x, sorted[k])
+		}
+	}
+}
+
+func TestEmptyAndConstant(t *testing.T) {
+	empty := New(nil)
+	if empty.Len() != 0 || empty.Rank(1, 0) != 0 {
+		t.Fail()
+	}
+	if _, ok := empty.Select(1, 0); ok {
+		t.Fail()
+	}
+	if _, ok := empty.Quantile(0, 0, 0); ok {
+		t.Fail()
+	}
+
+	constant := New([]int{4, 4, 4})
+	if constant.Access(1) != 4 || constant.Rank(4, 2) != 2 {
+		t.Fail()
+	}
+	if pos, ok := constant.Select(4, 2); !ok || pos != 2 {
+		t.Error(pos)
+	}
+}